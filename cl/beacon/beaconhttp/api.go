@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/ledgerwatch/erigon-lib/types/ssz"
+	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice/fork_graph"
 	"github.com/ledgerwatch/log/v3"
 	"golang.org/x/exp/slices"
@@ -53,6 +54,12 @@ func (e *EndpointError) WriteTo(w http.ResponseWriter) {
 	}
 }
 
+// ConsensusVersioned is implemented by responses which are bound to a fork, the version is
+// reported back to the client through the Eth-Consensus-Version header.
+type ConsensusVersioned interface {
+	ConsensusVersion() (clparams.StateVersion, bool)
+}
+
 type EndpointHandler[T any] interface {
 	Handle(w http.ResponseWriter, r *http.Request) (T, error)
 }
@@ -83,6 +90,11 @@ func HandleEndpoint[T any](h EndpointHandler[T]) http.HandlerFunc {
 			endpointError.WriteTo(w)
 			return
 		}
+		if versioned, ok := any(ans).(ConsensusVersioned); ok && !isNil(ans) {
+			if version, ok := versioned.ConsensusVersion(); ok {
+				w.Header().Set("Eth-Consensus-Version", clparams.ClVersionToString(version))
+			}
+		}
 		// TODO: potentially add a context option to buffer these
 		contentType := r.Header.Get("Accept")
 		contentTypes := strings.Split(contentType, ",")
@@ -99,6 +111,7 @@ func HandleEndpoint[T any](h EndpointHandler[T]) http.HandlerFunc {
 				WrapEndpointError(err).WriteTo(w)
 				return
 			}
+			w.Header().Set("Content-Type", "application/octet-stream")
 			w.Write(encoded)
 		case contentType == "*/*", contentType == "", slices.Contains(contentTypes, "text/html"), slices.Contains(contentTypes, "application/json"):
 			if !isNil(ans) {
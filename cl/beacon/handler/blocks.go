@@ -80,6 +80,16 @@ func (a *ApiHandler) getBlock(w http.ResponseWriter, r *http.Request) (*beaconRe
 	if err != nil {
 		return nil, err
 	}
+	if blk == nil && a.checkpointStore != nil {
+		// Finalized checkpoints are kept around to serve checkpoint sync requests.
+		blk, err = a.checkpointStore.ReadBlock(root)
+		if err != nil {
+			return nil, err
+		}
+		if blk != nil {
+			return newBeaconResponse(blk).withFinalized(true).withVersion(blk.Version()), nil
+		}
+	}
 	if blk == nil {
 		return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("block not found %x", root))
 	}
@@ -68,19 +68,14 @@ func (a *ApiHandler) getCommittees(w http.ResponseWriter, r *http.Request) (*bea
 	}
 	resp := make([]*committeeResponse, 0, a.beaconChainCfg.SlotsPerEpoch*a.beaconChainCfg.MaxCommitteesPerSlot)
 	isFinalized := slot <= a.forkchoiceStore.FinalizedSlot()
-	if a.forkchoiceStore.LowestAvaiableSlot() <= slot {
-		// non-finality case
-		s, cn := a.syncedData.HeadState()
-		defer cn()
-		if s == nil {
-			return nil, beaconhttp.NewEndpointError(http.StatusServiceUnavailable, "node is syncing")
-		}
+	// committeesFromState computes the committees of the requested epoch from a full beacon state.
+	committeesFromState := func(s *state.CachingBeaconState) (*beaconResponse, error) {
 		if epoch > state.Epoch(s)+1 {
 			return nil, beaconhttp.NewEndpointError(http.StatusBadRequest, fmt.Sprintf("epoch %d is too far in the future", epoch))
 		}
 		// get active validator indicies
 		committeeCount := s.CommitteeCount(epoch)
-		// now start obtaining the committees from the head state
+		// now start obtaining the committees from the state
 		for currSlot := epoch * a.beaconChainCfg.SlotsPerEpoch; currSlot < (epoch+1)*a.beaconChainCfg.SlotsPerEpoch; currSlot++ {
 			if slotFilter != nil && currSlot != *slotFilter {
 				continue
@@ -102,11 +97,31 @@ func (a *ApiHandler) getCommittees(w http.ResponseWriter, r *http.Request) (*bea
 		}
 		return newBeaconResponse(resp).withFinalized(isFinalized), nil
 	}
+	if a.forkchoiceStore.LowestAvaiableSlot() <= slot {
+		// non-finality case
+		s, cn := a.syncedData.HeadState()
+		defer cn()
+		if s == nil {
+			return nil, beaconhttp.NewEndpointError(http.StatusServiceUnavailable, "node is syncing")
+		}
+		return committeesFromState(s)
+	}
 	// finality case
 	activeIdxs, err := state_accessors.ReadActiveIndicies(tx, epoch*a.beaconChainCfg.SlotsPerEpoch)
 	if err != nil {
 		return nil, err
 	}
+	if len(activeIdxs) == 0 {
+		// The active indicies are missing for the epochs the antiquary did not process.
+		s, err := a.historicalStateAtSlot(ctx, tx, slot)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read active indicies for epoch %d", epoch))
+		}
+		return committeesFromState(s)
+	}
 
 	committeesPerSlot := uint64(len(activeIdxs)) / a.beaconChainCfg.SlotsPerEpoch / a.beaconChainCfg.TargetCommitteeSize
 	if a.beaconChainCfg.MaxCommitteesPerSlot < committeesPerSlot {
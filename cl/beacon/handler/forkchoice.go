@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+)
+
+type forkChoiceResponse struct {
+	JustifiedCheckpoint solid.Checkpoint      `json:"justified_checkpoint"`
+	FinalizedCheckpoint solid.Checkpoint      `json:"finalized_checkpoint"`
+	ForkChoiceNodes     []forkchoice.ForkNode `json:"fork_choice_nodes"`
+	ExtraData           map[string]any        `json:"extra_data"`
+}
+
+func (a *ApiHandler) getForkChoice(w http.ResponseWriter, r *http.Request) (*forkChoiceResponse, error) {
+	return &forkChoiceResponse{
+		JustifiedCheckpoint: a.forkchoiceStore.JustifiedCheckpoint(),
+		FinalizedCheckpoint: a.forkchoiceStore.FinalizedCheckpoint(),
+		ForkChoiceNodes:     a.forkchoiceStore.ForkNodes(),
+		ExtraData: map[string]any{
+			"proposer_boost_root": a.forkchoiceStore.ProposerBoostRoot(),
+		},
+	}, nil
+}
+
+// getForkChoiceDOT exports the fork choice block tree in graphviz DOT format, to debug reorgs.
+func (a *ApiHandler) getForkChoiceDOT(w http.ResponseWriter, r *http.Request) {
+	head, _, err := a.forkchoiceStore.GetHead()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/vnd.graphviz")
+	if err := forkchoice.WriteForkNodesDOT(w, a.forkchoiceStore.ForkNodes(), head,
+		a.forkchoiceStore.JustifiedCheckpoint().BlockRoot(), a.forkchoiceStore.FinalizedCheckpoint().BlockRoot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetForkChoice(t *testing.T) {
+	_, _, _, _, _, handler, _, _, fcu := setupTestingHandler(t, clparams.Phase0Version)
+
+	finalizedRoot, headRoot, forkRoot := libcommon.Hash{1}, libcommon.Hash{2}, libcommon.Hash{3}
+	fcu.HeadVal = headRoot
+	fcu.FinalizedCheckpointVal = solid.NewCheckpointFromParameters(finalizedRoot, 1)
+	fcu.JustifiedCheckpointVal = solid.NewCheckpointFromParameters(finalizedRoot, 1)
+	fcu.ForkNodesVal = []forkchoice.ForkNode{
+		{Slot: 32, BlockRoot: finalizedRoot, Validity: "valid", Weight: 10},
+		{Slot: 33, BlockRoot: headRoot, ParentRoot: finalizedRoot, Validity: "valid", Weight: 7},
+		{Slot: 33, BlockRoot: forkRoot, ParentRoot: finalizedRoot, Validity: "valid", Weight: 3},
+	}
+
+	server := httptest.NewServer(handler.mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/eth/v1/debug/fork_choice")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	out := struct {
+		FinalizedCheckpoint map[string]string     `json:"finalized_checkpoint"`
+		ForkChoiceNodes     []forkchoice.ForkNode `json:"fork_choice_nodes"`
+	}{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	require.Equal(t, fcu.ForkNodesVal, out.ForkChoiceNodes)
+	require.Equal(t, finalizedRoot.Hex(), out.FinalizedCheckpoint["root"])
+	require.Equal(t, "1", out.FinalizedCheckpoint["epoch"])
+
+	resp, err = http.Get(server.URL + "/eth/v1/debug/fork_choice/dot")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/vnd.graphviz", resp.Header.Get("Content-Type"))
+	dot, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(dot), "digraph forkchoice {"))
+	require.Contains(t, string(dot), "\""+strings.TrimPrefix(finalizedRoot.Hex(), "0x")+"\" -> \""+strings.TrimPrefix(forkRoot.Hex(), "0x")+"\"")
+}
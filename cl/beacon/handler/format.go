@@ -45,6 +45,13 @@ func (b *beaconResponse) EncodingSizeSSZ() int {
 	return marshaler.EncodingSizeSSZ()
 }
 
+func (b *beaconResponse) ConsensusVersion() (clparams.StateVersion, bool) {
+	if b.Version == nil {
+		return 0, false
+	}
+	return *b.Version, true
+}
+
 func newBeaconResponse(data any) *beaconResponse {
 	return &beaconResponse{
 		Data: data,
@@ -12,7 +12,10 @@ import (
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/persistence"
+	"github.com/ledgerwatch/erigon/cl/persistence/checkpoint_store"
 	"github.com/ledgerwatch/erigon/cl/persistence/state/historical_states_reader"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state/lru"
 	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
 	"github.com/ledgerwatch/erigon/cl/pool"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
@@ -31,15 +34,22 @@ type ApiHandler struct {
 	syncedData      *synced_data.SyncedDataManager
 	stateReader     *historical_states_reader.HistoricalStatesReader
 	sentinel        sentinel.SentinelClient
+	checkpointStore *checkpoint_store.CheckpointStore
 
 	// pools
 	randaoMixesPool sync.Pool
+	// caches
+	historicalStatesCache *lru.Cache[uint64, *state.CachingBeaconState]
 }
 
-func NewApiHandler(genesisConfig *clparams.GenesisConfig, beaconChainConfig *clparams.BeaconChainConfig, source persistence.RawBeaconBlockChain, indiciesDB kv.RoDB, forkchoiceStore forkchoice.ForkChoiceStorage, operationsPool pool.OperationsPool, rcsn freezeblocks.BeaconSnapshotReader, syncedData *synced_data.SyncedDataManager, stateReader *historical_states_reader.HistoricalStatesReader, sentinel sentinel.SentinelClient) *ApiHandler {
+func NewApiHandler(genesisConfig *clparams.GenesisConfig, beaconChainConfig *clparams.BeaconChainConfig, source persistence.RawBeaconBlockChain, indiciesDB kv.RoDB, forkchoiceStore forkchoice.ForkChoiceStorage, operationsPool pool.OperationsPool, rcsn freezeblocks.BeaconSnapshotReader, syncedData *synced_data.SyncedDataManager, stateReader *historical_states_reader.HistoricalStatesReader, sentinel sentinel.SentinelClient, checkpointStore *checkpoint_store.CheckpointStore) (*ApiHandler, error) {
+	historicalStatesCache, err := lru.New[uint64, *state.CachingBeaconState]("beaconapi_historical_states", historicalStatesCacheSize)
+	if err != nil {
+		return nil, err
+	}
 	return &ApiHandler{o: sync.Once{}, genesisCfg: genesisConfig, beaconChainCfg: beaconChainConfig, indiciesDB: indiciesDB, forkchoiceStore: forkchoiceStore, operationsPool: operationsPool, blockReader: rcsn, syncedData: syncedData, stateReader: stateReader, randaoMixesPool: sync.Pool{New: func() interface{} {
 		return solid.NewHashVector(int(beaconChainConfig.EpochsPerHistoricalVector))
-	}}, sentinel: sentinel}
+	}}, sentinel: sentinel, checkpointStore: checkpointStore, historicalStatesCache: historicalStatesCache}, nil
 }
 
 func (a *ApiHandler) init() {
@@ -51,6 +61,10 @@ func (a *ApiHandler) init() {
 		r.Route("/v1", func(r chi.Router) {
 
 			r.Get("/events", http.NotFound)
+			r.Route("/debug", func(r chi.Router) {
+				r.Get("/fork_choice", beaconhttp.HandleEndpointFunc(a.getForkChoice))
+				r.Get("/fork_choice/dot", a.getForkChoiceDOT)
+			})
 			r.Route("/config", func(r chi.Router) {
 				r.Get("/spec", beaconhttp.HandleEndpointFunc(a.getSpec))
 				r.Get("/deposit_contract", beaconhttp.HandleEndpointFunc(a.getDepositContract))
@@ -14,6 +14,8 @@ import (
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/persistence/beacon_indicies"
 	state_accessors "github.com/ledgerwatch/erigon/cl/persistence/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/transition"
 	"github.com/ledgerwatch/erigon/cl/utils"
 )
 
@@ -60,6 +62,132 @@ func (a *ApiHandler) blockRootFromStateId(ctx context.Context, tx kv.Tx, stateId
 	}
 }
 
+// historicalStatesCacheSize is the number of states reconstructed from the antiquary which are kept in memory.
+// Beacon states are big, so keep it small.
+const historicalStatesCacheSize = 4
+
+// historicalStateAtSlot reconstructs the canonical beacon state at the given slot from the antiquary. If there is no
+// block at that slot, the state of the closest preceding block is advanced to it. States at finalized slots are
+// cached, and the caller always gets its own copy.
+func (a *ApiHandler) historicalStateAtSlot(ctx context.Context, tx kv.Tx, slot uint64) (*state.CachingBeaconState, error) {
+	if a.stateReader == nil {
+		return nil, nil
+	}
+	if s, ok := a.historicalStatesCache.Get(slot); ok {
+		return s.Copy()
+	}
+	blockSlot := slot
+	for {
+		root, err := beacon_indicies.ReadCanonicalBlockRoot(tx, blockSlot)
+		if err != nil {
+			return nil, err
+		}
+		if root != (libcommon.Hash{}) || blockSlot == 0 {
+			break
+		}
+		// do not walk back forever on an empty or pruned range.
+		if slot-blockSlot >= a.beaconChainCfg.SlotsPerHistoricalRoot {
+			return nil, nil
+		}
+		blockSlot--
+	}
+	s, err := a.stateReader.ReadHistoricalState(ctx, tx, blockSlot)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, nil
+	}
+	if err := s.InitBeaconState(); err != nil {
+		return nil, err
+	}
+	if blockSlot < slot {
+		if err := transition.DefaultMachine.ProcessSlots(s, slot); err != nil {
+			return nil, err
+		}
+	}
+	if slot > a.forkchoiceStore.FinalizedSlot() {
+		// may still be reorged, do not cache it.
+		return s, nil
+	}
+	a.historicalStatesCache.Add(slot, s)
+	return s.Copy()
+}
+
+// stateFromStateId returns the beacon state for the given state id, looking first in the fork choice and then
+// falling back to the antiquary. Slot ids are served even if no block was proposed at that slot.
+func (a *ApiHandler) stateFromStateId(ctx context.Context, tx kv.Tx, stateId *segmentID) (*state.CachingBeaconState, bool, error) {
+	if stateId.getSlot() != nil {
+		slot := *stateId.getSlot()
+		root, err := beacon_indicies.ReadCanonicalBlockRoot(tx, slot)
+		if err != nil {
+			return nil, false, err
+		}
+		if root != (libcommon.Hash{}) {
+			s, err := a.forkchoiceStore.GetStateAtBlockRoot(root, true)
+			if err != nil {
+				return nil, false, err
+			}
+			if s != nil {
+				return s, slot <= a.forkchoiceStore.FinalizedSlot(), nil
+			}
+		}
+		s, err := a.historicalStateAtSlot(ctx, tx, slot)
+		if err != nil {
+			return nil, false, err
+		}
+		if s == nil {
+			return nil, false, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read state at slot %d", slot))
+		}
+		return s, slot <= a.forkchoiceStore.FinalizedSlot(), nil
+	}
+
+	blockRoot, httpStatus, err := a.blockRootFromStateId(ctx, tx, stateId)
+	if err != nil {
+		return nil, false, beaconhttp.NewEndpointError(httpStatus, err.Error())
+	}
+	s, err := a.forkchoiceStore.GetStateAtBlockRoot(blockRoot, true)
+	if err != nil {
+		return nil, false, err
+	}
+	slot, err := beacon_indicies.ReadBlockSlotByBlockRoot(tx, blockRoot)
+	if err != nil {
+		return nil, false, err
+	}
+	if s != nil {
+		return s, slot != nil && *slot <= a.forkchoiceStore.FinalizedSlot(), nil
+	}
+	if a.checkpointStore != nil {
+		// Finalized checkpoints are kept around to serve checkpoint sync requests.
+		s, err = a.checkpointStore.ReadState(blockRoot)
+		if err != nil {
+			return nil, false, err
+		}
+		if s != nil {
+			return s, s.Slot() <= a.forkchoiceStore.FinalizedSlot(), nil
+		}
+	}
+	// Sanity checks slot and canonical data.
+	if slot == nil {
+		return nil, false, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read block slot: %x", blockRoot))
+	}
+	canonicalRoot, err := beacon_indicies.ReadCanonicalBlockRoot(tx, *slot)
+	if err != nil {
+		return nil, false, err
+	}
+	if canonicalRoot != blockRoot {
+		return nil, false, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read state: %x", blockRoot))
+	}
+	s, err = a.historicalStateAtSlot(ctx, tx, *slot)
+	if err != nil {
+		return nil, false, err
+	}
+	if s == nil {
+		return nil, false, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read state: %x", blockRoot))
+	}
+	return s, *slot <= a.forkchoiceStore.FinalizedSlot(), nil
+}
+
 type rootResponse struct {
 	Root libcommon.Hash `json:"root"`
 }
@@ -166,42 +294,11 @@ func (a *ApiHandler) getFullState(w http.ResponseWriter, r *http.Request) (*beac
 		return nil, beaconhttp.NewEndpointError(http.StatusBadRequest, err.Error())
 	}
 
-	blockRoot, httpStatus, err := a.blockRootFromStateId(ctx, tx, blockId)
+	state, finalized, err := a.stateFromStateId(ctx, tx, blockId)
 	if err != nil {
-		return nil, beaconhttp.NewEndpointError(httpStatus, err.Error())
-	}
-
-	state, err := a.forkchoiceStore.GetStateAtBlockRoot(blockRoot, true)
-	if err != nil {
-		return nil, beaconhttp.NewEndpointError(http.StatusBadRequest, err.Error())
-	}
-	if state == nil {
-		slot, err := beacon_indicies.ReadBlockSlotByBlockRoot(tx, blockRoot)
-		if err != nil {
-			return nil, err
-		}
-		// Sanity checks slot and canonical data.
-		if slot == nil {
-			return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read block slot: %x", blockRoot))
-		}
-		canonicalRoot, err := beacon_indicies.ReadCanonicalBlockRoot(tx, *slot)
-		if err != nil {
-			return nil, err
-		}
-		if canonicalRoot != blockRoot {
-			return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read state: %x", blockRoot))
-		}
-		state, err := a.stateReader.ReadHistoricalState(ctx, tx, *slot)
-		if err != nil {
-			return nil, err
-		}
-		if state == nil {
-			return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read state: %x", blockRoot))
-		}
-		return newBeaconResponse(state).withFinalized(true).withVersion(state.Version()), nil
+		return nil, err
 	}
-
-	return newBeaconResponse(state).withFinalized(false).withVersion(state.Version()), nil
+	return newBeaconResponse(state).withFinalized(finalized).withVersion(state.Version()), nil
 }
 
 type finalityCheckpointsResponse struct {
@@ -246,7 +343,15 @@ func (a *ApiHandler) getFinalityCheckpoints(w http.ResponseWriter, r *http.Reque
 			return nil, beaconhttp.NewEndpointError(http.StatusBadRequest, err.Error())
 		}
 		if currentJustifiedCheckpoint == nil {
-			return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read checkpoints: %x, %d", blockRoot, a.beaconChainCfg.RoundSlotToEpoch(*slot)))
+			// No epoch data was dumped for this epoch, take the checkpoints from the state of the slot.
+			s, err := a.historicalStateAtSlot(ctx, tx, *slot)
+			if err != nil {
+				return nil, err
+			}
+			if s == nil {
+				return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read checkpoints: %x, %d", blockRoot, a.beaconChainCfg.RoundSlotToEpoch(*slot)))
+			}
+			currentJustifiedCheckpoint, previousJustifiedCheckpoint, finalizedCheckpoint = s.CurrentJustifiedCheckpoint(), s.PreviousJustifiedCheckpoint(), s.FinalizedCheckpoint()
 		}
 	}
 	version := a.beaconChainCfg.GetCurrentStateVersion(*slot / a.beaconChainCfg.SlotsPerEpoch)
@@ -307,7 +412,15 @@ func (a *ApiHandler) getSyncCommittees(w http.ResponseWriter, r *http.Request) (
 			return nil, err
 		}
 		if currentSyncCommittee == nil || nextSyncCommittee == nil {
-			return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read sync committees: %x, %d", blockRoot, *slot))
+			// The sync committees of this period were not dumped, take them from the state of the slot.
+			s, err := a.historicalStateAtSlot(ctx, tx, *slot)
+			if err != nil {
+				return nil, err
+			}
+			if s == nil || s.Version() < clparams.AltairVersion {
+				return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Sprintf("could not read sync committees: %x, %d", blockRoot, *slot))
+			}
+			currentSyncCommittee, nextSyncCommittee = s.CurrentSyncCommittee(), s.NextSyncCommittee()
 		}
 	}
 	// Now fetch the data we need
@@ -394,6 +507,10 @@ func (a *ApiHandler) getRandao(w http.ResponseWriter, r *http.Request) (*beaconR
 	slot := *slotPtr
 	epoch := slot / a.beaconChainCfg.SlotsPerEpoch
 	if epochReq != nil {
+		// only the mixes of the last EpochsPerHistoricalVector epochs are kept in the state.
+		if *epochReq > epoch || epoch-*epochReq >= a.beaconChainCfg.EpochsPerHistoricalVector {
+			return nil, beaconhttp.NewEndpointError(http.StatusBadRequest, fmt.Sprintf("epoch %d is out of range", *epochReq))
+		}
 		epoch = *epochReq
 	}
 	randaoMixes := a.randaoMixesPool.Get().(solid.HashListSSZ)
@@ -8,10 +8,16 @@ import (
 	"strconv"
 	"testing"
 
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/cl/antiquary/tests"
 	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/persistence/checkpoint_store"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/transition"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 )
 
@@ -267,6 +273,115 @@ func TestGetStateFullForkchoice(t *testing.T) {
 	}
 }
 
+func TestGetStateFullCheckpointStore(t *testing.T) {
+	_, blocks, _, _, postState, handler, _, _, fcu := setupTestingHandler(t, clparams.Phase0Version)
+
+	postRoot, err := postState.HashSSZ()
+	require.NoError(t, err)
+
+	fcu.HeadVal, err = blocks[len(blocks)-1].Block.HashSSZ()
+	require.NoError(t, err)
+
+	fcu.HeadSlotVal = blocks[len(blocks)-1].Block.Slot
+
+	fcu.FinalizedCheckpointVal = solid.NewCheckpointFromParameters(fcu.HeadVal, fcu.HeadSlotVal/32)
+
+	handler.checkpointStore, err = checkpoint_store.NewCheckpointStore(afero.NewMemMapFs(), &clparams.MainnetBeaconConfig, 1)
+	require.NoError(t, err)
+	require.NoError(t, handler.checkpointStore.WriteCheckpoint(blocks[len(blocks)-1], postState))
+	// make sure the historical reader and the block reader are not used.
+	handler.stateReader = nil
+	handler.blockReader = tests.NewMockBlockReader()
+
+	server := httptest.NewServer(handler.mux)
+	defer server.Close()
+	req, err := http.NewRequest("GET", server.URL+"/eth/v2/debug/beacon/states/finalized", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/octet-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "phase0", resp.Header.Get("Eth-Consensus-Version"))
+
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	other := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, other.DecodeSSZ(out, int(clparams.Phase0Version)))
+
+	otherRoot, err := other.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, postRoot, otherRoot)
+
+	// the finalized block is served along with its state.
+	req, err = http.NewRequest("GET", server.URL+"/eth/v2/beacon/blocks/finalized", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/octet-stream")
+	blockResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer blockResp.Body.Close()
+	require.Equal(t, http.StatusOK, blockResp.StatusCode)
+
+	out, err = io.ReadAll(blockResp.Body)
+	require.NoError(t, err)
+	block := cltypes.NewSignedBeaconBlock(&clparams.MainnetBeaconConfig)
+	require.NoError(t, block.DecodeSSZ(out, int(clparams.Phase0Version)))
+	blockRoot, err := block.Block.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, fcu.HeadVal, libcommon.Hash(blockRoot))
+}
+
+func TestGetStateFullHistoricalEmptySlot(t *testing.T) {
+
+	// setupTestingHandler(t, clparams.Phase0Version)
+	_, blocks, _, preState, _, handler, _, _, fcu := setupTestingHandler(t, clparams.Phase0Version)
+
+	var err error
+	fcu.HeadVal, err = blocks[len(blocks)-1].Block.HashSSZ()
+	require.NoError(t, err)
+	fcu.HeadSlotVal = blocks[len(blocks)-1].Block.Slot
+	fcu.FinalizedCheckpointVal = solid.NewCheckpointFromParameters(fcu.HeadVal, fcu.HeadSlotVal/32)
+	// the state is cached only once it is finalized.
+	fcu.FinalizedSlotVal = fcu.HeadSlotVal
+
+	// no block is proposed between the first and the last block.
+	emptySlot := blocks[0].Block.Slot + 12
+	require.Less(t, emptySlot, blocks[1].Block.Slot)
+
+	expected, err := preState.Copy()
+	require.NoError(t, err)
+	require.NoError(t, transition.TransitionState(expected, blocks[0], nil, false))
+	require.NoError(t, transition.DefaultMachine.ProcessSlots(expected, emptySlot))
+	expectedRoot, err := expected.HashSSZ()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(handler.mux)
+	defer server.Close()
+	// Query twice, so that the second time is served from the cache.
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", server.URL+"/eth/v2/debug/beacon/states/"+strconv.FormatUint(emptySlot, 10), nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "application/octet-stream")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		resp.Body.Close()
+		other := state.New(&clparams.MainnetBeaconConfig)
+		require.NoError(t, other.DecodeSSZ(out, int(clparams.Phase0Version)))
+
+		otherRoot, err := other.HashSSZ()
+		require.NoError(t, err)
+		require.Equal(t, expectedRoot, otherRoot)
+	}
+	require.True(t, handler.historicalStatesCache.Contains(emptySlot))
+}
+
 func TestGetStateSyncCommittees(t *testing.T) {
 
 	// setupTestingHandler(t, clparams.Phase0Version)
@@ -52,7 +52,7 @@ func setupTestingHandler(t *testing.T, v clparams.StateVersion) (db kv.RwDB, blo
 	fcu.Pool = opPool
 	syncedData = synced_data.NewSyncedDataManager(true, &bcfg)
 	gC := clparams.GenesisConfigs[clparams.MainnetNetwork]
-	handler = NewApiHandler(
+	handler, err := NewApiHandler(
 		&gC,
 		&bcfg,
 		rawDB,
@@ -62,7 +62,9 @@ func setupTestingHandler(t *testing.T, v clparams.StateVersion) (db kv.RwDB, blo
 		reader,
 		syncedData,
 		statesReader,
+		nil,
 		nil)
+	require.NoError(t, err)
 	handler.init()
 	return
 }
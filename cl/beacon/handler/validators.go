@@ -340,12 +340,12 @@ func (a *ApiHandler) getSingleValidator(w http.ResponseWriter, r *http.Request)
 	if blockId.head() { // Lets see if we point to head, if yes then we need to look at the head state we always keep.
 		s, cn := a.syncedData.HeadState()
 		defer cn()
-		if s.ValidatorLength() <= int(validatorIndex) {
-			return newBeaconResponse([]int{}).withFinalized(false), nil
-		}
 		if s == nil {
 			return nil, beaconhttp.NewEndpointError(http.StatusNotFound, "node is not synced")
 		}
+		if s.ValidatorLength() <= int(validatorIndex) {
+			return newBeaconResponse([]int{}).withFinalized(false), nil
+		}
 		return responseValidator(validatorIndex, state.Epoch(s), s.Balances(), s.Validators(), false)
 	}
 	slot, err := beacon_indicies.ReadBlockSlotByBlockRoot(tx, blockRoot)
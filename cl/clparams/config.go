@@ -33,6 +33,8 @@ import (
 type CaplinConfig struct {
 	Backfilling bool
 	Archive     bool
+	// CheckpointsToKeep is the number of finalized states kept on disk to serve checkpoint sync, 0 disables it.
+	CheckpointsToKeep uint64
 }
 
 type NetworkType int
@@ -8,6 +8,7 @@ const (
 	BellatrixVersion StateVersion = 2
 	CapellaVersion   StateVersion = 3
 	DenebVersion     StateVersion = 4
+	ElectraVersion   StateVersion = 5
 )
 
 // stringToClVersion converts the string to the current state version.
@@ -23,6 +24,8 @@ func StringToClVersion(s string) StateVersion {
 		return CapellaVersion
 	case "deneb":
 		return DenebVersion
+	case "electra":
+		return ElectraVersion
 	default:
 		panic("unsupported fork version: " + s)
 	}
@@ -40,6 +43,8 @@ func ClVersionToString(s StateVersion) string {
 		return "capella"
 	case DenebVersion:
 		return "deneb"
+	case ElectraVersion:
+		return "electra"
 	default:
 		panic("unsupported fork version")
 	}
@@ -13,7 +13,11 @@ const (
 	TopicNameAttesterSlashing        = "attester_slashing"
 	TopicNameBlsToExecutionChange    = "bls_to_execution_change"
 
-	TopicNamePrefixBlobSidecar = "blob_sidecar_"
+	TopicNameSyncCommitteeContributionAndProof = "sync_committee_contribution_and_proof"
+
+	TopicNamePrefixBlobSidecar       = "blob_sidecar_"
+	TopicNamePrefixBeaconAttestation = "beacon_attestation_"
+	TopicNamePrefixSyncCommittee     = "sync_committee_"
 )
 
 func TopicNameBlobSidecar(d int) string {
@@ -23,3 +27,20 @@ func TopicNameBlobSidecar(d int) string {
 func IsTopicBlobSidecar(d string) bool {
 	return strings.Contains(d, TopicNamePrefixBlobSidecar)
 }
+
+func TopicNameBeaconAttestation(d int) string {
+	return TopicNamePrefixBeaconAttestation + strconv.Itoa(d)
+}
+
+func IsTopicBeaconAttestation(d string) bool {
+	return strings.Contains(d, TopicNamePrefixBeaconAttestation)
+}
+
+func TopicNameSyncCommittee(d int) string {
+	return TopicNamePrefixSyncCommittee + strconv.Itoa(d)
+}
+
+// IsTopicSyncCommittee reports whether d is one of the sync committee subnets, the contribution topic shares the prefix.
+func IsTopicSyncCommittee(d string) bool {
+	return strings.Contains(d, TopicNamePrefixSyncCommittee) && !strings.Contains(d, TopicNameSyncCommitteeContributionAndProof)
+}
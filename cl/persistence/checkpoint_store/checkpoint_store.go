@@ -0,0 +1,270 @@
+package checkpoint_store
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/spf13/afero"
+)
+
+const (
+	checkpointFileExtension = ".ssz_snappy"
+	// blockFilePrefix tells the files of the blocks apart from the files of the states.
+	blockFilePrefix = "block_"
+)
+
+// CheckpointStore keeps the blocks and the beacon states of the last finalized checkpoints on disk, so that they
+// can be served to other nodes doing checkpoint sync, even after they have been evicted from the fork choice caches.
+type CheckpointStore struct {
+	fs        afero.Fs
+	beaconCfg *clparams.BeaconChainConfig
+	keep      uint64
+
+	mu          sync.RWMutex
+	checkpoints []checkpointEntry // sorted by slot
+}
+
+type checkpointEntry struct {
+	slot      uint64
+	blockRoot libcommon.Hash
+	stateRoot libcommon.Hash
+	version   clparams.StateVersion
+}
+
+func (c checkpointEntry) filename() string {
+	return fmt.Sprintf("%d_%d_%x_%x%s", c.slot, c.version, c.blockRoot, c.stateRoot, checkpointFileExtension)
+}
+
+func (c checkpointEntry) blockFilename() string {
+	return blockFilePrefix + c.filename()
+}
+
+func parseCheckpointFilename(name string) (entry checkpointEntry, ok bool) {
+	if !strings.HasSuffix(name, checkpointFileExtension) || strings.HasPrefix(name, blockFilePrefix) {
+		return entry, false
+	}
+	var (
+		version              uint8
+		blockRoot, stateRoot []byte
+	)
+	if _, err := fmt.Sscanf(strings.TrimSuffix(name, checkpointFileExtension), "%d_%d_%x_%x", &entry.slot, &version, &blockRoot, &stateRoot); err != nil {
+		return entry, false
+	}
+	if len(blockRoot) != length.Hash || len(stateRoot) != length.Hash {
+		return entry, false
+	}
+	entry.version = clparams.StateVersion(version)
+	copy(entry.blockRoot[:], blockRoot)
+	copy(entry.stateRoot[:], stateRoot)
+	return entry, true
+}
+
+// NewCheckpointStore opens a checkpoint store on top of the given filesystem, keeping at most keep finalized checkpoints.
+func NewCheckpointStore(fs afero.Fs, beaconCfg *clparams.BeaconChainConfig, keep uint64) (*CheckpointStore, error) {
+	c := &CheckpointStore{
+		fs:        fs,
+		beaconCfg: beaconCfg,
+		keep:      keep,
+	}
+	files, err := afero.ReadDir(fs, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		entry, ok := parseCheckpointFilename(file.Name())
+		if !ok {
+			continue
+		}
+		c.checkpoints = append(c.checkpoints, entry)
+	}
+	sort.Slice(c.checkpoints, func(i, j int) bool {
+		return c.checkpoints[i].slot < c.checkpoints[j].slot
+	})
+	if err := c.prune(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Has returns whether the checkpoint for the given block root is stored.
+func (c *CheckpointStore) Has(blockRoot libcommon.Hash) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.find(blockRoot)
+	return ok
+}
+
+// Latest returns the block root of the most recent stored finalized checkpoint.
+func (c *CheckpointStore) Latest() (libcommon.Hash, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.checkpoints) == 0 {
+		return libcommon.Hash{}, false
+	}
+	return c.checkpoints[len(c.checkpoints)-1].blockRoot, true
+}
+
+func (c *CheckpointStore) find(blockRoot libcommon.Hash) (checkpointEntry, bool) {
+	for _, entry := range c.checkpoints {
+		if entry.blockRoot == blockRoot {
+			return entry, true
+		}
+	}
+	return checkpointEntry{}, false
+}
+
+// WriteCheckpoint stores the finalized block along with its post-state, and prunes the oldest stored checkpoints.
+func (c *CheckpointStore) WriteCheckpoint(block *cltypes.SignedBeaconBlock, bs *state.CachingBeaconState) error {
+	if c.keep == 0 {
+		return nil
+	}
+	blockRoot, err := block.Block.HashSSZ()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.find(blockRoot); ok {
+		return nil
+	}
+	stateRoot, err := bs.HashSSZ()
+	if err != nil {
+		return err
+	}
+	if block.Block.StateRoot != stateRoot {
+		return fmt.Errorf("state root %x does not match the one of block %x", stateRoot, blockRoot)
+	}
+	encodedBlock, err := block.EncodeSSZ(nil)
+	if err != nil {
+		return err
+	}
+	encodedState, err := bs.EncodeSSZ(nil)
+	if err != nil {
+		return err
+	}
+	entry := checkpointEntry{
+		slot:      bs.Slot(),
+		blockRoot: blockRoot,
+		stateRoot: stateRoot,
+		version:   bs.Version(),
+	}
+	// The state file is written last, as it is the one listing the checkpoint when the store is opened.
+	if err := c.writeFile(entry.blockFilename(), encodedBlock); err != nil {
+		return err
+	}
+	if err := c.writeFile(entry.filename(), encodedState); err != nil {
+		return err
+	}
+
+	c.checkpoints = append(c.checkpoints, entry)
+	sort.Slice(c.checkpoints, func(i, j int) bool {
+		return c.checkpoints[i].slot < c.checkpoints[j].slot
+	})
+	return c.prune()
+}
+
+// writeFile writes the compressed data on a temporary file first, so that a crash never leaves a truncated
+// file behind.
+func (c *CheckpointStore) writeFile(name string, data []byte) error {
+	tmpName := name + ".tmp"
+	file, err := c.fs.OpenFile(tmpName, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(snappy.Encode(nil, data)); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return c.fs.Rename(tmpName, name)
+}
+
+// prune removes the oldest checkpoints until at most keep are left.
+func (c *CheckpointStore) prune() error {
+	for uint64(len(c.checkpoints)) > c.keep {
+		for _, name := range []string{c.checkpoints[0].filename(), c.checkpoints[0].blockFilename()} {
+			if err := c.fs.Remove(name); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		c.checkpoints = c.checkpoints[1:]
+	}
+	return nil
+}
+
+// ReadStateSSZ returns the raw SSZ encoding of the stored state for blockRoot, along with its fork version.
+// It returns nil if the state is not stored.
+func (c *CheckpointStore) ReadStateSSZ(blockRoot libcommon.Hash) ([]byte, clparams.StateVersion, error) {
+	return c.readFile(blockRoot, checkpointEntry.filename)
+}
+
+// ReadBlock returns the stored block with the given root, or nil if it is not stored.
+func (c *CheckpointStore) ReadBlock(blockRoot libcommon.Hash) (*cltypes.SignedBeaconBlock, error) {
+	encoded, version, err := c.readFile(blockRoot, checkpointEntry.blockFilename)
+	if err != nil || encoded == nil {
+		return nil, err
+	}
+	block := cltypes.NewSignedBeaconBlock(c.beaconCfg)
+	if err := block.DecodeSSZ(encoded, int(version)); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (c *CheckpointStore) readFile(blockRoot libcommon.Hash, filename func(checkpointEntry) string) ([]byte, clparams.StateVersion, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.find(blockRoot)
+	if !ok {
+		return nil, 0, nil
+	}
+	file, err := c.fs.Open(filename(entry))
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	compressed, err := io.ReadAll(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	encoded, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, 0, err
+	}
+	return encoded, entry.version, nil
+}
+
+// ReadState returns the stored state for blockRoot, or nil if it is not stored.
+func (c *CheckpointStore) ReadState(blockRoot libcommon.Hash) (*state.CachingBeaconState, error) {
+	encoded, version, err := c.ReadStateSSZ(blockRoot)
+	if err != nil || encoded == nil {
+		return nil, err
+	}
+	bs := state.New(c.beaconCfg)
+	if err := bs.DecodeSSZ(encoded, int(version)); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
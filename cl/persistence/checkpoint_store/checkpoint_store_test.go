@@ -0,0 +1,79 @@
+package checkpoint_store
+
+import (
+	"os"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/cl/antiquary/tests"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointStoreWriteRead(t *testing.T) {
+	blocks, preState, postState := tests.GetPhase0Random()
+	fs := afero.NewMemMapFs()
+
+	store, err := NewCheckpointStore(fs, &clparams.MainnetBeaconConfig, 1)
+	require.NoError(t, err)
+
+	// The first block stands in for the block of the pre-state, whose actual block is not available.
+	preBlock, postBlock := blocks[0], blocks[1]
+	preBlock.Block.StateRoot, err = preState.HashSSZ()
+	require.NoError(t, err)
+	preRoot, err := preBlock.Block.HashSSZ()
+	require.NoError(t, err)
+	postRoot, err := postBlock.Block.HashSSZ()
+	require.NoError(t, err)
+	require.NoError(t, store.WriteCheckpoint(preBlock, preState))
+	require.True(t, store.Has(preRoot))
+
+	// The state has to be the post-state of the block.
+	require.Error(t, store.WriteCheckpoint(postBlock, preState))
+
+	// Writing a newer checkpoint evicts the older one.
+	require.NoError(t, store.WriteCheckpoint(postBlock, postState))
+	require.False(t, store.Has(preRoot))
+	require.True(t, store.Has(postRoot))
+	latest, ok := store.Latest()
+	require.True(t, ok)
+	require.Equal(t, libcommon.Hash(postRoot), latest)
+
+	missing, err := store.ReadState(preRoot)
+	require.NoError(t, err)
+	require.Nil(t, missing)
+	missingBlock, err := store.ReadBlock(preRoot)
+	require.NoError(t, err)
+	require.Nil(t, missingBlock)
+	files, err := afero.ReadDir(fs, ".")
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, file := range files {
+		require.Equal(t, os.FileMode(0o644), file.Mode().Perm())
+	}
+
+	read, err := store.ReadState(postRoot)
+	require.NoError(t, err)
+	require.NotNil(t, read)
+	expectedRoot, err := postState.HashSSZ()
+	require.NoError(t, err)
+	readRoot, err := read.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, expectedRoot, readRoot)
+
+	readBlock, err := store.ReadBlock(postRoot)
+	require.NoError(t, err)
+	require.NotNil(t, readBlock)
+	readBlockRoot, err := readBlock.Block.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, postRoot, readBlockRoot)
+
+	// Reopening the store picks up the checkpoints already on disk.
+	store, err = NewCheckpointStore(fs, &clparams.MainnetBeaconConfig, 1)
+	require.NoError(t, err)
+	require.True(t, store.Has(postRoot))
+	readBlock, err = store.ReadBlock(postRoot)
+	require.NoError(t, err)
+	require.NotNil(t, readBlock)
+}
@@ -0,0 +1,107 @@
+package forkchoice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+)
+
+// ForkNode is a node of the fork choice block tree, as exposed by the debug api.
+type ForkNode struct {
+	Slot               uint64         `json:"slot,string"`
+	BlockRoot          libcommon.Hash `json:"block_root"`
+	ParentRoot         libcommon.Hash `json:"parent_root"`
+	JustifiedEpoch     uint64         `json:"justified_epoch,string"`
+	FinalizedEpoch     uint64         `json:"finalized_epoch,string"`
+	Weight             uint64         `json:"weight,string"`
+	Validity           string         `json:"validity"`
+	ExecutionBlockHash libcommon.Hash `json:"execution_block_hash"`
+}
+
+// ForkNodes returns the block tree rooted at the finalized checkpoint, along with the attestation weight of each block.
+func (f *ForkChoiceStore) ForkNodes() []ForkNode {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var filteredIndicies []uint64
+	justificationState, err := f.getCheckpointState(f.justifiedCheckpoint)
+	if err == nil {
+		filteredIndicies = f.filterValidatorSetForAttestationScores(justificationState, justificationState.epoch)
+	}
+
+	nodes := []ForkNode{}
+	queue := []libcommon.Hash{f.finalizedCheckpoint.BlockRoot()}
+	for len(queue) > 0 {
+		blockRoot := queue[0]
+		queue = queue[1:]
+		header, has := f.forkGraph.GetHeader(blockRoot)
+		if !has {
+			continue
+		}
+		node := ForkNode{
+			Slot:       header.Slot,
+			BlockRoot:  blockRoot,
+			ParentRoot: header.ParentRoot,
+			Validity:   "valid",
+		}
+		if justified, has := f.forkGraph.GetCurrentJustifiedCheckpoint(blockRoot); has {
+			node.JustifiedEpoch = justified.Epoch()
+		}
+		if finalized, has := f.forkGraph.GetFinalizedCheckpoint(blockRoot); has {
+			node.FinalizedEpoch = finalized.Epoch()
+		}
+		if justificationState != nil {
+			node.Weight = f.getWeight(blockRoot, filteredIndicies, justificationState)
+		}
+		node.ExecutionBlockHash, _ = f.eth2Roots.Get(blockRoot)
+		nodes = append(nodes, node)
+		queue = append(queue, f.children(blockRoot)...)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Slot == nodes[j].Slot {
+			return bytes.Compare(nodes[i].BlockRoot[:], nodes[j].BlockRoot[:]) < 0
+		}
+		return nodes[i].Slot < nodes[j].Slot
+	})
+	return nodes
+}
+
+// WriteForkNodesDOT writes the block tree in graphviz DOT format, head, justified and finalized blocks are highlighted.
+func WriteForkNodesDOT(w io.Writer, nodes []ForkNode, head, justified, finalized libcommon.Hash) error {
+	if _, err := fmt.Fprintln(w, "digraph forkchoice {"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "\trankdir=LR;\n\tnode [shape=box];"); err != nil {
+		return err
+	}
+	known := make(map[libcommon.Hash]struct{}, len(nodes))
+	for _, node := range nodes {
+		known[node.BlockRoot] = struct{}{}
+	}
+	for _, node := range nodes {
+		attrs := ""
+		switch node.BlockRoot {
+		case head:
+			attrs = `, style=filled, fillcolor="lightblue"`
+		case justified:
+			attrs = `, style=filled, fillcolor="lightyellow"`
+		case finalized:
+			attrs = `, style=filled, fillcolor="lightgreen"`
+		}
+		if _, err := fmt.Fprintf(w, "\t\"%x\" [label=\"slot %d\\n%x\\nweight %d\"%s];\n", node.BlockRoot, node.Slot, node.BlockRoot[:4], node.Weight, attrs); err != nil {
+			return err
+		}
+		// the parent of the root of the tree is not part of it.
+		if _, ok := known[node.ParentRoot]; !ok {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\t\"%x\" -> \"%x\";\n", node.ParentRoot, node.BlockRoot); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
@@ -66,6 +66,7 @@ type ForkChoiceStorageMock struct {
 	StateAtSlotVal            map[uint64]*state.CachingBeaconState
 	GetSyncCommitteesVal      map[common.Hash][2]*solid.SyncCommittee
 	GetFinalityCheckpointsVal map[common.Hash][3]solid.Checkpoint
+	ForkNodesVal              []ForkNode
 
 	Pool pool.OperationsPool
 }
@@ -215,3 +216,7 @@ func (f *ForkChoiceStorageMock) OnBlsToExecutionChange(signedChange *cltypes.Sig
 	f.Pool.BLSToExecutionChangesPool.Insert(signedChange.Signature, signedChange)
 	return nil
 }
+
+func (f *ForkChoiceStorageMock) ForkNodes() []ForkNode {
+	return f.ForkNodesVal
+}
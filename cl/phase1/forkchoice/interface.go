@@ -37,6 +37,7 @@ type ForkChoiceStorageReader interface {
 	RandaoMixes(blockRoot libcommon.Hash, out solid.HashListSSZ) bool
 	BlockRewards(root libcommon.Hash) (*eth2.BlockRewardsCollector, bool)
 	TotalActiveBalance(root libcommon.Hash) (uint64, bool)
+	ForkNodes() []ForkNode
 
 	GetStateAtSlot(slot uint64, alwaysCopy bool) (*state.CachingBeaconState, error)
 	GetStateAtStateRoot(root libcommon.Hash, alwaysCopy bool) (*state.CachingBeaconState, error)
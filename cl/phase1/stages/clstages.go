@@ -16,6 +16,7 @@ import (
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/persistence"
 	"github.com/ledgerwatch/erigon/cl/persistence/beacon_indicies"
+	"github.com/ledgerwatch/erigon/cl/persistence/checkpoint_store"
 	"github.com/ledgerwatch/erigon/cl/persistence/db_config"
 	state_accessors "github.com/ledgerwatch/erigon/cl/persistence/state"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
@@ -46,6 +47,7 @@ type Cfg struct {
 	sn              *freezeblocks.CaplinSnapshots
 	antiquary       *antiquary.Antiquary
 	syncedData      *synced_data.SyncedDataManager
+	checkpointStore *checkpoint_store.CheckpointStore
 
 	hasDownloaded, backfilling bool
 }
@@ -75,6 +77,7 @@ func ClStagesCfg(
 	dbConfig db_config.DatabaseConfiguration,
 	backfilling bool,
 	syncedData *synced_data.SyncedDataManager,
+	checkpointStore *checkpoint_store.CheckpointStore,
 ) *Cfg {
 	return &Cfg{
 		rpc:             rpc,
@@ -92,6 +95,7 @@ func ClStagesCfg(
 		sn:              sn,
 		backfilling:     backfilling,
 		syncedData:      syncedData,
+		checkpointStore: checkpointStore,
 	}
 }
 
@@ -292,7 +296,7 @@ func ConsensusClStages(ctx context.Context,
 									currentEpoch = utils.Max64(args.seenEpoch, currentEpoch-1)
 									continue MainLoop
 								}
-								blockBatch = append(blockBatch, types.NewBlockFromStorage(executionPayload.BlockHash, header, txs, nil, body.Withdrawals))
+								blockBatch = append(blockBatch, types.NewBlockFromStorage(executionPayload.BlockHash, header, txs, nil, body.Withdrawals, nil))
 							}
 							if err := processBlock(tx, block, false, true); err != nil {
 								log.Warn("bad blocks segment received", "err", err)
@@ -605,6 +609,12 @@ func ConsensusClStages(ctx context.Context,
 					if err != nil {
 						return err
 					}
+					// keep the latest finalized blocks and states around so that we can act as a checkpoint sync provider.
+					if cfg.checkpointStore != nil {
+						if err := storeFinalizedCheckpoint(ctx, logger, cfg, tx); err != nil {
+							return err
+						}
+					}
 					// TODO(Giulio2002): schedule snapshots retirement if needed.
 					if !cfg.backfilling {
 						if err := cfg.beaconDB.PurgeRange(ctx, tx, 1, cfg.forkChoice.HighestSeen()-100_000); err != nil {
@@ -638,3 +648,42 @@ func ConsensusClStages(ctx context.Context,
 		},
 	}
 }
+
+// storeFinalizedCheckpoint writes the finalized block and its post-state to the checkpoint store, unless they
+// are stored already or no longer available.
+func storeFinalizedCheckpoint(ctx context.Context, logger log.Logger, cfg *Cfg, tx kv.Tx) error {
+	finalizedRoot := cfg.forkChoice.FinalizedCheckpoint().BlockRoot()
+	if cfg.checkpointStore.Has(finalizedRoot) {
+		return nil
+	}
+	slot, err := beacon_indicies.ReadBlockSlotByBlockRoot(tx, finalizedRoot)
+	if err != nil {
+		return err
+	}
+	if slot == nil {
+		logger.Debug("could not find finalized block", "root", finalizedRoot)
+		return nil
+	}
+	block, err := cfg.beaconDB.GetBlock(ctx, tx, *slot)
+	if err != nil {
+		return err
+	}
+	if block == nil || block.Data == nil {
+		logger.Debug("could not retrieve finalized block", "root", finalizedRoot, "slot", *slot)
+		return nil
+	}
+	blockRoot, err := block.Data.Block.HashSSZ()
+	if err != nil {
+		return err
+	}
+	if blockRoot != finalizedRoot {
+		logger.Debug("finalized block is not canonical", "root", finalizedRoot, "slot", *slot)
+		return nil
+	}
+	finalizedState, err := cfg.forkChoice.GetStateAtBlockRoot(finalizedRoot, true)
+	if err != nil || finalizedState == nil {
+		logger.Debug("could not retrieve finalized state", "root", finalizedRoot, "err", err)
+		return nil
+	}
+	return cfg.checkpointStore.WriteCheckpoint(block.Data, finalizedState)
+}
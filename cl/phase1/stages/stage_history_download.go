@@ -261,7 +261,7 @@ func SpawnStageHistoryDownload(cfg StageHistoryReconstructionCfg, ctx context.Co
 			return err
 		}
 
-		block := types.NewBlockFromStorage(executionPayload.BlockHash, header, txs, nil, body.Withdrawals)
+		block := types.NewBlockFromStorage(executionPayload.BlockHash, header, txs, nil, body.Withdrawals, nil)
 		blockBatch = append(blockBatch, block)
 		if len(blockBatch) >= blockBatchMaxSize {
 			if err := cfg.engine.InsertBlocks(blockBatch); err != nil {
@@ -44,6 +44,9 @@ type SentinelConfig struct {
 	NoDiscovery    bool
 	TmpDir         string
 	LocalDiscovery bool
+	// ActiveValidatorCount sizes the expected attestation traffic for peer scoring,
+	// zero assumes the maximum number of committees.
+	ActiveValidatorCount uint64
 
 	EnableBlocks bool
 }
@@ -30,9 +30,41 @@ import (
 var (
 	// maxInMeshScore describes the max score a peer can attain from being in the mesh.
 	maxInMeshScore = 10.
+	// maxFirstDeliveryScore describes the max score a peer can obtain from first deliveries.
+	maxFirstDeliveryScore = 40.
+	// maxPositiveScore is the highest score a peer can attain on a topic, a single invalid
+	// message wipes it out.
+	maxPositiveScore = maxInMeshScore + maxFirstDeliveryScore
 	// beaconBlockWeight specifies the scoring weight that we apply to
 	// our beacon block topic.
 	beaconBlockWeight = 0.8
+	// aggregateWeight specifies the scoring weight that we apply to
+	// our aggregate topic.
+	aggregateWeight = 0.5
+	// attestationTotalWeight specifies the scoring weight that we apply to
+	// all of our attestation subnet topics together.
+	attestationTotalWeight = 1.
+	// syncCommitteesTotalWeight specifies the scoring weight that we apply to
+	// all of our sync committee subnet topics together.
+	syncCommitteesTotalWeight = 0.4
+	// syncContributionWeight specifies the scoring weight that we apply to
+	// our sync committee contribution topic.
+	syncContributionWeight = 0.2
+	// blobSidecarWeight specifies the scoring weight that we apply to
+	// each of our blob sidecar topics.
+	blobSidecarWeight = 0.1
+	// voluntaryExitWeight specifies the scoring weight that we apply to
+	// our voluntary exit topic.
+	voluntaryExitWeight = 0.05
+	// proposerSlashingWeight specifies the scoring weight that we apply to
+	// our proposer slashing topic.
+	proposerSlashingWeight = 0.05
+	// attesterSlashingWeight specifies the scoring weight that we apply to
+	// our attester slashing topic.
+	attesterSlashingWeight = 0.05
+	// blsToExecutionChangeWeight specifies the scoring weight that we apply to
+	// our bls to execution change topic.
+	blsToExecutionChangeWeight = 0.05
 )
 
 const SSZSnappyCodec = "ssz_snappy"
@@ -195,7 +227,9 @@ func (s *Sentinel) SubscribeGossip(topic GossipTopic, opts ...pubsub.TopicOpt) (
 	}
 	topicScoreParams := s.topicScoreParams(topic.Name)
 	if topicScoreParams != nil {
-		sub.topic.SetScoreParams(topicScoreParams)
+		if err := sub.topic.SetScoreParams(topicScoreParams); err != nil {
+			return nil, fmt.Errorf("failed to set score params for topic %s, err=%w", path, err)
+		}
 	}
 	s.subManager.AddSubscription(path, sub)
 
@@ -216,22 +250,24 @@ func (s *Sentinel) topicScoreParams(topic string) *pubsub.TopicScoreParams {
 	switch {
 	case strings.Contains(topic, gossip.TopicNameBeaconBlock):
 		return s.defaultBlockTopicParams()
-	/*case strings.Contains(topic, GossipAggregateAndProofMessage):
-	return defaultAggregateTopicParams(activeValidators), nil
-	case strings.Contains(topic, GossipAttestationMessage):
-	return defaultAggregateSubnetTopicParams(activeValidators), nil
-	case strings.Contains(topic, GossipSyncCommitteeMessage):
-	return defaultSyncSubnetTopicParams(activeValidators), nil
-	case strings.Contains(topic, GossipContributionAndProofMessage):
-	return defaultSyncContributionTopicParams(), nil
-	case strings.Contains(topic, GossipExitMessage):
-	return defaultVoluntaryExitTopicParams(), nil
-	case strings.Contains(topic, GossipProposerSlashingMessage):
-	return defaultProposerSlashingTopicParams(), nil
-	case strings.Contains(topic, GossipAttesterSlashingMessage):
-	return defaultAttesterSlashingTopicParams(), nil
-	case strings.Contains(topic, GossipBlsToExecutionChangeMessage):
-	return defaultBlsToExecutionChangeTopicParams(), nil*/
+	case strings.Contains(topic, gossip.TopicNameBeaconAggregateAndProof):
+		return s.defaultAggregateTopicParams()
+	case gossip.IsTopicBeaconAttestation(topic):
+		return s.defaultAttestationSubnetTopicParams()
+	case strings.Contains(topic, gossip.TopicNameSyncCommitteeContributionAndProof):
+		return s.defaultSyncContributionTopicParams()
+	case gossip.IsTopicSyncCommittee(topic):
+		return s.defaultSyncCommitteeSubnetTopicParams()
+	case gossip.IsTopicBlobSidecar(topic):
+		return s.defaultBlobSidecarTopicParams()
+	case strings.Contains(topic, gossip.TopicNameVoluntaryExit):
+		return s.defaultOperationTopicParams(voluntaryExitWeight, 2, 5)
+	case strings.Contains(topic, gossip.TopicNameProposerSlashing):
+		return s.defaultOperationTopicParams(proposerSlashingWeight, 36, 1)
+	case strings.Contains(topic, gossip.TopicNameAttesterSlashing):
+		return s.defaultOperationTopicParams(attesterSlashingWeight, 36, 1)
+	case strings.Contains(topic, gossip.TopicNameBlsToExecutionChange):
+		return s.defaultOperationTopicParams(blsToExecutionChangeWeight, 2, 5)
 	default:
 		return nil
 	}
@@ -263,6 +299,100 @@ func (s *Sentinel) defaultBlockTopicParams() *pubsub.TopicScoreParams {
 	}
 }
 
+func (s *Sentinel) defaultAggregateTopicParams() *pubsub.TopicScoreParams {
+	aggregatesPerSlot := s.committeesPerSlot() * s.cfg.BeaconConfig.TargetAggregatorsPerCommittee
+	return s.defaultRateTopicParams(aggregateWeight, float64(aggregatesPerSlot), s.oneEpochDuration(), s.oneEpochDuration(), s.oneEpochDuration())
+}
+
+// each attestation subnet carries the attestations of the committees assigned to it.
+// when a subnet sees fewer than two committees per epoch its traffic comes in bursts, so we decay slower.
+func (s *Sentinel) defaultAttestationSubnetTopicParams() *pubsub.TopicScoreParams {
+	cfg := s.cfg.BeaconConfig
+	attestationsPerSlot := float64(s.activeValidatorCount()) / float64(cfg.SlotsPerEpoch) / float64(s.cfg.NetworkConfig.AttestationSubnetCount)
+	firstDecay, meshDecay := 10*s.oneEpochDuration(), 16*s.oneEpochDuration()
+	if s.committeesPerSlot() >= 2*s.cfg.NetworkConfig.AttestationSubnetCount/cfg.SlotsPerEpoch {
+		firstDecay, meshDecay = s.oneEpochDuration(), 4*s.oneEpochDuration()
+	}
+	return s.defaultRateTopicParams(attestationTotalWeight/float64(s.cfg.NetworkConfig.AttestationSubnetCount), attestationsPerSlot, firstDecay, meshDecay, 17*s.oneSlotDuration())
+}
+
+// the sync committee is split evenly over its subnets and every member signs each slot.
+func (s *Sentinel) defaultSyncCommitteeSubnetTopicParams() *pubsub.TopicScoreParams {
+	cfg := s.cfg.BeaconConfig
+	messagesPerSlot := float64(cfg.SyncCommitteeSize) / float64(cfg.SyncCommitteeSubnetCount)
+	return s.defaultRateTopicParams(syncCommitteesTotalWeight/float64(cfg.SyncCommitteeSubnetCount), messagesPerSlot, s.oneEpochDuration(), 4*s.oneEpochDuration(), s.oneEpochDuration())
+}
+
+func (s *Sentinel) defaultSyncContributionTopicParams() *pubsub.TopicScoreParams {
+	cfg := s.cfg.BeaconConfig
+	contributionsPerSlot := float64(cfg.TargetAggregatorsPerSyncSubcommittee * cfg.SyncCommitteeSubnetCount)
+	return s.defaultRateTopicParams(syncContributionWeight, contributionsPerSlot, s.oneEpochDuration(), s.oneEpochDuration(), s.oneEpochDuration())
+}
+
+// defaultRateTopicParams scores a topic expected to carry messagesPerSlot messages each slot.
+// mesh peers delivering less than the expected rate are penalised once the activation period has passed.
+func (s *Sentinel) defaultRateTopicParams(weight, messagesPerSlot float64, firstDecay, meshDecay, meshActivation time.Duration) *pubsub.TopicScoreParams {
+	firstMessageDecay := s.scoreDecay(firstDecay)
+	firstMessageCap := decayConvergence(firstMessageDecay, 2*messagesPerSlot/gossipSubD)
+	meshMessageDecay := s.scoreDecay(meshDecay)
+	meshThreshold := decayThreshold(meshMessageDecay, messagesPerSlot/dampeningFactor)
+	meshWeight := -maxPositiveScore / (weight * meshThreshold * meshThreshold)
+	return &pubsub.TopicScoreParams{
+		TopicWeight:                     weight,
+		TimeInMeshWeight:                maxInMeshScore / s.inMeshCap(),
+		TimeInMeshQuantum:               s.oneSlotDuration(),
+		TimeInMeshCap:                   s.inMeshCap(),
+		FirstMessageDeliveriesWeight:    maxFirstDeliveryScore / firstMessageCap,
+		FirstMessageDeliveriesDecay:     firstMessageDecay,
+		FirstMessageDeliveriesCap:       firstMessageCap,
+		MeshMessageDeliveriesWeight:     meshWeight,
+		MeshMessageDeliveriesDecay:      meshMessageDecay,
+		MeshMessageDeliveriesCap:        4 * meshThreshold,
+		MeshMessageDeliveriesThreshold:  meshThreshold,
+		MeshMessageDeliveriesWindow:     2 * time.Second,
+		MeshMessageDeliveriesActivation: meshActivation,
+		MeshFailurePenaltyWeight:        meshWeight,
+		MeshFailurePenaltyDecay:         meshMessageDecay,
+		InvalidMessageDeliveriesWeight:  -maxPositiveScore / weight,
+		InvalidMessageDeliveriesDecay:   s.scoreDecay(50 * s.oneEpochDuration()),
+	}
+}
+
+// blob sidecars arrive at most once per slot and subnet, like blocks.
+func (s *Sentinel) defaultBlobSidecarTopicParams() *pubsub.TopicScoreParams {
+	return &pubsub.TopicScoreParams{
+		TopicWeight:                    blobSidecarWeight,
+		TimeInMeshWeight:               maxInMeshScore / s.inMeshCap(),
+		TimeInMeshQuantum:              s.oneSlotDuration(),
+		TimeInMeshCap:                  s.inMeshCap(),
+		FirstMessageDeliveriesWeight:   1,
+		FirstMessageDeliveriesDecay:    s.scoreDecay(20 * s.oneEpochDuration()),
+		FirstMessageDeliveriesCap:      23,
+		MeshMessageDeliveriesDecay:     s.scoreDecay(5 * s.oneEpochDuration()),
+		MeshFailurePenaltyDecay:        s.scoreDecay(5 * s.oneEpochDuration()),
+		InvalidMessageDeliveriesWeight: -maxPositiveScore / blobSidecarWeight,
+		InvalidMessageDeliveriesDecay:  s.scoreDecay(50 * s.oneEpochDuration()),
+	}
+}
+
+// defaultOperationTopicParams scores the low traffic operation topics (exits, slashings and bls changes).
+// invalid messages are punished hard since these are cheap to verify.
+func (s *Sentinel) defaultOperationTopicParams(weight, firstMessageWeight, firstMessageCap float64) *pubsub.TopicScoreParams {
+	return &pubsub.TopicScoreParams{
+		TopicWeight:                    weight,
+		TimeInMeshWeight:               maxInMeshScore / s.inMeshCap(),
+		TimeInMeshQuantum:              s.oneSlotDuration(),
+		TimeInMeshCap:                  s.inMeshCap(),
+		FirstMessageDeliveriesWeight:   firstMessageWeight,
+		FirstMessageDeliveriesDecay:    s.scoreDecay(100 * s.oneEpochDuration()),
+		FirstMessageDeliveriesCap:      firstMessageCap,
+		MeshMessageDeliveriesDecay:     s.scoreDecay(s.oneEpochDuration()),
+		MeshFailurePenaltyDecay:        s.scoreDecay(s.oneEpochDuration()),
+		InvalidMessageDeliveriesWeight: -2000,
+		InvalidMessageDeliveriesDecay:  s.scoreDecay(50 * s.oneEpochDuration()),
+	}
+}
+
 func (g *GossipManager) Close() {
 	for _, topic := range g.subscriptions {
 		if topic != nil {
@@ -8,6 +8,22 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
+const (
+	// peers below the gossip threshold are not gossiped to (IHAVE/IWANT).
+	gossipThreshold = -4000
+	// peers below the publish threshold do not receive our own messages.
+	publishThreshold = -8000
+	// peers below the graylist threshold are ignored by gossipsub, and pruned from our pool.
+	graylistThreshold = -16000
+
+	// how often we take a snapshot of the gossipsub scores.
+	peerScoreInspectInterval = 15 * time.Second
+
+	// the expected mesh delivery rate is divided by this factor before it is used as a threshold,
+	// so that a peer is only penalised when it falls well behind the rest of the mesh.
+	dampeningFactor = 50
+)
+
 // determines the decay rate from the provided time period till
 // the decayToZero value. Ex: ( 1 -> 0.01)
 func (s *Sentinel) scoreDecay(totalDurationDecay time.Duration) float64 {
@@ -15,11 +31,21 @@ func (s *Sentinel) scoreDecay(totalDurationDecay time.Duration) float64 {
 	return math.Pow(decayToZero, 1/float64(numOfTimes))
 }
 
+// the value a counter converges to when it receives rate messages every decay interval.
+func decayConvergence(decay, rate float64) float64 {
+	return rate / (1 - decay)
+}
+
+// the converged counter right after a decay, used as the mesh delivery threshold.
+func decayThreshold(decay, rate float64) float64 {
+	return decayConvergence(decay, rate) * decay
+}
+
 func (s *Sentinel) pubsubOptions() []pubsub.Option {
 	thresholds := &pubsub.PeerScoreThresholds{
-		GossipThreshold:             -4000,
-		PublishThreshold:            -8000,
-		GraylistThreshold:           -16000,
+		GossipThreshold:             gossipThreshold,
+		PublishThreshold:            publishThreshold,
+		GraylistThreshold:           graylistThreshold,
 		AcceptPXThreshold:           100,
 		OpportunisticGraftThreshold: 5,
 	}
@@ -49,6 +75,7 @@ func (s *Sentinel) pubsubOptions() []pubsub.Option {
 		pubsub.WithMaxMessageSize(int(s.cfg.NetworkConfig.GossipMaxSizeBellatrix)),
 		pubsub.WithValidateQueueSize(pubsubQueueSize),
 		pubsub.WithPeerScore(scoreParams, thresholds),
+		pubsub.WithPeerScoreInspect(s.inspectPeerScores, peerScoreInspectInterval),
 		pubsub.WithGossipSubParams(pubsubGossipParam()),
 	}
 	return psOpts
@@ -0,0 +1,82 @@
+package sentinel
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/metrics"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+var (
+	peerScoreMin       = metrics.GetOrCreateGauge("sentinel_peer_score_min")
+	peerScoreMax       = metrics.GetOrCreateGauge("sentinel_peer_score_max")
+	peerScoreAvg       = metrics.GetOrCreateGauge("sentinel_peer_score_avg")
+	peersBelowGossip   = metrics.GetOrCreateGauge("sentinel_peers_below_gossip_threshold")
+	peersPrunedByScore = metrics.GetOrCreateCounter("sentinel_peers_pruned_by_score")
+)
+
+// inspectPeerScores is invoked periodically by gossipsub with a snapshot of the peer scores.
+// it keeps the snapshot around for debugging, updates the metrics and prunes graylisted peers.
+func (s *Sentinel) inspectPeerScores(snapshot map[peer.ID]*pubsub.PeerScoreSnapshot) {
+	scores := make(map[peer.ID]float64, len(snapshot))
+	invalidMessages := make(map[string]float64)
+	minScore, maxScore, sumScore := math.Inf(1), math.Inf(-1), 0.
+	belowGossip := 0
+	for pid, peerScore := range snapshot {
+		scores[pid] = peerScore.Score
+		minScore = math.Min(minScore, peerScore.Score)
+		maxScore = math.Max(maxScore, peerScore.Score)
+		sumScore += peerScore.Score
+		if peerScore.Score < gossipThreshold {
+			belowGossip++
+		}
+		for topic, topicScore := range peerScore.Topics {
+			invalidMessages[topicNameFromPath(topic)] += topicScore.InvalidMessageDeliveries
+		}
+	}
+
+	s.peerScoresMu.Lock()
+	s.peerScores = snapshot
+	s.peerScoresMu.Unlock()
+
+	if len(snapshot) > 0 {
+		peerScoreMin.Set(minScore)
+		peerScoreMax.Set(maxScore)
+		peerScoreAvg.Set(sumScore / float64(len(snapshot)))
+	}
+	peersBelowGossip.SetInt(belowGossip)
+	for topic, invalid := range invalidMessages {
+		metrics.GetOrCreateGauge(fmt.Sprintf(`sentinel_topic_invalid_messages{topic="%s"}`, topic)).Set(invalid)
+	}
+
+	for _, pid := range s.peers.PruneByScore(scores, graylistThreshold, time.Now()) {
+		s.logger.Debug("[Sentinel] Pruning peer with low gossip score", "peer", pid, "score", scores[pid])
+		s.host.Peerstore().RemovePeer(pid)
+		s.host.Network().ClosePeer(pid)
+		peersPrunedByScore.Inc()
+	}
+}
+
+// PeerScores returns the latest snapshot of the gossipsub peer scores.
+func (s *Sentinel) PeerScores() map[peer.ID]*pubsub.PeerScoreSnapshot {
+	s.peerScoresMu.RLock()
+	defer s.peerScoresMu.RUnlock()
+	scores := make(map[peer.ID]*pubsub.PeerScoreSnapshot, len(s.peerScores))
+	for pid, score := range s.peerScores {
+		scores[pid] = score
+	}
+	return scores
+}
+
+// topicNameFromPath extracts the topic name from a gossip path such as /eth2/{digest}/{name}/{codec}.
+func topicNameFromPath(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) != 5 {
+		return path
+	}
+	return parts[3]
+}
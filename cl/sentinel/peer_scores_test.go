@@ -0,0 +1,81 @@
+package sentinel
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/gossip"
+	"github.com/libp2p/go-libp2p"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicScoreParams(t *testing.T) {
+	g := clparams.GenesisConfigs[clparams.MainnetNetwork]
+	n := clparams.NetworkConfigs[clparams.MainnetNetwork]
+	s := &Sentinel{
+		ctx: context.TODO(),
+		cfg: &SentinelConfig{
+			BeaconConfig:  &clparams.MainnetBeaconConfig,
+			GenesisConfig: &g,
+			NetworkConfig: &n,
+		},
+	}
+	host, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	defer host.Close()
+	s.pubsub, err = pubsub.NewGossipSub(s.ctx, host, s.pubsubOptions()...)
+	require.NoError(t, err)
+
+	topics := []GossipTopic{
+		BeaconBlockSsz,
+		BeaconAggregateAndProofSsz,
+		VoluntaryExitSsz,
+		ProposerSlashingSsz,
+		AttesterSlashingSsz,
+		BlsToExecutionChangeSsz,
+	}
+	topics = append(topics, GossipSidecarTopics(6)...)
+	topics = append(topics,
+		GossipTopic{Name: gossip.TopicNameBeaconAttestation(0), CodecStr: SSZSnappyCodec},
+		GossipTopic{Name: gossip.TopicNameSyncCommittee(0), CodecStr: SSZSnappyCodec},
+		GossipTopic{Name: gossip.TopicNameSyncCommitteeContributionAndProof, CodecStr: SSZSnappyCodec},
+	)
+	for _, topic := range topics {
+		params := s.topicScoreParams(topic.Name)
+		require.NotNil(t, params, topic.Name)
+		// a single invalid message must cost more than what a peer can gain on the topic.
+		require.Less(t, params.InvalidMessageDeliveriesWeight*params.TopicWeight, -params.TopicWeight*maxInMeshScore)
+
+		joined, err := s.pubsub.Join(fmt.Sprintf("/eth2/%x/%s/%s", [4]byte{}, topic.Name, topic.CodecStr))
+		require.NoError(t, err)
+		require.NoError(t, joined.SetScoreParams(params), topic.Name)
+	}
+	require.Nil(t, s.topicScoreParams("unknown_topic"))
+
+	// mesh peers falling behind on the aggregates and subnets are penalised.
+	for _, topic := range []string{gossip.TopicNameBeaconAggregateAndProof, gossip.TopicNameBeaconAttestation(1), gossip.TopicNameSyncCommittee(1), gossip.TopicNameSyncCommitteeContributionAndProof} {
+		params := s.topicScoreParams(topic)
+		require.Negative(t, params.MeshMessageDeliveriesWeight, topic)
+		require.Negative(t, params.MeshFailurePenaltyWeight, topic)
+		require.Positive(t, params.MeshMessageDeliveriesThreshold, topic)
+	}
+	require.NotEqual(t, s.topicScoreParams(gossip.TopicNameSyncCommittee(0)).TopicWeight, s.topicScoreParams(gossip.TopicNameSyncCommitteeContributionAndProof).TopicWeight)
+
+	// a small validator set spreads the committees, the subnets then decay slower.
+	full := s.topicScoreParams(gossip.TopicNameBeaconAttestation(0))
+	s.cfg.ActiveValidatorCount = 1000
+	require.Equal(t, uint64(1), s.committeesPerSlot())
+	small := s.topicScoreParams(gossip.TopicNameBeaconAttestation(0))
+	require.Less(t, full.MeshMessageDeliveriesDecay, small.MeshMessageDeliveriesDecay)
+	joined, err := s.pubsub.Join("small_validator_set")
+	require.NoError(t, err)
+	require.NoError(t, joined.SetScoreParams(small))
+}
+
+func TestTopicNameFromPath(t *testing.T) {
+	require.Equal(t, gossip.TopicNameBeaconBlock, topicNameFromPath("/eth2/6a95a1a9/beacon_block/ssz_snappy"))
+	require.Equal(t, "garbage", topicNameFromPath("garbage"))
+}
@@ -4,11 +4,16 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/ledgerwatch/erigon-lib/common/ring"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
+// ScoreBanDuration is how long the peers pruned for their gossip score stay banned, after which
+// they may reconnect and be scored again.
+const ScoreBanDuration = 30 * time.Minute
+
 // Item is an item in the pool
 type Item struct {
 	id    peer.ID
@@ -41,7 +46,9 @@ type Pool struct {
 	peerData map[peer.ID]*Item
 
 	bannedPeers map[peer.ID]struct{}
-	queue       *ring.Buffer[*Item]
+	// scoreBans are the expiries of the bans of the peers pruned by score
+	scoreBans map[peer.ID]time.Time
+	queue     *ring.Buffer[*Item]
 
 	mu sync.Mutex
 }
@@ -50,12 +57,27 @@ func NewPool() *Pool {
 	return &Pool{
 		peerData:    make(map[peer.ID]*Item),
 		bannedPeers: map[peer.ID]struct{}{},
+		scoreBans:   map[peer.ID]time.Time{},
 		queue:       ring.NewBuffer[*Item](0, 1024),
 	}
 }
 
 func (p *Pool) BanStatus(pid peer.ID) bool {
-	_, ok := p.bannedPeers[pid]
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.banned(pid, time.Now())
+}
+
+// banned reports whether the peer is banned, forgetting the expired score ban. assume has lock
+func (p *Pool) banned(pid peer.ID, now time.Time) bool {
+	if _, ok := p.bannedPeers[pid]; ok {
+		return true
+	}
+	expiry, ok := p.scoreBans[pid]
+	if ok && !now.Before(expiry) {
+		delete(p.scoreBans, pid)
+		return false
+	}
 	return ok
 }
 
@@ -63,7 +85,7 @@ func (p *Pool) AddPeer(pid peer.ID) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	// if peer banned, return immediately
-	if _, ok := p.bannedPeers[pid]; ok {
+	if p.banned(pid, time.Now()) {
 		return
 	}
 	// if peer already here, return immediately
@@ -86,7 +108,28 @@ func (p *Pool) SetBanStatus(pid peer.ID, banned bool) {
 		delete(p.peerData, pid)
 	} else {
 		delete(p.bannedPeers, pid)
+		delete(p.scoreBans, pid)
+	}
+}
+
+// PruneByScore bans every peer whose gossip score dropped below the threshold for ScoreBanDuration
+// and returns them. the caller is responsible for disconnecting the returned peers.
+func (p *Pool) PruneByScore(scores map[peer.ID]float64, threshold float64, now time.Time) []peer.ID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var pruned []peer.ID
+	for pid, score := range scores {
+		if score >= threshold {
+			continue
+		}
+		if p.banned(pid, now) {
+			continue
+		}
+		p.scoreBans[pid] = now.Add(ScoreBanDuration)
+		delete(p.peerData, pid)
+		pruned = append(pruned, pid)
 	}
+	return pruned
 }
 
 func (p *Pool) RemovePeer(pid peer.ID) {
@@ -112,7 +155,7 @@ func (p *Pool) nextPeer() (i *Item, ok bool) {
 		return nil, false
 	}
 	// if peer been banned, get next peer
-	if _, ok := p.bannedPeers[val.id]; ok {
+	if p.banned(val.id, time.Now()) {
 		return p.nextPeer()
 	}
 	// if peer not in set, get next peer
@@ -127,8 +170,8 @@ func (p *Pool) nextPeer() (i *Item, ok bool) {
 func (p *Pool) Request() (pid *Item, done func(), err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	//grab a peer from our ringbuffer
-	val, ok := p.queue.PopFront()
+	//grab a peer from our ringbuffer, skipping banned and removed peers
+	val, ok := p.nextPeer()
 	if !ok {
 		return nil, nil, fmt.Errorf("no peers? (  :(  > ")
 	}
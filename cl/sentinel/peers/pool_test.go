@@ -0,0 +1,46 @@
+package peers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolPruneByScore(t *testing.T) {
+	pool := NewPool()
+	good, bad, banned := peer.ID("good"), peer.ID("bad"), peer.ID("banned")
+	pool.AddPeer(good)
+	pool.AddPeer(bad)
+	pool.SetBanStatus(banned, true)
+
+	now := time.Now()
+	pruned := pool.PruneByScore(map[peer.ID]float64{
+		good:   10,
+		bad:    -20000,
+		banned: -20000,
+	}, -16000, now)
+	require.Equal(t, []peer.ID{bad}, pruned)
+	require.True(t, pool.BanStatus(bad))
+	require.False(t, pool.BanStatus(good))
+
+	// the pruned peer is not handed out anymore.
+	for i := 0; i < 2; i++ {
+		item, done, err := pool.Request()
+		if err != nil {
+			break
+		}
+		require.NotEqual(t, bad, item.Id())
+		done()
+	}
+
+	// the score ban expires, unlike the explicit one.
+	pool.mu.Lock()
+	require.True(t, pool.banned(bad, now.Add(ScoreBanDuration-time.Second)))
+	require.False(t, pool.banned(bad, now.Add(ScoreBanDuration)))
+	require.True(t, pool.banned(banned, now.Add(ScoreBanDuration)))
+	pool.mu.Unlock()
+	pool.AddPeer(bad)
+	require.False(t, pool.BanStatus(bad))
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -84,6 +85,9 @@ type Sentinel struct {
 	metrics              bool
 	listenForPeersDoneCh chan struct{}
 	logger               log.Logger
+
+	peerScores   map[peer.ID]*pubsub.PeerScoreSnapshot
+	peerScoresMu sync.RWMutex
 }
 
 func (s *Sentinel) createLocalNode(
@@ -324,6 +324,29 @@ func (s *SentinelServer) GetPeersStatistics() map[string]*diagnostics.PeerStatis
 	return stats
 }
 
+func (s *SentinelServer) GetPeersScores() map[string]*diagnostics.PeerScore {
+	scores := make(map[string]*diagnostics.PeerScore)
+	for pid, snapshot := range s.sentinel.PeerScores() {
+		score := &diagnostics.PeerScore{
+			Score:              snapshot.Score,
+			AppSpecificScore:   snapshot.AppSpecificScore,
+			IPColocationFactor: snapshot.IPColocationFactor,
+			BehaviourPenalty:   snapshot.BehaviourPenalty,
+			Topics:             make(map[string]*diagnostics.TopicScore, len(snapshot.Topics)),
+		}
+		for topic, topicScore := range snapshot.Topics {
+			score.Topics[topic] = &diagnostics.TopicScore{
+				TimeInMesh:               topicScore.TimeInMesh,
+				FirstMessageDeliveries:   topicScore.FirstMessageDeliveries,
+				MeshMessageDeliveries:    topicScore.MeshMessageDeliveries,
+				InvalidMessageDeliveries: topicScore.InvalidMessageDeliveries,
+			}
+		}
+		scores[pid.String()] = score
+	}
+	return scores
+}
+
 func (s *SentinelServer) trackPeerStatistics(peerID string, inbound bool, msgType string, msgCap string, bytes int) {
 	if s.peerStatistics == nil {
 		s.peerStatistics = make(map[string]*diagnostics.PeerStatistics)
@@ -110,6 +110,28 @@ func (s *Sentinel) oneEpochDuration() time.Duration {
 	return s.oneSlotDuration() * time.Duration(s.cfg.BeaconConfig.SlotsPerEpoch)
 }
 
+// the number of active validators used to size the expected gossip traffic,
+// without a count we assume every slot has the maximum number of committees.
+func (s *Sentinel) activeValidatorCount() uint64 {
+	if s.cfg.ActiveValidatorCount > 0 {
+		return s.cfg.ActiveValidatorCount
+	}
+	cfg := s.cfg.BeaconConfig
+	return cfg.MaxCommitteesPerSlot * cfg.SlotsPerEpoch * cfg.TargetCommitteeSize
+}
+
+func (s *Sentinel) committeesPerSlot() uint64 {
+	cfg := s.cfg.BeaconConfig
+	committees := s.activeValidatorCount() / cfg.SlotsPerEpoch / cfg.TargetCommitteeSize
+	if committees > cfg.MaxCommitteesPerSlot {
+		committees = cfg.MaxCommitteesPerSlot
+	}
+	if committees < 1 {
+		committees = 1
+	}
+	return committees
+}
+
 // the cap for `inMesh` time scoring.
 func (s *Sentinel) inMeshCap() float64 {
 	return float64((3600 * time.Second) / s.oneSlotDuration())
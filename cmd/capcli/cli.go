@@ -80,6 +80,7 @@ var CLI struct {
 	LoopSnapshots           LoopSnapshots           `cmd:"" help:"loop over snapshots"`
 	RetrieveHistoricalState RetrieveHistoricalState `cmd:"" help:"retrieve historical state from db"`
 	ChainEndpoint           ChainEndpoint           `cmd:"" help:"chain endpoint"`
+	Replay                  Replay                  `cmd:"" help:"replay blocks on top of a state, reporting state roots and balance changes"`
 }
 
 type chainCfg struct {
@@ -806,33 +807,30 @@ type RetrieveHistoricalState struct {
 	CompareSlot uint64 `help:"compare slot" default:"0"`
 }
 
-func (r *RetrieveHistoricalState) Run(ctx *Context) error {
+// openHistoricalStatesReader opens the caplin database of dirs and the reader of the states reconstructed by the antiquary.
+func openHistoricalStatesReader(ctx *Context, chain string, dirs datadir.Dirs) (*historical_states_reader.HistoricalStatesReader, kv.RwDB, error) {
 	vt := state_accessors.NewStaticValidatorTable()
-	_, _, beaconConfig, t, err := clparams.GetConfigsByNetworkName(r.Chain)
+	_, _, beaconConfig, t, err := clparams.GetConfigsByNetworkName(chain)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	dirs := datadir.New(r.Datadir)
 	rawDB, fs := persistence.AferoRawBeaconBlockChainFromOsPath(beaconConfig, dirs.CaplinHistory)
 	beaconDB, db, err := caplin1.OpenCaplinDatabase(ctx, db_config.DatabaseConfiguration{PruneDepth: math.MaxUint64}, beaconConfig, rawDB, dirs.CaplinIndexing, nil, false)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	log.Root().SetHandler(log.LvlFilterHandler(log.LvlDebug, log.StderrHandler))
-
-	tx, err := db.BeginRo(ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-	snapshotVersion := snapcfg.KnownCfg(r.Chain, 0).Version
+	snapshotVersion := snapcfg.KnownCfg(chain, 0).Version
 
 	allSnapshots := freezeblocks.NewRoSnapshots(ethconfig.BlocksFreezing{}, dirs.Snap, snapshotVersion, log.Root())
 	if err := allSnapshots.ReopenFolder(); err != nil {
-		return err
+		db.Close()
+		return nil, nil, err
 	}
-	if err := state_accessors.ReadValidatorsTable(tx, vt); err != nil {
-		return err
+	if err := db.View(ctx, func(tx kv.Tx) error {
+		return state_accessors.ReadValidatorsTable(tx, vt)
+	}); err != nil {
+		db.Close()
+		return nil, nil, err
 	}
 
 	var bor *freezeblocks.BorRoSnapshots
@@ -840,15 +838,35 @@ func (r *RetrieveHistoricalState) Run(ctx *Context) error {
 	eth1Getter := getters.NewExecutionSnapshotReader(ctx, beaconConfig, blockReader, db)
 	csn := freezeblocks.NewCaplinSnapshots(ethconfig.BlocksFreezing{}, beaconConfig, dirs.Snap, snapshotVersion, log.Root())
 	if err := csn.ReopenFolder(); err != nil {
-		return err
+		db.Close()
+		return nil, nil, err
 	}
 	snr := freezeblocks.NewBeaconSnapshotReader(csn, eth1Getter, beaconDB, beaconConfig)
 	gSpot, err := initial_state.GetGenesisState(t)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return historical_states_reader.NewHistoricalStatesReader(beaconConfig, snr, vt, fs, gSpot), db, nil
+}
+
+func (r *RetrieveHistoricalState) Run(ctx *Context) error {
+	_, _, beaconConfig, _, err := clparams.GetConfigsByNetworkName(r.Chain)
+	if err != nil {
+		return err
+	}
+	hr, db, err := openHistoricalStatesReader(ctx, r.Chain, datadir.New(r.Datadir))
 	if err != nil {
 		return err
 	}
+	defer db.Close()
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlDebug, log.StderrHandler))
 
-	hr := historical_states_reader.NewHistoricalStatesReader(beaconConfig, snr, vt, fs, gSpot)
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 	start := time.Now()
 	haveState, err := hr.ReadHistoricalState(ctx, tx, r.CompareSlot)
 	if err != nil {
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/datadir"
+	"github.com/ledgerwatch/erigon/cl/abstract"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/persistence/beacon_indicies"
+	"github.com/ledgerwatch/erigon/cl/persistence/checkpoint_store"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/transition/impl/eth2"
+	"github.com/ledgerwatch/erigon/cl/transition/machine"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/spf13/afero"
+)
+
+type Replay struct {
+	chainCfg
+	withPPROF
+
+	State     string   `help:"pre-state file, ssz or ssz_snappy encoded" type:"existingfile"`
+	StateSlot int64    `help:"slot of the pre-state to read from the caplin checkpoints or antiquary of --datadir, instead of --state" default:"-1"`
+	Datadir   string   `help:"datadir holding the caplin database, used with --state-slot" default:"~/.local/share/erigon" type:"path"`
+	Blocks    []string `arg:"" name:"blocks" help:"signed blocks to replay, in order, ssz or ssz_snappy encoded" type:"existingfile"`
+
+	VerifySignatures bool `help:"verify block and operation signatures" default:"false"`
+	BalanceDiffs     int  `help:"max number of balance changes to print per block, -1 prints all of them" default:"16"`
+}
+
+// replayMachine checks the post-state root itself, so that mismatching roots can be reported.
+type replayMachine struct {
+	*eth2.Impl
+}
+
+func (replayMachine) VerifyTransition(abstract.BeaconState, *cltypes.BeaconBlock) error {
+	return nil
+}
+
+type balanceDiff struct {
+	Index  int
+	Before uint64
+	After  uint64
+}
+
+type replayResult struct {
+	Slot              uint64
+	BlockRoot         libcommon.Hash
+	StateRoot         libcommon.Hash
+	ExpectedStateRoot libcommon.Hash
+	BalanceDiffs      []balanceDiff
+}
+
+func (r *replayResult) mismatch() bool {
+	return r.StateRoot != r.ExpectedStateRoot
+}
+
+// replayBlocks transitions s through blocks, calling fn after each of them.
+// it stops at the first block whose state root does not match the computed one.
+func replayBlocks(s *state.CachingBeaconState, blocks []*cltypes.SignedBeaconBlock, verifySignatures bool, fn func(*replayResult) error) error {
+	impl := replayMachine{Impl: &eth2.Impl{FullValidation: verifySignatures}}
+	for _, block := range blocks {
+		before := make([]uint64, 0, s.ValidatorLength())
+		s.ForEachBalance(func(v uint64, _, _ int) bool {
+			before = append(before, v)
+			return true
+		})
+		if err := machine.TransitionState(impl, s, block); err != nil {
+			return fmt.Errorf("failed to replay block at slot %d: %w", block.Block.Slot, err)
+		}
+		res := &replayResult{
+			Slot:              block.Block.Slot,
+			ExpectedStateRoot: block.Block.StateRoot,
+		}
+		var err error
+		if res.BlockRoot, err = block.Block.HashSSZ(); err != nil {
+			return err
+		}
+		if res.StateRoot, err = s.HashSSZ(); err != nil {
+			return err
+		}
+		s.ForEachBalance(func(v uint64, idx, _ int) bool {
+			// new validators have no balance before the block.
+			var prev uint64
+			if idx < len(before) {
+				prev = before[idx]
+			}
+			if prev != v {
+				res.BalanceDiffs = append(res.BalanceDiffs, balanceDiff{Index: idx, Before: prev, After: v})
+			}
+			return true
+		})
+		if err := fn(res); err != nil {
+			return err
+		}
+		if res.mismatch() {
+			return fmt.Errorf("state root mismatch at slot %d: got %x, want %x", res.Slot, res.StateRoot, res.ExpectedStateRoot)
+		}
+	}
+	return nil
+}
+
+// readSSZFile reads a file and decompresses it when it is snappy compressed.
+func readSSZFile(fs afero.Fs, path string) ([]byte, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(path, ".ssz_snappy") {
+		return utils.DecompressSnappy(data)
+	}
+	return data, nil
+}
+
+// readReplayState decodes a beacon state, its version is inferred from the slot.
+func readReplayState(fs afero.Fs, path string, beaconConfig *clparams.BeaconChainConfig) (*state.CachingBeaconState, error) {
+	data, err := readSSZFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	// genesis_time (8 bytes) and genesis_validators_root (32 bytes) come before the slot.
+	if len(data) < 48 {
+		return nil, fmt.Errorf("state file %s is too short", path)
+	}
+	slot := binary.LittleEndian.Uint64(data[40:48])
+	s := state.New(beaconConfig)
+	if err := s.DecodeSSZ(data, int(beaconConfig.GetCurrentStateVersion(slot/beaconConfig.SlotsPerEpoch))); err != nil {
+		return nil, fmt.Errorf("failed to decode state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// readStoredReplayState reads the state of the canonical block at slot from the caplin database of dirs.
+// The finalized checkpoints kept on disk are tried first, the other states are reconstructed by the antiquary.
+func readStoredReplayState(ctx *Context, chain string, dirs datadir.Dirs, slot uint64, beaconConfig *clparams.BeaconChainConfig) (*state.CachingBeaconState, error) {
+	hr, db, err := openHistoricalStatesReader(ctx, chain, dirs)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blockRoot, err := beacon_indicies.ReadCanonicalBlockRoot(tx, slot)
+	if err != nil {
+		return nil, err
+	}
+	if blockRoot != (libcommon.Hash{}) {
+		if _, err := os.Stat(dirs.CaplinCheckpoints); err == nil {
+			checkpoints, err := checkpoint_store.NewCheckpointStore(afero.NewBasePathFs(afero.NewOsFs(), dirs.CaplinCheckpoints), beaconConfig, math.MaxUint64)
+			if err != nil {
+				return nil, err
+			}
+			s, err := checkpoints.ReadState(blockRoot)
+			if err != nil {
+				return nil, err
+			}
+			if s != nil {
+				return s, nil
+			}
+		}
+	}
+	s, err := hr.ReadHistoricalState(ctx, tx, slot)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("no state stored for slot %d in %s", slot, dirs.DataDir)
+	}
+	if err := s.InitBeaconState(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// readReplayBlock decodes a signed beacon block, its version is inferred from the slot.
+func readReplayBlock(fs afero.Fs, path string, beaconConfig *clparams.BeaconChainConfig) (*cltypes.SignedBeaconBlock, error) {
+	data, err := readSSZFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	// the block offset (4 bytes) and the signature (96 bytes) come before the slot.
+	if len(data) < 108 {
+		return nil, fmt.Errorf("block file %s is too short", path)
+	}
+	slot := binary.LittleEndian.Uint64(data[100:108])
+	block := cltypes.NewSignedBeaconBlock(beaconConfig)
+	if err := block.DecodeSSZ(data, int(beaconConfig.GetCurrentStateVersion(slot/beaconConfig.SlotsPerEpoch))); err != nil {
+		return nil, fmt.Errorf("failed to decode block %s: %w", path, err)
+	}
+	return block, nil
+}
+
+func printReplayResult(w io.Writer, res *replayResult, maxDiffs int) {
+	status := "ok"
+	if res.mismatch() {
+		status = "MISMATCH"
+	}
+	fmt.Fprintf(w, "slot=%d block_root=%x state_root=%x expected_state_root=%x balances_changed=%d %s\n",
+		res.Slot, res.BlockRoot, res.StateRoot, res.ExpectedStateRoot, len(res.BalanceDiffs), status)
+	for i, diff := range res.BalanceDiffs {
+		if maxDiffs >= 0 && i >= maxDiffs {
+			fmt.Fprintf(w, "\t... %d more\n", len(res.BalanceDiffs)-i)
+			break
+		}
+		fmt.Fprintf(w, "\tvalidator=%d before=%d after=%d delta=%d\n", diff.Index, diff.Before, diff.After, int64(diff.After)-int64(diff.Before))
+	}
+}
+
+func (r *Replay) Run(ctx *Context) error {
+	r.withProfile()
+	beaconConfig, _, err := r.chainCfg.configs()
+	if err != nil {
+		return err
+	}
+	if (r.State == "") == (r.StateSlot < 0) {
+		return fmt.Errorf("exactly one of --state and --state-slot must be given")
+	}
+	fs := afero.NewOsFs()
+	var s *state.CachingBeaconState
+	if r.State != "" {
+		s, err = readReplayState(fs, r.State, beaconConfig)
+	} else {
+		s, err = readStoredReplayState(ctx, r.Chain, datadir.New(r.Datadir), uint64(r.StateSlot), beaconConfig)
+	}
+	if err != nil {
+		return err
+	}
+	blocks := make([]*cltypes.SignedBeaconBlock, 0, len(r.Blocks))
+	for _, path := range r.Blocks {
+		block, err := readReplayBlock(fs, path, beaconConfig)
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, block)
+	}
+	return replayBlocks(s, blocks, r.VerifySignatures, func(res *replayResult) error {
+		printReplayResult(os.Stdout, res, r.BalanceDiffs)
+		return nil
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/cl/antiquary/tests"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayFromFiles(t *testing.T) {
+	blocks, preState, postState := tests.GetPhase0Random()
+	fs := afero.NewMemMapFs()
+
+	stateSSZ, err := utils.EncodeSSZSnappy(preState)
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(fs, "pre.ssz_snappy", stateSSZ, 0o644))
+	for i, block := range blocks {
+		blockSSZ, err := block.EncodeSSZ(nil)
+		require.NoError(t, err)
+		require.NoError(t, afero.WriteFile(fs, []string{"block0.ssz", "block1.ssz"}[i], blockSSZ, 0o644))
+	}
+
+	s, err := readReplayState(fs, "pre.ssz_snappy", &clparams.MainnetBeaconConfig)
+	require.NoError(t, err)
+	b0, err := readReplayBlock(fs, "block0.ssz", &clparams.MainnetBeaconConfig)
+	require.NoError(t, err)
+	b1, err := readReplayBlock(fs, "block1.ssz", &clparams.MainnetBeaconConfig)
+	require.NoError(t, err)
+
+	var results []*replayResult
+	require.NoError(t, replayBlocks(s, []*cltypes.SignedBeaconBlock{b0, b1}, true, func(res *replayResult) error {
+		results = append(results, res)
+		return nil
+	}))
+	require.Len(t, results, 2)
+	expectedRoot, err := postState.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, expectedRoot, [32]byte(results[1].StateRoot))
+	require.NotEmpty(t, results[1].BalanceDiffs)
+
+	out := &bytes.Buffer{}
+	printReplayResult(out, results[1], 1)
+	require.Contains(t, out.String(), "more")
+}
+
+func TestReplayStopsAtMismatch(t *testing.T) {
+	blocks, preState, _ := tests.GetPhase0Random()
+	s, err := preState.Copy()
+	require.NoError(t, err)
+	blocks[0].Block.StateRoot[0] ^= 0xff
+
+	calls := 0
+	err = replayBlocks(s, blocks, false, func(res *replayResult) error {
+		calls++
+		require.True(t, res.mismatch())
+		return nil
+	})
+	require.ErrorContains(t, err, "state root mismatch")
+	require.Equal(t, 1, calls)
+}
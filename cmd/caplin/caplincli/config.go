@@ -23,6 +23,7 @@ type CaplinCliCfg struct {
 	*sentinelcli.SentinelCliCfg
 
 	CheckpointUri         string        `json:"checkpoint_uri"`
+	CheckpointsToKeep     uint64        `json:"checkpoints_to_keep"`
 	Chaindata             string        `json:"chaindata"`
 	ErigonPrivateApi      string        `json:"erigon_private_api"`
 	TransitionChain       bool          `json:"transition_chain"`
@@ -103,6 +104,7 @@ func SetupCaplinCli(ctx *cli.Context) (cfg *CaplinCliCfg, err error) {
 	} else {
 		cfg.CheckpointUri = clparams.GetCheckpointSyncEndpoint(cfg.NetworkType)
 	}
+	cfg.CheckpointsToKeep = ctx.Uint64(utils.CaplinCheckpointsToKeepFlag.Name)
 
 	cfg.Chaindata = ctx.String(caplinflags.ChaindataFlag.Name)
 
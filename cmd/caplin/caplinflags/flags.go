@@ -26,6 +26,7 @@ var CliFlags = []cli.Flag{
 	&utils.BeaconApiAllowCredentialsFlag,
 	&utils.BeaconApiAllowMethodsFlag,
 	&utils.BeaconApiAllowOriginsFlag,
+	&utils.CaplinCheckpointsToKeepFlag,
 }
 
 var (
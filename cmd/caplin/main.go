@@ -90,6 +90,8 @@ func runCaplinNode(cliCtx *cli.Context) error {
 		NetworkConfig: cfg.NetworkCfg,
 		BeaconConfig:  cfg.BeaconCfg,
 		NoDiscovery:   cfg.NoDiscovery,
+
+		ActiveValidatorCount: uint64(len(state.GetActiveValidatorsIndices(state.Slot() / cfg.BeaconCfg.SlotsPerEpoch))),
 	}, nil, nil, &service.ServerConfig{Network: cfg.ServerProtocol, Addr: cfg.ServerAddr}, nil, &cltypes.Status{
 		ForkDigest:     forkDigest,
 		FinalizedRoot:  state.FinalizedCheckpoint().BlockRoot(),
@@ -141,5 +143,5 @@ func runCaplinNode(cliCtx *cli.Context) error {
 		AllowedOrigins:   cfg.AllowedOrigins,
 		AllowedMethods:   cfg.AllowedMethods,
 		AllowCredentials: cfg.AllowCredentials,
-	}, nil, nil, false, false, historyDB, indiciesDB, nil)
+	}, nil, nil, false, false, cfg.CheckpointsToKeep, historyDB, indiciesDB, nil)
 }
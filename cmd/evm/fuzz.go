@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/evm/internal/fuzz"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+var (
+	FuzzSeedFlag = cli.Int64Flag{
+		Name:  "seed",
+		Usage: "seed of the first generated case, the following ones use the next seeds",
+		Value: 1,
+	}
+	FuzzIterationsFlag = cli.IntFlag{
+		Name:  "iterations",
+		Usage: "number of cases to generate",
+		Value: 100,
+	}
+	FuzzCorpusFlag = cli.StringFlag{
+		Name:  "corpus",
+		Usage: "directory of the corpus: the cases to replay, or where to record generated cases and divergences",
+	}
+	FuzzRecordFlag = cli.BoolFlag{
+		Name:  "record",
+		Usage: "record the generated cases, along with their results, into the corpus",
+	}
+	FuzzReferenceFlag = cli.StringFlag{
+		Name:  "reference",
+		Usage: "binary accepting the flags of 'evm run' to compare the generated cases against",
+	}
+)
+
+var fuzzCommand = cli.Command{
+	Action: fuzzCmd,
+	Name:   "fuzz",
+	Usage:  "differentially fuzzes the EVM",
+	Description: `The fuzz command generates random programs and pre-states, and compares their traces
+and post-states with the ones of a reference binary, given with --reference, or with the
+ones recorded in a corpus, given with --corpus. Divergences from the reference binary are
+minimised and written to the corpus. Use --record to grow a corpus from generated cases.`,
+	Flags: []cli.Flag{
+		&FuzzSeedFlag,
+		&FuzzIterationsFlag,
+		&FuzzCorpusFlag,
+		&FuzzRecordFlag,
+		&FuzzReferenceFlag,
+	},
+}
+
+func fuzzCmd(ctx *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StderrHandler))
+	var (
+		corpus    = ctx.String(FuzzCorpusFlag.Name)
+		reference = ctx.String(FuzzReferenceFlag.Name)
+		seed      = ctx.Int64(FuzzSeedFlag.Name)
+		n         = ctx.Int(FuzzIterationsFlag.Name)
+	)
+	switch {
+	case reference != "":
+		return fuzzReference(reference, corpus, seed, n)
+	case corpus != "" && ctx.Bool(FuzzRecordFlag.Name):
+		return fuzzRecord(corpus, seed, n)
+	case corpus != "":
+		return fuzzReplay(corpus)
+	default:
+		return errors.New("either --reference or --corpus is required")
+	}
+}
+
+// fuzzReference compares the generated cases with the reference binary, and writes
+// the minimised divergences to the corpus.
+func fuzzReference(reference, corpus string, seed int64, n int) error {
+	gen := fuzz.NewGenerator(params.AllProtocolChanges)
+	var divergences int
+	for i := 0; i < n; i++ {
+		c := gen.Generate(seed + int64(i))
+		diff, err := diverge(reference, c)
+		if err != nil {
+			return fmt.Errorf("seed %d: %w", c.Seed, err)
+		}
+		if diff == "" {
+			continue
+		}
+		divergences++
+		log.Warn("Divergence", "seed", c.Seed, "diff", diff)
+		c = fuzz.Minimise(c, func(c *fuzz.Case) bool {
+			diff, err := diverge(reference, c)
+			return err == nil && diff != ""
+		})
+		if c.Expected, err = fuzz.RunReference(reference, c); err != nil {
+			return fmt.Errorf("seed %d: %w", c.Seed, err)
+		}
+		log.Warn("Minimised divergence", "seed", c.Seed, "code", fmt.Sprintf("%x", c.Code), "diff", fuzz.Compare(mustExecute(c), c.Expected))
+		if corpus != "" {
+			if err := writeCase(filepath.Join(corpus, fmt.Sprintf("divergence-%d.json", c.Seed)), c); err != nil {
+				return err
+			}
+		}
+	}
+	log.Info("Fuzzing done", "cases", n, "divergences", divergences)
+	if divergences > 0 {
+		return fmt.Errorf("%d divergences found", divergences)
+	}
+	return nil
+}
+
+// diverge returns the first difference between the in-process execution of the
+// case and its execution by the reference binary.
+func diverge(reference string, c *fuzz.Case) (string, error) {
+	have, err := fuzz.Execute(c)
+	if err != nil {
+		return "", err
+	}
+	want, err := fuzz.RunReference(reference, c)
+	if err != nil {
+		return "", err
+	}
+	return fuzz.Compare(have, want), nil
+}
+
+func mustExecute(c *fuzz.Case) *fuzz.Result {
+	res, err := fuzz.Execute(c)
+	if err != nil {
+		return &fuzz.Result{}
+	}
+	return res
+}
+
+// fuzzRecord writes the generated cases, along with their results, to the corpus.
+func fuzzRecord(corpus string, seed int64, n int) error {
+	if err := os.MkdirAll(corpus, 0755); err != nil {
+		return err
+	}
+	gen := fuzz.NewGenerator(params.AllProtocolChanges)
+	for i := 0; i < n; i++ {
+		c := gen.Generate(seed + int64(i))
+		res, err := fuzz.Execute(c)
+		if err != nil {
+			return fmt.Errorf("seed %d: %w", c.Seed, err)
+		}
+		c.Expected = res
+		if err := writeCase(filepath.Join(corpus, fmt.Sprintf("seed-%d.json", c.Seed)), c); err != nil {
+			return err
+		}
+	}
+	log.Info("Corpus recorded", "cases", n, "dir", corpus)
+	return nil
+}
+
+// fuzzReplay executes the cases of the corpus and compares them with their recorded results.
+func fuzzReplay(corpus string) error {
+	files, err := filepath.Glob(filepath.Join(corpus, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	var divergences int
+	for _, file := range files {
+		blob, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var c fuzz.Case
+		if err := json.Unmarshal(blob, &c); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if c.Expected == nil {
+			return fmt.Errorf("%s: no expected result", file)
+		}
+		res, err := fuzz.Execute(&c)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if diff := fuzz.Compare(res, c.Expected); diff != "" {
+			divergences++
+			log.Warn("Divergence", "file", file, "diff", diff)
+		}
+	}
+	log.Info("Corpus replayed", "cases", len(files), "divergences", divergences)
+	if divergences > 0 {
+		return fmt.Errorf("%d divergences found", divergences)
+	}
+	return nil
+}
+
+func writeCase(path string, c *fuzz.Case) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	blob, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, blob, 0644)
+}
@@ -0,0 +1,167 @@
+// Package fuzz generates random EVM programs and pre-states, executes them with
+// the struct logger, and compares the traces and post-states with the ones of a
+// reference implementation or of a stored corpus.
+package fuzz
+
+import (
+	"math/big"
+	"math/rand"
+	"strings"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+var (
+	// Sender is the origin of the generated calls.
+	Sender = libcommon.HexToAddress("0x00000000000000000000000000000000000f0000")
+	// Receiver is the account the generated code is executed at.
+	Receiver = libcommon.HexToAddress("0x00000000000000000000000000000000000f0001")
+)
+
+// Case is a single program to execute, along with the pre-state it runs against.
+// Cases stored in a corpus also hold the expected result of the execution.
+type Case struct {
+	Seed     int64            `json:"seed"`
+	Genesis  *types.Genesis   `json:"genesis"` // Chain config and pre-state, the gas limit is the gas of the call
+	Code     hexutility.Bytes `json:"code"`
+	Input    hexutility.Bytes `json:"input"`
+	Expected *Result          `json:"expected,omitempty"`
+}
+
+// Copy returns a deep copy of the case, without the expected result.
+func (c *Case) Copy() *Case {
+	cpy := &Case{Seed: c.Seed, Code: libcommon.Copy(c.Code), Input: libcommon.Copy(c.Input)}
+	genesis := *c.Genesis
+	genesis.Alloc = make(types.GenesisAlloc, len(c.Genesis.Alloc))
+	for addr, account := range c.Genesis.Alloc {
+		storage := make(map[libcommon.Hash]libcommon.Hash, len(account.Storage))
+		for k, v := range account.Storage {
+			storage[k] = v
+		}
+		account.Storage = storage
+		account.Code = libcommon.Copy(account.Code)
+		genesis.Alloc[addr] = account
+	}
+	cpy.Genesis = &genesis
+	return cpy
+}
+
+// Generator produces random cases. The cases only depend on the seed they are
+// generated from, so that any case can be reproduced from its seed.
+type Generator struct {
+	Config *chain.Config
+	ops    []vm.OpCode // Defined opcodes, undefined ones are generated sparingly
+}
+
+// NewGenerator returns a generator of cases running against the given chain config.
+func NewGenerator(config *chain.Config) *Generator {
+	g := &Generator{Config: config}
+	for op := 0; op < 256; op++ {
+		if !strings.HasPrefix(vm.OpCode(op).String(), "opcode ") {
+			g.ops = append(g.ops, vm.OpCode(op))
+		}
+	}
+	return g
+}
+
+// Generate returns the case of the given seed.
+func (g *Generator) Generate(seed int64) *Case {
+	rnd := rand.New(rand.NewSource(seed)) //nolint:gosec
+	addrs := []libcommon.Address{Receiver}
+	for i := rnd.Intn(4); i > 0; i-- {
+		addrs = append(addrs, libcommon.BigToAddress(big.NewInt(0xf0002+int64(i))))
+	}
+	alloc := types.GenesisAlloc{
+		Sender: {Balance: new(big.Int).Lsh(big.NewInt(1), 100)},
+	}
+	for _, addr := range addrs {
+		account := types.GenesisAccount{
+			Balance: big.NewInt(rnd.Int63n(1_000_000)),
+			Nonce:   uint64(rnd.Intn(3)),
+			Storage: map[libcommon.Hash]libcommon.Hash{},
+		}
+		if addr != Receiver {
+			account.Code = g.code(rnd, addrs, 1+rnd.Intn(32))
+		}
+		for i := rnd.Intn(4); i > 0; i-- {
+			account.Storage[libcommon.BigToHash(big.NewInt(int64(rnd.Intn(8))))] = libcommon.BigToHash(big.NewInt(rnd.Int63n(4)))
+		}
+		alloc[addr] = account
+	}
+	input := make([]byte, rnd.Intn(69))
+	rnd.Read(input)
+	return &Case{
+		Seed: seed,
+		Genesis: &types.Genesis{
+			Config:     g.Config,
+			Alloc:      alloc,
+			GasLimit:   10_000 + uint64(rnd.Int63n(1_000_000)),
+			Difficulty: big.NewInt(0),
+		},
+		Code:  g.code(rnd, addrs, 1+rnd.Intn(128)),
+		Input: input,
+	}
+}
+
+// code generates a program of n instructions. The push arguments are biased towards
+// small numbers, memory offsets and the addresses of the pre-state, so that memory
+// accesses, jumps and calls have a chance to succeed.
+func (g *Generator) code(rnd *rand.Rand, addrs []libcommon.Address, n int) []byte {
+	var code []byte
+	for i := 0; i < n; i++ {
+		var op vm.OpCode
+		switch r := rnd.Intn(100); {
+		case r < 35:
+			op = vm.PUSH1 + vm.OpCode(rnd.Intn(32))
+		case r < 36:
+			op = vm.OpCode(rnd.Intn(256))
+		default:
+			op = g.ops[rnd.Intn(len(g.ops))]
+		}
+		code = append(code, byte(op))
+		if !op.IsPush() {
+			continue
+		}
+		size := int(op - vm.PUSH1 + 1)
+		var arg uint256.Int
+		switch rnd.Intn(5) {
+		case 0:
+			arg.SetUint64(uint64(rnd.Intn(4)))
+		case 1:
+			arg.SetUint64(uint64(rnd.Intn(256)))
+		case 2:
+			arg.SetBytes(addrs[rnd.Intn(len(addrs))].Bytes())
+		case 3:
+			arg.SetAllOne()
+		default:
+			arg.SetUint64(rnd.Uint64())
+		}
+		b := arg.Bytes32()
+		code = append(code, b[32-size:]...)
+	}
+	return code
+}
+
+// instructions splits code into its instructions, each push with its argument.
+func instructions(code []byte) [][]byte {
+	var res [][]byte
+	for pc := 0; pc < len(code); {
+		op := vm.OpCode(code[pc])
+		end := pc + 1
+		if op.IsPush() {
+			end += int(op - vm.PUSH1 + 1)
+		}
+		if end > len(code) {
+			end = len(code)
+		}
+		res = append(res, code[pc:end])
+		pc = end
+	}
+	return res
+}
@@ -0,0 +1,263 @@
+package fuzz
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/core/vm/runtime"
+	"github.com/ledgerwatch/erigon/eth/tracers/logger"
+)
+
+// Step is an execution step of the trace, limited to the fields every
+// implementation reports the same way.
+type Step struct {
+	Pc      uint64   `json:"pc"`
+	Op      string   `json:"op"`
+	Gas     uint64   `json:"gas"`
+	GasCost uint64   `json:"gasCost"`
+	Depth   int      `json:"depth"`
+	Stack   []string `json:"stack"`
+}
+
+// Account is an account of the post-state. Numbers are normalised to lower case
+// hexadecimal without leading zeros, and zero storage slots are omitted.
+type Account struct {
+	Balance string            `json:"balance"`
+	Nonce   uint64            `json:"nonce"`
+	Code    hexutility.Bytes  `json:"code,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+}
+
+// Result is the outcome of the execution of a case.
+type Result struct {
+	Trace   []Step                        `json:"trace"`
+	Output  hexutility.Bytes              `json:"output"`
+	GasUsed uint64                        `json:"gasUsed"`
+	Failed  bool                          `json:"failed"`
+	Post    map[libcommon.Address]Account `json:"post"`
+}
+
+// Execute runs the case in-process the same way `evm run` does, tracing it with
+// the struct logger.
+func Execute(c *Case) (*Result, error) {
+	db := memdb.New("")
+	defer db.Close()
+	core.MustCommitGenesis(c.Genesis, db, "", log.New())
+	tx, err := db.BeginRw(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	statedb := state.New(state.NewPlainStateReader(tx))
+	statedb.CreateAccount(Sender, true)
+	if len(c.Code) > 0 {
+		statedb.SetCode(Receiver, c.Code)
+	}
+	tracer := logger.NewStructLogger(&logger.LogConfig{DisableMemory: true, DisableStorage: true, DisableReturnData: true})
+	cfg := runtime.Config{
+		Origin:      Sender,
+		State:       statedb,
+		GasLimit:    c.Genesis.GasLimit,
+		GasPrice:    new(uint256.Int),
+		Value:       new(uint256.Int),
+		Difficulty:  c.Genesis.Difficulty,
+		Time:        new(big.Int).SetUint64(c.Genesis.Timestamp),
+		Coinbase:    c.Genesis.Coinbase,
+		BlockNumber: new(big.Int).SetUint64(c.Genesis.Number),
+		ChainConfig: c.Genesis.Config,
+		EVMConfig:   vm.Config{Debug: true, Tracer: tracer},
+	}
+	output, gasLeft, err := runtime.Call(Receiver, c.Input, &cfg)
+
+	res := &Result{Output: output, GasUsed: c.Genesis.GasLimit - gasLeft, Failed: err != nil}
+	logs := tracer.StructLogs()
+	res.Trace = make([]Step, len(logs))
+	for i := range logs {
+		res.Trace[i] = stepFromLog(&logs[i])
+	}
+
+	rules := c.Genesis.Config.Rules(c.Genesis.Number, c.Genesis.Timestamp)
+	if err := statedb.CommitBlock(rules, state.NewPlainStateWriterNoHistory(tx)); err != nil {
+		return nil, err
+	}
+	res.Post = postFromDump(state.NewDumper(tx, c.Genesis.Number+1, false).RawDump(false, false))
+	return res, nil
+}
+
+// RunReference runs the case with `<bin> run`, which must accept the flags of
+// `evm run` and print the JSON trace and state dump to its standard output.
+func RunReference(bin string, c *Case) (*Result, error) {
+	dir, err := os.MkdirTemp("", "evm-fuzz")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	prestate := filepath.Join(dir, "prestate.json")
+	genesis, err := json.Marshal(c.Genesis)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(prestate, genesis, 0644); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(bin, //nolint:gosec
+		"--json", "--nomemory", "--nostorage", "--noreturndata", "--dump",
+		"--prestate", prestate,
+		"--sender", Sender.Hex(),
+		"--receiver", Receiver.Hex(),
+		"--gas", strconv.FormatUint(c.Genesis.GasLimit, 10),
+		"--code", hex.EncodeToString(c.Code),
+		"--input", hex.EncodeToString(c.Input),
+		"run")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", bin, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseReference(&stdout)
+}
+
+// parseReference decodes the stream of JSON objects printed by `evm run --json --dump`:
+// one object per step, the result of the call and the dump of the post-state.
+func parseReference(r io.Reader) (*Result, error) {
+	res := &Result{}
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid reference output: %w", err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, fmt.Errorf("invalid reference output: %w", err)
+		}
+		switch {
+		case fields["pc"] != nil:
+			var l logger.StructLog
+			if err := json.Unmarshal(raw, &l); err != nil {
+				return nil, fmt.Errorf("invalid reference step: %w", err)
+			}
+			res.Trace = append(res.Trace, stepFromLog(&l))
+		case fields["gasUsed"] != nil:
+			var end struct {
+				Output  string              `json:"output"`
+				GasUsed math.HexOrDecimal64 `json:"gasUsed"`
+				Error   string              `json:"error"`
+			}
+			if err := json.Unmarshal(raw, &end); err != nil {
+				return nil, fmt.Errorf("invalid reference result: %w", err)
+			}
+			res.Output = libcommon.FromHex(end.Output)
+			res.GasUsed, res.Failed = uint64(end.GasUsed), end.Error != ""
+		case fields["accounts"] != nil:
+			var dump state.Dump
+			if err := json.Unmarshal(raw, &dump); err != nil {
+				return nil, fmt.Errorf("invalid reference dump: %w", err)
+			}
+			res.Post = postFromDump(dump)
+		}
+	}
+	return res, nil
+}
+
+func stepFromLog(l *logger.StructLog) Step {
+	step := Step{Pc: l.Pc, Op: l.Op.String(), Gas: l.Gas, GasCost: l.GasCost, Depth: l.Depth, Stack: make([]string, len(l.Stack))}
+	for i, v := range l.Stack {
+		step.Stack[i] = "0x" + v.Text(16)
+	}
+	return step
+}
+
+func postFromDump(dump state.Dump) map[libcommon.Address]Account {
+	post := make(map[libcommon.Address]Account, len(dump.Accounts))
+	for addr, account := range dump.Accounts {
+		balance, ok := new(big.Int).SetString(account.Balance, 0)
+		if !ok {
+			balance = new(big.Int)
+		}
+		// The dump may reference the memory of the database, which doesn't outlive the execution
+		acc := Account{Balance: "0x" + balance.Text(16), Nonce: account.Nonce, Code: libcommon.Copy(account.Code)}
+		for k, v := range account.Storage {
+			if v = normaliseHex(v); v != "0x0" {
+				if acc.Storage == nil {
+					acc.Storage = map[string]string{}
+				}
+				acc.Storage[normaliseHex(k)] = v
+			}
+		}
+		post[addr] = acc
+	}
+	return post
+}
+
+func normaliseHex(s string) string {
+	s = strings.TrimLeft(strings.TrimPrefix(strings.ToLower(s), "0x"), "0")
+	if s == "" {
+		return "0x0"
+	}
+	return "0x" + s
+}
+
+// Compare returns a description of the first difference between the results,
+// or an empty string if they are the same.
+func Compare(have, want *Result) string {
+	for i := 0; i < len(have.Trace) && i < len(want.Trace); i++ {
+		h, w := have.Trace[i], want.Trace[i]
+		if h.Pc != w.Pc || h.Op != w.Op || h.Gas != w.Gas || h.GasCost != w.GasCost || h.Depth != w.Depth || !slices.Equal(h.Stack, w.Stack) {
+			return fmt.Sprintf("step %d: have %+v, want %+v", i, h, w)
+		}
+	}
+	if len(have.Trace) != len(want.Trace) {
+		return fmt.Sprintf("trace length: have %d steps, want %d", len(have.Trace), len(want.Trace))
+	}
+	if have.Failed != want.Failed {
+		return fmt.Sprintf("failed: have %t, want %t", have.Failed, want.Failed)
+	}
+	if have.GasUsed != want.GasUsed {
+		return fmt.Sprintf("gas used: have %d, want %d", have.GasUsed, want.GasUsed)
+	}
+	if !bytes.Equal(have.Output, want.Output) {
+		return fmt.Sprintf("output: have %x, want %x", have.Output, want.Output)
+	}
+	for addr, w := range want.Post {
+		h, ok := have.Post[addr]
+		if !ok {
+			return fmt.Sprintf("post-state: missing account %x", addr)
+		}
+		if h.Balance != w.Balance || h.Nonce != w.Nonce || !bytes.Equal(h.Code, w.Code) || !maps.Equal(h.Storage, w.Storage) {
+			return fmt.Sprintf("post-state of %x: have %+v, want %+v", addr, h, w)
+		}
+	}
+	for addr := range have.Post {
+		if _, ok := want.Post[addr]; !ok {
+			return fmt.Sprintf("post-state: unexpected account %x", addr)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package fuzz
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+func TestGenerateDeterministic(t *testing.T) {
+	gen := NewGenerator(params.AllProtocolChanges)
+	for seed := int64(0); seed < 20; seed++ {
+		a, err := json.Marshal(gen.Generate(seed))
+		require.NoError(t, err)
+		b, err := json.Marshal(gen.Generate(seed))
+		require.NoError(t, err)
+		require.Equal(t, a, b, "seed %d", seed)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	c := NewGenerator(params.AllProtocolChanges).Generate(1)
+	// SSTORE(1, 2), then RETURN the word at memory 0
+	c.Code = []byte{byte(vm.PUSH1), 2, byte(vm.PUSH1), 1, byte(vm.SSTORE), byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN)}
+
+	res, err := Execute(c)
+	require.NoError(t, err)
+	require.False(t, res.Failed)
+	require.Equal(t, make([]byte, 32), []byte(res.Output))
+	require.Len(t, res.Trace, 6)
+	require.Equal(t, "SSTORE", res.Trace[2].Op)
+	require.Equal(t, []string{"0x2", "0x1"}, res.Trace[2].Stack)
+	require.Equal(t, "0x2", res.Post[Receiver].Storage["0x1"])
+
+	again, err := Execute(c)
+	require.NoError(t, err)
+	require.Empty(t, Compare(res, again))
+
+	again.Post[Receiver].Storage["0x1"] = "0x3"
+	require.Contains(t, Compare(res, again), "post-state")
+}
+
+func TestMinimise(t *testing.T) {
+	c := NewGenerator(params.AllProtocolChanges).Generate(2)
+	// Diverges as long as the program stores something
+	diverges := func(c *Case) bool {
+		for _, ins := range instructions(c.Code) {
+			if vm.OpCode(ins[0]) == vm.SSTORE {
+				return true
+			}
+		}
+		return false
+	}
+	c.Code = append(c.Code, byte(vm.SSTORE))
+	min := Minimise(c, diverges)
+	require.Equal(t, []byte{byte(vm.SSTORE)}, []byte(min.Code))
+	require.Empty(t, min.Input)
+	require.Len(t, min.Genesis.Alloc, 1)
+	require.Contains(t, min.Genesis.Alloc, Sender)
+	// The original case is left untouched
+	require.NotEqual(t, min.Code, c.Code)
+}
+
+func TestParseReference(t *testing.T) {
+	// Output of `evm run --json --nomemory --nostorage --noreturndata --dump --code 600260015500 run`
+	out := `{"pc":0,"op":96,"gas":"0x186a0","gasCost":"0x3","memory":"0x","memSize":0,"stack":[],"returnStack":null,"returnData":"0x","depth":1,"refund":0,"opName":"PUSH1","error":""}
+{"pc":2,"op":96,"gas":"0x1869d","gasCost":"0x3","memory":"0x","memSize":0,"stack":["0x2"],"returnStack":null,"returnData":"0x","depth":1,"refund":0,"opName":"PUSH1","error":""}
+{"pc":4,"op":85,"gas":"0x1869a","gasCost":"0x5654","memory":"0x","memSize":0,"stack":["0x2","0x1"],"returnStack":null,"returnData":"0x","depth":1,"refund":0,"opName":"SSTORE","error":""}
+{"pc":5,"op":0,"gas":"0x13046","gasCost":"0x0","memory":"0x","memSize":0,"stack":[],"returnStack":null,"returnData":"0x","depth":1,"refund":0,"opName":"STOP","error":""}
+{"output":"","gasUsed":"0x565a"}
+{
+    "root": "0000000000000000000000000000000000000000000000000000000000000000",
+    "accounts": {
+        "0x0000000000000000000000007265636569766572": {
+            "balance": "0",
+            "nonce": 0,
+            "root": "0x6302d6aa5cf8befc2c23254172197534a8639fc400eb7a11fedbb44c388e2967",
+            "codeHash": "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+            "storage": {
+                "0x0000000000000000000000000000000000000000000000000000000000000001": "02"
+            }
+        }
+    }
+}
+`
+	res, err := parseReference(strings.NewReader(out))
+	require.NoError(t, err)
+	require.Len(t, res.Trace, 4)
+	require.Equal(t, Step{Pc: 4, Op: "SSTORE", Gas: 0x1869a, GasCost: 0x5654, Depth: 1, Stack: []string{"0x2", "0x1"}}, res.Trace[2])
+	require.Equal(t, uint64(0x565a), res.GasUsed)
+	require.False(t, res.Failed)
+	require.Equal(t, map[libcommon.Address]Account{
+		libcommon.HexToAddress("0x0000000000000000000000007265636569766572"): {Balance: "0x0", Storage: map[string]string{"0x1": "0x2"}},
+	}, res.Post)
+}
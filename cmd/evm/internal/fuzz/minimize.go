@@ -0,0 +1,67 @@
+package fuzz
+
+import (
+	"bytes"
+)
+
+// Minimise returns the smallest case it finds for which diverges still holds, by
+// removing instructions of the program, accounts and storage slots of the
+// pre-state and the input of the call.
+func Minimise(c *Case, diverges func(*Case) bool) *Case {
+	c = c.Copy()
+	for progress := true; progress; {
+		progress = false
+		if code, ok := shrinkCode(c, diverges); ok {
+			c.Code, progress = code, true
+		}
+		for addr := range c.Genesis.Alloc {
+			if addr == Sender {
+				continue
+			}
+			candidate := c.Copy()
+			delete(candidate.Genesis.Alloc, addr)
+			if diverges(candidate) {
+				c, progress = candidate, true
+				continue
+			}
+			for key := range c.Genesis.Alloc[addr].Storage {
+				candidate := c.Copy()
+				delete(candidate.Genesis.Alloc[addr].Storage, key)
+				if diverges(candidate) {
+					c, progress = candidate, true
+				}
+			}
+		}
+		if len(c.Input) > 0 {
+			candidate := c.Copy()
+			candidate.Input = nil
+			if diverges(candidate) {
+				c, progress = candidate, true
+			}
+		}
+	}
+	return c
+}
+
+// shrinkCode removes chunks of instructions from the program, halving the size
+// of the chunks down to single instructions, as long as the case diverges.
+func shrinkCode(c *Case, diverges func(*Case) bool) ([]byte, bool) {
+	ins := instructions(c.Code)
+	shrunk := false
+	for chunk := len(ins) / 2; chunk > 0; chunk /= 2 {
+		for start := 0; start+chunk <= len(ins); {
+			candidate := c.Copy()
+			candidate.Code = bytes.Join(append(append([][]byte{}, ins[:start]...), ins[start+chunk:]...), nil)
+			if diverges(candidate) {
+				ins = append(ins[:start:start], ins[start+chunk:]...)
+				shrunk = true
+				continue
+			}
+			start += chunk
+		}
+	}
+	if !shrunk {
+		return nil, false
+	}
+	return bytes.Join(ins, nil), true
+}
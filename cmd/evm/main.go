@@ -93,6 +93,10 @@ var (
 		Name:  "bench",
 		Usage: "benchmark the execution",
 	}
+	GasProfileFlag = cli.StringFlag{
+		Name:  "gasprofile",
+		Usage: "writes a gas profile of the execution to the given path in folded stack format for flamegraph tools, and as JSON to <path>.json",
+	}
 	CreateFlag = cli.BoolFlag{
 		Name:  "create",
 		Usage: "indicates the action should be create rather than call",
@@ -157,6 +161,7 @@ var stateTransitionCommand = cli.Command{
 func init() {
 	app.Flags = []cli.Flag{
 		&BenchFlag,
+		&GasProfileFlag,
 		&CreateFlag,
 		&DebugFlag,
 		&VerbosityFlag,
@@ -183,6 +188,7 @@ func init() {
 	app.Commands = []*cli.Command{
 		&compileCommand,
 		&disasmCommand,
+		&fuzzCommand,
 		&runCommand,
 		&stateTestCommand,
 		&stateTransitionCommand,
@@ -26,6 +26,7 @@ import (
 	"os"
 	goruntime "runtime"
 	"runtime/pprof"
+	"strings"
 	"testing"
 	"time"
 
@@ -47,7 +48,9 @@ import (
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/core/vm/runtime"
+	"github.com/ledgerwatch/erigon/eth/tracers"
 	"github.com/ledgerwatch/erigon/eth/tracers/logger"
+	_ "github.com/ledgerwatch/erigon/eth/tracers/native"
 	"github.com/ledgerwatch/erigon/params"
 )
 
@@ -166,12 +169,9 @@ func runCmd(ctx *cli.Context) error {
 	}
 	defer tx.Rollback()
 
-	statedb = state.New(state.NewPlainStateReader(tx))
 	if ctx.String(SenderFlag.Name) != "" {
 		sender = libcommon.HexToAddress(ctx.String(SenderFlag.Name))
 	}
-	statedb.CreateAccount(sender, true)
-
 	if ctx.String(ReceiverFlag.Name) != "" {
 		receiver = libcommon.HexToAddress(ctx.String(ReceiverFlag.Name))
 	}
@@ -220,6 +220,17 @@ func runCmd(ctx *cli.Context) error {
 		}
 		code = libcommon.Hex2Bytes(bin)
 	}
+	create := ctx.Bool(CreateFlag.Name)
+	// newPreState builds the state before the execution on top of the genesis alloc
+	newPreState := func() *state.IntraBlockState {
+		ibs := state.New(state.NewPlainStateReader(tx))
+		ibs.CreateAccount(sender, true)
+		if !create && len(code) > 0 {
+			ibs.SetCode(receiver, code)
+		}
+		return ibs
+	}
+	statedb = newPreState()
 	initialGas := ctx.Uint64(GasFlag.Name)
 	if genesisConfig.GasLimit != 0 {
 		initialGas = genesisConfig.GasLimit
@@ -273,31 +284,57 @@ func runCmd(ctx *cli.Context) error {
 	}
 	input := hexutility.MustDecodeHex(string(bytes.TrimSpace(hexInput)))
 
-	var execFunc func() ([]byte, uint64, error)
-	if ctx.Bool(CreateFlag.Name) {
+	var execute func(cfg *runtime.Config) ([]byte, uint64, error)
+	if create {
 		input = append(code, input...)
-		execFunc = func() ([]byte, uint64, error) {
-			output, _, gasLeft, err := runtime.Create(input, &runtimeConfig, 0)
+		execute = func(cfg *runtime.Config) ([]byte, uint64, error) {
+			output, _, gasLeft, err := runtime.Create(input, cfg, 0)
 			return output, gasLeft, err
 		}
 	} else {
-		if len(code) > 0 {
-			statedb.SetCode(receiver, code)
-		}
-		execFunc = func() ([]byte, uint64, error) {
-			return runtime.Call(receiver, input, &runtimeConfig)
+		execute = func(cfg *runtime.Config) ([]byte, uint64, error) {
+			return runtime.Call(receiver, input, cfg)
 		}
 	}
+	execFunc := func() ([]byte, uint64, error) {
+		return execute(&runtimeConfig)
+	}
 
 	bench := ctx.Bool(BenchFlag.Name)
+	var profiler tracers.Tracer
+	if ctx.String(GasProfileFlag.Name) != "" {
+		if profiler, err = tracers.New("gasProfiler", new(tracers.Context), nil); err != nil {
+			return err
+		}
+		if !bench {
+			withTracer(&runtimeConfig, profiler)
+		}
+	}
+	if profiler != nil && bench {
+		// Profile a single execution on its own copy of the pre-state, so that tracing
+		// doesn't skew the benchmark and the benchmark runs don't change what is profiled
+		profileConfig := runtimeConfig
+		profileConfig.State = newPreState()
+		withTracer(&profileConfig, profiler)
+		if _, _, profileErr := execute(&profileConfig); profileErr != nil {
+			log.Warn("Profiled execution failed", "err", profileErr)
+		}
+	}
 	output, leftOverGas, stats, err := timedExec(bench, execFunc)
+	if profiler != nil {
+		if err := writeGasProfile(ctx.String(GasProfileFlag.Name), profiler); err != nil {
+			fmt.Println("could not write gas profile: ", err)
+			os.Exit(1)
+		}
+	}
 
 	if ctx.Bool(DumpFlag.Name) {
 		rules := &chain.Rules{}
 		if chainConfig != nil {
 			rules = chainConfig.Rules(runtimeConfig.BlockNumber.Uint64(), runtimeConfig.Time.Uint64())
 		}
-		if err = statedb.CommitBlock(rules, state.NewNoopWriter()); err != nil {
+		// Write the changes to the plain state, so that the dump shows the post-state
+		if err = statedb.CommitBlock(rules, state.NewPlainStateWriterNoHistory(tx)); err != nil {
 			fmt.Println("Could not commit state: ", err)
 			os.Exit(1)
 		}
@@ -305,7 +342,8 @@ func runCmd(ctx *cli.Context) error {
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(state.NewDumper(tx, 0, historyV3).DefaultDump()))
+		// Dump as of the next block, block 0 holds the changesets of the genesis storage
+		fmt.Println(string(state.NewDumper(tx, runtimeConfig.BlockNumber.Uint64()+1, historyV3).DefaultDump()))
 	}
 
 	if memProfilePath := ctx.String(MemProfileFlag.Name); memProfilePath != "" {
@@ -358,3 +396,35 @@ allocated bytes: %d
 
 	return nil
 }
+
+// withTracer adds the tracer to the tracers of the runtime config.
+func withTracer(cfg *runtime.Config, tracer vm.EVMLogger) {
+	if cfg.EVMConfig.Tracer != nil {
+		tracer = vm.NewMuxLogger(cfg.EVMConfig.Tracer, tracer)
+	}
+	cfg.EVMConfig.Tracer = tracer
+	cfg.EVMConfig.Debug = true
+}
+
+// writeGasProfile writes the result of the gas profiler to path in folded stack
+// format, and to path.json as JSON.
+func writeGasProfile(path string, profiler tracers.Tracer) error {
+	res, err := profiler.GetResult()
+	if err != nil {
+		return err
+	}
+	var profile struct {
+		Folded []string `json:"folded"`
+	}
+	if err := json.Unmarshal(res, &profile); err != nil {
+		return err
+	}
+	folded := strings.Join(profile.Folded, "\n")
+	if len(profile.Folded) > 0 {
+		folded += "\n"
+	}
+	if err := os.WriteFile(path, []byte(folded), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(path+".json", res, 0644)
+}
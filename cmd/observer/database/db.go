@@ -53,6 +53,10 @@ type DB interface {
 
 	UpdateForkCompatibility(ctx context.Context, id NodeID, isCompatFork bool) error
 
+	UpsertENR(ctx context.Context, id NodeID, enr string) error
+	// FindLiveENRs returns the latest records of the live nodes known to be fork-compatible.
+	FindLiveENRs(ctx context.Context, maxPingTries uint, networkID uint, limit uint) ([]string, error)
+
 	UpdateNeighborBucketKeys(ctx context.Context, id NodeID, keys []string) error
 	FindNeighborBucketKeys(ctx context.Context, id NodeID) ([]string, error)
 
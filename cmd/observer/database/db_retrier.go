@@ -198,6 +198,25 @@ func (db DBRetrier) UpdateForkCompatibility(ctx context.Context, id NodeID, isCo
 	return err
 }
 
+func (db DBRetrier) UpsertENR(ctx context.Context, id NodeID, enr string) error {
+	_, err := db.retry(ctx, "UpsertENR", func(ctx context.Context) (interface{}, error) {
+		return nil, db.db.UpsertENR(ctx, id, enr)
+	})
+	return err
+}
+
+func (db DBRetrier) FindLiveENRs(ctx context.Context, maxPingTries uint, networkID uint, limit uint) ([]string, error) {
+	resultAny, err := db.retry(ctx, "FindLiveENRs", func(ctx context.Context) (interface{}, error) {
+		return db.db.FindLiveENRs(ctx, maxPingTries, networkID, limit)
+	})
+
+	if resultAny == nil {
+		return nil, err
+	}
+	result := resultAny.([]string)
+	return result, err
+}
+
 func (db DBRetrier) UpdateNeighborBucketKeys(ctx context.Context, id NodeID, keys []string) error {
 	_, err := db.retry(ctx, "UpdateNeighborBucketKeys", func(ctx context.Context) (interface{}, error) {
 		return nil, db.db.UpdateNeighborBucketKeys(ctx, id, keys)
@@ -56,6 +56,12 @@ CREATE TABLE IF NOT EXISTS handshake_errors (
     updated INTEGER NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS enrs (
+    id TEXT PRIMARY KEY,
+    enr TEXT NOT NULL,
+    updated INTEGER NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS sentry_candidates_intake (
     id INTEGER PRIMARY KEY,
     last_event_time INTEGER NOT NULL
@@ -195,6 +201,27 @@ UPDATE nodes SET handshake_retry_time = ? WHERE id IN (123)
 
 	sqlUpdateForkCompatibility = `
 UPDATE nodes SET compat_fork = ?, compat_fork_updated = ? WHERE id = ?
+`
+
+	sqlUpsertENR = `
+INSERT INTO enrs(
+	id,
+	enr,
+	updated
+) VALUES (?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+	enr = excluded.enr,
+	updated = excluded.updated
+`
+
+	sqlFindLiveENRs = `
+SELECT enrs.enr FROM enrs
+JOIN nodes ON nodes.id = enrs.id
+WHERE (nodes.ping_try < ?)
+	AND ((nodes.network_id = ?) OR (nodes.network_id IS NULL))
+	AND (nodes.compat_fork == TRUE)
+ORDER BY enrs.updated DESC
+LIMIT ?
 `
 
 	sqlUpdateNeighborBucketKeys = `
@@ -685,6 +712,46 @@ func (db *DBSQLite) UpdateForkCompatibility(ctx context.Context, id NodeID, isCo
 	return nil
 }
 
+func (db *DBSQLite) UpsertENR(ctx context.Context, id NodeID, enr string) error {
+	updated := time.Now().Unix()
+
+	_, err := db.db.ExecContext(ctx, sqlUpsertENR, id, enr, updated)
+	if err != nil {
+		return fmt.Errorf("UpsertENR failed: %w", err)
+	}
+	return nil
+}
+
+func (db *DBSQLite) FindLiveENRs(ctx context.Context, maxPingTries uint, networkID uint, limit uint) ([]string, error) {
+	cursor, err := db.db.QueryContext(
+		ctx,
+		sqlFindLiveENRs,
+		maxPingTries,
+		networkID,
+		limit)
+	if err != nil {
+		return nil, fmt.Errorf("FindLiveENRs failed to query: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close()
+	}()
+
+	var enrs []string
+	for cursor.Next() {
+		var enr string
+		err := cursor.Scan(&enr)
+		if err != nil {
+			return nil, fmt.Errorf("FindLiveENRs failed to read data: %w", err)
+		}
+		enrs = append(enrs, enr)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("FindLiveENRs failed to iterate over rows: %w", err)
+	}
+	return enrs, nil
+}
+
 func (db *DBSQLite) UpdateNeighborBucketKeys(ctx context.Context, id NodeID, keys []string) error {
 	keysStr := strings.Join(keys, ",")
 
@@ -0,0 +1,151 @@
+package dnstree
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/utils"
+)
+
+type CommandFlags struct {
+	DataDir      string
+	Chain        string
+	MaxPingTries uint
+	NodesLimit   uint
+
+	Domain  string
+	KeyPath string
+	Seq     uint
+	Links   []string
+
+	Format     string
+	OutputPath string
+}
+
+type Command struct {
+	command cobra.Command
+	flags   CommandFlags
+}
+
+func NewCommand() *Command {
+	command := cobra.Command{
+		Use:   "dnstree",
+		Short: "Generate a signed EIP-1459 DNS discovery tree of the crawled nodes",
+	}
+
+	instance := Command{
+		command: command,
+	}
+	instance.withDatadir()
+	instance.withChain()
+	instance.withMaxPingTries()
+	instance.withNodesLimit()
+	instance.withDomain()
+	instance.withKeyPath()
+	instance.withSeq()
+	instance.withLinks()
+	instance.withFormat()
+	instance.withOutputPath()
+
+	return &instance
+}
+
+func (command *Command) withDatadir() {
+	flag := utils.DataDirFlag
+	command.command.Flags().StringVar(&command.flags.DataDir, flag.Name, flag.Value.String(), flag.Usage)
+	must(command.command.MarkFlagDirname(utils.DataDirFlag.Name))
+}
+
+func (command *Command) withChain() {
+	flag := utils.ChainFlag
+	command.command.Flags().StringVar(&command.flags.Chain, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withMaxPingTries() {
+	flag := cli.UintFlag{
+		Name:  "max-ping-tries",
+		Usage: "A number of PING failures for a node to be considered dead",
+		Value: 3,
+	}
+	command.command.Flags().UintVar(&command.flags.MaxPingTries, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withNodesLimit() {
+	flag := cli.UintFlag{
+		Name:  "nodes-limit",
+		Usage: "A maximum number of nodes in the tree, the most recently seen ones are taken",
+		Value: 1000,
+	}
+	command.command.Flags().UintVar(&command.flags.NodesLimit, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withDomain() {
+	flag := cli.StringFlag{
+		Name:  "domain",
+		Usage: "The domain name of the tree root, e.g. nodes.example.org",
+	}
+	command.command.Flags().StringVar(&command.flags.Domain, flag.Name, flag.Value, flag.Usage)
+	must(command.command.MarkFlagRequired(flag.Name))
+}
+
+func (command *Command) withKeyPath() {
+	flag := cli.StringFlag{
+		Name:  "key",
+		Usage: "The path of the hex encoded private key to sign the tree root",
+	}
+	command.command.Flags().StringVar(&command.flags.KeyPath, flag.Name, flag.Value, flag.Usage)
+	must(command.command.MarkFlagFilename(flag.Name))
+	must(command.command.MarkFlagRequired(flag.Name))
+}
+
+func (command *Command) withSeq() {
+	flag := cli.UintFlag{
+		Name:  "seq",
+		Usage: "The sequence number of the tree, it has to grow with every update. The current unix time if not set.",
+	}
+	command.command.Flags().UintVar(&command.flags.Seq, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withLinks() {
+	flag := cli.StringSliceFlag{
+		Name:  "link",
+		Usage: "An enrtree:// URL of another tree to link, can be repeated",
+	}
+	command.command.Flags().StringSliceVar(&command.flags.Links, flag.Name, nil, flag.Usage)
+}
+
+func (command *Command) withFormat() {
+	flag := cli.StringFlag{
+		Name:  "format",
+		Usage: "The output format: 'zone' for a DNS zone file, 'json' for a map of the TXT records by name",
+		Value: FormatZone,
+	}
+	command.command.Flags().StringVar(&command.flags.Format, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withOutputPath() {
+	flag := cli.StringFlag{
+		Name:  "output",
+		Usage: "The output file path, stdout if not set",
+	}
+	command.command.Flags().StringVar(&command.flags.OutputPath, flag.Name, flag.Value, flag.Usage)
+	must(command.command.MarkFlagFilename(flag.Name))
+}
+
+func (command *Command) RawCommand() *cobra.Command {
+	return &command.command
+}
+
+func (command *Command) OnRun(runFunc func(ctx context.Context, flags CommandFlags) error) {
+	command.command.RunE = func(cmd *cobra.Command, args []string) error {
+		return runFunc(cmd.Context(), command.flags)
+	}
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
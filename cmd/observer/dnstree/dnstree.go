@@ -0,0 +1,104 @@
+package dnstree
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/p2p/dnsdisc"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+const (
+	FormatZone = "zone"
+	FormatJSON = "json"
+)
+
+// zoneTTL is the TTL of the records in the zone file, the clients recheck the root every 30 minutes
+const zoneTTL = 1800
+
+// maxTXTStringLen is the maximum length of a single character-string of a TXT record,
+// the longer entries are split into multiple strings of the same record.
+const maxTXTStringLen = 255
+
+// CreateTree builds an unsigned EIP-1459 tree of the live, fork-compatible nodes
+// whose records were obtained by the crawler.
+func CreateTree(ctx context.Context, db database.DB, maxPingTries uint, networkID uint, limit uint, seq uint, links []string) (*dnsdisc.Tree, error) {
+	enrs, err := db.FindLiveENRs(ctx, maxPingTries, networkID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*enode.Node, 0, len(enrs))
+	for _, enr := range enrs {
+		node, err := enode.Parse(enode.ValidSchemes, enr)
+		if err != nil {
+			return nil, fmt.Errorf("CreateTree failed to parse a node record %s: %w", enr, err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	return dnsdisc.MakeTree(seq, nodes, links)
+}
+
+// Write outputs the TXT records of a signed tree in the given format.
+func Write(w io.Writer, tree *dnsdisc.Tree, domain string, format string) error {
+	switch format {
+	case FormatZone:
+		return WriteZone(w, tree, domain)
+	case FormatJSON:
+		return WriteJSON(w, tree, domain)
+	default:
+		return fmt.Errorf("unknown output format %q, expected %q or %q", format, FormatZone, FormatJSON)
+	}
+}
+
+// WriteZone outputs the TXT records of a signed tree as a DNS zone file.
+func WriteZone(w io.Writer, tree *dnsdisc.Tree, domain string) error {
+	records := tree.ToTXT(domain)
+	names := make([]string, 0, len(records))
+	for name := range records {
+		names = append(names, name)
+	}
+	// The root goes first, followed by the entries in a stable order
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == domain) != (names[j] == domain) {
+			return names[i] == domain
+		}
+		return names[i] < names[j]
+	})
+
+	if _, err := fmt.Fprintf(w, "; EIP-1459 tree, seq %d\n", tree.Seq()); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "%s.\t%d\tIN\tTXT\t%s\n", name, zoneTTL, quoteTXT(records[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON outputs the TXT records of a signed tree as a JSON object mapping the
+// record names to their values.
+func WriteJSON(w io.Writer, tree *dnsdisc.Tree, domain string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tree.ToTXT(domain))
+}
+
+// quoteTXT formats the value of a TXT record for a zone file, the entries are base64
+// or base32 text, so they need no escaping.
+func quoteTXT(value string) string {
+	var parts []string
+	for len(value) > maxTXTStringLen {
+		parts = append(parts, `"`+value[:maxTXTStringLen]+`"`)
+		value = value[maxTXTStringLen:]
+	}
+	parts = append(parts, `"`+value+`"`)
+	return strings.Join(parts, " ")
+}
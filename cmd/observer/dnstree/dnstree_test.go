@@ -0,0 +1,136 @@
+package dnstree
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/cmd/observer/observer/node_utils"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p/dnsdisc"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+)
+
+type mapResolver map[string]string
+
+func (mr mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if record, ok := mr[name]; ok {
+		return []string{record}, nil
+	}
+	return nil, fmt.Errorf("no TXT record for %s", name)
+}
+
+func insertNode(t *testing.T, db database.DB, i int, isCompatFork bool, pingErrors int) *enode.Node {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+
+	var r enr.Record
+	r.Set(enr.IP(net.IPv4(10, 0, 1, byte(i))))
+	r.Set(enr.TCP(30303))
+	r.Set(enr.UDP(30303))
+	require.Nil(t, enode.SignV4(&r, key))
+	node, err := enode.New(enode.ValidSchemes, &r)
+	require.Nil(t, err)
+
+	id, err := node_utils.NodeID(node)
+	require.Nil(t, err)
+	require.Nil(t, db.UpsertNodeAddr(ctx, id, database.NodeAddr{NodeAddr1: database.NodeAddr1{IP: node.IP(), PortDisc: 30303, PortRLPx: 30303}}))
+	require.Nil(t, db.UpdateForkCompatibility(ctx, id, isCompatFork))
+	require.Nil(t, db.UpsertENR(ctx, id, node.String()))
+	for j := 0; j < pingErrors; j++ {
+		require.Nil(t, db.UpdatePingError(ctx, id))
+	}
+	return node
+}
+
+func TestCreateTree(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewDBSQLite(filepath.Join(t.TempDir(), "observer.sqlite"))
+	require.Nil(t, err)
+	defer func() { _ = db.Close() }()
+
+	var live []*enode.Node
+	for i := 0; i < 20; i++ {
+		live = append(live, insertNode(t, db, i, true, 0))
+	}
+	insertNode(t, db, 100, false, 0) // incompatible
+	insertNode(t, db, 101, true, 3)  // dead
+
+	const domain = "nodes.example.org"
+	tree, err := CreateTree(ctx, db, 3, 1, 100, 7, nil)
+	require.Nil(t, err)
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	url, err := tree.Sign(key, domain)
+	require.Nil(t, err)
+
+	// The published records sync into the same tree
+	client := dnsdisc.NewClient(dnsdisc.Config{Resolver: mapResolver(tree.ToTXT(domain)), RateLimit: 1000})
+	synced, err := client.SyncTree(url)
+	require.Nil(t, err)
+	assert.Equal(t, uint(7), synced.Seq())
+	assert.ElementsMatch(t, live, synced.Nodes())
+	assert.Equal(t, tree.ToTXT(domain), synced.ToTXT(domain))
+
+	// The limit takes a subset of the nodes
+	tree, err = CreateTree(ctx, db, 3, 1, 5, 7, nil)
+	require.Nil(t, err)
+	assert.Len(t, tree.Nodes(), 5)
+}
+
+func TestWrite(t *testing.T) {
+	db, err := database.NewDBSQLite(filepath.Join(t.TempDir(), "observer.sqlite"))
+	require.Nil(t, err)
+	defer func() { _ = db.Close() }()
+	for i := 0; i < 20; i++ {
+		insertNode(t, db, i, true, 0)
+	}
+
+	const domain = "nodes.example.org"
+	tree, err := CreateTree(context.Background(), db, 3, 1, 100, 1, nil)
+	require.Nil(t, err)
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	_, err = tree.Sign(key, domain)
+	require.Nil(t, err)
+	records := tree.ToTXT(domain)
+
+	var out bytes.Buffer
+	require.Nil(t, Write(&out, tree, domain, FormatJSON))
+	var fromJSON map[string]string
+	require.Nil(t, json.Unmarshal(out.Bytes(), &fromJSON))
+	assert.Equal(t, records, fromJSON)
+
+	out.Reset()
+	require.Nil(t, Write(&out, tree, domain, FormatZone))
+	fromZone := make(map[string]string)
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, ";") {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 5)
+		require.Len(t, fields, 5)
+		assert.Equal(t, "TXT", fields[3])
+		for _, part := range strings.Split(fields[4], `" "`) {
+			assert.LessOrEqual(t, len(strings.Trim(part, `"`)), maxTXTStringLen)
+		}
+		fromZone[strings.TrimSuffix(fields[0], ".")] = strings.ReplaceAll(strings.Trim(fields[4], `"`), `" "`, "")
+	}
+	assert.Equal(t, records, fromZone)
+
+	assert.NotNil(t, Write(&out, tree, domain, "yaml"))
+}
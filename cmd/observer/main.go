@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/cmd/observer/dnstree"
 	"github.com/ledgerwatch/erigon/cmd/observer/observer"
 	"github.com/ledgerwatch/erigon/cmd/observer/reports"
 	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/log/v3"
 )
@@ -103,6 +108,51 @@ func reportWithFlags(ctx context.Context, flags reports.CommandFlags) error {
 	return nil
 }
 
+func dnsTreeWithFlags(ctx context.Context, flags dnstree.CommandFlags) error {
+	key, err := crypto.LoadECDSA(flags.KeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load the signing key: %w", err)
+	}
+
+	db, err := database.NewDBSQLite(filepath.Join(flags.DataDir, "observer.sqlite"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	networkID := uint(params.NetworkIDByChainName(flags.Chain))
+	seq := flags.Seq
+	if seq == 0 {
+		seq = uint(time.Now().Unix())
+	}
+
+	tree, err := dnstree.CreateTree(ctx, db, flags.MaxPingTries, networkID, flags.NodesLimit, seq, flags.Links)
+	if err != nil {
+		return err
+	}
+	url, err := tree.Sign(key, flags.Domain)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if flags.OutputPath != "" {
+		file, err := os.Create(flags.OutputPath)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		out = file
+	}
+	if err := dnstree.Write(out, tree, flags.Domain, flags.Format); err != nil {
+		return err
+	}
+
+	// stdout may carry the tree itself
+	fmt.Fprintf(os.Stderr, "Generated a tree of %d nodes with seq %d: %s\n", len(tree.Nodes()), seq, url)
+	return nil
+}
+
 func main() {
 	ctx, cancel := common.RootContext()
 	defer cancel()
@@ -113,6 +163,10 @@ func main() {
 	reportCommand.OnRun(reportWithFlags)
 	command.AddSubCommand(reportCommand.RawCommand())
 
+	dnsTreeCommand := dnstree.NewCommand()
+	dnsTreeCommand.OnRun(dnsTreeWithFlags)
+	command.AddSubCommand(dnsTreeCommand.RawCommand())
+
 	err := command.ExecuteContext(ctx, mainWithFlags)
 	if (err != nil) && !errors.Is(err, context.Canceled) {
 		utils.Fatalf("%v", err)
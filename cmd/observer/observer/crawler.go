@@ -447,6 +447,13 @@ func (crawler *Crawler) saveInterrogationResult(
 		}
 	}
 
+	if (result != nil) && (result.ENR != nil) {
+		dbErr := crawler.db.UpsertENR(ctx, id, result.ENR.String())
+		if dbErr != nil {
+			return dbErr
+		}
+	}
+
 	if isPingError {
 		dbErr := crawler.db.UpdatePingError(ctx, id)
 		if dbErr != nil {
@@ -40,6 +40,7 @@ type Interrogator struct {
 
 type InterrogationResult struct {
 	Node               *enode.Node
+	ENR                *enode.Node // the signed record of the node, nil if the ENR request failed
 	IsCompatFork       *bool
 	HandshakeResult    *DiplomatResult
 	HandshakeRetryTime *time.Time
@@ -163,6 +164,7 @@ func (interrogator *Interrogator) Run(ctx context.Context) (*InterrogationResult
 
 	result := InterrogationResult{
 		interrogator.node,
+		enr,
 		isCompatFork,
 		handshakeResult,
 		handshakeRetryTime,
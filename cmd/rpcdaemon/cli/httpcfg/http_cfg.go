@@ -74,10 +74,11 @@ type HttpCfg struct {
 	LogDirVerbosity string
 	LogDirPath      string
 
-	BatchLimit                  int  // Maximum number of requests in a batch
-	ReturnDataLimit             int  // Maximum number of bytes returned from calls (like eth_call)
-	AllowUnprotectedTxs         bool // Whether to allow non EIP-155 protected transactions  txs over RPC
-	MaxGetProofRewindBlockCount int  //Max GetProof rewind block count
+	BatchLimit                  int    // Maximum number of requests in a batch
+	ReturnDataLimit             int    // Maximum number of bytes returned from calls (like eth_call)
+	AllowUnprotectedTxs         bool   // Whether to allow non EIP-155 protected transactions  txs over RPC
+	MaxGetProofRewindBlockCount int    //Max GetProof rewind block count
+	MaxStateDiffBlockRange      uint64 // Max number of blocks covered by one debug_traceBlock* call with stateDiff
 	// Ots API
 	OtsMaxPageSize uint64
 
@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 
 	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/diagnostics"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	"github.com/ledgerwatch/erigon-lib/kv"
@@ -326,12 +327,88 @@ func (back *RemoteBackend) AddPeer(ctx context.Context, request *remote.AddPeerR
 	return result, nil
 }
 
+func (back *RemoteBackend) RemovePeer(ctx context.Context, request *remote.RemovePeerRequest) (*remote.RemovePeerReply, error) {
+	result, err := back.remoteEthBackend.RemovePeer(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("ETHBACKENDClient.RemovePeer() error: %w", err)
+	}
+	return result, nil
+}
+
+func (back *RemoteBackend) AddTrustedPeer(ctx context.Context, request *remote.AddPeerRequest) (*remote.AddPeerReply, error) {
+	result, err := back.remoteEthBackend.AddTrustedPeer(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("ETHBACKENDClient.AddTrustedPeer() error: %w", err)
+	}
+	return result, nil
+}
+
+func (back *RemoteBackend) RemoveTrustedPeer(ctx context.Context, request *remote.RemovePeerRequest) (*remote.RemovePeerReply, error) {
+	result, err := back.remoteEthBackend.RemoveTrustedPeer(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("ETHBACKENDClient.RemoveTrustedPeer() error: %w", err)
+	}
+	return result, nil
+}
+
+func (back *RemoteBackend) BanPeer(ctx context.Context, request *remote.BanPeerRequest) (*remote.BanPeerReply, error) {
+	result, err := back.remoteEthBackend.BanPeer(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("ETHBACKENDClient.BanPeer() error: %w", err)
+	}
+	return result, nil
+}
+
+func (back *RemoteBackend) UnbanPeer(ctx context.Context, request *remote.UnbanPeerRequest) (*remote.UnbanPeerReply, error) {
+	result, err := back.remoteEthBackend.UnbanPeer(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("ETHBACKENDClient.UnbanPeer() error: %w", err)
+	}
+	return result, nil
+}
+
+func (back *RemoteBackend) BannedPeers(ctx context.Context) (*remote.BannedPeersReply, error) {
+	result, err := back.remoteEthBackend.BannedPeers(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("ETHBACKENDClient.BannedPeers() error: %w", err)
+	}
+	return result, nil
+}
+
+func (back *RemoteBackend) PeerEvents(ctx context.Context, onPeerEvent func(*remote.PeerEvent)) error {
+	subscription, err := back.remoteEthBackend.PeerEvents(ctx, &remote.PeerEventsRequest{}, grpc.WaitForReady(true))
+	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			return errors.New(s.Message())
+		}
+		return err
+	}
+	for {
+		event, err := subscription.Recv()
+		if errors.Is(err, io.EOF) {
+			log.Debug("rpcdaemon: the peer events channel was closed")
+			break
+		}
+		if err != nil {
+			return err
+		}
+		onPeerEvent(event)
+	}
+	return nil
+}
+
 func (back *RemoteBackend) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
 	rpcPeers, err := back.remoteEthBackend.Peers(ctx, &emptypb.Empty{})
 	if err != nil {
 		return nil, fmt.Errorf("ETHBACKENDClient.Peers() error: %w", err)
 	}
 
+	// Scores are only known when the backend runs in process
+	var scores map[string]*diagnostics.PeerScore
+	if diag, ok := back.remoteEthBackend.(diagnostics.PeerScoreGetter); ok {
+		scores = diag.GetPeersScores()
+	}
+
 	peers := make([]*p2p.PeerInfo, 0, len(rpcPeers.Peers))
 
 	for _, rpcPeer := range rpcPeers.Peers {
@@ -356,6 +433,11 @@ func (back *RemoteBackend) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
 			},
 			Protocols: nil,
 		}
+		if score, ok := scores[rpcPeer.Id]; ok {
+			peer.Protocols = map[string]interface{}{
+				"eth": map[string]interface{}{"score": score.Score},
+			}
+		}
 
 		peers = append(peers, &peer)
 	}
@@ -47,6 +47,9 @@ type Worker struct {
 	callTracer  *CallTracer
 	taskGasPool *core.GasPool
 
+	liveTracer    vm.LiveLogger
+	liveTracerMux vm.EVMLogger
+
 	evm   *vm.EVM
 	ibs   *state.IntraBlockState
 	vmCfg vm.Config
@@ -94,6 +97,13 @@ func NewWorker(lock sync.Locker, logger log.Logger, ctx context.Context, backgro
 	return w
 }
 
+// SetLiveTracer attaches a live tracer to the worker. It must only be used when the
+// transactions are executed in order and exactly once, i.e. not in parallel mode.
+func (rw *Worker) SetLiveTracer(tracer vm.LiveLogger) {
+	rw.liveTracer = tracer
+	rw.liveTracerMux = vm.NewMuxLogger(rw.callTracer, tracer)
+}
+
 func (rw *Worker) ResetState(rs *state.StateV3) {
 	rw.rs = rs
 	rw.SetReader(state.NewStateReaderV3(rs.Domains()))
@@ -180,6 +190,13 @@ func (rw *Worker) RunTxTaskNoLock(txTask *state.TxTask) {
 	ibs := rw.ibs
 	//ibs.SetTrace(true)
 
+	liveTracer := rw.liveTracer
+	if txTask.HistoryExecution {
+		// catching up to the tx where execution stopped: these txs were traced already
+		liveTracer = nil
+	}
+	ibs.SetTracer(liveTracer)
+
 	rules := txTask.Rules
 	var err error
 	header := txTask.Header
@@ -201,6 +218,9 @@ func (rw *Worker) RunTxTaskNoLock(txTask *state.TxTask) {
 
 		// Block initialisation
 		//fmt.Printf("txNum=%d, blockNum=%d, initialisation of the block\n", txTask.TxNum, txTask.BlockNum)
+		if liveTracer != nil {
+			liveTracer.CaptureBlockStart(header)
+		}
 		syscall := func(contract libcommon.Address, data []byte, ibs *state.IntraBlockState, header *types.Header, constCall bool) ([]byte, error) {
 			return core.SysCallContract(contract, data, rw.chainConfig, ibs, header, rw.engine, constCall /* constCall */)
 		}
@@ -217,7 +237,7 @@ func (rw *Worker) RunTxTaskNoLock(txTask *state.TxTask) {
 			return core.SysCallContract(contract, data, rw.chainConfig, ibs, header, rw.engine, false /* constCall */)
 		}
 
-		_, _, err := rw.engine.Finalize(rw.chainConfig, types.CopyHeader(header), ibs, txTask.Txs, txTask.Uncles, nil, txTask.Withdrawals, rw.chain, syscall, rw.logger)
+		_, _, _, err := rw.engine.Finalize(rw.chainConfig, types.CopyHeader(header), ibs, txTask.Txs, txTask.Uncles, txTask.BlockReceipts, txTask.Withdrawals, nil, rw.chain, syscall, rw.logger)
 		if err != nil {
 			txTask.Error = err
 		} else {
@@ -231,11 +251,19 @@ func (rw *Worker) RunTxTaskNoLock(txTask *state.TxTask) {
 				txTask.TraceTos[uncle.Coinbase] = struct{}{}
 			}
 		}
+		if liveTracer != nil {
+			liveTracer.CaptureBlockEnd(txTask.Error)
+		}
 	default:
 		txHash := txTask.Tx.Hash()
 		rw.taskGasPool.Reset(txTask.Tx.GetGas())
 		rw.callTracer.Reset()
 		rw.vmCfg.SkipAnalysis = txTask.SkipAnalysis
+		rw.vmCfg.Tracer = rw.callTracer
+		if liveTracer != nil {
+			liveTracer.CaptureTransactionStart(txTask.TxIndex, txTask.Tx, *txTask.Sender)
+			rw.vmCfg.Tracer = rw.liveTracerMux
+		}
 		ibs.SetTxContext(txHash, txTask.BlockHash, txTask.TxIndex)
 		msg := txTask.TxAsMessage
 
@@ -270,6 +298,9 @@ func (rw *Worker) RunTxTaskNoLock(txTask *state.TxTask) {
 			txTask.TraceFroms = rw.callTracer.Froms()
 			txTask.TraceTos = rw.callTracer.Tos()
 		}
+		if liveTracer != nil {
+			liveTracer.CaptureTransactionEnd(txTask.UsedGas, txTask.Logs, txTask.Error)
+		}
 
 	}
 	// Prepare read set, write set and balanceIncrease set and send for serialisation
@@ -504,6 +504,11 @@ var (
 		Usage: "Max GetProof rewind block count",
 		Value: 100_000,
 	}
+	RpcMaxStateDiffBlockRange = cli.Uint64Flag{
+		Name:  "rpc.maxstatediffblockrange",
+		Usage: "Max number of blocks covered by one debug_traceBlockByNumber or debug_traceBlockByHash call with stateDiff",
+		Value: 10_000,
+	}
 	StateCacheFlag = cli.StringFlag{
 		Name:  "state.cache",
 		Value: "0MB",
@@ -536,6 +541,10 @@ var (
 		Usage: "Allowed ports to pick for different eth p2p protocol versions as follows <porta>,<portb>,..,<porti>",
 		Value: cli.NewUintSlice(uint(ListenPortFlag.Value), 30304, 30305, 30306, 30307),
 	}
+	P2pQUICPortFlag = cli.UintFlag{
+		Name:  "p2p.quic.port",
+		Usage: "Experimental: UDP port to accept RLPx connections over QUIC on, advertised in the node record. The sentries of the following eth p2p protocol versions use the next ports. Disabled if not set",
+	}
 	SentryAddrFlag = cli.StringFlag{
 		Name:  "sentry.api.addr",
 		Usage: "Comma separated sentry addresses '<host>:<port>,<host>:<port>'",
@@ -593,6 +602,18 @@ var (
 		Name:  "v5disc",
 		Usage: "Enables the experimental RLPx V5 (Topic Discovery) mechanism",
 	}
+	DiscoveryForkFilterFlag = cli.BoolFlag{
+		Name:  "p2p.discovery-fork-filter",
+		Usage: "Skip dialing the discovered nodes which advertise an incompatible fork id in their records",
+	}
+	SnapServeFlag = cli.BoolFlag{
+		Name:  "p2p.snap",
+		Usage: "Serves the state of the recent blocks to the snap/1 peers (eth/68 sentry only)",
+	}
+	PortalHistoryFlag = cli.BoolFlag{
+		Name:  "portal.history",
+		Usage: "Serves the block history to the Portal history network (requires --v5disc)",
+	}
 	NetrestrictFlag = cli.StringFlag{
 		Name:  "netrestrict",
 		Usage: "Restricts network communication to the given IP networks (CIDR masks)",
@@ -862,6 +883,15 @@ var (
 		Usage: "Enable embedded Silkworm Sentry service",
 	}
 
+	LiveTracerFlag = cli.StringFlag{
+		Name:  "tracer.live",
+		Usage: "Name of the tracer to run while blocks are executed, one of: file, socket",
+	}
+	LiveTracerConfigFlag = cli.StringFlag{
+		Name:  "tracer.live.config",
+		Usage: `JSON config of the live tracer, e.g. '{"path":"/data/trace.jsonl"}' for file or '{"network":"unix","address":"/tmp/indexer.sock"}' for socket`,
+	}
+
 	BeaconAPIFlag = cli.BoolFlag{
 		Name:  "beacon.api",
 		Usage: "Enable beacon API",
@@ -912,6 +942,11 @@ var (
 		Usage: "enables archival node in caplin (Experimental, does not work)",
 		Value: false,
 	}
+	CaplinCheckpointsToKeepFlag = cli.Uint64Flag{
+		Name:  "caplin.checkpoint-sync-server.keep",
+		Usage: "number of finalized checkpoint states caplin keeps on disk to act as a checkpoint sync provider (0 to disable)",
+		Value: 0,
+	}
 	BeaconApiAllowCredentialsFlag = cli.BoolFlag{
 		Name:  "beacon.api.cors.allow-credentials",
 		Usage: "set the cors' allow credentials",
@@ -1138,6 +1173,9 @@ func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
 	if ctx.IsSet(P2pProtocolVersionFlag.Name) {
 		cfg.ProtocolVersion = ctx.UintSlice(P2pProtocolVersionFlag.Name)
 	}
+	if ctx.IsSet(P2pQUICPortFlag.Name) {
+		cfg.QUICListenAddr = fmt.Sprintf(":%d", ctx.Uint(P2pQUICPortFlag.Name))
+	}
 	if ctx.IsSet(SentryAddrFlag.Name) {
 		cfg.SentryAddr = libcommon.CliString2Array(ctx.String(SentryAddrFlag.Name))
 	}
@@ -1244,6 +1282,12 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config, nodeName, datadir string, l
 	if ctx.IsSet(DiscoveryV5Flag.Name) {
 		cfg.DiscoveryV5 = ctx.Bool(DiscoveryV5Flag.Name)
 	}
+	if ctx.IsSet(DiscoveryForkFilterFlag.Name) {
+		cfg.DiscoveryForkFilter = ctx.Bool(DiscoveryForkFilterFlag.Name)
+	}
+	if ctx.IsSet(SnapServeFlag.Name) {
+		cfg.Snap = ctx.Bool(SnapServeFlag.Name)
+	}
 
 	if ctx.IsSet(MetricsEnabledFlag.Name) {
 		cfg.MetricsEnabled = ctx.Bool(MetricsEnabledFlag.Name)
@@ -1562,6 +1606,7 @@ func setBeaconAPI(ctx *cli.Context, cfg *ethconfig.Config) {
 func setCaplin(ctx *cli.Context, cfg *ethconfig.Config) {
 	cfg.CaplinConfig.Backfilling = ctx.Bool(CaplinBackfillingFlag.Name) || ctx.Bool(CaplinArchiveFlag.Name)
 	cfg.CaplinConfig.Archive = ctx.Bool(CaplinArchiveFlag.Name)
+	cfg.CaplinConfig.CheckpointsToKeep = ctx.Uint64(CaplinCheckpointsToKeepFlag.Name)
 }
 
 func setSilkworm(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1570,6 +1615,11 @@ func setSilkworm(ctx *cli.Context, cfg *ethconfig.Config) {
 	cfg.SilkwormSentry = ctx.Bool(SilkwormSentryFlag.Name)
 }
 
+func setLiveTracer(ctx *cli.Context, cfg *ethconfig.Config) {
+	cfg.LiveTracer = ctx.String(LiveTracerFlag.Name)
+	cfg.LiveTracerConfig = ctx.String(LiveTracerConfigFlag.Name)
+}
+
 // CheckExclusive verifies that only a single instance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1677,6 +1727,7 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	setWhitelist(ctx, cfg)
 	setBorConfig(ctx, cfg)
 	setSilkworm(ctx, cfg)
+	setLiveTracer(ctx, cfg)
 	setBeaconAPI(ctx, cfg)
 	setCaplin(ctx, cfg)
 
@@ -1707,6 +1758,7 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 			cfg.EthDiscoveryURLs = libcommon.CliString2Array(urls)
 		}
 	}
+	cfg.PortalHistory = ctx.Bool(PortalHistoryFlag.Name)
 	// Override any default configs for hard coded networks.
 	chain := ctx.String(ChainFlag.Name)
 
@@ -130,17 +130,19 @@ func (s *Merge) CalculateRewards(config *chain.Config, header *types.Header, unc
 	return []consensus.Reward{}, nil
 }
 
-func (s *Merge) Finalize(config *chain.Config, header *types.Header, state *state.IntraBlockState,
-	txs types.Transactions, uncles []*types.Header, r types.Receipts, withdrawals []*types.Withdrawal,
+// finalize applies the rewards and withdrawals of the block and derives its requests after Prague,
+// without checking them against the header which is not complete yet when the block is assembled.
+func (s *Merge) finalize(config *chain.Config, header *types.Header, state *state.IntraBlockState,
+	txs types.Transactions, uncles []*types.Header, r types.Receipts, withdrawals []*types.Withdrawal, requests types.Requests,
 	chain consensus.ChainReader, syscall consensus.SystemCall, logger log.Logger,
-) (types.Transactions, types.Receipts, error) {
+) (types.Transactions, types.Receipts, types.Requests, error) {
 	if !misc.IsPoSHeader(header) {
-		return s.eth1Engine.Finalize(config, header, state, txs, uncles, r, withdrawals, chain, syscall, logger)
+		return s.eth1Engine.Finalize(config, header, state, txs, uncles, r, withdrawals, requests, chain, syscall, logger)
 	}
 
 	rewards, err := s.CalculateRewards(config, header, uncles, syscall)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	for _, r := range rewards {
 		state.AddBalance(r.Beneficiary, &r.Amount)
@@ -149,7 +151,7 @@ func (s *Merge) Finalize(config *chain.Config, header *types.Header, state *stat
 	if withdrawals != nil {
 		if auraEngine, ok := s.eth1Engine.(*aura.AuRa); ok {
 			if err := auraEngine.ExecuteSystemWithdrawals(withdrawals, syscall); err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
 		} else {
 			for _, w := range withdrawals {
@@ -159,7 +161,47 @@ func (s *Merge) Finalize(config *chain.Config, header *types.Header, state *stat
 		}
 	}
 
-	return txs, r, nil
+	var rs types.Requests
+	if config.IsPrague(header.Time) {
+		var allLogs []*types.Log
+		for _, receipt := range r {
+			allLogs = append(allLogs, receipt.Logs...)
+		}
+		deposits, err := types.ParseDepositLogs(allLogs, config.DepositContract)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("could not parse deposit requests: %w", err)
+		}
+		withdrawalRequests, err := misc.DequeueWithdrawalRequests7002(syscall)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("could not dequeue withdrawal requests: %w", err)
+		}
+		// a Prague block always has a list of requests, even an empty one
+		rs = append(append(types.Requests{}, deposits...), withdrawalRequests...)
+	} else if requests != nil {
+		return nil, nil, nil, consensus.ErrUnexpectedRequests
+	}
+
+	return txs, r, rs, nil
+}
+
+func (s *Merge) Finalize(config *chain.Config, header *types.Header, state *state.IntraBlockState,
+	txs types.Transactions, uncles []*types.Header, r types.Receipts, withdrawals []*types.Withdrawal, requests types.Requests,
+	chain consensus.ChainReader, syscall consensus.SystemCall, logger log.Logger,
+) (types.Transactions, types.Receipts, types.Requests, error) {
+	outTxs, outReceipts, outRequests, err := s.finalize(config, header, state, txs, uncles, r, withdrawals, requests, chain, syscall, logger)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if misc.IsPoSHeader(header) && config.IsPrague(header.Time) {
+		// the requests of the block are derived from its execution, a block without requests has an empty list
+		if header.RequestsRoot == nil {
+			return nil, nil, nil, fmt.Errorf("missing requestsRoot")
+		}
+		if rh := types.DeriveSha(outRequests); rh != *header.RequestsRoot {
+			return nil, nil, nil, fmt.Errorf("invalid requestsRoot: have %x, computed %x", *header.RequestsRoot, rh)
+		}
+	}
+	return outTxs, outReceipts, outRequests, nil
 }
 
 func (s *Merge) FinalizeAndAssemble(config *chain.Config, header *types.Header, state *state.IntraBlockState,
@@ -169,11 +211,11 @@ func (s *Merge) FinalizeAndAssemble(config *chain.Config, header *types.Header,
 	if !misc.IsPoSHeader(header) {
 		return s.eth1Engine.FinalizeAndAssemble(config, header, state, txs, uncles, receipts, withdrawals, chain, syscall, call, logger)
 	}
-	outTxs, outReceipts, err := s.Finalize(config, header, state, txs, uncles, receipts, withdrawals, chain, syscall, logger)
+	outTxs, outReceipts, outRequests, err := s.finalize(config, header, state, txs, uncles, receipts, withdrawals, nil, chain, syscall, logger)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	return types.NewBlock(header, outTxs, uncles, outReceipts, withdrawals), outTxs, outReceipts, nil
+	return types.NewBlock(header, outTxs, uncles, outReceipts, withdrawals, outRequests), outTxs, outReceipts, nil
 }
 
 func (s *Merge) SealHash(header *types.Header) (hash libcommon.Hash) {
@@ -242,6 +284,15 @@ func (s *Merge) verifyHeader(chain consensus.ChainHeaderReader, header, parent *
 		return consensus.ErrUnexpectedWithdrawals
 	}
 
+	// Verify existence / non-existence of requestsRoot
+	prague := chain.Config().IsPrague(header.Time)
+	if prague && header.RequestsRoot == nil {
+		return fmt.Errorf("missing requestsRoot")
+	}
+	if !prague && header.RequestsRoot != nil {
+		return consensus.ErrUnexpectedRequests
+	}
+
 	if !chain.Config().IsCancun(header.Time) {
 		return misc.VerifyAbsenceOfCancunHeaderFields(header)
 	}
@@ -282,6 +333,11 @@ func (s *Merge) Initialize(config *chain.Config, chain consensus.ChainHeaderRead
 			return syscall(addr, data, state, header, false /* constCall */)
 		})
 	}
+	if chain.Config().IsPrague(header.Time) {
+		misc.ApplyBlockHashHistoryEip2935(header, func(addr libcommon.Address, data []byte) ([]byte, error) {
+			return syscall(addr, data, state, header, false /* constCall */)
+		})
+	}
 }
 
 func (s *Merge) APIs(chain consensus.ChainHeaderReader) []rpc.API {
@@ -0,0 +1,20 @@
+package misc
+
+import (
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// ApplyBlockHashHistoryEip2935 stores the parent hash of header in the EIP-2935 history storage contract.
+func ApplyBlockHashHistoryEip2935(header *types.Header, syscall consensus.SystemCall) {
+	if header.Number.Sign() == 0 { // no parent when the fork is active at genesis
+		return
+	}
+	_, err := syscall(params.HistoryStorageAddress, header.ParentHash.Bytes())
+	if err != nil {
+		log.Warn("Failed to call block hash history contract", "err", err)
+	}
+}
@@ -0,0 +1,17 @@
+package misc
+
+import (
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// DequeueWithdrawalRequests7002 calls the EIP-7002 system contract at the end of the block,
+// which dequeues the withdrawal requests submitted by the block transactions.
+func DequeueWithdrawalRequests7002(syscall consensus.SystemCall) (types.Requests, error) {
+	res, err := syscall(params.WithdrawalRequestAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	return types.ParseWithdrawalRequests(res)
+}
@@ -81,13 +81,21 @@ func ExecuteBlockEphemerally(
 	stateReader state.StateReader, stateWriter state.WriterWithChangeSets,
 	chainReader consensus.ChainReader, getTracer func(txIndex int, txHash libcommon.Hash) (vm.EVMLogger, error),
 	logger log.Logger,
-) (*EphemeralExecResult, error) {
-
+) (execRs *EphemeralExecResult, err error) {
 	defer blockExecutionTimer.ObserveDuration(time.Now())
 	block.Uncles()
 	ibs := state.New(stateReader)
 	header := block.Header()
 
+	liveTracer := vmConfig.LiveTracer
+	if liveTracer != nil {
+		ibs.SetTracer(liveTracer)
+		liveTracer.CaptureBlockStart(header)
+		defer func() {
+			liveTracer.CaptureBlockEnd(err)
+		}()
+	}
+
 	usedGas := new(uint64)
 	usedBlobGas := new(uint64)
 	gp := new(GasPool)
@@ -116,7 +124,30 @@ func ExecuteBlockEphemerally(
 			vmConfig.Tracer = tracer
 			writeTrace = true
 		}
-		receipt, _, err := ApplyTransaction(chainConfig, blockHashFunc, engine, nil, gp, ibs, noop, header, tx, usedGas, usedBlobGas, *vmConfig)
+		txVmConfig := *vmConfig
+		if liveTracer != nil {
+			from, ok := tx.GetSender()
+			if !ok {
+				if from, err = types.MakeSigner(chainConfig, header.Number.Uint64(), header.Time).Sender(tx); err != nil {
+					return nil, fmt.Errorf("could not recover sender of tx %d: %w", i, err)
+				}
+			}
+			liveTracer.CaptureTransactionStart(i, tx, from)
+			txVmConfig.Debug = true
+			if txVmConfig.Tracer != nil {
+				txVmConfig.Tracer = vm.NewMuxLogger(txVmConfig.Tracer, liveTracer)
+			} else {
+				txVmConfig.Tracer = liveTracer
+			}
+		}
+		receipt, _, err := ApplyTransaction(chainConfig, blockHashFunc, engine, nil, gp, ibs, noop, header, tx, usedGas, usedBlobGas, txVmConfig)
+		if liveTracer != nil {
+			if err != nil {
+				liveTracer.CaptureTransactionEnd(0, nil, err)
+			} else {
+				liveTracer.CaptureTransactionEnd(receipt.GasUsed, receipt.Logs, nil)
+			}
+		}
 		if writeTrace {
 			if ftracer, ok := vmConfig.Tracer.(vm.FlushableTracer); ok {
 				ftracer.Flush(tx)
@@ -137,17 +168,33 @@ func ExecuteBlockEphemerally(
 		}
 	}
 
+	return finishBlockExecution(chainConfig, vmConfig, engine, block, stateReader, stateWriter, chainReader, ibs,
+		receipts, includedTxs, rejectedTxs, *usedGas, *usedBlobGas, logger)
+}
+
+// finishBlockExecution checks the results of the transactions of the block against its header,
+// then finalises the block and writes its state changes to stateWriter.
+func finishBlockExecution(
+	chainConfig *chain.Config, vmConfig *vm.Config,
+	engine consensus.Engine, block *types.Block,
+	stateReader state.StateReader, stateWriter state.WriterWithChangeSets,
+	chainReader consensus.ChainReader, ibs *state.IntraBlockState,
+	receipts types.Receipts, includedTxs types.Transactions, rejectedTxs []*RejectedTx,
+	usedGas, usedBlobGas uint64,
+	logger log.Logger,
+) (execRs *EphemeralExecResult, err error) {
+	header := block.Header()
 	receiptSha := types.DeriveSha(receipts)
 	if !vmConfig.StatelessExec && chainConfig.IsByzantium(header.Number.Uint64()) && !vmConfig.NoReceipts && receiptSha != block.ReceiptHash() {
 		return nil, fmt.Errorf("mismatched receipt headers for block %d (%s != %s)", block.NumberU64(), receiptSha.Hex(), block.ReceiptHash().Hex())
 	}
 
-	if !vmConfig.StatelessExec && *usedGas != header.GasUsed {
-		return nil, fmt.Errorf("gas used by execution: %d, in header: %d", *usedGas, header.GasUsed)
+	if !vmConfig.StatelessExec && usedGas != header.GasUsed {
+		return nil, fmt.Errorf("gas used by execution: %d, in header: %d", usedGas, header.GasUsed)
 	}
 
-	if header.BlobGasUsed != nil && *usedBlobGas != *header.BlobGasUsed {
-		return nil, fmt.Errorf("blob gas used by execution: %d, in header: %d", *usedBlobGas, *header.BlobGasUsed)
+	if header.BlobGasUsed != nil && usedBlobGas != *header.BlobGasUsed {
+		return nil, fmt.Errorf("blob gas used by execution: %d, in header: %d", usedBlobGas, *header.BlobGasUsed)
 	}
 
 	var bloom types.Bloom
@@ -159,19 +206,19 @@ func ExecuteBlockEphemerally(
 	}
 	if !vmConfig.ReadOnly {
 		txs := block.Transactions()
-		if _, _, _, err := FinalizeBlockExecution(engine, stateReader, block.Header(), txs, block.Uncles(), stateWriter, chainConfig, ibs, receipts, block.Withdrawals(), chainReader, false, logger); err != nil {
+		if _, _, _, err := FinalizeBlockExecution(engine, stateReader, block.Header(), txs, block.Uncles(), stateWriter, chainConfig, ibs, receipts, block.Withdrawals(), block.Requests(), chainReader, false, logger); err != nil {
 			return nil, err
 		}
 	}
 	blockLogs := ibs.Logs()
-	execRs := &EphemeralExecResult{
+	execRs = &EphemeralExecResult{
 		TxRoot:      types.DeriveSha(includedTxs),
 		ReceiptRoot: receiptSha,
 		Bloom:       bloom,
 		LogsHash:    rlpHash(blockLogs),
 		Receipts:    receipts,
 		Difficulty:  (*math.HexOrDecimal256)(header.Difficulty),
-		GasUsed:     math.HexOrDecimal64(*usedGas),
+		GasUsed:     math.HexOrDecimal64(usedGas),
 		Rejected:    rejectedTxs,
 	}
 
@@ -283,7 +330,7 @@ func FinalizeBlockExecution(
 	header *types.Header, txs types.Transactions, uncles []*types.Header,
 	stateWriter state.StateWriter, cc *chain.Config,
 	ibs *state.IntraBlockState, receipts types.Receipts,
-	withdrawals []*types.Withdrawal, chainReader consensus.ChainReader,
+	withdrawals []*types.Withdrawal, requests types.Requests, chainReader consensus.ChainReader,
 	isMining bool,
 	logger log.Logger,
 ) (newBlock *types.Block, newTxs types.Transactions, newReceipt types.Receipts, err error) {
@@ -293,7 +340,7 @@ func FinalizeBlockExecution(
 	if isMining {
 		newBlock, newTxs, newReceipt, err = engine.FinalizeAndAssemble(cc, header, ibs, txs, uncles, receipts, withdrawals, chainReader, syscall, nil, logger)
 	} else {
-		_, _, err = engine.Finalize(cc, header, ibs, txs, uncles, receipts, withdrawals, chainReader, syscall, logger)
+		_, _, _, err = engine.Finalize(cc, header, ibs, txs, uncles, receipts, withdrawals, requests, chainReader, syscall, logger)
 	}
 	if err != nil {
 		return nil, nil, nil, err
@@ -404,7 +404,7 @@ func GenerateChain(config *chain.Config, parent *types.Block, engine consensus.E
 			}
 			_ = err
 			// Recreating block to make sure Root makes it into the header
-			block := types.NewBlock(b.header, b.txs, b.uncles, b.receipts, nil /* withdrawals */)
+			block := types.NewBlock(b.header, b.txs, b.uncles, b.receipts, nil, nil)
 			return block, b.receipts, nil
 		}
 		return nil, nil, fmt.Errorf("no engine to generate blocks")
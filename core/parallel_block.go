@@ -0,0 +1,410 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/metrics"
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+var (
+	parallelTxs          = metrics.GetOrCreateCounter("chain_execution_parallel_txs")
+	parallelConflicts    = metrics.GetOrCreateCounter("chain_execution_parallel_conflicts")
+	parallelConflictRate = metrics.GetOrCreateSummary("chain_execution_parallel_conflict_rate")
+	parallelSpeedup      = metrics.GetOrCreateSummary("chain_execution_parallel_speedup")
+)
+
+var errParallelExecStopped = errors.New("parallel execution stopped")
+
+// ParallelTracer is a tracer which can be used by ExecuteBlockParallel: each transaction
+// is traced by a tracer returned by Fork, which is joined back if its execution is kept.
+type ParallelTracer interface {
+	vm.EVMLogger
+	Fork() vm.EVMLogger
+	Join(forked vm.EVMLogger)
+}
+
+type parallelTxResult struct {
+	done     chan struct{}
+	reads    *state.TxReadSet
+	writes   *state.TxWriteSet
+	receipt  *types.Receipt
+	logs     []*types.Log
+	usedGas  uint64
+	tracer   vm.EVMLogger
+	duration time.Duration
+	err      error
+}
+
+// ExecuteBlockParallel runs a block like ExecuteBlockEphemerally, with the same results, but executes
+// its transactions optimistically in parallel on the given number of workers. Each transaction is
+// executed against the state at the start of the block, recording what it reads and writes. The
+// transactions are then committed in order: the writes of a transaction are applied if what it read
+// hasn't been changed by the previous ones, otherwise it is executed again on the up-to-date state.
+// The blocks which can't be executed this way, because of the tracers or the consensus engine, are
+// handed over to ExecuteBlockEphemerally.
+func ExecuteBlockParallel(
+	chainConfig *chain.Config, vmConfig *vm.Config,
+	blockHashFunc func(n uint64) libcommon.Hash,
+	engine consensus.Engine, block *types.Block,
+	stateReader state.StateReader, stateWriter state.WriterWithChangeSets,
+	chainReader consensus.ChainReader, getTracer func(txIndex int, txHash libcommon.Hash) (vm.EVMLogger, error),
+	workers int,
+	logger log.Logger,
+) (*EphemeralExecResult, error) {
+	txs := block.Transactions()
+	tracer, canFork := vmConfig.Tracer.(ParallelTracer)
+	if workers < 2 || len(txs) < 2 || vmConfig.LiveTracer != nil || vmConfig.StatelessExec || chainConfig.Aura != nil ||
+		(vmConfig.Debug && !canFork) {
+		return ExecuteBlockEphemerally(chainConfig, vmConfig, blockHashFunc, engine, block, stateReader, stateWriter, chainReader, getTracer, logger)
+	}
+
+	start := time.Now()
+	block.Uncles()
+	ibs := state.New(stateReader)
+	header := block.Header()
+	rules := chainConfig.Rules(header.Number.Uint64(), header.Time)
+	if err := InitializeBlockExecution(engine, chainReader, header, chainConfig, ibs, logger); err != nil {
+		return nil, err
+	}
+	// The transactions are executed on top of the changes made by the initialisation of the block
+	initWriter := state.NewWriteSetWriter()
+	if err := ibs.MakeWriteSet(rules, initWriter); err != nil {
+		return nil, err
+	}
+	initWrites := initWriter.WriteSet(nil)
+	if !initWrites.Simple() {
+		return ExecuteBlockEphemerally(chainConfig, vmConfig, blockHashFunc, engine, block, stateReader, stateWriter, chainReader, getTracer, logger)
+	}
+	defer blockExecutionTimer.ObserveDuration(start)
+
+	// The state reader and the block hashes are backed by the database transaction, which only
+	// this goroutine may use: the workers ask it to read for them while it waits for their results.
+	owner := &txOwner{requests: make(chan func()), quit: make(chan struct{})}
+	overlay := state.NewWriteSetReader(newSharedStateReader(stateReader, owner), initWrites)
+	sharedHashFunc := func(n uint64) (hash libcommon.Hash) {
+		owner.do(func() { hash = blockHashFunc(n) })
+		return hash
+	}
+
+	results := make([]parallelTxResult, len(txs))
+	for i := range results {
+		results[i].done = make(chan struct{})
+	}
+	txVmConfig := *vmConfig
+	var (
+		next atomic.Int64
+		wg   sync.WaitGroup
+	)
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(txs) {
+					return
+				}
+				select {
+				case <-owner.quit:
+					return
+				default:
+				}
+				executeSpeculatively(chainConfig, txVmConfig, sharedHashFunc, engine, block, header, rules, overlay, txs[i], i, &results[i])
+			}
+		}()
+	}
+	defer func() {
+		close(owner.quit)
+		wg.Wait()
+	}()
+
+	var (
+		usedGas, usedBlobGas uint64
+		serialDuration       time.Duration
+		conflicts            int
+		receipts             types.Receipts
+		includedTxs          types.Transactions
+		noop                 = state.NewNoopWriter()
+	)
+	gp := new(GasPool)
+	gp.AddGas(block.GasLimit()).AddBlobGas(chainConfig.GetMaxBlobGasPerBlock())
+	for i, tx := range txs {
+		res := &results[i]
+		owner.wait(res.done)
+		ibs.SetTxContext(tx.Hash(), block.Hash(), i)
+		var receipt *types.Receipt
+		if res.err == nil && gp.Gas() >= tx.GetGas() && gp.BlobGas() >= tx.GetBlobGas() &&
+			res.writes.Simple() && ibs.ReadsValid(res.reads) {
+			ibs.ApplyWriteSet(res.writes)
+			for _, l := range res.logs {
+				ibs.AddLog(l)
+			}
+			if err := ibs.FinalizeTx(rules, noop); err != nil {
+				return nil, err
+			}
+			if err := gp.SubGas(res.usedGas); err != nil {
+				return nil, err
+			}
+			if err := gp.SubBlobGas(tx.GetBlobGas()); err != nil {
+				return nil, err
+			}
+			usedGas += res.usedGas
+			usedBlobGas += tx.GetBlobGas()
+			receipt = res.receipt
+			if receipt != nil {
+				receipt.CumulativeGasUsed = usedGas
+			}
+			if res.tracer != nil {
+				tracer.Join(res.tracer)
+			}
+			serialDuration += res.duration
+		} else {
+			conflicts++
+			txStart := time.Now()
+			var err error
+			receipt, _, err = ApplyTransaction(chainConfig, blockHashFunc, engine, nil, gp, ibs, noop, header, tx, &usedGas, &usedBlobGas, *vmConfig)
+			if err != nil {
+				return nil, fmt.Errorf("could not apply tx %d from block %d [%v]: %w", i, block.NumberU64(), tx.Hash().Hex(), err)
+			}
+			serialDuration += time.Since(txStart)
+		}
+		includedTxs = append(includedTxs, tx)
+		if !vmConfig.NoReceipts {
+			receipts = append(receipts, receipt)
+		}
+	}
+
+	parallelTxs.AddInt(len(txs))
+	parallelConflicts.AddInt(conflicts)
+	parallelConflictRate.Observe(float64(conflicts) / float64(len(txs)))
+	if elapsed := time.Since(start); elapsed > 0 {
+		parallelSpeedup.Observe(float64(serialDuration) / float64(elapsed))
+	}
+
+	return finishBlockExecution(chainConfig, vmConfig, engine, block, stateReader, stateWriter, chainReader, ibs,
+		receipts, includedTxs, nil, usedGas, usedBlobGas, logger)
+}
+
+// executeSpeculatively executes a transaction on its own IntraBlockState, against the state at the
+// start of the block, and records its results along with what it read and wrote.
+func executeSpeculatively(
+	chainConfig *chain.Config, vmConfig vm.Config,
+	blockHashFunc func(n uint64) libcommon.Hash,
+	engine consensus.Engine, block *types.Block, header *types.Header, rules *chain.Rules,
+	stateReader state.StateReader, tx types.Transaction, txIndex int, res *parallelTxResult,
+) {
+	defer close(res.done)
+	start := time.Now()
+	reader := state.NewReadSetReader(stateReader)
+	ibs := state.New(reader)
+	ibs.DeferBalanceIncreases()
+	ibs.SetTxContext(tx.Hash(), block.Hash(), txIndex)
+	if tracer, ok := vmConfig.Tracer.(ParallelTracer); ok {
+		res.tracer = tracer.Fork()
+		vmConfig.Tracer = res.tracer
+	}
+	// The gas pool of the block is only known when the transaction is committed
+	gp := new(GasPool).AddGas(tx.GetGas()).AddBlobGas(tx.GetBlobGas())
+	var usedBlobGas uint64
+	res.receipt, _, res.err = ApplyTransaction(chainConfig, blockHashFunc, engine, nil, gp, ibs, state.NewNoopWriter(), header, tx, &res.usedGas, &usedBlobGas, vmConfig)
+	if res.err == nil {
+		res.err = ibs.Error()
+	}
+	if res.err != nil {
+		return
+	}
+	writer := state.NewWriteSetWriter()
+	if res.err = ibs.MakeWriteSet(rules, writer); res.err != nil {
+		return
+	}
+	res.reads = reader.ReadSet()
+	res.writes = writer.WriteSet(ibs.BalanceIncreaseSet())
+	res.logs = ibs.GetLogs(tx.Hash())
+	res.duration = time.Since(start)
+}
+
+// txOwner runs functions on the goroutine owning the database transaction, on behalf of the others.
+type txOwner struct {
+	requests chan func()
+	quit     chan struct{}
+}
+
+// do runs f on the owning goroutine and waits for it to return.
+func (o *txOwner) do(f func()) error {
+	done := make(chan struct{})
+	select {
+	case o.requests <- func() { f(); close(done) }:
+	case <-o.quit:
+		return errParallelExecStopped
+	}
+	<-done
+	return nil
+}
+
+// wait runs the requested functions until done is closed. It must be called by the owning goroutine.
+func (o *txOwner) wait(done <-chan struct{}) {
+	for {
+		select {
+		case f := <-o.requests:
+			f()
+		case <-done:
+			return
+		}
+	}
+}
+
+type sharedStorageKey struct {
+	address     libcommon.Address
+	incarnation uint64
+	key         libcommon.Hash
+}
+
+// sharedStateReader is a StateReader safe for concurrent use, caching what it reads
+// through the owner of the database transaction.
+type sharedStateReader struct {
+	r     state.StateReader
+	owner *txOwner
+
+	lock         sync.RWMutex
+	accounts     map[libcommon.Address]*accounts.Account
+	storage      map[sharedStorageKey][]byte
+	code         map[libcommon.Hash][]byte
+	codeSizes    map[libcommon.Hash]int
+	incarnations map[libcommon.Address]uint64
+}
+
+func newSharedStateReader(r state.StateReader, owner *txOwner) *sharedStateReader {
+	return &sharedStateReader{
+		r:            r,
+		owner:        owner,
+		accounts:     map[libcommon.Address]*accounts.Account{},
+		storage:      map[sharedStorageKey][]byte{},
+		code:         map[libcommon.Hash][]byte{},
+		codeSizes:    map[libcommon.Hash]int{},
+		incarnations: map[libcommon.Address]uint64{},
+	}
+}
+
+func (r *sharedStateReader) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	r.lock.RLock()
+	a, ok := r.accounts[address]
+	r.lock.RUnlock()
+	if !ok {
+		var err error
+		if stopErr := r.owner.do(func() { a, err = r.r.ReadAccountData(address) }); stopErr != nil {
+			return nil, stopErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			a = a.SelfCopy()
+		}
+		r.lock.Lock()
+		r.accounts[address] = a
+		r.lock.Unlock()
+	}
+	if a == nil {
+		return nil, nil
+	}
+	return a.SelfCopy(), nil
+}
+
+func (r *sharedStateReader) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	k := sharedStorageKey{address, incarnation, *key}
+	r.lock.RLock()
+	enc, ok := r.storage[k]
+	r.lock.RUnlock()
+	if ok {
+		return enc, nil
+	}
+	var err error
+	if stopErr := r.owner.do(func() { enc, err = r.r.ReadAccountStorage(address, incarnation, key) }); stopErr != nil {
+		return nil, stopErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	enc = libcommon.Copy(enc)
+	r.lock.Lock()
+	r.storage[k] = enc
+	r.lock.Unlock()
+	return enc, nil
+}
+
+func (r *sharedStateReader) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) ([]byte, error) {
+	r.lock.RLock()
+	code, ok := r.code[codeHash]
+	r.lock.RUnlock()
+	if ok {
+		return code, nil
+	}
+	var err error
+	if stopErr := r.owner.do(func() { code, err = r.r.ReadAccountCode(address, incarnation, codeHash) }); stopErr != nil {
+		return nil, stopErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	code = libcommon.Copy(code)
+	r.lock.Lock()
+	r.code[codeHash] = code
+	r.lock.Unlock()
+	return code, nil
+}
+
+func (r *sharedStateReader) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (int, error) {
+	r.lock.RLock()
+	size, ok := r.codeSizes[codeHash]
+	r.lock.RUnlock()
+	if ok {
+		return size, nil
+	}
+	var err error
+	if stopErr := r.owner.do(func() { size, err = r.r.ReadAccountCodeSize(address, incarnation, codeHash) }); stopErr != nil {
+		return 0, stopErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	r.lock.Lock()
+	r.codeSizes[codeHash] = size
+	r.lock.Unlock()
+	return size, nil
+}
+
+func (r *sharedStateReader) ReadAccountIncarnation(address libcommon.Address) (uint64, error) {
+	r.lock.RLock()
+	inc, ok := r.incarnations[address]
+	r.lock.RUnlock()
+	if ok {
+		return inc, nil
+	}
+	var err error
+	if stopErr := r.owner.do(func() { inc, err = r.r.ReadAccountIncarnation(address) }); stopErr != nil {
+		return 0, stopErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	r.lock.Lock()
+	r.incarnations[address] = inc
+	r.lock.Unlock()
+	return inc, nil
+}
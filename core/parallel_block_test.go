@@ -0,0 +1,125 @@
+package core_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/stages/mock"
+)
+
+func TestExecuteBlockParallel(t *testing.T) {
+	t.Parallel()
+	var (
+		keys    = make([]*ecdsa.PrivateKey, 4)
+		addrs   = make([]libcommon.Address, len(keys))
+		counter = libcommon.HexToAddress("0xc0")
+		owners  = libcommon.HexToAddress("0xc1")
+		funds   = big.NewInt(params.Ether)
+		alloc   = types.GenesisAlloc{
+			// Increments slot 0 and logs its new value
+			counter: {Balance: new(big.Int), Code: []byte{
+				byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.PUSH1), 1, byte(vm.ADD), byte(vm.DUP1), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+				byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG0),
+			}},
+			// Stores the caller in the slot of the caller
+			owners: {Balance: new(big.Int), Code: []byte{byte(vm.CALLER), byte(vm.CALLER), byte(vm.SSTORE)}},
+		}
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = types.GenesisAccount{Balance: funds}
+	}
+	gspec := &types.Genesis{Config: params.TestChainConfig, Alloc: alloc}
+	m := mock.MockWithGenesis(t, gspec, keys[0], false)
+	signer := types.LatestSignerForChainID(m.ChainConfig.ChainID)
+
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 1, func(i int, b *core.BlockGen) {
+		nonces := make([]uint64, len(keys))
+		send := func(from int, to *libcommon.Address, value uint64, data []byte) {
+			var tx types.Transaction
+			if to == nil {
+				tx = types.NewContractCreation(nonces[from], uint256.NewInt(value), 100_000, uint256.NewInt(params.GWei), data)
+			} else {
+				tx = types.NewTransaction(nonces[from], *to, uint256.NewInt(value), 100_000, uint256.NewInt(params.GWei), data)
+			}
+			signed, err := types.SignTx(tx, *signer, keys[from])
+			require.NoError(t, err)
+			b.AddTx(signed)
+			nonces[from]++
+		}
+		send(0, &counter, 0, nil)
+		send(1, &counter, 0, nil) // Conflicts with the previous transaction on the slot of the counter
+		send(0, &owners, 0, nil)  // Conflicts with the first transaction on the nonce of the sender
+		send(2, &owners, 0, nil)
+		send(3, &libcommon.Address{0x42}, 1, nil)
+		send(2, &addrs[1], 1, nil)                                                     // Conflicts with the second transaction on the balance of the recipient
+		send(3, nil, 0, []byte{byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.RETURN)}) // Creates a contract
+	})
+	require.NoError(t, err)
+	block := chain.TopBlock
+
+	execute := func(parallel bool) (*core.EphemeralExecResult, map[string][]byte) {
+		tx, err := m.DB.BeginRw(context.Background())
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		getHeader := func(hash libcommon.Hash, number uint64) *types.Header {
+			h, _ := m.BlockReader.Header(context.Background(), tx, hash, number)
+			return h
+		}
+		var (
+			vmConfig    = vm.Config{}
+			getHashFn   = core.GetHashFn(block.Header(), getHeader)
+			stateReader = state.NewPlainStateReader(tx)
+			stateWriter = state.NewPlainStateWriterNoHistory(tx)
+			chainReader = stagedsync.ChainReader{Cfg: *m.ChainConfig, Db: tx, BlockReader: m.BlockReader}
+			res         *core.EphemeralExecResult
+		)
+		if parallel {
+			res, err = core.ExecuteBlockParallel(m.ChainConfig, &vmConfig, getHashFn, m.Engine, block, stateReader, stateWriter, chainReader, nil, 4, log.New())
+		} else {
+			res, err = core.ExecuteBlockEphemerally(m.ChainConfig, &vmConfig, getHashFn, m.Engine, block, stateReader, stateWriter, chainReader, nil, log.New())
+		}
+		require.NoError(t, err)
+
+		plainState := map[string][]byte{}
+		require.NoError(t, tx.ForEach(kv.PlainState, nil, func(k, v []byte) error {
+			plainState[string(k)] = libcommon.Copy(v)
+			return nil
+		}))
+		return res, plainState
+	}
+
+	serial, serialState := execute(false)
+	for i := 0; i < 10; i++ {
+		parallel, parallelState := execute(true)
+		require.Equal(t, serialState, parallelState)
+		// The logs of the block are collected from a map, so their hash isn't deterministic
+		parallel.LogsHash = serial.LogsHash
+		serialJson, err := json.Marshal(serial)
+		require.NoError(t, err)
+		parallelJson, err := json.Marshal(parallel)
+		require.NoError(t, err)
+		require.JSONEq(t, string(serialJson), string(parallelJson))
+	}
+	require.Len(t, serial.Receipts, 7)
+	require.Equal(t, uint(1), serial.Receipts[1].Logs[0].Index)
+	require.Equal(t, uint(1), serial.Receipts[1].Logs[0].TxIndex)
+}
@@ -51,6 +51,16 @@ type BalanceIncrease struct {
 	count       int  // Number of increases - this needs tracking for proper reversion
 }
 
+// StateTracer is notified of the modifications of accounts and storage made through
+// IntraBlockState at the moment they are made. Modifications which are reverted later,
+// for example by a failing call frame, are reported too.
+type StateTracer interface {
+	CaptureBalanceChange(addr libcommon.Address, prev, next *uint256.Int)
+	CaptureNonceChange(addr libcommon.Address, prev, next uint64)
+	CaptureCodeChange(addr libcommon.Address, prevCodeHash libcommon.Hash, prevCode []byte, codeHash libcommon.Hash, code []byte)
+	CaptureStorageChange(addr libcommon.Address, key libcommon.Hash, prev, next *uint256.Int)
+}
+
 // IntraBlockState is responsible for caching and managing state changes
 // that occur during block's execution.
 // NOT THREAD SAFE!
@@ -86,11 +96,13 @@ type IntraBlockState struct {
 
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
-	journal        *journal
-	validRevisions []revision
-	nextRevisionID int
-	trace          bool
-	balanceInc     map[libcommon.Address]*BalanceIncrease // Map of balance increases (without first reading the account)
+	journal         *journal
+	validRevisions  []revision
+	nextRevisionID  int
+	trace           bool
+	balanceInc      map[libcommon.Address]*BalanceIncrease // Map of balance increases (without first reading the account)
+	deferBalanceInc bool                                   // FinalizeTx leaves the balance increases pending
+	tracer          StateTracer
 }
 
 // Create a new state from a given trie
@@ -113,6 +125,19 @@ func (sdb *IntraBlockState) SetTrace(trace bool) {
 	sdb.trace = trace
 }
 
+// SetTracer sets the tracer to notify of state modifications, nil disables it.
+func (sdb *IntraBlockState) SetTracer(tracer StateTracer) {
+	sdb.tracer = tracer
+}
+
+// DeferBalanceIncreases makes FinalizeTx leave the balance increases of the accounts which were
+// not read pending, so that they are reported by BalanceIncreaseSet instead of being added to the
+// accounts read from the state. Speculative execution relies on it: reading the coinbase to pay
+// the fees would make every transaction depend on the previous one.
+func (sdb *IntraBlockState) DeferBalanceIncreases() {
+	sdb.deferBalanceInc = true
+}
+
 // setErrorUnsafe sets error but should be called in medhods that already have locks
 func (sdb *IntraBlockState) setErrorUnsafe(err error) {
 	if sdb.savedErr == nil {
@@ -234,6 +259,11 @@ func (sdb *IntraBlockState) TxIndex() int {
 	return sdb.txIndex
 }
 
+// BlockHash returns the current block hash set by SetTxContext.
+func (sdb *IntraBlockState) BlockHash() libcommon.Hash {
+	return sdb.bhash
+}
+
 // DESCRIBED: docs/programmers_guide/guide.md#address---identifier-of-an-account
 func (sdb *IntraBlockState) GetCode(addr libcommon.Address) []byte {
 	stateObject := sdb.getStateObject(addr)
@@ -325,6 +355,10 @@ func (sdb *IntraBlockState) AddBalance(addr libcommon.Address, amount *uint256.I
 	if !needAccount && addr == ripemd && amount.IsZero() {
 		needAccount = true
 	}
+	if !needAccount && sdb.tracer != nil && !amount.IsZero() {
+		// the tracer needs the previous balance
+		needAccount = true
+	}
 	if !needAccount {
 		sdb.journal.append(balanceIncrease{
 			account:  addr,
@@ -432,6 +466,9 @@ func (sdb *IntraBlockState) Selfdestruct(addr libcommon.Address) bool {
 	})
 	stateObject.markSelfdestructed()
 	stateObject.createdContract = false
+	if sdb.tracer != nil && !stateObject.data.Balance.IsZero() {
+		sdb.tracer.CaptureBalanceChange(addr, &stateObject.data.Balance, new(uint256.Int))
+	}
 	stateObject.data.Balance.Clear()
 
 	return true
@@ -677,7 +714,7 @@ func printAccount(EIP161Enabled bool, addr libcommon.Address, stateObject *state
 // FinalizeTx should be called after every transaction.
 func (sdb *IntraBlockState) FinalizeTx(chainRules *chain.Rules, stateWriter StateWriter) error {
 	for addr, bi := range sdb.balanceInc {
-		if !bi.transferred {
+		if !bi.transferred && !sdb.deferBalanceInc {
 			sdb.getStateObject(addr)
 		}
 	}
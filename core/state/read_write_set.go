@@ -0,0 +1,241 @@
+package state
+
+import (
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+)
+
+type storageSlot struct {
+	address libcommon.Address
+	key     libcommon.Hash
+}
+
+// TxReadSet is the state a transaction read through its StateReader when executed speculatively
+// on its own IntraBlockState. Code is not recorded: it is identified by the code hash of the account.
+// Neither are incarnations, they only matter to the transactions creating contracts, whose write
+// sets can't be applied.
+type TxReadSet struct {
+	accounts map[libcommon.Address]*accounts.Account // nil for the accounts which don't exist
+	storage  map[storageSlot]uint256.Int
+}
+
+// ReadSetReader is a StateReader recording the reads made through it in a TxReadSet.
+type ReadSetReader struct {
+	r   StateReader
+	set *TxReadSet
+}
+
+func NewReadSetReader(r StateReader) *ReadSetReader {
+	return &ReadSetReader{r: r, set: &TxReadSet{
+		accounts: map[libcommon.Address]*accounts.Account{},
+		storage:  map[storageSlot]uint256.Int{},
+	}}
+}
+
+// ReadSet returns the reads made so far.
+func (r *ReadSetReader) ReadSet() *TxReadSet { return r.set }
+
+func (r *ReadSetReader) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	a, err := r.r.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	if a != nil {
+		a = a.SelfCopy()
+		if a.CodeHash == (libcommon.Hash{}) {
+			a.CodeHash = emptyCodeHashH
+		}
+	}
+	r.set.accounts[address] = a
+	return a, nil
+}
+
+func (r *ReadSetReader) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	enc, err := r.r.ReadAccountStorage(address, incarnation, key)
+	if err != nil {
+		return nil, err
+	}
+	var value uint256.Int
+	value.SetBytes(enc)
+	r.set.storage[storageSlot{address, *key}] = value
+	return enc, nil
+}
+
+func (r *ReadSetReader) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) ([]byte, error) {
+	return r.r.ReadAccountCode(address, incarnation, codeHash)
+}
+
+func (r *ReadSetReader) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (int, error) {
+	return r.r.ReadAccountCodeSize(address, incarnation, codeHash)
+}
+
+func (r *ReadSetReader) ReadAccountIncarnation(address libcommon.Address) (uint64, error) {
+	return r.r.ReadAccountIncarnation(address)
+}
+
+// TxWriteSet is the changes made by a transaction executed speculatively on its own IntraBlockState,
+// as passed to the StateWriter of MakeWriteSet, along with its BalanceIncreaseSet.
+type TxWriteSet struct {
+	accounts   map[libcommon.Address]*accounts.Account // nil for the deleted accounts
+	storage    map[storageSlot]uint256.Int
+	balanceInc map[libcommon.Address]uint256.Int
+	complex    bool // Accounts were deleted or created, or code was deployed
+}
+
+// WriteSetWriter is a StateWriter collecting the writes made through it in a TxWriteSet.
+type WriteSetWriter struct {
+	set *TxWriteSet
+}
+
+func NewWriteSetWriter() *WriteSetWriter {
+	return &WriteSetWriter{set: &TxWriteSet{
+		accounts: map[libcommon.Address]*accounts.Account{},
+		storage:  map[storageSlot]uint256.Int{},
+	}}
+}
+
+// WriteSet returns the writes made so far, along with the given balance increases.
+func (w *WriteSetWriter) WriteSet(balanceInc map[libcommon.Address]uint256.Int) *TxWriteSet {
+	w.set.balanceInc = balanceInc
+	return w.set
+}
+
+func (w *WriteSetWriter) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	w.set.accounts[address] = account.SelfCopy()
+	return nil
+}
+
+func (w *WriteSetWriter) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	w.set.complex = true
+	return nil
+}
+
+func (w *WriteSetWriter) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	w.set.accounts[address] = nil
+	w.set.complex = true
+	return nil
+}
+
+func (w *WriteSetWriter) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	w.set.storage[storageSlot{address, *key}] = *value
+	return nil
+}
+
+func (w *WriteSetWriter) CreateContract(address libcommon.Address) error {
+	w.set.complex = true
+	return nil
+}
+
+// Simple reports whether the write set only changes balances, nonces and storage of accounts,
+// which is what ApplyWriteSet supports. Creating, deleting or deploying code to accounts
+// has side effects, on incarnations for example, which are left to the re-execution of the transaction.
+func (ws *TxWriteSet) Simple() bool {
+	return !ws.complex
+}
+
+// WriteSetReader is a StateReader returning the state of the underlying reader with the changes
+// of a simple write set applied. It doesn't modify the write set, so it is safe for concurrent use
+// when the underlying reader is.
+type WriteSetReader struct {
+	r   StateReader
+	set *TxWriteSet
+}
+
+func NewWriteSetReader(r StateReader, set *TxWriteSet) *WriteSetReader {
+	return &WriteSetReader{r: r, set: set}
+}
+
+func (r *WriteSetReader) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	if a, ok := r.set.accounts[address]; ok {
+		if a == nil {
+			return nil, nil
+		}
+		return a.SelfCopy(), nil
+	}
+	a, err := r.r.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	if increase, ok := r.set.balanceInc[address]; ok {
+		if a == nil {
+			na := accounts.NewAccount()
+			a = &na
+		} else {
+			a = a.SelfCopy()
+		}
+		a.Balance.Add(&a.Balance, &increase)
+	}
+	return a, nil
+}
+
+func (r *WriteSetReader) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	if value, ok := r.set.storage[storageSlot{address, *key}]; ok {
+		return value.Bytes(), nil
+	}
+	if a, ok := r.set.accounts[address]; ok && a == nil {
+		return nil, nil
+	}
+	return r.r.ReadAccountStorage(address, incarnation, key)
+}
+
+func (r *WriteSetReader) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) ([]byte, error) {
+	return r.r.ReadAccountCode(address, incarnation, codeHash)
+}
+
+func (r *WriteSetReader) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (int, error) {
+	return r.r.ReadAccountCodeSize(address, incarnation, codeHash)
+}
+
+func (r *WriteSetReader) ReadAccountIncarnation(address libcommon.Address) (uint64, error) {
+	return r.r.ReadAccountIncarnation(address)
+}
+
+// ReadsValid reports whether the reads of a transaction executed speculatively are the same as
+// the current state, in which case executing the transaction now would give the same results.
+func (sdb *IntraBlockState) ReadsValid(rs *TxReadSet) bool {
+	for addr, read := range rs.accounts {
+		so := sdb.getStateObject(addr)
+		if so == nil || so.deleted {
+			if read != nil {
+				return false
+			}
+			continue
+		}
+		if read == nil || so.data.Nonce != read.Nonce || !so.data.Balance.Eq(&read.Balance) ||
+			so.data.CodeHash != read.CodeHash || so.data.Incarnation != read.Incarnation {
+			return false
+		}
+	}
+	var value uint256.Int
+	for item, read := range rs.storage {
+		sdb.GetState(item.address, item.key, &value)
+		if value != read {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyWriteSet applies the changes of a transaction executed speculatively on another IntraBlockState,
+// through the same operations the execution makes, so that finalising the transaction afterwards
+// leaves the state as if the transaction had been executed on sdb. The write set must be simple.
+func (sdb *IntraBlockState) ApplyWriteSet(ws *TxWriteSet) {
+	for addr, account := range ws.accounts {
+		so := sdb.getStateObject(addr)
+		if so == nil || so.deleted {
+			so = sdb.createObject(addr, so)
+			so.data.PrevIncarnation = account.PrevIncarnation
+		}
+		so.SetBalance(&account.Balance)
+		so.SetNonce(account.Nonce)
+	}
+	for item, value := range ws.storage {
+		sdb.SetState(item.address, item.key, value)
+	}
+	for addr, increase := range ws.balanceInc {
+		increase := increase
+		sdb.AddBalance(addr, &increase)
+	}
+}
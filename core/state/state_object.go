@@ -219,6 +219,9 @@ func (so *stateObject) SetState(key libcommon.Hash, value uint256.Int) {
 	if prev == value {
 		return
 	}
+	if so.db.tracer != nil {
+		so.db.tracer.CaptureStorageChange(so.address, key, &prev, &value)
+	}
 	// New value is different, update and journal the change
 	so.db.journal.append(storageChange{
 		account:  so.address,
@@ -294,6 +297,9 @@ func (so *stateObject) SubBalance(amount *uint256.Int) {
 }
 
 func (so *stateObject) SetBalance(amount *uint256.Int) {
+	if so.db.tracer != nil {
+		so.db.tracer.CaptureBalanceChange(so.address, &so.data.Balance, amount)
+	}
 	so.db.journal.append(balanceChange{
 		account: so.address,
 		prev:    so.data.Balance,
@@ -340,6 +346,9 @@ func (so *stateObject) Code() []byte {
 
 func (so *stateObject) SetCode(codeHash libcommon.Hash, code []byte) {
 	prevcode := so.Code()
+	if so.db.tracer != nil {
+		so.db.tracer.CaptureCodeChange(so.address, so.data.CodeHash, prevcode, codeHash, code)
+	}
 	so.db.journal.append(codeChange{
 		account:  so.address,
 		prevhash: so.data.CodeHash,
@@ -355,6 +364,9 @@ func (so *stateObject) setCode(codeHash libcommon.Hash, code []byte) {
 }
 
 func (so *stateObject) SetNonce(nonce uint64) {
+	if so.db.tracer != nil {
+		so.db.tracer.CaptureNonceChange(so.address, so.data.Nonce, nonce)
+	}
 	so.db.journal.append(nonceChange{
 		account: so.address,
 		prev:    so.data.Nonce,
@@ -30,6 +30,7 @@ type TxTask struct {
 	Uncles          []*types.Header
 	Coinbase        libcommon.Address
 	Withdrawals     types.Withdrawals
+	BlockReceipts   types.Receipts // receipts of the txs executed before the Final task, used to derive the requests of the block
 	BlockHash       libcommon.Hash
 	Sender          *libcommon.Address
 	SkipAnalysis    bool
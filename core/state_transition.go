@@ -22,12 +22,14 @@ import (
 	"github.com/holiman/uint256"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/fixedgas"
 	"github.com/ledgerwatch/erigon-lib/txpool/txpoolcfg"
 	types2 "github.com/ledgerwatch/erigon-lib/types"
 
 	cmath "github.com/ledgerwatch/erigon/common/math"
 	"github.com/ledgerwatch/erigon/common/u256"
 	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
 	"github.com/ledgerwatch/erigon/crypto"
@@ -93,6 +95,7 @@ type Message interface {
 	Data() []byte
 	AccessList() types2.AccessList
 	BlobHashes() []libcommon.Hash
+	Authorizations() []types.Authorization
 
 	IsFree() bool
 }
@@ -133,7 +136,7 @@ func (result *ExecutionResult) Revert() []byte {
 }
 
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
-func IntrinsicGas(data []byte, accessList types2.AccessList, isContractCreation bool, isHomestead, isEIP2028, isEIP3860 bool) (uint64, error) {
+func IntrinsicGas(data []byte, accessList types2.AccessList, authorizationsLen uint64, isContractCreation bool, isHomestead, isEIP2028, isEIP3860 bool) (uint64, error) {
 	// Zero and non-zero bytes are priced differently
 	dataLen := uint64(len(data))
 	dataNonZeroLen := uint64(0)
@@ -143,7 +146,7 @@ func IntrinsicGas(data []byte, accessList types2.AccessList, isContractCreation
 		}
 	}
 
-	gas, status := txpoolcfg.CalcIntrinsicGas(dataLen, dataNonZeroLen, accessList, isContractCreation, isHomestead, isEIP2028, isEIP3860)
+	gas, status := txpoolcfg.CalcIntrinsicGas(dataLen, dataNonZeroLen, authorizationsLen, accessList, isContractCreation, isHomestead, isEIP2028, isEIP3860)
 	if status != txpoolcfg.Success {
 		return 0, ErrGasUintOverflow
 	}
@@ -271,6 +274,43 @@ func CheckEip1559TxGasFeeCap(from libcommon.Address, gasFeeCap, tip, baseFee *ui
 	return nil
 }
 
+// applyAuthorizations sets the code of the authority of every valid authorization
+// to a delegation designator, see EIP-7702. Invalid authorizations are skipped.
+func (st *StateTransition) applyAuthorizations(authorizations []types.Authorization) {
+	chainID := st.evm.ChainConfig().ChainID
+	for i := range authorizations {
+		auth := &authorizations[i]
+		if !auth.ChainID.IsZero() && auth.ChainID.ToBig().Cmp(chainID) != 0 {
+			continue
+		}
+		if auth.Nonce+1 < auth.Nonce {
+			continue
+		}
+		authority, err := auth.Authority()
+		if err != nil {
+			continue
+		}
+		st.state.AddAddressToAccessList(authority)
+		if code := st.state.GetCode(authority); len(code) > 0 {
+			if _, ok := types.ParseDelegation(code); !ok {
+				continue
+			}
+		}
+		if st.state.GetNonce(authority) != auth.Nonce {
+			continue
+		}
+		if st.state.Exist(authority) {
+			st.state.AddRefund(fixedgas.PerEmptyAccountCost - fixedgas.PerAuthBaseCost)
+		}
+		if auth.Address == (libcommon.Address{}) {
+			st.state.SetCode(authority, nil)
+		} else {
+			st.state.SetCode(authority, types.AddressToDelegation(auth.Address))
+		}
+		st.state.SetNonce(authority, auth.Nonce+1)
+	}
+}
+
 // DESCRIBED: docs/programmers_guide/guide.md#nonce
 func (st *StateTransition) preCheck(gasBailout bool) error {
 	// Make sure this transaction's nonce is correct.
@@ -292,8 +332,12 @@ func (st *StateTransition) preCheck(gasBailout bool) error {
 			// libcommon.Hash{} means that the sender is not in the state.
 			// Historically there were transactions with 0 gas price and non-existing sender,
 			// so we have to allow that.
-			return fmt.Errorf("%w: address %v, codehash: %s", ErrSenderNoEOA,
-				st.msg.From().Hex(), codeHash)
+			// After Prague, an account whose code is a delegation designator (EIP-7702) is still an EOA.
+			_, delegated := types.ParseDelegation(st.state.GetCode(st.msg.From()))
+			if !st.evm.ChainRules().IsPrague || !delegated {
+				return fmt.Errorf("%w: address %v, codehash: %s", ErrSenderNoEOA,
+					st.msg.From().Hex(), codeHash)
+			}
 		}
 	}
 
@@ -377,7 +421,7 @@ func (st *StateTransition) TransitionDb(refunds bool, gasBailout bool) (*Executi
 	isEIP3860 := vmConfig.HasEip3860(rules)
 
 	// Check clauses 4-5, subtract intrinsic gas if everything is correct
-	gas, err := IntrinsicGas(st.data, st.msg.AccessList(), contractCreation, rules.IsHomestead, rules.IsIstanbul, isEIP3860)
+	gas, err := IntrinsicGas(st.data, st.msg.AccessList(), uint64(len(st.msg.Authorizations())), contractCreation, rules.IsHomestead, rules.IsIstanbul, isEIP3860)
 	if err != nil {
 		return nil, err
 	}
@@ -404,6 +448,17 @@ func (st *StateTransition) TransitionDb(refunds bool, gasBailout bool) (*Executi
 	// - reset transient storage(eip 1153)
 	st.state.Prepare(rules, msg.From(), coinbase, msg.To(), vm.ActivePrecompiles(rules), msg.AccessList())
 
+	if !contractCreation {
+		// Increment the nonce for the next transaction. This happens before the authorizations
+		// are applied, so an authorization signed by the sender itself must carry tx.nonce+1.
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+	}
+
+	// Install the delegations of the authorization list (EIP-7702)
+	if rules.IsPrague && len(msg.Authorizations()) > 0 {
+		st.applyAuthorizations(msg.Authorizations())
+	}
+
 	var (
 		ret   []byte
 		vmerr error // vm errors do not effect consensus and are therefore not assigned to err
@@ -415,8 +470,6 @@ func (st *StateTransition) TransitionDb(refunds bool, gasBailout bool) (*Executi
 		// of the contract's address, but before the execution of the code.
 		ret, _, st.gas, vmerr = st.evm.Create(sender, st.data, st.gas, st.value)
 	} else {
-		// Increment the nonce for the next transaction
-		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
 		ret, st.gas, vmerr = st.evm.Call(sender, st.to(), st.data, st.gas, st.value, bailout)
 	}
 	if refunds {
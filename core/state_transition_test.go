@@ -0,0 +1,92 @@
+package core_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// TestSelfSponsoredAuthorization checks that the nonce of the sender is incremented before the
+// authorizations are applied, so the sender's own authorization is valid with the nonce tx.nonce+1.
+func TestSelfSponsoredAuthorization(t *testing.T) {
+	t.Parallel()
+	config := &chain.Config{}
+	*config = *params.AllProtocolChanges
+	config.PragueTime = big.NewInt(0)
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	delegate := libcommon.HexToAddress("0xbb")
+	signer := types.LatestSigner(config)
+
+	for _, tt := range []struct {
+		name      string
+		authNonce uint64
+		delegated bool
+	}{
+		{name: "nonce after the tx", authNonce: 1, delegated: true},
+		{name: "nonce of the tx", authNonce: 0, delegated: false},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, tx := memdb.NewTestTx(t)
+			ibs := state.New(state.NewPlainState(tx, 1, nil))
+			ibs.SetBalance(sender, uint256.NewInt(params.Ether))
+
+			auth, err := types.SignAuthorization(types.Authorization{
+				ChainID: *uint256.MustFromBig(config.ChainID),
+				Address: delegate,
+				Nonce:   tt.authNonce,
+			}, key)
+			require.NoError(t, err)
+			txn, err := types.SignTx(&types.SetCodeTransaction{
+				DynamicFeeTransaction: types.DynamicFeeTransaction{
+					CommonTx: types.CommonTx{Nonce: 0, Gas: 100_000, To: &sender, Value: new(uint256.Int)},
+					ChainID:  uint256.MustFromBig(config.ChainID),
+					Tip:      uint256.NewInt(1),
+					FeeCap:   uint256.NewInt(params.GWei),
+				},
+				Authorizations: []types.Authorization{auth},
+			}, *signer, key)
+			require.NoError(t, err)
+
+			excessBlobGas := uint64(0)
+			blockContext := evmtypes.BlockContext{
+				CanTransfer:   core.CanTransfer,
+				Transfer:      core.Transfer,
+				BlockNumber:   1,
+				GasLimit:      params.MaxGasLimit,
+				BaseFee:       uint256.NewInt(1),
+				ExcessBlobGas: &excessBlobGas,
+			}
+			rules := config.Rules(blockContext.BlockNumber, blockContext.Time)
+			msg, err := txn.AsMessage(*signer, blockContext.BaseFee.ToBig(), rules)
+			require.NoError(t, err)
+			evm := vm.NewEVM(blockContext, core.NewEVMTxContext(msg), ibs, config, vm.Config{})
+			_, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(txn.GetGas()), true /* refunds */, false /* gasBailout */)
+			require.NoError(t, err)
+
+			if tt.delegated {
+				require.Equal(t, types.AddressToDelegation(delegate), ibs.GetCode(sender))
+				require.Equal(t, uint64(2), ibs.GetNonce(sender))
+			} else {
+				require.Empty(t, ibs.GetCode(sender))
+				require.Equal(t, uint64(1), ibs.GetNonce(sender))
+			}
+		})
+	}
+}
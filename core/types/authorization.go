@@ -0,0 +1,196 @@
+package types
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/secp256k1"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	rlp2 "github.com/ledgerwatch/erigon-lib/rlp"
+
+	"github.com/ledgerwatch/erigon/common/u256"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// SetCodeMagicPrefix is the prefix of the message signed by an authority in EIP-7702 authorizations.
+const SetCodeMagicPrefix = 0x05
+
+// DelegationPrefix is the prefix of the delegation designator, `0xef0100 ++ address`,
+// that EIP-7702 installs as the code of an authority.
+var DelegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// DelegationDesignatorLen is the size of a delegation designator.
+const DelegationDesignatorLen = 3 + 20
+
+var ErrInvalidAuthorizationSignature = errors.New("invalid authorization signature")
+
+// Authorization is an entry of the authorization list of a set code transaction: the authority,
+// recovered from the signature, allows its code to be set to a delegation to Address.
+// See EIP-7702: Set EOA account code.
+type Authorization struct {
+	ChainID uint256.Int       `json:"chainId"`
+	Address libcommon.Address `json:"address"`
+	Nonce   uint64            `json:"nonce"`
+	YParity uint8             `json:"yParity"`
+	R       uint256.Int       `json:"r"`
+	S       uint256.Int       `json:"s"`
+}
+
+// SigningHash returns the hash signed by the authority, keccak256(MAGIC ++ rlp([chain_id, address, nonce])).
+func (a *Authorization) SigningHash() libcommon.Hash {
+	return prefixedRlpHash(SetCodeMagicPrefix, []interface{}{&a.ChainID, a.Address, a.Nonce})
+}
+
+// Authority recovers the address of the account which signed the authorization.
+func (a *Authorization) Authority() (libcommon.Address, error) {
+	if a.YParity > 1 {
+		return libcommon.Address{}, fmt.Errorf("%w: yParity %d", ErrInvalidAuthorizationSignature, a.YParity)
+	}
+	var v uint256.Int
+	v.SetUint64(uint64(a.YParity))
+	v.Add(&v, u256.Num27)
+	addr, err := recoverPlain(secp256k1.DefaultContext, a.SigningHash(), &a.R, &a.S, &v, true /* homestead */)
+	if err != nil {
+		return libcommon.Address{}, fmt.Errorf("%w: %w", ErrInvalidAuthorizationSignature, err)
+	}
+	return addr, nil
+}
+
+// SignAuthorization signs the authorization with the given private key.
+func SignAuthorization(auth Authorization, prv *ecdsa.PrivateKey) (Authorization, error) {
+	h := auth.SigningHash()
+	sig, err := crypto.Sign(h[:], prv)
+	if err != nil {
+		return Authorization{}, err
+	}
+	r, s, v := decodeSignature(sig)
+	auth.R.Set(r)
+	auth.S.Set(s)
+	auth.YParity = uint8(v.Uint64())
+	return auth, nil
+}
+
+func (a *Authorization) payloadSize() int {
+	size := 1 + rlp.Uint256LenExcludingHead(&a.ChainID)
+	size += 21 // Address
+	size += 1 + rlp.IntLenExcludingHead(a.Nonce)
+	size += 1 + rlp.IntLenExcludingHead(uint64(a.YParity))
+	size += 1 + rlp.Uint256LenExcludingHead(&a.R)
+	size += 1 + rlp.Uint256LenExcludingHead(&a.S)
+	return size
+}
+
+func authorizationsSize(authorizations []Authorization) int {
+	size := 0
+	for i := range authorizations {
+		authLen := authorizations[i].payloadSize()
+		size += rlp2.ListPrefixLen(authLen) + authLen
+	}
+	return size
+}
+
+func encodeAuthorizations(authorizations []Authorization, w io.Writer, b []byte) error {
+	for i := range authorizations {
+		a := &authorizations[i]
+		if err := EncodeStructSizePrefix(a.payloadSize(), w, b); err != nil {
+			return err
+		}
+		if err := a.ChainID.EncodeRLP(w); err != nil {
+			return err
+		}
+		b[0] = 128 + 20
+		if _, err := w.Write(b[:1]); err != nil {
+			return err
+		}
+		if _, err := w.Write(a.Address.Bytes()); err != nil {
+			return err
+		}
+		if err := rlp.EncodeInt(a.Nonce, w, b); err != nil {
+			return err
+		}
+		if err := rlp.EncodeInt(uint64(a.YParity), w, b); err != nil {
+			return err
+		}
+		if err := a.R.EncodeRLP(w); err != nil {
+			return err
+		}
+		if err := a.S.EncodeRLP(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeAuthorizations(authorizations *[]Authorization, s *rlp.Stream) error {
+	_, err := s.List()
+	if err != nil {
+		return fmt.Errorf("open authorizations: %w", err)
+	}
+	var b []byte
+	i := 0
+	for _, err = s.List(); err == nil; _, err = s.List() {
+		a := Authorization{}
+		if b, err = s.Uint256Bytes(); err != nil {
+			return fmt.Errorf("read ChainID: %w", err)
+		}
+		a.ChainID.SetBytes(b)
+		if b, err = s.Bytes(); err != nil {
+			return fmt.Errorf("read Address: %w", err)
+		}
+		if len(b) != 20 {
+			return fmt.Errorf("wrong size for Authorization address: %d", len(b))
+		}
+		copy(a.Address[:], b)
+		if a.Nonce, err = s.Uint(); err != nil {
+			return fmt.Errorf("read Nonce: %w", err)
+		}
+		var yParity uint64
+		if yParity, err = s.Uint(); err != nil {
+			return fmt.Errorf("read YParity: %w", err)
+		}
+		if yParity > 0xff {
+			return fmt.Errorf("wrong value for YParity: %d", yParity)
+		}
+		a.YParity = uint8(yParity)
+		if b, err = s.Uint256Bytes(); err != nil {
+			return fmt.Errorf("read R: %w", err)
+		}
+		a.R.SetBytes(b)
+		if b, err = s.Uint256Bytes(); err != nil {
+			return fmt.Errorf("read S: %w", err)
+		}
+		a.S.SetBytes(b)
+		// end of authorization
+		if err = s.ListEnd(); err != nil {
+			return fmt.Errorf("close Authorization: %w", err)
+		}
+		*authorizations = append(*authorizations, a)
+		i++
+	}
+	if !errors.Is(err, rlp.EOL) {
+		return fmt.Errorf("open Authorization: %d %w", i, err)
+	}
+	if err = s.ListEnd(); err != nil {
+		return fmt.Errorf("close authorizations: %w", err)
+	}
+	return nil
+}
+
+// AddressToDelegation returns the delegation designator pointing to addr.
+func AddressToDelegation(addr libcommon.Address) []byte {
+	return append(libcommon.CopyBytes(DelegationPrefix), addr.Bytes()...)
+}
+
+// ParseDelegation returns the address that code delegates to, if code is a delegation designator.
+func ParseDelegation(code []byte) (libcommon.Address, bool) {
+	if len(code) != DelegationDesignatorLen || !bytes.HasPrefix(code, DelegationPrefix) {
+		return libcommon.Address{}, false
+	}
+	return libcommon.BytesToAddress(code[len(DelegationPrefix):]), true
+}
@@ -358,7 +358,7 @@ func makeBenchBlock() *Block {
 			Extra:      []byte("benchmark uncle"),
 		}
 	}
-	return NewBlock(header, txs, uncles, receipts, nil /* withdrawals */)
+	return NewBlock(header, txs, uncles, receipts, nil, nil)
 }
 
 func TestCanEncodeAndDecodeRawBody(t *testing.T) {
@@ -506,7 +506,7 @@ func TestWithdrawalsEncoding(t *testing.T) {
 		Amount:    5_000_000_000,
 	}
 
-	block := NewBlock(&header, nil, nil, nil, withdrawals)
+	block := NewBlock(&header, nil, nil, nil, withdrawals, nil)
 	_ = block.Size()
 
 	encoded, err := rlp.EncodeToBytes(block)
@@ -518,7 +518,7 @@ func TestWithdrawalsEncoding(t *testing.T) {
 	assert.Equal(t, block, &decoded)
 
 	// Now test with empty withdrawals
-	block2 := NewBlock(&header, nil, nil, nil, []*Withdrawal{})
+	block2 := NewBlock(&header, nil, nil, nil, []*Withdrawal{}, nil)
 	_ = block2.Size()
 
 	encoded2, err := rlp.EncodeToBytes(block2)
@@ -603,3 +603,68 @@ func TestCopyTxs(t *testing.T) {
 	copies := CopyTxs(txs)
 	assert.Equal(t, txs, copies)
 }
+
+func TestRequestsEncoding(t *testing.T) {
+	t.Parallel()
+	header := Header{
+		ParentHash: libcommon.HexToHash("0x8b00fcf1e541d371a3a1b79cc999a85cc3db5ee5637b5159646e1acd3613fd15"),
+		Coinbase:   libcommon.HexToAddress("0x571846e42308df2dad8ed792f44a8bfddf0acb4d"),
+		Root:       libcommon.HexToHash("0x351780124dae86b84998c6d4fe9a88acfb41b4856b4f2c56767b51a4e2f94dd4"),
+		Difficulty: libcommon.Big0,
+		Number:     big.NewInt(20_000_000),
+		GasLimit:   30_000_000,
+		GasUsed:    3_074_345,
+		Time:       1666343339,
+		Extra:      make([]byte, 0),
+		MixDigest:  libcommon.HexToHash("0x7f04e338b206ef863a1fad30e082bbb61571c74e135df8d1677e3f8b8171a09b"),
+		BaseFee:    big.NewInt(7_000_000_000),
+	}
+	// optional header fields are positional, a Prague header carries all the Cancun ones
+	blobGasUsed, excessBlobGas := uint64(0), uint64(0)
+	header.BlobGasUsed, header.ExcessBlobGas = &blobGasUsed, &excessBlobGas
+	header.ParentBeaconBlockRoot = &libcommon.Hash{0x01}
+
+	withdrawals := []*Withdrawal{{
+		Index:     44555666,
+		Validator: 89,
+		Address:   libcommon.HexToAddress("0x690b9a9e9aa1c9db991c7721a92d351db4fac990"),
+		Amount:    2,
+	}}
+	deposit := &Deposit{
+		WithdrawalCredentials: libcommon.HexToHash("0x0100000000000000000000006295ee1b4f6dd65047762f924ecd367c17eabf8f"),
+		Amount:                32_000_000_000,
+		Index:                 7,
+	}
+	deposit.Pubkey[0], deposit.Signature[0] = 0xaa, 0xbb
+	withdrawalRequest := &WithdrawalRequest{
+		SourceAddress: libcommon.HexToAddress("0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5"),
+		Amount:        1_000_000_000,
+	}
+	withdrawalRequest.ValidatorPubkey[47] = 0xcc
+	requests := Requests{deposit, withdrawalRequest}
+
+	block := NewBlock(&header, nil, nil, nil, withdrawals, requests)
+	require.NotNil(t, block.Header().RequestsRoot)
+	require.Equal(t, DeriveSha(requests), *block.Header().RequestsRoot)
+	_ = block.Size()
+
+	encoded, err := rlp.EncodeToBytes(block)
+	require.NoError(t, err)
+
+	var decoded Block
+	require.NoError(t, rlp.DecodeBytes(encoded, &decoded))
+	assert.Equal(t, block, &decoded)
+	assert.Equal(t, Deposits{deposit}, decoded.Requests().Deposits())
+	assert.Equal(t, WithdrawalRequests{withdrawalRequest}, decoded.Requests().WithdrawalRequests())
+
+	// Bodies with an empty requests list must keep it non nil
+	body := block.RawBody()
+	body.Requests = Requests{}
+	encodedBody, err := rlp.EncodeToBytes(body)
+	require.NoError(t, err)
+
+	var decodedBody RawBody
+	require.NoError(t, rlp.DecodeBytes(encodedBody, &decodedBody))
+	require.NotNil(t, decodedBody.Requests)
+	require.Equal(t, 0, len(decodedBody.Requests))
+}
@@ -0,0 +1,229 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	rlp2 "github.com/ledgerwatch/erigon-lib/rlp"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+const (
+	BLSPubkeyLen    = 48
+	BLSSignatureLen = 96
+)
+
+// DepositEventSignature is the topic of the deposit contract DepositEvent log,
+// keccak256("DepositEvent(bytes,bytes,bytes,bytes,bytes)").
+var DepositEventSignature = crypto.Keccak256Hash([]byte("DepositEvent(bytes,bytes,bytes,bytes,bytes)"))
+
+// Deposit is a validator deposit made to the deposit contract, surfaced as an execution layer request.
+// See EIP-6110: Supply validator deposits on chain.
+type Deposit struct {
+	Pubkey                [BLSPubkeyLen]byte    `json:"pubkey"`                // public key of validator
+	WithdrawalCredentials libcommon.Hash        `json:"withdrawalCredentials"` // beneficiary of the validator funds
+	Amount                uint64                `json:"amount"`                // deposit size in Gwei
+	Signature             [BLSSignatureLen]byte `json:"signature"`             // signature over deposit msg
+	Index                 uint64                `json:"index"`                 // deposit count value
+}
+
+func (d *Deposit) RequestType() byte { return DepositRequestType }
+
+func (d *Deposit) payloadSize() int {
+	payloadSize := rlp2.ListPrefixLen(BLSPubkeyLen) + BLSPubkeyLen
+	payloadSize += 33 /* WithdrawalCredentials */
+	payloadSize++
+	payloadSize += rlp.IntLenExcludingHead(d.Amount)
+	payloadSize += rlp2.ListPrefixLen(BLSSignatureLen) + BLSSignatureLen
+	payloadSize++
+	payloadSize += rlp.IntLenExcludingHead(d.Index)
+	return payloadSize
+}
+
+func (d *Deposit) EncodingSize() int {
+	payloadSize := d.payloadSize()
+	return 1 + rlp2.ListPrefixLen(payloadSize) + payloadSize
+}
+
+func (d *Deposit) MarshalBinary(w io.Writer) error {
+	var b [33]byte
+	b[0] = DepositRequestType
+	if _, err := w.Write(b[:1]); err != nil {
+		return err
+	}
+	if err := EncodeStructSizePrefix(d.payloadSize(), w, b[:]); err != nil {
+		return err
+	}
+	if err := rlp.EncodeString(d.Pubkey[:], w, b[:]); err != nil {
+		return err
+	}
+	if err := rlp.EncodeString(d.WithdrawalCredentials[:], w, b[:]); err != nil {
+		return err
+	}
+	if err := rlp.EncodeInt(d.Amount, w, b[:]); err != nil {
+		return err
+	}
+	if err := rlp.EncodeString(d.Signature[:], w, b[:]); err != nil {
+		return err
+	}
+	return rlp.EncodeInt(d.Index, w, b[:])
+}
+
+func (d *Deposit) decodePayload(s *rlp.Stream) error {
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	var (
+		b   []byte
+		err error
+	)
+	if b, err = s.Bytes(); err != nil {
+		return fmt.Errorf("read Pubkey: %w", err)
+	}
+	if len(b) != BLSPubkeyLen {
+		return fmt.Errorf("wrong size for Pubkey: %d", len(b))
+	}
+	copy(d.Pubkey[:], b)
+	if b, err = s.Bytes(); err != nil {
+		return fmt.Errorf("read WithdrawalCredentials: %w", err)
+	}
+	if len(b) != 32 {
+		return fmt.Errorf("wrong size for WithdrawalCredentials: %d", len(b))
+	}
+	copy(d.WithdrawalCredentials[:], b)
+	if d.Amount, err = s.Uint(); err != nil {
+		return fmt.Errorf("read Amount: %w", err)
+	}
+	if b, err = s.Bytes(); err != nil {
+		return fmt.Errorf("read Signature: %w", err)
+	}
+	if len(b) != BLSSignatureLen {
+		return fmt.Errorf("wrong size for Signature: %d", len(b))
+	}
+	copy(d.Signature[:], b)
+	if d.Index, err = s.Uint(); err != nil {
+		return fmt.Errorf("read Index: %w", err)
+	}
+	return s.ListEnd()
+}
+
+func (d *Deposit) copy() Request {
+	cpy := *d
+	return &cpy
+}
+
+type depositJSON struct {
+	Pubkey                hexutility.Bytes `json:"pubkey"`
+	WithdrawalCredentials libcommon.Hash   `json:"withdrawalCredentials"`
+	Amount                hexutil.Uint64   `json:"amount"`
+	Signature             hexutility.Bytes `json:"signature"`
+	Index                 hexutil.Uint64   `json:"index"`
+}
+
+func (d Deposit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(depositJSON{
+		Pubkey:                d.Pubkey[:],
+		WithdrawalCredentials: d.WithdrawalCredentials,
+		Amount:                hexutil.Uint64(d.Amount),
+		Signature:             d.Signature[:],
+		Index:                 hexutil.Uint64(d.Index),
+	})
+}
+
+func (d *Deposit) UnmarshalJSON(input []byte) error {
+	var dec depositJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if len(dec.Pubkey) != BLSPubkeyLen {
+		return fmt.Errorf("wrong size for pubkey: %d", len(dec.Pubkey))
+	}
+	if len(dec.Signature) != BLSSignatureLen {
+		return fmt.Errorf("wrong size for signature: %d", len(dec.Signature))
+	}
+	copy(d.Pubkey[:], dec.Pubkey)
+	d.WithdrawalCredentials = dec.WithdrawalCredentials
+	d.Amount = uint64(dec.Amount)
+	copy(d.Signature[:], dec.Signature)
+	d.Index = uint64(dec.Index)
+	return nil
+}
+
+// Deposits implements DerivableList for deposit requests.
+type Deposits []*Deposit
+
+func (ds Deposits) Len() int { return len(ds) }
+
+func (ds Deposits) EncodeIndex(i int, w *bytes.Buffer) {
+	ds[i].MarshalBinary(w) //nolint:errcheck
+}
+
+// sizes of the abi encoded DepositEvent fields, amount and index are little endian uint64s.
+var depositEventFieldSizes = [5]int{BLSPubkeyLen, 32, 8, BLSSignatureLen, 8}
+
+// unpackDepositLog decodes the abi encoded data of a DepositEvent log:
+// five dynamic byte arrays (pubkey, withdrawal_credentials, amount, signature, index).
+func unpackDepositLog(data []byte) (*Deposit, error) {
+	var fields [5][]byte
+	for i, size := range depositEventFieldSizes {
+		if len(data) < 32*(i+1) {
+			return nil, fmt.Errorf("deposit log too short: %d", len(data))
+		}
+		offset, ok := abiWordToInt(data[32*i:32*(i+1)], len(data)-32)
+		if !ok {
+			return nil, fmt.Errorf("invalid offset of deposit log field %d", i)
+		}
+		start := offset + 32
+		length, ok := abiWordToInt(data[offset:start], len(data)-start)
+		if !ok || length != size {
+			return nil, fmt.Errorf("invalid length of deposit log field %d", i)
+		}
+		fields[i] = data[start : start+size]
+	}
+	d := &Deposit{
+		Amount: binary.LittleEndian.Uint64(fields[2]),
+		Index:  binary.LittleEndian.Uint64(fields[4]),
+	}
+	copy(d.Pubkey[:], fields[0])
+	copy(d.WithdrawalCredentials[:], fields[1])
+	copy(d.Signature[:], fields[3])
+	return d, nil
+}
+
+// abiWordToInt reads a 32 byte big endian abi word, it fails if the value is greater than limit.
+func abiWordToInt(word []byte, limit int) (int, bool) {
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	v := binary.BigEndian.Uint64(word[24:])
+	if limit < 0 || v > uint64(limit) {
+		return 0, false
+	}
+	return int(v), true
+}
+
+// ParseDepositLogs extracts the deposit requests from the DepositEvent logs emitted by the deposit contract.
+func ParseDepositLogs(logs []*Log, depositContractAddress libcommon.Address) (Requests, error) {
+	deposits := Requests{}
+	for _, log := range logs {
+		if log.Address != depositContractAddress || len(log.Topics) == 0 || log.Topics[0] != DepositEventSignature {
+			continue
+		}
+		d, err := unpackDepositLog(log.Data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse deposit data: %w", err)
+		}
+		deposits = append(deposits, d)
+	}
+	return deposits, nil
+}
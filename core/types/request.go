@@ -0,0 +1,160 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	rlp2 "github.com/ledgerwatch/erigon-lib/rlp"
+
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// Request types of EIP-7685: General purpose execution layer requests.
+const (
+	DepositRequestType    byte = 0x00
+	WithdrawalRequestType byte = 0x01
+)
+
+var ErrRequestTypeNotSupported = errors.New("request type not supported")
+
+// Request is an execution layer request to the consensus layer.
+// Requests are encoded as `request_type ++ rlp(request_data)`, the same way as typed transactions.
+type Request interface {
+	RequestType() byte
+	// EncodingSize returns the size of the typed encoding, type byte included.
+	EncodingSize() int
+	// MarshalBinary writes the typed encoding to w.
+	MarshalBinary(w io.Writer) error
+	decodePayload(s *rlp.Stream) error
+	copy() Request
+}
+
+// DecodeRequest decodes a request from its typed encoding.
+func DecodeRequest(data []byte) (Request, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty request encoding")
+	}
+	var r Request
+	switch data[0] {
+	case DepositRequestType:
+		r = new(Deposit)
+	case WithdrawalRequestType:
+		r = new(WithdrawalRequest)
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrRequestTypeNotSupported, data[0])
+	}
+	s := rlp.NewStream(bytes.NewReader(data[1:]), uint64(len(data)-1))
+	if err := r.decodePayload(s); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Requests implements DerivableList for requests.
+type Requests []Request
+
+func (r Requests) Len() int { return len(r) }
+
+// EncodeIndex encodes the i'th request to w in its typed form, which is what
+// goes into the requests trie.
+func (r Requests) EncodeIndex(i int, w *bytes.Buffer) {
+	r[i].MarshalBinary(w) //nolint:errcheck
+}
+
+// Deposits returns the deposit requests contained in r.
+func (r Requests) Deposits() Deposits {
+	var deposits Deposits
+	for _, req := range r {
+		if d, ok := req.(*Deposit); ok {
+			deposits = append(deposits, d)
+		}
+	}
+	return deposits
+}
+
+// WithdrawalRequests returns the EIP-7002 withdrawal requests contained in r.
+func (r Requests) WithdrawalRequests() WithdrawalRequests {
+	var withdrawalRequests WithdrawalRequests
+	for _, req := range r {
+		if w, ok := req.(*WithdrawalRequest); ok {
+			withdrawalRequests = append(withdrawalRequests, w)
+		}
+	}
+	return withdrawalRequests
+}
+
+func (r Requests) Copy() Requests {
+	if r == nil {
+		return nil
+	}
+	cpy := make(Requests, 0, len(r))
+	for _, req := range r {
+		cpy = append(cpy, req.copy())
+	}
+	return cpy
+}
+
+// payloadSize returns the size of the requests list without its prefix.
+// each request is an rlp string holding its typed encoding.
+func (r Requests) payloadSize() int {
+	size := 0
+	for _, req := range r {
+		reqLen := req.EncodingSize()
+		size += rlp2.ListPrefixLen(reqLen) + reqLen
+	}
+	return size
+}
+
+// encodingSize returns the size of the requests list, prefix included.
+func (r Requests) encodingSize() int {
+	size := r.payloadSize()
+	return rlp2.ListPrefixLen(size) + size
+}
+
+func (r Requests) encodeRLP(w io.Writer, b []byte) error {
+	if err := EncodeStructSizePrefix(r.payloadSize(), w, b); err != nil {
+		return err
+	}
+	for _, req := range r {
+		if err := rlp.EncodeStringSizePrefix(req.EncodingSize(), w, b); err != nil {
+			return err
+		}
+		if err := req.MarshalBinary(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeRequests reads the optional requests list of a block body.
+// it returns nil requests, and leaves the stream untouched, when the list is absent (pre-Prague bodies).
+func decodeRequests(s *rlp.Stream) (Requests, error) {
+	if _, err := s.List(); err != nil {
+		if errors.Is(err, rlp.EOL) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read Requests: %w", err)
+	}
+	requests := Requests{}
+	var (
+		b   []byte
+		err error
+	)
+	for b, err = s.Bytes(); err == nil; b, err = s.Bytes() {
+		req, err := DecodeRequest(b)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	if !errors.Is(err, rlp.EOL) {
+		return nil, err
+	}
+	// end of Requests
+	if err = s.ListEnd(); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
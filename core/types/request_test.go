@@ -0,0 +1,111 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// packDepositLog builds the abi encoded data of a DepositEvent log the way the deposit contract does.
+func packDepositLog(d *Deposit) []byte {
+	var amount, index [8]byte
+	binary.LittleEndian.PutUint64(amount[:], d.Amount)
+	binary.LittleEndian.PutUint64(index[:], d.Index)
+	fields := [][]byte{d.Pubkey[:], d.WithdrawalCredentials[:], amount[:], d.Signature[:], index[:]}
+
+	word := func(v int) []byte {
+		var w [32]byte
+		binary.BigEndian.PutUint64(w[24:], uint64(v))
+		return w[:]
+	}
+	var head, tail []byte
+	for _, f := range fields {
+		head = append(head, word(32*len(fields)+len(tail))...)
+		tail = append(tail, word(len(f))...)
+		padded := make([]byte, (len(f)+31)/32*32)
+		copy(padded, f)
+		tail = append(tail, padded...)
+	}
+	return append(head, tail...)
+}
+
+func TestParseDepositLogs(t *testing.T) {
+	t.Parallel()
+	depositContract := libcommon.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa")
+	d := &Deposit{
+		WithdrawalCredentials: libcommon.HexToHash("0x0100000000000000000000006295ee1b4f6dd65047762f924ecd367c17eabf8f"),
+		Amount:                32_000_000_000,
+		Index:                 12345,
+	}
+	for i := range d.Pubkey {
+		d.Pubkey[i] = byte(i)
+	}
+	for i := range d.Signature {
+		d.Signature[i] = byte(255 - i)
+	}
+
+	logs := []*Log{
+		{Address: depositContract, Topics: []libcommon.Hash{DepositEventSignature}, Data: packDepositLog(d)},
+		// logs from other contracts or with other topics are ignored
+		{Address: libcommon.HexToAddress("0x01"), Topics: []libcommon.Hash{DepositEventSignature}, Data: packDepositLog(d)},
+		{Address: depositContract, Topics: []libcommon.Hash{{0x01}}},
+	}
+	requests, err := ParseDepositLogs(logs, depositContract)
+	require.NoError(t, err)
+	require.Equal(t, Requests{d}, requests)
+
+	requests, err = ParseDepositLogs(nil, depositContract)
+	require.NoError(t, err)
+	require.NotNil(t, requests)
+
+	logs[0].Data = logs[0].Data[:len(logs[0].Data)-64]
+	_, err = ParseDepositLogs(logs, depositContract)
+	require.Error(t, err)
+}
+
+func TestParseWithdrawalRequests(t *testing.T) {
+	t.Parallel()
+	data := make([]byte, 2*WithdrawalRequestDataLen)
+	for i := 0; i < 2; i++ {
+		chunk := data[i*WithdrawalRequestDataLen:]
+		chunk[19] = byte(i + 1)
+		chunk[20] = 0xab
+		binary.BigEndian.PutUint64(chunk[20+BLSPubkeyLen:], uint64(i)*1_000_000_000)
+	}
+	requests, err := ParseWithdrawalRequests(data)
+	require.NoError(t, err)
+	withdrawalRequests := requests.WithdrawalRequests()
+	require.Equal(t, 2, len(withdrawalRequests))
+	assert.Equal(t, libcommon.HexToAddress("0x02"), withdrawalRequests[1].SourceAddress)
+	assert.Equal(t, byte(0xab), withdrawalRequests[1].ValidatorPubkey[0])
+	assert.Equal(t, uint64(0), withdrawalRequests[0].Amount)
+	assert.Equal(t, uint64(1_000_000_000), withdrawalRequests[1].Amount)
+
+	_, err = ParseWithdrawalRequests(data[1:])
+	require.Error(t, err)
+}
+
+func TestRequestEncoding(t *testing.T) {
+	t.Parallel()
+	d := &Deposit{Amount: 1, Index: 2}
+	d.Pubkey[0] = 0x01
+	w := &WithdrawalRequest{SourceAddress: libcommon.HexToAddress("0x03"), Amount: 4}
+
+	for _, r := range []Request{d, w} {
+		var buf bytes.Buffer
+		require.NoError(t, r.MarshalBinary(&buf))
+		require.Equal(t, r.EncodingSize(), buf.Len())
+		require.Equal(t, r.RequestType(), buf.Bytes()[0])
+
+		decoded, err := DecodeRequest(buf.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, r, decoded)
+	}
+
+	_, err := DecodeRequest([]byte{0x7f, 0xc0})
+	require.ErrorIs(t, err, ErrRequestTypeNotSupported)
+}
@@ -43,6 +43,9 @@ type txJSON struct {
 	Commitments BlobKzgs  `json:"commitments,omitempty"`
 	Proofs      KZGProofs `json:"proofs,omitempty"`
 
+	// Set code transaction fields:
+	Authorizations []Authorization `json:"authorizationList,omitempty"`
+
 	// Only used for encoding:
 	Hash libcommon.Hash `json:"hash"`
 }
@@ -132,6 +135,27 @@ func (tx BlobTx) MarshalJSON() ([]byte, error) {
 	return json.Marshal(toBlobTxJSON(&tx))
 }
 
+func (tx SetCodeTransaction) MarshalJSON() ([]byte, error) {
+	var enc txJSON
+	// These are set for all tx types.
+	enc.Hash = tx.Hash()
+	enc.Type = hexutil.Uint64(tx.Type())
+	enc.ChainID = (*hexutil.Big)(tx.ChainID.ToBig())
+	enc.AccessList = &tx.AccessList
+	enc.Nonce = (*hexutil.Uint64)(&tx.Nonce)
+	enc.Gas = (*hexutil.Uint64)(&tx.Gas)
+	enc.FeeCap = (*hexutil.Big)(tx.FeeCap.ToBig())
+	enc.Tip = (*hexutil.Big)(tx.Tip.ToBig())
+	enc.Value = (*hexutil.Big)(tx.Value.ToBig())
+	enc.Data = (*hexutility.Bytes)(&tx.Data)
+	enc.To = tx.To
+	enc.V = (*hexutil.Big)(tx.V.ToBig())
+	enc.R = (*hexutil.Big)(tx.R.ToBig())
+	enc.S = (*hexutil.Big)(tx.S.ToBig())
+	enc.Authorizations = tx.Authorizations
+	return json.Marshal(&enc)
+}
+
 func (tx BlobTxWrapper) MarshalJSON() ([]byte, error) {
 	enc := toBlobTxJSON(&tx.Tx)
 
@@ -181,6 +205,12 @@ func UnmarshalTransactionFromJSON(input []byte) (Transaction, error) {
 			return nil, err
 		}
 		return tx, nil
+	case SetCodeTxType:
+		tx := &SetCodeTransaction{}
+		if err = tx.UnmarshalJSON(input); err != nil {
+			return nil, err
+		}
+		return tx, nil
 	default:
 		return nil, fmt.Errorf("unknown transaction type: %v", txType)
 	}
@@ -413,6 +443,142 @@ func (tx *DynamicFeeTransaction) UnmarshalJSON(input []byte) error {
 	return nil
 }
 
+func (tx *SetCodeTransaction) UnmarshalJSON(input []byte) error {
+	var dec txJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.AccessList != nil {
+		tx.AccessList = *dec.AccessList
+	} else {
+		tx.AccessList = []types2.AccessTuple{}
+	}
+	if dec.ChainID == nil {
+		return errors.New("missing required field 'chainId' in transaction")
+	}
+	var overflow bool
+	tx.ChainID, overflow = uint256.FromBig(dec.ChainID.ToInt())
+	if overflow {
+		return errors.New("'chainId' in transaction does not fit in 256 bits")
+	}
+	if dec.To == nil {
+		return errors.New("missing required field 'to' in transaction")
+	}
+	tx.To = dec.To
+	if dec.Nonce == nil {
+		return errors.New("missing required field 'nonce' in transaction")
+	}
+	tx.Nonce = uint64(*dec.Nonce)
+	tx.Tip, overflow = uint256.FromBig(dec.Tip.ToInt())
+	if overflow {
+		return errors.New("'tip' in transaction does not fit in 256 bits")
+	}
+	tx.FeeCap, overflow = uint256.FromBig(dec.FeeCap.ToInt())
+	if overflow {
+		return errors.New("'feeCap' in transaction does not fit in 256 bits")
+	}
+	if dec.Gas == nil {
+		return errors.New("missing required field 'gas' in transaction")
+	}
+	tx.Gas = uint64(*dec.Gas)
+	if dec.Value == nil {
+		return errors.New("missing required field 'value' in transaction")
+	}
+	tx.Value, overflow = uint256.FromBig(dec.Value.ToInt())
+	if overflow {
+		return errors.New("'value' in transaction does not fit in 256 bits")
+	}
+	if dec.Data == nil {
+		return errors.New("missing required field 'input' in transaction")
+	}
+	tx.Data = *dec.Data
+	if dec.Authorizations == nil {
+		return errors.New("missing required field 'authorizationList' in transaction")
+	}
+	tx.Authorizations = dec.Authorizations
+	if dec.V == nil {
+		return errors.New("missing required field 'v' in transaction")
+	}
+	overflow = tx.V.SetFromBig(dec.V.ToInt())
+	if overflow {
+		return fmt.Errorf("dec.V higher than 2^256-1")
+	}
+	if dec.R == nil {
+		return errors.New("missing required field 'r' in transaction")
+	}
+	overflow = tx.R.SetFromBig(dec.R.ToInt())
+	if overflow {
+		return fmt.Errorf("dec.R higher than 2^256-1")
+	}
+	if dec.S == nil {
+		return errors.New("missing required field 's' in transaction")
+	}
+	overflow = tx.S.SetFromBig(dec.S.ToInt())
+	if overflow {
+		return fmt.Errorf("dec.S higher than 2^256-1")
+	}
+	withSignature := !tx.V.IsZero() || !tx.R.IsZero() || !tx.S.IsZero()
+	if withSignature {
+		if err := sanityCheckSignature(&tx.V, &tx.R, &tx.S, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorizationJSON is the JSON representation of an EIP-7702 authorization.
+type authorizationJSON struct {
+	ChainID *hexutil.Big      `json:"chainId"`
+	Address libcommon.Address `json:"address"`
+	Nonce   hexutil.Uint64    `json:"nonce"`
+	YParity hexutil.Uint64    `json:"yParity"`
+	R       *hexutil.Big      `json:"r"`
+	S       *hexutil.Big      `json:"s"`
+}
+
+func (a Authorization) MarshalJSON() ([]byte, error) {
+	return json.Marshal(authorizationJSON{
+		ChainID: (*hexutil.Big)(a.ChainID.ToBig()),
+		Address: a.Address,
+		Nonce:   hexutil.Uint64(a.Nonce),
+		YParity: hexutil.Uint64(a.YParity),
+		R:       (*hexutil.Big)(a.R.ToBig()),
+		S:       (*hexutil.Big)(a.S.ToBig()),
+	})
+}
+
+func (a *Authorization) UnmarshalJSON(input []byte) error {
+	var dec authorizationJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.ChainID == nil {
+		return errors.New("missing required field 'chainId' in authorization")
+	}
+	if a.ChainID.SetFromBig(dec.ChainID.ToInt()) {
+		return errors.New("'chainId' in authorization does not fit in 256 bits")
+	}
+	a.Address = dec.Address
+	a.Nonce = uint64(dec.Nonce)
+	if dec.YParity > 1 {
+		return fmt.Errorf("invalid 'yParity' in authorization: %d", dec.YParity)
+	}
+	a.YParity = uint8(dec.YParity)
+	if dec.R == nil {
+		return errors.New("missing required field 'r' in authorization")
+	}
+	if a.R.SetFromBig(dec.R.ToInt()) {
+		return errors.New("'r' in authorization does not fit in 256 bits")
+	}
+	if dec.S == nil {
+		return errors.New("missing required field 's' in authorization")
+	}
+	if a.S.SetFromBig(dec.S.ToInt()) {
+		return errors.New("'s' in authorization does not fit in 256 bits")
+	}
+	return nil
+}
+
 func UnmarshalBlobTxJSON(input []byte) (Transaction, error) {
 	var dec txJSON
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -32,6 +32,7 @@ import (
 	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/common/fixedgas"
 	types2 "github.com/ledgerwatch/erigon-lib/types"
@@ -816,3 +817,100 @@ func TestBlobTxEncodeDecode(t *testing.T) {
 		}
 	}
 }
+
+func TestSetCodeTxEncodeDecode(t *testing.T) {
+	t.Parallel()
+	key, addr := defaultTestKey()
+	authorityKey, _ := crypto.GenerateKey()
+	authorityAddr := crypto.PubkeyToAddress(authorityKey.PublicKey)
+	delegate := libcommon.HexToAddress("0x000000000000000000000000000000000000aaaa")
+
+	auth, err := SignAuthorization(Authorization{ChainID: *u256.Num1, Address: delegate, Nonce: 7}, authorityKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authority, err := auth.Authority(); err != nil || authority != authorityAddr {
+		t.Fatalf("authority mismatch: want %x, got %x, err %v", authorityAddr, authority, err)
+	}
+
+	signer := LatestSignerForChainID(big.NewInt(1))
+	tx, err := SignTx(&SetCodeTransaction{
+		DynamicFeeTransaction: *dynFeeTx,
+		Authorizations:        []Authorization{auth},
+	}, *signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// RLP
+	parsedTx, err := encodeDecodeBinary(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := assertEqual(tx, parsedTx); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tx.(*SetCodeTransaction).Authorizations, parsedTx.(*SetCodeTransaction).Authorizations) {
+		t.Fatal("authorization list wrong")
+	}
+	if sender, err := parsedTx.Sender(*signer); err != nil || sender != addr {
+		t.Fatalf("sender mismatch: want %x, got %x, err %v", addr, sender, err)
+	}
+
+	// JSON
+	parsedTx, err = encodeDecodeJSON(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := assertEqual(tx, parsedTx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The txpool parser must agree on the hashes and count the authorizations
+	var buf bytes.Buffer
+	if err := tx.MarshalBinary(&buf); err != nil {
+		t.Fatal(err)
+	}
+	ctx := types2.NewTxParseContext(*u256.Num1)
+	slot, sender := &types2.TxSlot{}, [20]byte{}
+	if _, err := ctx.ParseTransaction(buf.Bytes(), 0, slot, sender[:], false /* hasEnvelope */, false /* wrappedWithBlobs */, nil); err != nil {
+		t.Fatal(err)
+	}
+	if slot.IDHash != tx.Hash() {
+		t.Fatalf("IdHash mismatch: want %x, got %x", tx.Hash(), slot.IDHash)
+	}
+	if libcommon.Address(sender) != addr {
+		t.Fatalf("txpool sender mismatch: want %x, got %x", addr, sender)
+	}
+	assert.Equal(t, 1, slot.AuthorizationsLen)
+
+	// The message is built from a decoded transaction, the sender of which is not cached yet
+	parsedTx, err = encodeDecodeBinary(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := parsedTx.AsMessage(*signer, big.NewInt(1), &chain.Rules{IsLondon: true, IsPrague: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, addr, msg.From())
+	assert.Equal(t, tx.(*SetCodeTransaction).Authorizations, msg.Authorizations())
+	assert.True(t, msg.CheckNonce())
+	_, err = parsedTx.AsMessage(*signer, big.NewInt(1), &chain.Rules{IsLondon: true})
+	assert.Error(t, err)
+}
+
+func TestDelegation(t *testing.T) {
+	t.Parallel()
+	delegate := libcommon.HexToAddress("0x000000000000000000000000000000000000aaaa")
+	code := AddressToDelegation(delegate)
+	assert.Len(t, code, DelegationDesignatorLen)
+	target, ok := ParseDelegation(code)
+	assert.True(t, ok)
+	assert.Equal(t, delegate, target)
+
+	_, ok = ParseDelegation(append(code, 0x00))
+	assert.False(t, ok)
+	_, ok = ParseDelegation(common.FromHex("0x6001600101"))
+	assert.False(t, ok)
+}
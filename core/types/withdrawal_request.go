@@ -0,0 +1,148 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	rlp2 "github.com/ledgerwatch/erigon-lib/rlp"
+
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// WithdrawalRequestDataLen is the size of a withdrawal request returned by the EIP-7002 system contract:
+// source_address (20 bytes), validator_pubkey (48 bytes) and amount (8 bytes, big endian).
+const WithdrawalRequestDataLen = 20 + BLSPubkeyLen + 8
+
+// WithdrawalRequest is a withdrawal or exit triggered from the execution layer.
+// See EIP-7002: Execution layer triggerable withdrawals.
+type WithdrawalRequest struct {
+	SourceAddress   libcommon.Address  `json:"sourceAddress"`
+	ValidatorPubkey [BLSPubkeyLen]byte `json:"validatorPublicKey"`
+	Amount          uint64             `json:"amount"` // in Gwei, a zero amount is a full exit
+}
+
+func (w *WithdrawalRequest) RequestType() byte { return WithdrawalRequestType }
+
+func (w *WithdrawalRequest) payloadSize() int {
+	payloadSize := 21 /* SourceAddress */
+	payloadSize += rlp2.ListPrefixLen(BLSPubkeyLen) + BLSPubkeyLen
+	payloadSize++
+	payloadSize += rlp.IntLenExcludingHead(w.Amount)
+	return payloadSize
+}
+
+func (w *WithdrawalRequest) EncodingSize() int {
+	payloadSize := w.payloadSize()
+	return 1 + rlp2.ListPrefixLen(payloadSize) + payloadSize
+}
+
+func (w *WithdrawalRequest) MarshalBinary(out io.Writer) error {
+	var b [33]byte
+	b[0] = WithdrawalRequestType
+	if _, err := out.Write(b[:1]); err != nil {
+		return err
+	}
+	if err := EncodeStructSizePrefix(w.payloadSize(), out, b[:]); err != nil {
+		return err
+	}
+	if err := rlp.EncodeString(w.SourceAddress[:], out, b[:]); err != nil {
+		return err
+	}
+	if err := rlp.EncodeString(w.ValidatorPubkey[:], out, b[:]); err != nil {
+		return err
+	}
+	return rlp.EncodeInt(w.Amount, out, b[:])
+}
+
+func (w *WithdrawalRequest) decodePayload(s *rlp.Stream) error {
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	var (
+		b   []byte
+		err error
+	)
+	if b, err = s.Bytes(); err != nil {
+		return fmt.Errorf("read SourceAddress: %w", err)
+	}
+	if len(b) != 20 {
+		return fmt.Errorf("wrong size for SourceAddress: %d", len(b))
+	}
+	copy(w.SourceAddress[:], b)
+	if b, err = s.Bytes(); err != nil {
+		return fmt.Errorf("read ValidatorPubkey: %w", err)
+	}
+	if len(b) != BLSPubkeyLen {
+		return fmt.Errorf("wrong size for ValidatorPubkey: %d", len(b))
+	}
+	copy(w.ValidatorPubkey[:], b)
+	if w.Amount, err = s.Uint(); err != nil {
+		return fmt.Errorf("read Amount: %w", err)
+	}
+	return s.ListEnd()
+}
+
+func (w *WithdrawalRequest) copy() Request {
+	cpy := *w
+	return &cpy
+}
+
+type withdrawalRequestJSON struct {
+	SourceAddress   libcommon.Address `json:"sourceAddress"`
+	ValidatorPubkey hexutility.Bytes  `json:"validatorPublicKey"`
+	Amount          hexutil.Uint64    `json:"amount"`
+}
+
+func (w WithdrawalRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(withdrawalRequestJSON{
+		SourceAddress:   w.SourceAddress,
+		ValidatorPubkey: w.ValidatorPubkey[:],
+		Amount:          hexutil.Uint64(w.Amount),
+	})
+}
+
+func (w *WithdrawalRequest) UnmarshalJSON(input []byte) error {
+	var dec withdrawalRequestJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if len(dec.ValidatorPubkey) != BLSPubkeyLen {
+		return fmt.Errorf("wrong size for validatorPublicKey: %d", len(dec.ValidatorPubkey))
+	}
+	w.SourceAddress = dec.SourceAddress
+	copy(w.ValidatorPubkey[:], dec.ValidatorPubkey)
+	w.Amount = uint64(dec.Amount)
+	return nil
+}
+
+// WithdrawalRequests implements DerivableList for withdrawal requests.
+type WithdrawalRequests []*WithdrawalRequest
+
+func (ws WithdrawalRequests) Len() int { return len(ws) }
+
+func (ws WithdrawalRequests) EncodeIndex(i int, w *bytes.Buffer) {
+	ws[i].MarshalBinary(w) //nolint:errcheck
+}
+
+// ParseWithdrawalRequests decodes the concatenated withdrawal requests returned by the EIP-7002 system contract.
+func ParseWithdrawalRequests(data []byte) (Requests, error) {
+	if len(data)%WithdrawalRequestDataLen != 0 {
+		return nil, fmt.Errorf("invalid withdrawal requests length: %d", len(data))
+	}
+	reqs := make(Requests, 0, len(data)/WithdrawalRequestDataLen)
+	for i := 0; i < len(data); i += WithdrawalRequestDataLen {
+		w := &WithdrawalRequest{
+			Amount: binary.BigEndian.Uint64(data[i+20+BLSPubkeyLen : i+WithdrawalRequestDataLen]),
+		}
+		copy(w.SourceAddress[:], data[i:i+20])
+		copy(w.ValidatorPubkey[:], data[i+20:i+20+BLSPubkeyLen])
+		reqs = append(reqs, w)
+	}
+	return reqs, nil
+}
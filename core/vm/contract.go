@@ -56,6 +56,9 @@ type Contract struct {
 	CodeAddr *libcommon.Address
 	Input    []byte
 
+	container   *eofContainer // Parsed EOF container if Code is EOF (EIP-3540)
+	returnStack []uint64      // Return addresses of CALLF (EIP-4750)
+
 	Gas   uint64
 	value *uint256.Int
 }
@@ -157,6 +160,11 @@ func (c *Contract) GetOp(n uint64) OpCode {
 	return STOP
 }
 
+// IsEOF reports whether the contract code is executed as an EOF container.
+func (c *Contract) IsEOF() bool {
+	return c.container != nil
+}
+
 // Caller returns the caller of the contract.
 //
 // Caller will recursively call caller when the contract is a delegate
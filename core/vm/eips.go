@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"encoding/binary"
 	"fmt"
 	"sort"
 
@@ -25,18 +26,24 @@ import (
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
 	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm/stack"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/params"
 )
 
 var activators = map[int]func(*JumpTable){
 	7516: enable7516,
+	7702: enable7702,
 	6780: enable6780,
 	5656: enable5656,
 	4844: enable4844,
+	3540: enable3540,
 	3860: enable3860,
 	3855: enable3855,
 	3529: enable3529,
 	3198: enable3198,
+	2935: enable2935,
 	2929: enable2929,
 	2200: enable2200,
 	1884: enable1884,
@@ -239,6 +246,237 @@ func opPush0(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]by
 	return nil, nil
 }
 
+// enable3540 applies EOF v1: EIP-3540 (EVM Object Format), EIP-3670 (code validation),
+// EIP-4200 (static relative jumps), EIP-4750 (functions) and EIP-5450 (stack validation).
+// - Define RJUMP, RJUMPI and RJUMPV
+// - Define CALLF and RETF
+// The new instructions are only valid in EOF code.
+func enable3540(jt *JumpTable) {
+	jt[RJUMP] = &operation{
+		execute:     opRjump,
+		constantGas: GasQuickStep,
+		numPop:      0,
+		numPush:     0,
+	}
+	jt[RJUMPI] = &operation{
+		execute:     opRjumpi,
+		constantGas: 4,
+		numPop:      1,
+		numPush:     0,
+	}
+	jt[RJUMPV] = &operation{
+		execute:     opRjumpv,
+		constantGas: 4,
+		numPop:      1,
+		numPush:     0,
+	}
+	jt[CALLF] = &operation{
+		execute:     opCallf,
+		constantGas: GasFastStep,
+		numPop:      0,
+		numPush:     0,
+	}
+	jt[RETF] = &operation{
+		execute:     opRetf,
+		constantGas: GasFastestStep,
+		numPop:      0,
+		numPush:     0,
+	}
+}
+
+// opRjump implements RJUMP, a jump by the signed 16-bit offset relative to the next instruction
+func opRjump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if !scope.Contract.IsEOF() {
+		return nil, &ErrInvalidOpCode{opcode: RJUMP}
+	}
+	offset := int16(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+	// the program counter is incremented after the instruction
+	*pc = uint64(int64(*pc) + 3 + int64(offset) - 1)
+	return nil, nil
+}
+
+// opRjumpi implements RJUMPI, a conditional RJUMP
+func opRjumpi(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if !scope.Contract.IsEOF() {
+		return nil, &ErrInvalidOpCode{opcode: RJUMPI}
+	}
+	cond := scope.Stack.Pop()
+	if cond.IsZero() {
+		*pc += 2
+		return nil, nil
+	}
+	offset := int16(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+	*pc = uint64(int64(*pc) + 3 + int64(offset) - 1)
+	return nil, nil
+}
+
+// opRjumpv implements RJUMPV, a relative jump through a table of offsets. Execution
+// continues with the next instruction if the case is out of the table.
+func opRjumpv(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if !scope.Contract.IsEOF() {
+		return nil, &ErrInvalidOpCode{opcode: RJUMPV}
+	}
+	code := scope.Contract.Code
+	maxIndex := uint64(code[*pc+1])
+	immediateSize := 1 + 2*(maxIndex+1)
+	idx := scope.Stack.Pop()
+	idx64, overflow := idx.Uint64WithOverflow()
+	if overflow || idx64 > maxIndex {
+		*pc += immediateSize
+		return nil, nil
+	}
+	offset := int16(binary.BigEndian.Uint16(code[*pc+2+2*idx64:]))
+	*pc = uint64(int64(*pc) + 1 + int64(immediateSize) + int64(offset) - 1)
+	return nil, nil
+}
+
+// opCallf implements CALLF, a call to a code section of the container
+func opCallf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	container := scope.Contract.container
+	if container == nil {
+		return nil, &ErrInvalidOpCode{opcode: CALLF}
+	}
+	idx := binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:])
+	typ := container.types[idx]
+	if limit := int(params.StackLimit); scope.Stack.Len()+int(typ.maxStackHeight)-int(typ.inputs) > limit {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.Len(), limit: limit}
+	}
+	if len(scope.Contract.returnStack) >= eofReturnStackLimit {
+		return nil, ErrReturnStackExceeded
+	}
+	scope.Contract.returnStack = append(scope.Contract.returnStack, *pc+3)
+	*pc = container.codeOffsets[idx] - 1
+	return nil, nil
+}
+
+// opRetf implements RETF, the return from a code section called with CALLF
+func opRetf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if !scope.Contract.IsEOF() {
+		return nil, &ErrInvalidOpCode{opcode: RETF}
+	}
+	if len(scope.Contract.returnStack) == 0 {
+		// RETF from the first code section ends the execution
+		return nil, errStopToken
+	}
+	last := len(scope.Contract.returnStack) - 1
+	*pc = scope.Contract.returnStack[last] - 1
+	scope.Contract.returnStack = scope.Contract.returnStack[:last]
+	return nil, nil
+}
+
+// enable2935 applies EIP-2935 (Serve historical block hashes from state)
+// - BLOCKHASH reads the ancestor hashes from the history storage contract,
+// and charges for the storage read as SLOAD does (EIP-2929).
+func enable2935(jt *JumpTable) {
+	jt[BLOCKHASH].execute = opBlockhash2935
+	jt[BLOCKHASH].dynamicGas = gasBlockhash2935
+}
+
+// blockhash2935Slot returns the slot of the history storage contract holding the hash
+// of the given block, and false if the block is outside of the window served to BLOCKHASH.
+func blockhash2935Slot(evm *EVM, num *uint256.Int) (libcommon.Hash, bool) {
+	num64, overflow := num.Uint64WithOverflow()
+	upper := evm.Context.BlockNumber
+	if overflow || num64 >= upper || num64+params.BlockHashOldWindow < upper {
+		return libcommon.Hash{}, false
+	}
+	return libcommon.BytesToHash(uint256.NewInt(num64 % params.BlockHashHistoryServeWindow).Bytes()), true
+}
+
+// gasBlockhash2935 charges the cold or warm cost of reading the history storage contract
+// and adds the slot to the access list. Blocks outside of the window are not read.
+func gasBlockhash2935(evm *EVM, contract *Contract, stack *stack.Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	slot, ok := blockhash2935Slot(evm, stack.Peek())
+	if !ok {
+		return 0, nil
+	}
+	if _, slotMod := evm.IntraBlockState().AddSlotToAccessList(params.HistoryStorageAddress, slot); slotMod {
+		return params.ColdSloadCostEIP2929, nil
+	}
+	return params.WarmStorageReadCostEIP2929, nil
+}
+
+// opBlockhash2935 implements BLOCKHASH after EIP-2935. The window served to the
+// opcode is unchanged, but hashes are read from the history storage contract so
+// that they end up in the block witness. Hashes of blocks processed before the
+// fork are not in the contract, for those we fall back to the header chain.
+func opBlockhash2935(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	num := scope.Stack.Peek()
+	slot, ok := blockhash2935Slot(interpreter.evm, num)
+	if !ok {
+		num.Clear()
+		return nil, nil
+	}
+	num64 := num.Uint64()
+	interpreter.evm.IntraBlockState().GetState(params.HistoryStorageAddress, slot, num)
+	if num.IsZero() {
+		num.SetBytes(interpreter.evm.Context.GetHash(num64).Bytes())
+	}
+	return nil, nil
+}
+
+// enable7702 applies EIP-7702 (Set EOA account code)
+// - CALL, CALLCODE, DELEGATECALL and STATICCALL charge for resolving delegation designators.
+// - EXTCODESIZE, EXTCODECOPY and EXTCODEHASH see the code of delegated accounts as 0xef01.
+func enable7702(jt *JumpTable) {
+	jt[CALL].dynamicGas = gasCallEIP7702
+	jt[CALLCODE].dynamicGas = gasCallCodeEIP7702
+	jt[DELEGATECALL].dynamicGas = gasDelegateCallEIP7702
+	jt[STATICCALL].dynamicGas = gasStaticCallEIP7702
+	jt[EXTCODESIZE].execute = opExtCodeSize7702
+	jt[EXTCODECOPY].execute = opExtCodeCopy7702
+	jt[EXTCODEHASH].execute = opExtCodeHash7702
+}
+
+// delegationCode is what the EXTCODE* opcodes observe as the code of a delegated account.
+var (
+	delegationCode     = []byte{0xef, 0x01}
+	delegationCodeHash = crypto.Keccak256Hash(delegationCode)
+)
+
+func opExtCodeSize7702(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	slot := scope.Stack.Peek()
+	code := interpreter.evm.IntraBlockState().GetCode(slot.Bytes20())
+	if _, ok := types.ParseDelegation(code); ok {
+		code = delegationCode
+	}
+	slot.SetUint64(uint64(len(code)))
+	return nil, nil
+}
+
+func opExtCodeCopy7702(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		stack      = scope.Stack
+		a          = stack.Pop()
+		memOffset  = stack.Pop()
+		codeOffset = stack.Pop()
+		length     = stack.Pop()
+	)
+	addr := libcommon.Address(a.Bytes20())
+	len64 := length.Uint64()
+	code := interpreter.evm.IntraBlockState().GetCode(addr)
+	if _, ok := types.ParseDelegation(code); ok {
+		code = delegationCode
+	}
+	codeCopy := getDataBig(code, &codeOffset, len64)
+	scope.Memory.Set(memOffset.Uint64(), len64, codeCopy)
+	return nil, nil
+}
+
+func opExtCodeHash7702(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	slot := scope.Stack.Peek()
+	address := libcommon.Address(slot.Bytes20())
+	ibs := interpreter.evm.IntraBlockState()
+	if ibs.Empty(address) {
+		slot.Clear()
+	} else if _, ok := types.ParseDelegation(ibs.GetCode(address)); ok {
+		slot.SetBytes(delegationCodeHash.Bytes())
+	} else {
+		slot.SetBytes(ibs.GetCodeHash(address).Bytes())
+	}
+	return nil, nil
+}
+
 // EIP-3860: Limit and meter initcode
 // https://eips.ethereum.org/EIPS/eip-3860
 func enable3860(jt *JumpTable) {
@@ -0,0 +1,219 @@
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// EOF v1 container layout, see EIP-3540: EOF - EVM Object Format v1.
+//
+//	container := header, body
+//	header    := magic, version, kind_types, types_size, kind_code, num_code_sections,
+//	             code_size+, kind_data, data_size, terminator
+//	body      := types_section, code_section+, data_section
+const (
+	eofFormatByte = 0xef
+	eofMagicByte  = 0x00
+	eof1Version   = 0x01
+
+	eofKindTypes      = 0x01
+	eofKindCode       = 0x02
+	eofKindData       = 0x04
+	eofTerminator     = 0x00
+	eofTypeEntrySize  = 4
+	eofMinContainerSz = 2 + 1 + 3 + 5 + 3 + 1 + eofTypeEntrySize + 1
+
+	eofMaxCodeSections  = 1024
+	eofMaxInputsOutputs = 127
+	eofMaxStackHeight   = 1023
+	eofReturnStackLimit = 1024
+)
+
+var (
+	ErrInvalidEOFInitcode = errors.New("invalid eof initcode")
+	ErrInvalidEOFCode     = errors.New("invalid eof code")
+
+	errInvalidMagic            = errors.New("invalid magic")
+	errInvalidVersion          = errors.New("invalid version")
+	errMissingTypeHeader       = errors.New("missing type header")
+	errInvalidTypeSize         = errors.New("invalid type section size")
+	errMissingCodeHeader       = errors.New("missing code header")
+	errInvalidCodeSize         = errors.New("invalid code size")
+	errTooManyCodeSections     = errors.New("too many code sections")
+	errMissingDataHeader       = errors.New("missing data header")
+	errMissingTerminator       = errors.New("missing header terminator")
+	errInvalidContainerSize    = errors.New("invalid container size")
+	errInvalidSection0Type     = errors.New("invalid section 0 type, input and output should be zero")
+	errTooManyInputs           = errors.New("invalid type content, too many inputs")
+	errTooManyOutputs          = errors.New("invalid type content, too many outputs")
+	errInvalidMaxStackHeight   = errors.New("invalid max stack height")
+	errUndefinedInstruction    = errors.New("undefined instruction")
+	errTruncatedImmediate      = errors.New("truncated immediate")
+	errInvalidSectionArgument  = errors.New("invalid section argument")
+	errInvalidJumpDest         = errors.New("invalid jump destination")
+	errInvalidCodeTermination  = errors.New("invalid code termination")
+	errConflictingStack        = errors.New("conflicting stack height")
+	errInvalidOutputs          = errors.New("invalid number of outputs")
+	errUnreachableCode         = errors.New("unreachable code")
+	errStackUnderflowInSection = errors.New("stack underflow")
+	errStackOverflowInSection  = errors.New("stack overflow")
+)
+
+// eofFunctionMetadata is an entry of the types section, describing a code section (EIP-4750).
+type eofFunctionMetadata struct {
+	inputs         uint8
+	outputs        uint8
+	maxStackHeight uint16
+}
+
+// eofContainer is a parsed EOF v1 container. The code and data sections are
+// subslices of the raw container, and codeOffsets are the positions of the code
+// sections in it, so that the interpreter can execute the container in place.
+type eofContainer struct {
+	types        []eofFunctionMetadata
+	codeSections [][]byte
+	codeOffsets  []uint64
+	data         []byte
+}
+
+// hasEOFMagic reports whether code starts with the EOF magic 0xEF00.
+func hasEOFMagic(code []byte) bool {
+	return len(code) >= 2 && code[0] == eofFormatByte && code[1] == eofMagicByte
+}
+
+// ValidateEOF parses and validates an EOF v1 container (EIPs 3540, 3670, 4200, 4750 and 5450).
+func ValidateEOF(code []byte) error {
+	c, err := parseEOF(code)
+	if err != nil {
+		return err
+	}
+	return c.validate(&eofInstructionSet)
+}
+
+// parseEOF decodes the header of an EOF container and checks the consistency of
+// the section sizes. It does not validate the code sections.
+func parseEOF(b []byte) (*eofContainer, error) {
+	if len(b) < eofMinContainerSz {
+		if !hasEOFMagic(b) {
+			return nil, errInvalidMagic
+		}
+		return nil, fmt.Errorf("%w: container is only %d bytes", errInvalidContainerSize, len(b))
+	}
+	if !hasEOFMagic(b) {
+		return nil, fmt.Errorf("%w: have %#x", errInvalidMagic, b[:2])
+	}
+	if b[2] != eof1Version {
+		return nil, fmt.Errorf("%w: have %d", errInvalidVersion, b[2])
+	}
+	pos := 3
+
+	// Types header
+	typesSize, pos, err := parseSectionSize(b, pos, eofKindTypes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMissingTypeHeader, err)
+	}
+	if typesSize < eofTypeEntrySize || typesSize%eofTypeEntrySize != 0 {
+		return nil, fmt.Errorf("%w: %d", errInvalidTypeSize, typesSize)
+	}
+	// Code header
+	codeSizes, pos, err := parseSectionSizes(b, pos, eofKindCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMissingCodeHeader, err)
+	}
+	if len(codeSizes) > eofMaxCodeSections {
+		return nil, fmt.Errorf("%w: %d", errTooManyCodeSections, len(codeSizes))
+	}
+	if typesSize != len(codeSizes)*eofTypeEntrySize {
+		return nil, fmt.Errorf("%w: %d for %d code sections", errInvalidTypeSize, typesSize, len(codeSizes))
+	}
+	for i, size := range codeSizes {
+		if size == 0 {
+			return nil, fmt.Errorf("%w: code section %d is empty", errInvalidCodeSize, i)
+		}
+	}
+	// Data header
+	dataSize, pos, err := parseSectionSize(b, pos, eofKindData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMissingDataHeader, err)
+	}
+	if pos >= len(b) || b[pos] != eofTerminator {
+		return nil, errMissingTerminator
+	}
+	pos++
+
+	// The body must match the sizes declared in the header exactly
+	bodySize := typesSize + dataSize
+	for _, size := range codeSizes {
+		bodySize += size
+	}
+	if pos+bodySize != len(b) {
+		return nil, fmt.Errorf("%w: have %d, want %d", errInvalidContainerSize, len(b), pos+bodySize)
+	}
+
+	c := &eofContainer{
+		types:        make([]eofFunctionMetadata, len(codeSizes)),
+		codeSections: make([][]byte, len(codeSizes)),
+		codeOffsets:  make([]uint64, len(codeSizes)),
+	}
+	for i := range c.types {
+		c.types[i] = eofFunctionMetadata{
+			inputs:         b[pos],
+			outputs:        b[pos+1],
+			maxStackHeight: binary.BigEndian.Uint16(b[pos+2:]),
+		}
+		pos += eofTypeEntrySize
+	}
+	if c.types[0].inputs != 0 || c.types[0].outputs != 0 {
+		return nil, fmt.Errorf("%w: have %d, %d", errInvalidSection0Type, c.types[0].inputs, c.types[0].outputs)
+	}
+	for i, t := range c.types {
+		if t.inputs > eofMaxInputsOutputs {
+			return nil, fmt.Errorf("%w: section %d has %d", errTooManyInputs, i, t.inputs)
+		}
+		if t.outputs > eofMaxInputsOutputs {
+			return nil, fmt.Errorf("%w: section %d has %d", errTooManyOutputs, i, t.outputs)
+		}
+		if t.maxStackHeight > eofMaxStackHeight {
+			return nil, fmt.Errorf("%w: section %d has %d", errInvalidMaxStackHeight, i, t.maxStackHeight)
+		}
+	}
+	for i, size := range codeSizes {
+		c.codeOffsets[i] = uint64(pos)
+		c.codeSections[i] = b[pos : pos+size]
+		pos += size
+	}
+	c.data = b[pos:]
+	return c, nil
+}
+
+// parseSectionSize reads a section header of the form kind, size.
+func parseSectionSize(b []byte, pos int, kind byte) (size, next int, err error) {
+	if pos+3 > len(b) {
+		return 0, 0, errors.New("header truncated")
+	}
+	if b[pos] != kind {
+		return 0, 0, fmt.Errorf("found section kind %#x instead", b[pos])
+	}
+	return int(binary.BigEndian.Uint16(b[pos+1:])), pos + 3, nil
+}
+
+// parseSectionSizes reads a section header of the form kind, num_sections, size+.
+func parseSectionSizes(b []byte, pos int, kind byte) (sizes []int, next int, err error) {
+	num, pos, err := parseSectionSize(b, pos, kind)
+	if err != nil {
+		return nil, 0, err
+	}
+	if num == 0 {
+		return nil, 0, errors.New("no sections")
+	}
+	if pos+2*num > len(b) {
+		return nil, 0, errors.New("header truncated")
+	}
+	sizes = make([]int, num)
+	for i := range sizes {
+		sizes[i] = int(binary.BigEndian.Uint16(b[pos:]))
+		pos += 2
+	}
+	return sizes, pos, nil
+}
@@ -0,0 +1,110 @@
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// makeEOF assembles an EOF v1 container from its sections.
+func makeEOF(types []eofFunctionMetadata, codeSections [][]byte, data []byte) []byte {
+	b := []byte{eofFormatByte, eofMagicByte, eof1Version}
+	b = append(b, eofKindTypes)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(types)*eofTypeEntrySize))
+	b = append(b, eofKindCode)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(codeSections)))
+	for _, code := range codeSections {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(code)))
+	}
+	b = append(b, eofKindData)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	b = append(b, eofTerminator)
+	for _, t := range types {
+		b = append(b, t.inputs, t.outputs)
+		b = binary.BigEndian.AppendUint16(b, t.maxStackHeight)
+	}
+	for _, code := range codeSections {
+		b = append(b, code...)
+	}
+	return append(b, data...)
+}
+
+func TestEOFValidation(t *testing.T) {
+	t.Parallel()
+	single := func(maxStackHeight uint16, code string) []byte {
+		return makeEOF([]eofFunctionMetadata{{maxStackHeight: maxStackHeight}}, [][]byte{common.FromHex(code)}, nil)
+	}
+	withData := makeEOF([]eofFunctionMetadata{{}}, [][]byte{{byte(STOP)}}, []byte{0xaa, 0xbb})
+
+	tests := []struct {
+		name string
+		code []byte
+		err  error
+	}{
+		{"stop", single(0, "00"), nil},
+		{"data", withData, nil},
+		{"sstore", single(2, "600160005500"), nil},
+		{"rjumpi", single(1, "6001e1000100"+"00"), nil},
+		{"rjumpv", single(1, "6000e2010000000000"), nil},
+		{"rjump loop", single(0, "e0fffd"), nil},
+		{"callf", makeEOF(
+			[]eofFunctionMetadata{{maxStackHeight: 1}, {outputs: 1, maxStackHeight: 1}},
+			[][]byte{common.FromHex("e3000100"), common.FromHex("6001e4")}, nil), nil},
+
+		{"invalid magic", append([]byte{0xef, 0x01}, single(0, "00")[2:]...), errInvalidMagic},
+		{"invalid version", append([]byte{0xef, 0x00, 0x02}, single(0, "00")[3:]...), errInvalidVersion},
+		{"trailing bytes", append(single(0, "00"), 0x00), errInvalidContainerSize},
+		{"truncated data", withData[:len(withData)-1], errInvalidContainerSize},
+		{"section 0 inputs", makeEOF([]eofFunctionMetadata{{inputs: 1, maxStackHeight: 1}}, [][]byte{{byte(STOP)}}, nil), errInvalidSection0Type},
+		{"undefined opcode", single(0, "0c00"), errUndefinedInstruction},
+		{"deprecated jump", single(1, "600056"), errUndefinedInstruction},
+		{"deprecated selfdestruct", single(1, "6000ff"), errUndefinedInstruction},
+		{"truncated push", single(1, "6100"), errTruncatedImmediate},
+		{"truncated rjumpv", single(1, "6000e201000000"), errTruncatedImmediate},
+		{"jump into immediate", single(1, "6001e0fffe"), errInvalidJumpDest},
+		{"jump out of section", single(0, "e00001"), errInvalidJumpDest},
+		{"callf unknown section", single(0, "e3000500"), errInvalidSectionArgument},
+		{"unreachable code", single(0, "0000"), errUnreachableCode},
+		{"stack underflow", single(0, "0100"), errStackUnderflowInSection},
+		{"max stack height", single(2, "600100"), errInvalidMaxStackHeight},
+		{"no terminating instruction", single(1, "600150"), errInvalidCodeTermination},
+		{"conflicting stack", single(1, "6001e10002600100"), errConflictingStack},
+		{"retf outputs", makeEOF(
+			[]eofFunctionMetadata{{maxStackHeight: 1}, {outputs: 1}},
+			[][]byte{common.FromHex("e3000100"), common.FromHex("e4")}, nil), errInvalidOutputs},
+	}
+	for _, tt := range tests {
+		err := ValidateEOF(tt.code)
+		if tt.err == nil && err != nil {
+			t.Errorf("%s: expected valid container, got %v", tt.name, err)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestEOFParseSections(t *testing.T) {
+	t.Parallel()
+	code := makeEOF(
+		[]eofFunctionMetadata{{maxStackHeight: 1}, {outputs: 1, maxStackHeight: 1}},
+		[][]byte{common.FromHex("e3000100"), common.FromHex("6001e4")},
+		[]byte{0xaa})
+	c, err := parseEOF(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.codeSections) != 2 || len(c.types) != 2 {
+		t.Fatalf("expected 2 sections, got %d code and %d types", len(c.codeSections), len(c.types))
+	}
+	for i, section := range c.codeSections {
+		if offset := c.codeOffsets[i]; OpCode(code[offset]) != OpCode(section[0]) {
+			t.Errorf("section %d: offset %d does not point to the code", i, offset)
+		}
+	}
+	if len(c.data) != 1 || c.data[0] != 0xaa {
+		t.Errorf("unexpected data section %x", c.data)
+	}
+}
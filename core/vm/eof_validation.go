@@ -0,0 +1,188 @@
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// validate checks every code section of the container against the rules of
+// EIP-3670 (code validation), EIP-4200 (static relative jumps), EIP-4750 (functions)
+// and EIP-5450 (stack validation).
+func (c *eofContainer) validate(jt *JumpTable) error {
+	for i, code := range c.codeSections {
+		if err := c.validateCode(i, code, jt); err != nil {
+			return fmt.Errorf("code section %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// eofImmediateSize returns the size of the immediate argument of the instruction at pos.
+// The bounds of the immediate are checked by validateInstructions.
+func eofImmediateSize(op OpCode, code []byte, pos int) int {
+	switch {
+	case op >= PUSH1 && op <= PUSH32:
+		return int(op - PUSH1 + 1)
+	case op == RJUMP || op == RJUMPI || op == CALLF:
+		return 2
+	case op == RJUMPV:
+		return 1 + 2*(int(code[pos+1])+1)
+	}
+	return 0
+}
+
+// eofIsTerminating reports whether execution can not continue after the instruction.
+func eofIsTerminating(op OpCode) bool {
+	switch op {
+	case STOP, RETURN, REVERT, INVALID, RETF:
+		return true
+	}
+	return false
+}
+
+// eofJumpTargets returns the destinations of a relative jump instruction at pos.
+func eofJumpTargets(op OpCode, code []byte, pos int) []int {
+	switch op {
+	case RJUMP, RJUMPI:
+		offset := int16(binary.BigEndian.Uint16(code[pos+1:]))
+		return []int{pos + 3 + int(offset)}
+	case RJUMPV:
+		count := int(code[pos+1]) + 1
+		end := pos + 2 + 2*count
+		targets := make([]int, count)
+		for i := range targets {
+			offset := int16(binary.BigEndian.Uint16(code[pos+2+2*i:]))
+			targets[i] = end + int(offset)
+		}
+		return targets
+	}
+	return nil
+}
+
+func (c *eofContainer) validateCode(section int, code []byte, jt *JumpTable) error {
+	isInstruction, err := c.validateInstructions(code, jt)
+	if err != nil {
+		return err
+	}
+	return c.validateStack(section, code, isInstruction, jt)
+}
+
+// validateInstructions implements EIP-3670 and the static checks of EIP-4200 and EIP-4750:
+// all opcodes are defined, immediates are not truncated, relative jumps land on
+// instructions within the section and CALLF targets an existing section.
+// It returns the positions of the instructions of the section.
+func (c *eofContainer) validateInstructions(code []byte, jt *JumpTable) ([]bool, error) {
+	isInstruction := make([]bool, len(code))
+	var jumps []int
+	for pos := 0; pos < len(code); {
+		op := OpCode(code[pos])
+		switch op {
+		case CALLCODE, SELFDESTRUCT, JUMP, JUMPI, PC:
+			// deprecated in EOF code
+			return nil, fmt.Errorf("%w: %v at %d", errUndefinedInstruction, op, pos)
+		case INVALID:
+			// designated invalid instruction is allowed
+		default:
+			if jt[op].undefined {
+				return nil, fmt.Errorf("%w: %#x at %d", errUndefinedInstruction, byte(op), pos)
+			}
+		}
+		isInstruction[pos] = true
+		if op == RJUMPV && pos+1 >= len(code) {
+			return nil, fmt.Errorf("%w: %v at %d", errTruncatedImmediate, op, pos)
+		}
+		next := pos + 1 + eofImmediateSize(op, code, pos)
+		if next > len(code) {
+			return nil, fmt.Errorf("%w: %v at %d", errTruncatedImmediate, op, pos)
+		}
+		if op == CALLF {
+			if idx := int(binary.BigEndian.Uint16(code[pos+1:])); idx >= len(c.types) {
+				return nil, fmt.Errorf("%w: CALLF to section %d at %d", errInvalidSectionArgument, idx, pos)
+			}
+		}
+		jumps = append(jumps, eofJumpTargets(op, code, pos)...)
+		pos = next
+	}
+	for _, target := range jumps {
+		if target < 0 || target >= len(code) || !isInstruction[target] {
+			return nil, fmt.Errorf("%w: %d", errInvalidJumpDest, target)
+		}
+	}
+	return isInstruction, nil
+}
+
+// validateStack implements EIP-5450: every instruction is reached with a single
+// stack height which does not underflow, execution can not fall off the end of the
+// section, RETF returns the declared number of outputs and the declared maximum
+// stack height is exact.
+func (c *eofContainer) validateStack(section int, code []byte, isInstruction []bool, jt *JumpTable) error {
+	heights := make([]int, len(code))
+	for i := range heights {
+		heights[i] = -1
+	}
+	typ := c.types[section]
+	heights[0] = int(typ.inputs)
+	maxHeight := int(typ.inputs)
+	worklist := []int{0}
+	for len(worklist) > 0 {
+		pos := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+
+		op := OpCode(code[pos])
+		height := heights[pos]
+		var required, change int
+		switch op {
+		case CALLF:
+			callee := c.types[binary.BigEndian.Uint16(code[pos+1:])]
+			required = int(callee.inputs)
+			change = int(callee.outputs) - int(callee.inputs)
+		case RETF:
+			if height != int(typ.outputs) {
+				return fmt.Errorf("%w: RETF at %d with stack height %d, want %d", errInvalidOutputs, pos, height, typ.outputs)
+			}
+		default:
+			required = jt[op].numPop
+			change = jt[op].numPush - jt[op].numPop
+		}
+		if height < required {
+			return fmt.Errorf("%w: %v at %d with stack height %d", errStackUnderflowInSection, op, pos, height)
+		}
+		height += change
+		if height > eofMaxStackHeight {
+			return fmt.Errorf("%w: %v at %d", errStackOverflowInSection, op, pos)
+		}
+		if height > maxHeight {
+			maxHeight = height
+		}
+
+		var successors []int
+		next := pos + 1 + eofImmediateSize(op, code, pos)
+		switch {
+		case eofIsTerminating(op):
+		case op == RJUMP:
+			successors = eofJumpTargets(op, code, pos)
+		default:
+			if next >= len(code) {
+				return fmt.Errorf("%w: %v at %d", errInvalidCodeTermination, op, pos)
+			}
+			successors = append(eofJumpTargets(op, code, pos), next)
+		}
+		for _, s := range successors {
+			if heights[s] == -1 {
+				heights[s] = height
+				worklist = append(worklist, s)
+			} else if heights[s] != height {
+				return fmt.Errorf("%w: at %d have %d, want %d", errConflictingStack, s, height, heights[s])
+			}
+		}
+	}
+	for pos, ok := range isInstruction {
+		if ok && heights[pos] == -1 {
+			return fmt.Errorf("%w: at %d", errUnreachableCode, pos)
+		}
+	}
+	if maxHeight != int(typ.maxStackHeight) {
+		return fmt.Errorf("%w: have %d, declared %d", errInvalidMaxStackHeight, maxHeight, typ.maxStackHeight)
+	}
+	return nil
+}
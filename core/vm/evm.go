@@ -25,6 +25,7 @@ import (
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
 	"github.com/ledgerwatch/erigon/common/u256"
+	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/params"
@@ -178,8 +179,16 @@ func (evm *EVM) call(typ OpCode, caller ContractRef, addr libcommon.Address, inp
 	}
 	p, isPrecompile := evm.precompile(addr)
 	var code []byte
+	codeAddr := addr
 	if !isPrecompile {
 		code = evm.intraBlockState.GetCode(addr)
+		// Follow the delegation designator of a delegated account (EIP-7702)
+		if evm.chainRules.IsPrague {
+			if target, ok := types.ParseDelegation(code); ok {
+				codeAddr = target
+				code = evm.intraBlockState.GetCode(target)
+			}
+		}
 	}
 
 	snapshot := evm.intraBlockState.Snapshot()
@@ -245,7 +254,7 @@ func (evm *EVM) call(typ OpCode, caller ContractRef, addr libcommon.Address, inp
 		addrCopy := addr
 		// Initialise a new contract and set the code that is to be used by the EVM.
 		// The contract is a scoped environment for this execution context only.
-		codeHash := evm.intraBlockState.GetCodeHash(addrCopy)
+		codeHash := evm.intraBlockState.GetCodeHash(codeAddr)
 		var contract *Contract
 		if typ == CALLCODE {
 			contract = NewContract(caller, caller.Address(), value, gas, evm.config.SkipAnalysis)
@@ -392,7 +401,16 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		return nil, address, gas, nil
 	}
 
-	ret, err = run(evm, contract, nil, false)
+	// EOF initcode must be a valid container (EIP-3540)
+	isEOFInitcode := evm.config.HasEip3540() && hasEOFMagic(codeAndHash.code)
+	if isEOFInitcode {
+		if ValidateEOF(codeAndHash.code) != nil {
+			err = ErrInvalidEOFInitcode
+		}
+	}
+	if err == nil {
+		ret, err = run(evm, contract, nil, false)
+	}
 
 	// EIP-170: Contract code size limit
 	if err == nil && evm.chainRules.IsSpuriousDragon && len(ret) > params.MaxCodeSize {
@@ -403,8 +421,13 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		}
 	}
 
-	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if err == nil && evm.chainRules.IsLondon && len(ret) >= 1 && ret[0] == 0xEF {
+	if err == nil && isEOFInitcode {
+		// EOF initcode can only deploy valid EOF code (EIP-3540)
+		if ValidateEOF(ret) != nil {
+			err = ErrInvalidEOFCode
+		}
+	} else if err == nil && evm.chainRules.IsLondon && len(ret) >= 1 && ret[0] == 0xEF {
+		// Reject code starting with 0xEF if EIP-3541 is enabled.
 		err = ErrInvalidCode
 	}
 	// if the contract creation ran successfully and no errors were returned
@@ -43,6 +43,8 @@ type Config struct {
 	RestoreState  bool      // Revert all changes made to the state (useful for constant system calls)
 
 	ExtraEips []int // Additional EIPS that are to be enabled
+
+	LiveTracer LiveLogger // Notified of every block, transaction and state change during block execution
 }
 
 var pool = sync.Pool{
@@ -60,6 +62,17 @@ func (vmConfig *Config) HasEip3860(rules *chain.Rules) bool {
 	return rules.IsShanghai
 }
 
+// HasEip3540 reports whether EOF v1 containers are validated and executed.
+// EOF is not scheduled for any fork yet and can only be enabled as an extra EIP.
+func (vmConfig *Config) HasEip3540() bool {
+	for _, eip := range vmConfig.ExtraEips {
+		if eip == 3540 {
+			return true
+		}
+	}
+	return false
+}
+
 // Interpreter is used to run Ethereum based contracts and will utilise the
 // passed environment to query external sources for state information.
 // The Interpreter will run the byte code VM based on the passed
@@ -240,6 +253,14 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		in.depth--
 	}()
 
+	// EOF containers are validated on deployment, execution starts at the first code section
+	if in.cfg.HasEip3540() && hasEOFMagic(contract.Code) {
+		if contract.container, err = parseEOF(contract.Code); err != nil {
+			return nil, err
+		}
+		_pc = contract.container.codeOffsets[0]
+	}
+
 	// The Interpreter main run loop (contextual). This loop runs until either an
 	// explicit STOP, RETURN or SELFDESTRUCT is executed, an error occurred during
 	// the execution of one of the operations or until the done flag is set by the
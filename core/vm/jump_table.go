@@ -46,6 +46,8 @@ type operation struct {
 	isSwap  bool
 	isDup   bool
 	opNum   int // only for push, swap, dup
+	// undefined is set for the opcodes that are not defined in the instruction set
+	undefined bool
 	// memorySize returns the memory size required for the operation
 	memorySize memorySizeFunc
 }
@@ -63,8 +65,16 @@ var (
 	shanghaiInstructionSet         = newShanghaiInstructionSet()
 	cancunInstructionSet           = newCancunInstructionSet()
 	pragueInstructionSet           = newPragueInstructionSet()
+
+	// eofInstructionSet is used to validate EOF code, see ValidateEOF.
+	// It is initialised in init, as CREATE refers to it.
+	eofInstructionSet JumpTable
 )
 
+func init() {
+	eofInstructionSet = newEOFInstructionSet()
+}
+
 // JumpTable contains the EVM opcodes supported at a given fork.
 type JumpTable [256]*operation
 
@@ -86,11 +96,21 @@ func validateAndFillMaxStack(jt *JumpTable) {
 	}
 }
 
+// newEOFInstructionSet returns the prague instructions with EOF v1 enabled.
+func newEOFInstructionSet() JumpTable {
+	instructionSet := newPragueInstructionSet()
+	enable3540(&instructionSet)
+	validateAndFillMaxStack(&instructionSet)
+	return instructionSet
+}
+
 // newPragueInstructionSet returns the frontier, homestead, byzantium,
 // constantinople, istanbul, petersburg, berlin, london, paris, shanghai,
 // cancun, and prague instructions.
 func newPragueInstructionSet() JumpTable {
 	instructionSet := newCancunInstructionSet()
+	enable2935(&instructionSet) // BLOCKHASH from the history storage contract
+	enable7702(&instructionSet) // Set EOA account code
 	validateAndFillMaxStack(&instructionSet)
 	return instructionSet
 }
@@ -1204,7 +1224,7 @@ func newFrontierInstructionSet() JumpTable {
 	// Fill all unassigned slots with opUndefined.
 	for i, entry := range tbl {
 		if entry == nil {
-			tbl[i] = &operation{execute: opUndefined}
+			tbl[i] = &operation{execute: opUndefined, undefined: true}
 		}
 	}
 
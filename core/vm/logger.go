@@ -20,6 +20,7 @@ import (
 	"github.com/holiman/uint256"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
+	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
 )
 
@@ -49,3 +50,74 @@ type FlushableTracer interface {
 	EVMLogger
 	Flush(tx types.Transaction)
 }
+
+// LiveLogger is an EVMLogger which follows the chain while its blocks are executed,
+// instead of re-executing them on demand. Besides the EVM events it is notified of
+// block and transaction boundaries and, as a state.StateTracer, of every state change,
+// including the ones made outside of transactions such as rewards and withdrawals.
+type LiveLogger interface {
+	EVMLogger
+	state.StateTracer
+	CaptureBlockStart(header *types.Header)
+	CaptureBlockEnd(err error)
+	CaptureTransactionStart(txIndex int, tx types.Transaction, from libcommon.Address)
+	CaptureTransactionEnd(usedGas uint64, logs []*types.Log, err error)
+	// CaptureUnwind is called once the blocks above unwindPoint are unwound, e.g. on a reorg.
+	// The events reported for those blocks no longer describe the canonical chain.
+	CaptureUnwind(unwindPoint uint64)
+}
+
+// NewMuxLogger returns an EVMLogger which forwards every event to all of the given loggers.
+func NewMuxLogger(loggers ...EVMLogger) EVMLogger {
+	return muxLogger(loggers)
+}
+
+type muxLogger []EVMLogger
+
+func (m muxLogger) CaptureTxStart(gasLimit uint64) {
+	for _, l := range m {
+		l.CaptureTxStart(gasLimit)
+	}
+}
+
+func (m muxLogger) CaptureTxEnd(restGas uint64) {
+	for _, l := range m {
+		l.CaptureTxEnd(restGas)
+	}
+}
+
+func (m muxLogger) CaptureStart(env *EVM, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	for _, l := range m {
+		l.CaptureStart(env, from, to, precompile, create, input, gas, value, code)
+	}
+}
+
+func (m muxLogger) CaptureEnd(output []byte, usedGas uint64, err error) {
+	for _, l := range m {
+		l.CaptureEnd(output, usedGas, err)
+	}
+}
+
+func (m muxLogger) CaptureEnter(typ OpCode, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	for _, l := range m {
+		l.CaptureEnter(typ, from, to, precompile, create, input, gas, value, code)
+	}
+}
+
+func (m muxLogger) CaptureExit(output []byte, usedGas uint64, err error) {
+	for _, l := range m {
+		l.CaptureExit(output, usedGas, err)
+	}
+}
+
+func (m muxLogger) CaptureState(pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, rData []byte, depth int, err error) {
+	for _, l := range m {
+		l.CaptureState(pc, op, gas, cost, scope, rData, depth, err)
+	}
+}
+
+func (m muxLogger) CaptureFault(pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, depth int, err error) {
+	for _, l := range m {
+		l.CaptureFault(pc, op, gas, cost, scope, depth, err)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
 )
 
 // Config is a basic type specifying certain configuration flags for running
@@ -47,6 +48,8 @@ type Config struct {
 	Debug       bool
 	EVMConfig   vm.Config
 	BaseFee     *uint256.Int
+	// ExcessBlobGas determines the blob base fee
+	ExcessBlobGas *uint64
 
 	State     *state.IntraBlockState
 	r         state.StateReader
@@ -95,6 +98,12 @@ func setDefaults(cfg *Config) {
 	if cfg.BlockNumber == nil {
 		cfg.BlockNumber = new(big.Int)
 	}
+	if cfg.BaseFee == nil {
+		cfg.BaseFee = uint256.NewInt(params.InitialBaseFee)
+	}
+	if cfg.ExcessBlobGas == nil {
+		cfg.ExcessBlobGas = new(uint64)
+	}
 	if cfg.GetHashFn == nil {
 		cfg.GetHashFn = func(n uint64) libcommon.Hash {
 			return libcommon.BytesToHash(crypto.Keccak256([]byte(new(big.Int).SetUint64(n).String())))
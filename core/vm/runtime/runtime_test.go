@@ -17,13 +17,17 @@
 package runtime
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/holiman/uint256"
+
 	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
@@ -36,6 +40,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/eth/tracers/logger"
+	"github.com/ledgerwatch/erigon/params"
 )
 
 func TestDefaults(t *testing.T) {
@@ -265,6 +270,9 @@ func (d *dummyChain) GetHeader(h libcommon.Hash, n uint64) *types.Header {
 	return fakeHeader(n, parentHash)
 }
 
+// blockhashTestContract is the Hasher contract of TestBlockhash.
+const blockhashTestContract = "6080604052348015600f57600080fd5b50600436106045576000357c010000000000000000000000000000000000000000000000000000000090048063f8a8fd6d14604a575b600080fd5b60506074565b60405180848152602001838152602001828152602001935050505060405180910390f35b600080600080439050600080600083409050600184034092506000600290505b61010481101560c35760008186034090506000816001900414151560b6578093505b5080806001019150506094565b508083839650965096505050505090919256fea165627a7a72305820462d71b510c1725ff35946c20b415b0d50b468ea157c8c77dff9466c9cb85f560029"
+
 // TestBlockhash tests the blockhash operation. It's a bit special, since it internally
 // requires access to a chain reader.
 func TestBlockhash(t *testing.T) {
@@ -309,7 +317,7 @@ func TestBlockhash(t *testing.T) {
 
 	*/
 	// The contract above
-	data := libcommon.Hex2Bytes("6080604052348015600f57600080fd5b50600436106045576000357c010000000000000000000000000000000000000000000000000000000090048063f8a8fd6d14604a575b600080fd5b60506074565b60405180848152602001838152602001828152602001935050505060405180910390f35b600080600080439050600080600083409050600184034092506000600290505b61010481101560c35760008186034090506000816001900414151560b6578093505b5080806001019150506094565b508083839650965096505050505090919256fea165627a7a72305820462d71b510c1725ff35946c20b415b0d50b468ea157c8c77dff9466c9cb85f560029")
+	data := libcommon.Hex2Bytes(blockhashTestContract)
 	// The method call to 'test()'
 	input := libcommon.Hex2Bytes("f8a8fd6d")
 	chain := &dummyChain{}
@@ -341,6 +349,145 @@ func TestBlockhash(t *testing.T) {
 	}
 }
 
+func TestBlockhashEip2935(t *testing.T) {
+	t.Parallel()
+	n := uint64(1000)
+	parentHash := libcommon.Hash{}
+	s := common.LeftPadBytes(big.NewInt(int64(n-1)).Bytes(), 32)
+	copy(parentHash[:], s)
+	header := fakeHeader(n, parentHash)
+
+	// The history storage contract holds the parent hash, older ancestors were
+	// processed before the fork and come from the header chain.
+	_, tx := memdb.NewTestTx(t)
+	ibs := state.New(state.NewPlainState(tx, 1, nil))
+	historyHash := libcommon.HexToHash("0xdeadbeef")
+	slot := libcommon.BigToHash(big.NewInt(int64((n - 1) % params.BlockHashHistoryServeWindow)))
+	ibs.SetState(params.HistoryStorageAddress, slot, *uint256.NewInt(0).SetBytes(historyHash.Bytes()))
+
+	chain := &dummyChain{}
+	ret, _, err := Execute(libcommon.Hex2Bytes(blockhashTestContract), libcommon.Hex2Bytes("f8a8fd6d"), &Config{
+		GetHashFn:   core.GetHashFn(header, chain.GetHeader),
+		BlockNumber: new(big.Int).Set(header.Number),
+		State:       ibs,
+	}, header.Number.Uint64())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(ret) != 96 {
+		t.Fatalf("expected returndata to be 96 bytes, got %d", len(ret))
+	}
+	if zero := new(big.Int).SetBytes(ret[0:32]); zero.Sign() != 0 {
+		t.Fatalf("expected zeroes, got %x", ret[0:32])
+	}
+	if first := libcommon.BytesToHash(ret[32:64]); first != historyHash {
+		t.Fatalf("parent hash should be read from the history contract, got %x", first)
+	}
+	if last := new(big.Int).SetBytes(ret[64:96]); last.Uint64() != 744 {
+		t.Fatalf("last block should be 744, got %d (%x)", last, ret[64:96])
+	}
+}
+
+func TestBlockhashEip2935Gas(t *testing.T) {
+	t.Parallel()
+	n := uint64(1000)
+	header := fakeHeader(n, libcommon.Hash{})
+	_, tx := memdb.NewTestTx(t)
+	cfg := &Config{
+		State:       state.New(state.NewPlainState(tx, 1, nil)),
+		GetHashFn:   core.GetHashFn(header, (&dummyChain{}).GetHeader),
+		BlockNumber: new(big.Int).Set(header.Number),
+		GasLimit:    100_000,
+	}
+	address := libcommon.HexToAddress("0xaa")
+	// BLOCKHASH of the parent twice, then of a block outside of the window
+	cfg.State.SetCode(address, []byte{
+		byte(vm.PUSH2), 0x03, 0xe7, byte(vm.BLOCKHASH), byte(vm.POP),
+		byte(vm.PUSH2), 0x03, 0xe7, byte(vm.BLOCKHASH), byte(vm.POP),
+		byte(vm.PUSH2), 0x03, 0xe8, byte(vm.BLOCKHASH), byte(vm.POP),
+		byte(vm.STOP),
+	})
+	_, leftOverGas, err := Call(address, nil, cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// The first read of the history storage contract is cold, the second one is warm
+	expected := 3*(vm.GasFastestStep+vm.GasExtStep+vm.GasQuickStep) + params.ColdSloadCostEIP2929 + params.WarmStorageReadCostEIP2929
+	if used := cfg.GasLimit - leftOverGas; used != expected {
+		t.Fatalf("expected %d gas to be used, got %d", expected, used)
+	}
+	slot := libcommon.BigToHash(big.NewInt(int64((n - 1) % params.BlockHashHistoryServeWindow)))
+	if _, warm := cfg.State.SlotInAccessList(params.HistoryStorageAddress, slot); !warm {
+		t.Fatalf("slot %x of the history storage contract should be warm", slot)
+	}
+}
+
+func TestDelegationEip7702(t *testing.T) {
+	t.Parallel()
+	_, tx := memdb.NewTestTx(t)
+	cfg := &Config{State: state.New(state.NewPlainState(tx, 1, nil))}
+	var (
+		authority = libcommon.HexToAddress("0xaa")
+		delegate  = libcommon.HexToAddress("0xbb")
+		inspector = libcommon.HexToAddress("0xcc")
+	)
+	// The delegate returns ADDRESS, which must be the authority when called through the delegation
+	cfg.State.SetCode(delegate, common.FromHex("0x3060005260206000f3"))
+	cfg.State.SetCode(authority, types.AddressToDelegation(delegate))
+	// The inspector returns EXTCODESIZE of the authority
+	cfg.State.SetCode(inspector, append(append([]byte{byte(vm.PUSH20)}, authority.Bytes()...), common.FromHex("0x3b60005260206000f3")...))
+
+	ret, _, err := Call(authority, nil, cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if addr := libcommon.BytesToAddress(ret); addr != authority {
+		t.Fatalf("delegated code should run in the context of the authority, got %x", addr)
+	}
+
+	ret, _, err = Call(inspector, nil, cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if size := new(big.Int).SetBytes(ret); size.Uint64() != 2 {
+		t.Fatalf("expected EXTCODESIZE of a delegated account to be 2, got %d", size)
+	}
+}
+
+func TestEOFCreateAndCall(t *testing.T) {
+	t.Parallel()
+	_, tx := memdb.NewTestTx(t)
+	cfg := &Config{State: state.New(state.NewPlainState(tx, 1, nil))}
+	cfg.EVMConfig.ExtraEips = []int{3540}
+
+	// The deployed container calls section 1, which pushes 42, and returns it
+	deployed := common.FromHex("0xef0001010008020002000b0003040000000000000200010001e3000160005260206000f3602ae4")
+	// The initcode copies the deployed container from its data section and returns it
+	initcode := append(common.FromHex("0xef0001010004020001000d0400270000000003602761002060003960276000f3"), deployed...)
+
+	_, address, _, err := Create(initcode, cfg, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if code := cfg.State.GetCode(address); !bytes.Equal(code, deployed) {
+		t.Fatalf("unexpected deployed code %x", code)
+	}
+	ret, _, err := Call(address, nil, cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if v := new(big.Int).SetBytes(ret); v.Uint64() != 42 {
+		t.Fatalf("expected 42, got %d", v)
+	}
+
+	// Initcode deploying a container with an undefined instruction must fail
+	invalid := append(common.FromHex("0xef0001010004020001000d0400270000000003602761002060003960276000f3"), deployed[:len(deployed)-1]...)
+	invalid = append(invalid, 0x0c)
+	if _, _, _, err := Create(invalid, cfg, 0); !errors.Is(err, vm.ErrInvalidEOFCode) {
+		t.Fatalf("expected %v, got %v", vm.ErrInvalidEOFCode, err)
+	}
+}
+
 // benchmarkNonModifyingCode benchmarks code, but if the code modifies the
 // state, this should not be used, since it does not reset the state between runs.
 func benchmarkNonModifyingCode(b *testing.B, gas uint64, code []byte, name string) { //nolint:unparam
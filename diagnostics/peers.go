@@ -44,6 +44,22 @@ func SetupPeersAccess(ctxclient *cli.Context, metricsMux *http.ServeMux, node *n
 	})
 }
 
+func SetupPeerScoresAccess(metricsMux *http.ServeMux, node *node.ErigonNode) {
+	metricsMux.HandleFunc("/sentinel_peer_scores", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+		writePeerScores(w, node)
+	})
+}
+
+func writePeerScores(w http.ResponseWriter, node *node.ErigonNode) {
+	scores := map[string]*diagnint.PeerScore{}
+	if diag, ok := node.Backend().Sentinel().(diagnint.PeerScoreGetter); ok {
+		scores = diag.GetPeersScores()
+	}
+	json.NewEncoder(w).Encode(scores)
+}
+
 func writePeers(w http.ResponseWriter, ctx *cli.Context, node *node.ErigonNode) {
 	sentinelPeers, err := sentinelPeers(node)
 	if err != nil {
@@ -74,6 +74,9 @@ type Config struct {
 	TargetBlobGasPerBlock      *uint64 `json:"targetBlobGasPerBlock,omitempty"`
 	BlobGasPriceUpdateFraction *uint64 `json:"blobGasPriceUpdateFraction,omitempty"`
 
+	// (Optional) deposit contract of the PoS chain, its logs are turned into EIP-6110 deposit requests after Prague
+	DepositContract common.Address `json:"depositContractAddress,omitempty"`
+
 	// (Optional) governance contract where EIP-1559 fees will be sent to that otherwise would be burnt since the London fork
 	BurntContract map[string]common.Address `json:"burntContract,omitempty"`
 
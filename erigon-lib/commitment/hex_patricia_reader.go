@@ -0,0 +1,471 @@
+/*
+   Copyright 2024 The Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package commitment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/holiman/uint256"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/rlp"
+)
+
+// HexPatriciaReader reads the branches stored by HexPatriciaHashed and serves
+// them as the nodes of the merkle patricia trie: single trie nodes, proofs and
+// the leaves in the order of the hashed keys. Storage tries are addressed by
+// the 64 nibbles of the hashed account key followed by the storage path.
+//
+// A trie whose root is not a branch node (it has less than two leaves or all
+// the keys share a prefix) is not stored as a branch, RootHash reports the
+// empty root for it.
+type HexPatriciaReader struct {
+	hph *HexPatriciaHashed
+}
+
+// AccountLeaf is an account leaf of the trie with the root of its storage trie.
+type AccountLeaf struct {
+	HashedKey   []byte // nibbles of the hashed account key
+	Nonce       uint64
+	Balance     uint256.Int
+	CodeHash    [length.Hash]byte
+	StorageRoot [length.Hash]byte
+}
+
+func NewHexPatriciaReader(accountKeyLen int, ctx PatriciaContext) *HexPatriciaReader {
+	return &HexPatriciaReader{hph: &HexPatriciaHashed{
+		ctx:           ctx,
+		keccak:        sha3.NewLegacyKeccak256().(keccakState),
+		keccak2:       sha3.NewLegacyKeccak256().(keccakState),
+		accountKeyLen: accountKeyLen,
+		auxBuffer:     bytes.NewBuffer(make([]byte, 8192)),
+	}}
+}
+
+// branch is a branch node loaded from the context, cells are at depth len(path)+1.
+type branch struct {
+	path   []byte
+	bitmap uint16
+	cells  [16]Cell
+}
+
+func (b *branch) depth() int { return len(b.path) + 1 }
+
+func (b *branch) childPath(nibble int) []byte {
+	return append(append(make([]byte, 0, len(b.path)+1), b.path...), byte(nibble))
+}
+
+// loadBranch returns nil if there is no branch at the path.
+func (r *HexPatriciaReader) loadBranch(path []byte) (*branch, error) {
+	branchData, err := r.hph.ctx.GetBranch(hexToCompact(path))
+	if err != nil {
+		return nil, err
+	}
+	if len(branchData) <= 4 {
+		return nil, nil
+	}
+	b := &branch{path: path, bitmap: binary.BigEndian.Uint16(branchData[2:])}
+	pos := 4
+	for bitset := b.bitmap; bitset != 0; {
+		bit := bitset & -bitset
+		cell := &b.cells[bits.TrailingZeros16(bit)]
+		if pos >= len(branchData) {
+			return nil, fmt.Errorf("branch [%x] is truncated", path)
+		}
+		fieldBits := PartFlags(branchData[pos])
+		pos++
+		if pos, err = cell.fillFromFields(branchData, pos, fieldBits); err != nil {
+			return nil, fmt.Errorf("branch [%x]: %w", path, err)
+		}
+		if cell.apl > 0 {
+			if err = r.hph.ctx.GetAccount(cell.apk[:cell.apl], cell); err != nil {
+				return nil, fmt.Errorf("branch [%x] GetAccount: %w", path, err)
+			}
+		}
+		if cell.spl > 0 {
+			if err = r.hph.ctx.GetStorage(cell.spk[:cell.spl], cell); err != nil {
+				return nil, fmt.Errorf("branch [%x] GetStorage: %w", path, err)
+			}
+		}
+		bitset ^= bit
+	}
+	return b, nil
+}
+
+func (r *HexPatriciaReader) branchNode(b *branch) ([]byte, error) {
+	var payload []byte
+	for nibble := 0; nibble < 16; nibble++ {
+		if b.bitmap&(uint16(1)<<nibble) == 0 {
+			payload = append(payload, 0x80)
+			continue
+		}
+		ref, err := r.hph.computeCellHash(&b.cells[nibble], b.depth(), r.hph.hashAuxBuffer[:0])
+		if err != nil {
+			return nil, err
+		}
+		payload = append(payload, ref...)
+	}
+	return listNode(append(payload, 0x80)), nil
+}
+
+func listNode(payload []byte) []byte {
+	var prefix [10]byte
+	pt := rlp.EncodeListPrefix(len(payload), prefix[:])
+	return append(prefix[:pt:pt], payload...)
+}
+
+// shortNode encodes an extension node or a leaf node, the key is in nibbles
+// and leaf keys end with the terminator. The value is RLP encoded.
+func shortNode(key, val []byte) []byte {
+	compact := hexToCompact(key)
+	payload := make([]byte, rlp.StringLen(compact), rlp.StringLen(compact)+len(val))
+	rlp.EncodeString(compact, payload)
+	return listNode(append(payload, val...))
+}
+
+func leafNode(key, val []byte) []byte {
+	enc := make([]byte, rlp.StringLen(val))
+	rlp.EncodeString(val, enc)
+	return shortNode(key, enc)
+}
+
+func (r *HexPatriciaReader) hashedKey(plainKey []byte) []byte {
+	key := make([]byte, 64, 65)
+	// hashKey can only fail on writing to keccak, which does not fail
+	_ = hashKey(r.hph.keccak, plainKey, key, 0)
+	return key
+}
+
+func (r *HexPatriciaReader) accountKey(cell *Cell) []byte {
+	return r.hashedKey(cell.apk[:cell.apl])
+}
+
+func (r *HexPatriciaReader) storageKey(cell *Cell) []byte {
+	return r.hashedKey(cell.spk[r.hph.accountKeyLen:cell.spl])
+}
+
+func storageValue(cell *Cell) []byte {
+	val := make([]byte, rlp.StringLen(cell.Storage[:cell.StorageLen]))
+	rlp.EncodeString(cell.Storage[:cell.StorageLen], val)
+	return val
+}
+
+func (r *HexPatriciaReader) keccak(data []byte) (h [length.Hash]byte) {
+	r.hph.keccak.Reset()
+	_, _ = r.hph.keccak.Write(data)
+	_, _ = r.hph.keccak.Read(h[:])
+	return h
+}
+
+// storageRootNode returns the root node of the storage trie of the account
+// cell, nil for the empty storage and for the branch node, which is stored
+// at the hashed account key.
+func (r *HexPatriciaReader) storageRootNode(cell *Cell) []byte {
+	switch {
+	case cell.spl > 0:
+		return leafNode(append(r.storageKey(cell), 16), storageValue(cell))
+	case cell.extLen > 0:
+		return shortNode(cell.extension[:cell.extLen], append([]byte{0x80 + length.Hash}, cell.h[:cell.hl]...))
+	}
+	return nil
+}
+
+func (r *HexPatriciaReader) storageRoot(cell *Cell) [length.Hash]byte {
+	if node := r.storageRootNode(cell); node != nil {
+		return r.keccak(node)
+	}
+	if cell.hl > 0 {
+		return cell.h
+	}
+	return *(*[length.Hash]byte)(EmptyRootHash)
+}
+
+func (r *HexPatriciaReader) accountNode(cell *Cell, depth int) []byte {
+	var val [128]byte
+	valLen := cell.accountForHashing(val[:], r.storageRoot(cell))
+	return leafNode(append(r.accountKey(cell)[depth:], 16), val[:valLen])
+}
+
+func (r *HexPatriciaReader) accountLeaf(cell *Cell) *AccountLeaf {
+	return &AccountLeaf{
+		HashedKey:   r.accountKey(cell),
+		Nonce:       cell.Nonce,
+		Balance:     cell.Balance,
+		CodeHash:    cell.CodeHash,
+		StorageRoot: r.storageRoot(cell),
+	}
+}
+
+func isStorageLeaf(cell *Cell, depth int) bool { return cell.spl > 0 && depth > 64 }
+
+func isExtension(cell *Cell) bool { return cell.apl == 0 && cell.spl == 0 && cell.extLen > 0 }
+
+// RootHash returns the hash of the root branch node.
+func (r *HexPatriciaReader) RootHash() ([]byte, error) {
+	b, err := r.loadBranch(nil)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return EmptyRootHash, nil
+	}
+	node, err := r.branchNode(b)
+	if err != nil {
+		return nil, err
+	}
+	h := r.keccak(node)
+	return h[:], nil
+}
+
+// walk visits the nodes on the path to the key, from the root down to the
+// node at the key or to the last node of the trie on the path to it. With
+// storage set the key continues into the storage trie of the account, the
+// nodes of the storage trie are visited with the paths relative to it.
+func (r *HexPatriciaReader) walk(key []byte, storage bool, visit func(path []byte, node []byte, storageNode bool)) error {
+	path := []byte{}
+	inStorage := false
+	for {
+		b, err := r.loadBranch(path)
+		if err != nil || b == nil {
+			return err
+		}
+		node, err := r.branchNode(b)
+		if err != nil {
+			return err
+		}
+		visit(relativePath(path, inStorage), node, inStorage)
+		if len(key) <= len(path) {
+			return nil
+		}
+		nibble := int(key[len(path)])
+		if b.bitmap&(uint16(1)<<nibble) == 0 {
+			return nil
+		}
+		cell, depth, cellPath := &b.cells[nibble], b.depth(), b.childPath(nibble)
+		switch {
+		case isStorageLeaf(cell, depth):
+			visit(relativePath(cellPath, true), leafNode(append(r.storageKey(cell)[depth-64:], 16), storageValue(cell)), true)
+			return nil
+		case cell.apl > 0:
+			visit(cellPath, r.accountNode(cell, depth), false)
+			accKey := r.accountKey(cell)
+			if !storage || len(key) < 64 || !bytes.Equal(key[:64], accKey) {
+				return nil
+			}
+			inStorage = true
+			if node := r.storageRootNode(cell); node != nil {
+				visit([]byte{}, node, true)
+				if cell.spl > 0 || !bytes.HasPrefix(key[64:], cell.extension[:cell.extLen]) {
+					return nil
+				}
+				path = append(accKey, cell.extension[:cell.extLen]...)
+			} else if cell.hl > 0 {
+				path = accKey
+			} else {
+				return nil
+			}
+		case isExtension(cell):
+			visit(relativePath(cellPath, inStorage), shortNode(cell.extension[:cell.extLen], append([]byte{0x80 + length.Hash}, cell.h[:cell.hl]...)), inStorage)
+			if !bytes.HasPrefix(key[depth:], cell.extension[:cell.extLen]) {
+				return nil
+			}
+			path = append(cellPath, cell.extension[:cell.extLen]...)
+		case cell.hl > 0:
+			path = cellPath
+		default:
+			return nil
+		}
+	}
+}
+
+func relativePath(path []byte, storage bool) []byte {
+	if storage {
+		return path[64:]
+	}
+	return path
+}
+
+// AccountNode returns the node of the accounts trie at the nibble path, nil
+// if there is none.
+func (r *HexPatriciaReader) AccountNode(path []byte) (node []byte, err error) {
+	err = r.walk(path, false, func(p []byte, n []byte, _ bool) {
+		if len(p) == len(path) {
+			node = n
+		}
+	})
+	return node, err
+}
+
+// StorageNode returns the node at the nibble path of the storage trie of the
+// account with the given hashed key (in nibbles), nil if there is none.
+func (r *HexPatriciaReader) StorageNode(accountKey, path []byte) (node []byte, err error) {
+	err = r.walk(append(append([]byte{}, accountKey...), path...), true, func(p []byte, n []byte, storageNode bool) {
+		if storageNode && len(p) == len(path) {
+			node = n
+		}
+	})
+	return node, err
+}
+
+// AccountProof returns the nodes of the accounts trie on the path to the
+// hashed key (in nibbles), which prove the account or its absence. The nodes
+// embedded into their parents are not included.
+func (r *HexPatriciaReader) AccountProof(key []byte) (proof [][]byte, err error) {
+	err = r.walk(key, false, func(_ []byte, n []byte, _ bool) {
+		if len(proof) == 0 || len(n) >= length.Hash {
+			proof = append(proof, n)
+		}
+	})
+	return proof, err
+}
+
+// StorageProof returns the nodes of the storage trie of the account on the
+// path to the hashed storage key, both keys are in nibbles.
+func (r *HexPatriciaReader) StorageProof(accountKey, key []byte) (proof [][]byte, err error) {
+	err = r.walk(append(append([]byte{}, accountKey...), key...), true, func(_ []byte, n []byte, storageNode bool) {
+		if storageNode && (len(proof) == 0 || len(n) >= length.Hash) {
+			proof = append(proof, n)
+		}
+	})
+	return proof, err
+}
+
+// Accounts calls fn for the accounts with the hashed keys which are not less
+// than the given one (in nibbles), in the order of the keys, until fn returns
+// false.
+func (r *HexPatriciaReader) Accounts(from []byte, fn func(acc *AccountLeaf) (bool, error)) error {
+	_, err := r.leaves(nil, 0, from, func(cell *Cell, depth int) (bool, error) {
+		if key := r.accountKey(cell); bytes.Compare(key, from) < 0 {
+			return true, nil
+		}
+		return fn(r.accountLeaf(cell))
+	})
+	return err
+}
+
+// Storage calls fn for the storage slots of the account with the hashed keys
+// which are not less than the given one, in the order of the keys, until fn
+// returns false. The slot values are RLP encoded, as in the trie leaves.
+func (r *HexPatriciaReader) Storage(accountKey, from []byte, fn func(key, value []byte) (bool, error)) error {
+	account, err := r.findAccount(accountKey)
+	if err != nil || account == nil {
+		return err
+	}
+	visitSlot := func(cell *Cell, depth int) (bool, error) {
+		if cell.spl == 0 {
+			return true, nil
+		}
+		key := r.storageKey(cell)
+		if bytes.Compare(key, from) < 0 {
+			return true, nil
+		}
+		return fn(key, storageValue(cell))
+	}
+	switch {
+	case account.spl > 0:
+		_, err = visitSlot(account, 64)
+	case account.hl > 0:
+		path := append(append([]byte{}, accountKey...), account.extension[:account.extLen]...)
+		if bytes.Compare(path[64:], prefixOf(from, len(path)-64)) < 0 {
+			return nil
+		}
+		_, err = r.leaves(path, 64, from, visitSlot)
+	}
+	return err
+}
+
+// findAccount returns the cell of the account with the hashed key.
+func (r *HexPatriciaReader) findAccount(accountKey []byte) (*Cell, error) {
+	path := []byte{}
+	for {
+		b, err := r.loadBranch(path)
+		if err != nil || b == nil || len(path) >= len(accountKey) {
+			return nil, err
+		}
+		nibble := int(accountKey[len(path)])
+		if b.bitmap&(uint16(1)<<nibble) == 0 {
+			return nil, nil
+		}
+		cell := &b.cells[nibble]
+		switch {
+		case cell.apl > 0:
+			if !bytes.Equal(r.accountKey(cell), accountKey) {
+				return nil, nil
+			}
+			return cell, nil
+		case isExtension(cell):
+			path = append(b.childPath(nibble), cell.extension[:cell.extLen]...)
+			if !bytes.HasPrefix(accountKey, path) {
+				return nil, nil
+			}
+		case cell.hl > 0:
+			path = b.childPath(nibble)
+		default:
+			return nil, nil
+		}
+	}
+}
+
+func prefixOf(key []byte, n int) []byte {
+	if len(key) < n {
+		return key
+	}
+	return key[:n]
+}
+
+// leaves visits the leaf cells below the branch at the path in the order of
+// the keys, skipping the subtrees with the keys less than from. The keys
+// are compared from the offset of the trie - 0 for the accounts trie and 64
+// for the storage tries, the accounts are the leaves of the accounts trie.
+func (r *HexPatriciaReader) leaves(path []byte, offset int, from []byte, fn func(cell *Cell, depth int) (bool, error)) (bool, error) {
+	b, err := r.loadBranch(path)
+	if err != nil || b == nil {
+		return err == nil, err
+	}
+	for bitset := b.bitmap; bitset != 0; {
+		bit := bitset & -bitset
+		bitset ^= bit
+		nibble := bits.TrailingZeros16(bit)
+		cell, depth, childPath := &b.cells[nibble], b.depth(), b.childPath(nibble)
+		if bytes.Compare(childPath[offset:], prefixOf(from, len(childPath)-offset)) < 0 {
+			continue
+		}
+		var ok bool
+		switch {
+		case cell.apl > 0 || isStorageLeaf(cell, depth):
+			ok, err = fn(cell, depth)
+		case isExtension(cell):
+			childPath = append(childPath, cell.extension[:cell.extLen]...)
+			if bytes.Compare(childPath[offset:], prefixOf(from, len(childPath)-offset)) < 0 {
+				continue
+			}
+			ok, err = r.leaves(childPath, offset, from, fn)
+		case cell.hl > 0:
+			ok, err = r.leaves(childPath, offset, from, fn)
+		default:
+			ok = true
+		}
+		if err != nil || !ok {
+			return ok, err
+		}
+	}
+	return true, nil
+}
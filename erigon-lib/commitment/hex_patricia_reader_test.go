@@ -0,0 +1,156 @@
+package commitment
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/ledgerwatch/erigon-lib/common/length"
+)
+
+func keccakNibbles(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	key := make([]byte, 64)
+	for i, b := range h.Sum(nil) {
+		key[2*i], key[2*i+1] = b>>4, b&0xf
+	}
+	return key
+}
+
+// requireProofChain checks that every node of the proof is referenced by the
+// previous one, starting from the root.
+func requireProofChain(t *testing.T, root []byte, proof [][]byte) {
+	t.Helper()
+	require.NotEmpty(t, proof)
+	for i, node := range proof {
+		h := sha3.NewLegacyKeccak256()
+		h.Write(node)
+		if i == 0 {
+			require.Equal(t, root, h.Sum(nil))
+			continue
+		}
+		ref := node
+		if len(node) >= length.Hash {
+			ref = h.Sum(nil)
+		}
+		require.True(t, bytes.Contains(proof[i-1], ref), "node %d is not referenced by its parent", i)
+	}
+}
+
+func Test_HexPatriciaReader(t *testing.T) {
+	ctx := context.Background()
+	ms := NewMockState(t)
+	hph := NewHexPatriciaHashed(length.Addr, ms)
+
+	rnd := rand.New(rand.NewSource(42))
+	addrs := make([]string, 300)
+	slots := map[string]map[string]string{}
+	builder := NewUpdateBuilder()
+	for i := range addrs {
+		addr := make([]byte, length.Addr)
+		rnd.Read(addr)
+		addrs[i] = hex.EncodeToString(addr)
+		builder.Balance(addrs[i], uint64(i+1)).Nonce(addrs[i], uint64(i))
+	}
+	// No storage, a single slot, a few slots and a large storage trie
+	for i, count := range []int{1, 3, 200} {
+		addr := addrs[i]
+		slots[addr] = map[string]string{}
+		for j := 0; j < count; j++ {
+			loc := make([]byte, length.Hash)
+			rnd.Read(loc)
+			val := fmt.Sprintf("%04x", j+1)
+			slots[addr][hex.EncodeToString(loc)] = val
+			builder.Storage(addr, hex.EncodeToString(loc), val)
+		}
+	}
+	plainKeys, updates := builder.Build()
+	require.NoError(t, ms.applyPlainUpdates(plainKeys, updates))
+	root, err := hph.ProcessUpdates(ctx, plainKeys, updates)
+	require.NoError(t, err)
+
+	r := NewHexPatriciaReader(length.Addr, ms)
+	readRoot, err := r.RootHash()
+	require.NoError(t, err)
+	require.Equal(t, root, readRoot)
+
+	var accounts []*AccountLeaf
+	err = r.Accounts(nil, func(acc *AccountLeaf) (bool, error) {
+		accounts = append(accounts, acc)
+		return true, nil
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, len(addrs))
+	require.True(t, sort.SliceIsSorted(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i].HashedKey, accounts[j].HashedKey) < 0
+	}))
+
+	for _, acc := range accounts {
+		proof, err := r.AccountProof(acc.HashedKey)
+		require.NoError(t, err)
+		requireProofChain(t, root, proof)
+	}
+
+	// The iteration starts at the given key
+	var tail []*AccountLeaf
+	err = r.Accounts(accounts[100].HashedKey, func(acc *AccountLeaf) (bool, error) {
+		tail = append(tail, acc)
+		return len(tail) < 10, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, accounts[100:110], tail)
+
+	// The proof of a missing key ends with the node where its path leaves the trie
+	missing := append(append([]byte{}, accounts[100].HashedKey[:63]...), (accounts[100].HashedKey[63]+1)%16)
+	proof, err := r.AccountProof(missing)
+	require.NoError(t, err)
+	requireProofChain(t, root, proof)
+
+	for addr, want := range slots {
+		plainKey, err := hex.DecodeString(addr)
+		require.NoError(t, err)
+		accountKey := keccakNibbles(plainKey)
+		var account *AccountLeaf
+		for _, acc := range accounts {
+			if bytes.Equal(acc.HashedKey, accountKey) {
+				account = acc
+			}
+		}
+		require.NotNil(t, account)
+
+		var (
+			keys             [][]byte
+			values, expected []string
+		)
+		err = r.Storage(accountKey, nil, func(key, value []byte) (bool, error) {
+			keys = append(keys, key)
+			values = append(values, hex.EncodeToString(value))
+			return true, nil
+		})
+		require.NoError(t, err)
+		for _, val := range want {
+			expected = append(expected, "82"+val)
+		}
+		require.ElementsMatch(t, expected, values)
+		require.True(t, sort.SliceIsSorted(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 }))
+
+		for _, key := range keys {
+			proof, err := r.StorageProof(accountKey, key)
+			require.NoError(t, err)
+			requireProofChain(t, account.StorageRoot[:], proof)
+		}
+		node, err := r.StorageNode(accountKey, nil)
+		require.NoError(t, err)
+		h := sha3.NewLegacyKeccak256()
+		h.Write(node)
+		require.Equal(t, account.StorageRoot[:], h.Sum(nil))
+	}
+}
@@ -32,20 +32,21 @@ import (
 // registered services, instead those can use utility methods to create/access
 // databases or flat files
 type Dirs struct {
-	DataDir         string
-	RelativeDataDir string // like dataDir, but without filepath.Abs() resolution
-	Chaindata       string
-	Tmp             string
-	Snap            string
-	SnapIdx         string
-	SnapHistory     string
-	SnapDomain      string
-	SnapAccessors   string
-	Downloader      string
-	TxPool          string
-	Nodes           string
-	CaplinHistory   string
-	CaplinIndexing  string
+	DataDir           string
+	RelativeDataDir   string // like dataDir, but without filepath.Abs() resolution
+	Chaindata         string
+	Tmp               string
+	Snap              string
+	SnapIdx           string
+	SnapHistory       string
+	SnapDomain        string
+	SnapAccessors     string
+	Downloader        string
+	TxPool            string
+	Nodes             string
+	CaplinHistory     string
+	CaplinIndexing    string
+	CaplinCheckpoints string
 }
 
 func New(datadir string) Dirs {
@@ -60,20 +61,21 @@ func New(datadir string) Dirs {
 	}
 
 	dirs := Dirs{
-		RelativeDataDir: relativeDataDir,
-		DataDir:         datadir,
-		Chaindata:       filepath.Join(datadir, "chaindata"),
-		Tmp:             filepath.Join(datadir, "temp"),
-		Snap:            filepath.Join(datadir, "snapshots"),
-		SnapIdx:         filepath.Join(datadir, "snapshots", "idx"),
-		SnapHistory:     filepath.Join(datadir, "snapshots", "history"),
-		SnapDomain:      filepath.Join(datadir, "snapshots", "domain"),
-		SnapAccessors:   filepath.Join(datadir, "snapshots", "accessor"),
-		Downloader:      filepath.Join(datadir, "downloader"),
-		TxPool:          filepath.Join(datadir, "txpool"),
-		Nodes:           filepath.Join(datadir, "nodes"),
-		CaplinHistory:   filepath.Join(datadir, "caplin/history"),
-		CaplinIndexing:  filepath.Join(datadir, "caplin/indexing"),
+		RelativeDataDir:   relativeDataDir,
+		DataDir:           datadir,
+		Chaindata:         filepath.Join(datadir, "chaindata"),
+		Tmp:               filepath.Join(datadir, "temp"),
+		Snap:              filepath.Join(datadir, "snapshots"),
+		SnapIdx:           filepath.Join(datadir, "snapshots", "idx"),
+		SnapHistory:       filepath.Join(datadir, "snapshots", "history"),
+		SnapDomain:        filepath.Join(datadir, "snapshots", "domain"),
+		SnapAccessors:     filepath.Join(datadir, "snapshots", "accessor"),
+		Downloader:        filepath.Join(datadir, "downloader"),
+		TxPool:            filepath.Join(datadir, "txpool"),
+		Nodes:             filepath.Join(datadir, "nodes"),
+		CaplinHistory:     filepath.Join(datadir, "caplin/history"),
+		CaplinIndexing:    filepath.Join(datadir, "caplin/indexing"),
+		CaplinCheckpoints: filepath.Join(datadir, "caplin/checkpoints"),
 	}
 
 	dir.MustExist(dirs.Chaindata, dirs.Tmp,
@@ -16,6 +16,8 @@
 
 package diagnostics
 
+import "time"
+
 type PeerStatisticsGetter interface {
 	GetPeersStatistics() map[string]*PeerStatistics
 }
@@ -29,6 +31,25 @@ type PeerStatistics struct {
 	TypeBytesOut map[string]uint64
 }
 
+type PeerScoreGetter interface {
+	GetPeersScores() map[string]*PeerScore
+}
+
+type PeerScore struct {
+	Score              float64                `json:"score"`
+	AppSpecificScore   float64                `json:"appSpecificScore"`
+	IPColocationFactor float64                `json:"ipColocationFactor"`
+	BehaviourPenalty   float64                `json:"behaviourPenalty"`
+	Topics             map[string]*TopicScore `json:"topics"`
+}
+
+type TopicScore struct {
+	TimeInMesh               time.Duration `json:"timeInMesh"`
+	FirstMessageDeliveries   float64       `json:"firstMessageDeliveries"`
+	MeshMessageDeliveries    float64       `json:"meshMessageDeliveries"`
+	InvalidMessageDeliveries float64       `json:"invalidMessageDeliveries"`
+}
+
 type SnapshotDownloadStatistics struct {
 	Downloaded           uint64                               `json:"downloaded"`
 	Total                uint64                               `json:"total"`
@@ -20,6 +20,7 @@ import (
 	"context"
 	"io"
 
+	"github.com/ledgerwatch/erigon-lib/diagnostics"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"google.golang.org/grpc"
@@ -207,10 +208,93 @@ func (s *EthBackendClientDirect) Peers(ctx context.Context, in *emptypb.Empty, o
 	return s.server.Peers(ctx, in)
 }
 
+func (s *EthBackendClientDirect) GetPeersScores() map[string]*diagnostics.PeerScore {
+	if diag, ok := s.server.(diagnostics.PeerScoreGetter); ok {
+		return diag.GetPeersScores()
+	}
+
+	return map[string]*diagnostics.PeerScore{}
+}
+
 func (s *EthBackendClientDirect) AddPeer(ctx context.Context, in *remote.AddPeerRequest, opts ...grpc.CallOption) (*remote.AddPeerReply, error) {
 	return s.server.AddPeer(ctx, in)
 }
 
+func (s *EthBackendClientDirect) RemovePeer(ctx context.Context, in *remote.RemovePeerRequest, opts ...grpc.CallOption) (*remote.RemovePeerReply, error) {
+	return s.server.RemovePeer(ctx, in)
+}
+
+func (s *EthBackendClientDirect) AddTrustedPeer(ctx context.Context, in *remote.AddPeerRequest, opts ...grpc.CallOption) (*remote.AddPeerReply, error) {
+	return s.server.AddTrustedPeer(ctx, in)
+}
+
+func (s *EthBackendClientDirect) RemoveTrustedPeer(ctx context.Context, in *remote.RemovePeerRequest, opts ...grpc.CallOption) (*remote.RemovePeerReply, error) {
+	return s.server.RemoveTrustedPeer(ctx, in)
+}
+
+func (s *EthBackendClientDirect) BanPeer(ctx context.Context, in *remote.BanPeerRequest, opts ...grpc.CallOption) (*remote.BanPeerReply, error) {
+	return s.server.BanPeer(ctx, in)
+}
+
+func (s *EthBackendClientDirect) UnbanPeer(ctx context.Context, in *remote.UnbanPeerRequest, opts ...grpc.CallOption) (*remote.UnbanPeerReply, error) {
+	return s.server.UnbanPeer(ctx, in)
+}
+
+func (s *EthBackendClientDirect) BannedPeers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*remote.BannedPeersReply, error) {
+	return s.server.BannedPeers(ctx, in)
+}
+
+// -- start PeerEvents
+
+func (s *EthBackendClientDirect) PeerEvents(ctx context.Context, in *remote.PeerEventsRequest, opts ...grpc.CallOption) (remote.ETHBACKEND_PeerEventsClient, error) {
+	ch := make(chan *peerEventsReply, 16384)
+	streamServer := &PeerEventsStreamS{ch: ch, ctx: ctx}
+	go func() {
+		defer close(ch)
+		streamServer.Err(s.server.PeerEvents(in, streamServer))
+	}()
+	return &PeerEventsStreamC{ch: ch, ctx: ctx}, nil
+}
+
+type peerEventsReply struct {
+	r   *remote.PeerEvent
+	err error
+}
+type PeerEventsStreamS struct {
+	ch  chan *peerEventsReply
+	ctx context.Context
+	grpc.ServerStream
+}
+
+func (s *PeerEventsStreamS) Send(m *remote.PeerEvent) error {
+	s.ch <- &peerEventsReply{r: m}
+	return nil
+}
+func (s *PeerEventsStreamS) Context() context.Context { return s.ctx }
+func (s *PeerEventsStreamS) Err(err error) {
+	if err == nil {
+		return
+	}
+	s.ch <- &peerEventsReply{err: err}
+}
+
+type PeerEventsStreamC struct {
+	ch  chan *peerEventsReply
+	ctx context.Context
+	grpc.ClientStream
+}
+
+func (c *PeerEventsStreamC) Recv() (*remote.PeerEvent, error) {
+	m, ok := <-c.ch
+	if !ok || m == nil {
+		return nil, io.EOF
+	}
+	return m.r, m.err
+}
+func (c *PeerEventsStreamC) Context() context.Context { return c.ctx }
+
+// -- end PeerEvents
+
 func (s *EthBackendClientDirect) PendingBlock(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*remote.PendingBlockReply, error) {
 	return s.server.PendingBlock(ctx, in)
 }
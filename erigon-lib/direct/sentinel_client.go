@@ -120,3 +120,11 @@ func (s *SentinelClientDirect) GetPeersStatistics() map[string]*diagnostics.Peer
 
 	return map[string]*diagnostics.PeerStatistics{}
 }
+
+func (s *SentinelClientDirect) GetPeersScores() map[string]*diagnostics.PeerScore {
+	if diag, ok := s.server.(diagnostics.PeerScoreGetter); ok {
+		return diag.GetPeersScores()
+	}
+
+	return map[string]*diagnostics.PeerScore{}
+}
@@ -35,6 +35,7 @@ const (
 	ETH66 = 66
 	ETH67 = 67
 	ETH68 = 68
+	ETH69 = 69
 )
 
 var ProtoIds = map[uint]map[sentry.MessageId]struct{}{
@@ -324,6 +325,30 @@ func (c *SentryClientDirect) AddPeer(ctx context.Context, in *sentry.AddPeerRequ
 	return c.server.AddPeer(ctx, in)
 }
 
+func (c *SentryClientDirect) RemovePeer(ctx context.Context, in *sentry.RemovePeerRequest, opts ...grpc.CallOption) (*sentry.RemovePeerReply, error) {
+	return c.server.RemovePeer(ctx, in)
+}
+
+func (c *SentryClientDirect) AddTrustedPeer(ctx context.Context, in *sentry.AddPeerRequest, opts ...grpc.CallOption) (*sentry.AddPeerReply, error) {
+	return c.server.AddTrustedPeer(ctx, in)
+}
+
+func (c *SentryClientDirect) RemoveTrustedPeer(ctx context.Context, in *sentry.RemovePeerRequest, opts ...grpc.CallOption) (*sentry.RemovePeerReply, error) {
+	return c.server.RemoveTrustedPeer(ctx, in)
+}
+
+func (c *SentryClientDirect) BanPeer(ctx context.Context, in *sentry.BanPeerRequest, opts ...grpc.CallOption) (*sentry.BanPeerReply, error) {
+	return c.server.BanPeer(ctx, in)
+}
+
+func (c *SentryClientDirect) UnbanPeer(ctx context.Context, in *sentry.UnbanPeerRequest, opts ...grpc.CallOption) (*sentry.UnbanPeerReply, error) {
+	return c.server.UnbanPeer(ctx, in)
+}
+
+func (c *SentryClientDirect) BannedPeers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*sentry.BannedPeersReply, error) {
+	return c.server.BannedPeers(ctx, in)
+}
+
 type peersReply struct {
 	r   *sentry.PeerEvent
 	err error
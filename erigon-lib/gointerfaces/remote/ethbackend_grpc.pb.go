@@ -21,21 +21,28 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	ETHBACKEND_Etherbase_FullMethodName       = "/remote.ETHBACKEND/Etherbase"
-	ETHBACKEND_NetVersion_FullMethodName      = "/remote.ETHBACKEND/NetVersion"
-	ETHBACKEND_NetPeerCount_FullMethodName    = "/remote.ETHBACKEND/NetPeerCount"
-	ETHBACKEND_Version_FullMethodName         = "/remote.ETHBACKEND/Version"
-	ETHBACKEND_ProtocolVersion_FullMethodName = "/remote.ETHBACKEND/ProtocolVersion"
-	ETHBACKEND_ClientVersion_FullMethodName   = "/remote.ETHBACKEND/ClientVersion"
-	ETHBACKEND_Subscribe_FullMethodName       = "/remote.ETHBACKEND/Subscribe"
-	ETHBACKEND_SubscribeLogs_FullMethodName   = "/remote.ETHBACKEND/SubscribeLogs"
-	ETHBACKEND_Block_FullMethodName           = "/remote.ETHBACKEND/Block"
-	ETHBACKEND_TxnLookup_FullMethodName       = "/remote.ETHBACKEND/TxnLookup"
-	ETHBACKEND_NodeInfo_FullMethodName        = "/remote.ETHBACKEND/NodeInfo"
-	ETHBACKEND_Peers_FullMethodName           = "/remote.ETHBACKEND/Peers"
-	ETHBACKEND_AddPeer_FullMethodName         = "/remote.ETHBACKEND/AddPeer"
-	ETHBACKEND_PendingBlock_FullMethodName    = "/remote.ETHBACKEND/PendingBlock"
-	ETHBACKEND_BorEvent_FullMethodName        = "/remote.ETHBACKEND/BorEvent"
+	ETHBACKEND_Etherbase_FullMethodName         = "/remote.ETHBACKEND/Etherbase"
+	ETHBACKEND_NetVersion_FullMethodName        = "/remote.ETHBACKEND/NetVersion"
+	ETHBACKEND_NetPeerCount_FullMethodName      = "/remote.ETHBACKEND/NetPeerCount"
+	ETHBACKEND_Version_FullMethodName           = "/remote.ETHBACKEND/Version"
+	ETHBACKEND_ProtocolVersion_FullMethodName   = "/remote.ETHBACKEND/ProtocolVersion"
+	ETHBACKEND_ClientVersion_FullMethodName     = "/remote.ETHBACKEND/ClientVersion"
+	ETHBACKEND_Subscribe_FullMethodName         = "/remote.ETHBACKEND/Subscribe"
+	ETHBACKEND_SubscribeLogs_FullMethodName     = "/remote.ETHBACKEND/SubscribeLogs"
+	ETHBACKEND_Block_FullMethodName             = "/remote.ETHBACKEND/Block"
+	ETHBACKEND_TxnLookup_FullMethodName         = "/remote.ETHBACKEND/TxnLookup"
+	ETHBACKEND_NodeInfo_FullMethodName          = "/remote.ETHBACKEND/NodeInfo"
+	ETHBACKEND_Peers_FullMethodName             = "/remote.ETHBACKEND/Peers"
+	ETHBACKEND_AddPeer_FullMethodName           = "/remote.ETHBACKEND/AddPeer"
+	ETHBACKEND_RemovePeer_FullMethodName        = "/remote.ETHBACKEND/RemovePeer"
+	ETHBACKEND_AddTrustedPeer_FullMethodName    = "/remote.ETHBACKEND/AddTrustedPeer"
+	ETHBACKEND_RemoveTrustedPeer_FullMethodName = "/remote.ETHBACKEND/RemoveTrustedPeer"
+	ETHBACKEND_BanPeer_FullMethodName           = "/remote.ETHBACKEND/BanPeer"
+	ETHBACKEND_UnbanPeer_FullMethodName         = "/remote.ETHBACKEND/UnbanPeer"
+	ETHBACKEND_BannedPeers_FullMethodName       = "/remote.ETHBACKEND/BannedPeers"
+	ETHBACKEND_PeerEvents_FullMethodName        = "/remote.ETHBACKEND/PeerEvents"
+	ETHBACKEND_PendingBlock_FullMethodName      = "/remote.ETHBACKEND/PendingBlock"
+	ETHBACKEND_BorEvent_FullMethodName          = "/remote.ETHBACKEND/BorEvent"
 )
 
 // ETHBACKENDClient is the client API for ETHBACKEND service.
@@ -66,6 +73,18 @@ type ETHBACKENDClient interface {
 	// Peers collects and returns peers information from all running sentry instances.
 	Peers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PeersReply, error)
 	AddPeer(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error)
+	// RemovePeer removes the node from the static nodes and disconnects it.
+	RemovePeer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error)
+	// AddTrustedPeer allows the node to connect even when the peer slots are full.
+	AddTrustedPeer(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error)
+	RemoveTrustedPeer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error)
+	// BanPeer disconnects the node and refuses its connections until the ban expires.
+	BanPeer(ctx context.Context, in *BanPeerRequest, opts ...grpc.CallOption) (*BanPeerReply, error)
+	UnbanPeer(ctx context.Context, in *UnbanPeerRequest, opts ...grpc.CallOption) (*UnbanPeerReply, error)
+	// BannedPeers returns the nodes which are banned, along with the expiry of their bans.
+	BannedPeers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BannedPeersReply, error)
+	// PeerEvents subscribes to the peers connecting to and disconnecting from all running sentry instances.
+	PeerEvents(ctx context.Context, in *PeerEventsRequest, opts ...grpc.CallOption) (ETHBACKEND_PeerEventsClient, error)
 	// PendingBlock returns latest built block.
 	PendingBlock(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PendingBlockReply, error)
 	BorEvent(ctx context.Context, in *BorEventRequest, opts ...grpc.CallOption) (*BorEventReply, error)
@@ -241,6 +260,92 @@ func (c *eTHBACKENDClient) AddPeer(ctx context.Context, in *AddPeerRequest, opts
 	return out, nil
 }
 
+func (c *eTHBACKENDClient) RemovePeer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error) {
+	out := new(RemovePeerReply)
+	err := c.cc.Invoke(ctx, ETHBACKEND_RemovePeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eTHBACKENDClient) AddTrustedPeer(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error) {
+	out := new(AddPeerReply)
+	err := c.cc.Invoke(ctx, ETHBACKEND_AddTrustedPeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eTHBACKENDClient) RemoveTrustedPeer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error) {
+	out := new(RemovePeerReply)
+	err := c.cc.Invoke(ctx, ETHBACKEND_RemoveTrustedPeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eTHBACKENDClient) BanPeer(ctx context.Context, in *BanPeerRequest, opts ...grpc.CallOption) (*BanPeerReply, error) {
+	out := new(BanPeerReply)
+	err := c.cc.Invoke(ctx, ETHBACKEND_BanPeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eTHBACKENDClient) UnbanPeer(ctx context.Context, in *UnbanPeerRequest, opts ...grpc.CallOption) (*UnbanPeerReply, error) {
+	out := new(UnbanPeerReply)
+	err := c.cc.Invoke(ctx, ETHBACKEND_UnbanPeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eTHBACKENDClient) BannedPeers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BannedPeersReply, error) {
+	out := new(BannedPeersReply)
+	err := c.cc.Invoke(ctx, ETHBACKEND_BannedPeers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eTHBACKENDClient) PeerEvents(ctx context.Context, in *PeerEventsRequest, opts ...grpc.CallOption) (ETHBACKEND_PeerEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ETHBACKEND_ServiceDesc.Streams[2], ETHBACKEND_PeerEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eTHBACKENDPeerEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ETHBACKEND_PeerEventsClient interface {
+	Recv() (*PeerEvent, error)
+	grpc.ClientStream
+}
+
+type eTHBACKENDPeerEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *eTHBACKENDPeerEventsClient) Recv() (*PeerEvent, error) {
+	m := new(PeerEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *eTHBACKENDClient) PendingBlock(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PendingBlockReply, error) {
 	out := new(PendingBlockReply)
 	err := c.cc.Invoke(ctx, ETHBACKEND_PendingBlock_FullMethodName, in, out, opts...)
@@ -287,6 +392,18 @@ type ETHBACKENDServer interface {
 	// Peers collects and returns peers information from all running sentry instances.
 	Peers(context.Context, *emptypb.Empty) (*PeersReply, error)
 	AddPeer(context.Context, *AddPeerRequest) (*AddPeerReply, error)
+	// RemovePeer removes the node from the static nodes and disconnects it.
+	RemovePeer(context.Context, *RemovePeerRequest) (*RemovePeerReply, error)
+	// AddTrustedPeer allows the node to connect even when the peer slots are full.
+	AddTrustedPeer(context.Context, *AddPeerRequest) (*AddPeerReply, error)
+	RemoveTrustedPeer(context.Context, *RemovePeerRequest) (*RemovePeerReply, error)
+	// BanPeer disconnects the node and refuses its connections until the ban expires.
+	BanPeer(context.Context, *BanPeerRequest) (*BanPeerReply, error)
+	UnbanPeer(context.Context, *UnbanPeerRequest) (*UnbanPeerReply, error)
+	// BannedPeers returns the nodes which are banned, along with the expiry of their bans.
+	BannedPeers(context.Context, *emptypb.Empty) (*BannedPeersReply, error)
+	// PeerEvents subscribes to the peers connecting to and disconnecting from all running sentry instances.
+	PeerEvents(*PeerEventsRequest, ETHBACKEND_PeerEventsServer) error
 	// PendingBlock returns latest built block.
 	PendingBlock(context.Context, *emptypb.Empty) (*PendingBlockReply, error)
 	BorEvent(context.Context, *BorEventRequest) (*BorEventReply, error)
@@ -336,6 +453,27 @@ func (UnimplementedETHBACKENDServer) Peers(context.Context, *emptypb.Empty) (*Pe
 func (UnimplementedETHBACKENDServer) AddPeer(context.Context, *AddPeerRequest) (*AddPeerReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddPeer not implemented")
 }
+func (UnimplementedETHBACKENDServer) RemovePeer(context.Context, *RemovePeerRequest) (*RemovePeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemovePeer not implemented")
+}
+func (UnimplementedETHBACKENDServer) AddTrustedPeer(context.Context, *AddPeerRequest) (*AddPeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddTrustedPeer not implemented")
+}
+func (UnimplementedETHBACKENDServer) RemoveTrustedPeer(context.Context, *RemovePeerRequest) (*RemovePeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveTrustedPeer not implemented")
+}
+func (UnimplementedETHBACKENDServer) BanPeer(context.Context, *BanPeerRequest) (*BanPeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BanPeer not implemented")
+}
+func (UnimplementedETHBACKENDServer) UnbanPeer(context.Context, *UnbanPeerRequest) (*UnbanPeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnbanPeer not implemented")
+}
+func (UnimplementedETHBACKENDServer) BannedPeers(context.Context, *emptypb.Empty) (*BannedPeersReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BannedPeers not implemented")
+}
+func (UnimplementedETHBACKENDServer) PeerEvents(*PeerEventsRequest, ETHBACKEND_PeerEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method PeerEvents not implemented")
+}
 func (UnimplementedETHBACKENDServer) PendingBlock(context.Context, *emptypb.Empty) (*PendingBlockReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PendingBlock not implemented")
 }
@@ -600,6 +738,135 @@ func _ETHBACKEND_AddPeer_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ETHBACKEND_RemovePeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemovePeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ETHBACKENDServer).RemovePeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ETHBACKEND_RemovePeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ETHBACKENDServer).RemovePeer(ctx, req.(*RemovePeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ETHBACKEND_AddTrustedPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ETHBACKENDServer).AddTrustedPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ETHBACKEND_AddTrustedPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ETHBACKENDServer).AddTrustedPeer(ctx, req.(*AddPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ETHBACKEND_RemoveTrustedPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemovePeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ETHBACKENDServer).RemoveTrustedPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ETHBACKEND_RemoveTrustedPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ETHBACKENDServer).RemoveTrustedPeer(ctx, req.(*RemovePeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ETHBACKEND_BanPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BanPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ETHBACKENDServer).BanPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ETHBACKEND_BanPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ETHBACKENDServer).BanPeer(ctx, req.(*BanPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ETHBACKEND_UnbanPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnbanPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ETHBACKENDServer).UnbanPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ETHBACKEND_UnbanPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ETHBACKENDServer).UnbanPeer(ctx, req.(*UnbanPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ETHBACKEND_BannedPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ETHBACKENDServer).BannedPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ETHBACKEND_BannedPeers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ETHBACKENDServer).BannedPeers(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ETHBACKEND_PeerEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PeerEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ETHBACKENDServer).PeerEvents(m, &eTHBACKENDPeerEventsServer{stream})
+}
+
+type ETHBACKEND_PeerEventsServer interface {
+	Send(*PeerEvent) error
+	grpc.ServerStream
+}
+
+type eTHBACKENDPeerEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *eTHBACKENDPeerEventsServer) Send(m *PeerEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _ETHBACKEND_PendingBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -687,6 +954,30 @@ var ETHBACKEND_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddPeer",
 			Handler:    _ETHBACKEND_AddPeer_Handler,
 		},
+		{
+			MethodName: "RemovePeer",
+			Handler:    _ETHBACKEND_RemovePeer_Handler,
+		},
+		{
+			MethodName: "AddTrustedPeer",
+			Handler:    _ETHBACKEND_AddTrustedPeer_Handler,
+		},
+		{
+			MethodName: "RemoveTrustedPeer",
+			Handler:    _ETHBACKEND_RemoveTrustedPeer_Handler,
+		},
+		{
+			MethodName: "BanPeer",
+			Handler:    _ETHBACKEND_BanPeer_Handler,
+		},
+		{
+			MethodName: "UnbanPeer",
+			Handler:    _ETHBACKEND_UnbanPeer_Handler,
+		},
+		{
+			MethodName: "BannedPeers",
+			Handler:    _ETHBACKEND_BannedPeers_Handler,
+		},
 		{
 			MethodName: "PendingBlock",
 			Handler:    _ETHBACKEND_PendingBlock_Handler,
@@ -708,6 +999,11 @@ var ETHBACKEND_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "PeerEvents",
+			Handler:       _ETHBACKEND_PeerEvents_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "remote/ethbackend.proto",
 }
@@ -24,6 +24,15 @@ var _ SentryServer = &SentryServerMock{}
 //			AddPeerFunc: func(contextMoqParam context.Context, addPeerRequest *AddPeerRequest) (*AddPeerReply, error) {
 //				panic("mock out the AddPeer method")
 //			},
+//			AddTrustedPeerFunc: func(contextMoqParam context.Context, addPeerRequest *AddPeerRequest) (*AddPeerReply, error) {
+//				panic("mock out the AddTrustedPeer method")
+//			},
+//			BanPeerFunc: func(contextMoqParam context.Context, banPeerRequest *BanPeerRequest) (*BanPeerReply, error) {
+//				panic("mock out the BanPeer method")
+//			},
+//			BannedPeersFunc: func(contextMoqParam context.Context, empty *emptypb.Empty) (*BannedPeersReply, error) {
+//				panic("mock out the BannedPeers method")
+//			},
 //			HandShakeFunc: func(contextMoqParam context.Context, empty *emptypb.Empty) (*HandShakeReply, error) {
 //				panic("mock out the HandShake method")
 //			},
@@ -51,6 +60,12 @@ var _ SentryServer = &SentryServerMock{}
 //			PenalizePeerFunc: func(contextMoqParam context.Context, penalizePeerRequest *PenalizePeerRequest) (*emptypb.Empty, error) {
 //				panic("mock out the PenalizePeer method")
 //			},
+//			RemovePeerFunc: func(contextMoqParam context.Context, removePeerRequest *RemovePeerRequest) (*RemovePeerReply, error) {
+//				panic("mock out the RemovePeer method")
+//			},
+//			RemoveTrustedPeerFunc: func(contextMoqParam context.Context, removePeerRequest *RemovePeerRequest) (*RemovePeerReply, error) {
+//				panic("mock out the RemoveTrustedPeer method")
+//			},
 //			SendMessageByIdFunc: func(contextMoqParam context.Context, sendMessageByIdRequest *SendMessageByIdRequest) (*SentPeers, error) {
 //				panic("mock out the SendMessageById method")
 //			},
@@ -66,6 +81,9 @@ var _ SentryServer = &SentryServerMock{}
 //			SetStatusFunc: func(contextMoqParam context.Context, statusData *StatusData) (*SetStatusReply, error) {
 //				panic("mock out the SetStatus method")
 //			},
+//			UnbanPeerFunc: func(contextMoqParam context.Context, unbanPeerRequest *UnbanPeerRequest) (*UnbanPeerReply, error) {
+//				panic("mock out the UnbanPeer method")
+//			},
 //			mustEmbedUnimplementedSentryServerFunc: func()  {
 //				panic("mock out the mustEmbedUnimplementedSentryServer method")
 //			},
@@ -79,6 +97,15 @@ type SentryServerMock struct {
 	// AddPeerFunc mocks the AddPeer method.
 	AddPeerFunc func(contextMoqParam context.Context, addPeerRequest *AddPeerRequest) (*AddPeerReply, error)
 
+	// AddTrustedPeerFunc mocks the AddTrustedPeer method.
+	AddTrustedPeerFunc func(contextMoqParam context.Context, addPeerRequest *AddPeerRequest) (*AddPeerReply, error)
+
+	// BanPeerFunc mocks the BanPeer method.
+	BanPeerFunc func(contextMoqParam context.Context, banPeerRequest *BanPeerRequest) (*BanPeerReply, error)
+
+	// BannedPeersFunc mocks the BannedPeers method.
+	BannedPeersFunc func(contextMoqParam context.Context, empty *emptypb.Empty) (*BannedPeersReply, error)
+
 	// HandShakeFunc mocks the HandShake method.
 	HandShakeFunc func(contextMoqParam context.Context, empty *emptypb.Empty) (*HandShakeReply, error)
 
@@ -106,6 +133,12 @@ type SentryServerMock struct {
 	// PenalizePeerFunc mocks the PenalizePeer method.
 	PenalizePeerFunc func(contextMoqParam context.Context, penalizePeerRequest *PenalizePeerRequest) (*emptypb.Empty, error)
 
+	// RemovePeerFunc mocks the RemovePeer method.
+	RemovePeerFunc func(contextMoqParam context.Context, removePeerRequest *RemovePeerRequest) (*RemovePeerReply, error)
+
+	// RemoveTrustedPeerFunc mocks the RemoveTrustedPeer method.
+	RemoveTrustedPeerFunc func(contextMoqParam context.Context, removePeerRequest *RemovePeerRequest) (*RemovePeerReply, error)
+
 	// SendMessageByIdFunc mocks the SendMessageById method.
 	SendMessageByIdFunc func(contextMoqParam context.Context, sendMessageByIdRequest *SendMessageByIdRequest) (*SentPeers, error)
 
@@ -121,6 +154,9 @@ type SentryServerMock struct {
 	// SetStatusFunc mocks the SetStatus method.
 	SetStatusFunc func(contextMoqParam context.Context, statusData *StatusData) (*SetStatusReply, error)
 
+	// UnbanPeerFunc mocks the UnbanPeer method.
+	UnbanPeerFunc func(contextMoqParam context.Context, unbanPeerRequest *UnbanPeerRequest) (*UnbanPeerReply, error)
+
 	// mustEmbedUnimplementedSentryServerFunc mocks the mustEmbedUnimplementedSentryServer method.
 	mustEmbedUnimplementedSentryServerFunc func()
 
@@ -133,6 +169,27 @@ type SentryServerMock struct {
 			// AddPeerRequest is the addPeerRequest argument value.
 			AddPeerRequest *AddPeerRequest
 		}
+		// AddTrustedPeer holds details about calls to the AddTrustedPeer method.
+		AddTrustedPeer []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+			// AddPeerRequest is the addPeerRequest argument value.
+			AddPeerRequest *AddPeerRequest
+		}
+		// BanPeer holds details about calls to the BanPeer method.
+		BanPeer []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+			// BanPeerRequest is the banPeerRequest argument value.
+			BanPeerRequest *BanPeerRequest
+		}
+		// BannedPeers holds details about calls to the BannedPeers method.
+		BannedPeers []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+			// Empty is the empty argument value.
+			Empty *emptypb.Empty
+		}
 		// HandShake holds details about calls to the HandShake method.
 		HandShake []struct {
 			// ContextMoqParam is the contextMoqParam argument value.
@@ -196,6 +253,20 @@ type SentryServerMock struct {
 			// PenalizePeerRequest is the penalizePeerRequest argument value.
 			PenalizePeerRequest *PenalizePeerRequest
 		}
+		// RemovePeer holds details about calls to the RemovePeer method.
+		RemovePeer []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+			// RemovePeerRequest is the removePeerRequest argument value.
+			RemovePeerRequest *RemovePeerRequest
+		}
+		// RemoveTrustedPeer holds details about calls to the RemoveTrustedPeer method.
+		RemoveTrustedPeer []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+			// RemovePeerRequest is the removePeerRequest argument value.
+			RemovePeerRequest *RemovePeerRequest
+		}
 		// SendMessageById holds details about calls to the SendMessageById method.
 		SendMessageById []struct {
 			// ContextMoqParam is the contextMoqParam argument value.
@@ -231,11 +302,21 @@ type SentryServerMock struct {
 			// StatusData is the statusData argument value.
 			StatusData *StatusData
 		}
+		// UnbanPeer holds details about calls to the UnbanPeer method.
+		UnbanPeer []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+			// UnbanPeerRequest is the unbanPeerRequest argument value.
+			UnbanPeerRequest *UnbanPeerRequest
+		}
 		// mustEmbedUnimplementedSentryServer holds details about calls to the mustEmbedUnimplementedSentryServer method.
 		mustEmbedUnimplementedSentryServer []struct {
 		}
 	}
 	lockAddPeer                            sync.RWMutex
+	lockAddTrustedPeer                     sync.RWMutex
+	lockBanPeer                            sync.RWMutex
+	lockBannedPeers                        sync.RWMutex
 	lockHandShake                          sync.RWMutex
 	lockMessages                           sync.RWMutex
 	lockNodeInfo                           sync.RWMutex
@@ -245,11 +326,14 @@ type SentryServerMock struct {
 	lockPeerMinBlock                       sync.RWMutex
 	lockPeers                              sync.RWMutex
 	lockPenalizePeer                       sync.RWMutex
+	lockRemovePeer                         sync.RWMutex
+	lockRemoveTrustedPeer                  sync.RWMutex
 	lockSendMessageById                    sync.RWMutex
 	lockSendMessageByMinBlock              sync.RWMutex
 	lockSendMessageToAll                   sync.RWMutex
 	lockSendMessageToRandomPeers           sync.RWMutex
 	lockSetStatus                          sync.RWMutex
+	lockUnbanPeer                          sync.RWMutex
 	lockmustEmbedUnimplementedSentryServer sync.RWMutex
 }
 
@@ -293,6 +377,126 @@ func (mock *SentryServerMock) AddPeerCalls() []struct {
 	return calls
 }
 
+// AddTrustedPeer calls AddTrustedPeerFunc.
+func (mock *SentryServerMock) AddTrustedPeer(contextMoqParam context.Context, addPeerRequest *AddPeerRequest) (*AddPeerReply, error) {
+	callInfo := struct {
+		ContextMoqParam context.Context
+		AddPeerRequest  *AddPeerRequest
+	}{
+		ContextMoqParam: contextMoqParam,
+		AddPeerRequest:  addPeerRequest,
+	}
+	mock.lockAddTrustedPeer.Lock()
+	mock.calls.AddTrustedPeer = append(mock.calls.AddTrustedPeer, callInfo)
+	mock.lockAddTrustedPeer.Unlock()
+	if mock.AddTrustedPeerFunc == nil {
+		var (
+			addPeerReplyOut *AddPeerReply
+			errOut          error
+		)
+		return addPeerReplyOut, errOut
+	}
+	return mock.AddTrustedPeerFunc(contextMoqParam, addPeerRequest)
+}
+
+// AddTrustedPeerCalls gets all the calls that were made to AddTrustedPeer.
+// Check the length with:
+//
+//	len(mockedSentryServer.AddTrustedPeerCalls())
+func (mock *SentryServerMock) AddTrustedPeerCalls() []struct {
+	ContextMoqParam context.Context
+	AddPeerRequest  *AddPeerRequest
+} {
+	var calls []struct {
+		ContextMoqParam context.Context
+		AddPeerRequest  *AddPeerRequest
+	}
+	mock.lockAddTrustedPeer.RLock()
+	calls = mock.calls.AddTrustedPeer
+	mock.lockAddTrustedPeer.RUnlock()
+	return calls
+}
+
+// BanPeer calls BanPeerFunc.
+func (mock *SentryServerMock) BanPeer(contextMoqParam context.Context, banPeerRequest *BanPeerRequest) (*BanPeerReply, error) {
+	callInfo := struct {
+		ContextMoqParam context.Context
+		BanPeerRequest  *BanPeerRequest
+	}{
+		ContextMoqParam: contextMoqParam,
+		BanPeerRequest:  banPeerRequest,
+	}
+	mock.lockBanPeer.Lock()
+	mock.calls.BanPeer = append(mock.calls.BanPeer, callInfo)
+	mock.lockBanPeer.Unlock()
+	if mock.BanPeerFunc == nil {
+		var (
+			banPeerReplyOut *BanPeerReply
+			errOut          error
+		)
+		return banPeerReplyOut, errOut
+	}
+	return mock.BanPeerFunc(contextMoqParam, banPeerRequest)
+}
+
+// BanPeerCalls gets all the calls that were made to BanPeer.
+// Check the length with:
+//
+//	len(mockedSentryServer.BanPeerCalls())
+func (mock *SentryServerMock) BanPeerCalls() []struct {
+	ContextMoqParam context.Context
+	BanPeerRequest  *BanPeerRequest
+} {
+	var calls []struct {
+		ContextMoqParam context.Context
+		BanPeerRequest  *BanPeerRequest
+	}
+	mock.lockBanPeer.RLock()
+	calls = mock.calls.BanPeer
+	mock.lockBanPeer.RUnlock()
+	return calls
+}
+
+// BannedPeers calls BannedPeersFunc.
+func (mock *SentryServerMock) BannedPeers(contextMoqParam context.Context, empty *emptypb.Empty) (*BannedPeersReply, error) {
+	callInfo := struct {
+		ContextMoqParam context.Context
+		Empty           *emptypb.Empty
+	}{
+		ContextMoqParam: contextMoqParam,
+		Empty:           empty,
+	}
+	mock.lockBannedPeers.Lock()
+	mock.calls.BannedPeers = append(mock.calls.BannedPeers, callInfo)
+	mock.lockBannedPeers.Unlock()
+	if mock.BannedPeersFunc == nil {
+		var (
+			bannedPeersReplyOut *BannedPeersReply
+			errOut              error
+		)
+		return bannedPeersReplyOut, errOut
+	}
+	return mock.BannedPeersFunc(contextMoqParam, empty)
+}
+
+// BannedPeersCalls gets all the calls that were made to BannedPeers.
+// Check the length with:
+//
+//	len(mockedSentryServer.BannedPeersCalls())
+func (mock *SentryServerMock) BannedPeersCalls() []struct {
+	ContextMoqParam context.Context
+	Empty           *emptypb.Empty
+} {
+	var calls []struct {
+		ContextMoqParam context.Context
+		Empty           *emptypb.Empty
+	}
+	mock.lockBannedPeers.RLock()
+	calls = mock.calls.BannedPeers
+	mock.lockBannedPeers.RUnlock()
+	return calls
+}
+
 // HandShake calls HandShakeFunc.
 func (mock *SentryServerMock) HandShake(contextMoqParam context.Context, empty *emptypb.Empty) (*HandShakeReply, error) {
 	callInfo := struct {
@@ -651,6 +855,86 @@ func (mock *SentryServerMock) PenalizePeerCalls() []struct {
 	return calls
 }
 
+// RemovePeer calls RemovePeerFunc.
+func (mock *SentryServerMock) RemovePeer(contextMoqParam context.Context, removePeerRequest *RemovePeerRequest) (*RemovePeerReply, error) {
+	callInfo := struct {
+		ContextMoqParam   context.Context
+		RemovePeerRequest *RemovePeerRequest
+	}{
+		ContextMoqParam:   contextMoqParam,
+		RemovePeerRequest: removePeerRequest,
+	}
+	mock.lockRemovePeer.Lock()
+	mock.calls.RemovePeer = append(mock.calls.RemovePeer, callInfo)
+	mock.lockRemovePeer.Unlock()
+	if mock.RemovePeerFunc == nil {
+		var (
+			removePeerReplyOut *RemovePeerReply
+			errOut             error
+		)
+		return removePeerReplyOut, errOut
+	}
+	return mock.RemovePeerFunc(contextMoqParam, removePeerRequest)
+}
+
+// RemovePeerCalls gets all the calls that were made to RemovePeer.
+// Check the length with:
+//
+//	len(mockedSentryServer.RemovePeerCalls())
+func (mock *SentryServerMock) RemovePeerCalls() []struct {
+	ContextMoqParam   context.Context
+	RemovePeerRequest *RemovePeerRequest
+} {
+	var calls []struct {
+		ContextMoqParam   context.Context
+		RemovePeerRequest *RemovePeerRequest
+	}
+	mock.lockRemovePeer.RLock()
+	calls = mock.calls.RemovePeer
+	mock.lockRemovePeer.RUnlock()
+	return calls
+}
+
+// RemoveTrustedPeer calls RemoveTrustedPeerFunc.
+func (mock *SentryServerMock) RemoveTrustedPeer(contextMoqParam context.Context, removePeerRequest *RemovePeerRequest) (*RemovePeerReply, error) {
+	callInfo := struct {
+		ContextMoqParam   context.Context
+		RemovePeerRequest *RemovePeerRequest
+	}{
+		ContextMoqParam:   contextMoqParam,
+		RemovePeerRequest: removePeerRequest,
+	}
+	mock.lockRemoveTrustedPeer.Lock()
+	mock.calls.RemoveTrustedPeer = append(mock.calls.RemoveTrustedPeer, callInfo)
+	mock.lockRemoveTrustedPeer.Unlock()
+	if mock.RemoveTrustedPeerFunc == nil {
+		var (
+			removePeerReplyOut *RemovePeerReply
+			errOut             error
+		)
+		return removePeerReplyOut, errOut
+	}
+	return mock.RemoveTrustedPeerFunc(contextMoqParam, removePeerRequest)
+}
+
+// RemoveTrustedPeerCalls gets all the calls that were made to RemoveTrustedPeer.
+// Check the length with:
+//
+//	len(mockedSentryServer.RemoveTrustedPeerCalls())
+func (mock *SentryServerMock) RemoveTrustedPeerCalls() []struct {
+	ContextMoqParam   context.Context
+	RemovePeerRequest *RemovePeerRequest
+} {
+	var calls []struct {
+		ContextMoqParam   context.Context
+		RemovePeerRequest *RemovePeerRequest
+	}
+	mock.lockRemoveTrustedPeer.RLock()
+	calls = mock.calls.RemoveTrustedPeer
+	mock.lockRemoveTrustedPeer.RUnlock()
+	return calls
+}
+
 // SendMessageById calls SendMessageByIdFunc.
 func (mock *SentryServerMock) SendMessageById(contextMoqParam context.Context, sendMessageByIdRequest *SendMessageByIdRequest) (*SentPeers, error) {
 	callInfo := struct {
@@ -851,6 +1135,46 @@ func (mock *SentryServerMock) SetStatusCalls() []struct {
 	return calls
 }
 
+// UnbanPeer calls UnbanPeerFunc.
+func (mock *SentryServerMock) UnbanPeer(contextMoqParam context.Context, unbanPeerRequest *UnbanPeerRequest) (*UnbanPeerReply, error) {
+	callInfo := struct {
+		ContextMoqParam  context.Context
+		UnbanPeerRequest *UnbanPeerRequest
+	}{
+		ContextMoqParam:  contextMoqParam,
+		UnbanPeerRequest: unbanPeerRequest,
+	}
+	mock.lockUnbanPeer.Lock()
+	mock.calls.UnbanPeer = append(mock.calls.UnbanPeer, callInfo)
+	mock.lockUnbanPeer.Unlock()
+	if mock.UnbanPeerFunc == nil {
+		var (
+			unbanPeerReplyOut *UnbanPeerReply
+			errOut            error
+		)
+		return unbanPeerReplyOut, errOut
+	}
+	return mock.UnbanPeerFunc(contextMoqParam, unbanPeerRequest)
+}
+
+// UnbanPeerCalls gets all the calls that were made to UnbanPeer.
+// Check the length with:
+//
+//	len(mockedSentryServer.UnbanPeerCalls())
+func (mock *SentryServerMock) UnbanPeerCalls() []struct {
+	ContextMoqParam  context.Context
+	UnbanPeerRequest *UnbanPeerRequest
+} {
+	var calls []struct {
+		ContextMoqParam  context.Context
+		UnbanPeerRequest *UnbanPeerRequest
+	}
+	mock.lockUnbanPeer.RLock()
+	calls = mock.calls.UnbanPeer
+	mock.lockUnbanPeer.RUnlock()
+	return calls
+}
+
 // mustEmbedUnimplementedSentryServer calls mustEmbedUnimplementedSentryServerFunc.
 func (mock *SentryServerMock) mustEmbedUnimplementedSentryServer() {
 	callInfo := struct {
@@ -891,6 +1215,15 @@ var _ SentryClient = &SentryClientMock{}
 //			AddPeerFunc: func(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error) {
 //				panic("mock out the AddPeer method")
 //			},
+//			AddTrustedPeerFunc: func(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error) {
+//				panic("mock out the AddTrustedPeer method")
+//			},
+//			BanPeerFunc: func(ctx context.Context, in *BanPeerRequest, opts ...grpc.CallOption) (*BanPeerReply, error) {
+//				panic("mock out the BanPeer method")
+//			},
+//			BannedPeersFunc: func(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BannedPeersReply, error) {
+//				panic("mock out the BannedPeers method")
+//			},
 //			HandShakeFunc: func(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*HandShakeReply, error) {
 //				panic("mock out the HandShake method")
 //			},
@@ -918,6 +1251,12 @@ var _ SentryClient = &SentryClientMock{}
 //			PenalizePeerFunc: func(ctx context.Context, in *PenalizePeerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 //				panic("mock out the PenalizePeer method")
 //			},
+//			RemovePeerFunc: func(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error) {
+//				panic("mock out the RemovePeer method")
+//			},
+//			RemoveTrustedPeerFunc: func(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error) {
+//				panic("mock out the RemoveTrustedPeer method")
+//			},
 //			SendMessageByIdFunc: func(ctx context.Context, in *SendMessageByIdRequest, opts ...grpc.CallOption) (*SentPeers, error) {
 //				panic("mock out the SendMessageById method")
 //			},
@@ -933,6 +1272,9 @@ var _ SentryClient = &SentryClientMock{}
 //			SetStatusFunc: func(ctx context.Context, in *StatusData, opts ...grpc.CallOption) (*SetStatusReply, error) {
 //				panic("mock out the SetStatus method")
 //			},
+//			UnbanPeerFunc: func(ctx context.Context, in *UnbanPeerRequest, opts ...grpc.CallOption) (*UnbanPeerReply, error) {
+//				panic("mock out the UnbanPeer method")
+//			},
 //		}
 //
 //		// use mockedSentryClient in code that requires SentryClient
@@ -943,6 +1285,15 @@ type SentryClientMock struct {
 	// AddPeerFunc mocks the AddPeer method.
 	AddPeerFunc func(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error)
 
+	// AddTrustedPeerFunc mocks the AddTrustedPeer method.
+	AddTrustedPeerFunc func(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error)
+
+	// BanPeerFunc mocks the BanPeer method.
+	BanPeerFunc func(ctx context.Context, in *BanPeerRequest, opts ...grpc.CallOption) (*BanPeerReply, error)
+
+	// BannedPeersFunc mocks the BannedPeers method.
+	BannedPeersFunc func(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BannedPeersReply, error)
+
 	// HandShakeFunc mocks the HandShake method.
 	HandShakeFunc func(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*HandShakeReply, error)
 
@@ -970,6 +1321,12 @@ type SentryClientMock struct {
 	// PenalizePeerFunc mocks the PenalizePeer method.
 	PenalizePeerFunc func(ctx context.Context, in *PenalizePeerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 
+	// RemovePeerFunc mocks the RemovePeer method.
+	RemovePeerFunc func(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error)
+
+	// RemoveTrustedPeerFunc mocks the RemoveTrustedPeer method.
+	RemoveTrustedPeerFunc func(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error)
+
 	// SendMessageByIdFunc mocks the SendMessageById method.
 	SendMessageByIdFunc func(ctx context.Context, in *SendMessageByIdRequest, opts ...grpc.CallOption) (*SentPeers, error)
 
@@ -985,6 +1342,9 @@ type SentryClientMock struct {
 	// SetStatusFunc mocks the SetStatus method.
 	SetStatusFunc func(ctx context.Context, in *StatusData, opts ...grpc.CallOption) (*SetStatusReply, error)
 
+	// UnbanPeerFunc mocks the UnbanPeer method.
+	UnbanPeerFunc func(ctx context.Context, in *UnbanPeerRequest, opts ...grpc.CallOption) (*UnbanPeerReply, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// AddPeer holds details about calls to the AddPeer method.
@@ -996,6 +1356,33 @@ type SentryClientMock struct {
 			// Opts is the opts argument value.
 			Opts []grpc.CallOption
 		}
+		// AddTrustedPeer holds details about calls to the AddTrustedPeer method.
+		AddTrustedPeer []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *AddPeerRequest
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
+		// BanPeer holds details about calls to the BanPeer method.
+		BanPeer []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *BanPeerRequest
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
+		// BannedPeers holds details about calls to the BannedPeers method.
+		BannedPeers []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *emptypb.Empty
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
 		// HandShake holds details about calls to the HandShake method.
 		HandShake []struct {
 			// Ctx is the ctx argument value.
@@ -1077,6 +1464,24 @@ type SentryClientMock struct {
 			// Opts is the opts argument value.
 			Opts []grpc.CallOption
 		}
+		// RemovePeer holds details about calls to the RemovePeer method.
+		RemovePeer []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *RemovePeerRequest
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
+		// RemoveTrustedPeer holds details about calls to the RemoveTrustedPeer method.
+		RemoveTrustedPeer []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *RemovePeerRequest
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
 		// SendMessageById holds details about calls to the SendMessageById method.
 		SendMessageById []struct {
 			// Ctx is the ctx argument value.
@@ -1122,8 +1527,20 @@ type SentryClientMock struct {
 			// Opts is the opts argument value.
 			Opts []grpc.CallOption
 		}
+		// UnbanPeer holds details about calls to the UnbanPeer method.
+		UnbanPeer []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *UnbanPeerRequest
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
 	}
 	lockAddPeer                  sync.RWMutex
+	lockAddTrustedPeer           sync.RWMutex
+	lockBanPeer                  sync.RWMutex
+	lockBannedPeers              sync.RWMutex
 	lockHandShake                sync.RWMutex
 	lockMessages                 sync.RWMutex
 	lockNodeInfo                 sync.RWMutex
@@ -1133,11 +1550,14 @@ type SentryClientMock struct {
 	lockPeerMinBlock             sync.RWMutex
 	lockPeers                    sync.RWMutex
 	lockPenalizePeer             sync.RWMutex
+	lockRemovePeer               sync.RWMutex
+	lockRemoveTrustedPeer        sync.RWMutex
 	lockSendMessageById          sync.RWMutex
 	lockSendMessageByMinBlock    sync.RWMutex
 	lockSendMessageToAll         sync.RWMutex
 	lockSendMessageToRandomPeers sync.RWMutex
 	lockSetStatus                sync.RWMutex
+	lockUnbanPeer                sync.RWMutex
 }
 
 // AddPeer calls AddPeerFunc.
@@ -1184,6 +1604,138 @@ func (mock *SentryClientMock) AddPeerCalls() []struct {
 	return calls
 }
 
+// AddTrustedPeer calls AddTrustedPeerFunc.
+func (mock *SentryClientMock) AddTrustedPeer(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *AddPeerRequest
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockAddTrustedPeer.Lock()
+	mock.calls.AddTrustedPeer = append(mock.calls.AddTrustedPeer, callInfo)
+	mock.lockAddTrustedPeer.Unlock()
+	if mock.AddTrustedPeerFunc == nil {
+		var (
+			addPeerReplyOut *AddPeerReply
+			errOut          error
+		)
+		return addPeerReplyOut, errOut
+	}
+	return mock.AddTrustedPeerFunc(ctx, in, opts...)
+}
+
+// AddTrustedPeerCalls gets all the calls that were made to AddTrustedPeer.
+// Check the length with:
+//
+//	len(mockedSentryClient.AddTrustedPeerCalls())
+func (mock *SentryClientMock) AddTrustedPeerCalls() []struct {
+	Ctx  context.Context
+	In   *AddPeerRequest
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *AddPeerRequest
+		Opts []grpc.CallOption
+	}
+	mock.lockAddTrustedPeer.RLock()
+	calls = mock.calls.AddTrustedPeer
+	mock.lockAddTrustedPeer.RUnlock()
+	return calls
+}
+
+// BanPeer calls BanPeerFunc.
+func (mock *SentryClientMock) BanPeer(ctx context.Context, in *BanPeerRequest, opts ...grpc.CallOption) (*BanPeerReply, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *BanPeerRequest
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockBanPeer.Lock()
+	mock.calls.BanPeer = append(mock.calls.BanPeer, callInfo)
+	mock.lockBanPeer.Unlock()
+	if mock.BanPeerFunc == nil {
+		var (
+			banPeerReplyOut *BanPeerReply
+			errOut          error
+		)
+		return banPeerReplyOut, errOut
+	}
+	return mock.BanPeerFunc(ctx, in, opts...)
+}
+
+// BanPeerCalls gets all the calls that were made to BanPeer.
+// Check the length with:
+//
+//	len(mockedSentryClient.BanPeerCalls())
+func (mock *SentryClientMock) BanPeerCalls() []struct {
+	Ctx  context.Context
+	In   *BanPeerRequest
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *BanPeerRequest
+		Opts []grpc.CallOption
+	}
+	mock.lockBanPeer.RLock()
+	calls = mock.calls.BanPeer
+	mock.lockBanPeer.RUnlock()
+	return calls
+}
+
+// BannedPeers calls BannedPeersFunc.
+func (mock *SentryClientMock) BannedPeers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BannedPeersReply, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *emptypb.Empty
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockBannedPeers.Lock()
+	mock.calls.BannedPeers = append(mock.calls.BannedPeers, callInfo)
+	mock.lockBannedPeers.Unlock()
+	if mock.BannedPeersFunc == nil {
+		var (
+			bannedPeersReplyOut *BannedPeersReply
+			errOut              error
+		)
+		return bannedPeersReplyOut, errOut
+	}
+	return mock.BannedPeersFunc(ctx, in, opts...)
+}
+
+// BannedPeersCalls gets all the calls that were made to BannedPeers.
+// Check the length with:
+//
+//	len(mockedSentryClient.BannedPeersCalls())
+func (mock *SentryClientMock) BannedPeersCalls() []struct {
+	Ctx  context.Context
+	In   *emptypb.Empty
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *emptypb.Empty
+		Opts []grpc.CallOption
+	}
+	mock.lockBannedPeers.RLock()
+	calls = mock.calls.BannedPeers
+	mock.lockBannedPeers.RUnlock()
+	return calls
+}
+
 // HandShake calls HandShakeFunc.
 func (mock *SentryClientMock) HandShake(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*HandShakeReply, error) {
 	callInfo := struct {
@@ -1580,6 +2132,94 @@ func (mock *SentryClientMock) PenalizePeerCalls() []struct {
 	return calls
 }
 
+// RemovePeer calls RemovePeerFunc.
+func (mock *SentryClientMock) RemovePeer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *RemovePeerRequest
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockRemovePeer.Lock()
+	mock.calls.RemovePeer = append(mock.calls.RemovePeer, callInfo)
+	mock.lockRemovePeer.Unlock()
+	if mock.RemovePeerFunc == nil {
+		var (
+			removePeerReplyOut *RemovePeerReply
+			errOut             error
+		)
+		return removePeerReplyOut, errOut
+	}
+	return mock.RemovePeerFunc(ctx, in, opts...)
+}
+
+// RemovePeerCalls gets all the calls that were made to RemovePeer.
+// Check the length with:
+//
+//	len(mockedSentryClient.RemovePeerCalls())
+func (mock *SentryClientMock) RemovePeerCalls() []struct {
+	Ctx  context.Context
+	In   *RemovePeerRequest
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *RemovePeerRequest
+		Opts []grpc.CallOption
+	}
+	mock.lockRemovePeer.RLock()
+	calls = mock.calls.RemovePeer
+	mock.lockRemovePeer.RUnlock()
+	return calls
+}
+
+// RemoveTrustedPeer calls RemoveTrustedPeerFunc.
+func (mock *SentryClientMock) RemoveTrustedPeer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *RemovePeerRequest
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockRemoveTrustedPeer.Lock()
+	mock.calls.RemoveTrustedPeer = append(mock.calls.RemoveTrustedPeer, callInfo)
+	mock.lockRemoveTrustedPeer.Unlock()
+	if mock.RemoveTrustedPeerFunc == nil {
+		var (
+			removePeerReplyOut *RemovePeerReply
+			errOut             error
+		)
+		return removePeerReplyOut, errOut
+	}
+	return mock.RemoveTrustedPeerFunc(ctx, in, opts...)
+}
+
+// RemoveTrustedPeerCalls gets all the calls that were made to RemoveTrustedPeer.
+// Check the length with:
+//
+//	len(mockedSentryClient.RemoveTrustedPeerCalls())
+func (mock *SentryClientMock) RemoveTrustedPeerCalls() []struct {
+	Ctx  context.Context
+	In   *RemovePeerRequest
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *RemovePeerRequest
+		Opts []grpc.CallOption
+	}
+	mock.lockRemoveTrustedPeer.RLock()
+	calls = mock.calls.RemoveTrustedPeer
+	mock.lockRemoveTrustedPeer.RUnlock()
+	return calls
+}
+
 // SendMessageById calls SendMessageByIdFunc.
 func (mock *SentryClientMock) SendMessageById(ctx context.Context, in *SendMessageByIdRequest, opts ...grpc.CallOption) (*SentPeers, error) {
 	callInfo := struct {
@@ -1799,3 +2439,47 @@ func (mock *SentryClientMock) SetStatusCalls() []struct {
 	mock.lockSetStatus.RUnlock()
 	return calls
 }
+
+// UnbanPeer calls UnbanPeerFunc.
+func (mock *SentryClientMock) UnbanPeer(ctx context.Context, in *UnbanPeerRequest, opts ...grpc.CallOption) (*UnbanPeerReply, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *UnbanPeerRequest
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockUnbanPeer.Lock()
+	mock.calls.UnbanPeer = append(mock.calls.UnbanPeer, callInfo)
+	mock.lockUnbanPeer.Unlock()
+	if mock.UnbanPeerFunc == nil {
+		var (
+			unbanPeerReplyOut *UnbanPeerReply
+			errOut            error
+		)
+		return unbanPeerReplyOut, errOut
+	}
+	return mock.UnbanPeerFunc(ctx, in, opts...)
+}
+
+// UnbanPeerCalls gets all the calls that were made to UnbanPeer.
+// Check the length with:
+//
+//	len(mockedSentryClient.UnbanPeerCalls())
+func (mock *SentryClientMock) UnbanPeerCalls() []struct {
+	Ctx  context.Context
+	In   *UnbanPeerRequest
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *UnbanPeerRequest
+		Opts []grpc.CallOption
+	}
+	mock.lockUnbanPeer.RLock()
+	calls = mock.calls.UnbanPeer
+	mock.lockUnbanPeer.RUnlock()
+	return calls
+}
@@ -35,6 +35,12 @@ const (
 	Sentry_PeerById_FullMethodName                 = "/sentry.Sentry/PeerById"
 	Sentry_PeerEvents_FullMethodName               = "/sentry.Sentry/PeerEvents"
 	Sentry_AddPeer_FullMethodName                  = "/sentry.Sentry/AddPeer"
+	Sentry_RemovePeer_FullMethodName               = "/sentry.Sentry/RemovePeer"
+	Sentry_AddTrustedPeer_FullMethodName           = "/sentry.Sentry/AddTrustedPeer"
+	Sentry_RemoveTrustedPeer_FullMethodName        = "/sentry.Sentry/RemoveTrustedPeer"
+	Sentry_BanPeer_FullMethodName                  = "/sentry.Sentry/BanPeer"
+	Sentry_UnbanPeer_FullMethodName                = "/sentry.Sentry/UnbanPeer"
+	Sentry_BannedPeers_FullMethodName              = "/sentry.Sentry/BannedPeers"
 	Sentry_NodeInfo_FullMethodName                 = "/sentry.Sentry/NodeInfo"
 )
 
@@ -63,6 +69,16 @@ type SentryClient interface {
 	// Subscribe to notifications about connected or lost peers.
 	PeerEvents(ctx context.Context, in *PeerEventsRequest, opts ...grpc.CallOption) (Sentry_PeerEventsClient, error)
 	AddPeer(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error)
+	// RemovePeer removes the node from the static nodes and disconnects it.
+	RemovePeer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error)
+	// AddTrustedPeer allows the node to connect even when the peer slots are full.
+	AddTrustedPeer(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error)
+	RemoveTrustedPeer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error)
+	// BanPeer disconnects the node and refuses its connections until the ban expires.
+	BanPeer(ctx context.Context, in *BanPeerRequest, opts ...grpc.CallOption) (*BanPeerReply, error)
+	UnbanPeer(ctx context.Context, in *UnbanPeerRequest, opts ...grpc.CallOption) (*UnbanPeerReply, error)
+	// BannedPeers returns the nodes which are banned, along with the expiry of their bans.
+	BannedPeers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BannedPeersReply, error)
 	// NodeInfo returns a collection of metadata known about the host.
 	NodeInfo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*types.NodeInfoReply, error)
 }
@@ -247,6 +263,60 @@ func (c *sentryClient) AddPeer(ctx context.Context, in *AddPeerRequest, opts ...
 	return out, nil
 }
 
+func (c *sentryClient) RemovePeer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error) {
+	out := new(RemovePeerReply)
+	err := c.cc.Invoke(ctx, Sentry_RemovePeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sentryClient) AddTrustedPeer(ctx context.Context, in *AddPeerRequest, opts ...grpc.CallOption) (*AddPeerReply, error) {
+	out := new(AddPeerReply)
+	err := c.cc.Invoke(ctx, Sentry_AddTrustedPeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sentryClient) RemoveTrustedPeer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerReply, error) {
+	out := new(RemovePeerReply)
+	err := c.cc.Invoke(ctx, Sentry_RemoveTrustedPeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sentryClient) BanPeer(ctx context.Context, in *BanPeerRequest, opts ...grpc.CallOption) (*BanPeerReply, error) {
+	out := new(BanPeerReply)
+	err := c.cc.Invoke(ctx, Sentry_BanPeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sentryClient) UnbanPeer(ctx context.Context, in *UnbanPeerRequest, opts ...grpc.CallOption) (*UnbanPeerReply, error) {
+	out := new(UnbanPeerReply)
+	err := c.cc.Invoke(ctx, Sentry_UnbanPeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sentryClient) BannedPeers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BannedPeersReply, error) {
+	out := new(BannedPeersReply)
+	err := c.cc.Invoke(ctx, Sentry_BannedPeers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *sentryClient) NodeInfo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*types.NodeInfoReply, error) {
 	out := new(types.NodeInfoReply)
 	err := c.cc.Invoke(ctx, Sentry_NodeInfo_FullMethodName, in, out, opts...)
@@ -281,6 +351,16 @@ type SentryServer interface {
 	// Subscribe to notifications about connected or lost peers.
 	PeerEvents(*PeerEventsRequest, Sentry_PeerEventsServer) error
 	AddPeer(context.Context, *AddPeerRequest) (*AddPeerReply, error)
+	// RemovePeer removes the node from the static nodes and disconnects it.
+	RemovePeer(context.Context, *RemovePeerRequest) (*RemovePeerReply, error)
+	// AddTrustedPeer allows the node to connect even when the peer slots are full.
+	AddTrustedPeer(context.Context, *AddPeerRequest) (*AddPeerReply, error)
+	RemoveTrustedPeer(context.Context, *RemovePeerRequest) (*RemovePeerReply, error)
+	// BanPeer disconnects the node and refuses its connections until the ban expires.
+	BanPeer(context.Context, *BanPeerRequest) (*BanPeerReply, error)
+	UnbanPeer(context.Context, *UnbanPeerRequest) (*UnbanPeerReply, error)
+	// BannedPeers returns the nodes which are banned, along with the expiry of their bans.
+	BannedPeers(context.Context, *emptypb.Empty) (*BannedPeersReply, error)
 	// NodeInfo returns a collection of metadata known about the host.
 	NodeInfo(context.Context, *emptypb.Empty) (*types.NodeInfoReply, error)
 	mustEmbedUnimplementedSentryServer()
@@ -332,6 +412,24 @@ func (UnimplementedSentryServer) PeerEvents(*PeerEventsRequest, Sentry_PeerEvent
 func (UnimplementedSentryServer) AddPeer(context.Context, *AddPeerRequest) (*AddPeerReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddPeer not implemented")
 }
+func (UnimplementedSentryServer) RemovePeer(context.Context, *RemovePeerRequest) (*RemovePeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemovePeer not implemented")
+}
+func (UnimplementedSentryServer) AddTrustedPeer(context.Context, *AddPeerRequest) (*AddPeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddTrustedPeer not implemented")
+}
+func (UnimplementedSentryServer) RemoveTrustedPeer(context.Context, *RemovePeerRequest) (*RemovePeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveTrustedPeer not implemented")
+}
+func (UnimplementedSentryServer) BanPeer(context.Context, *BanPeerRequest) (*BanPeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BanPeer not implemented")
+}
+func (UnimplementedSentryServer) UnbanPeer(context.Context, *UnbanPeerRequest) (*UnbanPeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnbanPeer not implemented")
+}
+func (UnimplementedSentryServer) BannedPeers(context.Context, *emptypb.Empty) (*BannedPeersReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BannedPeers not implemented")
+}
 func (UnimplementedSentryServer) NodeInfo(context.Context, *emptypb.Empty) (*types.NodeInfoReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method NodeInfo not implemented")
 }
@@ -606,6 +704,114 @@ func _Sentry_AddPeer_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Sentry_RemovePeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemovePeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SentryServer).RemovePeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sentry_RemovePeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SentryServer).RemovePeer(ctx, req.(*RemovePeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sentry_AddTrustedPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SentryServer).AddTrustedPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sentry_AddTrustedPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SentryServer).AddTrustedPeer(ctx, req.(*AddPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sentry_RemoveTrustedPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemovePeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SentryServer).RemoveTrustedPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sentry_RemoveTrustedPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SentryServer).RemoveTrustedPeer(ctx, req.(*RemovePeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sentry_BanPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BanPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SentryServer).BanPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sentry_BanPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SentryServer).BanPeer(ctx, req.(*BanPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sentry_UnbanPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnbanPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SentryServer).UnbanPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sentry_UnbanPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SentryServer).UnbanPeer(ctx, req.(*UnbanPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sentry_BannedPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SentryServer).BannedPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sentry_BannedPeers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SentryServer).BannedPeers(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Sentry_NodeInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -679,6 +885,30 @@ var Sentry_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddPeer",
 			Handler:    _Sentry_AddPeer_Handler,
 		},
+		{
+			MethodName: "RemovePeer",
+			Handler:    _Sentry_RemovePeer_Handler,
+		},
+		{
+			MethodName: "AddTrustedPeer",
+			Handler:    _Sentry_AddTrustedPeer_Handler,
+		},
+		{
+			MethodName: "RemoveTrustedPeer",
+			Handler:    _Sentry_RemoveTrustedPeer_Handler,
+		},
+		{
+			MethodName: "BanPeer",
+			Handler:    _Sentry_BanPeer_Handler,
+		},
+		{
+			MethodName: "UnbanPeer",
+			Handler:    _Sentry_UnbanPeer_Handler,
+		},
+		{
+			MethodName: "BannedPeers",
+			Handler:    _Sentry_BannedPeers_Handler,
+		},
 		{
 			MethodName: "NodeInfo",
 			Handler:    _Sentry_NodeInfo_Handler,
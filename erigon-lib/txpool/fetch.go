@@ -17,6 +17,7 @@
 package txpool
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -55,6 +56,7 @@ type Fetch struct {
 	sentryClients            []direct.SentryClient // sentry clients that will be used for accessing the network
 	stateChangesParseCtxLock sync.Mutex
 	pooledTxsParseCtxLock    sync.Mutex
+	fetcher                  *txFetcher // tracks the announced transactions until they are delivered
 	logger                   log.Logger
 }
 
@@ -76,6 +78,7 @@ func NewFetch(ctx context.Context, sentryClients []direct.SentryClient, pool Poo
 		stateChangesClient:   stateChangesClient,
 		stateChangesParseCtx: types2.NewTxParseContext(chainID).ChainIDRequired(), //TODO: change ctx if rules changed
 		pooledTxsParseCtx:    types2.NewTxParseContext(chainID).ChainIDRequired(),
+		fetcher:              newTxFetcher(),
 		logger:               logger,
 	}
 	f.pooledTxsParseCtx.ValidateRLP(f.pool.ValidateSerializedTxn)
@@ -110,7 +113,56 @@ func (f *Fetch) ConnectSentries() {
 			f.receivePeerLoop(f.sentryClients[i])
 		}(i)
 	}
+	go f.fetchLoop()
 }
+
+// fetchLoop re-requests the transactions which were not delivered in time from the other
+// peers announcing them
+func (f *Fetch) fetchLoop() {
+	ticker := time.NewTicker(txFetchTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case now := <-ticker.C:
+			f.fetcher.expire(now)
+			f.requestAnnounced(now)
+		}
+	}
+}
+
+// requestAnnounced sends the GetPooledTransactions requests for the announced transactions
+// to the peers which have none in flight
+func (f *Fetch) requestAnnounced(now time.Time) {
+	for _, r := range f.fetcher.schedule(now) {
+		f.sendRequest(r)
+	}
+}
+
+// requestAnnouncedFrom sends the GetPooledTransactions request for the announced transactions
+// to the peer if it has none in flight, the other peers wait for the next tick
+func (f *Fetch) requestAnnouncedFrom(peerID types2.PeerID, now time.Time) {
+	if r := f.fetcher.schedulePeer(peerID, now); r != nil {
+		f.sendRequest(r)
+	}
+}
+
+func (f *Fetch) sendRequest(r *txRequest) {
+	encodedRequest, err := types2.EncodeGetPooledTransactions66(r.hashList(), r.id, nil)
+	if err != nil {
+		f.logger.Warn("[txpool.fetch] encoding GetPooledTransactions", "err", err)
+		return
+	}
+	// A failed request times out and is retried from the other announcers
+	if _, err = r.client.SendMessageById(f.ctx, &sentry.SendMessageByIdRequest{
+		Data:   &sentry.OutboundMessageData{Id: sentry.MessageId_GET_POOLED_TRANSACTIONS_66, Data: encodedRequest},
+		PeerId: r.peer.peerID,
+	}, &grpc.EmptyCallOption{}); err != nil {
+		f.logger.Debug("[txpool.fetch] sending GetPooledTransactions", "err", err)
+	}
+}
+
 func (f *Fetch) ConnectCore() {
 	go func() {
 		for {
@@ -235,21 +287,11 @@ func (f *Fetch) handleInboundMessage(ctx context.Context, req *sentry.InboundMes
 			return err
 		}
 		if len(unknownHashes) > 0 {
-			var encodedRequest []byte
-			var messageID sentry.MessageId
-			if encodedRequest, err = types2.EncodeGetPooledTransactions66(unknownHashes, uint64(1), nil); err != nil {
-				return err
-			}
-			messageID = sentry.MessageId_GET_POOLED_TRANSACTIONS_66
-			if _, err = sentryClient.SendMessageById(f.ctx, &sentry.SendMessageByIdRequest{
-				Data:   &sentry.OutboundMessageData{Id: messageID, Data: encodedRequest},
-				PeerId: req.PeerId,
-			}, &grpc.EmptyCallOption{}); err != nil {
-				return err
-			}
+			f.fetcher.announce(req.PeerId, sentryClient, nil, nil, unknownHashes)
+			f.requestAnnouncedFrom(req.PeerId, time.Now())
 		}
 	case sentry.MessageId_NEW_POOLED_TRANSACTION_HASHES_68:
-		_, _, hashes, _, err := rlp.ParseAnnouncements(req.Data, 0)
+		txTypes, sizes, hashes, _, err := rlp.ParseAnnouncements(req.Data, 0)
 		if err != nil {
 			return fmt.Errorf("parsing NewPooledTransactionHashes88: %w", err)
 		}
@@ -257,20 +299,10 @@ func (f *Fetch) handleInboundMessage(ctx context.Context, req *sentry.InboundMes
 		if err != nil {
 			return err
 		}
-
 		if len(unknownHashes) > 0 {
-			var encodedRequest []byte
-			var messageID sentry.MessageId
-			if encodedRequest, err = types2.EncodeGetPooledTransactions66(unknownHashes, uint64(1), nil); err != nil {
-				return err
-			}
-			messageID = sentry.MessageId_GET_POOLED_TRANSACTIONS_66
-			if _, err = sentryClient.SendMessageById(f.ctx, &sentry.SendMessageByIdRequest{
-				Data:   &sentry.OutboundMessageData{Id: messageID, Data: encodedRequest},
-				PeerId: req.PeerId,
-			}, &grpc.EmptyCallOption{}); err != nil {
-				return err
-			}
+			txTypes, sizes = unknownAnnouncements(txTypes, sizes, hashes, unknownHashes)
+			f.fetcher.announce(req.PeerId, sentryClient, txTypes, sizes, unknownHashes)
+			f.requestAnnouncedFrom(req.PeerId, time.Now())
 		}
 	case sentry.MessageId_GET_POOLED_TRANSACTIONS_66:
 		//TODO: handleInboundMessage is single-threaded - means it can accept as argument couple buffers (or analog of txParseContext). Protobuf encoding will copy data anyway, but DirectClient doesn't
@@ -282,6 +314,10 @@ func (f *Fetch) handleInboundMessage(ctx context.Context, req *sentry.InboundMes
 			return err
 		}
 
+		if !f.fetcher.allowServe(req.PeerId, time.Now()) {
+			return nil
+		}
+
 		// limit to max 256 transactions in a reply
 		const hashSize = 32
 		hashes = hashes[:cmp.Min(len(hashes), 256*hashSize)]
@@ -321,51 +357,44 @@ func (f *Fetch) handleInboundMessage(ctx context.Context, req *sentry.InboundMes
 		}, &grpc.EmptyCallOption{}); err != nil {
 			return err
 		}
+		f.fetcher.served(req.PeerId, len(encodedRequest), time.Now())
 	case sentry.MessageId_POOLED_TRANSACTIONS_66, sentry.MessageId_TRANSACTIONS_66:
 		txs := types2.TxSlots{}
-		if err := f.threadSafeParsePooledTxn(func(parseContext *types2.TxParseContext) error {
+		// the known transactions are rejected by the parsing, but still count as delivered
+		var known [][32]byte
+		rejectKnown := func(hash []byte) error {
+			isKnown, err := f.pool.IdHashKnown(tx, hash)
+			if err != nil {
+				return err
+			}
+			if isKnown {
+				known = append(known, [32]byte(hash))
+				return types2.ErrRejected
+			}
 			return nil
-		}); err != nil {
-			return err
 		}
 
 		switch req.Id {
 		case sentry.MessageId_TRANSACTIONS_66:
 			if err := f.threadSafeParsePooledTxn(func(parseContext *types2.TxParseContext) error {
-				if _, err := types2.ParseTransactions(req.Data, 0, parseContext, &txs, func(hash []byte) error {
-					known, err := f.pool.IdHashKnown(tx, hash)
-					if err != nil {
-						return err
-					}
-					if known {
-						return types2.ErrRejected
-					}
-					return nil
-				}); err != nil {
-					return err
-				}
-				return nil
+				_, err := types2.ParseTransactions(req.Data, 0, parseContext, &txs, rejectKnown)
+				return err
 			}); err != nil {
 				return err
 			}
+			txs = f.fetcher.receive(txs, known)
 		case sentry.MessageId_POOLED_TRANSACTIONS_66:
-			if err := f.threadSafeParsePooledTxn(func(parseContext *types2.TxParseContext) error {
-				if _, _, err := types2.ParsePooledTransactions66(req.Data, 0, parseContext, &txs, func(hash []byte) error {
-					known, err := f.pool.IdHashKnown(tx, hash)
-					if err != nil {
-						return err
-					}
-					if known {
-						return types2.ErrRejected
-					}
-					return nil
-				}); err != nil {
-					return err
-				}
-				return nil
+			var requestID uint64
+			if err := f.threadSafeParsePooledTxn(func(parseContext *types2.TxParseContext) (err error) {
+				requestID, _, err = types2.ParsePooledTransactions66(req.Data, 0, parseContext, &txs, rejectKnown)
+				return err
 			}); err != nil {
 				return err
 			}
+			txs = f.fetcher.deliver(req.PeerId, requestID, txs, known)
+			// The peer gets its next request, the transactions it failed to deliver are
+			// requested from the other announcers on the next tick
+			f.requestAnnouncedFrom(req.PeerId, time.Now())
 		default:
 			return fmt.Errorf("unexpected message: %s", req.Id.String())
 		}
@@ -446,6 +475,8 @@ func (f *Fetch) handleNewPeer(req *sentry.PeerEvent) error {
 	switch req.EventId {
 	case sentry.PeerEvent_Connect:
 		f.pool.AddNewGoodPeer(req.PeerId)
+	case sentry.PeerEvent_Disconnect:
+		f.fetcher.drop(req.PeerId)
 	}
 
 	return nil
@@ -531,3 +562,18 @@ func (f *Fetch) handleStateChangesRequest(ctx context.Context, req *remote.State
 	}
 	return nil
 }
+
+// unknownAnnouncements returns the types and sizes of the announced transactions which are
+// unknown to the pool, in the order of their hashes
+func unknownAnnouncements(txTypes []byte, sizes []uint32, hashes, unknownHashes types2.Hashes) ([]byte, []uint32) {
+	unknownTypes := make([]byte, 0, unknownHashes.Len())
+	unknownSizes := make([]uint32, 0, unknownHashes.Len())
+	for i, j := 0, 0; i < hashes.Len() && j < unknownHashes.Len(); i++ {
+		if bytes.Equal(hashes.At(i), unknownHashes.At(j)) {
+			unknownTypes = append(unknownTypes, txTypes[i])
+			unknownSizes = append(unknownSizes, sizes[i])
+			j++
+		}
+	}
+	return unknownTypes, unknownSizes
+}
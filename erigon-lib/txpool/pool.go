@@ -221,12 +221,14 @@ type TxPool struct {
 	isPostAgra              atomic.Bool
 	cancunTime              *uint64
 	isPostCancun            atomic.Bool
+	pragueTime              *uint64
+	isPostPrague            atomic.Bool
 	maxBlobsPerBlock        uint64
 	logger                  log.Logger
 }
 
 func New(newTxs chan types.Announcements, coreDB kv.RoDB, cfg txpoolcfg.Config, cache kvcache.Cache,
-	chainID uint256.Int, shanghaiTime, agraBlock, cancunTime *big.Int, maxBlobsPerBlock uint64, logger log.Logger,
+	chainID uint256.Int, shanghaiTime, agraBlock, cancunTime, pragueTime *big.Int, maxBlobsPerBlock uint64, logger log.Logger,
 ) (*TxPool, error) {
 	localsHistory, err := simplelru.NewLRU[string, struct{}](10_000, nil)
 	if err != nil {
@@ -293,6 +295,13 @@ func New(newTxs chan types.Announcements, coreDB kv.RoDB, cfg txpoolcfg.Config,
 		cancunTimeU64 := cancunTime.Uint64()
 		res.cancunTime = &cancunTimeU64
 	}
+	if pragueTime != nil {
+		if !pragueTime.IsUint64() {
+			return nil, errors.New("pragueTime overflow")
+		}
+		pragueTimeU64 := pragueTime.Uint64()
+		res.pragueTime = &pragueTimeU64
+	}
 
 	return res, nil
 }
@@ -408,6 +417,12 @@ func (p *TxPool) OnNewBlock(ctx context.Context, stateChanges *remote.StateChang
 	return nil
 }
 
+// ProcessRemoteTxs adds the transactions received from the peers to the pool, MainLoop
+// does it periodically.
+func (p *TxPool) ProcessRemoteTxs(ctx context.Context) error {
+	return p.processRemoteTxs(ctx)
+}
+
 func (p *TxPool) processRemoteTxs(ctx context.Context) error {
 	if !p.started.Load() {
 		return fmt.Errorf("txpool not started yet")
@@ -658,7 +673,7 @@ func (p *TxPool) best(n uint16, txs *types.TxsRlp, tx kv.Tx, onTopOf, availableG
 		// make sure we have enough gas in the caller to add this transaction.
 		// not an exact science using intrinsic gas but as close as we could hope for at
 		// this stage
-		intrinsicGas, _ := txpoolcfg.CalcIntrinsicGas(uint64(mt.Tx.DataLen), uint64(mt.Tx.DataNonZeroLen), nil, mt.Tx.Creation, true, true, isShanghai)
+		intrinsicGas, _ := txpoolcfg.CalcIntrinsicGas(uint64(mt.Tx.DataLen), uint64(mt.Tx.DataNonZeroLen), uint64(mt.Tx.AuthorizationsLen), nil, mt.Tx.Creation, true, true, isShanghai)
 		if intrinsicGas > availableGas {
 			// we might find another TX with a low enough intrinsic gas to include so carry on
 			continue
@@ -783,6 +798,17 @@ func (p *TxPool) validateTx(txn *types.TxSlot, isLocal bool, stateCache kvcache.
 			return txpoolcfg.UnmatchedBlobTxExt
 		}
 	}
+	if txn.Type == types.SetCodeTxType {
+		if !p.isPrague() {
+			return txpoolcfg.TypeNotActivated
+		}
+		if txn.Creation {
+			return txpoolcfg.CreateSetCodeTxn
+		}
+		if txn.AuthorizationsLen == 0 {
+			return txpoolcfg.NoAuthorizations
+		}
+	}
 
 	// Drop non-local transactions under our own minimal accepted gas price or tip
 	if !isLocal && uint256.NewInt(p.cfg.MinFeeCap).Cmp(&txn.FeeCap) == 1 {
@@ -791,7 +817,7 @@ func (p *TxPool) validateTx(txn *types.TxSlot, isLocal bool, stateCache kvcache.
 		}
 		return txpoolcfg.UnderPriced
 	}
-	gas, reason := txpoolcfg.CalcIntrinsicGas(uint64(txn.DataLen), uint64(txn.DataNonZeroLen), nil, txn.Creation, true, true, isShanghai)
+	gas, reason := txpoolcfg.CalcIntrinsicGas(uint64(txn.DataLen), uint64(txn.DataNonZeroLen), uint64(txn.AuthorizationsLen), nil, txn.Creation, true, true, isShanghai)
 	if txn.Traced {
 		p.logger.Info(fmt.Sprintf("TX TRACING: validateTx intrinsic gas idHash=%x gas=%d", txn.IDHash, gas))
 	}
@@ -958,6 +984,31 @@ func (p *TxPool) isCancun() bool {
 	return activated
 }
 
+func (p *TxPool) isPrague() bool {
+	// once this flag has been set for the first time we no longer need to check the timestamp
+	set := p.isPostPrague.Load()
+	if set {
+		return true
+	}
+	if p.pragueTime == nil {
+		return false
+	}
+	pragueTime := *p.pragueTime
+
+	// a zero here means Prague is always active
+	if pragueTime == 0 {
+		p.isPostPrague.Swap(true)
+		return true
+	}
+
+	now := time.Now().Unix()
+	activated := uint64(now) >= pragueTime
+	if activated {
+		p.isPostPrague.Swap(true)
+	}
+	return activated
+}
+
 // Check that that the serialized txn should not exceed a certain max size
 func (p *TxPool) ValidateSerializedTxn(serializedTxn []byte) error {
 	const (
@@ -1698,94 +1749,7 @@ func MainLoop(ctx context.Context, db kv.RwDB, coreDB kv.RoDB, p *TxPool, newTxs
 					}
 					break
 				}
-				if announcements.Len() == 0 {
-					return
-				}
-				defer propagateNewTxsTimer.ObserveDuration(time.Now())
-
-				announcements = announcements.DedupCopy()
-
-				notifyMiningAboutNewSlots()
-
-				if p.cfg.NoGossip {
-					// drain newTxs for emptying newTx channel
-					// newTx channel will be filled only with local transactions
-					// early return to avoid outbound transaction propagation
-					log.Debug("[txpool] tx gossip disabled", "state", "drain new transactions")
-					return
-				}
-
-				var localTxTypes []byte
-				var localTxSizes []uint32
-				var localTxHashes types.Hashes
-				var localTxRlps [][]byte
-				var remoteTxTypes []byte
-				var remoteTxSizes []uint32
-				var remoteTxHashes types.Hashes
-				var remoteTxRlps [][]byte
-				var broadCastedHashes types.Hashes
-				slotsRlp := make([][]byte, 0, announcements.Len())
-
-				if err := db.View(ctx, func(tx kv.Tx) error {
-					for i := 0; i < announcements.Len(); i++ {
-						t, size, hash := announcements.At(i)
-						slotRlp, err := p.GetRlp(tx, hash)
-						if err != nil {
-							return err
-						}
-						if len(slotRlp) == 0 {
-							continue
-						}
-
-						// Empty rlp can happen if a transaction we want to broadcast has just been mined, for example
-						slotsRlp = append(slotsRlp, slotRlp)
-						if p.IsLocal(hash) {
-							localTxTypes = append(localTxTypes, t)
-							localTxSizes = append(localTxSizes, size)
-							localTxHashes = append(localTxHashes, hash...)
-
-							// "Nodes MUST NOT automatically broadcast blob transactions to their peers" - EIP-4844
-							if t != types.BlobTxType {
-								localTxRlps = append(localTxRlps, slotRlp)
-								broadCastedHashes = append(broadCastedHashes, hash...)
-							}
-						} else {
-							remoteTxTypes = append(remoteTxTypes, t)
-							remoteTxSizes = append(remoteTxSizes, size)
-							remoteTxHashes = append(remoteTxHashes, hash...)
-
-							// "Nodes MUST NOT automatically broadcast blob transactions to their peers" - EIP-4844
-							if t != types.BlobTxType && len(slotRlp) < txMaxBroadcastSize {
-								remoteTxRlps = append(remoteTxRlps, slotRlp)
-							}
-						}
-					}
-					return nil
-				}); err != nil {
-					p.logger.Error("[txpool] collect info to propagate", "err", err)
-					return
-				}
-				if newSlotsStreams != nil {
-					// TODO(eip-4844) What is this for? Is it OK to broadcast blob transactions?
-					newSlotsStreams.Broadcast(&proto_txpool.OnAddReply{RplTxs: slotsRlp}, p.logger)
-				}
-
-				// broadcast local transactions
-				const localTxsBroadcastMaxPeers uint64 = 10
-				txSentTo := send.BroadcastPooledTxs(localTxRlps, localTxsBroadcastMaxPeers)
-				for i, peer := range txSentTo {
-					p.logger.Info("Local tx broadcasted", "txHash", hex.EncodeToString(broadCastedHashes.At(i)), "to peer", peer)
-				}
-				hashSentTo := send.AnnouncePooledTxs(localTxTypes, localTxSizes, localTxHashes, localTxsBroadcastMaxPeers*2)
-				for i := 0; i < localTxHashes.Len(); i++ {
-					hash := localTxHashes.At(i)
-					p.logger.Info("Local tx announced", "txHash", hex.EncodeToString(hash), "to peer", hashSentTo[i], "baseFee", p.pendingBaseFee.Load())
-				}
-
-				// broadcast remote transactions
-				const remoteTxsBroadcastMaxPeers uint64 = 3
-				send.BroadcastPooledTxs(remoteTxRlps, remoteTxsBroadcastMaxPeers)
-				send.AnnouncePooledTxs(remoteTxTypes, remoteTxSizes, remoteTxHashes, remoteTxsBroadcastMaxPeers*2)
+				PropagateNewTxs(ctx, db, p, announcements, send, newSlotsStreams, notifyMiningAboutNewSlots)
 			}()
 		case <-syncToNewPeersEvery.C: // new peer
 			newPeers := p.recentlyConnectedPeers.GetAndClean()
@@ -1808,6 +1772,99 @@ func MainLoop(ctx context.Context, db kv.RwDB, coreDB kv.RoDB, p *TxPool, newTxs
 	}
 }
 
+// PropagateNewTxs sends the new transactions of the pool to its peers, the local ones
+// to more peers than the remote ones. MainLoop runs it for every batch of announcements.
+func PropagateNewTxs(ctx context.Context, db kv.RwDB, p *TxPool, announcements types.Announcements, send *Send, newSlotsStreams *NewSlotsStreams, notifyMiningAboutNewSlots func()) {
+	if announcements.Len() == 0 {
+		return
+	}
+	defer propagateNewTxsTimer.ObserveDuration(time.Now())
+
+	announcements = announcements.DedupCopy()
+
+	notifyMiningAboutNewSlots()
+
+	if p.cfg.NoGossip {
+		// drain newTxs for emptying newTx channel
+		// newTx channel will be filled only with local transactions
+		// early return to avoid outbound transaction propagation
+		log.Debug("[txpool] tx gossip disabled", "state", "drain new transactions")
+		return
+	}
+
+	var localTxTypes []byte
+	var localTxSizes []uint32
+	var localTxHashes types.Hashes
+	var localTxRlps [][]byte
+	var remoteTxTypes []byte
+	var remoteTxSizes []uint32
+	var remoteTxHashes types.Hashes
+	var remoteTxRlps [][]byte
+	var broadCastedHashes types.Hashes
+	slotsRlp := make([][]byte, 0, announcements.Len())
+
+	if err := db.View(ctx, func(tx kv.Tx) error {
+		for i := 0; i < announcements.Len(); i++ {
+			t, size, hash := announcements.At(i)
+			slotRlp, err := p.GetRlp(tx, hash)
+			if err != nil {
+				return err
+			}
+			if len(slotRlp) == 0 {
+				continue
+			}
+
+			// Empty rlp can happen if a transaction we want to broadcast has just been mined, for example
+			slotsRlp = append(slotsRlp, slotRlp)
+			if p.IsLocal(hash) {
+				localTxTypes = append(localTxTypes, t)
+				localTxSizes = append(localTxSizes, size)
+				localTxHashes = append(localTxHashes, hash...)
+
+				// "Nodes MUST NOT automatically broadcast blob transactions to their peers" - EIP-4844
+				if t != types.BlobTxType {
+					localTxRlps = append(localTxRlps, slotRlp)
+					broadCastedHashes = append(broadCastedHashes, hash...)
+				}
+			} else {
+				remoteTxTypes = append(remoteTxTypes, t)
+				remoteTxSizes = append(remoteTxSizes, size)
+				remoteTxHashes = append(remoteTxHashes, hash...)
+
+				// "Nodes MUST NOT automatically broadcast blob transactions to their peers" - EIP-4844
+				if t != types.BlobTxType && len(slotRlp) < txMaxBroadcastSize {
+					remoteTxRlps = append(remoteTxRlps, slotRlp)
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		p.logger.Error("[txpool] collect info to propagate", "err", err)
+		return
+	}
+	if newSlotsStreams != nil {
+		// TODO(eip-4844) What is this for? Is it OK to broadcast blob transactions?
+		newSlotsStreams.Broadcast(&proto_txpool.OnAddReply{RplTxs: slotsRlp}, p.logger)
+	}
+
+	// broadcast local transactions
+	const localTxsBroadcastMaxPeers uint64 = 10
+	txSentTo := send.BroadcastPooledTxs(localTxRlps, localTxsBroadcastMaxPeers)
+	for i, peer := range txSentTo {
+		p.logger.Info("Local tx broadcasted", "txHash", hex.EncodeToString(broadCastedHashes.At(i)), "to peer", peer)
+	}
+	hashSentTo := send.AnnouncePooledTxs(localTxTypes, localTxSizes, localTxHashes, localTxsBroadcastMaxPeers*2)
+	for i := 0; i < localTxHashes.Len(); i++ {
+		hash := localTxHashes.At(i)
+		p.logger.Info("Local tx announced", "txHash", hex.EncodeToString(hash), "to peer", hashSentTo[i], "baseFee", p.pendingBaseFee.Load())
+	}
+
+	// broadcast remote transactions
+	const remoteTxsBroadcastMaxPeers uint64 = 3
+	send.BroadcastPooledTxs(remoteTxRlps, remoteTxsBroadcastMaxPeers)
+	send.AnnouncePooledTxs(remoteTxTypes, remoteTxSizes, remoteTxHashes, remoteTxsBroadcastMaxPeers*2)
+}
+
 func (p *TxPool) flushNoFsync(ctx context.Context, db kv.RwDB) (written uint64, err error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
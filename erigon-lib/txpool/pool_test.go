@@ -52,7 +52,7 @@ func TestNonceFromAddress(t *testing.T) {
 
 	cfg := txpoolcfg.DefaultConfig
 	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, log.New())
+	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, log.New())
 	assert.NoError(err)
 	require.True(pool != nil)
 	ctx := context.Background()
@@ -172,7 +172,7 @@ func TestReplaceWithHigherFee(t *testing.T) {
 
 	cfg := txpoolcfg.DefaultConfig
 	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, log.New())
+	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, log.New())
 	assert.NoError(err)
 	require.NotEqual(nil, pool)
 	ctx := context.Background()
@@ -289,7 +289,7 @@ func TestReverseNonces(t *testing.T) {
 
 	cfg := txpoolcfg.DefaultConfig
 	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, log.New())
+	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, log.New())
 	assert.NoError(err)
 	require.True(pool != nil)
 	ctx := context.Background()
@@ -416,7 +416,7 @@ func TestTxPoke(t *testing.T) {
 
 	cfg := txpoolcfg.DefaultConfig
 	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, log.New())
+	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, log.New())
 	assert.NoError(err)
 	require.True(pool != nil)
 	ctx := context.Background()
@@ -627,7 +627,7 @@ func TestShanghaiIntrinsicGas(t *testing.T) {
 
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
-			gas, reason := txpoolcfg.CalcIntrinsicGas(c.dataLen, c.dataNonZeroLen, nil, c.creation, true, true, c.isShanghai)
+			gas, reason := txpoolcfg.CalcIntrinsicGas(c.dataLen, c.dataNonZeroLen, 0, nil, c.creation, true, true, c.isShanghai)
 			if reason != txpoolcfg.Success {
 				t.Errorf("expected success but got reason %v", reason)
 			}
@@ -681,7 +681,7 @@ func TestShanghaiValidateTx(t *testing.T) {
 			}
 
 			cache := &kvcache.DummyCache{}
-			pool, err := New(ch, coreDB, cfg, cache, *u256.N1, shanghaiTime, nil /* agraBlock */, nil /* cancunTime */, fixedgas.DefaultMaxBlobsPerBlock, logger)
+			pool, err := New(ch, coreDB, cfg, cache, *u256.N1, shanghaiTime, nil /* agraBlock */, nil /* cancunTime */, nil /* pragueTime */, fixedgas.DefaultMaxBlobsPerBlock, logger)
 			asrt.NoError(err)
 			ctx := context.Background()
 			tx, err := coreDB.BeginRw(ctx)
@@ -720,6 +720,91 @@ func TestShanghaiValidateTx(t *testing.T) {
 	}
 }
 
+func TestSetCodeTxValidateTx(t *testing.T) {
+	asrt := assert.New(t)
+	tests := map[string]struct {
+		expected          txpoolcfg.DiscardReason
+		creation          bool
+		authorizationsLen int
+		isPrague          bool
+	}{
+		"no prague": {
+			expected:          txpoolcfg.TypeNotActivated,
+			authorizationsLen: 1,
+			isPrague:          false,
+		},
+		"prague": {
+			expected:          txpoolcfg.Success,
+			authorizationsLen: 1,
+			isPrague:          true,
+		},
+		"create": {
+			expected:          txpoolcfg.CreateSetCodeTxn,
+			creation:          true,
+			authorizationsLen: 1,
+			isPrague:          true,
+		},
+		"empty authorization list": {
+			expected:          txpoolcfg.NoAuthorizations,
+			authorizationsLen: 0,
+			isPrague:          true,
+		},
+	}
+
+	logger := log.New()
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ch := make(chan types.Announcements, 100)
+			_, coreDB := memdb.NewTestPoolDB(t), memdb.NewTestDB(t)
+			cfg := txpoolcfg.DefaultConfig
+
+			var pragueTime *big.Int
+			if test.isPrague {
+				pragueTime = big.NewInt(0)
+			}
+
+			cache := &kvcache.DummyCache{}
+			pool, err := New(ch, coreDB, cfg, cache, *u256.N1, big.NewInt(0) /* shanghaiTime */, nil /* agraBlock */, big.NewInt(0) /* cancunTime */, pragueTime, fixedgas.DefaultMaxBlobsPerBlock, logger)
+			asrt.NoError(err)
+			ctx := context.Background()
+			tx, err := coreDB.BeginRw(ctx)
+			defer tx.Rollback()
+			asrt.NoError(err)
+
+			sndr := sender{nonce: 0, balance: *uint256.NewInt(math.MaxUint64)}
+			sndrBytes := make([]byte, types.EncodeSenderLengthForStorage(sndr.nonce, sndr.balance))
+			types.EncodeSender(sndr.nonce, sndr.balance, sndrBytes)
+			err = tx.Put(kv.PlainState, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, sndrBytes)
+			asrt.NoError(err)
+
+			txn := &types.TxSlot{
+				Type:              types.SetCodeTxType,
+				FeeCap:            *uint256.NewInt(21000),
+				Gas:               500000,
+				SenderID:          0,
+				Creation:          test.creation,
+				AuthorizationsLen: test.authorizationsLen,
+			}
+
+			txns := types.TxSlots{
+				Txs:     append([]*types.TxSlot{}, txn),
+				Senders: types.Addresses{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			}
+			err = pool.senders.registerNewSenders(&txns, logger)
+			asrt.NoError(err)
+			view, err := cache.View(ctx, tx)
+			asrt.NoError(err)
+
+			reason := pool.validateTx(txn, false, view)
+
+			if reason != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, reason)
+			}
+		})
+	}
+}
+
 // Blob gas price bump + other requirements to replace existing txns in the pool
 func TestBlobTxReplacement(t *testing.T) {
 	assert, require := assert.New(t), require.New(t)
@@ -727,7 +812,7 @@ func TestBlobTxReplacement(t *testing.T) {
 	db, coreDB := memdb.NewTestPoolDB(t), memdb.NewTestDB(t)
 	cfg := txpoolcfg.DefaultConfig
 	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, common.Big0, nil, common.Big0, fixedgas.DefaultMaxBlobsPerBlock, log.New())
+	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, common.Big0, nil, common.Big0, nil, fixedgas.DefaultMaxBlobsPerBlock, log.New())
 	assert.NoError(err)
 	require.True(pool != nil)
 	ctx := context.Background()
@@ -952,7 +1037,7 @@ func TestDropRemoteAtNoGossip(t *testing.T) {
 	logger := log.New()
 	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
 
-	txPool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, big.NewInt(0), big.NewInt(0), nil, fixedgas.DefaultMaxBlobsPerBlock, logger)
+	txPool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, big.NewInt(0), big.NewInt(0), nil, nil, fixedgas.DefaultMaxBlobsPerBlock, logger)
 	assert.NoError(err)
 	require.True(txPool != nil)
 
@@ -0,0 +1,431 @@
+/*
+   Copyright 2024 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package txpool
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	"github.com/ledgerwatch/erigon-lib/metrics"
+	"github.com/ledgerwatch/erigon-lib/types"
+)
+
+const (
+	// maxTxAnnounces is the maximum number of the announced unknown transactions tracked
+	// per peer, the further announcements of the peer are ignored until it delivers
+	maxTxAnnounces = 4096
+	// maxTxAnnouncesTotal bounds the number of the announced transactions tracked at once
+	maxTxAnnouncesTotal = 64 * 1024
+	// maxTxRequestHashes is the maximum number of hashes in a GetPooledTransactions request
+	maxTxRequestHashes = 256
+	// maxTxRequestSize is the target size of the transactions requested at once, going by
+	// the sizes of the eth/68 announcements. A request can get larger than this if a single
+	// transaction exceeds it.
+	maxTxRequestSize = 128 * 1024
+	// txFetchTimeout is how long a peer has to deliver the requested transactions before
+	// they are requested from another peer announcing them
+	txFetchTimeout = 5 * time.Second
+	// txFetchTick is the period of checking the requests for timeouts
+	txFetchTick = 500 * time.Millisecond
+
+	// servedTxBytesRate and servedTxBytesBurst limit the size of the PooledTransactions
+	// answers sent to a peer
+	servedTxBytesRate  = 1024 * 1024
+	servedTxBytesBurst = 4 * 1024 * 1024
+)
+
+var (
+	txFetchTimeouts         = metrics.GetOrCreateCounter(`txpool_fetch_timeouts`)
+	txFetchRejected         = metrics.GetOrCreateCounter(`txpool_fetch_rejected`)
+	txFetchDroppedAnnounces = metrics.GetOrCreateCounter(`txpool_fetch_dropped_announces`)
+	txServeLimited          = metrics.GetOrCreateCounter(`txpool_serve_limited`)
+)
+
+// txAnnounce is an announced transaction which is not in the pool yet
+type txAnnounce struct {
+	hash [32]byte
+	// the peers which announced the transaction and did not fail to deliver it, in the order of the announcements
+	announcers []*fetcherPeer
+	request    *txRequest // non-nil while the transaction is being requested
+}
+
+// peerAnnounce is the type and size of a transaction as announced by one peer, the peers
+// may disagree and only the ones lying about the transaction are affected
+type peerAnnounce struct {
+	txType  byte
+	hasType bool   // eth/66 and eth/67 announcements carry no type and size
+	size    uint32 // 0 if unknown
+}
+
+// txRequest is a GetPooledTransactions request waiting for the response
+type txRequest struct {
+	id     uint64
+	peer   *fetcherPeer
+	client sentry.SentryClient // the sentry of the peer when the request was scheduled
+	hashes [][32]byte
+	sent   time.Time
+}
+
+func (r *txRequest) hashList() types.Hashes {
+	hashes := make(types.Hashes, 0, len(r.hashes)*32)
+	for i := range r.hashes {
+		hashes = append(hashes, r.hashes[i][:]...)
+	}
+	return hashes
+}
+
+func (r *txRequest) has(hash [32]byte) bool {
+	for i := range r.hashes {
+		if r.hashes[i] == hash {
+			return true
+		}
+	}
+	return false
+}
+
+type fetcherPeer struct {
+	id     [64]byte
+	peerID types.PeerID
+	client sentry.SentryClient // the sentry the peer is connected to
+	// the announced transactions the peer is expected to deliver, the order keeps the
+	// requests in the order of the announcements and is compacted lazily
+	announced map[[32]byte]peerAnnounce
+	order     [][32]byte
+	request   *txRequest    // at most one request is in flight per peer
+	served    *rate.Limiter // limits the PooledTransactions answers
+}
+
+// txFetcher keeps track of the transactions announced by the peers and pulls them with
+// GetPooledTransactions requests. Each peer has at most one request in flight, and the
+// transactions it fails to deliver in time, or answers without, are requested from the
+// other peers which announced them. Blob transactions are only accepted from the peers
+// they were requested from.
+type txFetcher struct {
+	lock      sync.Mutex
+	announces map[[32]byte]*txAnnounce
+	peers     map[[64]byte]*fetcherPeer
+	requestID uint64
+}
+
+func newTxFetcher() *txFetcher {
+	return &txFetcher{
+		announces: map[[32]byte]*txAnnounce{},
+		peers:     map[[64]byte]*fetcherPeer{},
+	}
+}
+
+func (f *txFetcher) peer(peerID types.PeerID, client sentry.SentryClient) *fetcherPeer {
+	id := gointerfaces.ConvertH512ToHash(peerID)
+	p, ok := f.peers[id]
+	if !ok {
+		p = &fetcherPeer{
+			id:        id,
+			peerID:    peerID,
+			announced: map[[32]byte]peerAnnounce{},
+			served:    rate.NewLimiter(servedTxBytesRate, servedTxBytesBurst),
+		}
+		f.peers[id] = p
+	}
+	if client != nil {
+		p.client = client
+	}
+	return p
+}
+
+// announce records the unknown transactions announced by the peer. The types and sizes
+// are nil for the eth/66 and eth/67 announcements.
+func (f *txFetcher) announce(peerID types.PeerID, client sentry.SentryClient, txTypes []byte, sizes []uint32, hashes types.Hashes) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	p := f.peer(peerID, client)
+	for i := 0; i < hashes.Len(); i++ {
+		var hash [32]byte
+		copy(hash[:], hashes.At(i))
+		if _, ok := p.announced[hash]; ok {
+			continue
+		}
+		a, ok := f.announces[hash]
+		if len(p.announced) >= maxTxAnnounces || (!ok && len(f.announces) >= maxTxAnnouncesTotal) {
+			txFetchDroppedAnnounces.Inc()
+			continue
+		}
+		if !ok {
+			a = &txAnnounce{hash: hash}
+			f.announces[hash] = a
+		}
+		var pa peerAnnounce
+		if txTypes != nil {
+			pa = peerAnnounce{txType: txTypes[i], hasType: true, size: sizes[i]}
+		}
+		a.announcers = append(a.announcers, p)
+		p.announced[hash] = pa
+		p.order = append(p.order, hash)
+	}
+	if len(p.order) > 2*maxTxAnnounces {
+		p.compact()
+	}
+}
+
+// compact drops the transactions the peer is not expected to deliver anymore from the order,
+// along with the duplicates left by the transactions forgotten and announced again
+func (p *fetcherPeer) compact() {
+	order := p.order[:0]
+	seen := make(map[[32]byte]struct{}, len(p.announced))
+	for _, hash := range p.order {
+		if _, ok := p.announced[hash]; !ok {
+			continue
+		}
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		seen[hash] = struct{}{}
+		order = append(order, hash)
+	}
+	p.order = order
+}
+
+// schedule assigns the announced transactions which are not being requested to the idle
+// peers announcing them, and returns the new requests to send.
+func (f *txFetcher) schedule(now time.Time) []*txRequest {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	ids := make([][64]byte, 0, len(f.peers))
+	for id, p := range f.peers {
+		if p.idle() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return bytes.Compare(ids[i][:], ids[j][:]) < 0 })
+
+	var requests []*txRequest
+	for _, id := range ids {
+		if r := f.request(f.peers[id], now); r != nil {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// schedulePeer assigns the announced transactions which are not being requested to the
+// peer if it is idle, and returns the new request to send or nil. The other peers are
+// left to the periodic schedule.
+func (f *txFetcher) schedulePeer(peerID types.PeerID, now time.Time) *txRequest {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	p, ok := f.peers[gointerfaces.ConvertH512ToHash(peerID)]
+	if !ok || !p.idle() {
+		return nil
+	}
+	return f.request(p, now)
+}
+
+func (p *fetcherPeer) idle() bool {
+	return p.request == nil && len(p.announced) > 0 && p.client != nil
+}
+
+// request builds the request of the idle peer from the transactions it announced which are
+// not being requested, in the order of the announcements
+func (f *txFetcher) request(p *fetcherPeer, now time.Time) *txRequest {
+	p.compact()
+	var hashes [][32]byte
+	var size uint32
+	for _, hash := range p.order {
+		txSize := p.announced[hash].size
+		if f.announces[hash].request != nil || (len(hashes) > 0 && size+txSize > maxTxRequestSize) {
+			continue
+		}
+		hashes = append(hashes, hash)
+		size += txSize
+		if len(hashes) == maxTxRequestHashes {
+			break
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	f.requestID++
+	r := &txRequest{id: f.requestID, peer: p, client: p.client, hashes: hashes, sent: now}
+	for _, hash := range hashes {
+		f.announces[hash].request = r
+	}
+	p.request = r
+	return r
+}
+
+// deliver processes the transactions of a PooledTransactions response, including the ones
+// known to the pool already, and returns the transactions to add to the pool. The requested
+// transactions the peer did not deliver, or delivered with another type than it announced,
+// are rescheduled to the other peers announcing them.
+func (f *txFetcher) deliver(peerID types.PeerID, requestID uint64, txs types.TxSlots, known [][32]byte) types.TxSlots {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var req *txRequest
+	p, ok := f.peers[gointerfaces.ConvertH512ToHash(peerID)]
+	if ok && p.request != nil && p.request.id == requestID {
+		req = p.request
+		p.request = nil
+	}
+
+	delivered := make(map[[32]byte]struct{}, len(txs.Txs)+len(known))
+	var accepted types.TxSlots
+	for i, txn := range txs.Txs {
+		// "Nodes MUST NOT automatically broadcast blob transactions to their peers" - EIP-4844
+		if txn.Type == types.BlobTxType && (req == nil || !req.has(txn.IDHash)) {
+			txFetchRejected.Inc()
+			continue
+		}
+		if p != nil {
+			if pa, ok := p.announced[txn.IDHash]; ok && pa.hasType && pa.txType != txn.Type {
+				txFetchRejected.Inc()
+				continue
+			}
+		}
+		delivered[txn.IDHash] = struct{}{}
+		accepted.Append(txn, txs.Senders.At(i), false)
+	}
+	for _, hash := range known {
+		delivered[hash] = struct{}{}
+	}
+
+	for hash := range delivered {
+		f.forget(hash)
+	}
+	if req != nil {
+		f.fail(req)
+	}
+	return accepted
+}
+
+// receive processes the transactions broadcast by a peer, including the ones known to the
+// pool already, and returns the transactions to add to the pool.
+func (f *txFetcher) receive(txs types.TxSlots, known [][32]byte) types.TxSlots {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var accepted types.TxSlots
+	for i, txn := range txs.Txs {
+		// Blob transactions are only pulled
+		if txn.Type == types.BlobTxType {
+			txFetchRejected.Inc()
+			continue
+		}
+		f.forget(txn.IDHash)
+		accepted.Append(txn, txs.Senders.At(i), false)
+	}
+	for _, hash := range known {
+		f.forget(hash)
+	}
+	return accepted
+}
+
+// expire fails the requests which were not answered in time
+func (f *txFetcher) expire(now time.Time) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, p := range f.peers {
+		if p.request != nil && now.Sub(p.request.sent) >= txFetchTimeout {
+			txFetchTimeouts.Inc()
+			r := p.request
+			p.request = nil
+			f.fail(r)
+		}
+	}
+}
+
+// drop forgets a disconnected peer, the transactions requested from it are rescheduled
+func (f *txFetcher) drop(peerID types.PeerID) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	p, ok := f.peers[gointerfaces.ConvertH512ToHash(peerID)]
+	if !ok {
+		return
+	}
+	if p.request != nil {
+		r := p.request
+		p.request = nil
+		f.fail(r)
+	}
+	for hash := range p.announced {
+		f.removeAnnouncer(f.announces[hash], p)
+	}
+	delete(f.peers, p.id)
+}
+
+// allowServe reports whether the peer may be answered with the pooled transactions now
+func (f *txFetcher) allowServe(peerID types.PeerID, now time.Time) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.peer(peerID, nil).served.TokensAt(now) > 0 {
+		return true
+	}
+	txServeLimited.Inc()
+	return false
+}
+
+// served accounts the size of an answer sent to the peer
+func (f *txFetcher) served(peerID types.PeerID, size int, now time.Time) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if size > servedTxBytesBurst {
+		size = servedTxBytesBurst
+	}
+	// The answer is sent already, so the peer goes into debt which delays its next answer
+	f.peer(peerID, nil).served.ReserveN(now, size)
+}
+
+// fail releases the transactions of a request which were not delivered, the peer is no
+// longer expected to deliver them
+func (f *txFetcher) fail(r *txRequest) {
+	for _, hash := range r.hashes {
+		a, ok := f.announces[hash]
+		if !ok || a.request != r {
+			continue
+		}
+		a.request = nil
+		f.removeAnnouncer(a, r.peer)
+	}
+}
+
+func (f *txFetcher) removeAnnouncer(a *txAnnounce, p *fetcherPeer) {
+	delete(p.announced, a.hash)
+	for i, announcer := range a.announcers {
+		if announcer == p {
+			a.announcers = append(a.announcers[:i], a.announcers[i+1:]...)
+			break
+		}
+	}
+	if len(a.announcers) == 0 && a.request == nil {
+		delete(f.announces, a.hash)
+	}
+}
+
+// forget stops tracking a transaction which is known now
+func (f *txFetcher) forget(hash [32]byte) {
+	a, ok := f.announces[hash]
+	if !ok {
+		return
+	}
+	for _, p := range a.announcers {
+		delete(p.announced, hash)
+	}
+	delete(f.announces, hash)
+}
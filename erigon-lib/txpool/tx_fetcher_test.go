@@ -0,0 +1,381 @@
+/*
+   Copyright 2024 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package txpool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon-lib/common/u256"
+	"github.com/ledgerwatch/erigon-lib/direct"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon-lib/rlp"
+	"github.com/ledgerwatch/erigon-lib/types"
+	"github.com/ledgerwatch/log/v3"
+)
+
+func requestedHashes(r *txRequest) []byte {
+	var hashes []byte
+	for _, hash := range r.hashes {
+		hashes = append(hashes, hash[0])
+	}
+	return hashes
+}
+
+func txSlots(txTypes []byte, hashes ...byte) types.TxSlots {
+	var txs types.TxSlots
+	for i, h := range hashes {
+		txs.Append(&types.TxSlot{Type: txTypes[i], IDHash: [32]byte{h}}, make([]byte, 20), false)
+	}
+	return txs
+}
+
+func slotHashes(txs types.TxSlots) []byte {
+	var hashes []byte
+	for _, txn := range txs.Txs {
+		hashes = append(hashes, txn.IDHash[0])
+	}
+	return hashes
+}
+
+func TestTxFetcherSchedule(t *testing.T) {
+	f := newTxFetcher()
+	client := &sentry.SentryClientMock{}
+	peers := toPeerIDs(1, 2)
+	now := time.Now()
+
+	f.announce(peers[0], client, nil, nil, toHashes(1, 2))
+	f.announce(peers[1], client, nil, nil, toHashes(2, 3))
+	requests := f.schedule(now)
+	require.Len(t, requests, 2)
+	// Each transaction is requested from a single peer
+	assert.Equal(t, []byte{1, 2}, requestedHashes(requests[0]))
+	assert.Equal(t, []byte{3}, requestedHashes(requests[1]))
+	assert.NotEqual(t, requests[0].id, requests[1].id)
+	// A peer has a single request in flight
+	f.announce(peers[0], client, nil, nil, toHashes(4))
+	assert.Empty(t, f.schedule(now))
+
+	// The transaction the first peer did not deliver is requested from the second one
+	accepted := f.deliver(peers[0], requests[0].id, txSlots([]byte{types.LegacyTxType}, 1), nil)
+	assert.Equal(t, []byte{1}, slotHashes(accepted))
+	requests = f.schedule(now)
+	require.Len(t, requests, 1)
+	assert.Equal(t, []byte{4}, requestedHashes(requests[0]))
+	f.deliver(peers[1], 2, txSlots([]byte{types.LegacyTxType}, 3), nil)
+	requests = f.schedule(now)
+	require.Len(t, requests, 1)
+	assert.Equal(t, peers[1], requests[0].peer.peerID)
+	assert.Equal(t, []byte{2}, requestedHashes(requests[0]))
+
+	// Nobody else announced the transactions, so they are forgotten once not delivered
+	f.deliver(peers[0], requests[0].id-1, types.TxSlots{}, nil)
+	f.deliver(peers[1], requests[0].id, types.TxSlots{}, nil)
+	assert.Empty(t, f.schedule(now))
+	assert.Empty(t, f.announces)
+}
+
+func TestTxFetcherSchedulePeer(t *testing.T) {
+	f := newTxFetcher()
+	client := &sentry.SentryClientMock{}
+	peers := toPeerIDs(1, 2, 3)
+	now := time.Now()
+
+	// Only the announcing peer is scheduled
+	f.announce(peers[0], client, nil, nil, toHashes(1))
+	f.announce(peers[1], client, nil, nil, toHashes(1, 2))
+	r := f.schedulePeer(peers[1], now)
+	require.NotNil(t, r)
+	assert.Equal(t, peers[1], r.peer.peerID)
+	assert.Equal(t, []byte{1, 2}, requestedHashes(r))
+	assert.Nil(t, f.peers[gointerfaces.ConvertH512ToHash(peers[0])].request)
+
+	// Nothing is left to request from the first peer, the busy and the unknown peers get no request
+	assert.Nil(t, f.schedulePeer(peers[0], now))
+	f.announce(peers[1], client, nil, nil, toHashes(3))
+	assert.Nil(t, f.schedulePeer(peers[1], now))
+	assert.Nil(t, f.schedulePeer(peers[2], now))
+
+	// The transaction the second peer did not deliver is left to the periodic schedule
+	f.deliver(peers[1], r.id, txSlots([]byte{types.LegacyTxType}, 2), nil)
+	r = f.schedulePeer(peers[1], now)
+	require.NotNil(t, r)
+	assert.Equal(t, []byte{3}, requestedHashes(r))
+	requests := f.schedule(now)
+	require.Len(t, requests, 1)
+	assert.Equal(t, peers[0], requests[0].peer.peerID)
+	assert.Equal(t, []byte{1}, requestedHashes(requests[0]))
+}
+
+func TestTxFetcherRequestSize(t *testing.T) {
+	f := newTxFetcher()
+	client := &sentry.SentryClientMock{}
+	peer := toPeerIDs(1)[0]
+
+	// The blob transactions are large, they are requested a few at a time
+	f.announce(peer, client, []byte{types.BlobTxType, types.BlobTxType, types.BlobTxType}, []uint32{100_000, 20_000, 100_000}, toHashes(1, 2, 3))
+	requests := f.schedule(time.Now())
+	require.Len(t, requests, 1)
+	assert.Equal(t, []byte{1, 2}, requestedHashes(requests[0]))
+
+	// The number of hashes per request is bounded as well
+	f = newTxFetcher()
+	var hashes types.Hashes
+	for i := 0; i < maxTxRequestHashes+1; i++ {
+		hash := [32]byte{byte(i), byte(i >> 8)}
+		hashes = append(hashes, hash[:]...)
+	}
+	f.announce(peer, client, nil, nil, hashes)
+	requests = f.schedule(time.Now())
+	require.Len(t, requests, 1)
+	assert.Len(t, requests[0].hashes, maxTxRequestHashes)
+}
+
+func TestTxFetcherTimeout(t *testing.T) {
+	f := newTxFetcher()
+	client := &sentry.SentryClientMock{}
+	peers := toPeerIDs(1, 2)
+	now := time.Now()
+
+	f.announce(peers[0], client, nil, nil, toHashes(1))
+	f.announce(peers[1], client, nil, nil, toHashes(1))
+	requests := f.schedule(now)
+	require.Len(t, requests, 1)
+	assert.Equal(t, peers[0], requests[0].peer.peerID)
+
+	f.expire(now.Add(txFetchTimeout - time.Millisecond))
+	assert.Empty(t, f.schedule(now))
+	f.expire(now.Add(txFetchTimeout))
+	requests = f.schedule(now.Add(txFetchTimeout))
+	require.Len(t, requests, 1)
+	assert.Equal(t, peers[1], requests[0].peer.peerID)
+
+	// A late response is not matched to a request, but still delivers the transaction
+	accepted := f.deliver(peers[0], 1, txSlots([]byte{types.LegacyTxType}, 1), nil)
+	assert.Equal(t, []byte{1}, slotHashes(accepted))
+	assert.Empty(t, f.announces)
+}
+
+func TestTxFetcherDrop(t *testing.T) {
+	f := newTxFetcher()
+	client := &sentry.SentryClientMock{}
+	peers := toPeerIDs(1, 2)
+	now := time.Now()
+
+	f.announce(peers[0], client, nil, nil, toHashes(1, 2))
+	f.announce(peers[1], client, nil, nil, toHashes(1))
+	require.Len(t, f.schedule(now), 1)
+
+	f.drop(peers[0])
+	requests := f.schedule(now)
+	require.Len(t, requests, 1)
+	assert.Equal(t, peers[1], requests[0].peer.peerID)
+	assert.Equal(t, []byte{1}, requestedHashes(requests[0]))
+	assert.Len(t, f.announces, 1)
+	assert.Len(t, f.peers, 1)
+}
+
+func TestTxFetcherBlobsArePulled(t *testing.T) {
+	f := newTxFetcher()
+	client := &sentry.SentryClientMock{}
+	peers := toPeerIDs(1, 2)
+
+	// Broadcast blob transactions are rejected
+	accepted := f.receive(txSlots([]byte{types.BlobTxType, types.DynamicFeeTxType}, 1, 2), nil)
+	assert.Equal(t, []byte{2}, slotHashes(accepted))
+
+	f.announce(peers[0], client, []byte{types.BlobTxType, types.DynamicFeeTxType}, []uint32{1000, 100}, toHashes(3, 4))
+	requests := f.schedule(time.Now())
+	require.Len(t, requests, 1)
+	// Only the requested blob transactions are accepted, and the announced types have to match
+	accepted = f.deliver(peers[0], requests[0].id, txSlots([]byte{types.BlobTxType, types.BlobTxType, types.BlobTxType}, 3, 4, 5), nil)
+	assert.Equal(t, []byte{3}, slotHashes(accepted))
+	accepted = f.deliver(peers[1], 0, txSlots([]byte{types.BlobTxType}, 6), nil)
+	assert.Empty(t, accepted.Txs)
+}
+
+func TestTxFetcherAnnouncedTypes(t *testing.T) {
+	f := newTxFetcher()
+	client := &sentry.SentryClientMock{}
+	peers := toPeerIDs(1, 2)
+	now := time.Now()
+
+	// The first announcer lies about the type, which does not affect the other announcer
+	f.announce(peers[0], client, []byte{types.LegacyTxType}, []uint32{100}, toHashes(1))
+	f.announce(peers[1], client, []byte{types.BlobTxType}, []uint32{100_000}, toHashes(1))
+	requests := f.schedule(now)
+	require.Len(t, requests, 1)
+	assert.Equal(t, peers[0], requests[0].peer.peerID)
+	accepted := f.deliver(peers[0], requests[0].id, txSlots([]byte{types.BlobTxType}, 1), nil)
+	assert.Empty(t, accepted.Txs)
+
+	requests = f.schedule(now)
+	require.Len(t, requests, 1)
+	assert.Equal(t, peers[1], requests[0].peer.peerID)
+	accepted = f.deliver(peers[1], requests[0].id, txSlots([]byte{types.BlobTxType}, 1), nil)
+	assert.Equal(t, []byte{1}, slotHashes(accepted))
+	assert.Empty(t, f.announces)
+}
+
+func TestTxFetcherBounds(t *testing.T) {
+	f := newTxFetcher()
+	client := &sentry.SentryClientMock{}
+	peers := toPeerIDs(1, 2)
+
+	var hashes types.Hashes
+	for i := 0; i < maxTxAnnounces+10; i++ {
+		hash := [32]byte{byte(i), byte(i >> 8)}
+		hashes = append(hashes, hash[:]...)
+	}
+	f.announce(peers[0], client, nil, nil, hashes)
+	assert.Len(t, f.announces, maxTxAnnounces)
+	// The announcements over the limit of one peer do not hold back the others
+	f.announce(peers[1], client, nil, nil, hashes[10*32:])
+	assert.Len(t, f.announces, maxTxAnnounces+10)
+
+	// The transactions received and announced again do not grow the order without bounds
+	var received types.TxSlots
+	for i := 0; i < hashes.Len(); i++ {
+		var hash [32]byte
+		copy(hash[:], hashes.At(i))
+		received.Append(&types.TxSlot{Type: types.LegacyTxType, IDHash: hash}, make([]byte, 20), false)
+	}
+	for i := 0; i < 4; i++ {
+		f.receive(received, nil)
+		f.announce(peers[0], client, nil, nil, hashes)
+	}
+	assert.LessOrEqual(t, len(f.peers[gointerfaces.ConvertH512ToHash(peers[0])].order), 2*maxTxAnnounces)
+}
+
+func TestTxFetcherServeLimit(t *testing.T) {
+	f := newTxFetcher()
+	peer := toPeerIDs(1)[0]
+	now := time.Now()
+
+	require.True(t, f.allowServe(peer, now))
+	f.served(peer, servedTxBytesBurst, now)
+	f.served(peer, servedTxBytesRate, now)
+	// The peer went into debt with the last answer, it is answered again once it is paid off
+	assert.False(t, f.allowServe(peer, now))
+	assert.False(t, f.allowServe(peer, now.Add(time.Second)))
+	assert.True(t, f.allowServe(peer, now.Add(time.Second+time.Millisecond)))
+}
+
+// TestFetchFromAlternateAnnouncer simulates two peers announcing the same transaction over
+// a mock sentry, the first one fails to answer and the transaction is pulled from the second.
+func TestFetchFromAlternateAnnouncer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := NewMockSentry(ctx)
+	var sentLock sync.Mutex
+	var sent []*sentry.SendMessageByIdRequest
+	m.SendMessageByIdFunc = func(_ context.Context, req *sentry.SendMessageByIdRequest) (*sentry.SentPeers, error) {
+		sentLock.Lock()
+		defer sentLock.Unlock()
+		sent = append(sent, req)
+		return &sentry.SentPeers{Peers: []*types2.H512{req.PeerId}}, nil
+	}
+	var added types.TxSlots
+	pool := &PoolMock{
+		StartedFunc: func() bool { return true },
+		FilterKnownIdHashesFunc: func(tx kv.Tx, hashes types.Hashes) (types.Hashes, error) {
+			return hashes, nil
+		},
+		IdHashKnownFunc: func(tx kv.Tx, hash []byte) (bool, error) { return false, nil },
+		AddRemoteTxsFunc: func(ctx context.Context, newTxs types.TxSlots) {
+			for i := range newTxs.Txs {
+				added.Append(newTxs.Txs[i], newTxs.Senders.At(i), false)
+			}
+		},
+	}
+	fetch := NewFetch(ctx, []direct.SentryClient{direct.NewSentryClientDirect(direct.ETH68, m)}, pool, &remote.KVClientMock{}, nil, memdb.NewTestPoolDB(t), *u256.N1, log.New())
+	var wg sync.WaitGroup
+	fetch.SetWaitGroup(&wg)
+	m.StreamWg.Add(2)
+	fetch.ConnectSentries()
+	m.StreamWg.Wait()
+
+	txn := types.TxParseMainnetTests[1]
+	txnRlp := decodeHex(txn.PayloadStr)
+	announcement := make([]byte, rlp.AnnouncementsLen([]byte{types.DynamicFeeTxType}, []uint32{uint32(len(txnRlp))}, decodeHex(txn.IdHashStr)))
+	rlp.EncodeAnnouncements([]byte{types.DynamicFeeTxType}, []uint32{uint32(len(txnRlp))}, decodeHex(txn.IdHashStr), announcement)
+	peers := toPeerIDs(1, 2)
+	send := func(id sentry.MessageId, data []byte, peer types.PeerID) {
+		wg.Add(1)
+		for _, err := range m.Send(&sentry.InboundMessage{Id: id, Data: data, PeerId: peer}) {
+			require.NoError(t, err)
+		}
+		wg.Wait()
+	}
+	lastRequest := func() (types.PeerID, uint64, types.Hashes) {
+		sentLock.Lock()
+		defer sentLock.Unlock()
+		require.NotEmpty(t, sent)
+		req := sent[len(sent)-1]
+		require.Equal(t, sentry.MessageId_GET_POOLED_TRANSACTIONS_66, req.Data.Id)
+		requestID, hashes, _, err := types.ParseGetPooledTransactions66(req.Data.Data, 0, nil)
+		require.NoError(t, err)
+		return req.PeerId, requestID, hashes
+	}
+
+	send(sentry.MessageId_NEW_POOLED_TRANSACTION_HASHES_68, announcement, peers[0])
+	send(sentry.MessageId_NEW_POOLED_TRANSACTION_HASHES_68, announcement, peers[1])
+	peer, _, hashes := lastRequest()
+	assert.Equal(t, peers[0], peer)
+	assert.Equal(t, decodeHex(txn.IdHashStr), []byte(hashes))
+	sentLock.Lock()
+	assert.Len(t, sent, 1)
+	sentLock.Unlock()
+
+	// The first peer does not answer in time
+	now := time.Now().Add(txFetchTimeout)
+	fetch.fetcher.expire(now)
+	fetch.requestAnnounced(now)
+	peer, requestID, hashes := lastRequest()
+	assert.Equal(t, peers[1], peer)
+	assert.Equal(t, decodeHex(txn.IdHashStr), []byte(hashes))
+
+	send(sentry.MessageId_POOLED_TRANSACTIONS_66, types.EncodePooledTransactions66([][]byte{txnRlp}, requestID, nil), peers[1])
+	require.Len(t, added.Txs, 1)
+	assert.Equal(t, decodeHex(txn.IdHashStr), added.Txs[0].IDHash[:])
+	assert.Empty(t, fetch.fetcher.announces)
+
+	// The peer is answered with the pooled transactions until it exhausts its budget
+	pool.GetRlpFunc = func(tx kv.Tx, hash []byte) ([]byte, error) { return make([]byte, servedTxBytesBurst), nil }
+	getPooled, err := types.EncodeGetPooledTransactions66(hashes, 1, nil)
+	require.NoError(t, err)
+	sentLock.Lock()
+	sentBefore := len(sent)
+	sentLock.Unlock()
+	for i := 0; i < 4; i++ {
+		send(sentry.MessageId_GET_POOLED_TRANSACTIONS_66, getPooled, peers[0])
+	}
+	sentLock.Lock()
+	assert.Equal(t, 2, len(sent)-sentBefore)
+	sentLock.Unlock()
+}
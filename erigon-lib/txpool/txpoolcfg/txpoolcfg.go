@@ -109,6 +109,8 @@ const (
 	BlobHashCheckFail   DiscardReason = 28 // KZGcommitment's versioned hash has to be equal to blob_versioned_hash at the same index
 	UnmatchedBlobTxExt  DiscardReason = 29 // KZGcommitments must match the corresponding blobs and proofs
 	BlobTxReplace       DiscardReason = 30 // Cannot replace type-3 blob txn with another type of txn
+	CreateSetCodeTxn    DiscardReason = 31 // Set code transactions cannot have the form of a create transaction
+	NoAuthorizations    DiscardReason = 32 // Set code transactions must have at least one authorization
 )
 
 func (r DiscardReason) String() string {
@@ -169,13 +171,17 @@ func (r DiscardReason) String() string {
 		return "max number of blobs exceeded"
 	case BlobTxReplace:
 		return "can't replace blob-txn with a non-blob-txn"
+	case CreateSetCodeTxn:
+		return "set code transactions cannot have the form of a create transaction"
+	case NoAuthorizations:
+		return "set code transactions must have at least one authorization"
 	default:
 		panic(fmt.Sprintf("discard reason: %d", r))
 	}
 }
 
 // CalcIntrinsicGas computes the 'intrinsic gas' for a message with the given data.
-func CalcIntrinsicGas(dataLen, dataNonZeroLen uint64, accessList types.AccessList, isContractCreation, isHomestead, isEIP2028, isShanghai bool) (uint64, DiscardReason) {
+func CalcIntrinsicGas(dataLen, dataNonZeroLen, authorizationsLen uint64, accessList types.AccessList, isContractCreation, isHomestead, isEIP2028, isShanghai bool) (uint64, DiscardReason) {
 	// Set the starting gas for the raw transaction
 	var gas uint64
 	if isContractCreation && isHomestead {
@@ -244,6 +250,17 @@ func CalcIntrinsicGas(dataLen, dataNonZeroLen uint64, accessList types.AccessLis
 			return 0, GasUintOverflow
 		}
 	}
+	// EIP-7702: every authorization is charged as if its authority was a new account
+	if authorizationsLen > 0 {
+		product, overflow := emath.SafeMul(authorizationsLen, fixedgas.PerEmptyAccountCost)
+		if overflow {
+			return 0, GasUintOverflow
+		}
+		gas, overflow = emath.SafeAdd(gas, product)
+		if overflow {
+			return 0, GasUintOverflow
+		}
+	}
 	return gas, Success
 }
 
@@ -108,6 +108,9 @@ type TxSlot struct {
 	Blobs       [][]byte
 	Commitments []gokzg4844.KZGCommitment
 	Proofs      []gokzg4844.KZGProof
+
+	// EIP-7702: Set EOA account code
+	AuthorizationsLen int // Number of authorizations in the authorization list
 }
 
 const (
@@ -115,6 +118,7 @@ const (
 	AccessListTxType byte = 1 // EIP-2930
 	DynamicFeeTxType byte = 2 // EIP-1559
 	BlobTxType       byte = 3 // EIP-4844
+	SetCodeTxType    byte = 4 // EIP-7702
 )
 
 var ErrParseTxn = fmt.Errorf("%w transaction", rlp.ErrParse)
@@ -184,7 +188,7 @@ func (ctx *TxParseContext) ParseTransaction(payload []byte, pos int, slot *TxSlo
 	// If it is non-legacy transaction, the transaction type follows, and then the the list
 	if !legacy {
 		slot.Type = payload[p]
-		if slot.Type > BlobTxType {
+		if slot.Type > SetCodeTxType {
 			return 0, fmt.Errorf("%w: unknown transaction type: %d", ErrParseTxn, slot.Type)
 		}
 		p++
@@ -465,6 +469,55 @@ func (ctx *TxParseContext) parseTransactionBody(payload []byte, pos, p0 int, slo
 		}
 		p = dataPos + dataLen
 	}
+	// Set code transactions carry the authorization list, we are only interested in the number of authorizations
+	if slot.Type == SetCodeTxType {
+		dataPos, dataLen, err = rlp.List(payload, p)
+		if err != nil {
+			return 0, fmt.Errorf("%w: authorization list len: %s", ErrParseTxn, err) //nolint
+		}
+		authPos := dataPos
+		for authPos < dataPos+dataLen {
+			var authLen int
+			authPos, authLen, err = rlp.List(payload, authPos)
+			if err != nil {
+				return 0, fmt.Errorf("%w: authorization len: %s", ErrParseTxn, err) //nolint
+			}
+			var x uint256.Int
+			fieldPos := authPos
+			if fieldPos, err = rlp.U256(payload, fieldPos, &x); err != nil {
+				return 0, fmt.Errorf("%w: authorization chainId: %s", ErrParseTxn, err) //nolint
+			}
+			if fieldPos, err = rlp.StringOfLen(payload, fieldPos, 20); err != nil {
+				return 0, fmt.Errorf("%w: authorization address: %s", ErrParseTxn, err) //nolint
+			}
+			fieldPos += 20
+			if fieldPos, _, err = rlp.U64(payload, fieldPos); err != nil {
+				return 0, fmt.Errorf("%w: authorization nonce: %s", ErrParseTxn, err) //nolint
+			}
+			var yParity uint64
+			if fieldPos, yParity, err = rlp.U64(payload, fieldPos); err != nil {
+				return 0, fmt.Errorf("%w: authorization yParity: %s", ErrParseTxn, err) //nolint
+			}
+			if yParity > 0xff {
+				return 0, fmt.Errorf("%w: authorization yParity too large: %d", ErrParseTxn, yParity)
+			}
+			if fieldPos, err = rlp.U256(payload, fieldPos, &x); err != nil {
+				return 0, fmt.Errorf("%w: authorization r: %s", ErrParseTxn, err) //nolint
+			}
+			if fieldPos, err = rlp.U256(payload, fieldPos, &x); err != nil {
+				return 0, fmt.Errorf("%w: authorization s: %s", ErrParseTxn, err) //nolint
+			}
+			if fieldPos != authPos+authLen {
+				return 0, fmt.Errorf("%w: extraneous space in the authorization", ErrParseTxn)
+			}
+			slot.AuthorizationsLen++
+			authPos += authLen
+		}
+		if authPos != dataPos+dataLen {
+			return 0, fmt.Errorf("%w: extraneous space in the authorization list", ErrParseTxn)
+		}
+		p = dataPos + dataLen
+	}
 	// This is where the data for Sighash ends
 	// Next follows V of the signature
 	var vByte byte
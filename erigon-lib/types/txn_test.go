@@ -292,3 +292,38 @@ func TestBlobTxParsing(t *testing.T) {
 	assert.Equal(t, proof0, fatTx.Proofs[0])
 	assert.Equal(t, proof1, fatTx.Proofs[1])
 }
+
+func TestSetCodeTxParsing(t *testing.T) {
+	ctx := NewTxParseContext(*uint256.NewInt(1))
+	ctx.withSender = false
+
+	// Two authorizations, the second one of any chain
+	txRlp := hexutility.MustDecodeHex("04f8620105843b9aca008477359400830186a09411111111111111111111111111111111111111118080c0" +
+		"f6da0194aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa07800101da8094bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb08010202800101")
+	txType, err := PeekTransactionType(txRlp)
+	require.NoError(t, err)
+	assert.Equal(t, SetCodeTxType, txType)
+
+	var slot TxSlot
+	p, err := ctx.ParseTransaction(txRlp, 0, &slot, nil, false /* hasEnvelope */, false /* wrappedWithBlobs */, nil)
+	require.NoError(t, err)
+	assert.Equal(t, len(txRlp), p)
+	assert.Equal(t, SetCodeTxType, slot.Type)
+	assert.Equal(t, uint64(5), slot.Nonce)
+	assert.Equal(t, uint64(100_000), slot.Gas)
+	assert.False(t, slot.Creation)
+	assert.Equal(t, 2, slot.AuthorizationsLen)
+
+	// An empty authorization list is parsed, it is rejected by the pool
+	txRlp = hexutility.MustDecodeHex("04ec0105843b9aca008477359400830186a09411111111111111111111111111111111111111118080c0c0800101")
+	slot = TxSlot{}
+	_, err = ctx.ParseTransaction(txRlp, 0, &slot, nil, false /* hasEnvelope */, false /* wrappedWithBlobs */, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, slot.AuthorizationsLen)
+
+	// An authorization with an extra field
+	txRlp = hexutility.MustDecodeHex("04f8480105843b9aca008477359400830186a09411111111111111111111111111111111111111118080c0" +
+		"dcdb0194aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa0780010101800101")
+	_, err = ctx.ParseTransaction(txRlp, 0, &TxSlot{}, nil, false /* hasEnvelope */, false /* wrappedWithBlobs */, nil)
+	require.ErrorIs(t, err, ErrParseTxn)
+}
@@ -19,8 +19,10 @@ package eth
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"math/big"
 	"net"
@@ -32,6 +34,7 @@ import (
 	"time"
 
 	"github.com/ledgerwatch/erigon-lib/common/dbg"
+	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 
 	lru "github.com/hashicorp/golang-lru/arc/v2"
@@ -81,6 +84,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/direct"
 	"github.com/ledgerwatch/erigon-lib/downloader"
 	"github.com/ledgerwatch/erigon-lib/downloader/downloadercfg"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	proto_downloader "github.com/ledgerwatch/erigon-lib/gointerfaces/downloader"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/grpcutil"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
@@ -120,13 +124,16 @@ import (
 	"github.com/ledgerwatch/erigon/eth/ethconsensusconfig"
 	"github.com/ledgerwatch/erigon/eth/ethutils"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	snapproto "github.com/ledgerwatch/erigon/eth/protocols/snap"
 	"github.com/ledgerwatch/erigon/eth/stagedsync"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/eth/tracers/live"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
 	"github.com/ledgerwatch/erigon/ethstats"
 	"github.com/ledgerwatch/erigon/node"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/portal"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/services"
@@ -213,6 +220,8 @@ type Ethereum struct {
 	silkworm                 *silkworm.Silkworm
 	silkwormRPCDaemonService *silkworm.RpcDaemonService
 	silkwormSentryService    *silkworm.SentryService
+
+	liveTracer vm.LiveLogger
 }
 
 func splitAddrIntoHostAndPort(addr string) (host string, port int, err error) {
@@ -278,6 +287,11 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 	}); err != nil {
 		return nil, err
 	}
+	if config.Sync.ParallelExec && config.HistoryV3 {
+		// the transactions of a block are executed by executeBlock only, which history v3 replaces with exec3
+		logger.Warn("--sync.parallel.exec is not supported with --experimental.history.v3, the blocks are executed serially")
+		config.Sync.ParallelExec = false
+	}
 
 	ctx, ctxCancel := context.WithCancel(context.Background())
 
@@ -414,6 +428,21 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 			return res
 		}
 
+		// lowest block whose receipts are kept according to the prune mode, announced to the eth/69 peers
+		readEarliestBlock := func(headHeight uint64) uint64 {
+			earliest := config.Prune.Receipts.PruneTo(headHeight)
+			if config.HistoryV3 {
+				// receipts are re-executed on top of the history of the state
+				earliest = max(earliest, config.Prune.History.PruneTo(headHeight))
+			}
+			return min(earliest, headHeight)
+		}
+
+		var snapServer *snapproto.Server
+		if stack.Config().P2P.Snap {
+			snapServer = snapproto.NewServer(backend.chainDB, blockReader)
+		}
+
 		discovery := func() enode.Iterator {
 			d, err := setupDiscovery(backend.config.EthDiscoveryURLs)
 			if err != nil {
@@ -428,8 +457,24 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 			return nil, err
 		}
 
+		if config.PortalHistory {
+			if !refCfg.DiscoveryV5 {
+				logger.Warn("[p2p] Portal history network is served only with the V5 discovery enabled (--v5disc)")
+			}
+			historyServer := portal.NewHistoryServer(chainKv, blockReader, logger)
+			refCfg.TalkProtocols = append(refCfg.TalkProtocols, historyServer.Protocol())
+		}
+
+		var quicHost string
+		var quicPort int
+		if refCfg.QUICListenAddr != "" {
+			if quicHost, quicPort, err = splitAddrIntoHostAndPort(refCfg.QUICListenAddr); err != nil {
+				return nil, err
+			}
+		}
+
 		var pi int // points to next port to be picked from refCfg.AllowedPorts
-		for _, protocol := range refCfg.ProtocolVersion {
+		for i, protocol := range refCfg.ProtocolVersion {
 			cfg := refCfg
 			cfg.NodeDatabase = filepath.Join(stack.Config().Dirs.Nodes, eth.ProtocolToString[protocol])
 
@@ -459,8 +504,12 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 			}
 
 			cfg.ListenAddr = fmt.Sprintf("%s:%d", listenHost, listenPort)
+			if refCfg.QUICListenAddr != "" && quicPort != 0 {
+				// every sentry needs a QUIC port of its own
+				cfg.QUICListenAddr = fmt.Sprintf("%s:%d", quicHost, quicPort+i)
+			}
 
-			server := sentry.NewGrpcServer(backend.sentryCtx, discovery, readNodeInfo, &cfg, protocol, logger)
+			server := sentry.NewGrpcServer(backend.sentryCtx, discovery, readNodeInfo, readEarliestBlock, snapServer, &cfg, protocol, logger)
 			backend.sentryServers = append(backend.sentryServers, server)
 			sentries = append(sentries, direct.NewSentryClientDirect(protocol, server))
 		}
@@ -797,6 +846,13 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		}
 	}()
 
+	if config.LiveTracer != "" {
+		backend.liveTracer, err = live.New(config.LiveTracer, json.RawMessage(config.LiveTracerConfig), logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if err := backend.StartMining(context.Background(), backend.chainDB, mining, backend.config.Miner, backend.gasPrice, backend.sentriesClient.Hd.QuitPoWMining, tmpdir, logger); err != nil {
 		return nil, err
 	}
@@ -804,7 +860,7 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 	backend.ethBackendRPC, backend.miningRPC, backend.stateChangesClient = ethBackendRPC, miningRPC, stateDiffClient
 
 	backend.syncStages = stages2.NewDefaultStages(backend.sentryCtx, backend.chainDB, snapDb, stack.Config().P2P, config, backend.sentriesClient, backend.notifications, backend.downloaderClient,
-		blockReader, blockRetire, backend.agg, backend.silkworm, backend.forkValidator, heimdallClient, recents, signatures, logger)
+		blockReader, blockRetire, backend.agg, backend.silkworm, backend.liveTracer, backend.forkValidator, heimdallClient, recents, signatures, logger)
 	backend.syncUnwindOrder = stagedsync.DefaultUnwindOrder
 	backend.syncPruneOrder = stagedsync.DefaultPruneOrder
 	backend.stagedSync = stagedsync.New(config.Sync, backend.syncStages, backend.syncUnwindOrder, backend.syncPruneOrder, logger)
@@ -812,7 +868,7 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 	hook := stages2.NewHook(backend.sentryCtx, backend.chainDB, backend.notifications, backend.stagedSync, backend.blockReader, backend.chainConfig, backend.logger, backend.sentriesClient.UpdateHead)
 
 	checkStateRoot := true
-	pipelineStages := stages2.NewPipelineStages(ctx, backend.chainDB, config, stack.Config().P2P, backend.sentriesClient, backend.notifications, backend.downloaderClient, blockReader, blockRetire, backend.agg, backend.silkworm, backend.forkValidator, logger, checkStateRoot)
+	pipelineStages := stages2.NewPipelineStages(ctx, backend.chainDB, config, stack.Config().P2P, backend.sentriesClient, backend.notifications, backend.downloaderClient, blockReader, blockRetire, backend.agg, backend.silkworm, backend.liveTracer, backend.forkValidator, logger, checkStateRoot)
 	backend.pipelineStagedSync = stagedsync.New(config.Sync, pipelineStages, stagedsync.PipelineUnwindOrder, stagedsync.PipelinePruneOrder, logger)
 	backend.eth1ExecutionServer = eth1.NewEthereumExecutionModule(blockReader, backend.chainDB, backend.pipelineStagedSync, backend.forkValidator, chainConfig, assembleBlockPOS, hook, backend.notifications.Accumulator, backend.notifications.StateChangesConsumer, logger, backend.engine, config.HistoryV3, config.Sync)
 	executionRpc := direct.NewExecutionClientDirect(backend.eth1ExecutionServer)
@@ -879,6 +935,8 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 			NetworkConfig: networkCfg,
 			BeaconConfig:  beaconCfg,
 			TmpDir:        tmpdir,
+
+			ActiveValidatorCount: uint64(len(state.GetActiveValidatorsIndices(state.Slot() / beaconCfg.SlotsPerEpoch))),
 		}, rawBeaconBlockChainDb, indiciesDB, &service.ServerConfig{Network: "tcp", Addr: fmt.Sprintf("%s:%d", config.SentinelAddr, config.SentinelPort)}, creds, &cltypes.Status{
 			ForkDigest:     forkDigest,
 			FinalizedRoot:  state.FinalizedCheckpoint().BlockRoot(),
@@ -894,7 +952,7 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 
 		go func() {
 			eth1Getter := getters.NewExecutionSnapshotReader(ctx, beaconCfg, blockReader, chainKv)
-			if err := caplin1.RunCaplinPhase1(ctx, client, engine, beaconCfg, genesisCfg, state, nil, dirs, snapshotVersion, config.BeaconRouter, eth1Getter, backend.downloaderClient, config.CaplinConfig.Backfilling, config.CaplinConfig.Archive, historyDB, indiciesDB, blockSnapBuildSema); err != nil {
+			if err := caplin1.RunCaplinPhase1(ctx, client, engine, beaconCfg, genesisCfg, state, nil, dirs, snapshotVersion, config.BeaconRouter, eth1Getter, backend.downloaderClient, config.CaplinConfig.Backfilling, config.CaplinConfig.Archive, config.CaplinConfig.CheckpointsToKeep, historyDB, indiciesDB, blockSnapBuildSema); err != nil {
 				logger.Error("could not start caplin", "err", err)
 			}
 			ctxCancel()
@@ -1312,6 +1370,16 @@ func (s *Ethereum) DiagnosticsPeersData() map[string]*diagnostics.PeerStatistics
 	return reply
 }
 
+func (s *Ethereum) GetPeersScores() map[string]*diagnostics.PeerScore {
+	scores := make(map[string]*diagnostics.PeerScore)
+	for _, sentryServer := range s.sentryServers {
+		for key, value := range sentryServer.GetPeersScores() {
+			scores[key] = value
+		}
+	}
+	return scores
+}
+
 func (s *Ethereum) AddPeer(ctx context.Context, req *remote.AddPeerRequest) (*remote.AddPeerReply, error) {
 	for _, sentryClient := range s.sentriesClient.Sentries() {
 		_, err := sentryClient.AddPeer(ctx, &proto_sentry.AddPeerRequest{Url: req.Url})
@@ -1322,6 +1390,120 @@ func (s *Ethereum) AddPeer(ctx context.Context, req *remote.AddPeerRequest) (*re
 	return &remote.AddPeerReply{Success: true}, nil
 }
 
+func (s *Ethereum) RemovePeer(ctx context.Context, req *remote.RemovePeerRequest) (*remote.RemovePeerReply, error) {
+	for _, sentryClient := range s.sentriesClient.Sentries() {
+		_, err := sentryClient.RemovePeer(ctx, &proto_sentry.RemovePeerRequest{Url: req.Url})
+		if err != nil {
+			return nil, fmt.Errorf("ethereum backend MultiClient.RemovePeer error: %w", err)
+		}
+	}
+	return &remote.RemovePeerReply{Success: true}, nil
+}
+
+func (s *Ethereum) AddTrustedPeer(ctx context.Context, req *remote.AddPeerRequest) (*remote.AddPeerReply, error) {
+	for _, sentryClient := range s.sentriesClient.Sentries() {
+		_, err := sentryClient.AddTrustedPeer(ctx, &proto_sentry.AddPeerRequest{Url: req.Url})
+		if err != nil {
+			return nil, fmt.Errorf("ethereum backend MultiClient.AddTrustedPeer error: %w", err)
+		}
+	}
+	return &remote.AddPeerReply{Success: true}, nil
+}
+
+func (s *Ethereum) RemoveTrustedPeer(ctx context.Context, req *remote.RemovePeerRequest) (*remote.RemovePeerReply, error) {
+	for _, sentryClient := range s.sentriesClient.Sentries() {
+		_, err := sentryClient.RemoveTrustedPeer(ctx, &proto_sentry.RemovePeerRequest{Url: req.Url})
+		if err != nil {
+			return nil, fmt.Errorf("ethereum backend MultiClient.RemoveTrustedPeer error: %w", err)
+		}
+	}
+	return &remote.RemovePeerReply{Success: true}, nil
+}
+
+func (s *Ethereum) BanPeer(ctx context.Context, req *remote.BanPeerRequest) (*remote.BanPeerReply, error) {
+	for _, sentryClient := range s.sentriesClient.Sentries() {
+		_, err := sentryClient.BanPeer(ctx, &proto_sentry.BanPeerRequest{Id: req.Id, DurationSeconds: req.DurationSeconds})
+		if err != nil {
+			return nil, fmt.Errorf("ethereum backend MultiClient.BanPeer error: %w", err)
+		}
+	}
+	return &remote.BanPeerReply{Success: true}, nil
+}
+
+func (s *Ethereum) UnbanPeer(ctx context.Context, req *remote.UnbanPeerRequest) (*remote.UnbanPeerReply, error) {
+	for _, sentryClient := range s.sentriesClient.Sentries() {
+		_, err := sentryClient.UnbanPeer(ctx, &proto_sentry.UnbanPeerRequest{Id: req.Id})
+		if err != nil {
+			return nil, fmt.Errorf("ethereum backend MultiClient.UnbanPeer error: %w", err)
+		}
+	}
+	return &remote.UnbanPeerReply{Success: true}, nil
+}
+
+// BannedPeers merges the bans of all sentries, a node banned by several of them is reported
+// with the latest expiry.
+func (s *Ethereum) BannedPeers(ctx context.Context) (*remote.BannedPeersReply, error) {
+	var reply remote.BannedPeersReply
+	seen := map[libcommon.Hash]*remote.BannedPeer{}
+	for _, sentryClient := range s.sentriesClient.Sentries() {
+		banned, err := sentryClient.BannedPeers(ctx, &emptypb.Empty{})
+		if err != nil {
+			return nil, fmt.Errorf("ethereum backend MultiClient.BannedPeers error: %w", err)
+		}
+		for _, peer := range banned.Peers {
+			id := gointerfaces.ConvertH256ToHash(peer.Id)
+			if prev, ok := seen[id]; ok {
+				prev.Until = max(prev.Until, peer.Until)
+				continue
+			}
+			p := &remote.BannedPeer{Id: peer.Id, Until: peer.Until}
+			seen[id] = p
+			reply.Peers = append(reply.Peers, p)
+		}
+	}
+	return &reply, nil
+}
+
+// PeerEvents forwards the peer events of all sentries to send until the context is cancelled
+// or one of the sentry streams fails.
+func (s *Ethereum) PeerEvents(ctx context.Context, send func(*remote.PeerEvent) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	events := make(chan *remote.PeerEvent)
+	for _, sentryClient := range s.sentriesClient.Sentries() {
+		sentryClient := sentryClient
+		g.Go(func() error {
+			stream, err := sentryClient.PeerEvents(ctx, &proto_sentry.PeerEventsRequest{})
+			if err != nil {
+				return fmt.Errorf("ethereum backend MultiClient.PeerEvents error: %w", err)
+			}
+			for {
+				event, err := stream.Recv()
+				if err != nil {
+					return err
+				}
+				select {
+				case events <- &remote.PeerEvent{PeerId: event.PeerId, EventId: remote.PeerEvent_PeerEventId(event.EventId)}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		})
+	}
+	g.Go(func() error {
+		for {
+			select {
+			case event := <-events:
+				if err := send(event); err != nil {
+					return err
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+	return g.Wait()
+}
+
 // Protocols returns all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -1430,6 +1612,11 @@ func (s *Ethereum) Stop() error {
 			s.logger.Error("silkworm.Close error", "err", err)
 		}
 	}
+	if closer, ok := s.liveTracer.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			s.logger.Error("live tracer close error", "err", err)
+		}
+	}
 
 	return nil
 }
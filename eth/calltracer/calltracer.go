@@ -60,6 +60,23 @@ func (ct *CallTracer) CaptureEnd(output []byte, usedGas uint64, err error) {
 func (ct *CallTracer) CaptureExit(output []byte, usedGas uint64, err error) {
 }
 
+// Fork returns an empty tracer capturing the calls of a transaction executed in parallel
+// with the others of the block, to be merged back with Join.
+func (ct *CallTracer) Fork() vm.EVMLogger {
+	return NewCallTracer()
+}
+
+// Join merges the calls captured by a tracer returned by Fork.
+func (ct *CallTracer) Join(forked vm.EVMLogger) {
+	other := forked.(*CallTracer)
+	for addr := range other.froms {
+		ct.froms[addr] = struct{}{}
+	}
+	for addr, created := range other.tos {
+		ct.tos[addr] = ct.tos[addr] || created
+	}
+}
+
 func (ct *CallTracer) WriteToDb(tx kv.StatelessWriteTx, block *types.Block, vmConfig vm.Config) error {
 	ct.tos[block.Coinbase()] = false
 	for _, uncle := range block.Uncles() {
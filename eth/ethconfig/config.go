@@ -183,6 +183,10 @@ type Config struct {
 	// for nodes to connect to.
 	EthDiscoveryURLs []string
 
+	// PortalHistory serves the block history to the Portal history network
+	// over the V5 discovery.
+	PortalHistory bool
+
 	Prune     prune.Mode
 	BatchSize datasize.ByteSize // Batch size for execution stage
 
@@ -261,6 +265,10 @@ type Config struct {
 	SilkwormSentry    bool
 
 	DisableTxPoolGossip bool
+
+	// Name and JSON config of the tracer to run during block execution, see eth/tracers/live
+	LiveTracer       string
+	LiveTracerConfig string
 }
 
 type Sync struct {
@@ -275,6 +283,9 @@ type Sync struct {
 	PruneLimit                 int //the maxumum records to delete from the DB during pruning
 	BreakAfterStage            string
 	LoopBlockLimit             uint
+	// ParallelExec executes the transactions of the blocks near the tip in parallel,
+	// re-executing those conflicting with the previous ones
+	ParallelExec bool
 
 	UploadLocation   string
 	UploadFrom       rpc.BlockNumber
@@ -22,6 +22,7 @@ import (
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
 	"github.com/ledgerwatch/erigon/core/forkid"
+	"github.com/ledgerwatch/erigon/p2p/enode"
 	"github.com/ledgerwatch/erigon/p2p/enr"
 	"github.com/ledgerwatch/erigon/rlp"
 )
@@ -56,3 +57,18 @@ func LoadENRForkID(r *enr.Record) (*forkid.ID, error) {
 	}
 	return &entry.ForkID, nil
 }
+
+// NewNodeFilter returns a discovery filter rejecting the nodes which advertise the `eth`
+// protocol with a fork ID incompatible with the local chain. The nodes without an `eth`
+// entry, e.g. the ones whose record could not be fetched, are let through and checked
+// by the handshake. The filter is obtained from forkFilter for every node, so that it
+// follows the local head.
+func NewNodeFilter(forkFilter func() forkid.Filter) func(*enode.Node) bool {
+	return func(n *enode.Node) bool {
+		var entry enrEntry
+		if err := n.Load(&entry); err != nil {
+			return enr.IsNotFound(err)
+		}
+		return forkFilter()(entry.ForkID) == nil
+	}
+}
@@ -0,0 +1,42 @@
+package eth
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/forkid"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+)
+
+func TestNodeFilter(t *testing.T) {
+	forks := []uint64{10, 20}
+	genesis := libcommon.Hash{1}
+	head := uint64(15)
+	filter := NewNodeFilter(func() forkid.Filter {
+		return forkid.NewFilterFromForks(forks, nil, genesis, head, 0)
+	})
+
+	node := func(entry enr.Entry) *enode.Node {
+		var r enr.Record
+		if entry != nil {
+			r.Set(entry)
+		}
+		return enode.SignNull(&r, enode.ID{1})
+	}
+
+	require.True(t, filter(node(CurrentENREntryFromForks(forks, nil, genesis, head, 0))), "same head")
+	require.True(t, filter(node(CurrentENREntryFromForks(forks, nil, genesis, 5, 0))), "remote syncing")
+	require.True(t, filter(node(CurrentENREntryFromForks(forks, nil, genesis, 25, 0))), "local syncing")
+	require.False(t, filter(node(CurrentENREntryFromForks(forks, nil, libcommon.Hash{2}, head, 0))), "other chain")
+	require.False(t, filter(node(CurrentENREntryFromForks([]uint64{10, 30}, nil, genesis, 35, 0))), "other fork schedule")
+	require.True(t, filter(node(nil)), "no eth entry")
+
+	// The filter follows the head, the node which did not upgrade becomes stale
+	stale := node(CurrentENREntryFromForks([]uint64{10}, nil, genesis, head, 0))
+	require.True(t, filter(stale))
+	head = 25
+	require.False(t, filter(stale))
+}
@@ -22,6 +22,7 @@ import (
 	"math/big"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
 	"github.com/ledgerwatch/erigon-lib/direct"
 	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
 	rlp2 "github.com/ledgerwatch/erigon-lib/rlp"
@@ -35,6 +36,7 @@ var ProtocolToString = map[uint]string{
 	direct.ETH66: "eth66",
 	direct.ETH67: "eth67",
 	direct.ETH68: "eth68",
+	direct.ETH69: "eth69",
 }
 
 // ProtocolName is the official short name of the `eth` protocol used during
@@ -64,6 +66,9 @@ const (
 	NewPooledTransactionHashesMsg = 0x08
 	GetPooledTransactionsMsg      = 0x09
 	PooledTransactionsMsg         = 0x0a
+
+	// Protocol messages introduced in eth/69
+	BlockRangeUpdateMsg = 0x11
 )
 
 var ToProto = map[uint]map[uint64]proto_sentry.MessageId{
@@ -111,6 +116,20 @@ var ToProto = map[uint]map[uint64]proto_sentry.MessageId{
 		GetPooledTransactionsMsg:      proto_sentry.MessageId_GET_POOLED_TRANSACTIONS_66,
 		PooledTransactionsMsg:         proto_sentry.MessageId_POOLED_TRANSACTIONS_66,
 	},
+	direct.ETH69: {
+		GetBlockHeadersMsg:            proto_sentry.MessageId_GET_BLOCK_HEADERS_66,
+		BlockHeadersMsg:               proto_sentry.MessageId_BLOCK_HEADERS_66,
+		GetBlockBodiesMsg:             proto_sentry.MessageId_GET_BLOCK_BODIES_66,
+		BlockBodiesMsg:                proto_sentry.MessageId_BLOCK_BODIES_66,
+		GetReceiptsMsg:                proto_sentry.MessageId_GET_RECEIPTS_66,
+		ReceiptsMsg:                   proto_sentry.MessageId_RECEIPTS_66, // Converted to and from the eth/69 encoding by the sentry
+		NewBlockHashesMsg:             proto_sentry.MessageId_NEW_BLOCK_HASHES_66,
+		NewBlockMsg:                   proto_sentry.MessageId_NEW_BLOCK_66,
+		TransactionsMsg:               proto_sentry.MessageId_TRANSACTIONS_66,
+		NewPooledTransactionHashesMsg: proto_sentry.MessageId_NEW_POOLED_TRANSACTION_HASHES_68,
+		GetPooledTransactionsMsg:      proto_sentry.MessageId_GET_POOLED_TRANSACTIONS_66,
+		PooledTransactionsMsg:         proto_sentry.MessageId_POOLED_TRANSACTIONS_66,
+	},
 }
 
 var FromProto = map[uint]map[proto_sentry.MessageId]uint64{
@@ -158,6 +177,20 @@ var FromProto = map[uint]map[proto_sentry.MessageId]uint64{
 		proto_sentry.MessageId_GET_POOLED_TRANSACTIONS_66:       GetPooledTransactionsMsg,
 		proto_sentry.MessageId_POOLED_TRANSACTIONS_66:           PooledTransactionsMsg,
 	},
+	direct.ETH69: {
+		proto_sentry.MessageId_GET_BLOCK_HEADERS_66:             GetBlockHeadersMsg,
+		proto_sentry.MessageId_BLOCK_HEADERS_66:                 BlockHeadersMsg,
+		proto_sentry.MessageId_GET_BLOCK_BODIES_66:              GetBlockBodiesMsg,
+		proto_sentry.MessageId_BLOCK_BODIES_66:                  BlockBodiesMsg,
+		proto_sentry.MessageId_GET_RECEIPTS_66:                  GetReceiptsMsg,
+		proto_sentry.MessageId_RECEIPTS_66:                      ReceiptsMsg,
+		proto_sentry.MessageId_NEW_BLOCK_HASHES_66:              NewBlockHashesMsg,
+		proto_sentry.MessageId_NEW_BLOCK_66:                     NewBlockMsg,
+		proto_sentry.MessageId_TRANSACTIONS_66:                  TransactionsMsg,
+		proto_sentry.MessageId_NEW_POOLED_TRANSACTION_HASHES_68: NewPooledTransactionHashesMsg,
+		proto_sentry.MessageId_GET_POOLED_TRANSACTIONS_66:       GetPooledTransactionsMsg,
+		proto_sentry.MessageId_POOLED_TRANSACTIONS_66:           PooledTransactionsMsg,
+	},
 }
 
 // Packet represents a p2p message in the `eth` protocol.
@@ -176,6 +209,26 @@ type StatusPacket struct {
 	ForkID          forkid.ID
 }
 
+// StatusPacket69 is the network packet for the status message for eth/69 and later.
+// The total difficulty is gone, instead the peer announces the range of blocks it can serve.
+type StatusPacket69 struct {
+	ProtocolVersion uint32
+	NetworkID       uint64
+	Genesis         libcommon.Hash
+	ForkID          forkid.ID
+	EarliestBlock   uint64
+	LatestBlock     uint64
+	LatestBlockHash libcommon.Hash
+}
+
+// BlockRangeUpdatePacket is the eth/69 announcement of a change in the range of blocks
+// the peer is able to serve, e.g. after a new head or after history expiry.
+type BlockRangeUpdatePacket struct {
+	EarliestBlock   uint64
+	LatestBlock     uint64
+	LatestBlockHash libcommon.Hash
+}
+
 // NewBlockHashesPacket is the network packet for the block announcements.
 type NewBlockHashesPacket []struct {
 	Hash   libcommon.Hash // Hash of one particular block being announced
@@ -356,18 +409,19 @@ type BlockBodiesRLPPacket66 struct {
 	BlockBodiesRLPPacket
 }
 
-// Unpack retrieves the transactions, uncles, and withdrawals from the range packet and returns
+// Unpack retrieves the transactions, uncles, withdrawals and requests from the range packet and returns
 // them in a split flat format that's more consistent with the internal data structures.
-func (p *BlockRawBodiesPacket) Unpack() ([][][]byte, [][]*types.Header, []types.Withdrawals) {
+func (p *BlockRawBodiesPacket) Unpack() ([][][]byte, [][]*types.Header, []types.Withdrawals, []types.Requests) {
 	var (
 		txSet         = make([][][]byte, len(*p))
 		uncleSet      = make([][]*types.Header, len(*p))
 		withdrawalSet = make([]types.Withdrawals, len(*p))
+		requestSet    = make([]types.Requests, len(*p))
 	)
 	for i, body := range *p {
-		txSet[i], uncleSet[i], withdrawalSet[i] = body.Transactions, body.Uncles, body.Withdrawals
+		txSet[i], uncleSet[i], withdrawalSet[i], requestSet[i] = body.Transactions, body.Uncles, body.Withdrawals, body.Requests
 	}
-	return txSet, uncleSet, withdrawalSet
+	return txSet, uncleSet, withdrawalSet, requestSet
 }
 
 // GetReceiptsPacket represents a block receipts query.
@@ -388,6 +442,98 @@ type ReceiptsPacket66 struct {
 	ReceiptsPacket
 }
 
+// Receipt69 is the eth/69 network encoding of a receipt. The transaction type is a
+// list element instead of an envelope, and the bloom is dropped since it can be
+// recomputed from the logs.
+type Receipt69 struct {
+	TxType            uint8
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Logs              []*types.Log
+}
+
+// NewReceipt69 converts the consensus receipt into its eth/69 network encoding.
+func NewReceipt69(r *types.Receipt) *Receipt69 {
+	status := r.PostState
+	if len(status) == 0 {
+		status = []byte{}
+		if r.Status == types.ReceiptStatusSuccessful {
+			status = []byte{0x01}
+		}
+	}
+	return &Receipt69{
+		TxType:            r.Type,
+		PostStateOrStatus: status,
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		Logs:              r.Logs,
+	}
+}
+
+// Receipt restores the consensus receipt, recomputing its bloom.
+func (r *Receipt69) Receipt() (*types.Receipt, error) {
+	receipt := &types.Receipt{
+		Type:              r.TxType,
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		Logs:              r.Logs,
+	}
+	switch {
+	case len(r.PostStateOrStatus) == length.Hash:
+		receipt.PostState = r.PostStateOrStatus
+	case len(r.PostStateOrStatus) == 0:
+		receipt.Status = types.ReceiptStatusFailed
+	case len(r.PostStateOrStatus) == 1 && r.PostStateOrStatus[0] == 0x01:
+		receipt.Status = types.ReceiptStatusSuccessful
+	default:
+		return nil, fmt.Errorf("invalid receipt status %x", r.PostStateOrStatus)
+	}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	return receipt, nil
+}
+
+// ReceiptsPacket69 is the network packet for block receipts distribution over eth/69.
+type ReceiptsPacket69 struct {
+	RequestId uint64
+	Receipts  [][]*Receipt69
+}
+
+// ReceiptsPacket66To69 re-encodes a receipts response from the eth/66 encoding,
+// as produced by the core, into the eth/69 one.
+func ReceiptsPacket66To69(data []byte) ([]byte, error) {
+	var packet ReceiptsPacket66
+	if err := rlp.DecodeBytes(data, &packet); err != nil {
+		return nil, fmt.Errorf("decode eth/66 receipts: %w", err)
+	}
+	out := ReceiptsPacket69{RequestId: packet.RequestId, Receipts: make([][]*Receipt69, len(packet.ReceiptsPacket))}
+	for i, receipts := range packet.ReceiptsPacket {
+		out.Receipts[i] = make([]*Receipt69, len(receipts))
+		for j, receipt := range receipts {
+			out.Receipts[i][j] = NewReceipt69(receipt)
+		}
+	}
+	return rlp.EncodeToBytes(&out)
+}
+
+// ReceiptsPacket69To66 re-encodes a receipts response received from an eth/69 peer
+// into the eth/66 encoding understood by the core.
+func ReceiptsPacket69To66(data []byte) ([]byte, error) {
+	var packet ReceiptsPacket69
+	if err := rlp.DecodeBytes(data, &packet); err != nil {
+		return nil, fmt.Errorf("decode eth/69 receipts: %w", err)
+	}
+	out := ReceiptsPacket66{RequestId: packet.RequestId, ReceiptsPacket: make(ReceiptsPacket, len(packet.Receipts))}
+	for i, receipts := range packet.Receipts {
+		out.ReceiptsPacket[i] = make([]*types.Receipt, len(receipts))
+		for j, receipt := range receipts {
+			r, err := receipt.Receipt()
+			if err != nil {
+				return nil, err
+			}
+			out.ReceiptsPacket[i][j] = r
+		}
+	}
+	return rlp.EncodeToBytes(&out)
+}
+
 // ReceiptsRLPPacket is used for receipts, when we already have it encoded
 type ReceiptsRLPPacket []rlp.RawValue
 
@@ -400,6 +546,12 @@ type ReceiptsRLPPacket66 struct {
 func (*StatusPacket) Name() string { return "Status" }
 func (*StatusPacket) Kind() byte   { return StatusMsg }
 
+func (*StatusPacket69) Name() string { return "Status" }
+func (*StatusPacket69) Kind() byte   { return StatusMsg }
+
+func (*BlockRangeUpdatePacket) Name() string { return "BlockRangeUpdate" }
+func (*BlockRangeUpdatePacket) Kind() byte   { return BlockRangeUpdateMsg }
+
 func (*NewBlockHashesPacket) Name() string { return "NewBlockHashes" }
 func (*NewBlockHashesPacket) Kind() byte   { return NewBlockHashesMsg }
 
@@ -229,3 +229,69 @@ func TestEth66Messages(t *testing.T) {
 		}
 	}
 }
+
+// TestEth69Receipts tests the conversion of receipts between the eth/66 and eth/69 encodings
+func TestEth69Receipts(t *testing.T) {
+	receipts := []*types.Receipt{
+		{
+			Type:              types.LegacyTxType,
+			Status:            types.ReceiptStatusFailed,
+			CumulativeGasUsed: 1,
+			Logs: []*types.Log{
+				{
+					Address: libcommon.BytesToAddress([]byte{0x11}),
+					Topics:  []libcommon.Hash{libcommon.HexToHash("dead"), libcommon.HexToHash("beef")},
+					Data:    []byte{0x01, 0x00, 0xff},
+				},
+			},
+		},
+		{
+			Type:              types.DynamicFeeTxType,
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 2,
+		},
+		{
+			Type:              types.LegacyTxType,
+			PostState:         libcommon.HexToHash("feedbeef").Bytes(),
+			CumulativeGasUsed: 3,
+		},
+	}
+	for _, r := range receipts {
+		r.Bloom = types.CreateBloom(types.Receipts{r})
+	}
+	data66, err := rlp.EncodeToBytes(&ReceiptsPacket66{1111, ReceiptsPacket([][]*types.Receipt{receipts, {}})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data69, err := ReceiptsPacket66To69(data66)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data69) >= len(data66) {
+		t.Errorf("eth/69 receipts are not smaller: %d >= %d", len(data69), len(data66))
+	}
+	var packet69 ReceiptsPacket69
+	if err := rlp.DecodeBytes(data69, &packet69); err != nil {
+		t.Fatal(err)
+	}
+	if packet69.RequestId != 1111 || len(packet69.Receipts) != 2 || len(packet69.Receipts[0]) != len(receipts) || len(packet69.Receipts[1]) != 0 {
+		t.Fatalf("unexpected eth/69 packet: %+v", packet69)
+	}
+	if packet69.Receipts[0][1].TxType != types.DynamicFeeTxType {
+		t.Errorf("tx type not preserved: have %d, want %d", packet69.Receipts[0][1].TxType, types.DynamicFeeTxType)
+	}
+
+	back, err := ReceiptsPacket69To66(data69)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(back, data66) {
+		t.Errorf("round trip mismatch, have\n\t%x\nwant\n\t%x", back, data66)
+	}
+
+	bad := &Receipt69{PostStateOrStatus: []byte{0x02}}
+	if _, err := bad.Receipt(); err == nil {
+		t.Errorf("invalid status accepted")
+	}
+}
@@ -0,0 +1,404 @@
+package snap
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/commitment"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/dbutils"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcfg"
+
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+const (
+	// softResponseLimit is the target maximum size of replies to data retrievals.
+	softResponseLimit = 2 * 1024 * 1024
+
+	// estAccountSize is the approximate size of an account hash and its slim body.
+	estAccountSize = length.Hash + 104
+
+	// maxCodeLookups is the maximum number of bytecodes to serve. This number is
+	// there to limit the number of disk lookups.
+	maxCodeLookups = 1024
+
+	// maxTrieNodeLookups is the maximum number of state trie nodes to serve. This
+	// number is there to limit the number of disk lookups.
+	maxTrieNodeLookups = 1024
+)
+
+// maxHash is the upper bound of the storage ranges without the limit.
+var maxHash = libcommon.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+// storageKeyNibbles is the length of the nibble encoded prefix of the storage
+// trie keys - account hash and incarnation.
+const storageKeyNibbles = 2 * (length.Hash + length.Incarnation)
+
+// responseLimit caps the soft limit requested by the peer.
+func responseLimit(requested uint64) uint64 {
+	if requested > softResponseLimit {
+		return softResponseLimit
+	}
+	return requested
+}
+
+// isServedRoot reports whether the given root is the root of the state which
+// is served: the hashed state of the block the intermediate hashes were last
+// computed for. Erigon3 serves the recent states from the commitment, see
+// servedTrieV3.
+func isServedRoot(tx kv.Tx, root libcommon.Hash, blockReader services.HeaderReader) (bool, error) {
+	progress, err := stages.GetStageProgress(tx, stages.IntermediateHashes)
+	if err != nil {
+		return false, err
+	}
+	header, err := blockReader.HeaderByNumber(context.Background(), tx, progress)
+	if err != nil {
+		return false, err
+	}
+	return header != nil && header.Root == root, nil
+}
+
+// loadProofs computes the state root retaining the trie nodes on the paths to
+// the given keys. It reports false if the computed root is not the requested
+// one, which should not happen unless the hashed state is inconsistent.
+func loadProofs(tx kv.Tx, root libcommon.Hash, hexKeys [][]byte, quit <-chan struct{}) (*trie.ProofRetainer, bool, error) {
+	rl := trie.NewRetainList(0)
+	pr := trie.NewMultiProofRetainer(hexKeys, rl)
+	loader := trie.NewFlatDBTrieLoader("snap", rl, nil, nil, false)
+	loader.SetProofRetainer(pr)
+	computed, err := loader.CalcTrieRoot(tx, quit)
+	if err != nil {
+		return nil, false, err
+	}
+	return pr, computed == root, nil
+}
+
+func keyToNibbles(key []byte) []byte {
+	var nibbles []byte
+	hexutil.DecompressNibbles(key, &nibbles)
+	return nibbles
+}
+
+func storageKeyToNibbles(accHash libcommon.Hash, incarnation uint64, storageHash []byte) []byte {
+	key := make([]byte, length.Hash+length.Incarnation+len(storageHash))
+	copy(key, accHash[:])
+	binary.BigEndian.PutUint64(key[length.Hash:], incarnation)
+	copy(key[length.Hash+length.Incarnation:], storageHash)
+	return keyToNibbles(key)
+}
+
+// pathToNibbles decodes a compact encoded trie path.
+func pathToNibbles(path []byte) []byte {
+	nibbles := commitment.CompactedKeyToHex(path)
+	if len(nibbles) > 0 && nibbles[len(nibbles)-1] == 16 {
+		nibbles = nibbles[:len(nibbles)-1]
+	}
+	return nibbles
+}
+
+// appendProof appends the nodes which are not in the proof yet.
+func appendProof(proof [][]byte, seen map[string]struct{}, nodes [][]byte) [][]byte {
+	for _, node := range nodes {
+		if _, ok := seen[string(node)]; ok {
+			continue
+		}
+		seen[string(node)] = struct{}{}
+		proof = append(proof, node)
+	}
+	return proof
+}
+
+func readAccount(tx kv.Tx, accHash libcommon.Hash) (*accounts.Account, error) {
+	enc, err := tx.GetOne(kv.HashedAccounts, accHash[:])
+	if err != nil || len(enc) == 0 {
+		return nil, err
+	}
+	var acc accounts.Account
+	if err := acc.DecodeForStorage(enc); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func AnswerGetAccountRangeQuery(tx kv.Tx, query *GetAccountRangePacket, blockReader services.HeaderReader, quit <-chan struct{}) (*AccountRangePacket, error) {
+	response := &AccountRangePacket{RequestId: query.RequestId}
+	if historyV3, err := kvcfg.HistoryV3.Enabled(tx); err != nil {
+		return nil, err
+	} else if historyV3 {
+		r, err := servedTrieV3(tx, query.Root, blockReader)
+		if err != nil || r == nil {
+			return response, err
+		}
+		return answerGetAccountRangeQueryV3(r, query)
+	}
+	if ok, err := isServedRoot(tx, query.Root, blockReader); err != nil || !ok {
+		return response, err
+	}
+	limit := responseLimit(query.Bytes)
+
+	c, err := tx.Cursor(kv.HashedAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var (
+		size    uint64
+		hashes  []libcommon.Hash
+		accs    []accounts.Account
+		hexKeys = [][]byte{keyToNibbles(query.Origin[:])}
+	)
+	for k, v, err := c.Seek(query.Origin[:]); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return nil, err
+		}
+		var acc accounts.Account
+		if err := acc.DecodeForStorage(v); err != nil {
+			return nil, fmt.Errorf("decoding account %x: %w", k, err)
+		}
+		hashes = append(hashes, libcommon.BytesToHash(k))
+		accs = append(accs, acc)
+		hexKeys = append(hexKeys, keyToNibbles(k))
+		size += estAccountSize
+		if bytes.Compare(k, query.Limit[:]) >= 0 || size >= limit {
+			break
+		}
+	}
+
+	pr, ok, err := loadProofs(tx, query.Root, hexKeys, quit)
+	if err != nil || !ok {
+		return response, err
+	}
+	for i := range accs {
+		storageRoot, ok := pr.StorageRoot(hexKeys[i+1])
+		if !ok {
+			return nil, fmt.Errorf("did not find storage root of account %x", hashes[i])
+		}
+		body, err := SlimAccountRLP(&accs[i], storageRoot)
+		if err != nil {
+			return nil, err
+		}
+		response.Accounts = append(response.Accounts, &AccountData{Hash: hashes[i], Body: body})
+	}
+
+	// The proofs of the first and the last accounts share the nodes near the root
+	seen := map[string]struct{}{}
+	response.Proof = appendProof(response.Proof, seen, pr.ProofNodes(hexKeys[0], 0))
+	if len(accs) > 0 {
+		response.Proof = appendProof(response.Proof, seen, pr.ProofNodes(hexKeys[len(hexKeys)-1], 0))
+	}
+	return response, nil
+}
+
+func AnswerGetStorageRangesQuery(tx kv.Tx, query *GetStorageRangesPacket, blockReader services.HeaderReader, quit <-chan struct{}) (*StorageRangesPacket, error) {
+	response := &StorageRangesPacket{RequestId: query.RequestId}
+	if historyV3, err := kvcfg.HistoryV3.Enabled(tx); err != nil {
+		return nil, err
+	} else if historyV3 {
+		r, err := servedTrieV3(tx, query.Root, blockReader)
+		if err != nil || r == nil {
+			return response, err
+		}
+		return answerGetStorageRangesQueryV3(r, query)
+	}
+	if ok, err := isServedRoot(tx, query.Root, blockReader); err != nil || !ok {
+		return response, err
+	}
+	limit := responseLimit(query.Bytes)
+	// A storage range which is already started may exceed the limit a bit,
+	// rather than being cut and proven
+	hardLimit := limit + limit/10
+
+	c, err := tx.CursorDupSort(kv.HashedStorage)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var size uint64
+	for i, accHash := range query.Accounts {
+		if size >= limit {
+			break
+		}
+		// Origin and limit apply only to the first account
+		var origin libcommon.Hash
+		limitHash := maxHash
+		if i == 0 {
+			if len(query.Origin) > 0 {
+				origin = libcommon.BytesToHash(query.Origin)
+			}
+			if len(query.Limit) > 0 {
+				limitHash = libcommon.BytesToHash(query.Limit)
+			}
+		}
+		acc, err := readAccount(tx, accHash)
+		if err != nil {
+			return nil, err
+		}
+		if acc == nil {
+			break
+		}
+
+		var (
+			slots []*StorageData
+			abort bool
+		)
+		prefix := dbutils.GenerateStoragePrefix(accHash[:], acc.Incarnation)
+		for v, err := c.SeekBothRange(prefix, origin[:]); v != nil; _, v, err = c.NextDup() {
+			if err != nil {
+				return nil, err
+			}
+			if size >= hardLimit {
+				abort = true
+				break
+			}
+			body, err := rlp.EncodeToBytes(v[length.Hash:])
+			if err != nil {
+				return nil, err
+			}
+			slots = append(slots, &StorageData{Hash: libcommon.BytesToHash(v[:length.Hash]), Body: body})
+			size += uint64(length.Hash + len(body))
+			if bytes.Compare(v[:length.Hash], limitHash[:]) >= 0 {
+				break
+			}
+		}
+		if len(slots) > 0 {
+			response.Slots = append(response.Slots, slots)
+		}
+
+		// The proofs are needed only when the storage range is not complete -
+		// it starts at a non-zero hash or it was capped
+		if origin != (libcommon.Hash{}) || (abort && len(slots) > 0) {
+			hexKeys := [][]byte{storageKeyToNibbles(accHash, acc.Incarnation, origin[:])}
+			if len(slots) > 0 {
+				hexKeys = append(hexKeys, storageKeyToNibbles(accHash, acc.Incarnation, slots[len(slots)-1].Hash[:]))
+			}
+			pr, ok, err := loadProofs(tx, query.Root, hexKeys, quit)
+			if err != nil || !ok {
+				return &StorageRangesPacket{RequestId: query.RequestId}, err
+			}
+			seen := map[string]struct{}{}
+			for _, hexKey := range hexKeys {
+				response.Proof = appendProof(response.Proof, seen, pr.ProofNodes(hexKey, storageKeyNibbles))
+			}
+			break
+		}
+	}
+	return response, nil
+}
+
+func AnswerGetByteCodesQuery(tx kv.Tx, query *GetByteCodesPacket) (*ByteCodesPacket, error) {
+	response := &ByteCodesPacket{RequestId: query.RequestId}
+	limit := responseLimit(query.Bytes)
+	hashes := query.Hashes
+	if len(hashes) > maxCodeLookups {
+		hashes = hashes[:maxCodeLookups]
+	}
+
+	var size uint64
+	for _, hash := range hashes {
+		if hash == trie.EmptyCodeHash {
+			// Peers should not request the empty code, but if they do, serve it
+			response.Codes = append(response.Codes, []byte{})
+			continue
+		}
+		code, err := tx.GetOne(kv.Code, hash[:])
+		if err != nil {
+			return nil, err
+		}
+		if len(code) == 0 {
+			continue
+		}
+		response.Codes = append(response.Codes, libcommon.CopyBytes(code))
+		size += uint64(len(code))
+		if size >= limit {
+			break
+		}
+	}
+	return response, nil
+}
+
+func AnswerGetTrieNodesQuery(tx kv.Tx, query *GetTrieNodesPacket, blockReader services.HeaderReader, quit <-chan struct{}) (*TrieNodesPacket, error) {
+	response := &TrieNodesPacket{RequestId: query.RequestId}
+	if historyV3, err := kvcfg.HistoryV3.Enabled(tx); err != nil {
+		return nil, err
+	} else if historyV3 {
+		r, err := servedTrieV3(tx, query.Root, blockReader)
+		if err != nil || r == nil {
+			return response, err
+		}
+		return answerGetTrieNodesQueryV3(r, query)
+	}
+	if ok, err := isServedRoot(tx, query.Root, blockReader); err != nil || !ok {
+		return response, err
+	}
+	limit := responseLimit(query.Bytes)
+
+	// Every requested path counts as a lookup, the nodes of the storage tries are
+	// addressed by the account hash and incarnation, as in the hashed state
+	var hexKeys [][]byte
+	for _, pathset := range query.Paths {
+		if len(hexKeys) >= maxTrieNodeLookups {
+			break
+		}
+		switch len(pathset) {
+		case 0:
+			return nil, fmt.Errorf("empty trie node path set")
+		case 1:
+			hexKeys = append(hexKeys, pathToNibbles(pathset[0]))
+		default:
+			accHash := libcommon.BytesToHash(pathset[0])
+			acc, err := readAccount(tx, accHash)
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range pathset[1:] {
+				if len(hexKeys) >= maxTrieNodeLookups {
+					break
+				}
+				if acc == nil {
+					// The nodes of missing storage tries are served as empty
+					hexKeys = append(hexKeys, nil)
+					continue
+				}
+				hexKey := storageKeyToNibbles(accHash, acc.Incarnation, nil)
+				hexKeys = append(hexKeys, append(hexKey, pathToNibbles(path)...))
+			}
+		}
+	}
+
+	var retained [][]byte
+	for _, hexKey := range hexKeys {
+		if hexKey != nil {
+			retained = append(retained, hexKey)
+		}
+	}
+	pr, ok, err := loadProofs(tx, query.Root, retained, quit)
+	if err != nil || !ok {
+		return response, err
+	}
+
+	var size uint64
+	for _, hexKey := range hexKeys {
+		var node []byte
+		if hexKey != nil {
+			node = pr.Node(hexKey)
+		}
+		response.Nodes = append(response.Nodes, node)
+		size += uint64(len(node))
+		if size >= limit {
+			break
+		}
+	}
+	return response, nil
+}
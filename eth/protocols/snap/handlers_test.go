@@ -0,0 +1,273 @@
+package snap
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/dbutils"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+type testHeaderReader struct {
+	services.HeaderReader
+	header *types.Header
+}
+
+func (r testHeaderReader) HeaderByNumber(_ context.Context, _ kv.Getter, blockNum uint64) (*types.Header, error) {
+	if blockNum != r.header.Number.Uint64() {
+		return nil, nil
+	}
+	return r.header, nil
+}
+
+type testState struct {
+	root        libcommon.Hash
+	accTrie     *trie.Trie
+	hashes      []libcommon.Hash // sorted account hashes
+	accounts    map[libcommon.Hash]*accounts.Account
+	storage     map[libcommon.Hash]*trie.Trie
+	slots       map[libcommon.Hash][]libcommon.Hash // sorted slot hashes
+	code        map[libcommon.Hash][]byte
+	blockReader services.HeaderReader
+}
+
+// seedTestState writes a hashed state of accounts, a few of which are
+// contracts with code and storage, and computes its root with an in-memory trie.
+func seedTestState(t *testing.T, tx kv.RwTx, accountCount, contractCount, slotCount int) *testState {
+	t.Helper()
+	rnd := rand.New(rand.NewSource(1)) // nolint: gosec
+	s := &testState{
+		accTrie:  trie.New(libcommon.Hash{}),
+		accounts: map[libcommon.Hash]*accounts.Account{},
+		storage:  map[libcommon.Hash]*trie.Trie{},
+		slots:    map[libcommon.Hash][]libcommon.Hash{},
+		code:     map[libcommon.Hash][]byte{},
+	}
+	for i := 0; i < accountCount; i++ {
+		var hash libcommon.Hash
+		rnd.Read(hash[:])
+		acc := accounts.NewAccount()
+		acc.Initialised = true
+		acc.Nonce = uint64(i)
+		acc.Balance.SetUint64(uint64(rnd.Int63()))
+		acc.Root = trie.EmptyRoot
+		if i < contractCount {
+			code := make([]byte, 64+i)
+			rnd.Read(code)
+			acc.CodeHash = crypto.Keccak256Hash(code)
+			acc.Incarnation = 1
+			s.code[acc.CodeHash] = code
+			require.NoError(t, tx.Put(kv.Code, acc.CodeHash[:], code))
+
+			st := trie.New(libcommon.Hash{})
+			prefix := dbutils.GenerateStoragePrefix(hash[:], acc.Incarnation)
+			for j := 0; j < slotCount; j++ {
+				var slotHash libcommon.Hash
+				rnd.Read(slotHash[:])
+				value := uint256.NewInt(uint64(j + 1)).Bytes()
+				enc, err := rlp.EncodeToBytes(value)
+				require.NoError(t, err)
+				st.Update(slotHash[:], enc)
+				require.NoError(t, tx.Put(kv.HashedStorage, append(libcommon.CopyBytes(prefix), slotHash[:]...), value))
+				s.slots[hash] = append(s.slots[hash], slotHash)
+			}
+			sort.Slice(s.slots[hash], func(i, j int) bool { return s.slots[hash][i].String() < s.slots[hash][j].String() })
+			s.storage[hash] = st
+			acc.Root = st.Hash()
+		}
+		enc := make([]byte, acc.EncodingLengthForStorage())
+		acc.EncodeForStorage(enc)
+		require.NoError(t, tx.Put(kv.HashedAccounts, hash[:], enc))
+		s.accTrie.UpdateAccount(hash[:], &acc)
+		s.accounts[hash] = &acc
+		s.hashes = append(s.hashes, hash)
+	}
+	sort.Slice(s.hashes, func(i, j int) bool { return s.hashes[i].String() < s.hashes[j].String() })
+	s.root = s.accTrie.Hash()
+
+	require.NoError(t, stages.SaveStageProgress(tx, stages.IntermediateHashes, 1))
+	s.blockReader = testHeaderReader{header: &types.Header{Number: libcommon.Big1, Root: s.root}}
+	return s
+}
+
+// proofOf returns the deduplicated nodes of the proofs of the given keys.
+func proofOf(t *testing.T, tr *trie.Trie, keys ...libcommon.Hash) [][]byte {
+	t.Helper()
+	var proof [][]byte
+	seen := map[string]struct{}{}
+	for _, key := range keys {
+		nodes, err := tr.Prove(key[:], 0, false)
+		require.NoError(t, err)
+		proof = appendProof(proof, seen, nodes)
+	}
+	return proof
+}
+
+func TestAnswerGetAccountRangeQuery(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	s := seedTestState(t, tx, 500, 5, 10)
+
+	// The whole state fits into the response
+	resp, err := AnswerGetAccountRangeQuery(tx, &GetAccountRangePacket{RequestId: 1, Root: s.root, Limit: maxHash, Bytes: softResponseLimit}, s.blockReader, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), resp.RequestId)
+	require.Len(t, resp.Accounts, len(s.hashes))
+	for i, data := range resp.Accounts {
+		require.Equal(t, s.hashes[i], data.Hash)
+		expected, err := SlimAccountRLP(s.accounts[data.Hash], s.accounts[data.Hash].Root)
+		require.NoError(t, err)
+		require.Equal(t, expected, data.Body)
+	}
+	require.ElementsMatch(t, proofOf(t, s.accTrie, libcommon.Hash{}, s.hashes[len(s.hashes)-1]), resp.Proof)
+
+	// The range starting in the middle is capped by the requested size
+	origin := s.hashes[100]
+	origin[31]--
+	resp, err = AnswerGetAccountRangeQuery(tx, &GetAccountRangePacket{RequestId: 2, Root: s.root, Origin: origin, Limit: maxHash, Bytes: 10 * estAccountSize}, s.blockReader, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Accounts, 10)
+	require.Equal(t, s.hashes[100], resp.Accounts[0].Hash)
+	require.ElementsMatch(t, proofOf(t, s.accTrie, origin, s.hashes[109]), resp.Proof)
+
+	// The limit is the last account returned
+	resp, err = AnswerGetAccountRangeQuery(tx, &GetAccountRangePacket{RequestId: 3, Root: s.root, Limit: s.hashes[2], Bytes: softResponseLimit}, s.blockReader, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Accounts, 3)
+
+	// Unknown roots are not served
+	resp, err = AnswerGetAccountRangeQuery(tx, &GetAccountRangePacket{RequestId: 4, Root: libcommon.Hash{1}, Limit: maxHash, Bytes: softResponseLimit}, s.blockReader, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), resp.RequestId)
+	require.Empty(t, resp.Accounts)
+	require.Empty(t, resp.Proof)
+}
+
+func TestAnswerGetStorageRangesQuery(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	s := seedTestState(t, tx, 50, 50, 20)
+	contracts := s.hashes[:3]
+
+	// Complete storage ranges are served without proofs
+	resp, err := AnswerGetStorageRangesQuery(tx, &GetStorageRangesPacket{RequestId: 1, Root: s.root, Accounts: contracts, Bytes: softResponseLimit}, s.blockReader, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Slots, len(contracts))
+	for i, slots := range resp.Slots {
+		require.Len(t, slots, 20)
+		for j, slot := range slots {
+			require.Equal(t, s.slots[contracts[i]][j], slot.Hash)
+			value, ok := s.storage[contracts[i]].Get(slot.Hash[:])
+			require.True(t, ok)
+			require.Equal(t, value, slot.Body)
+		}
+	}
+	require.Empty(t, resp.Proof)
+
+	// The range of the first account starting in the middle is proven
+	origin := s.slots[contracts[0]][5]
+	resp, err = AnswerGetStorageRangesQuery(tx, &GetStorageRangesPacket{RequestId: 2, Root: s.root, Accounts: contracts, Origin: origin[:], Bytes: softResponseLimit}, s.blockReader, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Slots, 1)
+	require.Len(t, resp.Slots[0], 15)
+	require.ElementsMatch(t, proofOf(t, s.storage[contracts[0]], origin, s.slots[contracts[0]][19]), resp.Proof)
+
+	// The capped range is proven
+	resp, err = AnswerGetStorageRangesQuery(tx, &GetStorageRangesPacket{RequestId: 3, Root: s.root, Accounts: contracts, Bytes: 100}, s.blockReader, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Slots, 1)
+	last := resp.Slots[0][len(resp.Slots[0])-1].Hash
+	require.Less(t, len(resp.Slots[0]), 20)
+	require.ElementsMatch(t, proofOf(t, s.storage[contracts[0]], libcommon.Hash{}, last), resp.Proof)
+}
+
+func TestAnswerGetByteCodesQuery(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	s := seedTestState(t, tx, 10, 3, 0)
+
+	var hashes []libcommon.Hash
+	for hash := range s.code {
+		hashes = append(hashes, hash)
+	}
+	hashes = append(hashes, libcommon.Hash{1}, trie.EmptyCodeHash)
+	resp, err := AnswerGetByteCodesQuery(tx, &GetByteCodesPacket{RequestId: 1, Hashes: hashes, Bytes: softResponseLimit})
+	require.NoError(t, err)
+	require.Len(t, resp.Codes, 4) // the unknown code is skipped
+	for i := 0; i < 3; i++ {
+		require.Equal(t, s.code[hashes[i]], resp.Codes[i])
+	}
+	require.Empty(t, resp.Codes[3])
+
+	resp, err = AnswerGetByteCodesQuery(tx, &GetByteCodesPacket{RequestId: 2, Hashes: hashes, Bytes: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.Codes, 1)
+}
+
+func TestAnswerGetTrieNodesQuery(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	s := seedTestState(t, tx, 100, 1, 10)
+	contract := s.hashes[0]
+	for hash := range s.storage {
+		contract = hash
+	}
+
+	resp, err := AnswerGetTrieNodesQuery(tx, &GetTrieNodesPacket{
+		RequestId: 1,
+		Root:      s.root,
+		Paths: []TrieNodePathSet{
+			{{0x00}},                            // account trie root
+			{contract[:], {0x00}},               // storage trie root
+			{{0x1f}},                            // a node at the first level of the account trie
+			{libcommon.Hash{1}.Bytes(), {0x00}}, // storage root of a missing account
+		},
+		Bytes: softResponseLimit,
+	}, s.blockReader, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Nodes, 4)
+	require.Equal(t, s.root, crypto.Keccak256Hash(resp.Nodes[0]))
+	require.Equal(t, s.accounts[contract].Root, crypto.Keccak256Hash(resp.Nodes[1]))
+	require.NotEmpty(t, resp.Nodes[2])
+	require.Empty(t, resp.Nodes[3])
+}
+
+func TestServerAnswer(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	s := seedTestState(t, tx, 10, 3, 0)
+	require.NoError(t, tx.Commit())
+	server := NewServer(db, s.blockReader)
+
+	var hashes []libcommon.Hash
+	for hash := range s.code {
+		hashes = append(hashes, hash)
+	}
+	data, err := rlp.EncodeToBytes(&GetByteCodesPacket{RequestId: 7, Hashes: hashes, Bytes: softResponseLimit})
+	require.NoError(t, err)
+	packet, err := server.Answer(context.Background(), GetByteCodesMsg, data)
+	require.NoError(t, err)
+	require.Equal(t, byte(ByteCodesMsg), packet.Kind())
+	resp := packet.(*ByteCodesPacket)
+	require.Equal(t, uint64(7), resp.RequestId)
+	require.Len(t, resp.Codes, len(hashes))
+
+	// a malformed request is reported as invalid RLP so the sentry drops the peer
+	_, err = server.Answer(context.Background(), GetAccountRangeMsg, []byte{0x01})
+	require.True(t, rlp.IsInvalidRLPError(err))
+
+	_, err = server.Answer(context.Background(), AccountRangeMsg, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,262 @@
+package snap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ledgerwatch/erigon-lib/commitment"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/rawdbv3"
+	types2 "github.com/ledgerwatch/erigon-lib/types"
+
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/services"
+)
+
+// servedBlocksV3 is the number of the recent blocks the state of which is
+// served by Erigon3. Snap clients pivot on a block about 64 blocks behind the
+// head and keep requesting its state while the chain advances.
+const servedBlocksV3 = 128
+
+// stateAsOfV3 reads the commitment branches and the state as of the txNum.
+type stateAsOfV3 struct {
+	tx    kv.TemporalTx
+	txNum uint64
+}
+
+func (s *stateAsOfV3) GetBranch(prefix []byte) ([]byte, error) {
+	v, _, err := s.tx.DomainGetAsOf(kv.CommitmentDomain, prefix, nil, s.txNum)
+	return v, err
+}
+
+func (s *stateAsOfV3) GetAccount(plainKey []byte, cell *commitment.Cell) error {
+	enc, _, err := s.tx.DomainGetAsOf(kv.AccountsDomain, plainKey, nil, s.txNum)
+	if err != nil {
+		return err
+	}
+	cell.Nonce = 0
+	cell.Balance.Clear()
+	if len(enc) > 0 {
+		nonce, balance, _ := types2.DecodeAccountBytesV3(enc)
+		cell.Nonce = nonce
+		cell.Balance.Set(balance)
+	}
+	// The code hash is computed from the code, as it is for the commitment
+	code, _, err := s.tx.DomainGetAsOf(kv.CodeDomain, plainKey, nil, s.txNum)
+	if err != nil {
+		return err
+	}
+	if len(code) > 0 {
+		copy(cell.CodeHash[:], crypto.Keccak256(code))
+	} else {
+		cell.CodeHash = commitment.EmptyCodeHashArray
+	}
+	cell.Delete = len(enc) == 0 && len(code) == 0
+	return nil
+}
+
+func (s *stateAsOfV3) GetStorage(plainKey []byte, cell *commitment.Cell) error {
+	enc, _, err := s.tx.DomainGetAsOf(kv.StorageDomain, plainKey, nil, s.txNum)
+	if err != nil {
+		return err
+	}
+	cell.StorageLen = len(enc)
+	copy(cell.Storage[:], enc)
+	cell.Delete = cell.StorageLen == 0
+	return nil
+}
+
+func (s *stateAsOfV3) TempDir() string { return os.TempDir() }
+
+func (s *stateAsOfV3) PutBranch(prefix []byte, data []byte, prevData []byte) error {
+	return fmt.Errorf("the state served to snap peers is read-only")
+}
+
+// servedTrieV3 returns the reader of the state trie with the given root if it
+// is the root of one of the recent executed blocks, nil otherwise. The state
+// of the block is read from the history as of its last txNum. Erigon3 computes
+// the commitment at the end of the execution batches, the state of a block in
+// the middle of a batch has no branches of its own and it is not served.
+func servedTrieV3(tx kv.Tx, root libcommon.Hash, blockReader services.HeaderReader) (*commitment.HexPatriciaReader, error) {
+	ttx, ok := tx.(kv.TemporalTx)
+	if !ok {
+		return nil, nil
+	}
+	progress, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < servedBlocksV3 && i <= progress; i++ {
+		header, err := blockReader.HeaderByNumber(context.Background(), tx, progress-i)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil || header.Root != root {
+			continue
+		}
+		txNum, err := rawdbv3.TxNums.Max(tx, progress-i)
+		if err != nil {
+			return nil, err
+		}
+		r := commitment.NewHexPatriciaReader(length.Addr, &stateAsOfV3{tx: ttx, txNum: txNum + 1})
+		computed, err := r.RootHash()
+		if err != nil || !bytes.Equal(computed, root[:]) {
+			return nil, err
+		}
+		return r, nil
+	}
+	return nil, nil
+}
+
+func nibblesToHash(nibbles []byte) (hash libcommon.Hash) {
+	var key []byte
+	hexutil.CompressNibbles(nibbles, &key)
+	copy(hash[:], key)
+	return hash
+}
+
+func answerGetAccountRangeQueryV3(r *commitment.HexPatriciaReader, query *GetAccountRangePacket) (*AccountRangePacket, error) {
+	response := &AccountRangePacket{RequestId: query.RequestId}
+	limit := responseLimit(query.Bytes)
+
+	var size uint64
+	origin := keyToNibbles(query.Origin[:])
+	err := r.Accounts(origin, func(leaf *commitment.AccountLeaf) (bool, error) {
+		acc := accounts.Account{Nonce: leaf.Nonce, Balance: leaf.Balance, CodeHash: leaf.CodeHash}
+		body, err := SlimAccountRLP(&acc, leaf.StorageRoot)
+		if err != nil {
+			return false, err
+		}
+		hash := nibblesToHash(leaf.HashedKey)
+		response.Accounts = append(response.Accounts, &AccountData{Hash: hash, Body: body})
+		size += estAccountSize
+		return bytes.Compare(hash[:], query.Limit[:]) < 0 && size < limit, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The proofs of the first and the last accounts share the nodes near the root
+	seen := map[string]struct{}{}
+	keys := [][]byte{origin}
+	if len(response.Accounts) > 0 {
+		keys = append(keys, keyToNibbles(response.Accounts[len(response.Accounts)-1].Hash[:]))
+	}
+	for _, key := range keys {
+		proof, err := r.AccountProof(key)
+		if err != nil {
+			return nil, err
+		}
+		response.Proof = appendProof(response.Proof, seen, proof)
+	}
+	return response, nil
+}
+
+func answerGetStorageRangesQueryV3(r *commitment.HexPatriciaReader, query *GetStorageRangesPacket) (*StorageRangesPacket, error) {
+	response := &StorageRangesPacket{RequestId: query.RequestId}
+	limit := responseLimit(query.Bytes)
+	hardLimit := limit + limit/10
+
+	var size uint64
+	for i, accHash := range query.Accounts {
+		if size >= limit {
+			break
+		}
+		var origin libcommon.Hash
+		limitHash := maxHash
+		if i == 0 {
+			if len(query.Origin) > 0 {
+				origin = libcommon.BytesToHash(query.Origin)
+			}
+			if len(query.Limit) > 0 {
+				limitHash = libcommon.BytesToHash(query.Limit)
+			}
+		}
+
+		var (
+			slots []*StorageData
+			abort bool
+		)
+		accountKey := keyToNibbles(accHash[:])
+		err := r.Storage(accountKey, keyToNibbles(origin[:]), func(key, value []byte) (bool, error) {
+			if size >= hardLimit {
+				abort = true
+				return false, nil
+			}
+			hash := nibblesToHash(key)
+			slots = append(slots, &StorageData{Hash: hash, Body: value})
+			size += uint64(length.Hash + len(value))
+			return bytes.Compare(hash[:], limitHash[:]) < 0, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(slots) > 0 {
+			response.Slots = append(response.Slots, slots)
+		}
+
+		if origin != (libcommon.Hash{}) || (abort && len(slots) > 0) {
+			keys := [][]byte{keyToNibbles(origin[:])}
+			if len(slots) > 0 {
+				keys = append(keys, keyToNibbles(slots[len(slots)-1].Hash[:]))
+			}
+			seen := map[string]struct{}{}
+			for _, key := range keys {
+				proof, err := r.StorageProof(accountKey, key)
+				if err != nil {
+					return nil, err
+				}
+				response.Proof = appendProof(response.Proof, seen, proof)
+			}
+			break
+		}
+	}
+	return response, nil
+}
+
+func answerGetTrieNodesQueryV3(r *commitment.HexPatriciaReader, query *GetTrieNodesPacket) (*TrieNodesPacket, error) {
+	response := &TrieNodesPacket{RequestId: query.RequestId}
+	limit := responseLimit(query.Bytes)
+
+	var size uint64
+	lookups := 0
+	appendNode := func(node []byte) bool {
+		response.Nodes = append(response.Nodes, node)
+		size += uint64(len(node))
+		lookups++
+		return size < limit && lookups < maxTrieNodeLookups
+	}
+	for _, pathset := range query.Paths {
+		switch len(pathset) {
+		case 0:
+			return nil, fmt.Errorf("empty trie node path set")
+		case 1:
+			node, err := r.AccountNode(pathToNibbles(pathset[0]))
+			if err != nil {
+				return nil, err
+			}
+			if !appendNode(node) {
+				return response, nil
+			}
+		default:
+			accountKey := keyToNibbles(pathset[0])
+			for _, path := range pathset[1:] {
+				node, err := r.StorageNode(accountKey, pathToNibbles(path))
+				if err != nil {
+					return nil, err
+				}
+				if !appendNode(node) {
+					return response, nil
+				}
+			}
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,223 @@
+package snap
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/commitment"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+// testCommitment keeps the commitment branches and the plain state in memory.
+type testCommitment struct {
+	dir      string
+	branches map[string][]byte
+	accounts map[string]*accounts.Account
+	storage  map[string][]byte
+}
+
+func (c *testCommitment) GetBranch(prefix []byte) ([]byte, error) {
+	return c.branches[string(prefix)], nil
+}
+
+func (c *testCommitment) PutBranch(prefix []byte, data []byte, prevData []byte) error {
+	c.branches[string(prefix)] = libcommon.CopyBytes(data)
+	return nil
+}
+
+func (c *testCommitment) GetAccount(plainKey []byte, cell *commitment.Cell) error {
+	acc, ok := c.accounts[string(plainKey)]
+	if !ok {
+		cell.Delete = true
+		return nil
+	}
+	cell.Nonce = acc.Nonce
+	cell.Balance.Set(&acc.Balance)
+	cell.CodeHash = acc.CodeHash
+	return nil
+}
+
+func (c *testCommitment) GetStorage(plainKey []byte, cell *commitment.Cell) error {
+	value := c.storage[string(plainKey)]
+	cell.StorageLen = len(value)
+	copy(cell.Storage[:], value)
+	cell.Delete = len(value) == 0
+	return nil
+}
+
+func (c *testCommitment) TempDir() string { return c.dir }
+
+// seedTestStateV3 computes the commitment of a plain state of accounts, a few
+// of which are contracts with storage, and the same state in an in-memory trie.
+func seedTestStateV3(t *testing.T, accountCount, contractCount, slotCount int) (*testState, *commitment.HexPatriciaReader) {
+	t.Helper()
+	rnd := rand.New(rand.NewSource(1)) // nolint: gosec
+	s := &testState{
+		accTrie:  trie.New(libcommon.Hash{}),
+		accounts: map[libcommon.Hash]*accounts.Account{},
+		storage:  map[libcommon.Hash]*trie.Trie{},
+		slots:    map[libcommon.Hash][]libcommon.Hash{},
+		code:     map[libcommon.Hash][]byte{},
+	}
+	c := &testCommitment{
+		dir:      t.TempDir(),
+		branches: map[string][]byte{},
+		accounts: map[string]*accounts.Account{},
+		storage:  map[string][]byte{},
+	}
+	var (
+		plainKeys [][]byte
+		updates   []commitment.Update
+	)
+	for i := 0; i < accountCount; i++ {
+		var addr libcommon.Address
+		rnd.Read(addr[:])
+		hash := crypto.Keccak256Hash(addr[:])
+		acc := accounts.NewAccount()
+		acc.Nonce = uint64(i)
+		acc.Balance.SetUint64(uint64(rnd.Int63()))
+		acc.Root = trie.EmptyRoot
+		if i < contractCount {
+			code := make([]byte, 64+i)
+			rnd.Read(code)
+			acc.CodeHash = crypto.Keccak256Hash(code)
+			s.code[acc.CodeHash] = code
+
+			st := trie.New(libcommon.Hash{})
+			for j := 0; j < slotCount; j++ {
+				var loc libcommon.Hash
+				rnd.Read(loc[:])
+				slotHash := crypto.Keccak256Hash(loc[:])
+				value := uint256.NewInt(uint64(j + 1)).Bytes()
+				enc, err := rlp.EncodeToBytes(value)
+				require.NoError(t, err)
+				st.Update(slotHash[:], enc)
+				plainKey := append(libcommon.CopyBytes(addr[:]), loc[:]...)
+				c.storage[string(plainKey)] = value
+				slot := commitment.Update{Flags: commitment.StorageUpdate, ValLength: len(value)}
+				copy(slot.CodeHashOrStorage[:], value)
+				plainKeys, updates = append(plainKeys, plainKey), append(updates, slot)
+				s.slots[hash] = append(s.slots[hash], slotHash)
+			}
+			sort.Slice(s.slots[hash], func(i, j int) bool { return s.slots[hash][i].String() < s.slots[hash][j].String() })
+			s.storage[hash] = st
+			acc.Root = st.Hash()
+		}
+		c.accounts[string(addr[:])] = &acc
+		update := commitment.Update{
+			Flags:             commitment.BalanceUpdate | commitment.NonceUpdate | commitment.CodeUpdate,
+			Nonce:             acc.Nonce,
+			Balance:           acc.Balance,
+			CodeHashOrStorage: acc.CodeHash,
+			ValLength:         length.Hash,
+		}
+		plainKeys, updates = append(plainKeys, addr[:]), append(updates, update)
+		s.accTrie.UpdateAccount(hash[:], &acc)
+		s.accounts[hash] = &acc
+		s.hashes = append(s.hashes, hash)
+	}
+	sort.Slice(s.hashes, func(i, j int) bool { return s.hashes[i].String() < s.hashes[j].String() })
+	s.root = s.accTrie.Hash()
+
+	root, err := commitment.NewHexPatriciaHashed(length.Addr, c).ProcessUpdates(context.Background(), plainKeys, updates)
+	require.NoError(t, err)
+	require.Equal(t, s.root[:], root)
+	return s, commitment.NewHexPatriciaReader(length.Addr, c)
+}
+
+func TestAnswerGetAccountRangeQueryV3(t *testing.T) {
+	s, r := seedTestStateV3(t, 500, 5, 10)
+
+	resp, err := answerGetAccountRangeQueryV3(r, &GetAccountRangePacket{RequestId: 1, Root: s.root, Limit: maxHash, Bytes: softResponseLimit})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), resp.RequestId)
+	require.Len(t, resp.Accounts, len(s.hashes))
+	for i, data := range resp.Accounts {
+		require.Equal(t, s.hashes[i], data.Hash)
+		expected, err := SlimAccountRLP(s.accounts[data.Hash], s.accounts[data.Hash].Root)
+		require.NoError(t, err)
+		require.Equal(t, expected, data.Body)
+	}
+	require.ElementsMatch(t, proofOf(t, s.accTrie, libcommon.Hash{}, s.hashes[len(s.hashes)-1]), resp.Proof)
+
+	origin := s.hashes[100]
+	origin[31]--
+	resp, err = answerGetAccountRangeQueryV3(r, &GetAccountRangePacket{RequestId: 2, Root: s.root, Origin: origin, Limit: maxHash, Bytes: 10 * estAccountSize})
+	require.NoError(t, err)
+	require.Len(t, resp.Accounts, 10)
+	require.Equal(t, s.hashes[100], resp.Accounts[0].Hash)
+	require.ElementsMatch(t, proofOf(t, s.accTrie, origin, s.hashes[109]), resp.Proof)
+
+	resp, err = answerGetAccountRangeQueryV3(r, &GetAccountRangePacket{RequestId: 3, Root: s.root, Limit: s.hashes[2], Bytes: softResponseLimit})
+	require.NoError(t, err)
+	require.Len(t, resp.Accounts, 3)
+}
+
+func TestAnswerGetStorageRangesQueryV3(t *testing.T) {
+	s, r := seedTestStateV3(t, 50, 50, 20)
+	contracts := s.hashes[:3]
+
+	resp, err := answerGetStorageRangesQueryV3(r, &GetStorageRangesPacket{RequestId: 1, Root: s.root, Accounts: contracts, Bytes: softResponseLimit})
+	require.NoError(t, err)
+	require.Len(t, resp.Slots, len(contracts))
+	for i, slots := range resp.Slots {
+		require.Len(t, slots, 20)
+		for j, slot := range slots {
+			require.Equal(t, s.slots[contracts[i]][j], slot.Hash)
+			value, ok := s.storage[contracts[i]].Get(slot.Hash[:])
+			require.True(t, ok)
+			require.Equal(t, value, slot.Body)
+		}
+	}
+	require.Empty(t, resp.Proof)
+
+	origin := s.slots[contracts[0]][5]
+	resp, err = answerGetStorageRangesQueryV3(r, &GetStorageRangesPacket{RequestId: 2, Root: s.root, Accounts: contracts, Origin: origin[:], Bytes: softResponseLimit})
+	require.NoError(t, err)
+	require.Len(t, resp.Slots, 1)
+	require.Len(t, resp.Slots[0], 15)
+	require.ElementsMatch(t, proofOf(t, s.storage[contracts[0]], origin, s.slots[contracts[0]][19]), resp.Proof)
+
+	resp, err = answerGetStorageRangesQueryV3(r, &GetStorageRangesPacket{RequestId: 3, Root: s.root, Accounts: contracts, Bytes: 100})
+	require.NoError(t, err)
+	require.Len(t, resp.Slots, 1)
+	last := resp.Slots[0][len(resp.Slots[0])-1].Hash
+	require.Less(t, len(resp.Slots[0]), 20)
+	require.ElementsMatch(t, proofOf(t, s.storage[contracts[0]], libcommon.Hash{}, last), resp.Proof)
+}
+
+func TestAnswerGetTrieNodesQueryV3(t *testing.T) {
+	s, r := seedTestStateV3(t, 100, 1, 10)
+	contract := s.hashes[0]
+	for hash := range s.storage {
+		contract = hash
+	}
+
+	resp, err := answerGetTrieNodesQueryV3(r, &GetTrieNodesPacket{
+		RequestId: 1,
+		Root:      s.root,
+		Paths: []TrieNodePathSet{
+			{{0x00}},                            // account trie root
+			{contract[:], {0x00}},               // storage trie root
+			{{0x1f}},                            // a node at the first level of the account trie
+			{libcommon.Hash{1}.Bytes(), {0x00}}, // storage root of a missing account
+		},
+		Bytes: softResponseLimit,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Nodes, 4)
+	require.Equal(t, s.root, crypto.Keccak256Hash(resp.Nodes[0]))
+	require.Equal(t, s.accounts[contract].Root, crypto.Keccak256Hash(resp.Nodes[1]))
+	require.NotEmpty(t, resp.Nodes[2])
+	require.Empty(t, resp.Nodes[3])
+}
@@ -0,0 +1,61 @@
+package snap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/services"
+)
+
+// Server answers the snap/1 requests of the peers from the local state. It is
+// called by the sentry running in the same process as the database.
+type Server struct {
+	db          kv.RoDB
+	blockReader services.HeaderReader
+}
+
+func NewServer(db kv.RoDB, blockReader services.HeaderReader) *Server {
+	return &Server{db: db, blockReader: blockReader}
+}
+
+// Answer decodes the request with the given message code and computes the
+// response packet, the message code of which is given by its Kind.
+func (s *Server) Answer(ctx context.Context, msgcode uint64, data []byte) (Packet, error) {
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	switch msgcode {
+	case GetAccountRangeMsg:
+		var query GetAccountRangePacket
+		if err := rlp.DecodeBytes(data, &query); err != nil {
+			return nil, fmt.Errorf("decoding GetAccountRange: %w, data: %x", err, data)
+		}
+		return AnswerGetAccountRangeQuery(tx, &query, s.blockReader, ctx.Done())
+	case GetStorageRangesMsg:
+		var query GetStorageRangesPacket
+		if err := rlp.DecodeBytes(data, &query); err != nil {
+			return nil, fmt.Errorf("decoding GetStorageRanges: %w, data: %x", err, data)
+		}
+		return AnswerGetStorageRangesQuery(tx, &query, s.blockReader, ctx.Done())
+	case GetByteCodesMsg:
+		var query GetByteCodesPacket
+		if err := rlp.DecodeBytes(data, &query); err != nil {
+			return nil, fmt.Errorf("decoding GetByteCodes: %w, data: %x", err, data)
+		}
+		return AnswerGetByteCodesQuery(tx, &query)
+	case GetTrieNodesMsg:
+		var query GetTrieNodesPacket
+		if err := rlp.DecodeBytes(data, &query); err != nil {
+			return nil, fmt.Errorf("decoding GetTrieNodes: %w, data: %x", err, data)
+		}
+		return AnswerGetTrieNodesQuery(tx, &query, s.blockReader, ctx.Done())
+	default:
+		return nil, fmt.Errorf("not a snap request: %d", msgcode)
+	}
+}
@@ -335,9 +335,19 @@ func ExecV3(ctx context.Context,
 	rwsConsumed := make(chan struct{}, 1)
 	defer close(rwsConsumed)
 
+	// receipts of the already applied txs of the current block, the Final task derives the block requests from them
+	var appliedReceipts types.Receipts
+
 	execWorkers, applyWorker, rws, stopWorkers, waitWorkers := exec3.NewWorkersPool(lock.RLocker(), logger, ctx, parallel, chainDb, rs, in, blockReader, chainConfig, genesis, engine, workerCount+1, cfg.dirs)
 	defer stopWorkers()
 	applyWorker.DiscardReadList()
+	if cfg.vmConfig != nil && cfg.vmConfig.LiveTracer != nil {
+		if parallel {
+			logger.Warn(fmt.Sprintf("[%s] live tracer is not supported by parallel execution", execStage.LogPrefix()))
+		} else {
+			applyWorker.SetLiveTracer(cfg.vmConfig.LiveTracer)
+		}
+	}
 
 	commitThreshold := batchSize.Bytes()
 	progress := NewProgress(blockNum, commitThreshold, workerCount, execStage.LogPrefix(), logger)
@@ -365,7 +375,7 @@ func ExecV3(ctx context.Context,
 				return err
 			}
 
-			processedTxNum, conflicts, triggers, processedBlockNum, stoppedAtBlockEnd, err := processResultQueue(ctx, in, rws, outputTxNum.Load(), rs, agg, tx, rwsConsumed, applyWorker, true, false)
+			processedTxNum, conflicts, triggers, processedBlockNum, stoppedAtBlockEnd, err := processResultQueue(ctx, in, rws, outputTxNum.Load(), rs, agg, tx, rwsConsumed, applyWorker, &appliedReceipts, true, false)
 			if err != nil {
 				return err
 			}
@@ -466,7 +476,7 @@ func ExecV3(ctx context.Context,
 							rws.DrainNonBlocking()
 							applyWorker.ResetTx(tx)
 
-							processedTxNum, conflicts, triggers, processedBlockNum, stoppedAtBlockEnd, err := processResultQueue(ctx, in, rws, outputTxNum.Load(), rs, agg, tx, nil, applyWorker, false, true)
+							processedTxNum, conflicts, triggers, processedBlockNum, stoppedAtBlockEnd, err := processResultQueue(ctx, in, rws, outputTxNum.Load(), rs, agg, tx, nil, applyWorker, &appliedReceipts, false, true)
 							if err != nil {
 								return err
 							}
@@ -701,6 +711,7 @@ Loop:
 
 		rules := chainConfig.Rules(blockNum, b.Time())
 		var gasUsed, blobGasUsed uint64
+		var blockReceipts types.Receipts
 		for txIndex := -1; txIndex <= len(txs); txIndex++ {
 
 			// Do not oversend, wait for the result heap to go under certain size
@@ -762,7 +773,14 @@ Loop:
 				if txTask.Error != nil {
 					break Loop
 				}
+				if txTask.Final {
+					// the txs of the block were executed already, their logs carry the deposit requests
+					txTask.BlockReceipts = blockReceipts
+				}
 				applyWorker.RunTxTaskNoLock(txTask)
+				if txTask.Tx != nil && txTask.Error == nil {
+					blockReceipts = append(blockReceipts, &types.Receipt{Logs: txTask.Logs})
+				}
 				if err := func() error {
 					if errors.Is(txTask.Error, context.Canceled) {
 						return err
@@ -1155,7 +1173,7 @@ func blockWithSenders(db kv.RoDB, tx kv.Tx, blockReader services.BlockReader, bl
 	return b, err
 }
 
-func processResultQueue(ctx context.Context, in *state.QueueWithRetry, rws *state.ResultsQueue, outputTxNumIn uint64, rs *state.StateV3, agg *libstate.AggregatorV3, applyTx kv.Tx, backPressure chan struct{}, applyWorker *exec3.Worker, canRetry, forceStopAtBlockEnd bool) (outputTxNum uint64, conflicts, triggers int, processedBlockNum uint64, stopedAtBlockEnd bool, err error) {
+func processResultQueue(ctx context.Context, in *state.QueueWithRetry, rws *state.ResultsQueue, outputTxNumIn uint64, rs *state.StateV3, agg *libstate.AggregatorV3, applyTx kv.Tx, backPressure chan struct{}, applyWorker *exec3.Worker, appliedReceipts *types.Receipts, canRetry, forceStopAtBlockEnd bool) (outputTxNum uint64, conflicts, triggers int, processedBlockNum uint64, stopedAtBlockEnd bool, err error) {
 	rwsIt := rws.Iter()
 	defer rwsIt.Close()
 
@@ -1163,6 +1181,12 @@ func processResultQueue(ctx context.Context, in *state.QueueWithRetry, rws *stat
 	outputTxNum = outputTxNumIn
 	for rwsIt.HasNext(outputTxNum) {
 		txTask := rwsIt.PopNext()
+		if txTask.Final && txTask.BlockReceipts == nil {
+			// The Final task was scheduled before the txs of its block were executed. Results are applied in
+			// order, so all of them are known here. If the requests derived without them did not match, the
+			// task failed and is re-executed below with the receipts attached.
+			txTask.BlockReceipts = *appliedReceipts
+		}
 		if txTask.Error != nil || !rs.ReadsValid(txTask.ReadLists) {
 			conflicts++
 
@@ -1180,8 +1204,12 @@ func processResultQueue(ctx context.Context, in *state.QueueWithRetry, rws *stat
 			// TODO: post-validation of gasUsed and blobGasUsed
 			i++
 		}
+		if txTask.Tx != nil {
+			*appliedReceipts = append(*appliedReceipts, &types.Receipt{Logs: txTask.Logs})
+		}
 
 		if txTask.Final {
+			*appliedReceipts = nil
 			rs.SetTxNum(txTask.TxNum, txTask.BlockNum)
 			err := rs.ApplyState4(ctx, txTask)
 			if err != nil {
@@ -174,7 +174,12 @@ func executeBlock(
 	var execRs *core.EphemeralExecResult
 	getHashFn := core.GetHashFn(block.Header(), getHeader)
 
-	execRs, err = core.ExecuteBlockEphemerally(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, stateReader, stateWriter, consensuschain.NewReader(cfg.chainConfig, tx, cfg.blockReader, logger), getTracer, logger)
+	chainReader := consensuschain.NewReader(cfg.chainConfig, tx, cfg.blockReader, logger)
+	if cfg.syncCfg.ParallelExec && !initialCycle {
+		execRs, err = core.ExecuteBlockParallel(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, stateReader, stateWriter, chainReader, getTracer, cfg.syncCfg.ExecWorkerCount, logger)
+	} else {
+		execRs, err = core.ExecuteBlockEphemerally(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, stateReader, stateWriter, chainReader, getTracer, logger)
+	}
 	if err != nil {
 		return fmt.Errorf("%w: %v", consensus.ErrInvalidBlock, err)
 	}
@@ -736,6 +741,9 @@ func UnwindExecutionStage(u *UnwindState, s *StageState, tx kv.RwTx, ctx context
 			return err
 		}
 	}
+	if cfg.vmConfig != nil && cfg.vmConfig.LiveTracer != nil {
+		cfg.vmConfig.LiveTracer.CaptureUnwind(u.UnwindPoint)
+	}
 	return nil
 }
 
@@ -31,6 +31,7 @@ type MiningBlock struct {
 	Txs         types.Transactions
 	Receipts    types.Receipts
 	Withdrawals []*types.Withdrawal
+	Requests    types.Requests
 	PreparedTxs types.TransactionsStream
 }
 
@@ -286,6 +287,7 @@ func SpawnMiningCreateBlockStage(s *StageState, tx kv.RwTx, cfg MiningCreateBloc
 	current.Header = header
 	current.Uncles = makeUncles(env.uncles)
 	current.Withdrawals = nil
+	current.Requests = nil
 	return nil
 }
 
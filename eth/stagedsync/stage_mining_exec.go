@@ -184,11 +184,15 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, ctx cont
 		current.Receipts = types.Receipts{}
 	}
 
-	var err error
-	_, current.Txs, current.Receipts, err = core.FinalizeBlockExecution(cfg.engine, stateReader, current.Header, current.Txs, current.Uncles, stateWriter, &cfg.chainConfig, ibs, current.Receipts, current.Withdrawals, consensuschain.NewReader(&cfg.chainConfig, tx, cfg.blockReader, logger), true, logger)
+	var (
+		block *types.Block
+		err   error
+	)
+	block, current.Txs, current.Receipts, err = core.FinalizeBlockExecution(cfg.engine, stateReader, current.Header, current.Txs, current.Uncles, stateWriter, &cfg.chainConfig, ibs, current.Receipts, current.Withdrawals, nil, consensuschain.NewReader(&cfg.chainConfig, tx, cfg.blockReader, logger), true, logger)
 	if err != nil {
 		return err
 	}
+	current.Requests = block.Requests()
 	logger.Debug("FinalizeBlockExecution", "current txn", current.Txs.Len(), "current receipt", current.Receipts.Len(), "payload", cfg.payloadId)
 
 	if histV3 {
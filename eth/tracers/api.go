@@ -5,6 +5,7 @@ import (
 
 	"github.com/ledgerwatch/erigon-lib/common/hexutil"
 	"github.com/ledgerwatch/erigon/eth/tracers/logger"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
 )
 
@@ -21,4 +22,14 @@ type TraceConfig struct {
 	BorTraceEnabled *bool
 	BorTx           *bool
 	TxIndex         *hexutil.Uint
+
+	// StateDiff makes debug_traceBlock* return the net state changes of the block instead of
+	// the traces of its transactions, read from the history without re-executing them
+	StateDiff *StateDiffConfig
+}
+
+// StateDiffConfig holds the options of the state diff mode of debug_traceBlock*.
+type StateDiffConfig struct {
+	WithCode bool                   `json:"withCode"` // If true, the bytecode of the accounts whose code changed is returned along with the code hashes
+	ToBlock  *rpc.BlockNumberOrHash `json:"toBlock"`  // Last block of the range starting at the traced block, the traced block alone if nil
 }
@@ -0,0 +1,247 @@
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+	"github.com/ledgerwatch/erigon/core/vm/runtime"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+	"github.com/ledgerwatch/erigon/tests"
+	"github.com/ledgerwatch/erigon/turbo/stages/mock"
+)
+
+type flatCallTrace struct {
+	Action struct {
+		Address  libcommon.Address `json:"address"`
+		CallType string            `json:"callType"`
+		From     libcommon.Address `json:"from"`
+		Value    *hexutil.Big      `json:"value"`
+	} `json:"action"`
+	BlockNumber  uint64 `json:"blockNumber"`
+	Error        string `json:"error"`
+	Subtraces    int    `json:"subtraces"`
+	TraceAddress []int  `json:"traceAddress"`
+	Type         string `json:"type"`
+}
+
+type transferTrace struct {
+	Type         string             `json:"type"`
+	Token        *libcommon.Address `json:"token"`
+	From         libcommon.Address  `json:"from"`
+	To           libcommon.Address  `json:"to"`
+	TokenID      *hexutil.Big       `json:"tokenId"`
+	Value        *hexutil.Big       `json:"value"`
+	TraceAddress []int              `json:"traceAddress"`
+}
+
+// flatten lists the frames of a nested call trace in depth-first order, along with
+// whether the frame and all of its parents succeeded.
+func flatten(call *callTrace, parentOk bool, out *[]*callTrace, ok *[]bool) {
+	succeeded := parentOk && call.Error == ""
+	*out = append(*out, call)
+	*ok = append(*ok, succeeded)
+	for i := range call.Calls {
+		flatten(&call.Calls[i], succeeded, out, ok)
+	}
+}
+
+// TestFlatCallAndTransferTracers runs the flat call and the transfer tracers through
+// the mux tracer over the call tracer test suite, and checks them against the
+// expected nested call traces.
+func TestFlatCallAndTransferTracers(t *testing.T) {
+	files, err := os.ReadDir(filepath.Join("testdata", "call_tracer"))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(file.Name(), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			test := new(callTracerTest)
+			if blob, err := os.ReadFile(filepath.Join("testdata", "call_tracer", file.Name())); err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			} else if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			if len(test.TracerConfig) > 0 {
+				t.Skip("expected result depends on the call tracer config")
+			}
+			res := runMuxTracer(t, test, `{"flatCallTracer":{"includePrecompiles":true},"erc20TransferTracer":{}}`)
+
+			var nested []*callTrace
+			var succeeded []bool
+			flatten(test.Result, true, &nested, &succeeded)
+
+			var flat []flatCallTrace
+			require.NoError(t, json.Unmarshal(res["flatCallTracer"], &flat))
+			require.Equal(t, len(nested), len(flat), "number of frames")
+			require.Empty(t, flat[0].TraceAddress)
+			for i, frame := range flat {
+				want := nested[i]
+				require.Equal(t, len(want.Calls), frame.Subtraces, "frame %d", i)
+				require.Equal(t, uint64(test.Context.Number), frame.BlockNumber, "frame %d", i)
+				switch want.Type {
+				case "CREATE", "CREATE2":
+					require.Equal(t, "create", frame.Type, "frame %d", i)
+					require.Equal(t, want.From, frame.Action.From, "frame %d", i)
+				case "SELFDESTRUCT":
+					require.Equal(t, "suicide", frame.Type, "frame %d", i)
+					require.Equal(t, want.From, frame.Action.Address, "frame %d", i)
+				default:
+					require.Equal(t, "call", frame.Type, "frame %d", i)
+					require.Equal(t, want.From, frame.Action.From, "frame %d", i)
+					require.Equal(t, strings.ToLower(want.Type), frame.Action.CallType, "frame %d", i)
+				}
+			}
+
+			var transfers []transferTrace
+			require.NoError(t, json.Unmarshal(res["erc20TransferTracer"], &transfers))
+			var wantEth []*callTrace
+			for i, call := range nested {
+				if !succeeded[i] || call.Value == nil || call.Value.ToInt().Sign() == 0 {
+					continue
+				}
+				switch call.Type {
+				case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+					wantEth = append(wantEth, call)
+				}
+			}
+			var eth []transferTrace
+			for _, tr := range transfers {
+				if tr.Type == "eth" {
+					eth = append(eth, tr)
+				}
+			}
+			require.Equal(t, len(wantEth), len(eth), "number of ether transfers")
+			for i, tr := range eth {
+				require.Equal(t, wantEth[i].From, tr.From)
+				require.Equal(t, wantEth[i].To, tr.To)
+				require.Equal(t, wantEth[i].Value.ToInt(), tr.Value.ToInt())
+			}
+		})
+	}
+}
+
+func runMuxTracer(t *testing.T, test *callTracerTest, config string) map[string]json.RawMessage {
+	tx, err := types.UnmarshalTransactionFromBinary(common.FromHex(test.Input))
+	if err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	var (
+		signer    = types.MakeSigner(test.Genesis.Config, uint64(test.Context.Number), uint64(test.Context.Time))
+		origin, _ = signer.Sender(tx)
+		txContext = evmtypes.TxContext{
+			Origin:   origin,
+			GasPrice: tx.GetPrice(),
+		}
+		context = evmtypes.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			Coinbase:    test.Context.Miner,
+			BlockNumber: uint64(test.Context.Number),
+			Time:        uint64(test.Context.Time),
+			Difficulty:  (*big.Int)(test.Context.Difficulty),
+			GasLimit:    uint64(test.Context.GasLimit),
+		}
+		rules = test.Genesis.Config.Rules(context.BlockNumber, context.Time)
+	)
+	m := mock.Mock(t)
+	dbTx, err := m.DB.BeginRw(m.Ctx)
+	require.NoError(t, err)
+	defer dbTx.Rollback()
+	statedb, _ := tests.MakePreState(rules, dbTx, test.Genesis.Alloc, uint64(test.Context.Number), m.HistoryV3)
+	if test.Genesis.BaseFee != nil {
+		context.BaseFee, _ = uint256.FromBig(test.Genesis.BaseFee)
+	}
+	tracerCtx := &tracers.Context{BlockNumber: new(big.Int).SetUint64(context.BlockNumber), TxHash: tx.Hash()}
+	tracer, err := tracers.New("muxTracer", tracerCtx, json.RawMessage(config))
+	if err != nil {
+		t.Fatalf("failed to create mux tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := tx.AsMessage(*signer, test.Genesis.BaseFee, rules)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	if _, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.GetGas()).AddBlobGas(tx.GetBlobGas()), true /* refunds */, false /* gasBailout */); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var results map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(res, &results))
+	return results
+}
+
+func TestTransferTracerTokenEvents(t *testing.T) {
+	var (
+		from  = libcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+		to    = libcommon.HexToAddress("0x2222222222222222222222222222222222222222")
+		token = libcommon.HexToAddress("0x3333333333333333333333333333333333333333")
+		topic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	)
+	log3 := func(value byte) []byte {
+		// MSTORE value at 0, then LOG3(0, 32, topic, from, to)
+		code := []byte{byte(vm.PUSH1), value, byte(vm.PUSH1), 0, byte(vm.MSTORE)}
+		code = append(append(code, byte(vm.PUSH20)), to.Bytes()...)
+		code = append(append(code, byte(vm.PUSH20)), from.Bytes()...)
+		code = append(append(code, byte(vm.PUSH32)), topic.Bytes()...)
+		return append(code, byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG3))
+	}
+	// The token emits an ERC-20 transfer of 42, the second one is reverted
+	code := append(log3(42), byte(vm.STOP))
+	reverting := append(log3(7), byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT))
+
+	tracer, err := tracers.New("erc20TransferTracer", new(tracers.Context), nil)
+	require.NoError(t, err)
+	_, dbTx := memdb.NewTestTx(t)
+	cfg := &runtime.Config{
+		State:     state.New(state.NewPlainState(dbTx, 1, nil)),
+		EVMConfig: vm.Config{Debug: true, Tracer: tracer},
+	}
+	cfg.State.SetCode(token, code)
+	cfg.State.SetCode(libcommon.Address{0xee}, reverting)
+
+	_, _, err = runtime.Call(token, nil, cfg)
+	require.NoError(t, err)
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	var transfers []transferTrace
+	require.NoError(t, json.Unmarshal(res, &transfers))
+	require.Len(t, transfers, 1)
+	require.Equal(t, "erc20", transfers[0].Type)
+	require.Equal(t, token, *transfers[0].Token)
+	require.Equal(t, from, transfers[0].From)
+	require.Equal(t, to, transfers[0].To)
+	require.Equal(t, int64(42), transfers[0].Value.ToInt().Int64())
+
+	_, _, err = runtime.Call(libcommon.Address{0xee}, nil, cfg)
+	require.ErrorIs(t, err, vm.ErrExecutionReverted)
+	res, err = tracer.GetResult()
+	require.NoError(t, err)
+	require.JSONEq(t, "[]", string(res))
+}
@@ -0,0 +1,103 @@
+package tracetest
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/core/vm/runtime"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+)
+
+func TestGasProfiler(t *testing.T) {
+	var (
+		caller = libcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+		callee = libcommon.HexToAddress("0x2222222222222222222222222222222222222222")
+	)
+	call := func(to libcommon.Address, selector []byte) []byte {
+		// MSTORE the selector at 0, then CALL(gas, to, 0, 28, 4, 0, 0)
+		code := []byte{byte(vm.PUSH4)}
+		code = append(code, selector...)
+		code = append(code, byte(vm.PUSH1), 0, byte(vm.MSTORE))
+		code = append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 4, byte(vm.PUSH1), 28, byte(vm.PUSH1), 0)
+		code = append(append(code, byte(vm.PUSH20)), to.Bytes()...)
+		return append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+	}
+	// The caller calls the callee twice and the identity precompile once, the
+	// callee stores two values and the second call to the callee runs out of gas
+	code := call(callee, []byte{0xa9, 0x05, 0x9c, 0xbb})
+	code = append(code, call(libcommon.BytesToAddress([]byte{4}), []byte{1, 2, 3, 4})...)
+	code = append(code, byte(vm.PUSH1), 50) // gas for the second call
+	code = append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 4, byte(vm.PUSH1), 28, byte(vm.PUSH1), 0)
+	code = append(append(code, byte(vm.PUSH20)), callee.Bytes()...)
+	code = append(code, byte(vm.DUP7), byte(vm.CALL), byte(vm.POP), byte(vm.STOP))
+	calleeCode := []byte{byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE), byte(vm.PUSH1), 2, byte(vm.PUSH1), 1, byte(vm.SSTORE), byte(vm.STOP)}
+
+	tracer, err := tracers.New("gasProfiler", new(tracers.Context), nil)
+	require.NoError(t, err)
+	_, dbTx := memdb.NewTestTx(t)
+	cfg := &runtime.Config{
+		GasLimit:  1_000_000,
+		State:     state.New(state.NewPlainState(dbTx, 1, nil)),
+		EVMConfig: vm.Config{Debug: true, Tracer: tracer},
+	}
+	cfg.State.SetCode(caller, code)
+	cfg.State.SetCode(callee, calleeCode)
+
+	_, gasLeft, err := runtime.Call(caller, nil, cfg)
+	require.NoError(t, err)
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+
+	var profile struct {
+		GasUsed   uint64 `json:"gasUsed"`
+		Contracts map[libcommon.Address]struct {
+			Gas   uint64 `json:"gas"`
+			Count uint64 `json:"count"`
+		} `json:"contracts"`
+		Functions map[string]struct {
+			Gas   uint64 `json:"gas"`
+			Count uint64 `json:"count"`
+		} `json:"functions"`
+		Opcodes map[string]struct {
+			Gas   uint64 `json:"gas"`
+			Count uint64 `json:"count"`
+		} `json:"opcodes"`
+		Folded []string `json:"folded"`
+	}
+	require.NoError(t, json.Unmarshal(res, &profile))
+	require.Equal(t, cfg.GasLimit-gasLeft, profile.GasUsed)
+
+	// Every unit of gas is attributed once, both in the aggregates and in the folded stacks
+	var contracts, opcodes, folded uint64
+	for _, stat := range profile.Contracts {
+		contracts += stat.Gas
+	}
+	for _, stat := range profile.Opcodes {
+		opcodes += stat.Gas
+	}
+	for _, line := range profile.Folded {
+		i := strings.LastIndexByte(line, ' ')
+		gas, err := strconv.ParseUint(line[i+1:], 10, 64)
+		require.NoError(t, err, line)
+		folded += gas
+	}
+	require.Equal(t, profile.GasUsed, contracts)
+	require.Equal(t, profile.GasUsed-profile.Contracts[libcommon.BytesToAddress([]byte{4})].Gas, opcodes)
+	require.Equal(t, profile.GasUsed, folded)
+
+	require.Equal(t, uint64(2), profile.Contracts[callee].Count)
+	require.Equal(t, uint64(1), profile.Functions["0x2222222222222222222222222222222222222222:0xa9059cbb"].Count)
+	require.Equal(t, uint64(1), profile.Functions["0x2222222222222222222222222222222222222222:0x01020304"].Count)
+	// The second call runs out of gas on its first SSTORE, which spends all of its gas
+	require.Equal(t, uint64(3), profile.Opcodes["SSTORE"].Count)
+	require.Contains(t, profile.Folded, "0x1111111111111111111111111111111111111111;0x0000000000000000000000000000000000000004:0x01020304 18")
+}
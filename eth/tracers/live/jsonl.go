@@ -0,0 +1,343 @@
+package live
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+func init() {
+	register("file", newFileTracer)
+	register("socket", newSocketTracer)
+}
+
+type fileTracerConfig struct {
+	Path string `json:"path"` // File to append the events to
+}
+
+// newFileTracer returns a jsonlTracer which appends the events to a file.
+func newFileTracer(cfg json.RawMessage, logger log.Logger) (vm.LiveLogger, error) {
+	var config fileTracerConfig
+	if err := decodeConfig(cfg, &config); err != nil {
+		return nil, err
+	}
+	if config.Path == "" {
+		return nil, errors.New("file tracer: path is required")
+	}
+	open := func() (io.WriteCloser, error) {
+		return os.OpenFile(config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	}
+	t, err := newJsonlTracer(open, logger)
+	if err != nil {
+		return nil, fmt.Errorf("file tracer: %w", err)
+	}
+	return t, nil
+}
+
+// socketDialTimeout bounds the (re)connection to the sink, which happens between two blocks.
+const socketDialTimeout = 5 * time.Second
+
+type socketTracerConfig struct {
+	Network string `json:"network"` // "tcp" or "unix", defaults to "tcp"
+	Address string `json:"address"` // Address of the sink to stream the events to
+}
+
+// newSocketTracer returns a jsonlTracer which streams the events to an external sink.
+func newSocketTracer(cfg json.RawMessage, logger log.Logger) (vm.LiveLogger, error) {
+	var config socketTracerConfig
+	if err := decodeConfig(cfg, &config); err != nil {
+		return nil, err
+	}
+	if config.Network == "" {
+		config.Network = "tcp"
+	}
+	if config.Address == "" {
+		return nil, errors.New("socket tracer: address is required")
+	}
+	open := func() (io.WriteCloser, error) {
+		return net.DialTimeout(config.Network, config.Address, socketDialTimeout)
+	}
+	t, err := newJsonlTracer(open, logger)
+	if err != nil {
+		return nil, fmt.Errorf("socket tracer: %w", err)
+	}
+	return t, nil
+}
+
+func decodeConfig(cfg json.RawMessage, config any) error {
+	if len(cfg) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(cfg, config); err != nil {
+		return fmt.Errorf("invalid live tracer config: %w", err)
+	}
+	return nil
+}
+
+type blockEvent struct {
+	Event  string            `json:"event"`
+	Number hexutil.Uint64    `json:"number"`
+	Hash   libcommon.Hash    `json:"hash"`
+	Parent libcommon.Hash    `json:"parentHash"`
+	Time   hexutil.Uint64    `json:"timestamp"`
+	Miner  libcommon.Address `json:"miner"`
+}
+
+type txEvent struct {
+	Event string            `json:"event"`
+	Index int               `json:"index"`
+	Hash  libcommon.Hash    `json:"hash"`
+	From  libcommon.Address `json:"from"`
+}
+
+type endEvent struct {
+	Event   string           `json:"event"`
+	GasUsed *hexutil.Uint64  `json:"gasUsed,omitempty"`
+	Logs    *int             `json:"logs,omitempty"`
+	Output  hexutility.Bytes `json:"output,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type enterEvent struct {
+	Event string            `json:"event"`
+	Type  string            `json:"type"`
+	Depth int               `json:"depth"`
+	From  libcommon.Address `json:"from"`
+	To    libcommon.Address `json:"to"`
+	Input hexutility.Bytes  `json:"input,omitempty"`
+	Gas   hexutil.Uint64    `json:"gas"`
+	Value string            `json:"value,omitempty"`
+}
+
+type reopenEvent struct {
+	Event         string `json:"event"`
+	DroppedBlocks uint64 `json:"droppedBlocks"`
+}
+
+type unwindEvent struct {
+	Event  string         `json:"event"`
+	Number hexutil.Uint64 `json:"number"`
+}
+
+type changeEvent struct {
+	Event   string            `json:"event"`
+	Address libcommon.Address `json:"address"`
+	Key     *libcommon.Hash   `json:"key,omitempty"`
+	Prev    string            `json:"prev"`
+	Next    string            `json:"new"`
+	Code    hexutility.Bytes  `json:"code,omitempty"`
+}
+
+// jsonlTracer writes every block, transaction, call frame and state change as a
+// JSON object on its own line. Opcode level events are not reported. The output
+// is flushed at the end of every block.
+//
+// When a write fails the sink is closed and the events are dropped until it is
+// reopened, which is attempted at the start of every block. The first event after
+// the sink is reopened is a "reopen" event with the number of dropped blocks.
+type jsonlTracer struct {
+	mu      sync.Mutex
+	open    func() (io.WriteCloser, error)
+	w       io.WriteCloser // nil while the sink is closed after a write error
+	buf     *bufio.Writer
+	enc     *json.Encoder
+	dropped uint64 // blocks whose events were dropped, in part or entirely, since the sink failed
+	depth   int
+	logger  log.Logger
+}
+
+func newJsonlTracer(open func() (io.WriteCloser, error), logger log.Logger) (*jsonlTracer, error) {
+	t := &jsonlTracer{open: open, logger: logger}
+	w, err := open()
+	if err != nil {
+		return nil, err
+	}
+	t.setWriter(w)
+	return t, nil
+}
+
+func (t *jsonlTracer) setWriter(w io.WriteCloser) {
+	t.w = w
+	t.buf = bufio.NewWriter(w)
+	t.enc = json.NewEncoder(t.buf)
+}
+
+func (t *jsonlTracer) write(ev any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.writeLocked(ev)
+}
+
+func (t *jsonlTracer) writeLocked(ev any) {
+	if t.w == nil {
+		return
+	}
+	if err := t.enc.Encode(ev); err != nil {
+		t.fail(err)
+	}
+}
+
+func (t *jsonlTracer) flushLocked() {
+	if t.w == nil {
+		return
+	}
+	if err := t.buf.Flush(); err != nil {
+		t.fail(err)
+	}
+}
+
+// fail closes the sink after a write error, the events are dropped until it is reopened.
+func (t *jsonlTracer) fail(err error) {
+	t.dropped++
+	t.logger.Error("[live tracer] write failed, dropping the events until the sink is reopened", "err", err)
+	if closeErr := t.w.Close(); closeErr != nil {
+		t.logger.Debug("[live tracer] close failed", "err", closeErr)
+	}
+	t.w, t.buf, t.enc = nil, nil, nil
+}
+
+// reopenLocked reopens the sink closed after a write error, and reports whether it is open.
+func (t *jsonlTracer) reopenLocked() bool {
+	if t.w != nil {
+		return true
+	}
+	w, err := t.open()
+	if err != nil {
+		t.dropped++
+		t.logger.Error("[live tracer] reopen failed, dropping the events of the block", "err", err, "droppedBlocks", t.dropped)
+		return false
+	}
+	t.setWriter(w)
+	t.logger.Info("[live tracer] reopened", "droppedBlocks", t.dropped)
+	t.writeLocked(&reopenEvent{Event: "reopen", DroppedBlocks: t.dropped})
+	t.dropped = 0
+	return t.w != nil
+}
+
+func (t *jsonlTracer) CaptureBlockStart(header *types.Header) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.reopenLocked() {
+		return
+	}
+	t.writeLocked(&blockEvent{
+		Event:  "blockStart",
+		Number: hexutil.Uint64(header.Number.Uint64()),
+		Hash:   header.Hash(),
+		Parent: header.ParentHash,
+		Time:   hexutil.Uint64(header.Time),
+		Miner:  header.Coinbase,
+	})
+}
+
+func (t *jsonlTracer) CaptureBlockEnd(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.writeLocked(&endEvent{Event: "blockEnd", Error: errString(err)})
+	t.flushLocked()
+}
+
+func (t *jsonlTracer) CaptureUnwind(unwindPoint uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.reopenLocked() {
+		return
+	}
+	t.writeLocked(&unwindEvent{Event: "unwind", Number: hexutil.Uint64(unwindPoint)})
+	t.flushLocked()
+}
+
+func (t *jsonlTracer) CaptureTransactionStart(txIndex int, tx types.Transaction, from libcommon.Address) {
+	t.depth = 0
+	t.write(&txEvent{Event: "txStart", Index: txIndex, Hash: tx.Hash(), From: from})
+}
+
+func (t *jsonlTracer) CaptureTransactionEnd(usedGas uint64, logs []*types.Log, err error) {
+	gas, n := hexutil.Uint64(usedGas), len(logs)
+	t.write(&endEvent{Event: "txEnd", GasUsed: &gas, Logs: &n, Error: errString(err)})
+}
+
+func (t *jsonlTracer) CaptureTxStart(gasLimit uint64) {}
+
+func (t *jsonlTracer) CaptureTxEnd(restGas uint64) {}
+
+func (t *jsonlTracer) CaptureStart(env *vm.EVM, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	typ := vm.CALL
+	if create {
+		typ = vm.CREATE
+	}
+	t.CaptureEnter(typ, from, to, precompile, create, input, gas, value, code)
+}
+
+func (t *jsonlTracer) CaptureEnd(output []byte, usedGas uint64, err error) {
+	t.CaptureExit(output, usedGas, err)
+}
+
+func (t *jsonlTracer) CaptureEnter(typ vm.OpCode, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	ev := &enterEvent{Event: "enter", Type: typ.String(), Depth: t.depth, From: from, To: to, Input: input, Gas: hexutil.Uint64(gas)}
+	if value != nil {
+		ev.Value = value.Hex()
+	}
+	t.depth++
+	t.write(ev)
+}
+
+func (t *jsonlTracer) CaptureExit(output []byte, usedGas uint64, err error) {
+	t.depth--
+	gas := hexutil.Uint64(usedGas)
+	t.write(&endEvent{Event: "exit", GasUsed: &gas, Output: output, Error: errString(err)})
+}
+
+func (t *jsonlTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *jsonlTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *jsonlTracer) CaptureBalanceChange(addr libcommon.Address, prev, next *uint256.Int) {
+	t.write(&changeEvent{Event: "balance", Address: addr, Prev: prev.Hex(), Next: next.Hex()})
+}
+
+func (t *jsonlTracer) CaptureNonceChange(addr libcommon.Address, prev, next uint64) {
+	t.write(&changeEvent{Event: "nonce", Address: addr, Prev: hexutil.EncodeUint64(prev), Next: hexutil.EncodeUint64(next)})
+}
+
+func (t *jsonlTracer) CaptureCodeChange(addr libcommon.Address, prevCodeHash libcommon.Hash, prevCode []byte, codeHash libcommon.Hash, code []byte) {
+	t.write(&changeEvent{Event: "code", Address: addr, Prev: prevCodeHash.Hex(), Next: codeHash.Hex(), Code: code})
+}
+
+func (t *jsonlTracer) CaptureStorageChange(addr libcommon.Address, key libcommon.Hash, prev, next *uint256.Int) {
+	t.write(&changeEvent{Event: "storage", Address: addr, Key: &key, Prev: prev.Hex(), Next: next.Hex()})
+}
+
+// Close flushes the pending events and closes the underlying file or connection.
+func (t *jsonlTracer) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.w == nil {
+		return nil
+	}
+	return errors.Join(t.buf.Flush(), t.w.Close())
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
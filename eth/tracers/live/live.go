@@ -0,0 +1,41 @@
+// Package live is a collection of tracers which follow the chain while its blocks
+// are executed by the staged sync, see vm.LiveLogger.
+//
+// A live tracer registers itself by name in the package initialization:
+//
+//	func init() {
+//		register("file", newFileTracer)
+//	}
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+// ctorFn is the constructor signature of a live tracer.
+type ctorFn = func(cfg json.RawMessage, logger log.Logger) (vm.LiveLogger, error)
+
+// ctors is made upon first use, see the notes on init order in eth/tracers/native.
+var ctors map[string]ctorFn
+
+// register is used by live tracers to register their presence.
+func register(name string, ctor ctorFn) {
+	if ctors == nil {
+		ctors = make(map[string]ctorFn)
+	}
+	ctors[name] = ctor
+}
+
+// New returns the live tracer registered under the given name, configured by cfg.
+// If the tracer holds resources, such as an open file, it implements io.Closer.
+func New(name string, cfg json.RawMessage, logger log.Logger) (vm.LiveLogger, error) {
+	if ctor, ok := ctors[name]; ok {
+		return ctor(cfg, logger)
+	}
+	return nil, fmt.Errorf("live tracer %q not found", name)
+}
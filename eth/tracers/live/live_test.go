@@ -0,0 +1,158 @@
+package live
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+func TestFileTracerStateChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	tracer, err := New("file", json.RawMessage(fmt.Sprintf(`{"path":%q}`, path)), log.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, tx := memdb.NewTestTx(t)
+	ibs := state.New(state.NewPlainState(tx, 1, nil))
+	ibs.SetTracer(tracer)
+
+	addr := libcommon.HexToAddress("0xaa")
+	tracer.CaptureBlockStart(&types.Header{Number: big.NewInt(7)})
+	ibs.AddBalance(addr, uint256.NewInt(100))
+	ibs.SubBalance(addr, uint256.NewInt(40))
+	ibs.SetNonce(addr, 1)
+	ibs.SetState(addr, libcommon.HexToHash("0x01"), *uint256.NewInt(5))
+	ibs.SetState(addr, libcommon.HexToHash("0x01"), *uint256.NewInt(5)) // no change, not reported
+	ibs.SetCode(addr, []byte{0x00})
+	ibs.Selfdestruct(addr)
+	tracer.CaptureBlockEnd(nil)
+	if err := tracer.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	type event struct {
+		Event  string `json:"event"`
+		Number string `json:"number"`
+		Prev   string `json:"prev"`
+		Next   string `json:"new"`
+	}
+	var events []event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, ev)
+	}
+	want := []event{
+		{Event: "blockStart", Number: "0x7"},
+		{Event: "balance", Prev: "0x0", Next: "0x64"},
+		{Event: "balance", Prev: "0x64", Next: "0x3c"},
+		{Event: "nonce", Prev: "0x0", Next: "0x1"},
+		{Event: "storage", Prev: "0x0", Next: "0x5"},
+		{Event: "code"},
+		{Event: "balance", Prev: "0x3c", Next: "0x0"},
+		{Event: "blockEnd"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, ev := range events {
+		if ev.Event == "code" {
+			ev.Prev, ev.Next = "", ""
+		}
+		if ev != want[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, want[i], ev)
+		}
+	}
+}
+
+func TestUnknownTracer(t *testing.T) {
+	if _, err := New("nope", nil, log.New()); err == nil {
+		t.Fatal("expected an error for an unknown tracer")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+func (failingWriter) Close() error              { return nil }
+
+type nopCloser struct{ *bytes.Buffer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestJsonlTracerReopen(t *testing.T) {
+	var out bytes.Buffer
+	sinks := []io.WriteCloser{failingWriter{}, nil, nopCloser{&out}}
+	open := func() (io.WriteCloser, error) {
+		w := sinks[0]
+		sinks = sinks[1:]
+		if w == nil {
+			return nil, errors.New("connection refused")
+		}
+		return w, nil
+	}
+	tracer, err := newJsonlTracer(open, log.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer.CaptureBlockStart(&types.Header{Number: big.NewInt(1)}) // write fails, the sink is closed
+	tracer.CaptureBlockEnd(nil)
+	tracer.CaptureBlockStart(&types.Header{Number: big.NewInt(2)}) // reopen fails
+	tracer.CaptureBlockEnd(nil)
+	tracer.CaptureBlockStart(&types.Header{Number: big.NewInt(3)}) // reopened
+	tracer.CaptureBlockEnd(nil)
+	tracer.CaptureUnwind(2)
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	type event struct {
+		Event         string `json:"event"`
+		Number        string `json:"number"`
+		DroppedBlocks uint64 `json:"droppedBlocks"`
+	}
+	var events []event
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var ev event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+	want := []event{
+		{Event: "reopen", DroppedBlocks: 2},
+		{Event: "blockStart", Number: "0x3"},
+		{Event: "blockEnd"},
+		{Event: "unwind", Number: "0x2"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected %+v, got %+v", want, events)
+	}
+}
@@ -0,0 +1,269 @@
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+)
+
+func init() {
+	register("gasProfiler", newGasProfiler)
+}
+
+type gasStat struct {
+	Gas   uint64 `json:"gas"`
+	Count uint64 `json:"count"`
+}
+
+type pcStat struct {
+	Op    string `json:"op"`
+	Gas   uint64 `json:"gas"`
+	Count uint64 `json:"count"`
+}
+
+// gasProfile is the result of the gas profiler. All the gas figures are self gas,
+// the gas spent by call frames is not counted again in the frames calling them.
+type gasProfile struct {
+	GasUsed      uint64                                   `json:"gasUsed"`
+	IntrinsicGas uint64                                   `json:"intrinsicGas"`
+	Refund       uint64                                   `json:"refund"`
+	Contracts    map[libcommon.Address]*gasStat           `json:"contracts"`
+	Functions    map[string]*gasStat                      `json:"functions"`
+	Opcodes      map[string]*gasStat                      `json:"opcodes"`
+	PCs          map[libcommon.Address]map[uint64]*pcStat `json:"pcs"`
+	Folded       []string                                 `json:"folded"`
+}
+
+type profileFrame struct {
+	to        libcommon.Address
+	function  string // Contract address and function selector
+	stack     string // Folded stack of the frame, the labels of the frame and its parents
+	startGas  uint64
+	pending   bool   // Whether an opcode is waiting for its gas to be settled
+	pc        uint64 // Program counter of the pending opcode
+	op        vm.OpCode
+	gas       uint64 // Gas available before the pending opcode
+	childUsed uint64 // Gas used by the frames entered by the pending opcode
+}
+
+// gasProfiler aggregates the gas spent by a transaction per contract, per
+// function selector and per opcode and program counter, across the call tree.
+// The gas of an opcode is measured as the difference of the gas available
+// before it and before the next opcode of the same frame, minus the gas used by
+// the frames it entered, so memory expansion, refunded call gas and stipends are
+// accounted exactly. The result also holds the profile in the folded stack
+// format consumed by flamegraph tools, one "frame;frame;OP@pc gas" line per
+// opcode location.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "gasProfiler"})
+//	{
+//	  "gasUsed": 51234,
+//	  "intrinsicGas": 21064,
+//	  "refund": 0,
+//	  "contracts": {"0x...": {"gas": 30170, "count": 1}},
+//	  "functions": {"0x...:0xa9059cbb": {"gas": 30170, "count": 1}},
+//	  "opcodes": {"SSTORE": {"gas": 22100, "count": 1}, ...},
+//	  "pcs": {"0x...": {"412": {"op": "SSTORE", "gas": 22100, "count": 1}, ...}},
+//	  "folded": ["0x...:0xa9059cbb;SSTORE@412 22100", ...]
+//	}
+type gasProfiler struct {
+	noopTracer
+	profile   gasProfile
+	folded    map[string]uint64
+	callstack []profileFrame
+	gasLimit  uint64
+	txEnded   bool
+	restGas   uint64
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newGasProfiler returns a native go tracer which profiles the gas spent by a
+// transaction, and implements vm.EVMLogger.
+func newGasProfiler(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	t := &gasProfiler{}
+	t.reset()
+	return t, nil
+}
+
+func (t *gasProfiler) reset() {
+	t.profile = gasProfile{
+		Contracts: make(map[libcommon.Address]*gasStat),
+		Functions: make(map[string]*gasStat),
+		Opcodes:   make(map[string]*gasStat),
+		PCs:       make(map[libcommon.Address]map[uint64]*pcStat),
+	}
+	t.folded = make(map[string]uint64)
+	t.callstack = nil
+	t.txEnded = false
+}
+
+func (t *gasProfiler) CaptureTxStart(gasLimit uint64) {
+	t.gasLimit = gasLimit
+}
+
+func (t *gasProfiler) CaptureTxEnd(restGas uint64) {
+	t.txEnded, t.restGas = true, restGas
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *gasProfiler) CaptureStart(env *vm.EVM, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	gasLimit := t.gasLimit
+	t.reset()
+	if gasLimit > gas {
+		t.profile.IntrinsicGas = gasLimit - gas
+	}
+	t.enter(to, create, input, gas)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *gasProfiler) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if len(t.callstack) != 1 {
+		return
+	}
+	t.exit(gasUsed)
+	t.profile.GasUsed = t.profile.IntrinsicGas + gasUsed
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *gasProfiler) CaptureEnter(typ vm.OpCode, from libcommon.Address, to libcommon.Address, precompile, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	if atomic.LoadUint32(&t.interrupt) > 0 || len(t.callstack) == 0 {
+		return
+	}
+	t.enter(to, create, input, gas)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *gasProfiler) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 || len(t.callstack) <= 1 {
+		return
+	}
+	t.exit(gasUsed)
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *gasProfiler) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 || len(t.callstack) == 0 {
+		return
+	}
+	frame := &t.callstack[len(t.callstack)-1]
+	if frame.pending {
+		t.settle(frame, gas)
+	}
+	frame.pending, frame.pc, frame.op, frame.gas, frame.childUsed = true, pc, op, gas, 0
+}
+
+func (t *gasProfiler) enter(to libcommon.Address, create bool, input []byte, gas uint64) {
+	function := strings.ToLower(to.Hex())
+	switch {
+	case create:
+		function += ":create"
+	case len(input) >= 4:
+		function += ":" + bytesToHex(input[:4])
+	}
+	stack := function
+	if len(t.callstack) > 0 {
+		stack = t.callstack[len(t.callstack)-1].stack + ";" + function
+	}
+	t.callstack = append(t.callstack, profileFrame{to: to, function: function, stack: stack, startGas: gas})
+	stat(t.profile.Contracts, to).Count++
+	stat(t.profile.Functions, function).Count++
+}
+
+func (t *gasProfiler) exit(gasUsed uint64) {
+	frame := &t.callstack[len(t.callstack)-1]
+	if frame.pending {
+		// The gas left once the frame returns is the gas left after its last opcode
+		t.settle(frame, frame.startGas-gasUsed)
+	} else if gasUsed > 0 {
+		// Precompiles don't execute any opcode, their gas is spent by the frame itself
+		t.profile.Contracts[frame.to].Gas += gasUsed
+		t.profile.Functions[frame.function].Gas += gasUsed
+		t.folded[frame.stack] += gasUsed
+	}
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	if len(t.callstack) > 0 {
+		t.callstack[len(t.callstack)-1].childUsed += gasUsed
+	}
+}
+
+// settle attributes to the pending opcode of the frame the gas spent until gasLeft
+// remains, less the gas used by the frames it entered.
+func (t *gasProfiler) settle(frame *profileFrame, gasLeft uint64) {
+	frame.pending = false
+	var spent uint64
+	if frame.gas > gasLeft+frame.childUsed {
+		spent = frame.gas - gasLeft - frame.childUsed
+	}
+	opName := frame.op.String()
+	t.profile.Contracts[frame.to].Gas += spent
+	t.profile.Functions[frame.function].Gas += spent
+	op := stat(t.profile.Opcodes, opName)
+	op.Gas += spent
+	op.Count++
+
+	pcs, ok := t.profile.PCs[frame.to]
+	if !ok {
+		pcs = make(map[uint64]*pcStat)
+		t.profile.PCs[frame.to] = pcs
+	}
+	loc, ok := pcs[frame.pc]
+	if !ok {
+		loc = &pcStat{Op: opName}
+		pcs[frame.pc] = loc
+	}
+	loc.Gas += spent
+	loc.Count++
+	if spent > 0 {
+		t.folded[frame.stack+";"+opName+"@"+strconv.FormatUint(frame.pc, 10)] += spent
+	}
+}
+
+func stat[K comparable](stats map[K]*gasStat, key K) *gasStat {
+	s, ok := stats[key]
+	if !ok {
+		s = new(gasStat)
+		stats[key] = s
+	}
+	return s
+}
+
+// GetResult returns the json-encoded gas profile, and any error arising
+// from the encoding or forceful termination (via `Stop`).
+func (t *gasProfiler) GetResult() (json.RawMessage, error) {
+	profile := t.profile
+	if t.txEnded && t.gasLimit >= t.restGas && profile.GasUsed > t.gasLimit-t.restGas {
+		profile.Refund = profile.GasUsed - (t.gasLimit - t.restGas)
+	}
+	profile.Folded = make([]string, 0, len(t.folded)+1)
+	if profile.IntrinsicGas > 0 {
+		profile.Folded = append(profile.Folded, fmt.Sprintf("intrinsic %d", profile.IntrinsicGas))
+	}
+	for stack, gas := range t.folded {
+		profile.Folded = append(profile.Folded, fmt.Sprintf("%s %d", stack, gas))
+	}
+	sort.Strings(profile.Folded)
+	res, err := json.Marshal(profile)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *gasProfiler) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
@@ -0,0 +1,267 @@
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutil"
+
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+)
+
+func init() {
+	register("erc20TransferTracer", newTransferTracer)
+}
+
+var (
+	// Transfer(address,address,uint256), shared by ERC-20 and ERC-721
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	// ERC-1155 TransferSingle(address,address,address,uint256,uint256)
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	// ERC-1155 TransferBatch(address,address,address,uint256[],uint256[])
+	transferBatchTopic = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+const (
+	transferTypeEth     = "eth"
+	transferTypeERC20   = "erc20"
+	transferTypeERC721  = "erc721"
+	transferTypeERC1155 = "erc1155"
+)
+
+// transfer is a movement of ether or of a token, attributed to the call frame it
+// happened in. The call frame is identified by its Parity style trace address.
+type transfer struct {
+	Type         string             `json:"type"`
+	Token        *libcommon.Address `json:"token,omitempty"`
+	Operator     *libcommon.Address `json:"operator,omitempty"`
+	From         libcommon.Address  `json:"from"`
+	To           libcommon.Address  `json:"to"`
+	TokenID      *hexutil.Big       `json:"tokenId,omitempty"`
+	Value        *hexutil.Big       `json:"value,omitempty"`
+	TraceAddress []int              `json:"traceAddress"`
+}
+
+type transferFrame struct {
+	traceAddress []int
+	calls        int        // Number of child frames entered so far
+	transfers    []transfer // Transfers of the frame and of its successful children
+}
+
+type transferTracerConfig struct {
+	WithEth bool `json:"withEth"` // If true, ether transfers are reported as well as token transfers
+}
+
+// transferTracer decodes the token transfer events of ERC-20, ERC-721 and ERC-1155
+// contracts, and optionally the movements of ether between accounts. Transfers
+// made by failed call frames are discarded.
+type transferTracer struct {
+	noopTracer
+	config    transferTracerConfig
+	callstack []transferFrame
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newTransferTracer returns a native go tracer which collects the token and
+// ether transfers of a transaction.
+func newTransferTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	config := transferTracerConfig{WithEth: true}
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	return &transferTracer{config: config}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *transferTracer) CaptureStart(env *vm.EVM, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.callstack = []transferFrame{{traceAddress: []int{}}}
+	t.addEth(from, to, value)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *transferTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if err != nil && len(t.callstack) > 0 {
+		t.callstack[0].transfers = nil
+	}
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *transferTracer) CaptureEnter(typ vm.OpCode, from libcommon.Address, to libcommon.Address, precompile, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	if atomic.LoadUint32(&t.interrupt) > 0 || len(t.callstack) == 0 {
+		return
+	}
+	parent := &t.callstack[len(t.callstack)-1]
+	traceAddress := make([]int, len(parent.traceAddress), len(parent.traceAddress)+1)
+	copy(traceAddress, parent.traceAddress)
+	t.callstack = append(t.callstack, transferFrame{traceAddress: append(traceAddress, parent.calls)})
+	parent.calls++
+
+	switch typ {
+	case vm.CALL, vm.CREATE, vm.CREATE2, vm.SELFDESTRUCT:
+		// DELEGATECALL and CALLCODE don't move ether between accounts
+		t.addEth(from, to, value)
+	}
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *transferTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 || len(t.callstack) <= 1 {
+		return
+	}
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	if err != nil {
+		return
+	}
+	parent := &t.callstack[len(t.callstack)-1]
+	parent.transfers = append(parent.transfers, call.transfers...)
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *transferTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if op < vm.LOG1 || op > vm.LOG4 || err != nil || len(t.callstack) == 0 {
+		return
+	}
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	stackData := scope.Stack.Data
+	size := int(op - vm.LOG0)
+	if len(stackData) < 2+size {
+		return
+	}
+	mStart, mSize := stackData[len(stackData)-1], stackData[len(stackData)-2]
+	topics := make([]libcommon.Hash, size)
+	for i := range topics {
+		topics[i] = stackData[len(stackData)-3-i].Bytes32()
+	}
+	if topics[0] != transferTopic && topics[0] != transferSingleTopic && topics[0] != transferBatchTopic {
+		return
+	}
+	data := scope.Memory.GetCopy(int64(mStart.Uint64()), int64(mSize.Uint64()))
+	t.addLog(scope.Contract.Address(), topics, data)
+}
+
+func (t *transferTracer) addEth(from, to libcommon.Address, value *uint256.Int) {
+	if !t.config.WithEth || value == nil || value.IsZero() {
+		return
+	}
+	t.add(transfer{Type: transferTypeEth, From: from, To: to, Value: (*hexutil.Big)(value.ToBig())})
+}
+
+func (t *transferTracer) addLog(token libcommon.Address, topics []libcommon.Hash, data []byte) {
+	switch {
+	case topics[0] == transferTopic && len(topics) == 3 && len(data) == 32:
+		t.add(transfer{
+			Type:  transferTypeERC20,
+			Token: &token,
+			From:  libcommon.BytesToAddress(topics[1][:]),
+			To:    libcommon.BytesToAddress(topics[2][:]),
+			Value: (*hexutil.Big)(new(big.Int).SetBytes(data)),
+		})
+	case topics[0] == transferTopic && len(topics) == 4:
+		t.add(transfer{
+			Type:    transferTypeERC721,
+			Token:   &token,
+			From:    libcommon.BytesToAddress(topics[1][:]),
+			To:      libcommon.BytesToAddress(topics[2][:]),
+			TokenID: (*hexutil.Big)(new(big.Int).SetBytes(topics[3][:])),
+		})
+	case topics[0] == transferSingleTopic && len(topics) == 4 && len(data) == 64:
+		operator := libcommon.BytesToAddress(topics[1][:])
+		t.add(transfer{
+			Type:     transferTypeERC1155,
+			Token:    &token,
+			Operator: &operator,
+			From:     libcommon.BytesToAddress(topics[2][:]),
+			To:       libcommon.BytesToAddress(topics[3][:]),
+			TokenID:  (*hexutil.Big)(new(big.Int).SetBytes(data[:32])),
+			Value:    (*hexutil.Big)(new(big.Int).SetBytes(data[32:])),
+		})
+	case topics[0] == transferBatchTopic && len(topics) == 4:
+		ids, values, ok := decodeTransferBatch(data)
+		if !ok {
+			return
+		}
+		operator := libcommon.BytesToAddress(topics[1][:])
+		for i := range ids {
+			t.add(transfer{
+				Type:     transferTypeERC1155,
+				Token:    &token,
+				Operator: &operator,
+				From:     libcommon.BytesToAddress(topics[2][:]),
+				To:       libcommon.BytesToAddress(topics[3][:]),
+				TokenID:  (*hexutil.Big)(ids[i]),
+				Value:    (*hexutil.Big)(values[i]),
+			})
+		}
+	}
+}
+
+func (t *transferTracer) add(tr transfer) {
+	frame := &t.callstack[len(t.callstack)-1]
+	tr.TraceAddress = frame.traceAddress
+	frame.transfers = append(frame.transfers, tr)
+}
+
+// decodeTransferBatch decodes the ABI encoded (uint256[] ids, uint256[] values)
+// data of an ERC-1155 TransferBatch event.
+func decodeTransferBatch(data []byte) (ids, values []*big.Int, ok bool) {
+	readArray := func(offsetWord int) ([]*big.Int, bool) {
+		if len(data) < (offsetWord+1)*32 {
+			return nil, false
+		}
+		offset := new(big.Int).SetBytes(data[offsetWord*32 : (offsetWord+1)*32])
+		if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(data)) {
+			return nil, false
+		}
+		start := int(offset.Uint64())
+		length := new(big.Int).SetBytes(data[start : start+32])
+		if !length.IsUint64() || length.Uint64() > uint64(len(data)-start-32)/32 {
+			return nil, false
+		}
+		items := make([]*big.Int, length.Uint64())
+		for i := range items {
+			pos := start + 32 + i*32
+			items[i] = new(big.Int).SetBytes(data[pos : pos+32])
+		}
+		return items, true
+	}
+	if ids, ok = readArray(0); !ok {
+		return nil, nil, false
+	}
+	if values, ok = readArray(1); !ok || len(ids) != len(values) {
+		return nil, nil, false
+	}
+	return ids, values, true
+}
+
+// GetResult returns the json-encoded list of transfers, and any error arising
+// from the encoding or forceful termination (via `Stop`).
+func (t *transferTracer) GetResult() (json.RawMessage, error) {
+	transfers := []transfer{}
+	if len(t.callstack) > 0 {
+		transfers = append(transfers, t.callstack[0].transfers...)
+	}
+	res, err := json.Marshal(transfers)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *transferTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
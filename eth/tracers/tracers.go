@@ -20,6 +20,7 @@ package tracers
 import (
 	"encoding/json"
 	"errors"
+	"math/big"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
@@ -29,9 +30,10 @@ import (
 // Context contains some contextual infos for a transaction execution that is not
 // available from within the EVM object.
 type Context struct {
-	BlockHash libcommon.Hash // Hash of the block the tx is contained within (zero if dangling tx or call)
-	TxIndex   int            // Index of the transaction within a block (zero if dangling tx or call)
-	TxHash    libcommon.Hash // Hash of the transaction being traced (zero if dangling call)
+	BlockHash   libcommon.Hash // Hash of the block the tx is contained within (zero if dangling tx or call)
+	BlockNumber *big.Int       // Number of the block the tx is contained within (nil if unknown)
+	TxIndex     int            // Index of the transaction within a block (zero if dangling tx or call)
+	TxHash      libcommon.Hash // Hash of the transaction being traced (zero if dangling call)
 }
 
 // Tracer interface extends vm.EVMLogger and additionally
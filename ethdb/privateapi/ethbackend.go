@@ -9,6 +9,7 @@ import (
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/diagnostics"
 	"github.com/ledgerwatch/erigon-lib/direct"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
@@ -54,6 +55,13 @@ type EthBackend interface {
 	NodesInfo(limit int) (*remote.NodesInfoReply, error)
 	Peers(ctx context.Context) (*remote.PeersReply, error)
 	AddPeer(ctx context.Context, url *remote.AddPeerRequest) (*remote.AddPeerReply, error)
+	RemovePeer(ctx context.Context, url *remote.RemovePeerRequest) (*remote.RemovePeerReply, error)
+	AddTrustedPeer(ctx context.Context, url *remote.AddPeerRequest) (*remote.AddPeerReply, error)
+	RemoveTrustedPeer(ctx context.Context, url *remote.RemovePeerRequest) (*remote.RemovePeerReply, error)
+	BanPeer(ctx context.Context, req *remote.BanPeerRequest) (*remote.BanPeerReply, error)
+	UnbanPeer(ctx context.Context, req *remote.UnbanPeerRequest) (*remote.UnbanPeerReply, error)
+	BannedPeers(ctx context.Context) (*remote.BannedPeersReply, error)
+	PeerEvents(ctx context.Context, send func(*remote.PeerEvent) error) error
 }
 
 func NewEthBackendServer(ctx context.Context, eth EthBackend, db kv.RwDB, events *shards.Events, blockReader services.FullBlockReader,
@@ -247,10 +255,46 @@ func (s *EthBackendServer) Peers(ctx context.Context, _ *emptypb.Empty) (*remote
 	return s.eth.Peers(ctx)
 }
 
+func (s *EthBackendServer) GetPeersScores() map[string]*diagnostics.PeerScore {
+	if diag, ok := s.eth.(diagnostics.PeerScoreGetter); ok {
+		return diag.GetPeersScores()
+	}
+
+	return map[string]*diagnostics.PeerScore{}
+}
+
 func (s *EthBackendServer) AddPeer(ctx context.Context, req *remote.AddPeerRequest) (*remote.AddPeerReply, error) {
 	return s.eth.AddPeer(ctx, req)
 }
 
+func (s *EthBackendServer) RemovePeer(ctx context.Context, req *remote.RemovePeerRequest) (*remote.RemovePeerReply, error) {
+	return s.eth.RemovePeer(ctx, req)
+}
+
+func (s *EthBackendServer) AddTrustedPeer(ctx context.Context, req *remote.AddPeerRequest) (*remote.AddPeerReply, error) {
+	return s.eth.AddTrustedPeer(ctx, req)
+}
+
+func (s *EthBackendServer) RemoveTrustedPeer(ctx context.Context, req *remote.RemovePeerRequest) (*remote.RemovePeerReply, error) {
+	return s.eth.RemoveTrustedPeer(ctx, req)
+}
+
+func (s *EthBackendServer) BanPeer(ctx context.Context, req *remote.BanPeerRequest) (*remote.BanPeerReply, error) {
+	return s.eth.BanPeer(ctx, req)
+}
+
+func (s *EthBackendServer) UnbanPeer(ctx context.Context, req *remote.UnbanPeerRequest) (*remote.UnbanPeerReply, error) {
+	return s.eth.UnbanPeer(ctx, req)
+}
+
+func (s *EthBackendServer) BannedPeers(ctx context.Context, _ *emptypb.Empty) (*remote.BannedPeersReply, error) {
+	return s.eth.BannedPeers(ctx)
+}
+
+func (s *EthBackendServer) PeerEvents(_ *remote.PeerEventsRequest, server remote.ETHBACKEND_PeerEventsServer) error {
+	return s.eth.PeerEvents(server.Context(), server.Send)
+}
+
 func (s *EthBackendServer) SubscribeLogs(server remote.ETHBACKEND_SubscribeLogsServer) (err error) {
 	if s.logsFilter != nil {
 		return s.logsFilter.subscribeLogs(server)
@@ -80,6 +80,7 @@ var (
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
 	errNoPort           = errors.New("node does not provide TCP port")
+	errBanned           = errors.New("banned")
 )
 
 // dialer creates outbound connections and submits them into Server.
@@ -133,10 +134,11 @@ type dialScheduler struct {
 type dialSetupFunc func(net.Conn, connFlag, *enode.Node) error
 
 type dialConfig struct {
-	self           enode.ID         // our own ID
-	maxDialPeers   int              // maximum number of dialed peers
-	maxActiveDials int              // maximum number of active dials
-	netRestrict    *netutil.Netlist // IP whitelist, disabled if nil
+	self           enode.ID            // our own ID
+	maxDialPeers   int                 // maximum number of dialed peers
+	maxActiveDials int                 // maximum number of active dials
+	netRestrict    *netutil.Netlist    // IP whitelist, disabled if nil
+	banned         func(enode.ID) bool // reports whether a node is banned, disabled if nil
 	resolver       nodeResolver
 	dialer         NodeDialer
 	log            log.Logger
@@ -417,6 +419,9 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	if d.netRestrict != nil && !d.netRestrict.Contains(n.IP()) {
 		return errNotWhitelisted
 	}
+	if d.banned != nil && d.banned(n.ID()) {
+		return errBanned
+	}
 	if d.history.contains(string(n.ID().Bytes())) {
 		return errRecentlyDialed
 	}
@@ -558,6 +563,9 @@ func (t *dialTask) dial(d *dialScheduler, dest *enode.Node) error {
 		d.mutex.Unlock()
 		return &dialError{err}
 	}
+	if _, ok := fd.(*quicConn); ok {
+		return d.setupFunc(fd, t.flags, dest)
+	}
 	mfd := newMeteredConn(fd, false, &net.TCPAddr{IP: dest.IP(), Port: dest.TCP()})
 	return d.setupFunc(mfd, t.flags, dest)
 }
@@ -45,6 +45,7 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbBanPrefix    = "ban:" // Identifier to prefix the expiry of node bans with
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
 
@@ -224,6 +225,11 @@ func localItemKey(id ID, field string) []byte {
 	return key
 }
 
+// banKey returns the database key for the ban of a node.
+func banKey(id ID) []byte {
+	return append([]byte(dbBanPrefix), id[:]...)
+}
+
 // fetchInt64 retrieves an integer associated with a particular key.
 func (db *DB) fetchInt64(key []byte) int64 {
 	var val int64
@@ -526,6 +532,55 @@ func (db *DB) UpdateFindFailsV5(id ID, ip net.IP, fails int) error {
 	return db.storeInt64(v5Key(id, ip, dbNodeFindFails), int64(fails))
 }
 
+// UpdateBan stores the time until which the node is banned.
+func (db *DB) UpdateBan(id ID, until time.Time) error {
+	return db.storeInt64(banKey(id), until.Unix())
+}
+
+// DeleteBan lifts the ban of a node.
+func (db *DB) DeleteBan(id ID) error {
+	return db.kv.Update(context.Background(), func(tx kv.RwTx) error {
+		return tx.Delete(kv.Inodes, banKey(id))
+	})
+}
+
+// Bans retrieves the nodes which are currently banned, along with the expiry of
+// their bans. Expired bans are removed from the database.
+func (db *DB) Bans() map[ID]time.Time {
+	var (
+		now     = time.Now()
+		bans    = make(map[ID]time.Time)
+		expired [][]byte
+	)
+	if err := db.kv.View(context.Background(), func(tx kv.Tx) error {
+		c, err := tx.Cursor(kv.Inodes)
+		if err != nil {
+			return err
+		}
+		p := []byte(dbBanPrefix)
+		for k, v, err := c.Seek(p); bytes.HasPrefix(k, p); k, v, err = c.Next() {
+			if err != nil {
+				return err
+			}
+			var id ID
+			copy(id[:], k[len(p):])
+			until, _ := binary.Varint(v)
+			if t := time.Unix(until, 0); t.After(now) {
+				bans[id] = t
+			} else {
+				expired = append(expired, libcommon.CopyBytes(k))
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Warn("nodeDB.Bans failed", "err", err)
+	}
+	for _, k := range expired {
+		deleteRange(db.kv, k)
+	}
+	return bans
+}
+
 // LocalSeq retrieves the local record sequence counter.
 func (db *DB) localSeq(id ID) uint64 {
 	return db.fetchUint64(localItemKey(id, dbLocalSeq))
@@ -491,3 +491,40 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+func TestDBBans(t *testing.T) {
+	root := t.TempDir()
+	var (
+		banned  = ID{1}
+		expired = ID{2}
+		lifted  = ID{3}
+		until   = time.Now().Add(time.Hour).Truncate(time.Second)
+	)
+	db, err := OpenDB(context.Background(), filepath.Join(root, "database"), root, log.Root())
+	if err != nil {
+		t.Fatalf("failed to create persistent database: %v", err)
+	}
+	for id, u := range map[ID]time.Time{banned: until, expired: time.Now().Add(-time.Minute), lifted: until} {
+		if err := db.UpdateBan(id, u); err != nil {
+			t.Fatalf("failed to store ban: %v", err)
+		}
+	}
+	if err := db.DeleteBan(lifted); err != nil {
+		t.Fatalf("failed to lift ban: %v", err)
+	}
+	db.Close()
+
+	// Bans must survive reopening the database, the expired ones are dropped.
+	db, err = OpenDB(context.Background(), filepath.Join(root, "database"), root, log.Root())
+	if err != nil {
+		t.Fatalf("failed to open persistent database: %v", err)
+	}
+	defer db.Close()
+	want := map[ID]time.Time{banned: until}
+	if bans := db.Bans(); !reflect.DeepEqual(bans, want) {
+		t.Fatalf("bans mismatch: have %v, want %v", bans, want)
+	}
+	if val := db.fetchInt64(banKey(expired)); val != 0 {
+		t.Fatalf("expired ban still stored: %v", val)
+	}
+}
@@ -75,6 +75,11 @@ type UDP6 uint16
 
 func (v UDP6) ENRKey() string { return "udp6" }
 
+// QUIC is the "quic" key, which holds the UDP port of the node accepting RLPx over QUIC.
+type QUIC uint16
+
+func (v QUIC) ENRKey() string { return "quic" }
+
 // ID is the "id" key, which holds the name of the identity scheme.
 type ID string
 
@@ -0,0 +1,143 @@
+package p2p
+
+import (
+	"sync"
+
+	"github.com/ledgerwatch/erigon-lib/metrics"
+
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+var filteredNodesMeter = metrics.GetOrCreateCounter("p2p_discovery_filtered")
+
+// NodeFilter reports whether a discovered node is worth dialing.
+type NodeFilter func(n *enode.Node) bool
+
+// ENRKeyFilter returns a filter accepting the nodes whose record has an entry with the
+// given key. If accept is not nil, the raw value of the entry has to pass it as well.
+func ENRKeyFilter(key string, accept func(value rlp.RawValue) bool) NodeFilter {
+	return func(n *enode.Node) bool {
+		var value rlp.RawValue
+		if err := n.Load(enr.WithEntry(key, &value)); err != nil {
+			return false
+		}
+		return accept == nil || accept(value)
+	}
+}
+
+// acceptNode reports whether the node passes all the configured filters
+func (srv *Server) acceptNode(n *enode.Node) bool {
+	for _, filter := range srv.NodeFilters {
+		if !filter(n) {
+			filteredNodesMeter.Inc()
+			return false
+		}
+	}
+	return true
+}
+
+// filterNodes wraps a source of the dial candidates, so that it only returns the nodes
+// passing the configured filters.
+func (srv *Server) filterNodes(it enode.Iterator) enode.Iterator {
+	if len(srv.NodeFilters) == 0 {
+		return it
+	}
+	return enode.Filter(it, srv.acceptNode)
+}
+
+// enrResolver is implemented by the discovery able to fetch the signed records of the nodes
+type enrResolver interface {
+	RequestENR(n *enode.Node) (*enode.Node, error)
+}
+
+// resolveConcurrency is the number of the record requests resolveIter keeps in flight
+const resolveConcurrency = 16
+
+// resolveIter replaces the nodes found without a record, like the ones of the discv4
+// neighbors responses, with their records requested from the nodes. The requests run
+// concurrently, so the nodes are not returned in the order of the source. The nodes not
+// answering the request are returned as they are.
+type resolveIter struct {
+	src      enode.Iterator
+	resolver enrResolver
+	nodes    chan *enode.Node
+	node     *enode.Node
+
+	closing   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func newResolveIter(it enode.Iterator, resolver enrResolver) *resolveIter {
+	ri := &resolveIter{
+		src:      it,
+		resolver: resolver,
+		nodes:    make(chan *enode.Node),
+		closing:  make(chan struct{}),
+	}
+	ri.wg.Add(1)
+	go ri.loop()
+	return ri
+}
+
+func (it *resolveIter) loop() {
+	defer it.wg.Done()
+
+	var requests sync.WaitGroup
+	defer func() {
+		requests.Wait()
+		close(it.nodes)
+	}()
+	slots := make(chan struct{}, resolveConcurrency)
+	for it.src.Next() {
+		n := it.src.Node()
+		if n.Seq() != 0 {
+			if !it.send(n) {
+				return
+			}
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-it.closing:
+			return
+		}
+		requests.Add(1)
+		go func() {
+			defer requests.Done()
+			defer func() { <-slots }()
+			if resolved, err := it.resolver.RequestENR(n); err == nil {
+				n = resolved
+			}
+			it.send(n)
+		}()
+	}
+}
+
+func (it *resolveIter) send(n *enode.Node) bool {
+	select {
+	case it.nodes <- n:
+		return true
+	case <-it.closing:
+		return false
+	}
+}
+
+func (it *resolveIter) Next() bool {
+	it.node = <-it.nodes
+	return it.node != nil
+}
+
+func (it *resolveIter) Node() *enode.Node {
+	return it.node
+}
+
+func (it *resolveIter) Close() {
+	it.closeOnce.Do(func() {
+		close(it.closing)
+		it.src.Close()
+		it.wg.Wait()
+	})
+}
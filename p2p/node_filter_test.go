@@ -0,0 +1,123 @@
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+type testENREntry string
+
+func (e testENREntry) ENRKey() string { return "test" }
+
+func TestENRKeyFilter(t *testing.T) {
+	withEntry := func(id byte, value string) *enode.Node {
+		var r enr.Record
+		if value != "" {
+			r.Set(testENREntry(value))
+		}
+		return enode.SignNull(&r, enode.ID{id})
+	}
+	hasValue := func(want string) func(rlp.RawValue) bool {
+		return func(value rlp.RawValue) bool {
+			var s string
+			return rlp.DecodeBytes(value, &s) == nil && s == want
+		}
+	}
+
+	require.True(t, ENRKeyFilter("test", nil)(withEntry(1, "a")))
+	require.False(t, ENRKeyFilter("test", nil)(withEntry(1, "")))
+	require.False(t, ENRKeyFilter("other", nil)(withEntry(1, "a")))
+	require.True(t, ENRKeyFilter("test", hasValue("a"))(withEntry(1, "a")))
+	require.False(t, ENRKeyFilter("test", hasValue("a"))(withEntry(1, "b")))
+
+	srv := &Server{Config: Config{NodeFilters: []NodeFilter{
+		ENRKeyFilter("test", nil),
+		func(n *enode.Node) bool { return n.ID() != enode.ID{2} },
+	}}}
+	it := srv.filterNodes(enode.IterNodes([]*enode.Node{withEntry(1, "a"), withEntry(2, "a"), withEntry(3, ""), withEntry(4, "b")}))
+	var ids []enode.ID
+	for it.Next() {
+		ids = append(ids, it.Node().ID())
+	}
+	require.Equal(t, []enode.ID{{1}, {4}}, ids)
+}
+
+type testResolver map[enode.ID]*enode.Node
+
+func (r testResolver) RequestENR(n *enode.Node) (*enode.Node, error) {
+	if resolved, ok := r[n.ID()]; ok {
+		return resolved, nil
+	}
+	return nil, errors.New("timeout")
+}
+
+func TestResolveIter(t *testing.T) {
+	signed := func(key *ecdsa.PrivateKey, seq uint64) *enode.Node {
+		var r enr.Record
+		r.Set(testENREntry("a"))
+		r.SetSeq(seq)
+		require.NoError(t, enode.SignV4(&r, key))
+		n, err := enode.New(enode.ValidSchemes, &r)
+		require.NoError(t, err)
+		return n
+	}
+	key1, key2, key3 := newkey(), newkey(), newkey()
+	// The nodes found by discv4 only have the keys and the endpoints
+	unresolved1 := enode.NewV4(&key1.PublicKey, nil, 30303, 30303)
+	unresolved2 := enode.NewV4(&key2.PublicKey, nil, 30303, 30303)
+	resolved2 := signed(key2, 1)
+	full := signed(key3, 2)
+
+	it := newResolveIter(enode.IterNodes([]*enode.Node{unresolved1, unresolved2, full}), testResolver{
+		unresolved2.ID(): resolved2,
+	})
+	defer it.Close()
+	var nodes []*enode.Node
+	for it.Next() {
+		nodes = append(nodes, it.Node())
+	}
+	// The nodes not answering and the ones with a record are returned as they are
+	require.ElementsMatch(t, []*enode.Node{unresolved1, resolved2, full}, nodes)
+	require.Nil(t, it.Node())
+}
+
+type blockingResolver chan struct{}
+
+func (r blockingResolver) RequestENR(n *enode.Node) (*enode.Node, error) {
+	<-r
+	return nil, errors.New("timeout")
+}
+
+func TestResolveIterConcurrent(t *testing.T) {
+	var unresolved []*enode.Node
+	for i := 0; i < resolveConcurrency; i++ {
+		key := newkey()
+		unresolved = append(unresolved, enode.NewV4(&key.PublicKey, nil, 30303, 30303))
+	}
+	key := newkey()
+	var r enr.Record
+	r.SetSeq(1)
+	require.NoError(t, enode.SignV4(&r, key))
+	full, err := enode.New(enode.ValidSchemes, &r)
+	require.NoError(t, err)
+
+	// A node with a record is not held back by the requests in flight
+	resolver := make(blockingResolver)
+	it := newResolveIter(enode.IterNodes(append(unresolved, full)), resolver)
+	require.True(t, it.Next())
+	require.Equal(t, full, it.Node())
+	close(resolver)
+	n := 0
+	for it.Next() {
+		n++
+	}
+	require.Equal(t, resolveConcurrency, n)
+	it.Close()
+}
@@ -0,0 +1,393 @@
+package portal
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+	"golang.org/x/time/rate"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/discover"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/services"
+)
+
+// HistoryNetwork is the TALKREQ protocol identifier of the Portal history network.
+const HistoryNetwork = "\x50\x0b"
+
+// Content key types of the history network. The key type is followed by the
+// block hash, or the little endian block number for BlockHeaderByNumberKey.
+const (
+	BlockHeaderKey         = 0x00
+	BlockBodyKey           = 0x01
+	ReceiptsKey            = 0x02
+	BlockHeaderByNumberKey = 0x03
+)
+
+// Selectors of the BlockHeaderProof union.
+const (
+	noProofSelector          = 0x00
+	accumulatorProofSelector = 0x01
+)
+
+const (
+	// epochSize is the number of header records in an epoch accumulator of the
+	// pre-merge blocks.
+	epochSize = 8192
+	// epochDepth is the depth of the merkle tree of an epoch accumulator.
+	epochDepth = 13
+	// accumulatorProofSize is the number of hashes of an AccumulatorProof: the
+	// total difficulty of the header record, the branch of the record in the
+	// epoch accumulator and its length.
+	accumulatorProofSize = 1 + epochDepth + 1
+
+	// maxInlineContentSize is the largest content sent in a TALKRESP, which has
+	// to fit into a single discv5 packet. Larger content has to be streamed
+	// over uTP, which is not supported, so it is answered like missing content.
+	maxInlineContentSize = 1165
+
+	epochCacheSize = 16
+
+	// epochBuildBurst and epochBuildInterval limit how often a node may make
+	// the server build the records of an epoch missing from the cache, which
+	// reads the canonical hashes and the total difficulties of its 8192 blocks.
+	epochBuildBurst    = 2
+	epochBuildInterval = time.Minute
+	sourceCacheSize    = 1024
+)
+
+// maxDataRadius is advertised in the pings, as all the history is kept by the
+// block snapshots.
+var maxDataRadius = bytes.Repeat([]byte{0xff}, 32)
+
+// HistoryServer answers the content requests of the Portal history network
+// from the block snapshots and the chain database. It only serves the content
+// and never stores or offers any. The content that doesn't fit into a single
+// TALKRESP would have to be streamed over uTP, which is not supported.
+type HistoryServer struct {
+	db          kv.RoDB
+	blockReader services.FullBlockReader
+	epochs      *lru.Cache[uint64, []libcommon.Hash] // header record roots of the epoch accumulators
+	builds      *lru.Cache[enode.ID, *rate.Limiter]  // epoch builds allowed to the requesting nodes
+	logger      log.Logger
+}
+
+func NewHistoryServer(db kv.RoDB, blockReader services.FullBlockReader, logger log.Logger) *HistoryServer {
+	epochs, err := lru.New[uint64, []libcommon.Hash](epochCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	builds, err := lru.New[enode.ID, *rate.Limiter](sourceCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	return &HistoryServer{db: db, blockReader: blockReader, epochs: epochs, builds: builds, logger: logger}
+}
+
+// Protocol returns the history network served over the V5 discovery.
+func (s *HistoryServer) Protocol() p2p.TalkProtocol {
+	return p2p.TalkProtocol{
+		Name: HistoryNetwork,
+		Handler: func(disc *discover.UDPv5) discover.TalkRequestHandler {
+			return func(id enode.ID, addr *net.UDPAddr, data []byte) []byte {
+				return s.HandleMessage(disc.LocalNode().Node(), id, data)
+			}
+		},
+	}
+}
+
+// HandleMessage answers a message of the Portal wire protocol received from the
+// given node. It returns nil if there is nothing to answer.
+func (s *HistoryServer) HandleMessage(self *enode.Node, from enode.ID, data []byte) []byte {
+	msg, err := DecodeMessage(data)
+	if err != nil {
+		s.logger.Trace("[portal] Invalid message", "from", from, "err", err)
+		return nil
+	}
+
+	var resp Message
+	switch msg := msg.(type) {
+	case *Ping:
+		resp = &Pong{EnrSeq: self.Seq(), CustomPayload: maxDataRadius}
+	case *FindNodes:
+		// The nodes of the history network are not tracked, only the own record
+		// at the distance 0 is known
+		nodes := &Nodes{Total: 1}
+		for _, d := range msg.Distances {
+			if d == 0 {
+				enc, err := rlp.EncodeToBytes(self.Record())
+				if err != nil {
+					s.logger.Warn("[portal] Encoding the node record", "err", err)
+					return nil
+				}
+				nodes.Enrs = [][]byte{enc}
+				break
+			}
+		}
+		resp = nodes
+	case *FindContent:
+		resp = &Content{Selector: ContentEnrsSelector}
+		content, err := s.content(context.Background(), msg.ContentKey, s.buildLimiter(from))
+		if err != nil {
+			s.logger.Debug("[portal] Reading content", "key", fmt.Sprintf("%x", msg.ContentKey), "err", err)
+		} else if content != nil && len(content) <= maxInlineContentSize {
+			resp = &Content{Selector: ContentPayloadSelector, Payload: content}
+		}
+	case *Offer:
+		// Declines all the content
+		resp = &Accept{ContentKeys: make([]bool, len(msg.ContentKeys))}
+	default:
+		return nil
+	}
+	return EncodeMessage(resp)
+}
+
+// ContentID returns the identifier of the content in the network.
+func ContentID(contentKey []byte) libcommon.Hash {
+	return sha256.Sum256(contentKey)
+}
+
+// buildLimiter returns the limiter of the epoch builds requested by the node.
+func (s *HistoryServer) buildLimiter(from enode.ID) *rate.Limiter {
+	limiter, ok := s.builds.Get(from)
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(epochBuildInterval), epochBuildBurst)
+		s.builds.Add(from, limiter)
+	}
+	return limiter
+}
+
+// Content returns the content of the given key, or nil if it is not known.
+func (s *HistoryServer) Content(ctx context.Context, contentKey []byte) ([]byte, error) {
+	return s.content(ctx, contentKey, nil)
+}
+
+// content returns the content of the given key. The records of the epochs
+// missing from the cache are built only if the limiter allows it, the header
+// is not known otherwise. A nil limiter allows all the builds.
+func (s *HistoryServer) content(ctx context.Context, contentKey []byte, limiter *rate.Limiter) ([]byte, error) {
+	if len(contentKey) == 0 {
+		return nil, fmt.Errorf("empty content key")
+	}
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var header *types.Header
+	switch contentKey[0] {
+	case BlockHeaderKey, BlockBodyKey, ReceiptsKey:
+		if len(contentKey) != 1+32 {
+			return nil, fmt.Errorf("invalid content key length %d", len(contentKey))
+		}
+		header, err = s.blockReader.HeaderByHash(ctx, tx, libcommon.BytesToHash(contentKey[1:]))
+	case BlockHeaderByNumberKey:
+		if len(contentKey) != 1+8 {
+			return nil, fmt.Errorf("invalid content key length %d", len(contentKey))
+		}
+		header, err = s.blockReader.HeaderByNumber(ctx, tx, binary.LittleEndian.Uint64(contentKey[1:]))
+	default:
+		return nil, fmt.Errorf("unknown content key type %d", contentKey[0])
+	}
+	if err != nil || header == nil {
+		return nil, err
+	}
+
+	switch contentKey[0] {
+	case BlockHeaderKey, BlockHeaderByNumberKey:
+		return s.headerWithProof(ctx, tx, header, limiter)
+	case BlockBodyKey:
+		return s.body(ctx, tx, header)
+	default:
+		return s.receipts(ctx, tx, header)
+	}
+}
+
+// headerWithProof encodes Container(header: ByteList, proof: BlockHeaderProof),
+// where the proof is an AccumulatorProof of the pre-merge headers and None
+// otherwise.
+func (s *HistoryServer) headerWithProof(ctx context.Context, tx kv.Tx, header *types.Header, limiter *rate.Limiter) ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	proof := []byte{noProofSelector}
+	if header.Difficulty != nil && header.Difficulty.Sign() != 0 {
+		hashes, err := s.accumulatorProof(ctx, tx, header, limiter)
+		if err != nil || hashes == nil {
+			return nil, err
+		}
+		proof = []byte{accumulatorProofSelector}
+		for _, h := range hashes {
+			proof = append(proof, h[:]...)
+		}
+	}
+	return encodeContainer(enc, proof), nil
+}
+
+// body encodes Container(transactions: List[ByteList], uncles: ByteList) with
+// the additional withdrawals: List[ByteList] since Shanghai.
+func (s *HistoryServer) body(ctx context.Context, tx kv.Tx, header *types.Header) ([]byte, error) {
+	number := header.Number.Uint64()
+	body, err := s.blockReader.BodyWithTransactions(ctx, tx, header.Hash(), number)
+	if err != nil || body == nil {
+		return nil, err
+	}
+	txs, err := types.MarshalTransactionsBinary(body.Transactions)
+	if err != nil {
+		return nil, err
+	}
+	uncles, err := rlp.EncodeToBytes(body.Uncles)
+	if err != nil {
+		return nil, err
+	}
+	if header.WithdrawalsHash == nil {
+		return encodeContainer(encodeByteLists(txs), uncles), nil
+	}
+	withdrawals := make([][]byte, len(body.Withdrawals))
+	for i, w := range body.Withdrawals {
+		if withdrawals[i], err = rlp.EncodeToBytes(w); err != nil {
+			return nil, err
+		}
+	}
+	return encodeContainer(encodeByteLists(txs), uncles, encodeByteLists(withdrawals)), nil
+}
+
+// receipts encodes List[ByteList] of the consensus encodings of the receipts.
+// The receipts are served only if they are kept in the database and match
+// the receipts root of the header.
+func (s *HistoryServer) receipts(ctx context.Context, tx kv.Tx, header *types.Header) ([]byte, error) {
+	number := header.Number.Uint64()
+	receipts := types.Receipts{}
+	if header.ReceiptHash != types.EmptyRootHash {
+		body, err := s.blockReader.BodyWithTransactions(ctx, tx, header.Hash(), number)
+		if err != nil || body == nil {
+			return nil, err
+		}
+		receipts = rawdb.ReadRawReceipts(tx, number)
+		if len(receipts) != len(body.Transactions) {
+			return nil, nil
+		}
+		for i, r := range receipts {
+			r.Type = body.Transactions[i].Type()
+			r.Bloom = types.CreateBloom(types.Receipts{r})
+		}
+		if root := types.DeriveSha(receipts); root != header.ReceiptHash {
+			return nil, fmt.Errorf("receipts root mismatch of block %d: %x != %x", number, root, header.ReceiptHash)
+		}
+	}
+	encs := make([][]byte, len(receipts))
+	var buf bytes.Buffer
+	for i := range receipts {
+		buf.Reset()
+		receipts.EncodeIndex(i, &buf)
+		encs[i] = libcommon.CopyBytes(buf.Bytes())
+	}
+	return encodeByteLists(encs), nil
+}
+
+// accumulatorProof proves the header record of the pre-merge header in its
+// epoch accumulator, from the block hash up to the root. It returns nil if the
+// records of the epoch are not known.
+func (s *HistoryServer) accumulatorProof(ctx context.Context, tx kv.Tx, header *types.Header, limiter *rate.Limiter) ([]libcommon.Hash, error) {
+	number := header.Number.Uint64()
+	records, err := s.epochRecords(ctx, tx, number/epochSize, limiter)
+	if err != nil || records == nil {
+		return nil, err
+	}
+	index := int(number % epochSize)
+	if index >= len(records) {
+		return nil, nil
+	}
+	td, err := rawdb.ReadTd(tx, header.Hash(), number)
+	if err != nil || td == nil {
+		return nil, err
+	}
+
+	proof := make([]libcommon.Hash, 0, accumulatorProofSize)
+	proof = append(proof, uint256Leaf(td))
+	layer := make([]libcommon.Hash, epochSize)
+	copy(layer, records)
+	for depth := 0; depth < epochDepth; depth++ {
+		proof = append(proof, layer[index^1])
+		next := make([]libcommon.Hash, len(layer)/2)
+		for i := range next {
+			next[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer, index = next, index/2
+	}
+	var length libcommon.Hash
+	binary.LittleEndian.PutUint64(length[:], uint64(len(records)))
+	return append(proof, length), nil
+}
+
+// epochRecords returns the roots of the header records of the epoch, which
+// ends early at the merge. It returns nil if the epoch is not cached and the
+// limiter doesn't allow to build it.
+func (s *HistoryServer) epochRecords(ctx context.Context, tx kv.Tx, epoch uint64, limiter *rate.Limiter) ([]libcommon.Hash, error) {
+	if records, ok := s.epochs.Get(epoch); ok {
+		return records, nil
+	}
+	if limiter != nil && !limiter.Allow() {
+		s.logger.Trace("[portal] Epoch build rate limited", "epoch", epoch)
+		return nil, nil
+	}
+	records := make([]libcommon.Hash, 0, epochSize)
+	var prevTd *big.Int
+	for number := epoch * epochSize; number < (epoch+1)*epochSize; number++ {
+		hash, err := s.blockReader.CanonicalHash(ctx, tx, number)
+		if err != nil {
+			return nil, err
+		}
+		if hash == (libcommon.Hash{}) {
+			return nil, nil
+		}
+		td, err := rawdb.ReadTd(tx, hash, number)
+		if err != nil {
+			return nil, err
+		}
+		if td == nil {
+			return nil, nil
+		}
+		// The post-merge blocks carry no difficulty
+		if prevTd != nil && td.Cmp(prevTd) == 0 {
+			break
+		}
+		prevTd = td
+		records = append(records, hashPair(hash, uint256Leaf(td)))
+	}
+	s.epochs.Add(epoch, records)
+	return records, nil
+}
+
+// encodeContainer encodes an SSZ container of variable size fields, which has
+// the layout of a list of variable size items.
+func encodeContainer(fields ...[]byte) []byte {
+	return encodeByteLists(fields)
+}
+
+func uint256Leaf(v *big.Int) (leaf libcommon.Hash) {
+	v.FillBytes(leaf[:])
+	for i, j := 0, len(leaf)-1; i < j; i, j = i+1, j-1 {
+		leaf[i], leaf[j] = leaf[j], leaf[i]
+	}
+	return leaf
+}
+
+func hashPair(a, b libcommon.Hash) libcommon.Hash {
+	return sha256.Sum256(append(a[:], b[:]...))
+}
@@ -0,0 +1,531 @@
+package portal
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+func TestMessageEncoding(t *testing.T) {
+	msgs := []Message{
+		&Ping{EnrSeq: 5, CustomPayload: maxDataRadius},
+		&Pong{EnrSeq: 7, CustomPayload: []byte{}},
+		&FindNodes{Distances: []uint16{0, 255, 256}},
+		&Nodes{Total: 2, Enrs: [][]byte{{1, 2, 3}, {}, {4}}},
+		&FindContent{ContentKey: []byte{BlockHeaderKey, 1, 2}},
+		&Content{Selector: ContentConnectionIDSelector, ConnectionID: [2]byte{1, 2}},
+		&Content{Selector: ContentPayloadSelector, Payload: []byte{1, 2, 3}},
+		&Content{Selector: ContentEnrsSelector, Enrs: [][]byte{{1}, {2, 3}}},
+		&Offer{ContentKeys: [][]byte{{1}, {2}}},
+		&Accept{ConnectionID: [2]byte{3, 4}, ContentKeys: []bool{true, false, false, true, false, false, false, false, true}},
+		&Accept{ContentKeys: []bool{}},
+	}
+	for _, msg := range msgs {
+		decoded, err := DecodeMessage(EncodeMessage(msg))
+		require.NoError(t, err)
+		require.Equal(t, EncodeMessage(msg), EncodeMessage(decoded))
+	}
+
+	// Ping(enr_seq=1, custom_payload=data_radius 2^256-2) of the Portal test vectors
+	ping := EncodeMessage(&Ping{EnrSeq: 1, CustomPayload: append([]byte{0xfe}, maxDataRadius[1:]...)})
+	require.Equal(t, libcommon.FromHex("0x0001000000000000000c000000feffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"), ping)
+
+	_, err := DecodeMessage([]byte{FindContentMsg, 5, 0, 0, 0})
+	require.Error(t, err)
+	_, err = DecodeMessage([]byte{0xff})
+	require.Error(t, err)
+}
+
+type testChain struct {
+	db      kv.RwDB
+	key     *ecdsa.PrivateKey
+	headers []*types.Header
+	tds     []*big.Int
+	server  *HistoryServer
+}
+
+// newTestChain writes a chain of blocks with a transaction each, the last
+// ones of which are post-merge.
+func newTestChain(t *testing.T, preMerge, postMerge int) *testChain {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	c := &testChain{db: memdb.NewTestDB(t), key: key}
+	tx, err := c.db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	for i := 0; i < preMerge+postMerge; i++ {
+		difficulty, txs := big.NewInt(131072), 1
+		if i >= preMerge {
+			difficulty = new(big.Int)
+		}
+		if i == 0 {
+			txs = 0
+		}
+		c.writeBlock(t, tx, difficulty, txs)
+	}
+	require.NoError(t, tx.Commit())
+
+	blockReader := freezeblocks.NewBlockReader(freezeblocks.NewRoSnapshots(ethconfig.BlocksFreezing{Enabled: false}, "", 1, log.New()), freezeblocks.NewBorRoSnapshots(ethconfig.BlocksFreezing{Enabled: false}, "", 1, log.New()))
+	c.server = NewHistoryServer(c.db, blockReader, log.New())
+	return c
+}
+
+// addBlock appends a post-merge block with the given number of transactions.
+func (c *testChain) addBlock(t *testing.T, txs int) *types.Header {
+	t.Helper()
+	tx, err := c.db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	header := c.writeBlock(t, tx, new(big.Int), txs)
+	require.NoError(t, tx.Commit())
+	return header
+}
+
+func (c *testChain) writeBlock(t *testing.T, tx kv.RwTx, difficulty *big.Int, txs int) *types.Header {
+	t.Helper()
+	number := uint64(len(c.headers))
+	parent, td := libcommon.Hash{}, new(big.Int)
+	if number > 0 {
+		parent, td = c.headers[number-1].Hash(), c.tds[number-1]
+	}
+	header := &types.Header{
+		ParentHash:  parent,
+		Number:      new(big.Int).SetUint64(number),
+		Difficulty:  difficulty,
+		GasLimit:    8_000_000,
+		ReceiptHash: types.EmptyRootHash,
+		TxHash:      types.EmptyRootHash,
+		UncleHash:   types.EmptyUncleHash,
+	}
+	body := &types.Body{}
+	var receipts types.Receipts
+	for i := 0; i < txs; i++ {
+		body.Transactions = append(body.Transactions, signedTx(t, c.key, number*1000+uint64(i)))
+		receipt := &types.Receipt{
+			Type:              body.Transactions[i].Type(),
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21000 * uint64(i+1),
+			Logs:              []*types.Log{{Address: libcommon.Address{1}, Topics: []libcommon.Hash{{2}}, Data: []byte{3}}},
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts = append(receipts, receipt)
+	}
+	if txs > 0 {
+		header.TxHash = types.DeriveSha(types.Transactions(body.Transactions))
+		header.ReceiptHash = types.DeriveSha(receipts)
+	}
+	hash := header.Hash()
+	td = new(big.Int).Add(td, header.Difficulty)
+
+	require.NoError(t, rawdb.WriteHeader(tx, header))
+	require.NoError(t, rawdb.WriteCanonicalHash(tx, hash, number))
+	require.NoError(t, rawdb.WriteTd(tx, hash, number, td))
+	require.NoError(t, rawdb.WriteBody(tx, hash, number, body))
+	require.NoError(t, rawdb.WriteReceipts(tx, number, receipts))
+
+	c.headers = append(c.headers, header)
+	c.tds = append(c.tds, td)
+	return header
+}
+
+func signedTx(t *testing.T, key *ecdsa.PrivateKey, nonce uint64) types.Transaction {
+	t.Helper()
+	txn := types.NewTransaction(nonce, libcommon.Address{1}, nil, 21000, nil, nil)
+	signed, err := types.SignTx(txn, *types.LatestSignerForChainID(nil), key)
+	require.NoError(t, err)
+	return signed
+}
+
+func contentKey(kind byte, hash libcommon.Hash) []byte {
+	return append([]byte{kind}, hash[:]...)
+}
+
+func splitContainer(t *testing.T, b []byte, fields int) [][]byte {
+	t.Helper()
+	split, err := decodeContainer(b, 0, fields)
+	require.NoError(t, err)
+	return split
+}
+
+func TestHeaderWithProof(t *testing.T) {
+	c := newTestChain(t, 20, 3)
+
+	// The epoch accumulator of the test, hash_tree_root(List[HeaderRecord, 8192])
+	var records []libcommon.Hash
+	for i := 0; i < 20; i++ {
+		records = append(records, hashPair(c.headers[i].Hash(), uint256Leaf(c.tds[i])))
+	}
+	zero := libcommon.Hash{}
+	layer := records
+	for depth := 0; depth < epochDepth; depth++ {
+		var next []libcommon.Hash
+		for i := 0; i < len(layer); i += 2 {
+			right := zero
+			if i+1 < len(layer) {
+				right = layer[i+1]
+			}
+			next = append(next, hashPair(layer[i], right))
+		}
+		layer, zero = next, hashPair(zero, zero)
+	}
+	var length libcommon.Hash
+	binary.LittleEndian.PutUint64(length[:], uint64(len(records)))
+	epochRoot := hashPair(layer[0], length)
+
+	for _, number := range []int{0, 7, 19} {
+		header := c.headers[number]
+		content, err := c.server.Content(context.Background(), contentKey(BlockHeaderKey, header.Hash()))
+		require.NoError(t, err)
+		fields := splitContainer(t, content, 2)
+		enc, err := rlp.EncodeToBytes(header)
+		require.NoError(t, err)
+		require.Equal(t, enc, fields[0])
+
+		proof := fields[1]
+		require.Equal(t, byte(accumulatorProofSelector), proof[0])
+		require.Len(t, proof, 1+32*accumulatorProofSize)
+		root := header.Hash()
+		index := number
+		for i := 0; i < accumulatorProofSize; i++ {
+			sibling := libcommon.BytesToHash(proof[1+32*i : 1+32*(i+1)])
+			switch {
+			case i == 0, i == accumulatorProofSize-1:
+				root = hashPair(root, sibling)
+			case index%2 == 0:
+				root = hashPair(root, sibling)
+				index /= 2
+			default:
+				root = hashPair(sibling, root)
+				index /= 2
+			}
+		}
+		require.Equal(t, epochRoot, root, "block %d", number)
+	}
+
+	// The post-merge headers are served without a proof, by hash and by number
+	byNumber := make([]byte, 9)
+	byNumber[0] = BlockHeaderByNumberKey
+	binary.LittleEndian.PutUint64(byNumber[1:], 21)
+	content, err := c.server.Content(context.Background(), byNumber)
+	require.NoError(t, err)
+	fields := splitContainer(t, content, 2)
+	require.Equal(t, []byte{noProofSelector}, fields[1])
+	var header types.Header
+	require.NoError(t, rlp.DecodeBytes(fields[0], &header))
+	require.Equal(t, c.headers[21].Hash(), header.Hash())
+
+	content, err = c.server.Content(context.Background(), contentKey(BlockHeaderKey, libcommon.Hash{1}))
+	require.NoError(t, err)
+	require.Nil(t, content)
+}
+
+func TestBodyAndReceipts(t *testing.T) {
+	c := newTestChain(t, 3, 0)
+	header := c.headers[2]
+
+	content, err := c.server.Content(context.Background(), contentKey(BlockBodyKey, header.Hash()))
+	require.NoError(t, err)
+	fields := splitContainer(t, content, 2)
+	txs, err := decodeByteLists(fields[0], 1)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	txn, err := types.DecodeTransaction(txs[0])
+	require.NoError(t, err)
+	require.Equal(t, header.TxHash, types.DeriveSha(types.Transactions{txn}))
+	require.Equal(t, []byte{0xc0}, fields[1])
+
+	content, err = c.server.Content(context.Background(), contentKey(ReceiptsKey, header.Hash()))
+	require.NoError(t, err)
+	encs, err := decodeByteLists(content, 1)
+	require.NoError(t, err)
+	require.Len(t, encs, 1)
+	var receipt types.Receipt
+	require.NoError(t, rlp.DecodeBytes(encs[0], &receipt))
+	require.Equal(t, header.ReceiptHash, types.DeriveSha(types.Receipts{&receipt}))
+
+	// The genesis has no transactions
+	content, err = c.server.Content(context.Background(), contentKey(ReceiptsKey, c.headers[0].Hash()))
+	require.NoError(t, err)
+	require.Empty(t, content)
+
+	// The headers of an incomplete epoch can't be proven
+	content, err = c.server.Content(context.Background(), contentKey(BlockHeaderKey, header.Hash()))
+	require.NoError(t, err)
+	require.Nil(t, content)
+}
+
+func TestHandleMessage(t *testing.T) {
+	c := newTestChain(t, 3, 1)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	db, err := enode.OpenDB(context.Background(), "", t.TempDir(), log.New())
+	require.NoError(t, err)
+	defer db.Close()
+	self := enode.NewLocalNode(db, key, log.New()).Node()
+
+	resp, err := DecodeMessage(c.server.HandleMessage(self, enode.ID{}, EncodeMessage(&Ping{EnrSeq: 1})))
+	require.NoError(t, err)
+	require.Equal(t, &Pong{EnrSeq: self.Seq(), CustomPayload: maxDataRadius}, resp)
+
+	resp, err = DecodeMessage(c.server.HandleMessage(self, enode.ID{}, EncodeMessage(&FindNodes{Distances: []uint16{256, 0}})))
+	require.NoError(t, err)
+	require.Len(t, resp.(*Nodes).Enrs, 1)
+
+	resp, err = DecodeMessage(c.server.HandleMessage(self, enode.ID{}, EncodeMessage(&FindContent{ContentKey: contentKey(BlockHeaderKey, c.headers[1].Hash())})))
+	require.NoError(t, err)
+	require.Equal(t, byte(ContentPayloadSelector), resp.(*Content).Selector)
+
+	resp, err = DecodeMessage(c.server.HandleMessage(self, enode.ID{}, EncodeMessage(&FindContent{ContentKey: contentKey(BlockHeaderKey, libcommon.Hash{1})})))
+	require.NoError(t, err)
+	require.Equal(t, &Content{Selector: ContentEnrsSelector}, resp)
+
+	// The bodies and the receipts fitting into a TALKRESP are sent inline
+	for _, kind := range []byte{BlockBodyKey, ReceiptsKey} {
+		key := contentKey(kind, c.headers[1].Hash())
+		content, err := c.server.Content(context.Background(), key)
+		require.NoError(t, err)
+		resp, err = DecodeMessage(c.server.HandleMessage(self, enode.ID{}, EncodeMessage(&FindContent{ContentKey: key})))
+		require.NoError(t, err)
+		require.Equal(t, &Content{Selector: ContentPayloadSelector, Payload: content}, resp)
+	}
+
+	// The larger ones would need uTP
+	header := c.addBlock(t, 16)
+	for _, kind := range []byte{BlockBodyKey, ReceiptsKey} {
+		key := contentKey(kind, header.Hash())
+		content, err := c.server.Content(context.Background(), key)
+		require.NoError(t, err)
+		require.Greater(t, len(content), maxInlineContentSize)
+		resp, err = DecodeMessage(c.server.HandleMessage(self, enode.ID{}, EncodeMessage(&FindContent{ContentKey: key})))
+		require.NoError(t, err)
+		require.Equal(t, &Content{Selector: ContentEnrsSelector}, resp)
+	}
+
+	resp, err = DecodeMessage(c.server.HandleMessage(self, enode.ID{}, EncodeMessage(&Offer{ContentKeys: [][]byte{{1}, {2}}})))
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, false}, resp.(*Accept).ContentKeys)
+
+	require.Nil(t, c.server.HandleMessage(self, enode.ID{}, []byte{0xff}))
+}
+
+func TestEpochBuildLimit(t *testing.T) {
+	c := newTestChain(t, 3, 1)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	db, err := enode.OpenDB(context.Background(), "", t.TempDir(), log.New())
+	require.NoError(t, err)
+	defer db.Close()
+	self := enode.NewLocalNode(db, key, log.New()).Node()
+
+	findHeader := func(from enode.ID) byte {
+		c.server.epochs.Purge()
+		resp, err := DecodeMessage(c.server.HandleMessage(self, from, EncodeMessage(&FindContent{ContentKey: contentKey(BlockHeaderKey, c.headers[1].Hash())})))
+		require.NoError(t, err)
+		return resp.(*Content).Selector
+	}
+	for i := 0; i < epochBuildBurst; i++ {
+		require.Equal(t, byte(ContentPayloadSelector), findHeader(enode.ID{1}))
+	}
+	// The epoch is not built again for the same node, but it is for another one
+	require.Equal(t, byte(ContentEnrsSelector), findHeader(enode.ID{1}))
+	require.Equal(t, byte(ContentPayloadSelector), findHeader(enode.ID{2}))
+
+	// The cached epochs are served without a limit
+	for i := 0; i < 2*epochBuildBurst; i++ {
+		resp, err := DecodeMessage(c.server.HandleMessage(self, enode.ID{2}, EncodeMessage(&FindContent{ContentKey: contentKey(BlockHeaderKey, c.headers[2].Hash())})))
+		require.NoError(t, err)
+		require.Equal(t, byte(ContentPayloadSelector), resp.(*Content).Selector)
+	}
+}
+
+// specTestsDir holds the mainnet vectors of the portal-spec-tests, fetched by
+// `make tests` in this directory.
+var specTestsDir = filepath.Join("testdata", "portal-spec-tests", "tests", "mainnet", "history")
+
+// specVectors collects the content keys and values of the YAML files of the
+// directory, whatever the layout of the files.
+func specVectors(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	vectors := map[string][]byte{}
+	var collect func(v any)
+	collect = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			key, okKey := v["content_key"].(string)
+			value, okValue := v["content_value"].(string)
+			if okKey && okValue && len(libcommon.FromHex(key)) > 0 {
+				vectors[string(libcommon.FromHex(key))] = libcommon.FromHex(value)
+				return
+			}
+			for _, item := range v {
+				collect(item)
+			}
+		case []any:
+			for _, item := range v {
+				collect(item)
+			}
+		}
+	}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !(strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		collect(v)
+		return nil
+	})
+	require.NoError(t, err)
+	return vectors
+}
+
+// splitOffsets splits an SSZ list of variable size items, or a container of
+// variable size fields, with no limit of the item size.
+func splitOffsets(t *testing.T, b []byte) [][]byte {
+	t.Helper()
+	if len(b) == 0 {
+		return nil
+	}
+	require.GreaterOrEqual(t, len(b), offsetSize)
+	split, err := decodeContainer(b, 0, int(binary.LittleEndian.Uint32(b))/offsetSize)
+	require.NoError(t, err)
+	return split
+}
+
+// TestSpecVectors checks the encodings of the headers with proof, the bodies
+// and the receipts against the mainnet vectors of the portal-spec-tests. The
+// bodies and the receipts of the blocks are written to the database, the
+// content served for them has to match the vectors byte for byte.
+func TestSpecVectors(t *testing.T) {
+	if _, err := os.Stat(specTestsDir); err != nil {
+		t.Skip("missing test files")
+	}
+	vectors := specVectors(t, specTestsDir)
+
+	headers := map[libcommon.Hash]*types.Header{}
+	for key, value := range vectors {
+		if key[0] != BlockHeaderKey || len(key) != 1+32 {
+			continue
+		}
+		fields := splitContainer(t, value, 2)
+		require.Equal(t, value, encodeContainer(fields[0], fields[1]))
+		header := new(types.Header)
+		require.NoError(t, rlp.DecodeBytes(fields[0], header))
+		require.Equal(t, libcommon.BytesToHash([]byte(key[1:])), header.Hash())
+		enc, err := rlp.EncodeToBytes(header)
+		require.NoError(t, err)
+		require.Equal(t, fields[0], enc)
+
+		proof := fields[1]
+		require.NotEmpty(t, proof)
+		if proof[0] == accumulatorProofSelector {
+			require.Len(t, proof, 1+32*accumulatorProofSize)
+		}
+		headers[header.Hash()] = header
+	}
+
+	db := memdb.NewTestDB(t)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	var checked []string
+	for key, value := range vectors {
+		if (key[0] != BlockBodyKey && key[0] != ReceiptsKey) || len(key) != 1+32 {
+			continue
+		}
+		header, ok := headers[libcommon.BytesToHash([]byte(key[1:]))]
+		if !ok {
+			continue
+		}
+		hash, number := header.Hash(), header.Number.Uint64()
+		require.NoError(t, rawdb.WriteHeader(tx, header))
+		require.NoError(t, rawdb.WriteCanonicalHash(tx, hash, number))
+		if key[0] == ReceiptsKey {
+			continue
+		}
+		fields := splitOffsets(t, value)
+		require.Contains(t, []int{2, 3}, len(fields))
+		body := &types.Body{}
+		for _, enc := range splitOffsets(t, fields[0]) {
+			txn, err := types.DecodeTransaction(enc)
+			require.NoError(t, err)
+			body.Transactions = append(body.Transactions, txn)
+		}
+		require.NoError(t, rlp.DecodeBytes(fields[1], &body.Uncles))
+		if len(fields) == 3 {
+			body.Withdrawals = []*types.Withdrawal{}
+			for _, enc := range splitOffsets(t, fields[2]) {
+				w := new(types.Withdrawal)
+				require.NoError(t, rlp.DecodeBytes(enc, w))
+				body.Withdrawals = append(body.Withdrawals, w)
+			}
+		}
+		require.NoError(t, rawdb.WriteBody(tx, hash, number, body))
+
+		if encs, ok := vectors[string(contentKey(ReceiptsKey, hash))]; ok {
+			var receipts types.Receipts
+			for _, enc := range splitOffsets(t, encs) {
+				// The typed receipts are wrapped into an RLP string, as in the block receipts
+				if len(enc) > 0 && enc[0] < 0x7f {
+					enc, err = rlp.EncodeToBytes(enc)
+					require.NoError(t, err)
+				}
+				receipt := new(types.Receipt)
+				require.NoError(t, rlp.DecodeBytes(enc, receipt))
+				receipts = append(receipts, receipt)
+			}
+			require.NoError(t, rawdb.WriteReceipts(tx, number, receipts))
+		}
+	}
+	require.NoError(t, tx.Commit())
+
+	blockReader := freezeblocks.NewBlockReader(freezeblocks.NewRoSnapshots(ethconfig.BlocksFreezing{Enabled: false}, "", 1, log.New()), freezeblocks.NewBorRoSnapshots(ethconfig.BlocksFreezing{Enabled: false}, "", 1, log.New()))
+	server := NewHistoryServer(db, blockReader, log.New())
+	for key, value := range vectors {
+		if (key[0] != BlockBodyKey && key[0] != ReceiptsKey) || len(key) != 1+32 {
+			continue
+		}
+		if _, ok := headers[libcommon.BytesToHash([]byte(key[1:]))]; !ok {
+			continue
+		}
+		if key[0] == ReceiptsKey {
+			if _, ok := vectors[string(contentKey(BlockBodyKey, libcommon.BytesToHash([]byte(key[1:]))))]; !ok {
+				continue
+			}
+		}
+		content, err := server.Content(context.Background(), []byte(key))
+		require.NoError(t, err)
+		require.Equal(t, value, content, "content key %x", key)
+		checked = append(checked, key)
+	}
+	require.NotEmpty(t, checked, "no body or receipts vector of a known header")
+}
@@ -0,0 +1,390 @@
+package portal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Messages of the Portal wire protocol. Every message is a single selector
+// byte followed by the SSZ encoding of the message container.
+const (
+	PingMsg        = 0x00
+	PongMsg        = 0x01
+	FindNodesMsg   = 0x02
+	NodesMsg       = 0x03
+	FindContentMsg = 0x04
+	ContentMsg     = 0x05
+	OfferMsg       = 0x06
+	AcceptMsg      = 0x07
+)
+
+// Selectors of the Content union.
+const (
+	ContentConnectionIDSelector = 0x00
+	ContentPayloadSelector      = 0x01
+	ContentEnrsSelector         = 0x02
+)
+
+const (
+	maxByteListSize   = 2048 // ByteList[2048] of the keys, payloads and ENRs
+	maxEnrs           = 32   // List[ByteList[2048], 32] of the Nodes and Content messages
+	maxDistances      = 256  // List[uint16, 256] of the FindNodes message
+	maxOfferedContent = 64   // List[ByteList[2048], 64] of the Offer message
+	offsetSize        = 4    // SSZ offset of a variable size field
+)
+
+var errInvalidMessage = errors.New("invalid portal message")
+
+// Message is a message of the Portal wire protocol.
+type Message interface {
+	Kind() byte
+	encode() []byte
+	decode(b []byte) error
+}
+
+// Ping checks the liveness of a node and carries the sub-protocol specific
+// payload, which is the data radius for the history network.
+type Ping struct {
+	EnrSeq        uint64
+	CustomPayload []byte
+}
+
+// Pong is the response to Ping.
+type Pong struct {
+	EnrSeq        uint64
+	CustomPayload []byte
+}
+
+// FindNodes requests the nodes at the given log2 distances from the recipient.
+type FindNodes struct {
+	Distances []uint16
+}
+
+// Nodes is the response to FindNodes.
+type Nodes struct {
+	Total uint8
+	Enrs  [][]byte
+}
+
+// FindContent requests the content of the given key.
+type FindContent struct {
+	ContentKey []byte
+}
+
+// Content is the response to FindContent. It is a union of the uTP connection
+// the content is streamed over, the content itself or the ENRs of the nodes
+// closer to the content.
+type Content struct {
+	Selector     byte
+	ConnectionID [2]byte
+	Payload      []byte
+	Enrs         [][]byte
+}
+
+// Offer proposes content to the recipient.
+type Offer struct {
+	ContentKeys [][]byte
+}
+
+// Accept is the response to Offer, marking the content keys the recipient
+// wants to receive.
+type Accept struct {
+	ConnectionID [2]byte
+	ContentKeys  []bool
+}
+
+// EncodeMessage returns the wire encoding of the message.
+func EncodeMessage(msg Message) []byte {
+	return append([]byte{msg.Kind()}, msg.encode()...)
+}
+
+// DecodeMessage decodes a message from its wire encoding.
+func DecodeMessage(b []byte) (Message, error) {
+	if len(b) == 0 {
+		return nil, fmt.Errorf("%w: empty", errInvalidMessage)
+	}
+	var msg Message
+	switch b[0] {
+	case PingMsg:
+		msg = &Ping{}
+	case PongMsg:
+		msg = &Pong{}
+	case FindNodesMsg:
+		msg = &FindNodes{}
+	case NodesMsg:
+		msg = &Nodes{}
+	case FindContentMsg:
+		msg = &FindContent{}
+	case ContentMsg:
+		msg = &Content{}
+	case OfferMsg:
+		msg = &Offer{}
+	case AcceptMsg:
+		msg = &Accept{}
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %d", errInvalidMessage, b[0])
+	}
+	if err := msg.decode(b[1:]); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (*Ping) Kind() byte        { return PingMsg }
+func (*Pong) Kind() byte        { return PongMsg }
+func (*FindNodes) Kind() byte   { return FindNodesMsg }
+func (*Nodes) Kind() byte       { return NodesMsg }
+func (*FindContent) Kind() byte { return FindContentMsg }
+func (*Content) Kind() byte     { return ContentMsg }
+func (*Offer) Kind() byte       { return OfferMsg }
+func (*Accept) Kind() byte      { return AcceptMsg }
+
+func (p *Ping) encode() []byte { return encodePing(p.EnrSeq, p.CustomPayload) }
+func (p *Pong) encode() []byte { return encodePing(p.EnrSeq, p.CustomPayload) }
+
+func (p *Ping) decode(b []byte) (err error) {
+	p.EnrSeq, p.CustomPayload, err = decodePing(b)
+	return err
+}
+
+func (p *Pong) decode(b []byte) (err error) {
+	p.EnrSeq, p.CustomPayload, err = decodePing(b)
+	return err
+}
+
+// Container(enr_seq: uint64, custom_payload: ByteList[2048])
+func encodePing(enrSeq uint64, payload []byte) []byte {
+	b := make([]byte, 8+offsetSize, 8+offsetSize+len(payload))
+	binary.LittleEndian.PutUint64(b, enrSeq)
+	binary.LittleEndian.PutUint32(b[8:], 8+offsetSize)
+	return append(b, payload...)
+}
+
+func decodePing(b []byte) (uint64, []byte, error) {
+	fields, err := decodeContainer(b, 8, 1)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(fields[0]) > maxByteListSize {
+		return 0, nil, fmt.Errorf("%w: custom payload too long", errInvalidMessage)
+	}
+	return binary.LittleEndian.Uint64(b), fields[0], nil
+}
+
+// Container(distances: List[uint16, 256])
+func (p *FindNodes) encode() []byte {
+	b := make([]byte, offsetSize, offsetSize+2*len(p.Distances))
+	binary.LittleEndian.PutUint32(b, offsetSize)
+	for _, d := range p.Distances {
+		b = binary.LittleEndian.AppendUint16(b, d)
+	}
+	return b
+}
+
+func (p *FindNodes) decode(b []byte) error {
+	fields, err := decodeContainer(b, 0, 1)
+	if err != nil {
+		return err
+	}
+	list := fields[0]
+	if len(list)%2 != 0 || len(list)/2 > maxDistances {
+		return fmt.Errorf("%w: distances", errInvalidMessage)
+	}
+	p.Distances = make([]uint16, len(list)/2)
+	for i := range p.Distances {
+		p.Distances[i] = binary.LittleEndian.Uint16(list[2*i:])
+	}
+	return nil
+}
+
+// Container(total: uint8, enrs: List[ByteList[2048], 32])
+func (p *Nodes) encode() []byte {
+	b := make([]byte, 1+offsetSize)
+	b[0] = p.Total
+	binary.LittleEndian.PutUint32(b[1:], 1+offsetSize)
+	return append(b, encodeByteLists(p.Enrs)...)
+}
+
+func (p *Nodes) decode(b []byte) error {
+	fields, err := decodeContainer(b, 1, 1)
+	if err != nil {
+		return err
+	}
+	p.Total = b[0]
+	p.Enrs, err = decodeByteLists(fields[0], maxEnrs)
+	return err
+}
+
+// Container(content_key: ByteList[2048])
+func (p *FindContent) encode() []byte {
+	b := make([]byte, offsetSize, offsetSize+len(p.ContentKey))
+	binary.LittleEndian.PutUint32(b, offsetSize)
+	return append(b, p.ContentKey...)
+}
+
+func (p *FindContent) decode(b []byte) error {
+	fields, err := decodeContainer(b, 0, 1)
+	if err != nil {
+		return err
+	}
+	if len(fields[0]) > maxByteListSize {
+		return fmt.Errorf("%w: content key too long", errInvalidMessage)
+	}
+	p.ContentKey = fields[0]
+	return nil
+}
+
+// Union[connection_id: Bytes2, content: ByteList, enrs: List[ByteList[2048], 32]]
+func (p *Content) encode() []byte {
+	b := []byte{p.Selector}
+	switch p.Selector {
+	case ContentConnectionIDSelector:
+		return append(b, p.ConnectionID[:]...)
+	case ContentPayloadSelector:
+		return append(b, p.Payload...)
+	default:
+		return append(b, encodeByteLists(p.Enrs)...)
+	}
+}
+
+func (p *Content) decode(b []byte) (err error) {
+	if len(b) == 0 {
+		return fmt.Errorf("%w: content selector", errInvalidMessage)
+	}
+	p.Selector = b[0]
+	switch p.Selector {
+	case ContentConnectionIDSelector:
+		if len(b) != 3 {
+			return fmt.Errorf("%w: connection id", errInvalidMessage)
+		}
+		copy(p.ConnectionID[:], b[1:])
+	case ContentPayloadSelector:
+		p.Payload = b[1:]
+	case ContentEnrsSelector:
+		p.Enrs, err = decodeByteLists(b[1:], maxEnrs)
+	default:
+		return fmt.Errorf("%w: content selector %d", errInvalidMessage, p.Selector)
+	}
+	return err
+}
+
+// Container(content_keys: List[ByteList[2048], 64])
+func (p *Offer) encode() []byte {
+	b := make([]byte, offsetSize)
+	binary.LittleEndian.PutUint32(b, offsetSize)
+	return append(b, encodeByteLists(p.ContentKeys)...)
+}
+
+func (p *Offer) decode(b []byte) error {
+	fields, err := decodeContainer(b, 0, 1)
+	if err != nil {
+		return err
+	}
+	p.ContentKeys, err = decodeByteLists(fields[0], maxOfferedContent)
+	return err
+}
+
+// Container(connection_id: Bytes2, content_keys: BitList[64])
+func (p *Accept) encode() []byte {
+	b := make([]byte, 2+offsetSize)
+	copy(b, p.ConnectionID[:])
+	binary.LittleEndian.PutUint32(b[2:], 2+offsetSize)
+	// The bitlist is terminated by a set bit following the last element
+	bits := make([]byte, len(p.ContentKeys)/8+1)
+	for i, accepted := range p.ContentKeys {
+		if accepted {
+			bits[i/8] |= 1 << (i % 8)
+		}
+	}
+	bits[len(p.ContentKeys)/8] |= 1 << (len(p.ContentKeys) % 8)
+	return append(b, bits...)
+}
+
+func (p *Accept) decode(b []byte) error {
+	fields, err := decodeContainer(b, 2, 1)
+	if err != nil {
+		return err
+	}
+	copy(p.ConnectionID[:], b)
+	bits := fields[0]
+	if len(bits) == 0 || bits[len(bits)-1] == 0 {
+		return fmt.Errorf("%w: content keys bitlist", errInvalidMessage)
+	}
+	last := bits[len(bits)-1]
+	n := 8*(len(bits)-1) - 1
+	for ; last != 0; last >>= 1 {
+		n++
+	}
+	if n > maxOfferedContent {
+		return fmt.Errorf("%w: content keys bitlist", errInvalidMessage)
+	}
+	p.ContentKeys = make([]bool, n)
+	for i := range p.ContentKeys {
+		p.ContentKeys[i] = bits[i/8]&(1<<(i%8)) != 0
+	}
+	return nil
+}
+
+// decodeContainer splits the SSZ container with the given size of the fixed
+// fields, followed by the offsets of the variable size fields, into the
+// variable size fields.
+func decodeContainer(b []byte, fixedSize, variableFields int) ([][]byte, error) {
+	start := fixedSize + variableFields*offsetSize
+	if len(b) < start {
+		return nil, fmt.Errorf("%w: container too short", errInvalidMessage)
+	}
+	fields := make([][]byte, variableFields)
+	for i := range fields {
+		from := int(binary.LittleEndian.Uint32(b[fixedSize+i*offsetSize:]))
+		to := len(b)
+		if i+1 < variableFields {
+			to = int(binary.LittleEndian.Uint32(b[fixedSize+(i+1)*offsetSize:]))
+		}
+		if (i == 0 && from != start) || from > to || to > len(b) {
+			return nil, fmt.Errorf("%w: container offsets", errInvalidMessage)
+		}
+		fields[i] = b[from:to]
+	}
+	return fields, nil
+}
+
+// encodeByteLists encodes a list of variable size byte lists.
+func encodeByteLists(lists [][]byte) []byte {
+	size := offsetSize * len(lists)
+	for _, l := range lists {
+		size += len(l)
+	}
+	b := make([]byte, offsetSize*len(lists), size)
+	for i, l := range lists {
+		binary.LittleEndian.PutUint32(b[i*offsetSize:], uint32(len(b)))
+		b = append(b, l...)
+	}
+	return b
+}
+
+func decodeByteLists(b []byte, limit int) ([][]byte, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if len(b) < offsetSize {
+		return nil, fmt.Errorf("%w: list too short", errInvalidMessage)
+	}
+	first := int(binary.LittleEndian.Uint32(b))
+	if first%offsetSize != 0 || first == 0 || first > len(b) || first/offsetSize > limit {
+		return nil, fmt.Errorf("%w: list offsets", errInvalidMessage)
+	}
+	lists := make([][]byte, first/offsetSize)
+	for i := range lists {
+		from := int(binary.LittleEndian.Uint32(b[i*offsetSize:]))
+		to := len(b)
+		if i+1 < len(lists) {
+			to = int(binary.LittleEndian.Uint32(b[(i+1)*offsetSize:]))
+		}
+		if from > to || to > len(b) || to-from > maxByteListSize {
+			return nil, fmt.Errorf("%w: list offsets", errInvalidMessage)
+		}
+		lists[i] = b[from:to]
+	}
+	return lists, nil
+}
@@ -19,6 +19,7 @@ package p2p
 import (
 	"fmt"
 
+	"github.com/ledgerwatch/erigon/p2p/discover"
 	"github.com/ledgerwatch/erigon/p2p/enode"
 	"github.com/ledgerwatch/erigon/p2p/enr"
 )
@@ -63,6 +64,17 @@ type Protocol struct {
 	Attributes []enr.Entry
 }
 
+// TalkProtocol represents a subprotocol served over the TALKREQ messages of the
+// V5 discovery.
+type TalkProtocol struct {
+	// Name is the protocol identifier carried by the talk requests.
+	Name string
+
+	// Handler is called when the V5 discovery has been started and returns
+	// the handler of the talk requests of the protocol.
+	Handler func(disc *discover.UDPv5) discover.TalkRequestHandler
+}
+
 func (p Protocol) cap() Cap {
 	return Cap{p.Name, p.Version}
 }
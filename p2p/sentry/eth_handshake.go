@@ -3,6 +3,7 @@ package sentry
 import (
 	"fmt"
 
+	"github.com/ledgerwatch/erigon-lib/direct"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
 	"github.com/ledgerwatch/erigon/core/forkid"
@@ -10,46 +11,73 @@ import (
 	"github.com/ledgerwatch/erigon/p2p"
 )
 
+// readAndValidatePeerStatusMessage reads the peer's status message in the format of the given
+// protocol version and returns the range of blocks the peer announced. Before eth/69 the peer
+// only announces its head hash, so the block numbers are left zero.
 func readAndValidatePeerStatusMessage(
 	rw p2p.MsgReadWriter,
 	status *proto_sentry.StatusData,
 	version uint,
 	minVersion uint,
-) (*eth.StatusPacket, *p2p.PeerError) {
+) (*eth.BlockRangeUpdatePacket, *p2p.PeerError) {
 	msg, err := rw.ReadMsg()
 	if err != nil {
 		return nil, p2p.NewPeerError(p2p.PeerErrorStatusReceive, p2p.DiscNetworkError, err, "readAndValidatePeerStatusMessage rw.ReadMsg error")
 	}
 
-	reply, err := tryDecodeStatusMessage(&msg)
+	if version >= direct.ETH69 {
+		var reply eth.StatusPacket69
+		err = tryDecodeStatusMessage(&msg, &reply)
+		msg.Discard()
+		if err != nil {
+			return nil, p2p.NewPeerError(p2p.PeerErrorStatusDecode, p2p.DiscProtocolError, err, "readAndValidatePeerStatusMessage tryDecodeStatusMessage error")
+		}
+
+		if reply.EarliestBlock > reply.LatestBlock {
+			return nil, p2p.NewPeerError(p2p.PeerErrorInvalidMessage, p2p.DiscProtocolError, nil, fmt.Sprintf("readAndValidatePeerStatusMessage: invalid block range: earliest %d, latest %d", reply.EarliestBlock, reply.LatestBlock))
+		}
+
+		err = checkPeerStatus69Compatibility(&reply, status, version, minVersion)
+		if err != nil {
+			return nil, p2p.NewPeerError(p2p.PeerErrorStatusIncompatible, p2p.DiscUselessPeer, err, "readAndValidatePeerStatusMessage checkPeerStatus69Compatibility error")
+		}
+
+		return &eth.BlockRangeUpdatePacket{
+			EarliestBlock:   reply.EarliestBlock,
+			LatestBlock:     reply.LatestBlock,
+			LatestBlockHash: reply.LatestBlockHash,
+		}, nil
+	}
+
+	var reply eth.StatusPacket
+	err = tryDecodeStatusMessage(&msg, &reply)
 	msg.Discard()
 	if err != nil {
 		return nil, p2p.NewPeerError(p2p.PeerErrorStatusDecode, p2p.DiscProtocolError, err, "readAndValidatePeerStatusMessage tryDecodeStatusMessage error")
 	}
 
-	err = checkPeerStatusCompatibility(reply, status, version, minVersion)
+	err = checkPeerStatusCompatibility(&reply, status, version, minVersion)
 	if err != nil {
 		return nil, p2p.NewPeerError(p2p.PeerErrorStatusIncompatible, p2p.DiscUselessPeer, err, "readAndValidatePeerStatusMessage checkPeerStatusCompatibility error")
 	}
 
-	return reply, nil
+	return &eth.BlockRangeUpdatePacket{LatestBlockHash: reply.Head}, nil
 }
 
-func tryDecodeStatusMessage(msg *p2p.Msg) (*eth.StatusPacket, error) {
+func tryDecodeStatusMessage(msg *p2p.Msg, reply interface{}) error {
 	if msg.Code != eth.StatusMsg {
-		return nil, fmt.Errorf("first msg has code %x (!= %x)", msg.Code, eth.StatusMsg)
+		return fmt.Errorf("first msg has code %x (!= %x)", msg.Code, eth.StatusMsg)
 	}
 
 	if msg.Size > eth.ProtocolMaxMsgSize {
-		return nil, fmt.Errorf("message is too large %d, limit %d", msg.Size, eth.ProtocolMaxMsgSize)
+		return fmt.Errorf("message is too large %d, limit %d", msg.Size, eth.ProtocolMaxMsgSize)
 	}
 
-	var reply eth.StatusPacket
-	if err := msg.Decode(&reply); err != nil {
-		return nil, fmt.Errorf("decode message %v: %w", msg, err)
+	if err := msg.Decode(reply); err != nil {
+		return fmt.Errorf("decode message %v: %w", msg, err)
 	}
 
-	return &reply, nil
+	return nil
 }
 
 func checkPeerStatusCompatibility(
@@ -78,3 +106,19 @@ func checkPeerStatusCompatibility(
 	forkFilter := forkid.NewFilterFromForks(status.ForkData.HeightForks, status.ForkData.TimeForks, genesisHash, status.MaxBlockHeight, status.MaxBlockTime)
 	return forkFilter(reply.ForkID)
 }
+
+func checkPeerStatus69Compatibility(
+	reply *eth.StatusPacket69,
+	status *proto_sentry.StatusData,
+	version uint,
+	minVersion uint,
+) error {
+	// Apart from the block range, eth/69 peers are checked the same way as the earlier versions
+	return checkPeerStatusCompatibility(&eth.StatusPacket{
+		ProtocolVersion: reply.ProtocolVersion,
+		NetworkID:       reply.NetworkID,
+		Head:            reply.LatestBlockHash,
+		Genesis:         reply.Genesis,
+		ForkID:          reply.ForkID,
+	}, status, version, minVersion)
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/ledgerwatch/erigon/core/forkid"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/params"
 )
 
@@ -81,3 +82,81 @@ func TestCheckPeerStatusCompatibility(t *testing.T) {
 		assert.ErrorIs(t, err, forkid.ErrLocalIncompatibleOrStale)
 	})
 }
+
+func TestCheckPeerStatus69Compatibility(t *testing.T) {
+	var version uint = direct.ETH69
+	networkID := params.MainnetChainConfig.ChainID.Uint64()
+	heightForks, timeForks := forkid.GatherForks(params.MainnetChainConfig, 0 /* genesisTime */)
+	goodReply := eth.StatusPacket69{
+		ProtocolVersion: uint32(version),
+		NetworkID:       networkID,
+		Genesis:         params.MainnetGenesisHash,
+		ForkID:          forkid.NewIDFromForks(heightForks, timeForks, params.MainnetGenesisHash, 0, 0),
+		EarliestBlock:   0,
+		LatestBlock:     0,
+		LatestBlockHash: libcommon.Hash{},
+	}
+	status := proto_sentry.StatusData{
+		NetworkId: networkID,
+		BestHash:  nil,
+		ForkData: &proto_sentry.Forks{
+			Genesis:     gointerfaces.ConvertHashToH256(params.MainnetGenesisHash),
+			HeightForks: heightForks,
+			TimeForks:   timeForks,
+		},
+		MaxBlockHeight: 0,
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		err := checkPeerStatus69Compatibility(&goodReply, &status, version, version)
+		assert.Nil(t, err)
+	})
+	t.Run("history expired", func(t *testing.T) {
+		reply := goodReply
+		reply.EarliestBlock = 100
+		reply.LatestBlock = 200
+		err := checkPeerStatus69Compatibility(&reply, &status, version, version)
+		assert.Nil(t, err)
+	})
+	t.Run("invalid block range", func(t *testing.T) {
+		reply := goodReply
+		reply.EarliestBlock = 201
+		reply.LatestBlock = 200
+		rw, peerRw := p2p.MsgPipe()
+		defer rw.Close()
+		go p2p.Send(peerRw, eth.StatusMsg, &reply) //nolint:errcheck
+		_, err := readAndValidatePeerStatusMessage(rw, &status, version, version)
+		assert.NotNil(t, err)
+		assert.Equal(t, p2p.PeerErrorInvalidMessage, err.Code)
+		assert.Equal(t, p2p.DiscProtocolError, err.Reason)
+		assert.Contains(t, err.Error(), "block range")
+	})
+	t.Run("network mismatch", func(t *testing.T) {
+		reply := goodReply
+		reply.NetworkID = 0
+		err := checkPeerStatus69Compatibility(&reply, &status, version, version)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "network")
+	})
+	t.Run("version mismatch min", func(t *testing.T) {
+		reply := goodReply
+		reply.ProtocolVersion = direct.ETH68
+		err := checkPeerStatus69Compatibility(&reply, &status, version, version)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "version is less")
+	})
+	t.Run("genesis mismatch", func(t *testing.T) {
+		reply := goodReply
+		reply.Genesis = libcommon.Hash{}
+		err := checkPeerStatus69Compatibility(&reply, &status, version, version)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "genesis")
+	})
+	t.Run("fork mismatch", func(t *testing.T) {
+		reply := goodReply
+		reply.ForkID = forkid.ID{}
+		err := checkPeerStatus69Compatibility(&reply, &status, version, version)
+		assert.NotNil(t, err)
+		assert.ErrorIs(t, err, forkid.ErrLocalIncompatibleOrStale)
+	})
+}
@@ -0,0 +1,287 @@
+package sentry
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/metrics"
+
+	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+const (
+	maxPeerScore = 100
+
+	// scoreRequestTimeout is the time after which an unanswered request counts
+	// as a useless response with the timeout as its latency.
+	scoreRequestTimeout = 20 * time.Second
+	// maxPendingRequests limits the requests tracked per peer, the oldest are
+	// expired first.
+	maxPendingRequests = 256
+	// scoreAlpha is the weight of the latest response in the moving averages
+	// of the latency and the usefulness.
+	scoreAlpha = 0.1
+	// maxScoreLatency is the latency at which the whole latency weight is lost.
+	maxScoreLatency = 5 * time.Second
+
+	usefulnessWeight = 50
+	latencyWeight    = 20
+	violationPenalty = 10
+	txSpamPenalty    = 10
+	// penaltyHalfLife is the time in which the penalties of the violations and
+	// the transaction spam are halved, so that a peer recovers from its past
+	// misbehaviour instead of carrying it for the whole connection.
+	penaltyHalfLife = 10 * time.Minute
+
+	// txSpamWindow and maxTxMessagesPerWindow bound the rate of the transaction
+	// messages, each window exceeding it is penalised.
+	txSpamWindow           = time.Minute
+	maxTxMessagesPerWindow = 600
+
+	// Once the peer slots are full, the lowest scoring peer below evictionScore
+	// is disconnected every evictionInterval to make room for a better one.
+	// Peers are not evicted in their first evictionGracePeriod.
+	evictionInterval    = 30 * time.Second
+	evictionGracePeriod = 2 * time.Minute
+	evictionScore       = 40
+)
+
+var (
+	peerScoreHistogram    = metrics.GetOrCreateHistogram("sentry_peer_score")
+	peerLatencyHistogram  = metrics.GetOrCreateHistogram("sentry_peer_response_latency_seconds")
+	peerUselessResponses  = metrics.GetOrCreateCounter("sentry_peer_useless_responses")
+	peerProtocolViolation = metrics.GetOrCreateCounter("sentry_peer_violations")
+	peerTxSpam            = metrics.GetOrCreateCounter("sentry_peer_tx_spam")
+	peerEvictions         = metrics.GetOrCreateCounter("sentry_peer_evictions")
+)
+
+// requestResponses maps the request messages of the scored peers to their responses.
+var requestResponses = map[uint64]uint64{
+	eth.GetBlockHeadersMsg:       eth.BlockHeadersMsg,
+	eth.GetBlockBodiesMsg:        eth.BlockBodiesMsg,
+	eth.GetReceiptsMsg:           eth.ReceiptsMsg,
+	eth.GetPooledTransactionsMsg: eth.PooledTransactionsMsg,
+}
+
+type pendingRequest struct {
+	response uint64 // expected response message code
+	sent     time.Time
+}
+
+// peerScore rates the quality of a peer from 0 to maxPeerScore. It combines the
+// latency and the usefulness of the responses to our requests, the protocol
+// violations and the rate of transaction messages.
+type peerScore struct {
+	lock       sync.Mutex
+	since      time.Time
+	pending    map[uint64]pendingRequest // by request id
+	latency    time.Duration             // moving average of the response latency
+	usefulness float64                   // moving average of the useful responses, from 0 to 1
+	violations float64                   // decayed number of the violations
+	txWindow   time.Time                 // start of the current transaction messages window
+	txMessages uint64                    // transaction messages in the current window
+	txSpam     float64                   // decayed number of the windows which exceeded maxTxMessagesPerWindow
+	decayed    time.Time                 // time the violations and txSpam were last decayed to
+}
+
+func newPeerScore(now time.Time) *peerScore {
+	return &peerScore{
+		since:      now,
+		pending:    map[uint64]pendingRequest{},
+		usefulness: 1,
+		txWindow:   now,
+		decayed:    now,
+	}
+}
+
+// request tracks the request sent to the peer.
+func (s *peerScore) request(msgcode uint64, data []byte, now time.Time) {
+	response, ok := requestResponses[msgcode]
+	if !ok {
+		return
+	}
+	requestID, err := packetRequestID(data)
+	if err != nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.expire(now)
+	if len(s.pending) >= maxPendingRequests {
+		var oldestID uint64
+		var oldest time.Time
+		for id, req := range s.pending {
+			if oldest.IsZero() || req.sent.Before(oldest) {
+				oldestID, oldest = id, req.sent
+			}
+		}
+		delete(s.pending, oldestID)
+		s.observe(false, scoreRequestTimeout)
+	}
+	s.pending[requestID] = pendingRequest{response: response, sent: now}
+}
+
+// response scores the response received from the peer. The responses which
+// are empty or were not requested are useless.
+func (s *peerScore) response(msgcode uint64, data []byte, now time.Time) {
+	requestID, err := packetRequestID(data)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err != nil {
+		s.decay(now)
+		s.violations++
+		peerProtocolViolation.Inc()
+		return
+	}
+	req, ok := s.pending[requestID]
+	if !ok || req.response != msgcode {
+		s.observe(false, 0)
+		return
+	}
+	delete(s.pending, requestID)
+	latency := now.Sub(req.sent)
+	peerLatencyHistogram.Observe(latency.Seconds())
+	s.observe(!emptyResponse(data), latency)
+}
+
+// violation records a breach of the protocol by the peer.
+func (s *peerScore) violation(now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.decay(now)
+	s.violations++
+	peerProtocolViolation.Inc()
+}
+
+// transactions counts a transaction message sent by the peer.
+func (s *peerScore) transactions(now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if now.Sub(s.txWindow) >= txSpamWindow {
+		s.txWindow, s.txMessages = now, 0
+	}
+	s.txMessages++
+	if s.txMessages == maxTxMessagesPerWindow+1 {
+		s.decay(now)
+		s.txSpam++
+		peerTxSpam.Inc()
+	}
+}
+
+// Score returns the score of the peer.
+func (s *peerScore) Score(now time.Time) float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.expire(now)
+	s.decay(now)
+	score := float64(maxPeerScore)
+	score -= usefulnessWeight * (1 - s.usefulness)
+	score -= latencyWeight * min(1, float64(s.latency)/float64(maxScoreLatency))
+	score -= violationPenalty * s.violations
+	score -= txSpamPenalty * s.txSpam
+	return max(0, score)
+}
+
+// expire scores the requests which were not answered in time.
+func (s *peerScore) expire(now time.Time) {
+	for id, req := range s.pending {
+		if now.Sub(req.sent) >= scoreRequestTimeout {
+			delete(s.pending, id)
+			s.observe(false, scoreRequestTimeout)
+		}
+	}
+}
+
+// decay halves the violations and the transaction spam every penaltyHalfLife.
+func (s *peerScore) decay(now time.Time) {
+	if !now.After(s.decayed) {
+		return
+	}
+	factor := math.Exp2(-float64(now.Sub(s.decayed)) / float64(penaltyHalfLife))
+	s.violations *= factor
+	s.txSpam *= factor
+	s.decayed = now
+}
+
+func (s *peerScore) observe(useful bool, latency time.Duration) {
+	var usefulness float64
+	if useful {
+		usefulness = 1
+	} else {
+		peerUselessResponses.Inc()
+	}
+	s.usefulness = (1-scoreAlpha)*s.usefulness + scoreAlpha*usefulness
+	if latency > 0 {
+		s.latency = time.Duration((1-scoreAlpha)*float64(s.latency) + scoreAlpha*float64(latency))
+	}
+}
+
+// packetRequestID returns the request id of an eth/66+ request or response.
+func packetRequestID(data []byte) (uint64, error) {
+	content, _, err := rlp.SplitList(data)
+	if err != nil {
+		return 0, err
+	}
+	requestID, _, err := rlp.SplitUint64(content)
+	return requestID, err
+}
+
+// emptyResponse reports whether the list of the eth/66+ response is empty.
+func emptyResponse(data []byte) bool {
+	content, _, err := rlp.SplitList(data)
+	if err != nil {
+		return true
+	}
+	_, rest, err := rlp.SplitUint64(content)
+	if err != nil {
+		return true
+	}
+	list, _, err := rlp.SplitList(rest)
+	return err != nil || len(list) == 0
+}
+
+// lowestScorePeer returns the peer with the lowest score below evictionScore,
+// skipping the static, trusted and recently connected peers. The scores of all
+// the peers are exported to the metrics.
+func (ss *GrpcServer) lowestScorePeer(now time.Time) (lowest *PeerInfo, lowestScore float64) {
+	lowestScore = evictionScore
+	ss.rangePeers(func(peerInfo *PeerInfo) bool {
+		score := peerInfo.score.Score(now)
+		peerScoreHistogram.Observe(score)
+		if now.Sub(peerInfo.score.since) < evictionGracePeriod {
+			return true
+		}
+		if info := peerInfo.peer.Info(); info.Network.Static || info.Network.Trusted {
+			return true
+		}
+		if score < lowestScore {
+			lowest, lowestScore = peerInfo, score
+		}
+		return true
+	})
+	return lowest, lowestScore
+}
+
+// evictPeersLoop disconnects the lowest scoring peer while the peer slots are full.
+func (ss *GrpcServer) evictPeersLoop() {
+	ticker := time.NewTicker(evictionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ss.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		peerInfo, score := ss.lowestScorePeer(time.Now())
+		if peerInfo == nil || ss.P2pServer == nil || ss.P2pServer.PeerCount() < ss.P2pServer.MaxPeers {
+			continue
+		}
+		ss.logger.Debug("[p2p] Evicting low score peer", "peerId", fmt.Sprintf("%x", peerInfo.ID())[:20], "name", peerInfo.peer.Fullname(), "score", score)
+		ss.removePeer(peerInfo.ID(), p2p.NewPeerError(p2p.PeerErrorDiscReason, p2p.DiscUselessPeer, nil, fmt.Sprintf("low peer score %.1f", score)))
+		peerEvictions.Inc()
+	}
+}
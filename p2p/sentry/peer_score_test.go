@@ -0,0 +1,132 @@
+package sentry
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// testResponse has the layout of the eth/66+ responses
+type testResponse struct {
+	RequestId uint64
+	Items     []rlp.RawValue
+}
+
+func encodePacket(t *testing.T, packet interface{}) []byte {
+	t.Helper()
+	b, err := rlp.EncodeToBytes(packet)
+	require.NoError(t, err)
+	return b
+}
+
+func TestPeerScoreResponses(t *testing.T) {
+	now := time.Now()
+	score := newPeerScore(now)
+	require.Equal(t, float64(maxPeerScore), score.Score(now))
+
+	request := encodePacket(t, &eth.GetBlockHeadersPacket66{RequestId: 1, GetBlockHeadersPacket: &eth.GetBlockHeadersPacket{Amount: 1}})
+	emptyHeaders := encodePacket(t, &testResponse{RequestId: 1})
+
+	// An empty but fast response is useless
+	score.request(eth.GetBlockHeadersMsg, request, now)
+	score.response(eth.BlockHeadersMsg, emptyHeaders, now.Add(time.Millisecond))
+	afterEmpty := score.Score(now)
+	require.Less(t, afterEmpty, float64(maxPeerScore))
+
+	// A useful response recovers the score
+	headers := encodePacket(t, &testResponse{RequestId: 2, Items: []rlp.RawValue{{0xc0}}})
+	score.request(eth.GetBlockHeadersMsg, encodePacket(t, &eth.GetBlockHeadersPacket66{RequestId: 2, GetBlockHeadersPacket: &eth.GetBlockHeadersPacket{Amount: 1}}), now)
+	score.response(eth.BlockHeadersMsg, headers, now.Add(time.Millisecond))
+	require.Greater(t, score.Score(now), afterEmpty)
+
+	// Unsolicited responses are useless
+	before := score.Score(now)
+	score.response(eth.BlockHeadersMsg, headers, now)
+	require.Less(t, score.Score(now), before)
+}
+
+func TestPeerScoreIdlePeer(t *testing.T) {
+	now := time.Now()
+	score := newPeerScore(now)
+	for i := uint64(0); i < 20; i++ {
+		score.request(eth.GetBlockBodiesMsg, encodePacket(t, &eth.GetBlockBodiesPacket66{RequestId: i}), now)
+	}
+	require.Equal(t, float64(maxPeerScore), score.Score(now))
+
+	// None of the requests is answered in time
+	later := now.Add(scoreRequestTimeout)
+	require.Less(t, score.Score(later), float64(evictionScore))
+	require.Empty(t, score.pending)
+}
+
+func TestPeerScoreViolationsAndSpam(t *testing.T) {
+	now := time.Now()
+	score := newPeerScore(now)
+	score.violation(now)
+	require.Equal(t, float64(maxPeerScore-violationPenalty), score.Score(now))
+
+	for i := 0; i <= maxTxMessagesPerWindow; i++ {
+		score.transactions(now)
+	}
+	require.Equal(t, float64(maxPeerScore-violationPenalty-txSpamPenalty), score.Score(now))
+
+	score.response(eth.BlockHeadersMsg, []byte{0x01}, now)
+	require.Equal(t, float64(maxPeerScore-2*violationPenalty-txSpamPenalty), score.Score(now))
+
+	// The rate is measured per window
+	for i := 0; i < maxTxMessagesPerWindow; i++ {
+		score.transactions(now.Add(txSpamWindow))
+	}
+	decayed := math.Exp2(-float64(txSpamWindow) / float64(penaltyHalfLife))
+	require.InDelta(t, maxPeerScore-(2*violationPenalty+txSpamPenalty)*decayed, score.Score(now.Add(txSpamWindow)), 1e-9)
+}
+
+func TestPeerScorePenaltiesDecay(t *testing.T) {
+	now := time.Now()
+	score := newPeerScore(now)
+	for i := 0; i < 4; i++ {
+		score.violation(now)
+	}
+	require.Equal(t, float64(maxPeerScore-4*violationPenalty), score.Score(now))
+	require.InDelta(t, maxPeerScore-2*violationPenalty, score.Score(now.Add(penaltyHalfLife)), 1e-9)
+
+	// A score asked for out of order does not undo the decay
+	require.InDelta(t, maxPeerScore-2*violationPenalty, score.Score(now), 1e-9)
+	score.violation(now.Add(penaltyHalfLife))
+	require.InDelta(t, maxPeerScore-3*violationPenalty, score.Score(now.Add(penaltyHalfLife)), 1e-9)
+	require.Greater(t, score.Score(now.Add(10*penaltyHalfLife)), float64(maxPeerScore-1))
+}
+
+func TestLowestScorePeer(t *testing.T) {
+	ss := &GrpcServer{ctx: context.Background(), logger: log.New()}
+	now := time.Now()
+	addPeer := func(id byte, connected time.Time, violations int) *PeerInfo {
+		peerInfo := NewPeerInfo(p2p.NewPeer(enode.ID{id}, [64]byte{id}, "test", nil, false), nil)
+		peerInfo.score = newPeerScore(connected)
+		for i := 0; i < violations; i++ {
+			peerInfo.score.violation(now)
+		}
+		ss.GoodPeers.Store(peerInfo.ID(), peerInfo)
+		return peerInfo
+	}
+
+	addPeer(1, now.Add(-time.Hour), 0)
+	addPeer(2, now, 9) // in the grace period
+	lowest, _ := ss.lowestScorePeer(now)
+	require.Nil(t, lowest)
+
+	addPeer(3, now.Add(-time.Hour), 7)
+	worst := addPeer(4, now.Add(-time.Hour), 8)
+	lowest, score := ss.lowestScorePeer(now)
+	require.Equal(t, worst, lowest)
+	require.Equal(t, float64(maxPeerScore-8*violationPenalty), score)
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/core/forkid"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/eth/protocols/snap"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/p2p/dnsdisc"
 	"github.com/ledgerwatch/erigon/p2p/enode"
@@ -50,6 +51,9 @@ const (
 	// complete before dropping the connection.= as malicious.
 	handshakeTimeout  = 5 * time.Second
 	maxPermitsPerPeer = 4 // How many outstanding requests per peer we may have
+	// blockRangeUpdateInterval is how many blocks the head has to advance before
+	// the new block range is announced to eth/69 peers
+	blockRangeUpdateInterval = 32
 )
 
 // PeerInfo collects various extra bits of information about the peer,
@@ -60,8 +64,10 @@ type PeerInfo struct {
 	deadlines     []time.Time // Request deadlines
 	latestDealine time.Time
 	height        uint64
+	earliestBlock uint64 // Lowest block the peer can serve, announced by eth/69 and later
 	rw            p2p.MsgReadWriter
 	protocol      uint
+	score         *peerScore
 
 	ctx       context.Context
 	ctxCancel context.CancelFunc
@@ -126,6 +132,7 @@ func NewPeerInfo(peer *p2p.Peer, rw p2p.MsgReadWriter) *PeerInfo {
 		rw:        rw,
 		removed:   make(chan struct{}),
 		tasks:     make(chan func(), 32),
+		score:     newPeerScore(time.Now()),
 		ctx:       ctx,
 		ctxCancel: cancel,
 	}
@@ -180,6 +187,17 @@ func (pi *PeerInfo) SetIncreasedHeight(newHeight uint64) {
 	}
 }
 
+func (pi *PeerInfo) EarliestBlock() uint64 {
+	return atomic.LoadUint64(&pi.earliestBlock)
+}
+
+// SetBlockRange updates the range of blocks the peer announced it can serve.
+// The earliest block may move either way, e.g. as the peer expires its history.
+func (pi *PeerInfo) SetBlockRange(earliest, latest uint64) {
+	atomic.StoreUint64(&pi.earliestBlock, earliest)
+	pi.SetIncreasedHeight(latest)
+}
+
 // ClearDeadlines goes through the deadlines of
 // given peers and removes the ones that have passed
 // Optionally, it also clears one extra deadline - this is used when response is received
@@ -278,32 +296,49 @@ func makeP2PServer(
 	return &p2p.Server{Config: p2pConfig}, nil
 }
 
+// handShake exchanges the Status messages with the peer and returns the range of blocks
+// the peer announced in its Status (see readAndValidatePeerStatusMessage).
 func handShake(
 	ctx context.Context,
 	status *proto_sentry.StatusData,
+	earliestBlock uint64,
 	rw p2p.MsgReadWriter,
 	version uint,
 	minVersion uint,
-) (*libcommon.Hash, *p2p.PeerError) {
+) (*eth.BlockRangeUpdatePacket, *p2p.PeerError) {
 	// Send out own handshake in a new thread
 	errChan := make(chan *p2p.PeerError, 2)
-	resultChan := make(chan *eth.StatusPacket, 1)
+	resultChan := make(chan *eth.BlockRangeUpdatePacket, 1)
 
-	ourTD := gointerfaces.ConvertH256ToUint256Int(status.TotalDifficulty)
 	// Convert proto status data into the one required by devp2p
 	genesisHash := gointerfaces.ConvertH256ToHash(status.ForkData.Genesis)
+	forkID := forkid.NewIDFromForks(status.ForkData.HeightForks, status.ForkData.TimeForks, genesisHash, status.MaxBlockHeight, status.MaxBlockTime)
 
 	go func() {
 		defer debug.LogPanic()
-		status := &eth.StatusPacket{
-			ProtocolVersion: uint32(version),
-			NetworkID:       status.NetworkId,
-			TD:              ourTD.ToBig(),
-			Head:            gointerfaces.ConvertH256ToHash(status.BestHash),
-			Genesis:         genesisHash,
-			ForkID:          forkid.NewIDFromForks(status.ForkData.HeightForks, status.ForkData.TimeForks, genesisHash, status.MaxBlockHeight, status.MaxBlockTime),
+		var packet interface{}
+		if version >= direct.ETH69 {
+			packet = &eth.StatusPacket69{
+				ProtocolVersion: uint32(version),
+				NetworkID:       status.NetworkId,
+				Genesis:         genesisHash,
+				ForkID:          forkID,
+				EarliestBlock:   earliestBlock,
+				LatestBlock:     status.MaxBlockHeight,
+				LatestBlockHash: gointerfaces.ConvertH256ToHash(status.BestHash),
+			}
+		} else {
+			ourTD := gointerfaces.ConvertH256ToUint256Int(status.TotalDifficulty)
+			packet = &eth.StatusPacket{
+				ProtocolVersion: uint32(version),
+				NetworkID:       status.NetworkId,
+				TD:              ourTD.ToBig(),
+				Head:            gointerfaces.ConvertH256ToHash(status.BestHash),
+				Genesis:         genesisHash,
+				ForkID:          forkID,
+			}
 		}
-		err := p2p.Send(rw, eth.StatusMsg, status)
+		err := p2p.Send(rw, eth.StatusMsg, packet)
 
 		if err == nil {
 			errChan <- nil
@@ -314,10 +349,10 @@ func handShake(
 
 	go func() {
 		defer debug.LogPanic()
-		status, err := readAndValidatePeerStatusMessage(rw, status, version, minVersion)
+		peerRange, err := readAndValidatePeerStatusMessage(rw, status, version, minVersion)
 
 		if err == nil {
-			resultChan <- status
+			resultChan <- peerRange
 			errChan <- nil
 		} else {
 			errChan <- err
@@ -339,8 +374,7 @@ func handShake(
 		}
 	}
 
-	peerStatus := <-resultChan
-	return &peerStatus.Head, nil
+	return <-resultChan, nil
 }
 
 func runPeer(
@@ -416,6 +450,7 @@ func runPeer(
 			if _, err := io.ReadFull(msg.Payload, b); err != nil {
 				logger.Error(fmt.Sprintf("%s: reading msg into bytes: %v", peerID, err))
 			}
+			peerInfo.score.response(msg.Code, b, time.Now())
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
 		case eth.GetBlockBodiesMsg:
 			if !hasSubscribers(eth.ToProto[protocol][msg.Code]) {
@@ -435,6 +470,7 @@ func runPeer(
 			if _, err := io.ReadFull(msg.Payload, b); err != nil {
 				logger.Error(fmt.Sprintf("%s: reading msg into bytes: %v", peerID, err))
 			}
+			peerInfo.score.response(msg.Code, b, time.Now())
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
 		case eth.GetNodeDataMsg:
 			if protocol >= direct.ETH67 {
@@ -468,6 +504,14 @@ func runPeer(
 			if _, err := io.ReadFull(msg.Payload, b); err != nil {
 				logger.Error(fmt.Sprintf("%s: reading msg into bytes: %v", peerID, err))
 			}
+			peerInfo.score.response(msg.Code, b, time.Now())
+			if protocol >= direct.ETH69 {
+				// The core only understands receipts with blooms
+				if b, err = eth.ReceiptsPacket69To66(b); err != nil {
+					msg.Discard()
+					return p2p.NewPeerError(p2p.PeerErrorInvalidMessage, p2p.DiscProtocolError, err, "sentry.runPeer: invalid eth/69 receipts")
+				}
+			}
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
 			//log.Info(fmt.Sprintf("[%s] ReceiptsMsg", peerID))
 		case eth.NewBlockHashesMsg:
@@ -491,6 +535,7 @@ func runPeer(
 			//log.Debug("NewBlockMsg from", "peerId", fmt.Sprintf("%x", peerID)[:20], "name", peerInfo.peer.Name())
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
 		case eth.NewPooledTransactionHashesMsg:
+			peerInfo.score.transactions(time.Now())
 			if !hasSubscribers(eth.ToProto[protocol][msg.Code]) {
 				continue
 			}
@@ -511,6 +556,7 @@ func runPeer(
 			}
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
 		case eth.TransactionsMsg:
+			peerInfo.score.transactions(time.Now())
 			if !hasSubscribers(eth.ToProto[protocol][msg.Code]) {
 				continue
 			}
@@ -529,11 +575,26 @@ func runPeer(
 			if _, err := io.ReadFull(msg.Payload, b); err != nil {
 				logger.Error(fmt.Sprintf("%s: reading msg into bytes: %v", peerID, err))
 			}
+			peerInfo.score.response(msg.Code, b, time.Now())
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
+		case eth.BlockRangeUpdateMsg:
+			if protocol < direct.ETH69 {
+				msg.Discard()
+				return p2p.NewPeerError(p2p.PeerErrorInvalidMessageCode, p2p.DiscSubprotocolError, nil, fmt.Sprintf("unexpected BlockRangeUpdateMsg from %s in eth/%d", peerID, protocol))
+			}
+			var update eth.BlockRangeUpdatePacket
+			if err := msg.Decode(&update); err != nil {
+				return p2p.NewPeerError(p2p.PeerErrorInvalidMessage, p2p.DiscProtocolError, err, "sentry.runPeer: invalid BlockRangeUpdateMsg")
+			}
+			if update.EarliestBlock > update.LatestBlock {
+				return p2p.NewPeerError(p2p.PeerErrorInvalidMessage, p2p.DiscProtocolError, nil, fmt.Sprintf("sentry.runPeer: invalid block range: earliest %d, latest %d", update.EarliestBlock, update.LatestBlock))
+			}
+			peerInfo.SetBlockRange(update.EarliestBlock, update.LatestBlock)
 		case 11:
 			// Ignore
 			// TODO: Investigate why BSC peers for eth/67 send these messages
 		default:
+			peerInfo.score.violation(time.Now())
 			logger.Error(fmt.Sprintf("[p2p] Unknown message code: %d, peerID=%x", msg.Code, peerID))
 		}
 
@@ -578,12 +639,14 @@ func grpcSentryServer(ctx context.Context, sentryAddr string, ss *GrpcServer, he
 	return grpcServer, nil
 }
 
-func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, readNodeInfo func() *eth.NodeInfo, cfg *p2p.Config, protocol uint, logger log.Logger) *GrpcServer {
+func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, readNodeInfo func() *eth.NodeInfo, readEarliestBlock func(headHeight uint64) uint64, snapServer *snap.Server, cfg *p2p.Config, protocol uint, logger log.Logger) *GrpcServer {
 	ss := &GrpcServer{
-		ctx:          ctx,
-		p2p:          cfg,
-		peersStreams: NewPeersStreams(),
-		logger:       logger,
+		ctx:               ctx,
+		p2p:               cfg,
+		peersStreams:      NewPeersStreams(),
+		readEarliestBlock: readEarliestBlock,
+		snapServer:        snapServer,
+		logger:            logger,
 	}
 
 	var disc enode.Iterator
@@ -591,15 +654,24 @@ func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, re
 		disc = dialCandidates()
 	}
 	protocols := []uint{protocol}
-	if protocol == direct.ETH67 {
+	switch protocol {
+	case direct.ETH67:
 		protocols = append(protocols, direct.ETH66)
+	case direct.ETH68:
+		// eth/69 is negotiated alongside eth/68, the core keeps talking eth/68 and
+		// the sentry translates the messages which changed
+		protocols = append(protocols, direct.ETH69)
 	}
 	for _, p := range protocols {
 		protocol := p
+		length := uint64(17)
+		if protocol >= direct.ETH69 {
+			length = 18 // BlockRangeUpdateMsg
+		}
 		ss.Protocols = append(ss.Protocols, p2p.Protocol{
 			Name:           eth.ProtocolName,
 			Version:        protocol,
-			Length:         17,
+			Length:         length,
 			DialCandidates: disc,
 			Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) *p2p.PeerError {
 				peerID := peer.Pubkey()
@@ -621,17 +693,18 @@ func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, re
 					return p2p.NewPeerError(p2p.PeerErrorLocalStatusNeeded, p2p.DiscProtocolError, nil, "could not get status message from core")
 				}
 
-				peerBestHash, err := handShake(ctx, status, rw, protocol, protocol)
+				peerRange, err := handShake(ctx, status, ss.earliestBlock(status.MaxBlockHeight), rw, protocol, protocol)
 				if err != nil {
 					return err
 				}
+				peerInfo.SetBlockRange(peerRange.EarliestBlock, peerRange.LatestBlock)
 
 				// handshake is successful
 				logger.Trace("[p2p] Received status message OK", "peerId", printablePeerID, "name", peer.Name())
 
 				ss.GoodPeers.Store(peerID, peerInfo)
 				ss.sendNewPeerToClients(gointerfaces.ConvertHashToH512(peerID))
-				getBlockHeadersErr := ss.getBlockHeaders(ctx, *peerBestHash, peerID)
+				getBlockHeadersErr := ss.getBlockHeaders(ctx, peerRange.LatestBlockHash, peerID)
 				if getBlockHeadersErr != nil {
 					return p2p.NewPeerError(p2p.PeerErrorFirstMessageSend, p2p.DiscNetworkError, getBlockHeadersErr, "p2p.Protocol.Run getBlockHeaders failure")
 				}
@@ -661,6 +734,10 @@ func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, re
 			//Attributes: []enr.Entry{eth.CurrentENREntry(chainConfig, genesisHash, headHeight)},
 		})
 	}
+	if protocol == direct.ETH68 && snapServer != nil {
+		ss.Protocols = append(ss.Protocols, ss.snapProtocol())
+	}
+	go ss.evictPeersLoop()
 
 	return ss
 }
@@ -676,7 +753,8 @@ func Sentry(ctx context.Context, dirs datadir.Dirs, sentryAddr string, discovery
 		}
 		return d
 	}
-	sentryServer := NewGrpcServer(ctx, discovery, func() *eth.NodeInfo { return nil }, cfg, protocolVersion, logger)
+	// a standalone sentry has no access to the database: it announces the full history and does not serve snap
+	sentryServer := NewGrpcServer(ctx, discovery, func() *eth.NodeInfo { return nil }, nil, nil, cfg, protocolVersion, logger)
 	sentryServer.discoveryDNS = discoveryDNS
 
 	grpcServer, err := grpcSentryServer(ctx, sentryAddr, sentryServer, healthCheck)
@@ -697,6 +775,7 @@ type GrpcServer struct {
 	discoveryDNS         []string
 	GoodPeers            sync.Map
 	statusData           *proto_sentry.StatusData
+	lastBlockRangeUpdate uint64 // head announced to eth/69 peers in the last BlockRangeUpdate
 	P2pServer            *p2p.Server
 	TxSubscribed         uint32 // Set to non-zero if downloader is subscribed to transaction messages
 	lock                 sync.RWMutex
@@ -705,9 +784,19 @@ type GrpcServer struct {
 	messageStreamsLock   sync.RWMutex
 	peersStreams         *PeersStreams
 	p2p                  *p2p.Config
+	readEarliestBlock    func(headHeight uint64) uint64 // lowest block whose bodies and receipts are served, nil for the full history
+	snapServer           *snap.Server                   // answers the snap/1 requests, nil if the state is not served
 	logger               log.Logger
 }
 
+// earliestBlock returns the lowest block announced to the eth/69 peers
+func (ss *GrpcServer) earliestBlock(headHeight uint64) uint64 {
+	if ss.readEarliestBlock == nil {
+		return 0
+	}
+	return ss.readEarliestBlock(headHeight)
+}
+
 func (ss *GrpcServer) rangePeers(f func(peerInfo *PeerInfo) bool) {
 	ss.GoodPeers.Range(func(key, value interface{}) bool {
 		peerInfo, _ := value.(*PeerInfo)
@@ -740,6 +829,13 @@ func (ss *GrpcServer) removePeer(peerID [64]byte, reason *p2p.PeerError) {
 
 func (ss *GrpcServer) writePeer(logPrefix string, peerInfo *PeerInfo, msgcode uint64, data []byte, ttl time.Duration) {
 	peerInfo.Async(func() {
+		if msgcode == eth.ReceiptsMsg && peerInfo.protocol >= direct.ETH69 {
+			var err error
+			if data, err = eth.ReceiptsPacket66To69(data); err != nil {
+				ss.logger.Debug(fmt.Sprintf("%s writePeer msgcode=%d", logPrefix, msgcode), "err", err)
+				return
+			}
+		}
 
 		cap := p2p.Cap{Name: eth.ProtocolName, Version: peerInfo.protocol}
 		msgType := eth.ToProto[cap.Version][msgcode]
@@ -753,6 +849,7 @@ func (ss *GrpcServer) writePeer(logPrefix string, peerInfo *PeerInfo, msgcode ui
 			if ttl > 0 {
 				peerInfo.AddDeadline(time.Now().Add(ttl))
 			}
+			peerInfo.score.request(msgcode, data, time.Now())
 		}
 	}, ss.logger)
 }
@@ -786,6 +883,9 @@ func (ss *GrpcServer) PenalizePeer(_ context.Context, req *proto_sentry.Penalize
 	//log.Warn("Received penalty", "kind", req.GetPenalty().Descriptor().FullName, "from", fmt.Sprintf("%s", req.GetPeerId()))
 	peerID := ConvertH512ToPeerID(req.PeerId)
 	peerInfo := ss.getPeer(peerID)
+	if peerInfo != nil {
+		peerInfo.score.violation(time.Now())
+	}
 	if ss.statusData != nil && peerInfo != nil && !peerInfo.peer.Info().Network.Static && !peerInfo.peer.Info().Network.Trusted {
 		ss.removePeer(peerID, p2p.NewPeerError(p2p.PeerErrorDiscReason, p2p.DiscRequested, nil, "penalized peer"))
 	}
@@ -843,7 +943,8 @@ func (ss *GrpcServer) findPeerByMinBlock(minBlock uint64) (*PeerInfo, bool) {
 	var maxPermits int
 	now := time.Now()
 	ss.rangePeers(func(peerInfo *PeerInfo) bool {
-		if peerInfo.Height() >= minBlock {
+		// Peers which expired their history cannot serve the blocks below their earliest one
+		if peerInfo.Height() >= minBlock && peerInfo.EarliestBlock() <= minBlock {
 			deadlines := peerInfo.ClearDeadlines(now, false /* givePermit */)
 			//fmt.Printf("%d deadlines for peer %s\n", deadlines, peerID)
 			if deadlines < maxPermitsPerPeer {
@@ -994,15 +1095,20 @@ func (ss *GrpcServer) SetStatus(ctx context.Context, statusData *proto_sentry.St
 					ss.discoveryDNS = []string{url}
 				}
 			}
-			for _, p := range ss.Protocols {
-				p.DialCandidates, err = setupDiscovery(ss.discoveryDNS)
+			for i := range ss.Protocols {
+				ss.Protocols[i].DialCandidates, err = setupDiscovery(ss.discoveryDNS)
 				if err != nil {
 					return nil, err
 				}
 			}
 		}
 
-		srv, err := makeP2PServer(*ss.p2p, genesisHash, ss.Protocols)
+		p2pConfig := *ss.p2p
+		if p2pConfig.DiscoveryForkFilter {
+			// Skip dialing the nodes which advertise an incompatible fork in their records
+			p2pConfig.NodeFilters = append(append([]p2p.NodeFilter{}, p2pConfig.NodeFilters...), eth.NewNodeFilter(ss.forkFilter))
+		}
+		srv, err := makeP2PServer(p2pConfig, genesisHash, ss.Protocols)
 		if err != nil {
 			return reply, err
 		}
@@ -1020,10 +1126,36 @@ func (ss *GrpcServer) SetStatus(ctx context.Context, statusData *proto_sentry.St
 	if ss.statusData == nil || statusData.MaxBlockHeight != 0 {
 		// Not overwrite statusData if the message contains zero MaxBlock (comes from standalone transaction pool)
 		ss.statusData = statusData
+		ss.announceBlockRange(statusData)
 	}
 	return reply, nil
 }
 
+// announceBlockRange sends BlockRangeUpdate to the eth/69 peers once the head
+// has advanced by blockRangeUpdateInterval blocks since the last announcement
+func (ss *GrpcServer) announceBlockRange(statusData *proto_sentry.StatusData) {
+	if statusData.MaxBlockHeight < ss.lastBlockRangeUpdate+blockRangeUpdateInterval {
+		return
+	}
+	ss.lastBlockRangeUpdate = statusData.MaxBlockHeight
+
+	b, err := rlp.EncodeToBytes(&eth.BlockRangeUpdatePacket{
+		EarliestBlock:   ss.earliestBlock(statusData.MaxBlockHeight),
+		LatestBlock:     statusData.MaxBlockHeight,
+		LatestBlockHash: gointerfaces.ConvertH256ToHash(statusData.BestHash),
+	})
+	if err != nil {
+		ss.logger.Error("[sentry] announceBlockRange encode packet failed", "err", err)
+		return
+	}
+	ss.rangePeers(func(peerInfo *PeerInfo) bool {
+		if peerInfo.protocol >= direct.ETH69 {
+			ss.writePeer("[sentry] announceBlockRange", peerInfo, eth.BlockRangeUpdateMsg, b, 0)
+		}
+		return true
+	})
+}
+
 func (ss *GrpcServer) Peers(_ context.Context, _ *emptypb.Empty) (*proto_sentry.PeersReply, error) {
 	if ss.P2pServer == nil {
 		return nil, errors.New("p2p server was not started")
@@ -1062,6 +1194,17 @@ func (ss *GrpcServer) DiagnosticsPeersData() map[string]*diagnostics.PeerStatist
 	return peers
 }
 
+// GetPeersScores returns the scores of the connected peers by their id.
+func (ss *GrpcServer) GetPeersScores() map[string]*diagnostics.PeerScore {
+	now := time.Now()
+	scores := make(map[string]*diagnostics.PeerScore)
+	ss.rangePeers(func(peerInfo *PeerInfo) bool {
+		scores[peerInfo.peer.ID().String()] = &diagnostics.PeerScore{Score: peerInfo.score.Score(now)}
+		return true
+	})
+	return scores
+}
+
 func (ss *GrpcServer) SimplePeerCount() map[uint]int {
 	counts := map[uint]int{}
 	ss.rangePeers(func(peerInfo *PeerInfo) bool {
@@ -1116,6 +1259,13 @@ func setupDiscovery(urls []string) (enode.Iterator, error) {
 	return client.NewIterator(urls...)
 }
 
+// forkFilter returns the filter of the fork IDs compatible with the current head
+func (ss *GrpcServer) forkFilter() forkid.Filter {
+	status := ss.GetStatus()
+	genesisHash := gointerfaces.ConvertH256ToHash(status.ForkData.Genesis)
+	return forkid.NewFilterFromForks(status.ForkData.HeightForks, status.ForkData.TimeForks, genesisHash, status.MaxBlockHeight, status.MaxBlockTime)
+}
+
 func (ss *GrpcServer) GetStatus() *proto_sentry.StatusData {
 	ss.lock.RLock()
 	defer ss.lock.RUnlock()
@@ -1242,6 +1392,78 @@ func (ss *GrpcServer) AddPeer(_ context.Context, req *proto_sentry.AddPeerReques
 	return &proto_sentry.AddPeerReply{Success: true}, nil
 }
 
+func (ss *GrpcServer) RemovePeer(_ context.Context, req *proto_sentry.RemovePeerRequest) (*proto_sentry.RemovePeerReply, error) {
+	if ss.P2pServer == nil {
+		return nil, errors.New("p2p server was not started")
+	}
+	node, err := enode.Parse(enode.ValidSchemes, req.Url)
+	if err != nil {
+		return nil, err
+	}
+	ss.P2pServer.RemovePeer(node)
+	return &proto_sentry.RemovePeerReply{Success: true}, nil
+}
+
+func (ss *GrpcServer) AddTrustedPeer(_ context.Context, req *proto_sentry.AddPeerRequest) (*proto_sentry.AddPeerReply, error) {
+	if ss.P2pServer == nil {
+		return nil, errors.New("p2p server was not started")
+	}
+	node, err := enode.Parse(enode.ValidSchemes, req.Url)
+	if err != nil {
+		return nil, err
+	}
+	ss.P2pServer.AddTrustedPeer(node)
+	return &proto_sentry.AddPeerReply{Success: true}, nil
+}
+
+func (ss *GrpcServer) RemoveTrustedPeer(_ context.Context, req *proto_sentry.RemovePeerRequest) (*proto_sentry.RemovePeerReply, error) {
+	if ss.P2pServer == nil {
+		return nil, errors.New("p2p server was not started")
+	}
+	node, err := enode.Parse(enode.ValidSchemes, req.Url)
+	if err != nil {
+		return nil, err
+	}
+	ss.P2pServer.RemoveTrustedPeer(node)
+	return &proto_sentry.RemovePeerReply{Success: true}, nil
+}
+
+func (ss *GrpcServer) BanPeer(_ context.Context, req *proto_sentry.BanPeerRequest) (*proto_sentry.BanPeerReply, error) {
+	if ss.P2pServer == nil {
+		return nil, errors.New("p2p server was not started")
+	}
+	id := enode.ID(gointerfaces.ConvertH256ToHash(req.Id))
+	if err := ss.P2pServer.BanPeer(id, time.Duration(req.DurationSeconds)*time.Second); err != nil {
+		return nil, err
+	}
+	return &proto_sentry.BanPeerReply{Success: true}, nil
+}
+
+func (ss *GrpcServer) UnbanPeer(_ context.Context, req *proto_sentry.UnbanPeerRequest) (*proto_sentry.UnbanPeerReply, error) {
+	if ss.P2pServer == nil {
+		return nil, errors.New("p2p server was not started")
+	}
+	id := enode.ID(gointerfaces.ConvertH256ToHash(req.Id))
+	if err := ss.P2pServer.UnbanPeer(id); err != nil {
+		return nil, err
+	}
+	return &proto_sentry.UnbanPeerReply{Success: true}, nil
+}
+
+func (ss *GrpcServer) BannedPeers(_ context.Context, _ *emptypb.Empty) (*proto_sentry.BannedPeersReply, error) {
+	if ss.P2pServer == nil {
+		return nil, errors.New("p2p server was not started")
+	}
+	var reply proto_sentry.BannedPeersReply
+	for id, until := range ss.P2pServer.BannedPeers() {
+		reply.Peers = append(reply.Peers, &proto_sentry.BannedPeer{
+			Id:    gointerfaces.ConvertHashToH256(libcommon.Hash(id)),
+			Until: uint64(until.Unix()),
+		})
+	}
+	return &reply, nil
+}
+
 func (ss *GrpcServer) NodeInfo(_ context.Context, _ *emptypb.Empty) (*proto_types.NodeInfoReply, error) {
 	if ss.P2pServer == nil {
 		return nil, errors.New("p2p server was not started")
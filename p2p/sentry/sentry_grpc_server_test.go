@@ -22,6 +22,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state/temporal"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/log/v3"
 )
@@ -64,7 +65,7 @@ func startHandshake(
 	errChan chan *p2p.PeerError,
 ) {
 	go func() {
-		_, err := handShake(ctx, status, pipe, protocolVersion, protocolVersion)
+		_, err := handShake(ctx, status, 0, pipe, protocolVersion, protocolVersion)
 		errChan <- err
 	}()
 }
@@ -72,6 +73,7 @@ func startHandshake(
 // Tests that peers are correctly accepted (or rejected) based on the advertised
 // fork IDs in the protocol handshake.
 func TestForkIDSplit66(t *testing.T) { testForkIDSplit(t, direct.ETH66) }
+func TestForkIDSplit69(t *testing.T) { testForkIDSplit(t, direct.ETH69) }
 
 func testForkIDSplit(t *testing.T, protocol uint) {
 	var (
@@ -169,6 +171,48 @@ func testForkIDSplit(t *testing.T, protocol uint) {
 	}
 }
 
+// Tests that the eth/69 handshake exchanges the block ranges instead of the total difficulty.
+func TestHandshake69BlockRange(t *testing.T) {
+	ctx := context.Background()
+	status := &proto_sentry.StatusData{
+		NetworkId:      1,
+		BestHash:       gointerfaces.ConvertHashToH256(libcommon.HexToHash("beef")),
+		MaxBlockHeight: 100,
+		ForkData: &proto_sentry.Forks{
+			Genesis: gointerfaces.ConvertHashToH256(libcommon.HexToHash("dead")),
+		},
+	}
+
+	p2pLocal, p2pRemote := p2p.MsgPipe()
+	defer p2pLocal.Close()
+	defer p2pRemote.Close()
+
+	// The remote peer has expired the history below block 50
+	go func() {
+		_ = p2p.Send(p2pRemote, eth.StatusMsg, &eth.StatusPacket69{
+			ProtocolVersion: direct.ETH69,
+			NetworkID:       1,
+			Genesis:         libcommon.HexToHash("dead"),
+			ForkID:          forkid.NewIDFromForks(nil, nil, libcommon.HexToHash("dead"), 100, 0),
+			EarliestBlock:   50,
+			LatestBlock:     120,
+			LatestBlockHash: libcommon.HexToHash("cafe"),
+		})
+		msg, err := p2pRemote.ReadMsg()
+		if err == nil {
+			msg.Discard()
+		}
+	}()
+
+	peerRange, err := handShake(ctx, status, 0, p2pLocal, direct.ETH69, direct.ETH69)
+	if err != nil {
+		t.Fatalf("handshake failed: %v", err)
+	}
+	require.Equal(t, uint64(50), peerRange.EarliestBlock)
+	require.Equal(t, uint64(120), peerRange.LatestBlock)
+	require.Equal(t, libcommon.HexToHash("cafe"), peerRange.LatestBlockHash)
+}
+
 func TestSentryServerImpl_SetStatusInitPanic(t *testing.T) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -0,0 +1,125 @@
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/eth/protocols/snap"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// snapHandshakeTimeout is the maximum time the snap protocol waits for the eth
+// handshake of the peer, which runs concurrently. The state is served only to
+// the peers which are also connected over eth.
+const snapHandshakeTimeout = 2 * handshakeTimeout
+
+// snapProtocol serves the snap/1 protocol. The requests are answered in the
+// peer goroutine, one at a time, from the state of the node the sentry runs in.
+func (ss *GrpcServer) snapProtocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:    snap.ProtocolName,
+		Version: snap.SNAP1,
+		Length:  snap.ProtocolLength,
+		Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) *p2p.PeerError {
+			peerID := peer.Pubkey()
+			peerInfo, err := ss.waitEthPeer(ss.ctx, peerID)
+			if err != nil {
+				return err
+			}
+
+			cap := p2p.Cap{Name: snap.ProtocolName, Version: snap.SNAP1}
+			return runSnapPeer(ss.ctx, peerID, cap, rw, peerInfo, ss.snapServer, ss.logger)
+		},
+	}
+}
+func (ss *GrpcServer) waitEthPeer(ctx context.Context, peerID [64]byte) (*PeerInfo, *p2p.PeerError) {
+	timeout := time.NewTimer(snapHandshakeTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if peerInfo := ss.getPeer(peerID); peerInfo != nil {
+			return peerInfo, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, p2p.NewPeerError(p2p.PeerErrorDiscReason, p2p.DiscQuitting, ctx.Err(), "sentry.waitEthPeer: context stopped")
+		case <-timeout.C:
+			return nil, p2p.NewPeerError(p2p.PeerErrorStatusHandshakeTimeout, p2p.DiscUselessPeer, nil, "sentry.waitEthPeer: no eth connection for snap")
+		case <-ticker.C:
+		}
+	}
+}
+
+func runSnapPeer(
+	ctx context.Context,
+	peerID [64]byte,
+	cap p2p.Cap,
+	rw p2p.MsgReadWriter,
+	peerInfo *PeerInfo,
+	snapServer *snap.Server,
+	logger log.Logger,
+) *p2p.PeerError {
+	for {
+		if err := libcommon.Stopped(ctx.Done()); err != nil {
+			return p2p.NewPeerError(p2p.PeerErrorDiscReason, p2p.DiscQuitting, ctx.Err(), "sentry.runSnapPeer: context stopped")
+		}
+		if err := peerInfo.RemoveReason(); err != nil {
+			return err
+		}
+
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return p2p.NewPeerError(p2p.PeerErrorMessageReceive, p2p.DiscNetworkError, err, "sentry.runSnapPeer: ReadMsg error")
+		}
+
+		if msg.Size > snap.ProtocolMaxMsgSize {
+			msg.Discard()
+			return p2p.NewPeerError(p2p.PeerErrorMessageSizeLimit, p2p.DiscSubprotocolError, nil, fmt.Sprintf("sentry.runSnapPeer: message is too large %d, limit %d", msg.Size, snap.ProtocolMaxMsgSize))
+		}
+		peerInfo.peer.CountBytesTransfered(snap.MsgNames[msg.Code], cap.String(), uint64(msg.Size), true)
+
+		switch msg.Code {
+		case snap.GetAccountRangeMsg, snap.GetStorageRangesMsg, snap.GetByteCodesMsg, snap.GetTrieNodesMsg:
+			b := make([]byte, msg.Size)
+			if _, err := io.ReadFull(msg.Payload, b); err != nil {
+				return p2p.NewPeerError(p2p.PeerErrorMessageReceive, p2p.DiscNetworkError, err, "sentry.runSnapPeer: reading msg into bytes")
+			}
+			response, err := snapServer.Answer(ctx, msg.Code, b)
+			if err != nil {
+				if rlp.IsInvalidRLPError(err) {
+					return p2p.NewPeerError(p2p.PeerErrorInvalidMessage, p2p.DiscProtocolError, err, "sentry.runSnapPeer: invalid request")
+				}
+				logger.Debug("[p2p] Could not answer snap request", "code", msg.Code, "peerId", fmt.Sprintf("%x", peerID[:8]), "err", err)
+				break
+			}
+			if err := writeSnapResponse(rw, peerInfo, cap, response); err != nil {
+				return p2p.NewPeerError(p2p.PeerErrorMessageSend, p2p.DiscNetworkError, err, "sentry.runSnapPeer: writing the response")
+			}
+		case snap.AccountRangeMsg, snap.StorageRangesMsg, snap.ByteCodesMsg, snap.TrieNodesMsg:
+			// The state is served, but never requested from the peers
+			logger.Trace("[p2p] Unrequested snap response", "code", msg.Code, "peerId", fmt.Sprintf("%x", peerID[:8]))
+		default:
+			logger.Error(fmt.Sprintf("[p2p] Unknown snap message code: %d, peerID=%x", msg.Code, peerID))
+		}
+
+		msg.Discard()
+	}
+}
+
+func writeSnapResponse(rw p2p.MsgReadWriter, peerInfo *PeerInfo, cap p2p.Cap, response snap.Packet) error {
+	b, err := rlp.EncodeToBytes(response)
+	if err != nil {
+		return fmt.Errorf("encode %s response: %w", response.Name(), err)
+	}
+	msgcode := uint64(response.Kind())
+	peerInfo.peer.CountBytesTransfered(snap.MsgNames[msgcode], cap.String(), uint64(len(b)), false)
+	return rw.WriteMsg(p2p.Msg{Code: msgcode, Size: uint32(len(b)), Payload: bytes.NewReader(b)})
+}
@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"crypto/tls"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -32,6 +33,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/quic-go/quic-go"
 	"golang.org/x/sync/semaphore"
 
 	"github.com/ledgerwatch/erigon-lib/diagnostics"
@@ -102,6 +104,10 @@ type Config struct {
 	// protocol should be started or not.
 	DiscoveryV5 bool `toml:",omitempty"`
 
+	// TalkProtocols are served over the V5 discovery, which has to be
+	// enabled for them to run.
+	TalkProtocols []TalkProtocol `toml:"-"`
+
 	// Name sets the node name of this server.
 	// Use common.MakeName to create a name that follows existing conventions.
 	Name string `toml:"-"`
@@ -128,6 +134,21 @@ type Config struct {
 	// IP networks contained in the list are considered.
 	NetRestrict *netutil.Netlist `toml:",omitempty"`
 
+	// NodeFilters are the predicates a discovered node has to pass to be dialed,
+	// e.g. checking the fork id in the `eth` entry of its record. They apply to
+	// the nodes found by discv4, discv5 and the protocol dial candidates, but not
+	// to the static nodes. The discv4 nodes are only filtered once their records
+	// are fetched, and discv5 only becomes a source of the dial candidates when
+	// the filters are set, as most of its nodes don't speak the eth protocol.
+	NodeFilters []NodeFilter `toml:"-"`
+
+	// DiscoveryForkFilter makes the sentry add a filter of the nodes advertising
+	// an `eth` fork id incompatible with the local chain to the NodeFilters.
+	DiscoveryForkFilter bool `toml:",omitempty"`
+
+	// Snap makes the sentry of eth/68 serve the snap/1 protocol next to it.
+	Snap bool `toml:",omitempty"`
+
 	// NodeDatabase is the path to the database containing the previously seen
 	// live nodes in the network.
 	NodeDatabase string `toml:",omitempty"`
@@ -149,6 +170,12 @@ type Config struct {
 	// for different protocol versions
 	AllowedPorts []uint
 
+	// QUICListenAddr enables the experimental RLPx transport over QUIC. If set, the
+	// server accepts QUIC connections on this UDP address and advertises its port in
+	// the `quic` entry of the node record. The nodes advertising the entry are dialed
+	// over QUIC, falling back to TCP if the QUIC dial fails.
+	QUICListenAddr string `toml:",omitempty"`
+
 	// eth/66, eth/67, etc
 	ProtocolVersion []uint
 
@@ -208,6 +235,10 @@ type Server struct {
 	running bool
 
 	listener     net.Listener
+	quicUDP      *net.UDPConn
+	quicTrans    *quic.Transport
+	quicListener *quic.Listener
+	quicTLS      *tls.Config
 	ourHandshake *protoHandshake
 	loopWG       sync.WaitGroup // loop, listenLoop
 	peerFeed     event.Feed
@@ -221,6 +252,9 @@ type Server struct {
 	discmix            *enode.FairMix
 	dialsched          *dialScheduler
 
+	banLock sync.RWMutex
+	banned  map[enode.ID]time.Time // expiry of the bans, persisted in nodedb
+
 	// Channels into the run loop.
 	quitCtx                 context.Context
 	quitFunc                context.CancelFunc
@@ -234,6 +268,7 @@ type Server struct {
 	checkpointAddPeer       chan *conn
 
 	// State of run loop and listenLoop.
+	inboundLock    sync.Mutex // protects inboundHistory, shared with the QUIC listener
 	inboundHistory expHeap
 	errors         map[string]uint
 }
@@ -413,6 +448,53 @@ func (srv *Server) RemoveTrustedPeer(node *enode.Node) {
 	}
 }
 
+// BanPeer disconnects the given node and refuses any connection to or from it
+// until the ban expires. Bans are stored in the node database, so they survive restarts.
+func (srv *Server) BanPeer(id enode.ID, d time.Duration) error {
+	until := time.Now().Add(d)
+	if err := srv.nodedb.UpdateBan(id, until); err != nil {
+		return err
+	}
+	srv.banLock.Lock()
+	srv.banned[id] = until
+	srv.banLock.Unlock()
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		if peer := peers[id]; peer != nil {
+			peer.Disconnect(NewPeerError(PeerErrorDiscReason, DiscRequested, nil, "Server.BanPeer Disconnect"))
+		}
+	})
+	return nil
+}
+
+// UnbanPeer lifts the ban of the given node.
+func (srv *Server) UnbanPeer(id enode.ID) error {
+	srv.banLock.Lock()
+	delete(srv.banned, id)
+	srv.banLock.Unlock()
+	return srv.nodedb.DeleteBan(id)
+}
+
+// BannedPeers returns the nodes which are currently banned, along with the expiry of their bans.
+func (srv *Server) BannedPeers() map[enode.ID]time.Time {
+	srv.banLock.RLock()
+	defer srv.banLock.RUnlock()
+	now := time.Now()
+	banned := make(map[enode.ID]time.Time, len(srv.banned))
+	for id, until := range srv.banned {
+		if until.After(now) {
+			banned[id] = until
+		}
+	}
+	return banned
+}
+
+func (srv *Server) isBanned(id enode.ID) bool {
+	srv.banLock.RLock()
+	defer srv.banLock.RUnlock()
+	until, ok := srv.banned[id]
+	return ok && time.Now().Before(until)
+}
+
 // SubscribeEvents subscribes the given channel to peer events.
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -447,11 +529,18 @@ func (srv *Server) Stop() {
 		// this unblocks listener Accept
 		_ = srv.listener.Close()
 	}
+	if srv.quicListener != nil {
+		_ = srv.quicListener.Close()
+	}
 	if srv.nodedb != nil {
 		srv.nodedb.Close()
 	}
 	srv.lock.Unlock()
 	srv.loopWG.Wait()
+	if srv.quicTrans != nil {
+		_ = srv.quicTrans.Close()
+		_ = srv.quicUDP.Close()
+	}
 }
 
 // sharedUDPConn implements a shared connection. Write sends messages to the underlying connection while read returns
@@ -510,7 +599,7 @@ func (srv *Server) Start(ctx context.Context, logger log.Logger) error {
 		return errors.New("MaxPendingPeers must be greater than zero")
 	}
 	if srv.newTransport == nil {
-		srv.newTransport = newRLPX
+		srv.newTransport = newTransport
 	}
 	if srv.listenFunc == nil {
 		srv.listenFunc = net.Listen
@@ -533,6 +622,11 @@ func (srv *Server) Start(ctx context.Context, logger log.Logger) error {
 			return err
 		}
 	}
+	if srv.QUICListenAddr != "" {
+		if err := srv.setupQUIC(srv.quitCtx); err != nil {
+			return err
+		}
+	}
 	if err := srv.setupDiscovery(srv.quitCtx); err != nil {
 		return err
 	}
@@ -563,6 +657,7 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.banned = db.Bans()
 
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey, srv.logger)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
@@ -610,7 +705,7 @@ func (srv *Server) setupDiscovery(ctx context.Context) error {
 	added := make(map[string]bool)
 	for _, proto := range srv.Protocols {
 		if proto.DialCandidates != nil && !added[proto.Name] {
-			srv.discmix.AddSource(proto.DialCandidates)
+			srv.discmix.AddSource(srv.filterNodes(proto.DialCandidates))
 			added[proto.Name] = true
 		}
 	}
@@ -663,7 +758,11 @@ func (srv *Server) setupDiscovery(ctx context.Context) error {
 			return err
 		}
 		srv.ntab = ntab
-		srv.discmix.AddSource(ntab.RandomNodes())
+		if len(srv.NodeFilters) > 0 {
+			srv.discmix.AddSource(srv.filterNodes(newResolveIter(ntab.RandomNodes(), ntab)))
+		} else {
+			srv.discmix.AddSource(ntab.RandomNodes())
+		}
 	}
 
 	// Discovery V5
@@ -683,6 +782,12 @@ func (srv *Server) setupDiscovery(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		for _, p := range srv.TalkProtocols {
+			srv.DiscV5.RegisterTalkHandler(p.Name, p.Handler(srv.DiscV5))
+		}
+		if len(srv.NodeFilters) > 0 {
+			srv.discmix.AddSource(srv.filterNodes(srv.DiscV5.RandomNodes()))
+		}
 	}
 	return nil
 }
@@ -694,6 +799,7 @@ func (srv *Server) setupDialScheduler() {
 		maxActiveDials: srv.MaxPendingPeers,
 		log:            srv.logger,
 		netRestrict:    srv.NetRestrict,
+		banned:         srv.isBanned,
 		dialer:         srv.Dialer,
 		clock:          srv.clock,
 	}
@@ -703,6 +809,9 @@ func (srv *Server) setupDialScheduler() {
 	if config.dialer == nil {
 		config.dialer = tcpDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 	}
+	if srv.quicTrans != nil {
+		config.dialer = &quicDialer{NodeDialer: config.dialer, transport: srv.quicTrans, tlsConfig: srv.quicTLS, logger: srv.logger}
+	}
 	var subProtocolVersion uint
 	if len(srv.Protocols) > 0 {
 		subProtocolVersion = srv.Protocols[0].Version
@@ -765,6 +874,49 @@ func (srv *Server) setupListening(ctx context.Context) error {
 	return nil
 }
 
+func (srv *Server) setupQUIC(ctx context.Context) error {
+	addr, err := net.ResolveUDPAddr("udp", srv.QUICListenAddr)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := newQUICTLSConfig()
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	// The same transport dials the QUIC connections, so that they come from the advertised port
+	trans := &quic.Transport{Conn: conn}
+	listener, err := trans.Listen(tlsConfig, quicConfig)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	srv.quicUDP, srv.quicTrans, srv.quicListener, srv.quicTLS = conn, trans, listener, tlsConfig
+
+	laddr := conn.LocalAddr().(*net.UDPAddr)
+	srv.QUICListenAddr = laddr.String()
+	srv.localnode.Set(enr.QUIC(laddr.Port))
+	if !laddr.IP.IsLoopback() && (srv.NAT != nil) && srv.NAT.SupportsMapping() {
+		srv.loopWG.Add(1)
+		go func() {
+			defer debug.LogPanic()
+			defer srv.loopWG.Done()
+			nat.Map(srv.NAT, srv.quit, "udp", laddr.Port, laddr.Port, "ethereum quic", srv.logger)
+		}()
+	}
+
+	srv.loopWG.Add(1)
+	go func() {
+		defer debug.LogPanic()
+		defer srv.loopWG.Done()
+		srv.quicListenLoop(ctx)
+	}()
+	return nil
+}
+
 // doPeerOp runs fn on the main loop.
 func (srv *Server) doPeerOp(fn peerOpFunc) {
 	select {
@@ -835,7 +987,12 @@ running:
 				// Ensure that the trusted flag is set before checking against MaxPeers.
 				c.flags |= trustedConn
 			}
-			c.cont <- nil
+			if srv.isBanned(c.node.ID()) {
+				// Bans apply to the trusted nodes too.
+				c.cont <- errBanned
+			} else {
+				c.cont <- nil
+			}
 
 		case c := <-srv.checkpointAddPeer:
 			// At this point the connection is past the protocol handshake.
@@ -925,7 +1082,9 @@ func (srv *Server) listenLoop(ctx context.Context) {
 	// The slots limit accepts of new connections.
 	slots := semaphore.NewWeighted(int64(srv.MaxPendingPeers))
 
+	srv.lock.Lock()
 	srv.errors = map[string]uint{}
+	srv.lock.Unlock()
 
 	// Wait for slots to be returned on exit. This ensures all connection goroutines
 	// are down before listenLoop returns.
@@ -993,6 +1152,43 @@ func (srv *Server) listenLoop(ctx context.Context) {
 	}
 }
 
+// quicListenLoop runs in its own goroutine and accepts inbound QUIC connections
+func (srv *Server) quicListenLoop(ctx context.Context) {
+	srv.logger.Trace("QUIC listener up", "addr", srv.quicListener.Addr())
+
+	slots := semaphore.NewWeighted(int64(srv.MaxPendingPeers))
+	defer func() {
+		_ = slots.Acquire(ctx, int64(srv.MaxPendingPeers))
+	}()
+
+	for {
+		if slotErr := slots.Acquire(ctx, 1); slotErr != nil {
+			return
+		}
+		qconn, err := srv.quicListener.Accept(ctx)
+		if err != nil {
+			slots.Release(1)
+			return
+		}
+		go func() {
+			defer debug.LogPanic()
+			defer slots.Release(1)
+			fd, err := acceptQUIC(ctx, qconn)
+			if err != nil {
+				srv.logger.Trace("Failed to accept QUIC stream", "addr", qconn.RemoteAddr(), "err", err)
+				return
+			}
+			if err := srv.checkInboundConn(fd, netutil.AddrIP(fd.RemoteAddr())); err != nil {
+				srv.logger.Trace("Rejected inbound connection", "addr", fd.RemoteAddr(), "err", err)
+				_ = fd.Close()
+				return
+			}
+			// The error is logged in Server.setupConn().
+			_ = srv.SetupConn(fd, inboundConn, nil)
+		}()
+	}
+}
+
 func (srv *Server) checkInboundConn(fd net.Conn, remoteIP net.IP) error {
 	if remoteIP == nil {
 		return nil
@@ -1002,6 +1198,8 @@ func (srv *Server) checkInboundConn(fd net.Conn, remoteIP net.IP) error {
 		return fmt.Errorf("not whitelisted in NetRestrict")
 	}
 	// Reject Internet peers that try too often.
+	srv.inboundLock.Lock()
+	defer srv.inboundLock.Unlock()
 	now := srv.clock.Now()
 	srv.inboundHistory.expire(now, nil)
 	if !netutil.IsLAN(remoteIP) && srv.inboundHistory.contains(remoteIP.String()) {
@@ -1114,9 +1312,13 @@ func (srv *Server) setupConn(c *conn, flags connFlag, dialDest *enode.Node) erro
 func nodeFromConn(pubkey *ecdsa.PublicKey, conn net.Conn) *enode.Node {
 	var ip net.IP
 	var port int
-	if tcp, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
-		ip = tcp.IP
-		port = tcp.Port
+	switch addr := conn.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		ip = addr.IP
+		port = addr.Port
+	case *net.UDPAddr:
+		// The QUIC connections come from the UDP port of the node, not its TCP one
+		ip = addr.IP
 	}
 	return enode.NewV4(pubkey, ip, port, port)
 }
@@ -55,6 +55,15 @@ func newRLPX(conn net.Conn, dialDest *ecdsa.PublicKey) transport {
 	return &rlpxTransport{conn: rlpx.NewConn(conn, dialDest)}
 }
 
+// newTransport picks the transport of a connection, the QUIC connections carry RLPx over
+// their streams.
+func newTransport(conn net.Conn, dialDest *ecdsa.PublicKey) transport {
+	if qc, ok := conn.(*quicConn); ok {
+		return newQUICTransport(qc, dialDest)
+	}
+	return newRLPX(conn, dialDest)
+}
+
 func (t *rlpxTransport) ReadMsg() (Msg, error) {
 	t.rmu.Lock()
 	defer t.rmu.Unlock()
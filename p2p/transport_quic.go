@@ -0,0 +1,434 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/quic-go/quic-go"
+
+	"github.com/ledgerwatch/erigon-lib/metrics"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+	"github.com/ledgerwatch/erigon/p2p/rlpx"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+const (
+	// quicALPN is the application protocol negotiated in the TLS handshake of the QUIC connections
+	quicALPN = "devp2p"
+
+	// quicBindingLabel is the label of the TLS keying material exchanged over the RLPx
+	// session, which binds the session to the TLS connection it runs on.
+	quicBindingLabel = "EXPORTER-devp2p-rlpx"
+
+	// quicBindingMsg is the code of the message carrying the TLS keying material, it is
+	// the only message exchanged between the encryption and the protocol handshakes.
+	quicBindingMsg = 0
+
+	// quicNoReason is the application error code closing the QUIC connections for a reason
+	// other than a DiscReason, which are sent as their own codes.
+	quicNoReason = 0x100
+
+	// quicMaxUniStreams is the limit of the sub-protocol streams a peer may open
+	quicMaxUniStreams = 16
+
+	// quicMaxFrameSize is the maximum size of a frame of the sub-protocol streams, it is
+	// the same as the one of the RLPx frames.
+	quicMaxFrameSize = 0xffffff
+
+	// quicDialTimeout bounds the QUIC dials, which are retried over TCP when they fail. It is
+	// much shorter than the TCP dial timeout because a node advertising a QUIC port it does
+	// not serve is only detected by the timeout.
+	quicDialTimeout = 2 * time.Second
+)
+
+var (
+	quicDialFallbackMeter = metrics.GetOrCreateCounter("p2p_quic_dial_fallback")
+
+	errQUICBinding = errors.New("RLPx session is not bound to the QUIC connection")
+)
+
+var quicConfig = &quic.Config{
+	HandshakeIdleTimeout:  handshakeTimeout,
+	MaxIdleTimeout:        frameReadTimeout,
+	MaxIncomingStreams:    1,
+	MaxIncomingUniStreams: quicMaxUniStreams,
+}
+
+// newQUICTLSConfig creates the TLS configuration of the QUIC connections with a fresh
+// self-signed certificate. The certificates are not verified, the peers are authenticated
+// by the RLPx handshake, which is then bound to the TLS connection.
+func newQUICTLSConfig() (*tls.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cert}, PrivateKey: key}},
+		NextProtos:   []string{quicALPN},
+		MinVersion:   tls.VersionTLS13,
+		//nolint:gosec
+		InsecureSkipVerify: true,
+	}, nil
+}
+
+// quicAddr returns the address the node accepts QUIC connections on, or nil if it
+// doesn't advertise one.
+func quicAddr(n *enode.Node) *net.UDPAddr {
+	var port enr.QUIC
+	if n.IP() == nil || n.Load(&port) != nil || port == 0 {
+		return nil
+	}
+	return &net.UDPAddr{IP: n.IP(), Port: int(port)}
+}
+
+// quicConn is the first bidirectional stream of a QUIC connection, which carries the
+// handshakes and the base protocol messages. It stands for the whole connection where
+// a net.Conn is expected.
+type quicConn struct {
+	quic.Stream
+	conn quic.Connection
+}
+
+func (c *quicConn) LocalAddr() net.Addr  { return c.conn.LocalAddr() }
+func (c *quicConn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+func (c *quicConn) Close() error {
+	return c.conn.CloseWithError(quicNoReason, "")
+}
+
+func dialQUIC(ctx context.Context, transport *quic.Transport, addr *net.UDPAddr, tlsConfig *tls.Config) (*quicConn, error) {
+	conn, err := transport.Dial(ctx, addr, tlsConfig, quicConfig)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		_ = conn.CloseWithError(quicNoReason, "")
+		return nil, err
+	}
+	return &quicConn{Stream: stream, conn: conn}, nil
+}
+
+func acceptQUIC(ctx context.Context, conn quic.Connection) (*quicConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+	stream, err := conn.AcceptStream(ctx)
+	if err != nil {
+		_ = conn.CloseWithError(quicNoReason, "")
+		return nil, err
+	}
+	return &quicConn{Stream: stream, conn: conn}, nil
+}
+
+// quicDialer dials the nodes advertising a QUIC port over QUIC, the other nodes and
+// the ones failing the QUIC dial are dialed by the wrapped dialer.
+type quicDialer struct {
+	NodeDialer
+	transport *quic.Transport
+	tlsConfig *tls.Config
+	logger    log.Logger
+}
+
+func (d *quicDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
+	if addr := quicAddr(dest); addr != nil {
+		dialCtx, cancel := context.WithTimeout(ctx, quicDialTimeout)
+		conn, err := dialQUIC(dialCtx, d.transport, addr, d.tlsConfig)
+		cancel()
+		if err == nil {
+			return conn, nil
+		}
+		quicDialFallbackMeter.Inc()
+		d.logger.Trace("QUIC dial failed, falling back to TCP", "id", dest.ID(), "addr", addr, "err", err)
+	}
+	return d.NodeDialer.Dial(ctx, dest)
+}
+
+// quicTransport runs RLPx over a QUIC connection. The encryption handshake, the protocol
+// handshake and the base protocol messages use RLPx framing on the first stream of the
+// connection. After the handshakes, every sub-protocol writes its messages to its own
+// unidirectional stream, so that a lost packet only holds back the messages of one
+// protocol. The sub-protocol streams rely on TLS for their encryption, the RLPx session
+// is bound to the TLS connection by exchanging the TLS keying material over it.
+type quicTransport struct {
+	conn    *quicConn
+	control *rlpxTransport
+
+	wmu     sync.Mutex // protects the sub-protocol streams and wbuf
+	streams map[Cap]quic.SendStream
+	wbuf    bytes.Buffer
+	snappy  bool // set by the protocol handshake, before the reads start
+
+	in       chan Msg
+	done     chan struct{}
+	doneOnce sync.Once
+	err      error // the reason of done, set before it is closed
+}
+
+func newQUICTransport(conn *quicConn, dialDest *ecdsa.PublicKey) transport {
+	return &quicTransport{
+		conn:    conn,
+		control: &rlpxTransport{conn: rlpx.NewConn(conn, dialDest)},
+		streams: make(map[Cap]quic.SendStream),
+		in:      make(chan Msg),
+		done:    make(chan struct{}),
+	}
+}
+
+func (t *quicTransport) doEncHandshake(prv *ecdsa.PrivateKey) (*ecdsa.PublicKey, error) {
+	pubkey, err := t.control.doEncHandshake(prv)
+	if err != nil {
+		return nil, quicError(err)
+	}
+	if err := t.bind(); err != nil {
+		return nil, quicError(err)
+	}
+	return pubkey, nil
+}
+
+// bind exchanges the keying material of the TLS connection over the RLPx session. A man
+// in the middle relaying the RLPx handshake between two TLS connections ends up with
+// different keying material on the two sides, and it can't change the RLPx messages.
+func (t *quicTransport) bind() error {
+	state := t.conn.conn.ConnectionState().TLS
+	binding, err := state.ExportKeyingMaterial(quicBindingLabel, nil, 32)
+	if err != nil {
+		return err
+	}
+	werr := make(chan error, 1)
+	go func() {
+		_, err := t.control.conn.Write(quicBindingMsg, binding)
+		werr <- err
+	}()
+	code, data, _, err := t.control.conn.Read()
+	if err != nil {
+		<-werr
+		return err
+	}
+	if err := <-werr; err != nil {
+		return err
+	}
+	if code != quicBindingMsg || !hmac.Equal(data, binding) {
+		return errQUICBinding
+	}
+	return nil
+}
+
+func (t *quicTransport) doProtoHandshake(our *protoHandshake) (*protoHandshake, error) {
+	their, err := t.control.doProtoHandshake(our)
+	if err != nil {
+		return nil, quicError(err)
+	}
+	t.snappy = their.Version >= snappyProtocolVersion
+
+	go t.readControl()
+	go t.acceptStreams()
+	return their, nil
+}
+
+func (t *quicTransport) ReadMsg() (Msg, error) {
+	select {
+	case msg := <-t.in:
+		return msg, nil
+	case <-t.done:
+		return Msg{}, t.err
+	}
+}
+
+// WriteMsg sends the base protocol messages over the first stream, and the sub-protocol
+// messages over the stream of their protocol.
+func (t *quicTransport) WriteMsg(msg Msg) error {
+	if msg.meterCap.Name == "" {
+		return t.control.WriteMsg(msg)
+	}
+
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	stream, err := t.stream(msg.meterCap)
+	if err != nil {
+		return err
+	}
+
+	t.wbuf.Reset()
+	if _, err := io.CopyN(&t.wbuf, msg.Payload, int64(msg.Size)); err != nil {
+		return err
+	}
+	data := t.wbuf.Bytes()
+	if t.snappy {
+		data = snappy.Encode(nil, data)
+	}
+	size := rlp.IntSize(msg.Code) + len(data)
+	if size > quicMaxFrameSize {
+		return fmt.Errorf("message too large: %d", size)
+	}
+	frame := make([]byte, 3, 3+size)
+	frame[0], frame[1], frame[2] = byte(size>>16), byte(size>>8), byte(size)
+	frame = rlp.AppendUint64(frame, msg.Code)
+	frame = append(frame, data...)
+
+	if err := stream.SetWriteDeadline(time.Now().Add(frameWriteTimeout)); err != nil {
+		return err
+	}
+	if _, err := stream.Write(frame); err != nil {
+		return quicError(err)
+	}
+	return nil
+}
+
+// stream returns the stream of a sub-protocol, opening it on the first message
+func (t *quicTransport) stream(c Cap) (quic.SendStream, error) {
+	if stream, ok := t.streams[c]; ok {
+		return stream, nil
+	}
+	stream, err := t.conn.conn.OpenUniStream()
+	if err != nil {
+		return nil, quicError(err)
+	}
+	t.streams[c] = stream
+	return stream, nil
+}
+
+// close sends the DiscReason as the error code of the QUIC connection, as the stream data
+// is not delivered once the connection is closed.
+func (t *quicTransport) close(err error) {
+	code, msg := quic.ApplicationErrorCode(quicNoReason), ""
+	if r, ok := err.(DiscReason); ok && r != DiscNetworkError {
+		code = quic.ApplicationErrorCode(r)
+	}
+	if err != nil {
+		msg = err.Error()
+	}
+	_ = t.conn.conn.CloseWithError(code, msg)
+	t.fail(net.ErrClosed)
+}
+
+func (t *quicTransport) fail(err error) {
+	t.doneOnce.Do(func() {
+		t.err = quicError(err)
+		close(t.done)
+	})
+}
+
+func (t *quicTransport) deliver(msg Msg) bool {
+	select {
+	case t.in <- msg:
+		return true
+	case <-t.done:
+		return false
+	}
+}
+
+func (t *quicTransport) readControl() {
+	for {
+		msg, err := t.control.ReadMsg()
+		if err != nil {
+			t.fail(err)
+			return
+		}
+		if !t.deliver(msg) {
+			return
+		}
+	}
+}
+
+func (t *quicTransport) acceptStreams() {
+	for {
+		stream, err := t.conn.conn.AcceptUniStream(t.conn.conn.Context())
+		if err != nil {
+			t.fail(err)
+			return
+		}
+		go t.readStream(stream)
+	}
+}
+
+func (t *quicTransport) readStream(stream quic.ReceiveStream) {
+	var header [3]byte
+	for {
+		if _, err := io.ReadFull(stream, header[:]); err != nil {
+			t.fail(err)
+			return
+		}
+		// The buffer grows as the data arrives rather than trusting the declared size, so a
+		// peer can't make us allocate a full frame without sending it.
+		var frame bytes.Buffer
+		size := int64(header[0])<<16 | int64(header[1])<<8 | int64(header[2])
+		if _, err := io.CopyN(&frame, stream, size); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			t.fail(err)
+			return
+		}
+		msg, err := t.decodeFrame(frame.Bytes())
+		if err != nil {
+			t.fail(err)
+			return
+		}
+		if !t.deliver(msg) {
+			return
+		}
+	}
+}
+
+func (t *quicTransport) decodeFrame(frame []byte) (Msg, error) {
+	code, data, err := rlp.SplitUint64(frame)
+	if err != nil {
+		return Msg{}, fmt.Errorf("invalid message code: %w", err)
+	}
+	wireSize := len(data)
+	if t.snappy {
+		size, err := snappy.DecodedLen(data)
+		if err != nil {
+			return Msg{}, err
+		}
+		if size > quicMaxFrameSize {
+			return Msg{}, fmt.Errorf("message too large: %d", size)
+		}
+		if data, err = snappy.Decode(nil, data); err != nil {
+			return Msg{}, err
+		}
+	}
+	return Msg{
+		ReceivedAt: time.Now(),
+		Code:       code,
+		Size:       uint32(len(data)),
+		meterSize:  uint32(wireSize),
+		Payload:    bytes.NewReader(data),
+	}, nil
+}
+
+// quicError turns the closing of the connection by the remote side with a DiscReason into
+// the reason, as if it had been received in a disconnect message.
+func quicError(err error) error {
+	var appErr *quic.ApplicationError
+	if errors.As(err, &appErr) && appErr.Remote && appErr.ErrorCode < quicNoReason {
+		return DiscReason(appErr.ErrorCode)
+	}
+	return err
+}
@@ -0,0 +1,289 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/log/v3"
+)
+
+func newQUICTestTransport(t *testing.T) *quic.Transport {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	trans := &quic.Transport{Conn: conn}
+	t.Cleanup(func() {
+		_ = trans.Close()
+		_ = conn.Close()
+	})
+	return trans
+}
+
+// quicListen accepts a QUIC connection on the loopback interface, the accepted connection
+// is sent once the dialer writes to its first stream.
+func quicListen(t *testing.T) (*net.UDPAddr, <-chan *quicConn) {
+	tlsConfig, err := newQUICTLSConfig()
+	require.NoError(t, err)
+	listener, err := newQUICTestTransport(t).Listen(tlsConfig, quicConfig)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	accepted := make(chan *quicConn, 1)
+	go func() {
+		defer close(accepted)
+		conn, err := listener.Accept(context.Background())
+		if err != nil {
+			return
+		}
+		if fd, err := acceptQUIC(context.Background(), conn); err == nil {
+			accepted <- fd
+		}
+	}()
+	return listener.Addr().(*net.UDPAddr), accepted
+}
+
+func quicDial(t *testing.T, addr *net.UDPAddr) *quicConn {
+	tlsConfig, err := newQUICTLSConfig()
+	require.NoError(t, err)
+	fd, err := dialQUIC(context.Background(), newQUICTestTransport(t), addr, tlsConfig)
+	require.NoError(t, err)
+	return fd
+}
+
+func quicTestMsg(t *testing.T, code uint64, c Cap, content string) Msg {
+	payload, err := rlp.EncodeToBytes(content)
+	require.NoError(t, err)
+	return Msg{Code: code, Size: uint32(len(payload)), Payload: bytes.NewReader(payload), meterCap: c}
+}
+
+func TestQUICTransport(t *testing.T) {
+	var (
+		prv0, _ = crypto.GenerateKey()
+		hs0     = &protoHandshake{Version: snappyProtocolVersion, Pubkey: crypto.MarshalPubkey(&prv0.PublicKey), Caps: []Cap{{"a", 1}, {"b", 2}}}
+		prv1, _ = crypto.GenerateKey()
+		hs1     = &protoHandshake{Version: snappyProtocolVersion, Pubkey: crypto.MarshalPubkey(&prv1.PublicKey), Caps: []Cap{{"a", 1}, {"b", 2}}}
+
+		capA = Cap{"a", 1}
+		capB = Cap{"b", 2}
+	)
+	addr, accepted := quicListen(t)
+	handshake := func(tr transport, prv *ecdsa.PrivateKey, hs *protoHandshake, remote *ecdsa.PrivateKey) {
+		pubkey, err := tr.doEncHandshake(prv)
+		require.NoError(t, err)
+		require.Equal(t, &remote.PublicKey, pubkey)
+		their, err := tr.doProtoHandshake(hs)
+		require.NoError(t, err)
+		require.Equal(t, crypto.MarshalPubkey(&remote.PublicKey), their.Pubkey)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var listenSide transport
+	go func() {
+		defer wg.Done()
+		fd := <-accepted
+		require.NotNil(t, fd)
+		listenSide = newTransport(fd, nil)
+		handshake(listenSide, prv1, hs1, prv0)
+	}()
+	dialSide := newTransport(quicDial(t, addr), &prv1.PublicKey)
+	handshake(dialSide, prv0, hs0, prv1)
+	wg.Wait()
+	require.IsType(t, &quicTransport{}, dialSide)
+
+	// The base protocol messages and the messages of every sub-protocol keep their order
+	sent := []Msg{
+		quicTestMsg(t, pingMsg, Cap{}, ""),
+		quicTestMsg(t, baseProtocolLength, capA, "a1"),
+		quicTestMsg(t, baseProtocolLength+10, capB, "b1"),
+		quicTestMsg(t, baseProtocolLength+1, capA, string(make([]byte, 100_000))),
+		quicTestMsg(t, baseProtocolLength+11, capB, "b2"),
+		quicTestMsg(t, pongMsg, Cap{}, ""),
+	}
+	for _, msg := range sent {
+		require.NoError(t, dialSide.WriteMsg(msg))
+	}
+	received := make(map[Cap][]uint64)
+	for range sent {
+		msg, err := listenSide.ReadMsg()
+		require.NoError(t, err)
+		var content string
+		require.NoError(t, msg.Decode(&content))
+		switch {
+		case msg.Code < baseProtocolLength:
+			received[Cap{}] = append(received[Cap{}], msg.Code)
+		case msg.Code < baseProtocolLength+10:
+			received[capA] = append(received[capA], msg.Code)
+		default:
+			received[capB] = append(received[capB], msg.Code)
+		}
+	}
+	require.Equal(t, map[Cap][]uint64{
+		{}:   {pingMsg, pongMsg},
+		capA: {baseProtocolLength, baseProtocolLength + 1},
+		capB: {baseProtocolLength + 10, baseProtocolLength + 11},
+	}, received)
+
+	require.NoError(t, listenSide.WriteMsg(quicTestMsg(t, baseProtocolLength, capA, "reply")))
+	require.NoError(t, ExpectMsg(dialSide, baseProtocolLength, "reply"))
+
+	// The disconnect reason is delivered along with the closing of the connection
+	dialSide.close(DiscQuitting)
+	_, err := listenSide.ReadMsg()
+	require.Equal(t, DiscQuitting, err)
+	_, err = dialSide.ReadMsg()
+	require.Error(t, err)
+}
+
+// TestQUICTransportBinding relays an RLPx handshake between two QUIC connections, the
+// handshake succeeds but the sessions are not bound to the connections they run on.
+func TestQUICTransportBinding(t *testing.T) {
+	prv0, _ := crypto.GenerateKey()
+	prv1, _ := crypto.GenerateKey()
+
+	relayAddr, relayAccepted := quicListen(t)
+	addr, accepted := quicListen(t)
+	go func() {
+		in := <-relayAccepted
+		if in == nil {
+			return
+		}
+		tlsConfig, err := newQUICTLSConfig()
+		if err != nil {
+			return
+		}
+		out, err := dialQUIC(context.Background(), newQUICTestTransport(t), addr, tlsConfig)
+		if err != nil {
+			return
+		}
+		go func() { _, _ = io.Copy(out, in) }()
+		_, _ = io.Copy(in, out)
+	}()
+
+	errc := make(chan error, 1)
+	go func() {
+		fd := <-accepted
+		if fd == nil {
+			errc <- net.ErrClosed
+			return
+		}
+		_, err := newTransport(fd, nil).doEncHandshake(prv1)
+		errc <- err
+	}()
+	_, err := newTransport(quicDial(t, relayAddr), &prv1.PublicKey).doEncHandshake(prv0)
+	require.Equal(t, errQUICBinding, err)
+	require.Equal(t, errQUICBinding, <-errc)
+}
+
+func startQUICTestServer(t *testing.T, quicListenAddr string, run func(*Peer, MsgReadWriter) *PeerError) *Server {
+	srv := &Server{Config: Config{
+		PrivateKey:      newkey(),
+		MaxPeers:        10,
+		MaxPendingPeers: 10,
+		NoDiscovery:     true,
+		ListenAddr:      "127.0.0.1:0",
+		QUICListenAddr:  quicListenAddr,
+		Protocols:       []Protocol{{Name: "test", Version: 1, Length: 1, Run: run}},
+	}}
+	require.NoError(t, srv.Start(context.Background(), log.New()))
+	t.Cleanup(srv.Stop)
+	return srv
+}
+
+func TestServerQUIC(t *testing.T) {
+	received := make(chan string, 10)
+	run := func(p *Peer, rw MsgReadWriter) *PeerError {
+		if err := Send(rw, 0, "hello"); err != nil {
+			return NewPeerError(PeerErrorDiscReason, DiscNetworkError, err, "send")
+		}
+		for {
+			msg, err := rw.ReadMsg()
+			if err != nil {
+				return NewPeerError(PeerErrorDiscReason, DiscNetworkError, err, "read")
+			}
+			var content string
+			if err := msg.Decode(&content); err != nil {
+				return NewPeerError(PeerErrorDiscReason, DiscProtocolError, err, "decode")
+			}
+			received <- content
+		}
+	}
+	withQUIC := startQUICTestServer(t, "127.0.0.1:0", run)
+	require.NotNil(t, quicAddr(withQUIC.Self()))
+
+	connect := func(t *testing.T, srv *Server, node *enode.Node) net.Addr {
+		// The fallback waits for the QUIC dial to time out
+		ch := make(chan *PeerEvent)
+		sub := srv.SubscribeEvents(ch)
+		defer sub.Unsubscribe()
+		srv.AddPeer(node)
+		timeout := time.After(quicDialTimeout + handshakeTimeout)
+		for added := false; !added; {
+			select {
+			case ev := <-ch:
+				added = ev.Type == PeerEventTypeAdd && ev.Peer == node.ID()
+			case <-timeout:
+				t.Fatal("peer not connected")
+			}
+		}
+		for i := 0; i < 2; i++ {
+			select {
+			case content := <-received:
+				require.Equal(t, "hello", content)
+			case <-time.After(2 * time.Second):
+				t.Fatal("no message from the sub-protocol")
+			}
+		}
+		for _, p := range srv.Peers() {
+			if p.ID() == node.ID() {
+				srv.RemovePeer(node)
+				return p.RemoteAddr()
+			}
+		}
+		t.Fatal("peer not found")
+		return nil
+	}
+
+	t.Run("quic", func(t *testing.T) {
+		srv := startQUICTestServer(t, "127.0.0.1:0", run)
+		require.IsType(t, &net.UDPAddr{}, connect(t, srv, withQUIC.Self()))
+	})
+	t.Run("remote without quic", func(t *testing.T) {
+		srv := startQUICTestServer(t, "", run)
+		require.IsType(t, &net.TCPAddr{}, connect(t, withQUIC, srv.Self()))
+	})
+	t.Run("local without quic", func(t *testing.T) {
+		srv := startQUICTestServer(t, "", run)
+		require.IsType(t, &net.TCPAddr{}, connect(t, srv, withQUIC.Self()))
+	})
+	t.Run("fallback", func(t *testing.T) {
+		remote := startQUICTestServer(t, "", run)
+		// The record advertises a QUIC port nobody listens on
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		require.NoError(t, err)
+		port := conn.LocalAddr().(*net.UDPAddr).Port
+		require.NoError(t, conn.Close())
+		var r enr.Record
+		r.Set(enr.IPv4(net.IPv4(127, 0, 0, 1)))
+		r.Set(enr.TCP(remote.Self().TCP()))
+		r.Set(enr.QUIC(port))
+		require.NoError(t, enode.SignV4(&r, remote.PrivateKey))
+		node, err := enode.New(enode.ValidSchemes, &r)
+		require.NoError(t, err)
+
+		srv := startQUICTestServer(t, "127.0.0.1:0", run)
+		require.IsType(t, &net.TCPAddr{}, connect(t, srv, node))
+	})
+}
@@ -0,0 +1,17 @@
+//go:build integration
+
+package tests
+
+import (
+	"testing"
+)
+
+func TestEOF(t *testing.T) {
+	//t.Parallel()
+	tm := new(testMatcher)
+	tm.walk(t, eofTestDir, func(t *testing.T, name string, test *EOFTest) {
+		if err := tm.checkFailure(t, test.Run()); err != nil {
+			t.Error(err)
+		}
+	})
+}
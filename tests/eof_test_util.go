@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+// EOFTest is the JSON structure of an EOF validation test (EOFTests in ethereum/tests).
+type EOFTest struct {
+	Vectors map[string]eofVector `json:"vectors"`
+}
+
+type eofVector struct {
+	Code    hexutility.Bytes   `json:"code"`
+	Results map[string]eofFork `json:"results"`
+}
+
+type eofFork struct {
+	Result    bool   `json:"result"`
+	Exception string `json:"exception"`
+}
+
+// Run validates every vector of the test and checks that the container is
+// accepted or rejected as expected for each fork.
+func (t *EOFTest) Run() error {
+	names := make([]string, 0, len(t.Vectors))
+	for name := range t.Vectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		vector := t.Vectors[name]
+		err := vm.ValidateEOF(vector.Code)
+		for fork, expected := range vector.Results {
+			if expected.Result && err != nil {
+				return fmt.Errorf("%s (%s): expected valid container, got %w", name, fork, err)
+			}
+			if !expected.Result && err == nil {
+				return fmt.Errorf("%s (%s): expected %s, got valid container", name, fork, expected.Exception)
+			}
+		}
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"sort"
 
 	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
 )
 
 // Forks table defines supported forks and their chain config.
@@ -329,6 +330,27 @@ var Forks = map[string]*chain.Config{
 		ShanghaiTime:                  big.NewInt(0),
 		CancunTime:                    big.NewInt(15_000),
 	},
+	"CancunToPragueAtTime15k": {
+		ChainID:                       big.NewInt(1),
+		HomesteadBlock:                big.NewInt(0),
+		TangerineWhistleBlock:         big.NewInt(0),
+		SpuriousDragonBlock:           big.NewInt(0),
+		ByzantiumBlock:                big.NewInt(0),
+		ConstantinopleBlock:           big.NewInt(0),
+		PetersburgBlock:               big.NewInt(0),
+		IstanbulBlock:                 big.NewInt(0),
+		MuirGlacierBlock:              big.NewInt(0),
+		BerlinBlock:                   big.NewInt(0),
+		LondonBlock:                   big.NewInt(0),
+		ArrowGlacierBlock:             big.NewInt(0),
+		GrayGlacierBlock:              big.NewInt(0),
+		TerminalTotalDifficulty:       big.NewInt(0),
+		TerminalTotalDifficultyPassed: true,
+		ShanghaiTime:                  big.NewInt(0),
+		CancunTime:                    big.NewInt(0),
+		PragueTime:                    big.NewInt(15_000),
+		DepositContract:               libcommon.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa"),
+	},
 }
 
 // Returns the set of defined fork names
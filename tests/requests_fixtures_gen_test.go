@@ -0,0 +1,395 @@
+//go:build genfixtures
+
+// The blockchain tests of requests-tests are filled by TestGenRequestsFixtures:
+//
+//	go test -tags genfixtures -run TestGenRequestsFixtures ./tests
+//
+// The fixtures are filled by the erigon block builder itself, so they check
+// that the requests are collected, committed to and validated consistently,
+// not that the implementation agrees with the other clients. The deposit
+// contract and the withdrawal request contract are minimal mocks: the deposit
+// contract emits its calldata as the DepositEvent log and the withdrawal
+// request contract queues a single request, which the system call dequeues.
+// The fees, the excess and the rate limiting of the EIP-7002 contract are not
+// covered. They are meant to be replaced by the Prague fixtures of the
+// execution-spec-tests once those are released.
+
+package tests
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/ethconsensusconfig"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+type gTx struct {
+	to   libcommon.Address
+	data []byte
+}
+
+type gBlock struct {
+	time      uint64
+	txs       []gTx
+	tamper    func(types.Requests) types.Requests
+	noBody    bool
+	exception string
+}
+
+type gTest struct {
+	network string
+	blocks  []gBlock
+}
+
+var (
+	gKey, _    = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	gSender    = crypto.PubkeyToAddress(gKey.PublicKey)
+	gCoinbase  = libcommon.HexToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba")
+	gDeposit   = libcommon.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa")
+	gFakeDepo  = libcommon.HexToAddress("0x0000000000000000000000000000000000000200")
+	gWithdrawC = params.WithdrawalRequestAddress
+)
+
+// depositCode logs the calldata with the DepositEvent topic.
+func depositCode() []byte {
+	code := []byte{0x36, 0x5f, 0x5f, 0x37, 0x7f}
+	code = append(code, types.DepositEventSignature.Bytes()...)
+	return append(code, 0x36, 0x5f, 0xa1, 0x00)
+}
+
+// withdrawalCode stores the request of any caller but the system address and
+// returns it, once, to the system call.
+var withdrawalCode = libcommon.FromHex("3373fffffffffffffffffffffffffffffffffffffffe14602e57335f555f356001556020356002556001600355005b600354156051575f5460601b5f526001546014526002546034525f600355604c5ff35b00")
+
+// depositData is the ABI encoding of the DepositEvent data.
+func depositData(seed byte, index uint64) []byte {
+	fields := [][]byte{make([]byte, 48), make([]byte, 32), make([]byte, 8), make([]byte, 96), make([]byte, 8)}
+	for i := range fields[0] {
+		fields[0][i] = seed
+	}
+	fields[1][0] = 0x01
+	fields[1][31] = seed
+	binary.LittleEndian.PutUint64(fields[2], 32_000_000_000)
+	for i := range fields[3] {
+		fields[3][i] = seed + 1
+	}
+	binary.LittleEndian.PutUint64(fields[4], index)
+	head := make([]byte, 0, 160)
+	var tail []byte
+	for _, f := range fields {
+		var w [32]byte
+		binary.BigEndian.PutUint64(w[24:], uint64(160+len(tail)))
+		head = append(head, w[:]...)
+		var l [32]byte
+		binary.BigEndian.PutUint64(l[24:], uint64(len(f)))
+		tail = append(tail, l[:]...)
+		padded := make([]byte, (len(f)+31)/32*32)
+		copy(padded, f)
+		tail = append(tail, padded...)
+	}
+	return append(head, tail...)
+}
+
+// withdrawalData is the validator pubkey followed by the amount.
+func withdrawalData(seed byte, amount uint64) []byte {
+	d := make([]byte, 56)
+	for i := 0; i < 48; i++ {
+		d[i] = seed
+	}
+	binary.BigEndian.PutUint64(d[48:], amount)
+	return d
+}
+
+func hdrJSON(h *types.Header) map[string]any {
+	m := map[string]any{
+		"parentHash":       h.ParentHash,
+		"uncleHash":        h.UncleHash,
+		"coinbase":         h.Coinbase,
+		"stateRoot":        h.Root,
+		"transactionsTrie": h.TxHash,
+		"receiptTrie":      h.ReceiptHash,
+		"bloom":            hexutility.Bytes(h.Bloom[:]),
+		"difficulty":       (*hexutil.Big)(h.Difficulty),
+		"number":           (*hexutil.Big)(h.Number),
+		"gasLimit":         hexutil.Uint64(h.GasLimit),
+		"gasUsed":          hexutil.Uint64(h.GasUsed),
+		"timestamp":        hexutil.Uint64(h.Time),
+		"extraData":        hexutility.Bytes(h.Extra),
+		"mixHash":          h.MixDigest,
+		"nonce":            hexutility.Bytes(h.Nonce[:]),
+		"baseFeePerGas":    (*hexutil.Big)(h.BaseFee),
+		"hash":             h.Hash(),
+	}
+	if h.WithdrawalsHash != nil {
+		m["withdrawalsRoot"] = h.WithdrawalsHash
+	}
+	if h.BlobGasUsed != nil {
+		m["blobGasUsed"] = hexutil.Uint64(*h.BlobGasUsed)
+		m["excessBlobGas"] = hexutil.Uint64(*h.ExcessBlobGas)
+	}
+	if h.ParentBeaconBlockRoot != nil {
+		m["parentBeaconBlockRoot"] = h.ParentBeaconBlockRoot
+	}
+	if h.RequestsRoot != nil {
+		m["requestsRoot"] = h.RequestsRoot
+	}
+	return m
+}
+
+type gAccount struct {
+	Nonce   hexutil.Uint64                    `json:"nonce"`
+	Balance *hexutil.Big                      `json:"balance"`
+	Code    hexutility.Bytes                  `json:"code"`
+	Storage map[libcommon.Hash]libcommon.Hash `json:"storage"`
+}
+
+// genTest fills a blockchain test, the blocks with an exception are filled
+// but not imported.
+func genTest(t *testing.T, name string, gt gTest) map[string]any {
+	logger := log.New()
+	ctx := context.Background()
+	cfg := Forks[gt.network]
+	engine := ethconsensusconfig.CreateConsensusEngineBareBones(ctx, cfg, logger)
+	alloc := types.GenesisAlloc{
+		gSender:    {Balance: new(big.Int).SetUint64(1_000_000_000_000_000_000)},
+		gDeposit:   {Code: depositCode(), Balance: new(big.Int)},
+		gFakeDepo:  {Code: depositCode(), Balance: new(big.Int)},
+		gWithdrawC: {Code: withdrawalCode, Balance: new(big.Int)},
+	}
+	genesis := &types.Genesis{Config: cfg, GasLimit: 100_000_000_000_000_000, BaseFee: big.NewInt(7), Difficulty: big.NewInt(0), Alloc: alloc, ExtraData: []byte{0}}
+	db := memdb.NewTestDB(t)
+	gblock := core.MustCommitGenesis(genesis, db, t.TempDir(), logger)
+	pre := map[libcommon.Address]gAccount{}
+	for a, acc := range alloc {
+		pre[a] = gAccount{Balance: (*hexutil.Big)(acc.Balance), Code: acc.Code, Storage: map[libcommon.Hash]libcommon.Hash{}}
+	}
+
+	parent := gblock
+	nonce := uint64(0)
+	signer := types.LatestSignerForChainID(cfg.ChainID)
+	var blocks []map[string]any
+	for _, b := range gt.blocks {
+		tx, err := db.BeginRw(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := core.MakeEmptyHeader(parent.Header(), cfg, b.time, nil)
+		header.Coinbase = gCoinbase
+		header.Extra = []byte{}
+		if cfg.IsCancun(header.Time) {
+			h := libcommon.BigToHash(header.Number)
+			header.ParentBeaconBlockRoot = &h
+		}
+		cr := &core.FakeChainReader{Cfg: cfg}
+		ibs := state.New(state.NewPlainStateReader(tx))
+		if err := core.InitializeBlockExecution(engine, cr, header, cfg, ibs, logger); err != nil {
+			t.Fatal(err)
+		}
+		gp := new(core.GasPool).AddGas(header.GasLimit).AddBlobGas(cfg.GetMaxBlobGasPerBlock())
+		var usedGas, usedBlobGas uint64
+		var txs types.Transactions
+		var receipts types.Receipts
+		n := nonce
+		for i, g := range b.txs {
+			to := g.to
+			txn := &types.DynamicFeeTransaction{
+				CommonTx: types.CommonTx{Nonce: n, Gas: 1_000_000, To: &to, Value: uint256.NewInt(0), Data: g.data},
+				ChainID:  uint256.MustFromBig(cfg.ChainID),
+				Tip:      uint256.NewInt(0),
+				FeeCap:   uint256.NewInt(1000),
+			}
+			n++
+			signed, err := types.SignTx(txn, *signer, gKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ibs.SetTxContext(signed.Hash(), libcommon.Hash{}, i)
+			r, _, err := core.ApplyTransaction(cfg, core.GetHashFn(header, func(libcommon.Hash, uint64) *types.Header { return nil }), engine, nil, gp, ibs, state.NewNoopWriter(), header, signed, &usedGas, &usedBlobGas, vm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			txs = append(txs, signed)
+			receipts = append(receipts, r)
+		}
+		header.GasUsed = usedGas
+		var withdrawals []*types.Withdrawal
+		if cfg.IsShanghai(header.Time) {
+			withdrawals = []*types.Withdrawal{}
+		}
+		built, _, outReceipts, err := core.FinalizeBlockExecution(engine, nil, header, txs, nil, state.NewPlainStateWriterNoHistory(tx), cfg, ibs, receipts, withdrawals, nil, cr, true, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := core.CalcHashRootForTests(tx, header, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := built.Header()
+		h.Root = root
+		requests := built.Requests()
+		if b.tamper != nil {
+			requests = b.tamper(requests)
+		}
+		block := types.NewBlock(h, txs, nil, outReceipts, withdrawals, requests)
+		if b.noBody {
+			block = types.NewBlockFromStorage(block.Hash(), block.Header(), txs, nil, withdrawals, nil)
+		}
+		enc, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var deposits, wrs []types.Request
+		for _, r := range block.Requests() {
+			if r.RequestType() == types.DepositRequestType {
+				deposits = append(deposits, r)
+			} else {
+				wrs = append(wrs, r)
+			}
+		}
+		body := map[string]any{
+			"blockHeader":  hdrJSON(block.Header()),
+			"transactions": block.Transactions(),
+			"uncleHeaders": []any{},
+		}
+		if withdrawals != nil {
+			body["withdrawals"] = []any{}
+		}
+		if block.Requests() != nil {
+			body["depositRequests"] = append([]types.Request{}, deposits...)
+			body["withdrawalRequests"] = append([]types.Request{}, wrs...)
+		}
+		if b.exception != "" {
+			blocks = append(blocks, map[string]any{"rlp": hexutility.Bytes(enc), "expectException": b.exception, "rlp_decoded": body})
+			tx.Rollback()
+			continue
+		}
+		body["rlp"] = hexutility.Bytes(enc)
+		blocks = append(blocks, body)
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		nonce = n
+		parent = block
+	}
+
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	ibs := state.New(state.NewPlainStateReader(tx))
+	post := map[libcommon.Address]gAccount{}
+	for a := range alloc {
+		acc := gAccount{Nonce: hexutil.Uint64(ibs.GetNonce(a)), Balance: (*hexutil.Big)(ibs.GetBalance(a).ToBig()), Code: ibs.GetCode(a), Storage: map[libcommon.Hash]libcommon.Hash{}}
+		for i := uint64(0); i < 4; i++ {
+			k := libcommon.BigToHash(new(big.Int).SetUint64(i))
+			var v uint256.Int
+			ibs.GetState(a, k, &v)
+			if !v.IsZero() {
+				acc.Storage[k] = libcommon.Hash(v.Bytes32())
+			}
+		}
+		post[a] = acc
+	}
+	genc, _ := rlp.EncodeToBytes(gblock)
+	return map[string]any{
+		"_info":              map[string]any{"comment": "filled with the erigon block builder, the deposit and withdrawal request system contracts are minimal mocks"},
+		"network":            gt.network,
+		"genesisRLP":         hexutility.Bytes(genc),
+		"genesisBlockHeader": hdrJSON(gblock.Header()),
+		"blocks":             blocks,
+		"lastblockhash":      parent.Hash(),
+		"pre":                pre,
+		"postState":          post,
+		"sealEngine":         "NoProof",
+	}
+}
+
+func writeFixture(t *testing.T, path string, tests map[string]gTest) {
+	out := map[string]any{}
+	for name, gt := range tests {
+		out[name] = genTest(t, name, gt)
+	}
+	data, err := json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("wrote", path)
+}
+
+func dropAll(types.Requests) types.Requests { return types.Requests{} }
+
+func TestGenRequestsFixtures(t *testing.T) {
+	dir := filepath.Join(".", "requests-tests")
+	writeFixture(t, filepath.Join(dir, "eip6110_deposits", "deposits.json"), map[string]gTest{
+		"000-fork=CancunToPragueAtTime15k-single_deposit": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 15_000, txs: []gTx{{gDeposit, depositData(0x11, 0)}}},
+			{time: 15_012},
+		}},
+		"001-fork=CancunToPragueAtTime15k-multiple_deposits_ignore_other_contracts": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 15_000, txs: []gTx{{gDeposit, depositData(0x11, 0)}, {gFakeDepo, depositData(0x21, 7)}, {gDeposit, depositData(0x31, 1)}}},
+		}},
+		"003-fork=CancunToPragueAtTime15k-empty_requests_root_without_requests_list": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 15_000, txs: []gTx{{gDeposit, depositData(0x51, 0)}}, tamper: dropAll, noBody: true, exception: "invalid requests root"},
+		}},
+		"002-fork=CancunToPragueAtTime15k-missing_deposit_request": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 15_000, txs: []gTx{{gDeposit, depositData(0x11, 0)}}},
+			{time: 15_012, txs: []gTx{{gDeposit, depositData(0x41, 1)}}, tamper: dropAll, exception: "invalid requests root"},
+		}},
+	})
+	writeFixture(t, filepath.Join(dir, "eip7002_withdrawal_requests", "withdrawal_requests.json"), map[string]gTest{
+		"000-fork=CancunToPragueAtTime15k-single_withdrawal_request": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 15_000, txs: []gTx{{gWithdrawC, withdrawalData(0x51, 1_000_000_000)}}},
+			{time: 15_012},
+		}},
+		"001-fork=CancunToPragueAtTime15k-full_exit_request": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 15_000, txs: []gTx{{gWithdrawC, withdrawalData(0x61, 0)}}},
+		}},
+		"002-fork=CancunToPragueAtTime15k-missing_withdrawal_request": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 15_000, txs: []gTx{{gWithdrawC, withdrawalData(0x71, 0)}}, tamper: dropAll, exception: "invalid requests root"},
+		}},
+	})
+	writeFixture(t, filepath.Join(dir, "eip7685_requests", "requests.json"), map[string]gTest{
+		"000-fork=CancunToPragueAtTime15k-deposit_and_withdrawal_request": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 15_000, txs: []gTx{{gWithdrawC, withdrawalData(0x81, 0)}, {gDeposit, depositData(0x91, 0)}}},
+		}},
+		"001-fork=CancunToPragueAtTime15k-requests_out_of_order": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 15_000, txs: []gTx{{gWithdrawC, withdrawalData(0x81, 0)}, {gDeposit, depositData(0x91, 0)}}, tamper: func(rs types.Requests) types.Requests {
+				return types.Requests{rs[1], rs[0]}
+			}, exception: "invalid requests root"},
+		}},
+		"002-fork=CancunToPragueAtTime15k-requests_at_transition": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 14_999, txs: []gTx{{gDeposit, depositData(0xa1, 0)}}},
+			{time: 15_000, txs: []gTx{{gDeposit, depositData(0xa1, 1)}, {gWithdrawC, withdrawalData(0xb1, 0)}}},
+		}},
+		"003-fork=CancunToPragueAtTime15k-requests_before_prague": {network: "CancunToPragueAtTime15k", blocks: []gBlock{
+			{time: 14_999, tamper: dropAll, exception: "unexpected requests"},
+		}},
+	})
+}
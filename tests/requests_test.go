@@ -0,0 +1,28 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ledgerwatch/log/v3"
+)
+
+// TestExecutionRequests runs the blockchain tests of the EIP-6110 deposits, the EIP-7002 withdrawal requests
+// and the EIP-7685 requests root. The fixtures are filled by TestGenRequestsFixtures of requests_fixtures_gen_test.go,
+// see there for their limits.
+func TestExecutionRequests(t *testing.T) {
+	defer log.Root().SetHandler(log.Root().GetHandler())
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlError, log.StderrHandler))
+
+	bt := new(testMatcher)
+
+	dir := filepath.Join(".", "requests-tests")
+
+	checkStateRoot := true
+
+	bt.walk(t, dir, func(t *testing.T, name string, test *BlockTest) {
+		if err := bt.checkFailure(t, test.Run(t, checkStateRoot)); err != nil {
+			t.Error(err)
+		}
+	})
+}
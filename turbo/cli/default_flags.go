@@ -78,6 +78,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.RpcReturnDataLimit,
 	&utils.AllowUnprotectedTxs,
 	&utils.RpcMaxGetProofRewindBlockCount,
+	&utils.RpcMaxStateDiffBlockRange,
 	&utils.RPCGlobalTxFeeCapFlag,
 	&utils.TxpoolApiAddrFlag,
 	&utils.TraceMaxtracesFlag,
@@ -104,9 +105,13 @@ var DefaultFlags = []cli.Flag{
 	&utils.ListenPortFlag,
 	&utils.P2pProtocolVersionFlag,
 	&utils.P2pProtocolAllowedPorts,
+	&utils.P2pQUICPortFlag,
 	&utils.NATFlag,
 	&utils.NoDiscoverFlag,
 	&utils.DiscoveryV5Flag,
+	&utils.DiscoveryForkFilterFlag,
+	&utils.SnapServeFlag,
+	&utils.PortalHistoryFlag,
 	&utils.NetrestrictFlag,
 	&utils.NodeKeyFileFlag,
 	&utils.NodeKeyHexFlag,
@@ -167,6 +172,9 @@ var DefaultFlags = []cli.Flag{
 	&utils.SilkwormRpcDaemonFlag,
 	&utils.SilkwormSentryFlag,
 
+	&utils.LiveTracerFlag,
+	&utils.LiveTracerConfigFlag,
+
 	&utils.BeaconAPIFlag,
 	&utils.BeaconApiAddrFlag,
 	&utils.BeaconApiAllowMethodsFlag,
@@ -180,10 +188,12 @@ var DefaultFlags = []cli.Flag{
 
 	&utils.CaplinBackfillingFlag,
 	&utils.CaplinArchiveFlag,
+	&utils.CaplinCheckpointsToKeepFlag,
 
 	&utils.TrustedSetupFile,
 	&utils.RPCSlowFlag,
 
 	&utils.TxPoolGossipDisableFlag,
 	&SyncLoopBlockLimitFlag,
+	&SyncParallelExecFlag,
 }
@@ -167,6 +167,11 @@ var (
 		Value: 2_000, // unlimited
 	}
 
+	SyncParallelExecFlag = cli.BoolFlag{
+		Name:  "sync.parallel.exec",
+		Usage: "Executes the transactions of the blocks near the chain tip in parallel, re-executing the conflicting ones. Not supported with --experimental.history.v3",
+	}
+
 	UploadLocationFlag = cli.StringFlag{
 		Name:  "upload.location",
 		Usage: "Location to upload snapshot segments to",
@@ -304,6 +309,8 @@ func ApplyFlagsForEthConfig(ctx *cli.Context, cfg *ethconfig.Config, logger log.
 		cfg.Sync.LoopBlockLimit = limit
 	}
 
+	cfg.Sync.ParallelExec = ctx.Bool(SyncParallelExecFlag.Name)
+
 	if location := ctx.String(UploadLocationFlag.Name); len(location) > 0 {
 		cfg.Sync.UploadLocation = location
 	}
@@ -468,6 +475,7 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logg
 		ReturnDataLimit:             ctx.Int(utils.RpcReturnDataLimit.Name),
 		AllowUnprotectedTxs:         ctx.Bool(utils.AllowUnprotectedTxs.Name),
 		MaxGetProofRewindBlockCount: ctx.Int(utils.RpcMaxGetProofRewindBlockCount.Name),
+		MaxStateDiffBlockRange:      ctx.Uint64(utils.RpcMaxStateDiffBlockRange.Name),
 
 		OtsMaxPageSize: ctx.Uint64(utils.OtsSearchMaxCapFlag.Name),
 
@@ -34,6 +34,26 @@ type ExecutionPayload struct {
 	Withdrawals   []*types.Withdrawal `json:"withdrawals"`
 	BlobGasUsed   *hexutil.Uint64     `json:"blobGasUsed"`
 	ExcessBlobGas *hexutil.Uint64     `json:"excessBlobGas"`
+
+	DepositRequests    []*types.Deposit           `json:"depositRequests"`    // added in V4 (EIP-6110)
+	WithdrawalRequests []*types.WithdrawalRequest `json:"withdrawalRequests"` // added in V4 (EIP-7002)
+}
+
+// Requests returns the execution layer requests of the payload in the order they are
+// committed to by the requests root: deposits first, then withdrawal requests.
+// nil is returned for payloads without requests (pre V4).
+func (p *ExecutionPayload) Requests() types.Requests {
+	if p.DepositRequests == nil && p.WithdrawalRequests == nil {
+		return nil
+	}
+	requests := make(types.Requests, 0, len(p.DepositRequests)+len(p.WithdrawalRequests))
+	for _, d := range p.DepositRequests {
+		requests = append(requests, d)
+	}
+	for _, w := range p.WithdrawalRequests {
+		requests = append(requests, w)
+	}
+	return requests
 }
 
 // PayloadAttributes represent the attributes required to start assembling a payload
@@ -152,7 +172,7 @@ func ConvertRpcBlockToExecutionPayload(payload *execution.Block) *ExecutionPaylo
 	return res
 }
 
-func ConvertPayloadFromRpc(payload *types2.ExecutionPayload) *ExecutionPayload {
+func ConvertPayloadFromRpc(payload *types2.ExecutionPayload) (*ExecutionPayload, error) {
 	var bloom types.Bloom = gointerfaces.ConvertH2048ToBloom(payload.LogsBloom)
 	baseFee := gointerfaces.ConvertH256ToUint256Int(payload.BaseFeePerGas).ToBig()
 
@@ -187,7 +207,23 @@ func ConvertPayloadFromRpc(payload *types2.ExecutionPayload) *ExecutionPayload {
 		excessBlobGas := *payload.ExcessBlobGas
 		res.ExcessBlobGas = (*hexutil.Uint64)(&excessBlobGas)
 	}
-	return res
+	if payload.Version >= 4 {
+		res.DepositRequests = []*types.Deposit{}
+		res.WithdrawalRequests = []*types.WithdrawalRequest{}
+		for _, data := range payload.Requests {
+			r, err := types.DecodeRequest(data)
+			if err != nil {
+				return nil, err
+			}
+			switch r := r.(type) {
+			case *types.Deposit:
+				res.DepositRequests = append(res.DepositRequests, r)
+			case *types.WithdrawalRequest:
+				res.WithdrawalRequests = append(res.WithdrawalRequests, r)
+			}
+		}
+	}
+	return res, nil
 }
 
 func ConvertBlobsFromRpc(bundle *types2.BlobsBundleV1) *BlobsBundleV1 {
@@ -162,6 +162,11 @@ func (e *EthereumExecutionModule) GetAssembledBlock(ctx context.Context, req *ex
 		payload.ExcessBlobGas = header.ExcessBlobGas
 	}
 
+	if block.Requests() != nil {
+		payload.Version = 4
+		payload.Requests = eth1_utils.ConvertRequestsToRpc(block.Requests())
+	}
+
 	blockValue := blockValue(blockWithReceipts, baseFee)
 
 	blobsBundle := &types2.BlobsBundleV1{}
@@ -1,6 +1,7 @@
 package eth1_utils
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"math/big"
@@ -57,6 +58,10 @@ func HeaderToHeaderRPC(header *types.Header) *execution.Header {
 		h.ParentBeaconBlockRoot = gointerfaces.ConvertHashToH256(*header.ParentBeaconBlockRoot)
 	}
 
+	if header.RequestsRoot != nil {
+		h.RequestsRoot = gointerfaces.ConvertHashToH256(*header.RequestsRoot)
+	}
+
 	if len(header.AuRaSeal) > 0 {
 		h.AuraSeal = header.AuRaSeal
 		h.AuraStep = &header.AuRaStep
@@ -128,6 +133,10 @@ func HeaderRpcToHeader(header *execution.Header) (*types.Header, error) {
 		h.ParentBeaconBlockRoot = new(libcommon.Hash)
 		*h.ParentBeaconBlockRoot = gointerfaces.ConvertH256ToHash(header.ParentBeaconBlockRoot)
 	}
+	if header.RequestsRoot != nil {
+		h.RequestsRoot = new(libcommon.Hash)
+		*h.RequestsRoot = gointerfaces.ConvertH256ToHash(header.RequestsRoot)
+	}
 	blockHash := gointerfaces.ConvertH256ToHash(header.BlockHash)
 	if blockHash != h.Hash() {
 		return nil, fmt.Errorf("block %d, %x has invalid hash. expected: %x", header.BlockNumber, h.Hash(), blockHash)
@@ -168,6 +177,37 @@ func ConvertWithdrawalsToRpc(in []*types.Withdrawal) []*types2.Withdrawal {
 	return out
 }
 
+// ConvertRequestsToRpc encodes each request in its typed form.
+func ConvertRequestsToRpc(in types.Requests) [][]byte {
+	if in == nil {
+		return nil
+	}
+	out := make([][]byte, 0, len(in))
+	for _, r := range in {
+		var buf bytes.Buffer
+		if err := r.MarshalBinary(&buf); err != nil {
+			panic(err)
+		}
+		out = append(out, buf.Bytes())
+	}
+	return out
+}
+
+func ConvertRequestsFromRpc(in [][]byte) (types.Requests, error) {
+	if in == nil {
+		return nil, nil
+	}
+	out := make(types.Requests, 0, len(in))
+	for _, data := range in {
+		r, err := types.DecodeRequest(data)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
 func ConvertRawBlockBodyToRpc(in *types.RawBody, blockNumber uint64, blockHash libcommon.Hash) *execution.BlockBody {
 	if in == nil {
 		return nil
@@ -178,6 +218,7 @@ func ConvertRawBlockBodyToRpc(in *types.RawBody, blockNumber uint64, blockHash l
 		BlockHash:    gointerfaces.ConvertHashToH256(blockHash),
 		Transactions: in.Transactions,
 		Withdrawals:  ConvertWithdrawalsToRpc(in.Withdrawals),
+		Requests:     ConvertRequestsToRpc(in.Requests),
 	}
 }
 
@@ -190,14 +231,19 @@ func ConvertRawBlockBodiesToRpc(in []*types.RawBody, blockNumbers []uint64, bloc
 	return ret
 }
 
-func ConvertRawBlockBodyFromRpc(in *execution.BlockBody) *types.RawBody {
+func ConvertRawBlockBodyFromRpc(in *execution.BlockBody) (*types.RawBody, error) {
 	if in == nil {
-		return nil
+		return nil, nil
+	}
+	requests, err := ConvertRequestsFromRpc(in.Requests)
+	if err != nil {
+		return nil, err
 	}
 	return &types.RawBody{
 		Transactions: in.Transactions,
 		Withdrawals:  ConvertWithdrawalsFromRpc(in.Withdrawals),
-	}
+		Requests:     requests,
+	}, nil
 }
 
 func ConvertBigIntFromRpc(in *types2.H256) *big.Int {
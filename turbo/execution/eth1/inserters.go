@@ -7,6 +7,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/execution"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/turbo/execution/eth1/eth1_utils"
 )
 
@@ -29,7 +30,14 @@ func (e *EthereumExecutionModule) InsertBlocks(ctx context.Context, req *executi
 		if err != nil {
 			return nil, fmt.Errorf("ethereumExecutionModule.InsertBlocks: cannot convert headers: %s", err)
 		}
-		body := eth1_utils.ConvertRawBlockBodyFromRpc(block.Body)
+		body, err := eth1_utils.ConvertRawBlockBodyFromRpc(block.Body)
+		if err != nil {
+			return nil, fmt.Errorf("ethereumExecutionModule.InsertBlocks: cannot convert body: %s", err)
+		}
+		if header.RequestsRoot != nil && body.Requests == nil {
+			// an empty requests list does not survive protobuf encoding
+			body.Requests = types.Requests{}
+		}
 
 		parentTd := common.Big0
 		if header.Number.Uint64() > 0 {
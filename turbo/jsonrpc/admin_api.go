@@ -4,10 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
-	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/log/v3"
 
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
@@ -22,6 +30,34 @@ type AdminAPI interface {
 
 	// AddPeer requests connecting to a remote node.
 	AddPeer(ctx context.Context, url string) (bool, error)
+
+	// RemovePeer disconnects from a remote node and stops reconnecting to it.
+	RemovePeer(ctx context.Context, url string) (bool, error)
+
+	// AddTrustedPeer allows a remote node to always connect, even when the peer slots are full.
+	AddTrustedPeer(ctx context.Context, url string) (bool, error)
+
+	// RemoveTrustedPeer removes a remote node from the trusted peer set.
+	RemoveTrustedPeer(ctx context.Context, url string) (bool, error)
+
+	// BanPeer disconnects from a remote node and refuses its connections for the given number of seconds.
+	// The node is given by its enode URL or its hex encoded ID. Bans survive restarts.
+	BanPeer(ctx context.Context, node string, seconds uint64) (bool, error)
+
+	// UnbanPeer lifts the ban of a remote node.
+	UnbanPeer(ctx context.Context, node string) (bool, error)
+
+	// BannedPeers returns the remote nodes which are currently banned.
+	BannedPeers(ctx context.Context) ([]*BannedPeer, error)
+
+	// PeerEvents sends a notification each time a remote node connects or disconnects.
+	PeerEvents(ctx context.Context) (*rpc.Subscription, error)
+}
+
+// BannedPeer is a remote node banned by BanPeer.
+type BannedPeer struct {
+	ID    enode.ID  `json:"id"`
+	Until time.Time `json:"until"`
 }
 
 // AdminAPIImpl data structure to store things needed for admin_* commands.
@@ -63,3 +99,129 @@ func (api *AdminAPIImpl) AddPeer(ctx context.Context, url string) (bool, error)
 	}
 	return result.Success, nil
 }
+
+func (api *AdminAPIImpl) RemovePeer(ctx context.Context, url string) (bool, error) {
+	result, err := api.ethBackend.RemovePeer(ctx, &remote.RemovePeerRequest{Url: url})
+	if err != nil {
+		return false, err
+	}
+	if result == nil {
+		return false, errors.New("nil removePeer response")
+	}
+	return result.Success, nil
+}
+
+func (api *AdminAPIImpl) AddTrustedPeer(ctx context.Context, url string) (bool, error) {
+	result, err := api.ethBackend.AddTrustedPeer(ctx, &remote.AddPeerRequest{Url: url})
+	if err != nil {
+		return false, err
+	}
+	if result == nil {
+		return false, errors.New("nil addTrustedPeer response")
+	}
+	return result.Success, nil
+}
+
+func (api *AdminAPIImpl) RemoveTrustedPeer(ctx context.Context, url string) (bool, error) {
+	result, err := api.ethBackend.RemoveTrustedPeer(ctx, &remote.RemovePeerRequest{Url: url})
+	if err != nil {
+		return false, err
+	}
+	if result == nil {
+		return false, errors.New("nil removeTrustedPeer response")
+	}
+	return result.Success, nil
+}
+
+func (api *AdminAPIImpl) BanPeer(ctx context.Context, node string, seconds uint64) (bool, error) {
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	if seconds == 0 {
+		return false, errors.New("ban duration must be positive")
+	}
+	result, err := api.ethBackend.BanPeer(ctx, &remote.BanPeerRequest{Id: gointerfaces.ConvertHashToH256(libcommon.Hash(id)), DurationSeconds: seconds})
+	if err != nil {
+		return false, err
+	}
+	if result == nil {
+		return false, errors.New("nil banPeer response")
+	}
+	return result.Success, nil
+}
+
+func (api *AdminAPIImpl) UnbanPeer(ctx context.Context, node string) (bool, error) {
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	result, err := api.ethBackend.UnbanPeer(ctx, &remote.UnbanPeerRequest{Id: gointerfaces.ConvertHashToH256(libcommon.Hash(id))})
+	if err != nil {
+		return false, err
+	}
+	if result == nil {
+		return false, errors.New("nil unbanPeer response")
+	}
+	return result.Success, nil
+}
+
+func (api *AdminAPIImpl) BannedPeers(ctx context.Context) ([]*BannedPeer, error) {
+	result, err := api.ethBackend.BannedPeers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	banned := make([]*BannedPeer, 0, len(result.Peers))
+	for _, peer := range result.Peers {
+		banned = append(banned, &BannedPeer{
+			ID:    enode.ID(gointerfaces.ConvertH256ToHash(peer.Id)),
+			Until: time.Unix(int64(peer.Until), 0).UTC(),
+		})
+	}
+	return banned, nil
+}
+
+func (api *AdminAPIImpl) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-rpcSub.Err():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		err := api.ethBackend.PeerEvents(ctx, func(event *remote.PeerEvent) {
+			pubkey := gointerfaces.ConvertH512ToHash(event.PeerId)
+			ev := &p2p.PeerEvent{Type: p2p.PeerEventTypeAdd, Peer: enode.ID(crypto.Keccak256Hash(pubkey[:]))}
+			if event.EventId == remote.PeerEvent_Disconnect {
+				ev.Type = p2p.PeerEventTypeDrop
+			}
+			if err := notifier.Notify(rpcSub.ID, ev); err != nil {
+				log.Warn("[rpc] error while notifying subscription", "err", err)
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Warn("[rpc] peer events subscription failed", "err", err)
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// parseNodeID accepts either the enode URL or the hex encoded ID of a node.
+func parseNodeID(node string) (enode.ID, error) {
+	if n, err := enode.Parse(enode.ValidSchemes, node); err == nil {
+		return n.ID(), nil
+	}
+	return enode.ParseID(node)
+}
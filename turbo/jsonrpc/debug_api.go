@@ -49,14 +49,17 @@ type PrivateDebugAPIImpl struct {
 	*BaseAPI
 	db     kv.RoDB
 	GasCap uint64
+
+	maxStateDiffBlockRange uint64 // 0 means no limit
 }
 
 // NewPrivateDebugAPI returns PrivateDebugAPIImpl instance
-func NewPrivateDebugAPI(base *BaseAPI, db kv.RoDB, gascap uint64) *PrivateDebugAPIImpl {
+func NewPrivateDebugAPI(base *BaseAPI, db kv.RoDB, gascap uint64, maxStateDiffBlockRange uint64) *PrivateDebugAPIImpl {
 	return &PrivateDebugAPIImpl{
-		BaseAPI: base,
-		db:      db,
-		GasCap:  gascap,
+		BaseAPI:                base,
+		db:                     db,
+		GasCap:                 gascap,
+		maxStateDiffBlockRange: maxStateDiffBlockRange,
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutil"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/iter"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
@@ -20,6 +21,7 @@ import (
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/rpc/rpccfg"
 	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/stretchr/testify/require"
 )
@@ -54,7 +56,7 @@ func TestTraceBlockByNumber(t *testing.T) {
 	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
 	baseApi := NewBaseApi(nil, stateCache, m.BlockReader, agg, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs)
 	ethApi := NewEthAPI(baseApi, m.DB, nil, nil, nil, 5000000, 100_000, false, 100_000, log.New())
-	api := NewPrivateDebugAPI(baseApi, m.DB, 0)
+	api := NewPrivateDebugAPI(baseApi, m.DB, 0, 0)
 	for _, tt := range debugTraceTransactionTests {
 		var buf bytes.Buffer
 		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
@@ -99,7 +101,7 @@ func TestTraceBlockByNumber(t *testing.T) {
 func TestTraceBlockByHash(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	ethApi := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 100_000, false, 100_000, log.New())
-	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0, 0)
 	for _, tt := range debugTraceTransactionTests {
 		var buf bytes.Buffer
 		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
@@ -130,7 +132,7 @@ func TestTraceBlockByHash(t *testing.T) {
 
 func TestTraceTransaction(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
-	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0, 0)
 	for _, tt := range debugTraceTransactionTests {
 		var buf bytes.Buffer
 		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
@@ -159,7 +161,7 @@ func TestTraceTransaction(t *testing.T) {
 
 func TestTraceTransactionNoRefund(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
-	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0, 0)
 	for _, tt := range debugTraceTransactionNoRefundTests {
 		var buf bytes.Buffer
 		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
@@ -189,7 +191,7 @@ func TestTraceTransactionNoRefund(t *testing.T) {
 
 func TestStorageRangeAt(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
-	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0, 0)
 	t.Run("invalid addr", func(t *testing.T) {
 		var block4 *types.Block
 		var err error
@@ -283,7 +285,7 @@ func TestStorageRangeAt(t *testing.T) {
 
 func TestAccountRange(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
-	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0, 0)
 
 	t.Run("valid account", func(t *testing.T) {
 		addr := common.HexToAddress("0x537e697c7ab75a26f9ecf0ce810e3154dfcaaf55")
@@ -342,7 +344,7 @@ func TestAccountRange(t *testing.T) {
 
 func TestGetModifiedAccountsByNumber(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
-	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0, 0)
 
 	t.Run("correct input", func(t *testing.T) {
 		n, n2 := rpc.BlockNumber(1), rpc.BlockNumber(2)
@@ -386,6 +388,94 @@ func TestGetModifiedAccountsByNumber(t *testing.T) {
 	})
 }
 
+func TestTraceBlockStateDiff(t *testing.T) {
+	m, bankAddress, contractAddr := chainWithDeployedContract(t)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0, 2)
+	stateDiff := func(number rpc.BlockNumber, config *tracers.StateDiffConfig) (RangeStateDiff, error) {
+		var buf bytes.Buffer
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+		if err := api.TraceBlockByNumber(m.Ctx, number, &tracers.TraceConfig{StateDiff: config}, stream); err != nil {
+			return nil, err
+		}
+		if err := stream.Flush(); err != nil {
+			return nil, err
+		}
+		var diff RangeStateDiff
+		if err := json.Unmarshal(buf.Bytes(), &diff); err != nil {
+			return nil, err
+		}
+		return diff, nil
+	}
+	toBlock := func(number rpc.BlockNumber) *rpc.BlockNumberOrHash {
+		to := rpc.BlockNumberOrHashWithNumber(number)
+		return &to
+	}
+
+	n, n2, n3 := rpc.BlockNumber(1), rpc.BlockNumber(2), rpc.BlockNumber(3)
+	_, err := stateDiff(n2, &tracers.StateDiffConfig{ToBlock: toBlock(n)})
+	require.ErrorContains(t, err, "must be less than or equal")
+	_, err = stateDiff(n, &tracers.StateDiffConfig{ToBlock: toBlock(n3)})
+	require.ErrorContains(t, err, "too large")
+
+	diff, err := stateDiff(n, &tracers.StateDiffConfig{WithCode: true, ToBlock: toBlock(n2)})
+	if !m.HistoryV3 {
+		require.ErrorIs(t, err, errStateDiffNoHistoryV3)
+		return
+	}
+	require.NoError(t, err)
+
+	// The contract is deployed in block 1 and stores 1 in block 2
+	require.Contains(t, diff, bankAddress)
+	require.Equal(t, hexutil.Uint64(0), diff[bankAddress].Nonce.From)
+	require.Equal(t, hexutil.Uint64(2), diff[bankAddress].Nonce.To)
+	require.Contains(t, diff, contractAddr)
+	require.True(t, diff[contractAddr].Created)
+	require.NotNil(t, diff[contractAddr].Code)
+	require.Empty(t, diff[contractAddr].Code.From)
+	require.NotEmpty(t, diff[contractAddr].Code.To)
+	require.NotEmpty(t, diff[contractAddr].Storage)
+
+	tx, err := m.DB.BeginRo(m.Ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	// The state at the beginning of block 1 and at the end of block 2
+	before, err := rpchelper.CreateHistoryStateReader(tx, 1, -1, m.HistoryV3, "")
+	require.NoError(t, err)
+	after, err := rpchelper.CreateHistoryStateReader(tx, 3, -1, m.HistoryV3, "")
+	require.NoError(t, err)
+
+	modified, err := api.GetModifiedAccountsByNumber(m.Ctx, n, &n2)
+	require.NoError(t, err)
+	for addr, account := range diff {
+		require.Contains(t, modified, addr)
+		from, err := before.ReadAccountData(addr)
+		require.NoError(t, err)
+		to, err := after.ReadAccountData(addr)
+		require.NoError(t, err)
+		require.Equal(t, from == nil, account.Created, addr)
+		require.Equal(t, to == nil, account.Deleted, addr)
+		if account.Balance != nil {
+			require.Equal(t, to.Balance.ToBig(), account.Balance.To.ToInt())
+		}
+		if account.Nonce != nil {
+			require.Equal(t, to.Nonce, uint64(account.Nonce.To))
+		}
+		for _, slot := range account.Storage {
+			key := slot.Key
+			v, err := after.ReadAccountStorage(addr, to.Incarnation, &key)
+			require.NoError(t, err)
+			require.Equal(t, common.BytesToHash(v), slot.To)
+		}
+	}
+
+	// A single block is the same as a range of one block
+	single, err := stateDiff(n, &tracers.StateDiffConfig{})
+	require.NoError(t, err)
+	ranged, err := stateDiff(n, &tracers.StateDiffConfig{ToBlock: toBlock(n)})
+	require.NoError(t, err)
+	require.Equal(t, single, ranged)
+}
+
 func TestMapTxNum2BlockNum(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	if !m.HistoryV3 {
@@ -444,7 +534,7 @@ func TestMapTxNum2BlockNum(t *testing.T) {
 
 func TestAccountAt(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
-	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0, 0)
 
 	var blockHash0, blockHash1, blockHash3, blockHash10, blockHash12 common.Hash
 	_ = m.DB.View(m.Ctx, func(tx kv.Tx) error {
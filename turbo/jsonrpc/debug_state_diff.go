@@ -0,0 +1,243 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/holiman/uint256"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutil"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/order"
+	"github.com/ledgerwatch/erigon-lib/kv/rawdbv3"
+
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+var errStateDiffNoHistoryV3 = errors.New("state diffs are read from the history domains, which requires --experimental.history.v3")
+
+// FieldDiff is the value of an account field before and after a block range.
+type FieldDiff[T any] struct {
+	From T `json:"from"`
+	To   T `json:"to"`
+}
+
+// RangeStorageDiff is the value of a storage slot before and after a block range, along with
+// the preimage of its hashed key.
+type RangeStorageDiff struct {
+	Key  common.Hash `json:"key"`
+	From common.Hash `json:"from"`
+	To   common.Hash `json:"to"`
+}
+
+// RangeAccountDiff holds the fields of an account which changed over a block range. A field which
+// didn't change, or changed and went back to its original value, is omitted.
+type RangeAccountDiff struct {
+	Created  bool                              `json:"created,omitempty"` // The account didn't exist before the range
+	Deleted  bool                              `json:"deleted,omitempty"` // The account doesn't exist after the range
+	Balance  *FieldDiff[*hexutil.Big]          `json:"balance,omitempty"`
+	Nonce    *FieldDiff[hexutil.Uint64]        `json:"nonce,omitempty"`
+	CodeHash *FieldDiff[common.Hash]           `json:"codeHash,omitempty"`
+	Code     *FieldDiff[hexutility.Bytes]      `json:"code,omitempty"`
+	Storage  map[common.Hash]*RangeStorageDiff `json:"storage,omitempty"`
+}
+
+// RangeStateDiff is the net change of the state over a block range, keyed by account.
+type RangeStateDiff map[common.Address]*RangeAccountDiff
+
+func (sd RangeStateDiff) account(addr common.Address) *RangeAccountDiff {
+	diff, ok := sd[addr]
+	if !ok {
+		diff = &RangeAccountDiff{}
+		sd[addr] = diff
+	}
+	return diff
+}
+
+// traceBlockStateDiff implements the state diff mode of debug_traceBlockByNumber and debug_traceBlockByHash.
+// Writes the net state changes of the given block, or of the blocks from startNum to config.ToBlock inclusive.
+// The diff is read from the history domains, so the blocks are not re-executed.
+func (api *PrivateDebugAPIImpl) traceBlockStateDiff(tx kv.Tx, startNum uint64, config *tracers.StateDiffConfig, stream *jsoniter.Stream) error {
+	endNum := startNum // the traced block alone
+	if config.ToBlock != nil {
+		var err error
+		if endNum, _, _, err = rpchelper.GetBlockNumber(*config.ToBlock, tx, api.filters); err != nil {
+			stream.WriteNil()
+			return err
+		}
+	}
+	latestBlock, err := stages.GetStageProgress(tx, stages.Finish)
+	if err != nil {
+		stream.WriteNil()
+		return err
+	}
+	if endNum > latestBlock {
+		stream.WriteNil()
+		return fmt.Errorf("end block (%d) is later than the latest block (%d)", endNum, latestBlock)
+	}
+	if startNum > endNum {
+		stream.WriteNil()
+		return fmt.Errorf("start block (%d) must be less than or equal to end block (%d)", startNum, endNum)
+	}
+	diff, err := api.stateDiff(tx, startNum, endNum, config.WithCode)
+	if err != nil {
+		stream.WriteNil()
+		return err
+	}
+	b, err := json.Marshal(diff)
+	if err != nil {
+		stream.WriteNil()
+		return err
+	}
+	stream.Write(b)
+	return nil
+}
+
+// stateDiff compares the accounts, storage and code changed by the blocks [startNum, endNum] as of the
+// first transaction of startNum, as recorded by the histories, and as of the end of endNum.
+func (api *PrivateDebugAPIImpl) stateDiff(tx kv.Tx, startNum, endNum uint64, withCode bool) (RangeStateDiff, error) {
+	if api.maxStateDiffBlockRange > 0 && endNum-startNum >= api.maxStateDiffBlockRange {
+		return nil, fmt.Errorf("block range [%d, %d] is too large, at most %d blocks are allowed", startNum, endNum, api.maxStateDiffBlockRange)
+	}
+	if !api.historyV3(tx) {
+		return nil, errStateDiffNoHistoryV3
+	}
+	startTxNum, err := rawdbv3.TxNums.Min(tx, startNum)
+	if err != nil {
+		return nil, err
+	}
+	endTxNum, err := rawdbv3.TxNums.Max(tx, endNum)
+	if err != nil {
+		return nil, err
+	}
+	endTxNum++ // the state as of the first transaction after the range
+	ttx := tx.(kv.TemporalTx)
+
+	diff := RangeStateDiff{}
+	if err := stateDiffAccounts(ttx, diff, startTxNum, endTxNum); err != nil {
+		return nil, err
+	}
+	if err := stateDiffStorage(ttx, diff, startTxNum, endTxNum); err != nil {
+		return nil, err
+	}
+	if withCode {
+		if err := stateDiffCode(ttx, diff, startTxNum, endTxNum); err != nil {
+			return nil, err
+		}
+	}
+	return diff, nil
+}
+
+// stateDiffAccounts adds the changes of the accounts modified in [startTxNum, endTxNum) to diff.
+func stateDiffAccounts(ttx kv.TemporalTx, diff RangeStateDiff, startTxNum, endTxNum uint64) error {
+	it, err := ttx.HistoryRange(kv.AccountsHistory, int(startTxNum), int(endTxNum), order.Asc, kv.Unlim)
+	if err != nil {
+		return err
+	}
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return err
+		}
+		addr := common.BytesToAddress(k)
+		next, _, err := ttx.DomainGetAsOf(kv.AccountsDomain, k, nil, endTxNum)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(v, next) {
+			continue
+		}
+		from, to := accounts.NewAccount(), accounts.NewAccount()
+		if len(v) > 0 {
+			if err := accounts.DeserialiseV3(&from, v); err != nil {
+				return fmt.Errorf("account %x: %w", addr, err)
+			}
+		}
+		if len(next) > 0 {
+			if err := accounts.DeserialiseV3(&to, next); err != nil {
+				return fmt.Errorf("account %x: %w", addr, err)
+			}
+		}
+		account := &RangeAccountDiff{Created: len(v) == 0, Deleted: len(next) == 0}
+		if !from.Balance.Eq(&to.Balance) {
+			account.Balance = &FieldDiff[*hexutil.Big]{From: (*hexutil.Big)(from.Balance.ToBig()), To: (*hexutil.Big)(to.Balance.ToBig())}
+		}
+		if from.Nonce != to.Nonce {
+			account.Nonce = &FieldDiff[hexutil.Uint64]{From: hexutil.Uint64(from.Nonce), To: hexutil.Uint64(to.Nonce)}
+		}
+		if from.CodeHash != to.CodeHash {
+			account.CodeHash = &FieldDiff[common.Hash]{From: from.CodeHash, To: to.CodeHash}
+		}
+		if account.Created || account.Deleted || account.Balance != nil || account.Nonce != nil || account.CodeHash != nil {
+			diff[addr] = account
+		}
+	}
+	return nil
+}
+
+// stateDiffStorage adds the changes of the storage slots modified in [startTxNum, endTxNum) to diff.
+// History keys are the account address followed by the preimage of the slot.
+func stateDiffStorage(ttx kv.TemporalTx, diff RangeStateDiff, startTxNum, endTxNum uint64) error {
+	it, err := ttx.HistoryRange(kv.StorageHistory, int(startTxNum), int(endTxNum), order.Asc, kv.Unlim)
+	if err != nil {
+		return err
+	}
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return err
+		}
+		next, _, err := ttx.DomainGetAsOf(kv.StorageDomain, k, nil, endTxNum)
+		if err != nil {
+			return err
+		}
+		var from, to uint256.Int
+		from.SetBytes(v)
+		to.SetBytes(next)
+		if from.Eq(&to) {
+			continue
+		}
+		key := common.BytesToHash(k[length.Addr:])
+		seckey, err := common.HashData(key[:])
+		if err != nil {
+			return err
+		}
+		account := diff.account(common.BytesToAddress(k[:length.Addr]))
+		if account.Storage == nil {
+			account.Storage = map[common.Hash]*RangeStorageDiff{}
+		}
+		account.Storage[seckey] = &RangeStorageDiff{Key: key, From: from.Bytes32(), To: to.Bytes32()}
+	}
+	return nil
+}
+
+// stateDiffCode adds the bytecode of the accounts whose code changed in [startTxNum, endTxNum) to diff.
+func stateDiffCode(ttx kv.TemporalTx, diff RangeStateDiff, startTxNum, endTxNum uint64) error {
+	it, err := ttx.HistoryRange(kv.CodeHistory, int(startTxNum), int(endTxNum), order.Asc, kv.Unlim)
+	if err != nil {
+		return err
+	}
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return err
+		}
+		next, _, err := ttx.DomainGetAsOf(kv.CodeDomain, k, nil, endTxNum)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(v, next) {
+			continue
+		}
+		diff.account(common.BytesToAddress(k)).Code = &FieldDiff[hexutility.Bytes]{From: common.Copy(v), To: common.Copy(next)}
+	}
+	return nil
+}
@@ -354,27 +354,28 @@ func NewEthAPI(base *BaseAPI, db kv.RoDB, eth rpchelper.ApiBackend, txPool txpoo
 
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
 type RPCTransaction struct {
-	BlockHash           *common.Hash       `json:"blockHash"`
-	BlockNumber         *hexutil.Big       `json:"blockNumber"`
-	From                common.Address     `json:"from"`
-	Gas                 hexutil.Uint64     `json:"gas"`
-	GasPrice            *hexutil.Big       `json:"gasPrice,omitempty"`
-	Tip                 *hexutil.Big       `json:"maxPriorityFeePerGas,omitempty"`
-	FeeCap              *hexutil.Big       `json:"maxFeePerGas,omitempty"`
-	Hash                common.Hash        `json:"hash"`
-	Input               hexutility.Bytes   `json:"input"`
-	Nonce               hexutil.Uint64     `json:"nonce"`
-	To                  *common.Address    `json:"to"`
-	TransactionIndex    *hexutil.Uint64    `json:"transactionIndex"`
-	Value               *hexutil.Big       `json:"value"`
-	Type                hexutil.Uint64     `json:"type"`
-	Accesses            *types2.AccessList `json:"accessList,omitempty"`
-	ChainID             *hexutil.Big       `json:"chainId,omitempty"`
-	MaxFeePerBlobGas    *hexutil.Big       `json:"maxFeePerBlobGas,omitempty"`
-	BlobVersionedHashes []common.Hash      `json:"blobVersionedHashes,omitempty"`
-	V                   *hexutil.Big       `json:"v"`
-	R                   *hexutil.Big       `json:"r"`
-	S                   *hexutil.Big       `json:"s"`
+	BlockHash           *common.Hash          `json:"blockHash"`
+	BlockNumber         *hexutil.Big          `json:"blockNumber"`
+	From                common.Address        `json:"from"`
+	Gas                 hexutil.Uint64        `json:"gas"`
+	GasPrice            *hexutil.Big          `json:"gasPrice,omitempty"`
+	Tip                 *hexutil.Big          `json:"maxPriorityFeePerGas,omitempty"`
+	FeeCap              *hexutil.Big          `json:"maxFeePerGas,omitempty"`
+	Hash                common.Hash           `json:"hash"`
+	Input               hexutility.Bytes      `json:"input"`
+	Nonce               hexutil.Uint64        `json:"nonce"`
+	To                  *common.Address       `json:"to"`
+	TransactionIndex    *hexutil.Uint64       `json:"transactionIndex"`
+	Value               *hexutil.Big          `json:"value"`
+	Type                hexutil.Uint64        `json:"type"`
+	Accesses            *types2.AccessList    `json:"accessList,omitempty"`
+	ChainID             *hexutil.Big          `json:"chainId,omitempty"`
+	MaxFeePerBlobGas    *hexutil.Big          `json:"maxFeePerBlobGas,omitempty"`
+	BlobVersionedHashes []common.Hash         `json:"blobVersionedHashes,omitempty"`
+	Authorizations      []types.Authorization `json:"authorizationList,omitempty"`
+	V                   *hexutil.Big          `json:"v"`
+	R                   *hexutil.Big          `json:"r"`
+	S                   *hexutil.Big          `json:"s"`
 }
 
 // NewRPCTransaction returns a transaction that will serialize to the RPC
@@ -435,6 +436,17 @@ func NewRPCTransaction(tx types.Transaction, blockHash common.Hash, blockNumber
 		result.GasPrice = computeGasPrice(tx, blockHash, baseFee)
 		result.MaxFeePerBlobGas = (*hexutil.Big)(t.MaxFeePerBlobGas.ToBig())
 		result.BlobVersionedHashes = t.BlobVersionedHashes
+	case *types.SetCodeTransaction:
+		chainId.Set(t.ChainID)
+		result.ChainID = (*hexutil.Big)(chainId.ToBig())
+		result.Tip = (*hexutil.Big)(t.Tip.ToBig())
+		result.FeeCap = (*hexutil.Big)(t.FeeCap.ToBig())
+		result.V = (*hexutil.Big)(t.V.ToBig())
+		result.R = (*hexutil.Big)(t.R.ToBig())
+		result.S = (*hexutil.Big)(t.S.ToBig())
+		result.Accesses = &t.AccessList
+		result.GasPrice = computeGasPrice(tx, blockHash, baseFee)
+		result.Authorizations = t.Authorizations
 	}
 	signer := types.LatestSignerForChainID(chainId.ToBig())
 	result.From, _ = tx.Sender(*signer)
@@ -95,6 +95,45 @@ func TestGetBlockByNumberWithPendingTag(t *testing.T) {
 	assert.Equal(t, (*hexutil.Big)(big.NewInt(int64(expected))), b["number"])
 }
 
+func TestGetBlockByNumberWithRequestsRoot(t *testing.T) {
+	m := mock.MockWithTxPool(t)
+	agg := m.HistoryV3Components()
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	txPool := txpool.NewTxpoolClient(conn)
+	ff := rpchelper.New(ctx, nil, txPool, txpool.NewMiningClient(conn), func() {}, m.Log)
+
+	// A Prague header carries all the optional fields up to the requests root
+	blobGasUsed, excessBlobGas := uint64(0), uint64(0)
+	withdrawalsHash, parentBeaconBlockRoot := types.EmptyRootHash, common.HexToHash("0x01")
+	requestsRoot := common.HexToHash("0x02")
+	header := &types.Header{
+		Number:                big.NewInt(1),
+		BaseFee:               big.NewInt(7),
+		WithdrawalsHash:       &withdrawalsHash,
+		BlobGasUsed:           &blobGasUsed,
+		ExcessBlobGas:         &excessBlobGas,
+		ParentBeaconBlockRoot: &parentBeaconBlockRoot,
+		RequestsRoot:          &requestsRoot,
+	}
+
+	rlpBlock, err := rlp.EncodeToBytes(types.NewBlockWithHeader(header))
+	if err != nil {
+		t.Errorf("failed encoding the block: %s", err)
+	}
+	ff.HandlePendingBlock(&txpool.OnPendingBlockReply{
+		RplBlock: rlpBlock,
+	})
+
+	api := NewEthAPI(NewBaseApi(ff, stateCache, m.BlockReader, agg, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs), m.DB, nil, nil, nil, 5000000, 100_000, false, 100_000, log.New())
+	b, err := api.GetBlockByNumber(context.Background(), rpc.PendingBlockNumber, false)
+	if err != nil {
+		t.Errorf("error getting block number with pending tag: %s", err)
+	}
+	assert.Equal(t, &requestsRoot, b["requestsRoot"])
+}
+
 func TestGetBlockByNumber_WithFinalizedTag_NoFinalizedBlockInDb(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	ctx := context.Background()
@@ -82,6 +82,10 @@ func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rp
 		config = &tracers.TraceConfig{}
 	}
 
+	if config.StateDiff != nil {
+		return api.traceBlockStateDiff(tx, block.NumberU64(), config.StateDiff, stream)
+	}
+
 	if config.BorTraceEnabled == nil {
 		config.BorTraceEnabled = newBoolPtr(false)
 	}
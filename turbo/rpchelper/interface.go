@@ -28,5 +28,12 @@ type ApiBackend interface {
 	NodeInfo(ctx context.Context, limit uint32) ([]p2p.NodeInfo, error)
 	Peers(ctx context.Context) ([]*p2p.PeerInfo, error)
 	AddPeer(ctx context.Context, url *remote.AddPeerRequest) (*remote.AddPeerReply, error)
+	RemovePeer(ctx context.Context, url *remote.RemovePeerRequest) (*remote.RemovePeerReply, error)
+	AddTrustedPeer(ctx context.Context, url *remote.AddPeerRequest) (*remote.AddPeerReply, error)
+	RemoveTrustedPeer(ctx context.Context, url *remote.RemovePeerRequest) (*remote.RemovePeerReply, error)
+	BanPeer(ctx context.Context, req *remote.BanPeerRequest) (*remote.BanPeerReply, error)
+	UnbanPeer(ctx context.Context, req *remote.UnbanPeerRequest) (*remote.UnbanPeerReply, error)
+	BannedPeers(ctx context.Context) (*remote.BannedPeersReply, error)
+	PeerEvents(ctx context.Context, cb func(*remote.PeerEvent)) error
 	PendingBlock(ctx context.Context) (*types.Block, error)
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	proto_downloader "github.com/ledgerwatch/erigon-lib/gointerfaces/downloader"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/execution"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
 	ptypes "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"github.com/ledgerwatch/erigon-lib/kv"
@@ -96,7 +97,11 @@ type MockSentry struct {
 	PeerId               *ptypes.H512
 	UpdateHead           func(Ctx context.Context, headHeight, headTime uint64, hash libcommon.Hash, td *uint256.Int)
 	streams              map[proto_sentry.MessageId][]proto_sentry.Sentry_MessagesServer
+	sentLock             sync.Mutex
 	sentMessages         []*proto_sentry.OutboundMessageData
+	statusLock           sync.Mutex
+	status               *proto_sentry.StatusData
+	network              *Network // nil unless the mock is attached to a simulated network
 	StreamWg             sync.WaitGroup
 	ReceiveWg            sync.WaitGroup
 	Address              libcommon.Address
@@ -110,6 +115,8 @@ type MockSentry struct {
 	TxPoolGrpcServer *txpool.GrpcServer
 	TxPool           *txpool.TxPool
 	txPoolDB         kv.RwDB
+	txPoolNewTxs     chan types2.Announcements
+	stopTxPoolLoop   func() // stops the main loop of the txpool, the simulated network drives the pool instead
 
 	HistoryV3      bool
 	agg            *libstate.AggregatorV3
@@ -137,6 +144,18 @@ func (ms *MockSentry) Close() {
 	}
 }
 
+// txPoolNotifier adds the state changes to the wait group, the txpool marks them
+// done once processed
+type txPoolNotifier struct {
+	shards.StateChangeConsumer
+	wg *sync.WaitGroup
+}
+
+func (n *txPoolNotifier) SendStateChanges(ctx context.Context, sc *remote.StateChangeBatch) {
+	n.wg.Add(1)
+	n.StateChangeConsumer.SendStateChanges(ctx, sc)
+}
+
 // Stream returns stream, waiting if necessary
 func (ms *MockSentry) Send(req *proto_sentry.InboundMessage) (errs []error) {
 	ms.StreamWg.Wait()
@@ -148,11 +167,65 @@ func (ms *MockSentry) Send(req *proto_sentry.InboundMessage) (errs []error) {
 	return errs
 }
 
-func (ms *MockSentry) SetStatus(context.Context, *proto_sentry.StatusData) (*proto_sentry.SetStatusReply, error) {
+// deliver hands the message of a simulated peer to all the streams subscribed to it
+// and waits until they are done with it
+func (ms *MockSentry) deliver(req *proto_sentry.InboundMessage) {
+	ms.StreamWg.Wait()
+	n := len(ms.streams[req.Id])
+	if n == 0 {
+		return
+	}
+	ms.ReceiveWg.Add(n)
+	for _, err := range ms.Send(req) {
+		ms.Log.Debug("[simnet] Could not deliver message", "id", req.Id, "err", err)
+		ms.ReceiveWg.Done()
+	}
+	ms.ReceiveWg.Wait()
+}
+
+// txPoolRound does on the spot what the main loop of the txpool does on its timers: the
+// transactions received from the peers enter the pool and the new ones are propagated
+func (ms *MockSentry) txPoolRound() error {
+	if ms.TxPool == nil || !ms.TxPool.Started() {
+		return nil
+	}
+	if err := ms.TxPool.ProcessRemoteTxs(ms.Ctx); err != nil {
+		return err
+	}
+	var announcements types2.Announcements
+	for drained := false; !drained; {
+		select {
+		case a, ok := <-ms.txPoolNewTxs:
+			announcements.AppendOther(a)
+			drained = !ok
+		default:
+			drained = true
+		}
+	}
+	txpool.PropagateNewTxs(ms.Ctx, ms.txPoolDB, ms.TxPool, announcements, ms.TxPoolSend, ms.TxPoolGrpcServer.NewSlotsStreams, func() {})
+	return nil
+}
+
+func (ms *MockSentry) SetStatus(_ context.Context, r *proto_sentry.StatusData) (*proto_sentry.SetStatusReply, error) {
+	ms.statusLock.Lock()
+	defer ms.statusLock.Unlock()
+	ms.status = r
 	return &proto_sentry.SetStatusReply{}, nil
 }
 
-func (ms *MockSentry) PenalizePeer(context.Context, *proto_sentry.PenalizePeerRequest) (*emptypb.Empty, error) {
+func (ms *MockSentry) headHeight() uint64 {
+	ms.statusLock.Lock()
+	defer ms.statusLock.Unlock()
+	if ms.status == nil {
+		return 0
+	}
+	return ms.status.MaxBlockHeight
+}
+
+func (ms *MockSentry) PenalizePeer(_ context.Context, r *proto_sentry.PenalizePeerRequest) (*emptypb.Empty, error) {
+	if ms.network != nil {
+		ms.network.penalize(ms, r.PeerId)
+	}
 	return nil, nil
 }
 func (ms *MockSentry) PeerMinBlock(context.Context, *proto_sentry.PeerMinBlockRequest) (*emptypb.Empty, error) {
@@ -162,23 +235,42 @@ func (ms *MockSentry) PeerMinBlock(context.Context, *proto_sentry.PeerMinBlockRe
 func (ms *MockSentry) HandShake(ctx context.Context, in *emptypb.Empty) (*proto_sentry.HandShakeReply, error) {
 	return &proto_sentry.HandShakeReply{Protocol: proto_sentry.Protocol_ETH68}, nil
 }
+func (ms *MockSentry) recordSent(data *proto_sentry.OutboundMessageData) {
+	ms.sentLock.Lock()
+	defer ms.sentLock.Unlock()
+	ms.sentMessages = append(ms.sentMessages, data)
+}
 func (ms *MockSentry) SendMessageByMinBlock(_ context.Context, r *proto_sentry.SendMessageByMinBlockRequest) (*proto_sentry.SentPeers, error) {
-	ms.sentMessages = append(ms.sentMessages, r.Data)
+	ms.recordSent(r.Data)
+	if ms.network != nil {
+		return ms.network.sendByMinBlock(ms, r), nil
+	}
 	return nil, nil
 }
 func (ms *MockSentry) SendMessageById(_ context.Context, r *proto_sentry.SendMessageByIdRequest) (*proto_sentry.SentPeers, error) {
-	ms.sentMessages = append(ms.sentMessages, r.Data)
+	ms.recordSent(r.Data)
+	if ms.network != nil {
+		return ms.network.sendById(ms, r), nil
+	}
 	return nil, nil
 }
 func (ms *MockSentry) SendMessageToRandomPeers(_ context.Context, r *proto_sentry.SendMessageToRandomPeersRequest) (*proto_sentry.SentPeers, error) {
-	ms.sentMessages = append(ms.sentMessages, r.Data)
+	ms.recordSent(r.Data)
+	if ms.network != nil {
+		return ms.network.sendToRandomPeers(ms, r), nil
+	}
 	return nil, nil
 }
 func (ms *MockSentry) SendMessageToAll(_ context.Context, r *proto_sentry.OutboundMessageData) (*proto_sentry.SentPeers, error) {
-	ms.sentMessages = append(ms.sentMessages, r)
+	ms.recordSent(r)
+	if ms.network != nil {
+		return ms.network.sendToAll(ms, r), nil
+	}
 	return nil, nil
 }
 func (ms *MockSentry) SentMessage(i int) *proto_sentry.OutboundMessageData {
+	ms.sentLock.Lock()
+	defer ms.sentLock.Unlock()
 	return ms.sentMessages[i]
 }
 
@@ -200,9 +292,15 @@ func (ms *MockSentry) Messages(req *proto_sentry.MessagesRequest, stream proto_s
 }
 
 func (ms *MockSentry) Peers(context.Context, *emptypb.Empty) (*proto_sentry.PeersReply, error) {
+	if ms.network != nil {
+		return &proto_sentry.PeersReply{Peers: ms.network.peerInfos(ms)}, nil
+	}
 	return &proto_sentry.PeersReply{}, nil
 }
 func (ms *MockSentry) PeerCount(context.Context, *proto_sentry.PeerCountRequest) (*proto_sentry.PeerCountReply, error) {
+	if ms.network != nil {
+		return &proto_sentry.PeerCountReply{Count: uint64(len(ms.network.peerInfos(ms)))}, nil
+	}
 	return &proto_sentry.PeerCountReply{Count: 0}, nil
 }
 func (ms *MockSentry) PeerById(context.Context, *proto_sentry.PeerByIdRequest) (*proto_sentry.PeerByIdReply, error) {
@@ -293,16 +391,37 @@ func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateK
 
 	mock.Address = crypto.PubkeyToAddress(mock.Key.PublicKey)
 
-	sendHeaderRequest := func(_ context.Context, r *headerdownload.HeaderRequest) ([64]byte, bool) { return [64]byte{}, false }
-	propagateNewBlockHashes := func(context.Context, []headerdownload.Announce) {}
-	penalize := func(context.Context, []headerdownload.PenaltyItem) {}
+	// Requests only leave the mock when it is attached to a simulated network
+	sendHeaderRequest := func(ctx context.Context, r *headerdownload.HeaderRequest) ([64]byte, bool) {
+		if mock.network == nil {
+			return [64]byte{}, false
+		}
+		return mock.sentriesClient.SendHeaderRequest(ctx, r)
+	}
+	propagateNewBlockHashes := func(ctx context.Context, announces []headerdownload.Announce) {
+		if mock.network != nil {
+			mock.sentriesClient.PropagateNewBlockHashes(ctx, announces)
+		}
+	}
+	penalize := func(ctx context.Context, penalties []headerdownload.PenaltyItem) {
+		if mock.network != nil {
+			mock.sentriesClient.Penalize(ctx, penalties)
+		}
+	}
 
 	mock.SentryClient = direct.NewSentryClientDirect(direct.ETH68, mock)
 	sentries := []direct.SentryClient{mock.SentryClient}
 
-	sendBodyRequest := func(context.Context, *bodydownload.BodyRequest) ([64]byte, bool) { return [64]byte{}, false }
+	sendBodyRequest := func(ctx context.Context, r *bodydownload.BodyRequest) ([64]byte, bool) {
+		if mock.network == nil {
+			return [64]byte{}, false
+		}
+		return mock.sentriesClient.SendBodyRequest(ctx, r)
+	}
 	blockPropagator := func(Ctx context.Context, header *types.Header, body *types.RawBody, td *big.Int) {}
 	if !cfg.DeprecatedTxPool.Disable {
+		// Count the state changes sent to the txpool, so that they can be waited for
+		mock.Notifications.StateChangesConsumer = &txPoolNotifier{StateChangeConsumer: erigonGrpcServeer, wg: &mock.ReceiveWg}
 		poolCfg := txpoolcfg.DefaultConfig
 		newTxs := make(chan types2.Announcements, 1024)
 		if tb != nil {
@@ -313,8 +432,9 @@ func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateK
 		chainID, _ := uint256.FromBig(mock.ChainConfig.ChainID)
 		shanghaiTime := mock.ChainConfig.ShanghaiTime
 		cancunTime := mock.ChainConfig.CancunTime
+		pragueTime := mock.ChainConfig.PragueTime
 		maxBlobsPerBlock := mock.ChainConfig.GetMaxBlobsPerBlock()
-		mock.TxPool, err = txpool.New(newTxs, mock.DB, poolCfg, kvcache.NewDummy(histV3), *chainID, shanghaiTime, nil /* agraBlock */, cancunTime, maxBlobsPerBlock, logger)
+		mock.TxPool, err = txpool.New(newTxs, mock.DB, poolCfg, kvcache.NewDummy(histV3), *chainID, shanghaiTime, nil /* agraBlock */, cancunTime, pragueTime, maxBlobsPerBlock, logger)
 		if err != nil {
 			tb.Fatal(err)
 		}
@@ -332,7 +452,17 @@ func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateK
 		mock.TxPoolFetch.ConnectSentries()
 		mock.StreamWg.Wait()
 
-		go txpool.MainLoop(mock.Ctx, mock.txPoolDB, mock.DB, mock.TxPool, newTxs, mock.TxPoolSend, mock.TxPoolGrpcServer.NewSlotsStreams, func() {})
+		mock.txPoolNewTxs = newTxs
+		loopCtx, stopLoop := context.WithCancel(mock.Ctx)
+		loopDone := make(chan struct{})
+		mock.stopTxPoolLoop = func() {
+			stopLoop()
+			<-loopDone
+		}
+		go func() {
+			defer close(loopDone)
+			txpool.MainLoop(loopCtx, mock.txPoolDB, mock.DB, mock.TxPool, newTxs, mock.TxPoolSend, mock.TxPoolGrpcServer.NewSlotsStreams, func() {})
+		}()
 	}
 
 	// Committed genesis will be shared between download and mock sentry
@@ -486,7 +616,7 @@ func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateK
 
 	cfg.Genesis = gspec
 	pipelineStages := stages2.NewPipelineStages(mock.Ctx, db, &cfg, p2p.Config{}, mock.sentriesClient, mock.Notifications,
-		snapshotsDownloader, mock.BlockReader, blockRetire, mock.agg, nil, forkValidator, logger, checkStateRoot)
+		snapshotsDownloader, mock.BlockReader, blockRetire, mock.agg, nil, nil, forkValidator, logger, checkStateRoot)
 	mock.posStagedSync = stagedsync.New(cfg.Sync, pipelineStages, stagedsync.PipelineUnwindOrder, stagedsync.PipelinePruneOrder, logger)
 
 	mock.Eth1ExecutionService = eth1.NewEthereumExecutionModule(mock.BlockReader, mock.DB, mock.posStagedSync, forkValidator, mock.ChainConfig, assembleBlockPOS, nil, mock.Notifications.Accumulator, mock.Notifications.StateChangesConsumer, logger, engine, histV3, cfg.Sync)
@@ -679,9 +809,6 @@ func (ms *MockSentry) insertPoWBlocks(chain *core.ChainPack) error {
 	}
 	ms.ReceiveWg.Wait() // Wait for all messages to be processed before we proceed
 
-	if ms.TxPool != nil {
-		ms.ReceiveWg.Add(1)
-	}
 	initialCycle := MockInsertAsInitialCycle
 	hook := stages2.NewHook(ms.Ctx, ms.DB, ms.Notifications, ms.Sync, ms.BlockReader, ms.ChainConfig, ms.Log, ms.UpdateHead)
 
@@ -0,0 +1,388 @@
+package mock
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	ptypes "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+)
+
+// LinkConfig describes a simulated connection between two nodes
+type LinkConfig struct {
+	Latency time.Duration // base delivery delay
+	Jitter  time.Duration // random extra delay, from 0 to Jitter
+	Loss    float64       // probability of a message being dropped, from 0 to 1
+}
+
+type link struct {
+	a, b int // a < b
+}
+
+func newLink(a, b int) link {
+	if a > b {
+		a, b = b, a
+	}
+	return link{a: a, b: b}
+}
+
+// inFlight is a message travelling between two nodes
+type inFlight struct {
+	at   time.Duration // virtual delivery time
+	seq  uint64        // breaks the ties of the delivery time in the sending order
+	from int
+	to   int
+	msg  *proto_sentry.OutboundMessageData
+}
+
+type inFlightQueue []*inFlight
+
+func (q inFlightQueue) Len() int { return len(q) }
+func (q inFlightQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q inFlightQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *inFlightQueue) Push(x interface{}) { *q = append(*q, x.(*inFlight)) }
+func (q *inFlightQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return x
+}
+
+// Network connects MockSentry nodes through simulated links instead of the p2p stack.
+// The messages sent by a node are queued with a virtual delivery time derived from
+// the link latency and are only delivered by Flush, one at a time in the order of the
+// delivery time, waiting for the receiving node to process each message before the next.
+// All the random choices (jitter, loss, peer selection) come from a single seeded source,
+// so the same scenario produces the same message schedule on every run.
+type Network struct {
+	tb    testing.TB
+	lock  sync.Mutex
+	rand  *rand.Rand
+	nodes []*MockSentry
+	links map[link]LinkConfig
+	// group of each node while the network is partitioned, nil otherwise
+	groups map[int]int
+	queue  inFlightQueue
+	now    time.Duration
+	seq    uint64
+
+	delivered uint64
+	dropped   uint64
+	penalties map[link]int
+}
+
+func NewNetwork(tb testing.TB, seed int64) *Network {
+	return &Network{
+		tb:        tb,
+		rand:      rand.New(rand.NewSource(seed)), // nolint: gosec
+		links:     map[link]LinkConfig{},
+		penalties: map[link]int{},
+	}
+}
+
+// nodeID is the peer id of the i-th node of the network
+func nodeID(i int) [64]byte {
+	var id [64]byte
+	copy(id[:], "simnet")
+	binary.BigEndian.PutUint64(id[56:], uint64(i+1))
+	return id
+}
+
+// AddNode attaches the mock to the network, from now on it sends and receives the
+// messages through the network. Returns the index of the node.
+func (n *Network) AddNode(ms *MockSentry) int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if ms.network != nil {
+		n.tb.Fatalf("mock sentry is already attached to a network")
+	}
+	ms.network = n
+	ms.UpdateHead = ms.sentriesClient.UpdateHead
+	// The mock does not run the initial cycle, which would make the header download
+	// request sparse skeletons and ignore the announcements
+	ms.sentriesClient.Hd.AfterInitialCycle()
+	// The txpool is driven by the rounds of the network instead of its own timers
+	if ms.stopTxPoolLoop != nil {
+		ms.stopTxPoolLoop()
+	}
+	n.nodes = append(n.nodes, ms)
+	return len(n.nodes) - 1
+}
+
+// Node returns the i-th node of the network
+func (n *Network) Node(i int) *MockSentry {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.nodes[i]
+}
+
+func (n *Network) Len() int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return len(n.nodes)
+}
+
+// NodeID returns the peer id under which the i-th node is seen by the others
+func (n *Network) NodeID(i int) [64]byte { return nodeID(i) }
+
+func (n *Network) Connect(a, b int, cfg LinkConfig) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if a == b {
+		n.tb.Fatalf("cannot connect node %d to itself", a)
+	}
+	n.links[newLink(a, b)] = cfg
+}
+
+// ConnectAll connects every pair of nodes with the same link configuration
+func (n *Network) ConnectAll(cfg LinkConfig) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	for a := range n.nodes {
+		for b := a + 1; b < len(n.nodes); b++ {
+			n.links[newLink(a, b)] = cfg
+		}
+	}
+}
+
+func (n *Network) Disconnect(a, b int) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	delete(n.links, newLink(a, b))
+}
+
+// Partition splits the network into the given groups of nodes, the links between
+// the groups stop carrying messages until Heal. Nodes not listed in any group form
+// a group of their own.
+func (n *Network) Partition(groups ...[]int) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.groups = map[int]int{}
+	for g, nodes := range groups {
+		for _, i := range nodes {
+			n.groups[i] = g
+		}
+	}
+}
+
+// Heal removes the partition
+func (n *Network) Heal() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.groups = nil
+}
+
+// Stats returns the number of delivered and dropped messages
+func (n *Network) Stats() (delivered, dropped uint64) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.delivered, n.dropped
+}
+
+// Penalties returns how many times the nodes penalised each other
+func (n *Network) Penalties(a, b int) int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.penalties[newLink(a, b)]
+}
+
+// Pending returns the number of messages in flight
+func (n *Network) Pending() int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.queue.Len()
+}
+
+// Flush delivers the messages in flight, including the ones sent in response, until
+// the network is quiet. Returns the number of delivered messages.
+func (n *Network) Flush() int {
+	var delivered int
+	for {
+		msg, to := n.next()
+		if msg == nil {
+			return delivered
+		}
+		if to == nil {
+			continue
+		}
+		to.deliver(msg)
+		delivered++
+	}
+}
+
+// next pops the earliest message in flight, advancing the virtual clock. The returned
+// node is nil if the message was lost on the way.
+func (n *Network) next() (*proto_sentry.InboundMessage, *MockSentry) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.queue.Len() == 0 {
+		return nil, nil
+	}
+	m := heap.Pop(&n.queue).(*inFlight)
+	if m.at > n.now {
+		n.now = m.at
+	}
+	// The link may have been cut while the message was in flight
+	if !n.reachable(m.from, m.to) {
+		n.dropped++
+		return &proto_sentry.InboundMessage{}, nil
+	}
+	n.delivered++
+	return &proto_sentry.InboundMessage{
+		Id:     m.msg.Id,
+		Data:   m.msg.Data,
+		PeerId: gointerfaces.ConvertHashToH512(nodeID(m.from)),
+	}, n.nodes[m.to]
+}
+
+func (n *Network) reachable(a, b int) bool {
+	if _, ok := n.links[newLink(a, b)]; !ok {
+		return false
+	}
+	return n.groups == nil || n.groups[a] == n.groups[b]
+}
+
+func (n *Network) index(ms *MockSentry) int {
+	for i, node := range n.nodes {
+		if node == ms {
+			return i
+		}
+	}
+	panic("mock sentry is not attached to the network")
+}
+
+func (n *Network) indexOf(peerID *ptypes.H512) (int, bool) {
+	id := gointerfaces.ConvertH512ToHash(peerID)
+	for i := range n.nodes {
+		if nodeID(i) == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// peers returns the nodes reachable from the given one, in the order of their indices
+func (n *Network) peers(from int) []int {
+	var peers []int
+	for i := range n.nodes {
+		if i != from && n.reachable(from, i) {
+			peers = append(peers, i)
+		}
+	}
+	return peers
+}
+
+// send queues the message to the given nodes, each of them is subject to the loss of its link
+func (n *Network) send(from int, to []int, msg *proto_sentry.OutboundMessageData) *proto_sentry.SentPeers {
+	reply := &proto_sentry.SentPeers{}
+	for _, i := range to {
+		reply.Peers = append(reply.Peers, gointerfaces.ConvertHashToH512(nodeID(i)))
+		cfg := n.links[newLink(from, i)]
+		if cfg.Loss > 0 && n.rand.Float64() < cfg.Loss {
+			n.dropped++
+			continue
+		}
+		delay := cfg.Latency
+		if cfg.Jitter > 0 {
+			delay += time.Duration(n.rand.Int63n(int64(cfg.Jitter) + 1))
+		}
+		n.seq++
+		heap.Push(&n.queue, &inFlight{at: n.now + delay, seq: n.seq, from: from, to: i, msg: msg})
+	}
+	return reply
+}
+
+// pick returns up to max randomly chosen peers, all of them if max is 0
+func (n *Network) pick(peers []int, max uint64) []int {
+	n.rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+	if max > 0 && uint64(len(peers)) > max {
+		peers = peers[:max]
+	}
+	sort.Ints(peers)
+	return peers
+}
+
+func (n *Network) sendById(ms *MockSentry, r *proto_sentry.SendMessageByIdRequest) *proto_sentry.SentPeers {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	from := n.index(ms)
+	to, ok := n.indexOf(r.PeerId)
+	if !ok || !n.reachable(from, to) {
+		return &proto_sentry.SentPeers{}
+	}
+	return n.send(from, []int{to}, r.Data)
+}
+
+func (n *Network) sendByMinBlock(ms *MockSentry, r *proto_sentry.SendMessageByMinBlockRequest) *proto_sentry.SentPeers {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	from := n.index(ms)
+	var peers []int
+	for _, i := range n.peers(from) {
+		if n.nodes[i].headHeight() >= r.MinBlock {
+			peers = append(peers, i)
+		}
+	}
+	return n.send(from, n.pick(peers, r.MaxPeers), r.Data)
+}
+
+func (n *Network) sendToRandomPeers(ms *MockSentry, r *proto_sentry.SendMessageToRandomPeersRequest) *proto_sentry.SentPeers {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	from := n.index(ms)
+	return n.send(from, n.pick(n.peers(from), r.MaxPeers), r.Data)
+}
+
+func (n *Network) sendToAll(ms *MockSentry, r *proto_sentry.OutboundMessageData) *proto_sentry.SentPeers {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	from := n.index(ms)
+	return n.send(from, n.peers(from), r)
+}
+
+// penalize disconnects the penalised peer, like the sentry kicks it
+func (n *Network) penalize(ms *MockSentry, peerID *ptypes.H512) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	from := n.index(ms)
+	to, ok := n.indexOf(peerID)
+	if !ok {
+		return
+	}
+	l := newLink(from, to)
+	if _, connected := n.links[l]; !connected {
+		return
+	}
+	n.penalties[l]++
+	delete(n.links, l)
+	ms.Log.Debug("[simnet] Peer penalised", "node", from, "peer", to)
+}
+
+func (n *Network) peerInfos(ms *MockSentry) []*ptypes.PeerInfo {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	var infos []*ptypes.PeerInfo
+	for _, i := range n.peers(n.index(ms)) {
+		id := nodeID(i)
+		infos = append(infos, &ptypes.PeerInfo{
+			Id:   fmt.Sprintf("%x", id[:]),
+			Name: fmt.Sprintf("simnet/node%d", i),
+			Caps: []string{"eth/68"},
+		})
+	}
+	return infos
+}
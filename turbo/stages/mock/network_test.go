@@ -0,0 +1,159 @@
+package mock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/stages/mock"
+)
+
+// transfers fills each block with a transaction, so that the bodies need to be downloaded
+func transfers(t *testing.T, m *mock.MockSentry) func(int, *core.BlockGen) {
+	signer := types.LatestSignerForChainID(m.ChainConfig.ChainID)
+	return func(i int, b *core.BlockGen) {
+		txn, err := types.SignTx(types.NewTransaction(b.TxNonce(m.Address), libcommon.Address{1}, uint256.NewInt(1000), params.TxGas, uint256.NewInt(10*params.GWei), nil), *signer, m.Key)
+		require.NoError(t, err)
+		b.AddTx(txn)
+	}
+}
+
+func TestNetworkPropagation(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	net := mock.MockNetwork(t, 1, 3, false)
+	link := mock.LinkConfig{Latency: 50 * time.Millisecond, Jitter: 20 * time.Millisecond}
+
+	mock.NewScenario().
+		// A line 0 - 1 - 2, the blocks of node 0 reach node 2 through node 1
+		Connect(0, 1, link).
+		Connect(1, 2, link).
+		MineWith(0, 5, transfers(t, net.Node(0))).
+		Sync().
+		ExpectHead(2, 5).
+		ExpectSameHead(0, 1, 2).
+		Run(t, net)
+
+	delivered, dropped := net.Stats()
+	require.NotZero(t, delivered)
+	require.Zero(t, dropped)
+}
+
+func TestNetworkPartitionReorg(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	net := mock.MockNetwork(t, 2, 3, false)
+
+	mock.NewScenario().
+		ConnectAll(mock.LinkConfig{Latency: 10 * time.Millisecond, Jitter: 100 * time.Millisecond}).
+		Mine(0, 3).
+		Sync().
+		ExpectSameHead(0, 1, 2).
+		// Node 0 is cut off and mines a shorter fork than the rest of the network
+		Partition([]int{0}, []int{1, 2}).
+		Mine(0, 2).
+		Mine(1, 4).
+		Sync().
+		ExpectHead(0, 5).
+		ExpectHead(2, 7).
+		ExpectSameHead(1, 2).
+		// Once healed, node 0 unwinds its fork and follows the heavier chain
+		Heal().
+		Sync().
+		ExpectHead(0, 7).
+		ExpectSameHead(0, 1, 2).
+		Run(t, net)
+}
+
+func TestNetworkUnwind(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	net := mock.MockNetwork(t, 3, 2, false)
+
+	mock.NewScenario().
+		ConnectAll(mock.LinkConfig{Latency: 20 * time.Millisecond}).
+		MineWith(0, 6, transfers(t, net.Node(0))).
+		Sync().
+		ExpectHead(1, 6).
+		Unwind(1, 2).
+		ExpectHead(1, 2).
+		// Node 1 downloads the unwound blocks from node 0 again
+		MineWith(0, 1, transfers(t, net.Node(0))).
+		Sync().
+		ExpectHead(1, 7).
+		ExpectSameHead(0, 1).
+		Run(t, net)
+}
+
+func TestNetworkLossySync(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	net := mock.MockNetwork(t, 7, 2, false)
+
+	mock.NewScenario().
+		// The requests lost on the way are sent again by the following rounds
+		ConnectAll(mock.LinkConfig{Latency: 20 * time.Millisecond, Loss: 0.4}).
+		Mine(0, 200).
+		Sync().
+		ExpectHead(1, 200).
+		Run(t, net)
+
+	_, dropped := net.Stats()
+	require.NotZero(t, dropped)
+}
+
+func TestNetworkTxGossip(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	if ethconfig.EnableHistoryV4InTest {
+		t.Skip("TODO: [e4] implement me")
+	}
+	net := mock.MockNetwork(t, 4, 2, true)
+	m := net.Node(0)
+	txn, err := types.SignTx(types.NewTransaction(0, libcommon.Address{1}, uint256.NewInt(1234), params.TxGas, uint256.NewInt(10*params.GWei), nil), *types.LatestSignerForChainID(m.ChainConfig.ChainID), m.Key)
+	require.NoError(t, err)
+
+	mock.NewScenario().
+		ConnectAll(mock.LinkConfig{Latency: 20 * time.Millisecond}).
+		// Both pools need a block to start
+		Mine(0, 1).
+		Sync().
+		ExpectSameHead(0, 1).
+		AddTx(0, txn).
+		ExpectPooled(1, txn.Hash()).
+		Run(t, net)
+}
+
+func TestNetworkDeterministic(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	lossy := mock.LinkConfig{Latency: 10 * time.Millisecond, Jitter: 50 * time.Millisecond, Loss: 0.2}
+	run := func() (uint64, uint64) {
+		net := mock.MockNetwork(t, 5, 2, false)
+		net.ConnectAll(lossy)
+		for i := 0; i < 3; i++ {
+			_, err := net.Mine(0, 1, transfers(t, net.Node(0)))
+			require.NoError(t, err)
+			require.NoError(t, net.SyncRound())
+		}
+		return net.Stats()
+	}
+	delivered1, dropped1 := run()
+	delivered2, dropped2 := run()
+	require.NotZero(t, dropped1)
+	require.Equal(t, delivered1, delivered2)
+	require.Equal(t, dropped1, dropped2)
+}
@@ -0,0 +1,376 @@
+package mock
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	txpool_proto "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	stages2 "github.com/ledgerwatch/erigon/turbo/stages"
+	"github.com/ledgerwatch/erigon/turbo/stages/headerdownload"
+)
+
+const (
+	// syncPatience is how many rounds in a row Sync keeps going without any node
+	// changing its head. Every round the stages request again what is still missing,
+	// so the requests lost on the way are retried before Sync gives up.
+	syncPatience = 16
+	// maxSyncRounds bounds the whole Sync
+	maxSyncRounds = 1000
+	// poolRounds bounds the wait for a transaction to reach a pool, it moves by one
+	// hop every round or two depending on the order of the nodes
+	poolRounds = 32
+)
+
+// MockNetwork creates a network of nodes sharing the genesis of Mock, the nodes are
+// not connected to each other
+func MockNetwork(t *testing.T, seed int64, nodes int, withTxPool bool) *Network {
+	net := NewNetwork(t, seed)
+	for i := 0; i < nodes; i++ {
+		if withTxPool {
+			net.AddNode(MockWithTxPool(t))
+		} else {
+			net.AddNode(Mock(t))
+		}
+	}
+	return net
+}
+
+// Head returns the number and the hash of the last block fully processed by the node
+func (n *Network) Head(node int) (number uint64, hash libcommon.Hash, err error) {
+	ms := n.Node(node)
+	err = ms.DB.View(ms.Ctx, func(tx kv.Tx) error {
+		if number, err = stages.GetStageProgress(tx, stages.Finish); err != nil {
+			return err
+		}
+		hash, err = rawdb.ReadCanonicalHash(tx, number)
+		return err
+	})
+	return number, hash, err
+}
+
+// Mine makes the node extend its own chain with the given number of blocks and
+// announce the new head to its peers. The blocks of each node have their own
+// coinbase, so the nodes mining on the same parent produce competing forks.
+// The optional gen fills the blocks, e.g. with transactions.
+func (n *Network) Mine(node int, blocks int, gen func(int, *core.BlockGen)) (*core.ChainPack, error) {
+	ms := n.Node(node)
+	number, hash, err := n.Head(node)
+	if err != nil {
+		return nil, err
+	}
+	var parent *types.Block
+	if err = ms.DB.View(ms.Ctx, func(tx kv.Tx) error {
+		parent = rawdb.ReadBlock(tx, hash, number)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("head block %d %x of node %d not found", number, hash, node)
+	}
+	chain, err := core.GenerateChain(ms.ChainConfig, parent, ms.Engine, ms.DB, blocks, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(libcommon.Address{byte(node + 1)})
+		if gen != nil {
+			gen(i, b)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = ms.InsertChain(chain); err != nil {
+		return nil, err
+	}
+	ms.sentriesClient.PropagateNewBlockHashes(ms.Ctx, []headerdownload.Announce{
+		{Hash: chain.TopBlock.Hash(), Number: chain.TopBlock.NumberU64()},
+	})
+	return chain, nil
+}
+
+// Unwind rolls the node back to the given block, as if the blocks above it were bad
+func (n *Network) Unwind(node int, to uint64) error {
+	ms := n.Node(node)
+	return ms.DB.Update(ms.Ctx, func(tx kv.RwTx) error {
+		if err := ms.Sync.UnwindTo(to, stagedsync.StagedUnwind, tx); err != nil {
+			return err
+		}
+		return ms.Sync.RunUnwind(nil, tx)
+	})
+}
+
+// SyncRound runs one iteration of the stage loop on each node, in the order of the
+// nodes, and delivers all the messages it triggered
+func (n *Network) SyncRound() error {
+	for i := 0; i < n.Len(); i++ {
+		ms := n.Node(i)
+		hook := stages2.NewHook(ms.Ctx, ms.DB, ms.Notifications, ms.Sync, ms.BlockReader, ms.ChainConfig, ms.Log, ms.UpdateHead)
+		// The stages waiting for the responses stop the iteration, they are delivered below
+		if err := stages2.StageLoopIteration(ms.Ctx, ms.DB, nil, ms.Sync, MockInsertAsInitialCycle, ms.Log, ms.BlockReader, hook); err != nil && !errors.Is(err, libcommon.ErrStopped) {
+			return fmt.Errorf("node %d: %w", i, err)
+		}
+		ms.ReceiveWg.Wait() // Wait for TxPool notification
+		n.Flush()
+	}
+	return nil
+}
+
+// Sync runs rounds until the connected nodes agree on their heads. Nodes which can
+// not agree, e.g. on competing forks of the same difficulty, stop the Sync once none
+// of the heads changed for syncPatience rounds.
+func (n *Network) Sync() error {
+	var idle int
+	heads := make([]libcommon.Hash, n.Len())
+	for round := 0; round < maxSyncRounds; round++ {
+		if err := n.SyncRound(); err != nil {
+			return err
+		}
+		changed := false
+		for i := range heads {
+			_, hash, err := n.Head(i)
+			if err != nil {
+				return err
+			}
+			if hash != heads[i] {
+				heads[i], changed = hash, true
+			}
+		}
+		switch {
+		case changed:
+			idle = 0
+		case n.agree(heads):
+			return nil
+		case idle >= syncPatience:
+			return nil
+		default:
+			idle++
+		}
+	}
+	return fmt.Errorf("sync did not finish in %d rounds", maxSyncRounds)
+}
+
+// agree reports whether all the nodes reachable from each other have the same head
+func (n *Network) agree(heads []libcommon.Hash) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	for a := range heads {
+		for _, b := range n.peers(a) {
+			if heads[a] != heads[b] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// AddTx submits the transaction to the pool of the node
+func (n *Network) AddTx(node int, txn types.Transaction) error {
+	ms := n.Node(node)
+	if ms.TxPoolGrpcServer == nil {
+		return fmt.Errorf("node %d has no txpool", node)
+	}
+	var buf bytes.Buffer
+	if err := txn.MarshalBinary(&buf); err != nil {
+		return err
+	}
+	reply, err := ms.TxPoolGrpcServer.Add(ms.Ctx, &txpool_proto.AddRequest{RlpTxs: [][]byte{buf.Bytes()}})
+	if err != nil {
+		return err
+	}
+	if reply.Imported[0] != txpool_proto.ImportResult_SUCCESS {
+		return fmt.Errorf("transaction %x not imported by node %d: %s", txn.Hash(), node, reply.Errors[0])
+	}
+	return nil
+}
+
+// Pooled reports whether the transaction is in the pool of the node
+func (n *Network) Pooled(node int, hash libcommon.Hash) (bool, error) {
+	ms := n.Node(node)
+	if ms.TxPool == nil {
+		return false, fmt.Errorf("node %d has no txpool", node)
+	}
+	var rlpTx []byte
+	err := ms.txPoolDB.View(ms.Ctx, func(tx kv.Tx) (err error) {
+		rlpTx, err = ms.TxPool.GetRlp(tx, hash[:])
+		return err
+	})
+	return rlpTx != nil, err
+}
+
+// PoolRound runs the work of the txpool of each node, in the order of the nodes, and
+// delivers all the messages it triggered
+func (n *Network) PoolRound() error {
+	for i := 0; i < n.Len(); i++ {
+		if err := n.Node(i).txPoolRound(); err != nil {
+			return fmt.Errorf("node %d: %w", i, err)
+		}
+		n.Flush()
+	}
+	return nil
+}
+
+// WaitPooled runs pool rounds until the transaction reaches the pool of the node
+func (n *Network) WaitPooled(node int, hash libcommon.Hash) error {
+	for round := 0; round < poolRounds; round++ {
+		if err := n.PoolRound(); err != nil {
+			return err
+		}
+		pooled, err := n.Pooled(node, hash)
+		if err != nil || pooled {
+			return err
+		}
+	}
+	return fmt.Errorf("transaction %x did not reach node %d in %d rounds", hash, node, poolRounds)
+}
+
+type scenarioStep struct {
+	name string
+	run  func(net *Network) error
+}
+
+// Scenario is a script run against a Network, e.g.
+//
+//	NewScenario().
+//		ConnectAll(LinkConfig{Latency: 50 * time.Millisecond}).
+//		Mine(0, 5).Sync().ExpectHead(2, 5).
+//		Partition([]int{0}, []int{1, 2}).Mine(0, 2).Mine(1, 4).Sync().
+//		Heal().Sync().ExpectSameHead(0, 1, 2).
+//		Run(t, net)
+//
+// The steps run in order, the first failing step fails the test.
+type Scenario struct {
+	steps []scenarioStep
+}
+
+func NewScenario() *Scenario {
+	return &Scenario{}
+}
+
+func (s *Scenario) step(name string, run func(net *Network) error) *Scenario {
+	s.steps = append(s.steps, scenarioStep{name: name, run: run})
+	return s
+}
+
+func (s *Scenario) Connect(a, b int, cfg LinkConfig) *Scenario {
+	return s.step(fmt.Sprintf("connect %d-%d", a, b), func(net *Network) error {
+		net.Connect(a, b, cfg)
+		return nil
+	})
+}
+
+func (s *Scenario) ConnectAll(cfg LinkConfig) *Scenario {
+	return s.step("connect all", func(net *Network) error {
+		net.ConnectAll(cfg)
+		return nil
+	})
+}
+
+func (s *Scenario) Disconnect(a, b int) *Scenario {
+	return s.step(fmt.Sprintf("disconnect %d-%d", a, b), func(net *Network) error {
+		net.Disconnect(a, b)
+		return nil
+	})
+}
+
+func (s *Scenario) Partition(groups ...[]int) *Scenario {
+	return s.step(fmt.Sprintf("partition %v", groups), func(net *Network) error {
+		net.Partition(groups...)
+		return nil
+	})
+}
+
+func (s *Scenario) Heal() *Scenario {
+	return s.step("heal", func(net *Network) error {
+		net.Heal()
+		return nil
+	})
+}
+
+func (s *Scenario) Mine(node, blocks int) *Scenario {
+	return s.MineWith(node, blocks, nil)
+}
+
+func (s *Scenario) MineWith(node, blocks int, gen func(int, *core.BlockGen)) *Scenario {
+	return s.step(fmt.Sprintf("mine %d blocks on node %d", blocks, node), func(net *Network) error {
+		_, err := net.Mine(node, blocks, gen)
+		return err
+	})
+}
+
+func (s *Scenario) Unwind(node int, to uint64) *Scenario {
+	return s.step(fmt.Sprintf("unwind node %d to %d", node, to), func(net *Network) error {
+		return net.Unwind(node, to)
+	})
+}
+
+func (s *Scenario) Sync() *Scenario {
+	return s.step("sync", func(net *Network) error {
+		return net.Sync()
+	})
+}
+
+func (s *Scenario) AddTx(node int, txn types.Transaction) *Scenario {
+	return s.step(fmt.Sprintf("add transaction %x to node %d", txn.Hash(), node), func(net *Network) error {
+		return net.AddTx(node, txn)
+	})
+}
+
+// ExpectPooled waits for the transaction to reach the pool of the node
+func (s *Scenario) ExpectPooled(node int, hash libcommon.Hash) *Scenario {
+	return s.step(fmt.Sprintf("expect transaction %x in node %d", hash, node), func(net *Network) error {
+		return net.WaitPooled(node, hash)
+	})
+}
+
+func (s *Scenario) ExpectHead(node int, number uint64) *Scenario {
+	return s.step(fmt.Sprintf("expect node %d at block %d", node, number), func(net *Network) error {
+		head, _, err := net.Head(node)
+		if err != nil {
+			return err
+		}
+		if head != number {
+			return fmt.Errorf("node %d is at block %d", node, head)
+		}
+		return nil
+	})
+}
+
+func (s *Scenario) ExpectSameHead(nodes ...int) *Scenario {
+	return s.step(fmt.Sprintf("expect same head on nodes %v", nodes), func(net *Network) error {
+		var expected libcommon.Hash
+		for i, node := range nodes {
+			number, hash, err := net.Head(node)
+			if err != nil {
+				return err
+			}
+			if i == 0 {
+				expected = hash
+			} else if hash != expected {
+				return fmt.Errorf("node %d is at block %d %x, node %d at %x", node, number, hash, nodes[0], expected)
+			}
+		}
+		return nil
+	})
+}
+
+// Do runs an arbitrary step, e.g. to check the state of the nodes
+func (s *Scenario) Do(name string, run func(net *Network) error) *Scenario {
+	return s.step(name, run)
+}
+
+func (s *Scenario) Run(tb testing.TB, net *Network) {
+	tb.Helper()
+	for i, step := range s.steps {
+		if err := step.run(net); err != nil {
+			tb.Fatalf("step %d (%s): %v", i, step.name, err)
+		}
+	}
+}
@@ -476,6 +476,7 @@ func NewDefaultStages(ctx context.Context,
 	blockRetire services.BlockRetire,
 	agg *state.AggregatorV3,
 	silkworm *silkworm.Silkworm,
+	liveTracer vm.LiveLogger,
 	forkValidator *engine_helpers.ForkValidator,
 	heimdallClient heimdall.IHeimdallClient,
 	recents *lru.ARCCache[libcommon.Hash, *bor.Snapshot],
@@ -526,7 +527,7 @@ func NewDefaultStages(ctx context.Context,
 			nil,
 			controlServer.ChainConfig,
 			controlServer.Engine,
-			&vm.Config{},
+			&vm.Config{LiveTracer: liveTracer},
 			notifications.Accumulator,
 			cfg.StateStream,
 			/*stateStream=*/ false,
@@ -560,6 +561,7 @@ func NewPipelineStages(ctx context.Context,
 	blockRetire services.BlockRetire,
 	agg *state.AggregatorV3,
 	silkworm *silkworm.Silkworm,
+	liveTracer vm.LiveLogger,
 	forkValidator *engine_helpers.ForkValidator,
 	logger log.Logger,
 	checkStateRoot bool,
@@ -600,7 +602,7 @@ func NewPipelineStages(ctx context.Context,
 				nil,
 				controlServer.ChainConfig,
 				controlServer.Engine,
-				&vm.Config{},
+				&vm.Config{LiveTracer: liveTracer},
 				notifications.Accumulator,
 				cfg.StateStream,
 				/*stateStream=*/ false,
@@ -636,7 +638,7 @@ func NewPipelineStages(ctx context.Context,
 			nil,
 			controlServer.ChainConfig,
 			controlServer.Engine,
-			&vm.Config{},
+			&vm.Config{LiveTracer: liveTracer},
 			notifications.Accumulator,
 			cfg.StateStream,
 			/*stateStream=*/ false,
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
@@ -157,9 +158,14 @@ func TraceTx(
 		if config != nil && config.TracerConfig != nil {
 			cfg = *config.TracerConfig
 		}
-		if tracer, err = tracers.New(*config.Tracer, &tracers.Context{
-			TxHash: txCtx.TxHash,
-		}, cfg); err != nil {
+		tracerCtx := &tracers.Context{
+			BlockNumber: new(big.Int).SetUint64(blockCtx.BlockNumber),
+			TxHash:      txCtx.TxHash,
+		}
+		if statedb, ok := ibs.(*state.IntraBlockState); ok {
+			tracerCtx.BlockHash, tracerCtx.TxIndex = statedb.BlockHash(), statedb.TxIndex()
+		}
+		if tracer, err = tracers.New(*config.Tracer, tracerCtx, cfg); err != nil {
 			stream.WriteNil()
 			return err
 		}
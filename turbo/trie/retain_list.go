@@ -56,6 +56,9 @@ type ProofRetainer struct {
 	storageKeys    []libcommon.Hash
 	storageHexKeys [][]byte
 	proofs         []*proofElement
+
+	byHexKey         map[string]*proofElement
+	byStorageRootKey map[string]*proofElement
 }
 
 // NewProofRetainer creates a new ProofRetainer instance for a given account and
@@ -94,6 +97,18 @@ func NewProofRetainer(addr libcommon.Address, a *accounts.Account, storageKeys [
 	}, nil
 }
 
+// NewMultiProofRetainer creates a new ProofRetainer which retains every node on
+// the paths to the given nibble encoded keys. The keys may be account keys,
+// storage keys (prefixed by the account hash and incarnation) or the partial
+// paths of trie nodes, and are added to the given RetainList. After the Load
+// operation the nodes are available via ProofNodes, Node and StorageRoot.
+func NewMultiProofRetainer(hexKeys [][]byte, rl *RetainList) *ProofRetainer {
+	for _, hexKey := range hexKeys {
+		rl.AddHex(hexKey)
+	}
+	return &ProofRetainer{rl: rl}
+}
+
 // ProofElement requests a new proof element for a given prefix.  This proof
 // element is retained by the ProofRetainer, and will be utilized to compute the
 // proof after the trie computation has completed.  The prefix is the standard
@@ -103,6 +118,16 @@ func (pr *ProofRetainer) ProofElement(prefix []byte) *proofElement {
 		return nil
 	}
 
+	pe := &proofElement{
+		hexKey: append([]byte{}, prefix...),
+	}
+	if pr.accHexKey == nil {
+		// A multiproof retains every node on the paths to its keys, and its
+		// elements are looked up by their keys rather than by their order
+		pr.proofs = append(pr.proofs, pe)
+		return pe
+	}
+
 	switch {
 	case bytes.HasPrefix(pr.accHexKey, prefix):
 		// This prefix is a node between the account and the root
@@ -113,9 +138,6 @@ func (pr *ProofRetainer) ProofElement(prefix []byte) *proofElement {
 		return nil
 	}
 
-	pe := &proofElement{
-		hexKey: append([]byte{}, prefix...),
-	}
 	// Since we do a depth-first traversal, reverse the proof elements so that
 	// they are ordered correctly root -> node -> ... -> leaf as dictated by
 	// EIP-1186
@@ -192,6 +214,55 @@ func (pr *ProofRetainer) ProofResult() (*accounts.AccProofResult, error) {
 	return result, nil
 }
 
+func (pr *ProofRetainer) ensureIndexed() {
+	if pr.byHexKey != nil {
+		return
+	}
+	pr.byHexKey = make(map[string]*proofElement, len(pr.proofs))
+	pr.byStorageRootKey = make(map[string]*proofElement)
+	for _, pe := range pr.proofs {
+		pr.byHexKey[string(pe.hexKey)] = pe
+		if pe.storageRootKey != nil {
+			pr.byStorageRootKey[string(pe.storageRootKey)] = pe
+		}
+	}
+}
+
+// ProofNodes returns the RLP encoded nodes on the path to the given nibble
+// encoded key, ordered root -> node -> ... -> leaf. Nodes whose paths are
+// shorter than from nibbles are skipped, which allows to return only the
+// storage trie part of the path to a storage key.
+func (pr *ProofRetainer) ProofNodes(hexKey []byte, from int) [][]byte {
+	pr.ensureIndexed()
+	var nodes [][]byte
+	for i := from; i <= len(hexKey); i++ {
+		if pe, ok := pr.byHexKey[string(hexKey[:i])]; ok {
+			nodes = append(nodes, pe.proof.Bytes())
+		}
+	}
+	return nodes
+}
+
+// Node returns the RLP encoding of the node at the given nibble encoded path,
+// or nil if there is no such node in the trie.
+func (pr *ProofRetainer) Node(hexPath []byte) []byte {
+	pr.ensureIndexed()
+	if pe, ok := pr.byHexKey[string(hexPath)]; ok {
+		return pe.proof.Bytes()
+	}
+	return nil
+}
+
+// StorageRoot returns the storage root of the account with the given nibble
+// encoded key, if the account leaf was retained.
+func (pr *ProofRetainer) StorageRoot(accHexKey []byte) (libcommon.Hash, bool) {
+	pr.ensureIndexed()
+	if pe, ok := pr.byStorageRootKey[string(accHexKey)]; ok {
+		return pe.storageRoot, true
+	}
+	return libcommon.Hash{}, false
+}
+
 // proofElement represent a node or leaf in the trie and its
 // corresponding RLP encoding.  We store the elements individually when
 // aggregating as multiple keys (in particular storage keys) may need to